@@ -29,6 +29,10 @@ type PGInfo struct {
 	PortAndDatadir     *models.PortAndDatadir
 	SlaveHosts96       []*models.SlaveHosts96
 	SlaveHosts10       []*models.SlaveHosts10
+	Checkpointer16     *models.Checkpointer16 // pg_stat_bgwriter, PostgreSQL < 17
+	Checkpointer17     *models.Checkpointer17 // pg_stat_checkpointer, PostgreSQL 17+
+	WalStats           *models.WalStats       // PostgreSQL 14+
+	IOStats            []*models.IOStats      // PostgreSQL 16+
 	Tablespaces        []*models.Tablespaces
 	Settings           []*models.Setting
 	Counters           map[models.Name][]*models.Counters    // Counters per database
@@ -136,6 +140,9 @@ func (i *PGInfo) CollectGlobalInfo(db models.XODB) []error {
 	var err error
 
 	version10, _ := version.NewVersion("10.0.0")
+	version14, _ := version.NewVersion("14.0.0")
+	version16, _ := version.NewVersion("16.0.0")
+	version17, _ := version.NewVersion("17.0.0")
 
 	ch := make(chan interface{}, 2)
 	i.logger.Info("Collecting global counters (1st pass)")
@@ -205,6 +212,33 @@ func (i *PGInfo) CollectGlobalInfo(db models.XODB) []error {
 		}
 	}
 
+	// The checkpoint counters moved from pg_stat_bgwriter to pg_stat_checkpointer in PostgreSQL 17
+	if i.ServerVersion.LessThan(version17) {
+		i.logger.Info("Collecting Checkpointer statistics (PostgreSQL < 17)")
+		if i.Checkpointer16, err = models.GetCheckpointer16(db); err != nil {
+			errs = append(errs, errors.Wrap(err, "Cannot get checkpointer statistics on Postgre < 17"))
+		}
+	} else {
+		i.logger.Info("Collecting Checkpointer statistics (PostgreSQL 17+)")
+		if i.Checkpointer17, err = models.GetCheckpointer17(db); err != nil {
+			errs = append(errs, errors.Wrap(err, "Cannot get checkpointer statistics in Postgre 17+"))
+		}
+	}
+
+	if i.ServerVersion.GreaterThanOrEqual(version14) {
+		i.logger.Info("Collecting WAL statistics (PostgreSQL 14+)")
+		if i.WalStats, err = models.GetWalStats(db); err != nil {
+			errs = append(errs, errors.Wrap(err, "Cannot get WAL statistics"))
+		}
+	}
+
+	if i.ServerVersion.GreaterThanOrEqual(version16) {
+		i.logger.Info("Collecting I/O statistics (PostgreSQL 16+)")
+		if i.IOStats, err = models.GetIOStats(db); err != nil {
+			errs = append(errs, errors.Wrap(err, "Cannot get I/O statistics"))
+		}
+	}
+
 	i.logger.Info("Waiting for counters information")
 	c2, err := waitForCounters(ch)
 	if err != nil {
@@ -26,6 +26,12 @@ func New(ctx context.Context, client *mongo.Client) *Explain {
 
 // Run runs mongo's explain for the selected database/query
 func (e *Explain) Run(db string, query []byte) ([]byte, error) {
+	return e.RunVerbosity(db, query, "")
+}
+
+// RunVerbosity is like Run with the verbosity of the explain, like "queryPlanner" to get the plan
+// without running the query. The server default is used when verbosity is empty.
+func (e *Explain) RunVerbosity(db string, query []byte, verbosity string) ([]byte, error) {
 	var err error
 	var eq proto.ExampleQuery
 
@@ -38,8 +44,13 @@ func (e *Explain) Run(db string, query []byte) ([]byte, error) {
 		db = eq.Db()
 	}
 
+	cmd := eq.ExplainCmd()
+	if verbosity != "" {
+		cmd = append(cmd, bson.E{Key: "verbosity", Value: verbosity})
+	}
+
 	var result proto.BsonD
-	res := e.client.Database(db).RunCommand(e.ctx, eq.ExplainCmd())
+	res := e.client.Database(db).RunCommand(e.ctx, cmd)
 	if res.Err() != nil {
 		return nil, res.Err()
 	}
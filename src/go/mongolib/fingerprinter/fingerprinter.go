@@ -49,7 +49,10 @@ func (f *Fingerprinter) Fingerprint(doc proto.SystemProfile) (Fingerprint, error
 	if err != nil {
 		return Fingerprint{}, err
 	}
-	retKeys := keys(realQuery, f.keyFilters)
+	retKeys := filterKeys(realQuery, f.keyFilters, 0)
+	// structure is what the operation does with its keys, in order: the stages of a pipeline, the operators
+	// of an update
+	structure := []string{}
 
 	// Proper way to detect if protocol used is "op_msg" or "op_command"
 	// would be to look at "doc.Protocol" field,
@@ -87,8 +90,15 @@ func (f *Fingerprinter) Fingerprint(doc proto.SystemProfile) (Fingerprint, error
 	}
 
 	switch doc.Op {
-	case "remove", "update":
+	case "remove":
 		op = doc.Op
+	case "update":
+		op = doc.Op
+		update := interface{}(doc.UpdateObj)
+		if len(doc.Command) > 0 {
+			update = doc.Command.Map()["u"]
+		}
+		structure = updateKeys(update, f.keyFilters)
 	case "insert":
 		op = doc.Op
 		retKeys = []string{}
@@ -144,7 +154,11 @@ func (f *Fingerprinter) Fingerprint(doc proto.SystemProfile) (Fingerprint, error
 		case "aggregate":
 			retKeys = []string{}
 			if v, ok := query.Map()["pipeline"]; ok {
-				retKeys = append(retKeys, keys(v, []string{})...)
+				structure = pipelineKeys(v, f.keyFilters, 0)
+			}
+		case "findAndModify", "findandmodify":
+			if v, ok := query.Map()["update"]; ok {
+				structure = updateKeys(v, f.keyFilters)
 			}
 		case "geoNear":
 			retKeys = []string{}
@@ -169,6 +183,9 @@ func (f *Fingerprinter) Fingerprint(doc proto.SystemProfile) (Fingerprint, error
 	sort.Strings(retKeys)
 	retKeys = deduplicate(retKeys)
 	keys := strings.Join(retKeys, ",")
+	if len(structure) > 0 {
+		keys = strings.TrimSpace(keys + " " + strings.Join(structure, ","))
+	}
 	op = strings.ToUpper(op)
 
 	parts := []string{}
@@ -107,3 +107,93 @@ func TestFingerprints(t *testing.T) {
 		})
 	}
 }
+
+func TestFingerprintNormalization(t *testing.T) {
+	tests := []struct {
+		name string
+		docs []proto.SystemProfile
+		want string
+	}{
+		{
+			name: "pipelines with different literals",
+			docs: []proto.SystemProfile{
+				{Ns: "db.orders", Op: "command", Command: bson.D{
+					{Key: "aggregate", Value: "orders"},
+					{Key: "pipeline", Value: bson.A{
+						bson.D{{Key: "$match", Value: bson.D{{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{"A"}}}}}}},
+						bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$cust"}, {Key: "total", Value: bson.D{{Key: "$sum", Value: "$qty"}}}}}},
+						bson.D{{Key: "$limit", Value: 10}},
+					}},
+				}},
+				{Ns: "db.orders", Op: "command", Command: bson.D{
+					{Key: "aggregate", Value: "orders"},
+					{Key: "pipeline", Value: bson.A{
+						bson.D{{Key: "$match", Value: bson.D{{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{"B", "C", "D"}}}}}}},
+						bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$cust"}, {Key: "total", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+						bson.D{{Key: "$limit", Value: 50}},
+					}},
+				}},
+			},
+			want: "AGGREGATE orders $match(status),$group(_id,total),$limit",
+		},
+		{
+			name: "embedded documents, array literals and array indexes",
+			docs: []proto.SystemProfile{
+				{Ns: "db.coll", Op: "query", Command: bson.D{
+					{Key: "find", Value: "coll"},
+					{Key: "filter", Value: bson.D{
+						{Key: "meta", Value: bson.D{{Key: "k1", Value: 1}}},
+						{Key: "items.0.qty", Value: bson.D{{Key: "$gt", Value: 1}}},
+						{Key: "tags", Value: bson.D{{Key: "$all", Value: bson.A{bson.D{{Key: "x", Value: 1}}}}}},
+					}},
+				}},
+				{Ns: "db.coll", Op: "query", Command: bson.D{
+					{Key: "find", Value: "coll"},
+					{Key: "filter", Value: bson.D{
+						{Key: "meta", Value: bson.D{{Key: "k2", Value: 2}, {Key: "k3", Value: 3}}},
+						{Key: "items.12.qty", Value: bson.D{{Key: "$gt", Value: 5}}},
+						{Key: "tags", Value: bson.D{{Key: "$all", Value: bson.A{}}}},
+					}},
+				}},
+			},
+			want: "FIND coll items.$.qty,meta,tags",
+		},
+		{
+			name: "update operators",
+			docs: []proto.SystemProfile{
+				{Ns: "db.coll", Op: "update", Command: bson.D{
+					{Key: "q", Value: bson.D{{Key: "_id", Value: 1}}},
+					{Key: "u", Value: bson.D{{Key: "$set", Value: bson.D{{Key: "a", Value: 1}}}, {Key: "$inc", Value: bson.D{{Key: "n", Value: 1}}}}},
+				}},
+				{Ns: "db.coll", Op: "update", Command: bson.D{
+					{Key: "q", Value: bson.D{{Key: "_id", Value: 2}}},
+					{Key: "u", Value: bson.D{{Key: "$inc", Value: bson.D{{Key: "n", Value: -1}}}, {Key: "$set", Value: bson.D{{Key: "a", Value: "x"}}}}},
+				}},
+			},
+			want: "UPDATE coll _id $inc(n),$set(a)",
+		},
+		{
+			name: "replacements",
+			docs: []proto.SystemProfile{
+				{Ns: "db.coll", Op: "update", Command: bson.D{
+					{Key: "q", Value: bson.D{{Key: "_id", Value: 1}}},
+					{Key: "u", Value: bson.D{{Key: "a", Value: 1}}},
+				}},
+				{Ns: "db.coll", Op: "update", Command: bson.D{
+					{Key: "q", Value: bson.D{{Key: "_id", Value: 2}}},
+					{Key: "u", Value: bson.D{{Key: "b", Value: 1}, {Key: "c", Value: 1}}},
+				}},
+			},
+			want: "UPDATE coll _id replacement",
+		},
+	}
+
+	fp := NewFingerprinter(DefaultKeyFilters())
+	for _, test := range tests {
+		for _, doc := range test.docs {
+			got, err := fp.Fingerprint(doc)
+			require.NoError(t, err, test.name)
+			assert.Equal(t, test.want, got.Fingerprint, test.name)
+		}
+	}
+}
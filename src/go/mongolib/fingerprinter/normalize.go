@@ -0,0 +1,238 @@
+package fingerprinter
+
+import (
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// filterKeys returns the fields of a query filter. The values compared to the fields are literals: the keys of
+// their embedded documents and the length of their arrays, like the values of $in, do not change the
+// fingerprint. Only the filters nested in $and, $or, $nor, $elemMatch and $not are walked.
+func filterKeys(filter interface{}, keyFilters []string, level int) []string {
+	ks := []string{}
+	if level > maxDepthLevel {
+		return ks
+	}
+
+	for _, e := range elements(filter) {
+		if shouldSkipKey(e.Key, keyFilters) {
+			continue
+		}
+
+		switch e.Key {
+		case "$and", "$or", "$nor":
+			if clauses, ok := e.Value.(primitive.A); ok {
+				for _, clause := range clauses {
+					ks = append(ks, filterKeys(clause, keyFilters, level+1)...)
+				}
+			}
+			continue
+		case "$query":
+			// legacy queries with modifiers: { $query: { ... }, $orderby: { ... } }
+			ks = append(ks, filterKeys(e.Value, keyFilters, level+1)...)
+			continue
+		case "$orderby":
+			ks = append(ks, fieldNames(e.Value, keyFilters)...)
+			continue
+		}
+		if strings.HasPrefix(e.Key, "$") {
+			// $expr, $text, $where... have no field names
+			continue
+		}
+
+		ks = append(ks, normalizePath(e.Key))
+		ks = append(ks, operatorKeys(e.Value, keyFilters, level+1)...)
+	}
+
+	return ks
+}
+
+// operatorKeys returns the fields of the filters nested in the operators of a field value.
+func operatorKeys(value interface{}, keyFilters []string, level int) []string {
+	ks := []string{}
+	for _, e := range elements(value) {
+		switch e.Key {
+		case "$elemMatch":
+			ks = append(ks, filterKeys(e.Value, keyFilters, level)...)
+		case "$not":
+			ks = append(ks, operatorKeys(e.Value, keyFilters, level)...)
+		}
+	}
+
+	return ks
+}
+
+// pipelineKeys returns a key per stage of an aggregation pipeline, in the order of the stages, with the
+// fields the stage filters, sorts or outputs, like $match(a,b), $group(_id,total) or $limit. The literal
+// values of the stages, like the $limit and the values compared by $match, are left out.
+func pipelineKeys(pipeline interface{}, keyFilters []string, level int) []string {
+	ks := []string{}
+	stages, ok := pipeline.(primitive.A)
+	if !ok || level > maxDepthLevel {
+		return ks
+	}
+
+	for _, stage := range stages {
+		for _, e := range elements(stage) {
+			var fields []string
+			switch e.Key {
+			case "$match":
+				fields = filterKeys(e.Value, keyFilters, level+1)
+			case "$sort", "$group", "$project", "$addFields", "$set", "$replaceRoot", "$replaceWith":
+				fields = fieldNames(e.Value, keyFilters)
+			case "$unset", "$count", "$sortByCount":
+				fields = stringValues(e.Value)
+			case "$unwind":
+				fields = stringValues(e.Value)
+				if path, ok := lookup(e.Value, "path").(string); ok {
+					fields = []string{path}
+				}
+			case "$lookup", "$graphLookup":
+				fields = stringValues(lookup(e.Value, "from"))
+			case "$unionWith":
+				fields = stringValues(e.Value)
+				if coll, ok := lookup(e.Value, "coll").(string); ok {
+					fields = []string{coll}
+				}
+			case "$out":
+				fields = stringValues(e.Value)
+			case "$merge":
+				fields = stringValues(e.Value)
+				if into, ok := lookup(e.Value, "into").(string); ok {
+					fields = []string{into}
+				}
+			case "$facet":
+				for _, facet := range elements(e.Value) {
+					fields = append(fields, facet.Key+"["+strings.Join(pipelineKeys(facet.Value, keyFilters, level+1), ",")+"]")
+				}
+			}
+			ks = append(ks, stageKey(e.Key, fields))
+		}
+	}
+
+	return ks
+}
+
+// updateKeys returns a key per operator of an update with the fields it updates, like $inc(a) or $set(b,c).
+// A replacement document is a single replacement key, whatever its fields, and an update pipeline has a key
+// per stage.
+func updateKeys(update interface{}, keyFilters []string) []string {
+	if _, ok := update.(primitive.A); ok {
+		return pipelineKeys(update, keyFilters, 0)
+	}
+
+	ks := []string{}
+	for _, e := range elements(update) {
+		if !strings.HasPrefix(e.Key, "$") {
+			return []string{"replacement"}
+		}
+		ks = append(ks, stageKey(e.Key, fieldNames(e.Value, keyFilters)))
+	}
+	sort.Strings(ks)
+
+	return ks
+}
+
+func stageKey(name string, fields []string) string {
+	if len(fields) == 0 {
+		return name
+	}
+
+	sort.Strings(fields)
+
+	return name + "(" + strings.Join(deduplicate(fields), ",") + ")"
+}
+
+// fieldNames returns the top level field names of a document, normalized.
+func fieldNames(doc interface{}, keyFilters []string) []string {
+	ks := []string{}
+	for _, e := range elements(doc) {
+		if shouldSkipKey(e.Key, keyFilters) || strings.HasPrefix(e.Key, "$") {
+			continue
+		}
+		ks = append(ks, normalizePath(e.Key))
+	}
+
+	return ks
+}
+
+// stringValues returns the field paths or collection names of a stage, a string or an array of strings.
+func stringValues(value interface{}) []string {
+	ks := []string{}
+	switch v := value.(type) {
+	case string:
+		ks = append(ks, normalizePath(strings.TrimPrefix(v, "$")))
+	case primitive.A:
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				ks = append(ks, normalizePath(strings.TrimPrefix(s, "$")))
+			}
+		}
+	}
+
+	return ks
+}
+
+// normalizePath replaces the array indexes of a dotted field path, items.3.qty, by the positional $ so that
+// the paths of every element collapse into one, items.$.qty.
+func normalizePath(path string) string {
+	if !strings.Contains(path, ".") {
+		return path
+	}
+
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		if i > 0 && isIndex(part) {
+			parts[i] = "$"
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// elements returns the elements of a document, sorted by key for the maps.
+func elements(doc interface{}) []primitive.E {
+	switch v := doc.(type) {
+	case primitive.D:
+		return v
+	case primitive.M:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		es := make([]primitive.E, 0, len(v))
+		for _, name := range names {
+			es = append(es, primitive.E{Key: name, Value: v[name]})
+		}
+		return es
+	}
+
+	return nil
+}
+
+func lookup(doc interface{}, key string) interface{} {
+	for _, e := range elements(doc) {
+		if e.Key == key {
+			return e.Value
+		}
+	}
+
+	return nil
+}
@@ -30,6 +30,15 @@ type Parsed struct {
 	ProcessManagement ProcessManagement `bson:"processManagement"`
 	Replication       Replication       `bson:"replication"`
 	Security          Security          `bson:"security"`
+	AuditLog          AuditLog          `bson:"auditLog"`
+}
+
+// AuditLog is only available in Percona Server for MongoDB and MongoDB Enterprise
+type AuditLog struct {
+	Destination string `bson:"destination"`
+	Format      string `bson:"format"`
+	Path        string `bson:"path"`
+	Filter      string `bson:"filter"`
 }
 
 // Security is a struct to hold security related configs
@@ -61,8 +70,10 @@ type Security struct {
 type Net struct {
 	HTTP                   HTTP   `bson:"http"`
 	SSL                    SSL    `bson:"ssl"`
+	TLS                    TLS    `bson:"tls"`
 	Port                   int64  `bson:"port"`
 	BindIP                 string `bson:"bindIp"`
+	BindIPAll              bool   `bson:"bindIpAll"`
 	MaxIncomingConnections int    `bson:"maxIncomingConnections"`
 	WireObjectCheck        bool   `bson:"wireObjectCheck"`
 	IPv6                   bool   `bson:"ipv6"`
@@ -97,6 +108,20 @@ type SSL struct {
 	FIPSMode                            bool   `bson:"FIPSMode"`
 }
 
+// TLS config options, replacing the SSL ones since 4.2. See https://www.mongodb.com/docs/manual/reference/configuration-options/#net.tls-options
+type TLS struct {
+	Mode                                string `bson:"mode"` // disabled, allowTLS, preferTLS, requireTLS
+	CertificateKeyFile                  string `bson:"certificateKeyFile"`
+	ClusterFile                         string `bson:"clusterFile"`
+	CAFile                              string `bson:"CAFile"`
+	CRLFile                             string `bson:"CRLFile"`
+	AllowConnectionsWithoutCertificates bool   `bson:"allowConnectionsWithoutCertificates"`
+	AllowInvalidCertificates            bool   `bson:"allowInvalidCertificates"`
+	AllowInvalidHostnames               bool   `bson:"allowInvalidHostnames"`
+	DisabledProtocols                   string `bson:"disabledProtocols"`
+	FIPSMode                            bool   `bson:"FIPSMode"`
+}
+
 type CommandLineOptions struct {
 	Argv     []string `bson:"argv"`
 	Ok       float64  `bson:"ok"`
@@ -57,17 +57,17 @@ type OplogColStats struct {
 	NumExtents        int
 	IndexDetails      bson.M
 	Nindexes          int
-	TotalIndexSize    int64
+	TotalIndexSize    int64 `bson:"totalIndexSize"`
 	Size              int64
 	PaddingFactorNote string
 	Capped            bool
-	MaxSize           int64
+	MaxSize           int64 `bson:"maxSize"`
 	IndexSizes        bson.M
 	GleStats          struct {
 		LastOpTime time.Time
 		ElectionId string
 	} `bson:"$gleStats"`
-	StorageSize    int64
+	StorageSize    int64 `bson:"storageSize"`
 	PaddingFactor  int64
 	AvgObjSize     int64
 	LastExtentSize int64
@@ -5,9 +5,11 @@ package proto
 //	var ps proto.ProfilerStatus
 //	err := db.Run(bson.M{"profile": -1}, &ps)
 type ProfilerStatus struct {
-	Was      int64 `bson:"was"`
-	SlowMs   int64 `bson:"slowms"`
-	GleStats struct {
+	Was        int64   `bson:"was"`
+	SlowMs     int64   `bson:"slowms"`
+	SampleRate float64 `bson:"sampleRate"` // since MongoDB 3.6
+	RateLimit  int64   `bson:"ratelimit"`  // Percona Server for MongoDB
+	GleStats   struct {
 		ElectionID string `bson:"electionId"`
 		LastOpTime int64  `bson:"lastOpTime"`
 	} `bson:"$gleStats"`
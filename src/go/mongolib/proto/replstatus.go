@@ -43,6 +43,7 @@ type Members struct {
 	PingMs               *float64            `bson:"pingMs,omitempty"`     // Represents the number of milliseconds (ms) that a round-trip packet takes to travel between the remote member and the local instance.
 	Set                  string              `bson:"-"`
 	StorageEngine        StorageEngine
+	Error                string `bson:"-"` // Why the member could not be read, when it was unreachable
 }
 
 // Struct for replSetGetStatus
@@ -57,15 +58,16 @@ type ReplicaSetStatus struct {
 }
 
 type Member struct {
-	Host         string  `bson:"host"`
-	Votes        int32   `bson:"votes"`
-	ID           int32   `bson:"_id"`
-	SlaveDelay   int64   `bson:"slaveDelay"`
-	Priority     float64 `bson:"priority"`
-	BuildIndexes bool    `bson:"buildIndexes"`
-	ArbiterOnly  bool    `bson:"arbiterOnly"`
-	Hidden       bool    `bson:"hidden"`
-	Tags         bson.M  `bson:"tags"`
+	Host               string  `bson:"host"`
+	Votes              int32   `bson:"votes"`
+	ID                 int32   `bson:"_id"`
+	SlaveDelay         int64   `bson:"slaveDelay"`
+	SecondaryDelaySecs int64   `bson:"secondaryDelaySecs"` // replaces slaveDelay from MongoDB 5.0
+	Priority           float64 `bson:"priority"`
+	BuildIndexes       bool    `bson:"buildIndexes"`
+	ArbiterOnly        bool    `bson:"arbiterOnly"`
+	Hidden             bool    `bson:"hidden"`
+	Tags               bson.M  `bson:"tags"`
 }
 
 type RSConfig struct {
@@ -11,6 +11,7 @@ import (
 // https://docs.mongodb.com/manual/reference/database-profiler/#system.profile.docsExamined
 type SystemProfile struct {
 	AllUsers        []interface{} `bson:"allUsers"`
+	AppName         string        `bson:"appName"`
 	Client          string        `bson:"client"`
 	CursorExhausted bool          `bson:"cursorExhausted"`
 	DocsExamined    int           `bson:"docsExamined"`
@@ -56,21 +57,25 @@ type SystemProfile struct {
 			AcquireCount struct {
 				R int `bson:"R"`
 			} `bson:"acquireCount"`
+			TimeAcquiringMicros map[string]int64 `bson:"timeAcquiringMicros"`
 		} `bson:"Collection"`
 		Database struct {
 			AcquireCount struct {
 				R int `bson:"r"`
 			} `bson:"acquireCount"`
+			TimeAcquiringMicros map[string]int64 `bson:"timeAcquiringMicros"`
 		} `bson:"Database"`
 		Global struct {
 			AcquireCount struct {
 				R int `bson:"r"`
 			} `bson:"acquireCount"`
+			TimeAcquiringMicros map[string]int64 `bson:"timeAcquiringMicros"`
 		} `bson:"Global"`
 		MMAPV1Journal struct {
 			AcquireCount struct {
 				R int `bson:"r"`
 			} `bson:"acquireCount"`
+			TimeAcquiringMicros map[string]int64 `bson:"timeAcquiringMicros"`
 		} `bson:"MMAPV1Journal"`
 	} `bson:"locks"`
 	Millis             int       `bson:"millis"`
@@ -78,6 +83,7 @@ type SystemProfile struct {
 	Ns                 string    `bson:"ns"`
 	NumYield           int       `bson:"numYield"`
 	Op                 string    `bson:"op"`
+	PlanSummary        string    `bson:"planSummary"`
 	Protocol           string    `bson:"protocol"`
 	Query              bson.D    `bson:"query"`
 	UpdateObj          bson.D    `bson:"updateobj"`
@@ -87,6 +93,86 @@ type SystemProfile struct {
 	Ts                 time.Time `bson:"ts"`
 	User               string    `bson:"user"`
 	WriteConflicts     int       `bson:"writeConflicts"`
+	ErrCode            int       `bson:"errCode"`
+	ErrName            string    `bson:"errName"`
+}
+
+// Classes of the operations, by the session fields of their commands.
+const (
+	// ClassTransaction is an operation of a multi-document transaction: its commands have autocommit: false.
+	ClassTransaction = "transaction"
+	// ClassRetryableWrite is a retryable write: its commands have a txnNumber but not autocommit.
+	ClassRetryableWrite = "retryable write"
+)
+
+// errWriteConflict is the code of the WriteConflict error, aborting the transaction of the operation.
+const errWriteConflict = 112
+
+// Class returns the class of the operation, ClassTransaction or ClassRetryableWrite, or an empty string for
+// the other operations. It is read from the command and the originating command of the operation, so the
+// update and delete statements, recorded without their command, are not classified.
+func (doc SystemProfile) Class() string {
+	txnNumber := false
+	for _, cmd := range []bson.D{doc.Command, doc.OriginatingCommand} {
+		for i, e := range cmd {
+			switch {
+			case e.Key == "autocommit":
+				return ClassTransaction
+			case i == 0 && (e.Key == "commitTransaction" || e.Key == "abortTransaction"):
+				return ClassTransaction
+			case e.Key == "txnNumber":
+				txnNumber = true
+			}
+		}
+	}
+	if txnNumber {
+		return ClassRetryableWrite
+	}
+
+	return ""
+}
+
+// Aborted returns true if the operation aborted its transaction: it is an abortTransaction command, or it
+// failed.
+func (doc SystemProfile) Aborted() bool {
+	if doc.Class() != ClassTransaction {
+		return false
+	}
+	if len(doc.Command) > 0 && doc.Command[0].Key == "abortTransaction" {
+		return true
+	}
+
+	return doc.Failed()
+}
+
+// Failed returns true if the operation ended in an error.
+func (doc SystemProfile) Failed() bool {
+	return doc.ErrCode != 0 || doc.ErrName != ""
+}
+
+// WriteConflict returns true if the operation failed with a write conflict, which, unlike the write
+// conflicts of the other operations, retried by the server and counted in WriteConflicts, aborts the
+// transaction of the operation.
+func (doc SystemProfile) WriteConflict() bool {
+	return doc.ErrCode == errWriteConflict || doc.ErrName == "WriteConflict"
+}
+
+// LockWaitMicros returns the time the operation waited to acquire its global, database and collection
+// locks, in all modes.
+func (doc SystemProfile) LockWaitMicros() int64 {
+	var total int64
+	for _, waits := range []map[string]int64{
+		doc.Locks.Global.TimeAcquiringMicros,
+		doc.Locks.Database.TimeAcquiringMicros,
+		doc.Locks.Collection.TimeAcquiringMicros,
+		doc.Locks.MMAPV1Journal.TimeAcquiringMicros,
+	} {
+		for _, micros := range waits {
+			total += micros
+		}
+	}
+
+	return total
 }
 
 func NewExampleQuery(doc SystemProfile) ExampleQuery {
@@ -42,3 +42,34 @@ func TestExplainCmd(t *testing.T) {
 		assert.Equal(t, want, eq.ExplainCmd())
 	}
 }
+
+func TestClass(t *testing.T) {
+	tests := []struct {
+		inDoc         string
+		class         string
+		aborted       bool
+		writeConflict bool
+	}{
+		{`{"op":"query","command":{"find":"orders","filter":{"a":1},"$db":"shop"}}`, "", false, false},
+		{`{"op":"insert","command":{"insert":"orders","ordered":true,"txnNumber":{"$numberLong":"4"},` +
+			`"$db":"shop"}}`, proto.ClassRetryableWrite, false, false},
+		{`{"op":"query","command":{"find":"orders","txnNumber":{"$numberLong":"5"},"autocommit":false,` +
+			`"startTransaction":true,"$db":"shop"}}`, proto.ClassTransaction, false, false},
+		{`{"op":"command","command":{"findAndModify":"orders","txnNumber":{"$numberLong":"5"},` +
+			`"autocommit":false,"$db":"shop"},"errCode":112,"errName":"WriteConflict"}`, proto.ClassTransaction, true, true},
+		{`{"op":"command","command":{"abortTransaction":1,"txnNumber":{"$numberLong":"5"},` +
+			`"autocommit":false,"$db":"admin"}}`, proto.ClassTransaction, true, false},
+		{`{"op":"getmore","command":{"getMore":{"$numberLong":"1"},"collection":"orders"},` +
+			`"originatingCommand":{"find":"orders","autocommit":false}}`, proto.ClassTransaction, false, false},
+	}
+
+	for _, tc := range tests {
+		var doc proto.SystemProfile
+		err := bson.UnmarshalExtJSON([]byte(tc.inDoc), false, &doc)
+		assert.NoError(t, err)
+
+		assert.Equal(t, tc.class, doc.Class(), tc.inDoc)
+		assert.Equal(t, tc.aborted, doc.Aborted(), tc.inDoc)
+		assert.Equal(t, tc.writeConflict, doc.WriteConflict(), tc.inDoc)
+	}
+}
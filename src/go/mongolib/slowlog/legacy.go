@@ -0,0 +1,413 @@
+package slowlog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+var (
+	// 2019-08-01T10:00:00.123+0000 I COMMAND  [conn12] command test.coll command: find { find: "coll", ... } ... 150ms
+	legacyLineRegexp     = regexp.MustCompile(`^(\S+)\s+[DIWEF]\d?\s+\S+\s+\[[^\]]*\]\s+(command|query|update|remove|insert|getmore)\s+(\S+)\s+(.*)\s(\d+)ms$`)
+	legacyMetricRegexp   = regexp.MustCompile(`\b(keysExamined|docsExamined|nscannedObjects|nreturned|numYields|reslen|writeConflicts|errCode):(\d+)\b`)
+	legacyProtocolRegexp = regexp.MustCompile(`\bprotocol:(\S+)`)
+	legacyPlanRegexp     = regexp.MustCompile(`\bplanSummary: (\w+)`)
+	legacyErrNameRegexp  = regexp.MustCompile(`\berrName:(\w+)`)
+	legacyAppNameRegexp  = regexp.MustCompile(`\bappName: "((?:[^"\\]|\\.)*)"`)
+
+	legacyTimeLayouts = []string{"2006-01-02T15:04:05.000-0700", "2006-01-02T15:04:05.000Z07:00"}
+)
+
+// parseLegacyLine parses the text log lines of the versions before MongoDB 4.4, where the commands are
+// written in the notation of the mongo shell.
+func parseLegacyLine(line string) (proto.SystemProfile, error) {
+	m := legacyLineRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return proto.SystemProfile{}, ErrNotSlowOp
+	}
+
+	doc := proto.SystemProfile{Ns: m[3]}
+
+	for _, layout := range legacyTimeLayouts {
+		if ts, err := time.Parse(layout, m[1]); err == nil {
+			doc.Ts = ts
+			break
+		}
+	}
+
+	// the command, or the query before MongoDB 3.6, is the first document after "command:" or "query:"
+	rest := m[4]
+	key := "command: "
+	start := strings.Index(rest, key)
+	if start < 0 {
+		key = "query: "
+		start = strings.Index(rest, key)
+	}
+	if start < 0 {
+		return proto.SystemProfile{}, errors.Errorf("no command in %q", line)
+	}
+
+	brace := strings.Index(rest[start:], "{")
+	if brace < 0 {
+		return proto.SystemProfile{}, errors.Errorf("no command in %q", line)
+	}
+
+	p := &shellParser{s: rest, pos: start + brace}
+	cmd, err := p.parseDocument()
+	if err != nil {
+		return proto.SystemProfile{}, errors.Wrapf(err, "cannot parse the command of %q", line)
+	}
+
+	if am := legacyAppNameRegexp.FindStringSubmatch(rest[:start]); am != nil {
+		doc.AppName = strings.ReplaceAll(am[1], `\"`, `"`)
+	}
+
+	op, ok := profilerOp(m[2], cmd)
+	if !ok {
+		return proto.SystemProfile{}, ErrNotSlowOp
+	}
+	doc.Op = op
+
+	if key == "command: " {
+		doc.Command = cmd
+	} else {
+		doc.Query = cmd
+	}
+
+	metrics := rest[p.pos:]
+	for _, mm := range legacyMetricRegexp.FindAllStringSubmatch(metrics, -1) {
+		value, _ := strconv.Atoi(mm[2])
+		switch mm[1] {
+		case "keysExamined":
+			doc.KeysExamined = value
+		case "docsExamined":
+			doc.DocsExamined = value
+		case "nscannedObjects":
+			doc.NscannedObjects = value
+		case "nreturned":
+			doc.Nreturned = value
+		case "numYields":
+			doc.NumYield = value
+		case "reslen":
+			doc.ResponseLength = value
+		case "writeConflicts":
+			doc.WriteConflicts = value
+		case "errCode":
+			doc.ErrCode = value
+		}
+	}
+
+	if pm := legacyProtocolRegexp.FindStringSubmatch(metrics); pm != nil {
+		doc.Protocol = pm[1]
+	}
+
+	if pm := legacyPlanRegexp.FindStringSubmatch(metrics); pm != nil {
+		doc.PlanSummary = pm[1]
+	}
+
+	if em := legacyErrNameRegexp.FindStringSubmatch(metrics); em != nil {
+		doc.ErrName = em[1]
+	}
+
+	doc.Millis, _ = strconv.Atoi(m[5])
+
+	return doc, nil
+}
+
+// shellParser parses the documents written in the notation of the mongo shell, like
+// { find: "coll", filter: { _id: ObjectId('5d42...'), n: { $gt: 1.0 } } }.
+type shellParser struct {
+	s   string
+	pos int
+}
+
+func (p *shellParser) skipSpaces() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *shellParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+
+	return p.s[p.pos]
+}
+
+func (p *shellParser) expect(c byte) error {
+	p.skipSpaces()
+	if p.peek() != c {
+		return errors.Errorf("expected %q at offset %d", c, p.pos)
+	}
+	p.pos++
+
+	return nil
+}
+
+func (p *shellParser) parseDocument() (bson.D, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+
+	doc := bson.D{}
+	for {
+		p.skipSpaces()
+		if p.peek() == '}' {
+			p.pos++
+			return doc, nil
+		}
+
+		if len(doc) > 0 {
+			if err := p.expect(','); err != nil {
+				return nil, err
+			}
+			p.skipSpaces()
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		doc = append(doc, bson.E{Key: key, Value: value})
+	}
+}
+
+func (p *shellParser) parseKey() (string, error) {
+	if c := p.peek(); c == '"' || c == '\'' {
+		return p.parseString()
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] != ':' && p.s[p.pos] != ' ' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", errors.Errorf("expected a key at offset %d", p.pos)
+	}
+
+	return p.s[start:p.pos], nil
+}
+
+func (p *shellParser) parseArray() (bson.A, error) {
+	if err := p.expect('['); err != nil {
+		return nil, err
+	}
+
+	arr := bson.A{}
+	for {
+		p.skipSpaces()
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+
+		if len(arr) > 0 {
+			if err := p.expect(','); err != nil {
+				return nil, err
+			}
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		arr = append(arr, value)
+	}
+}
+
+func (p *shellParser) parseString() (string, error) {
+	quote := p.peek()
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s):
+			sb.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return "", errors.New("unterminated string")
+}
+
+func (p *shellParser) parseValue() (interface{}, error) {
+	p.skipSpaces()
+
+	switch c := p.peek(); {
+	case c == '{':
+		return p.parseDocument()
+	case c == '[':
+		return p.parseArray()
+	case c == '"' || c == '\'':
+		return p.parseString()
+	case c == '/':
+		return p.parseRegex()
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c == 0:
+		return nil, errors.New("unexpected end of the document")
+	}
+
+	return p.parseIdentifier()
+}
+
+func (p *shellParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	token := p.s[start:p.pos]
+
+	if i, err := strconv.ParseInt(token, 10, 64); err == nil {
+		if i == int64(int32(i)) {
+			return int32(i), nil
+		}
+		return i, nil
+	}
+
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, errors.Errorf("invalid number %q", token)
+	}
+
+	return f, nil
+}
+
+func (p *shellParser) parseRegex() (interface{}, error) {
+	p.pos++ // opening slash
+
+	var sb strings.Builder
+	for p.pos < len(p.s) && p.s[p.pos] != '/' {
+		if p.s[p.pos] == '\\' && p.pos+1 < len(p.s) {
+			sb.WriteByte(p.s[p.pos])
+			p.pos++
+		}
+		sb.WriteByte(p.s[p.pos])
+		p.pos++
+	}
+	if p.pos >= len(p.s) {
+		return nil, errors.New("unterminated regular expression")
+	}
+	p.pos++ // closing slash
+
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z' {
+		p.pos++
+	}
+
+	return primitive.Regex{Pattern: sb.String(), Options: p.s[start:p.pos]}, nil
+}
+
+// parseIdentifier parses the constants, like true and MinKey, and the constructors, like ObjectId('...')
+// and new Date(1564653600000). The constructors without a bson equivalent are kept as strings.
+func (p *shellParser) parseIdentifier() (interface{}, error) {
+	ident := p.parseWord()
+	if ident == "new" {
+		p.skipSpaces()
+		ident = p.parseWord()
+	}
+
+	switch ident {
+	case "":
+		return nil, errors.Errorf("unexpected %q at offset %d", p.peek(), p.pos)
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null", "undefined":
+		return nil, nil
+	case "MinKey":
+		return primitive.MinKey{}, nil
+	case "MaxKey":
+		return primitive.MaxKey{}, nil
+	}
+
+	if p.peek() != '(' {
+		return ident, nil
+	}
+
+	// the arguments are read up to the matching parenthesis
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		p.pos++
+		if depth == 0 {
+			break
+		}
+	}
+	if depth != 0 {
+		return nil, errors.Errorf("unterminated %s(", ident)
+	}
+
+	args := strings.Trim(p.s[start+1:p.pos-1], ` '"`)
+	switch ident {
+	case "ObjectId":
+		if oid, err := primitive.ObjectIDFromHex(args); err == nil {
+			return oid, nil
+		}
+	case "Date":
+		if ms, err := strconv.ParseInt(args, 10, 64); err == nil {
+			return primitive.DateTime(ms), nil
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, args); err == nil {
+			return primitive.NewDateTimeFromTime(ts), nil
+		}
+	case "Timestamp":
+		parts := strings.Split(args, ",")
+		if len(parts) == 2 {
+			t, errT := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+			i, errI := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+			if errT == nil && errI == nil {
+				return primitive.Timestamp{T: uint32(t), I: uint32(i)}, nil
+			}
+		}
+	}
+
+	return p.s[start-len(ident) : p.pos], nil
+}
+
+func (p *shellParser) parseWord() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if !(c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			break
+		}
+		p.pos++
+	}
+
+	return p.s[start:p.pos]
+}
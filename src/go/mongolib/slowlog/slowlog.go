@@ -0,0 +1,210 @@
+// Package slowlog reads the slow operations of mongod and mongos log files as profiler documents, for when
+// the profiler is disabled. It reads the structured log lines of MongoDB 4.4 and later, and the text lines
+// of the previous versions.
+package slowlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+// Stdin is the file name reading the log from the standard input.
+const Stdin = "-"
+
+// MaxLineLength is the longest log line read. MongoDB truncates the logged commands, but lines can still
+// be long with large filters or pipelines.
+var MaxLineLength = 16 * 1024 * 1024
+
+// slow operations are logged with this message since MongoDB 4.4
+const slowQueryMsg = "Slow query"
+
+// ErrNotSlowOp is returned by ParseLine for the lines that are not slow operations.
+var ErrNotSlowOp = errors.New("not a slow operation")
+
+type structuredLine struct {
+	T    time.Time `bson:"t"`
+	Msg  string    `bson:"msg"`
+	Attr struct {
+		Type               string `bson:"type"`
+		Ns                 string `bson:"ns"`
+		Command            bson.D `bson:"command"`
+		OriginatingCommand bson.D `bson:"originatingCommand"`
+		KeysExamined       int    `bson:"keysExamined"`
+		DocsExamined       int    `bson:"docsExamined"`
+		NReturned          int    `bson:"nreturned"`
+		NumYields          int    `bson:"numYields"`
+		Reslen             int    `bson:"reslen"`
+		DurationMillis     int    `bson:"durationMillis"`
+		PlanSummary        string `bson:"planSummary"`
+		Remote             string `bson:"remote"`
+		AppName            string `bson:"appName"`
+		Protocol           string `bson:"protocol"`
+		WriteConflicts     int    `bson:"writeConflicts"`
+		ErrCode            int    `bson:"errCode"`
+		ErrName            string `bson:"errName"`
+	} `bson:"attr"`
+}
+
+// Open opens a log file, decompressing the gzipped ones, like rotated logs often are. Stdin reads the
+// standard input.
+func Open(name string) (io.ReadCloser, error) {
+	if name == Stdin {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(name, ".gz") {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "cannot decompress %s", name)
+	}
+
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	if err := g.Reader.Close(); err != nil {
+		g.file.Close()
+		return err
+	}
+
+	return g.file.Close()
+}
+
+// Reader reads the slow operations of a log.
+type Reader struct {
+	scanner *bufio.Scanner
+	skipped int
+}
+
+// NewReader returns a Reader of the log lines of r.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxLineLength)
+
+	return &Reader{scanner: scanner}
+}
+
+// Next returns the next slow operation of the log, or io.EOF at its end. The slow operations that cannot
+// be parsed, like the ones whose command was truncated, are counted by Skipped.
+func (r *Reader) Next() (proto.SystemProfile, error) {
+	for r.scanner.Scan() {
+		doc, err := ParseLine(r.scanner.Text())
+		if err == nil {
+			return doc, nil
+		}
+		if !errors.Is(err, ErrNotSlowOp) {
+			r.skipped++
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return proto.SystemProfile{}, errors.Wrap(err, "cannot read the log")
+	}
+
+	return proto.SystemProfile{}, io.EOF
+}
+
+// Skipped is the number of slow operations that could not be parsed.
+func (r *Reader) Skipped() int {
+	return r.skipped
+}
+
+// ParseLine returns the profiler document of a slow operation log line, or ErrNotSlowOp.
+func ParseLine(line string) (proto.SystemProfile, error) {
+	if strings.HasPrefix(line, "{") {
+		return parseStructuredLine(line)
+	}
+
+	return parseLegacyLine(line)
+}
+
+func parseStructuredLine(line string) (proto.SystemProfile, error) {
+	// cheap test before parsing every line
+	if !strings.Contains(line, slowQueryMsg) {
+		return proto.SystemProfile{}, ErrNotSlowOp
+	}
+
+	var sl structuredLine
+	if err := bson.UnmarshalExtJSON([]byte(line), false, &sl); err != nil {
+		return proto.SystemProfile{}, errors.Wrap(err, "cannot parse the log line")
+	}
+
+	if sl.Msg != slowQueryMsg || sl.Attr.Ns == "" {
+		return proto.SystemProfile{}, ErrNotSlowOp
+	}
+
+	op, ok := profilerOp(sl.Attr.Type, sl.Attr.Command)
+	if !ok {
+		return proto.SystemProfile{}, ErrNotSlowOp
+	}
+
+	return proto.SystemProfile{
+		Ts:                 sl.T,
+		Ns:                 sl.Attr.Ns,
+		Op:                 op,
+		PlanSummary:        sl.Attr.PlanSummary,
+		Command:            sl.Attr.Command,
+		OriginatingCommand: sl.Attr.OriginatingCommand,
+		KeysExamined:       sl.Attr.KeysExamined,
+		DocsExamined:       sl.Attr.DocsExamined,
+		Nreturned:          sl.Attr.NReturned,
+		NumYield:           sl.Attr.NumYields,
+		ResponseLength:     sl.Attr.Reslen,
+		Millis:             sl.Attr.DurationMillis,
+		Client:             sl.Attr.Remote,
+		AppName:            sl.Attr.AppName,
+		Protocol:           sl.Attr.Protocol,
+		WriteConflicts:     sl.Attr.WriteConflicts,
+		ErrCode:            sl.Attr.ErrCode,
+		ErrName:            sl.Attr.ErrName,
+	}, nil
+}
+
+// profilerOp returns the op the profiler records for a logged operation, so that the operations read from
+// the logs are grouped like the ones read from system.profile. The update and delete commands are left out:
+// each of their statements is logged on its own, as the profiler records them.
+func profilerOp(logType string, cmd bson.D) (string, bool) {
+	if logType != "command" {
+		return logType, true
+	}
+
+	if len(cmd) == 0 {
+		return "command", true
+	}
+
+	switch cmd[0].Key {
+	case "find":
+		return "query", true
+	case "getMore":
+		return "getmore", true
+	case "insert":
+		return "insert", true
+	case "update", "delete":
+		return "", false
+	}
+
+	return "command", true
+}
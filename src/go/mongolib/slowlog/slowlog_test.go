@@ -0,0 +1,146 @@
+package slowlog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	structuredFind      = `{"t":{"$date":"2023-05-10T10:00:01.123+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"test.coll","command":{"find":"coll","filter":{"a":{"$gt":1}},"$db":"test"},"planSummary":"COLLSCAN","keysExamined":0,"docsExamined":1000,"nreturned":10,"numYields":1,"reslen":512,"remote":"127.0.0.1:51234","appName":"orders-service","protocol":"op_msg","durationMillis":150}}`
+	structuredUpdate    = `{"t":{"$date":"2023-05-10T10:00:02.000+00:00"},"s":"I","c":"WRITE","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"update","ns":"test.coll","command":{"q":{"a":1},"u":{"$set":{"b":2}},"multi":false,"upsert":false},"keysExamined":1,"docsExamined":1,"nMatched":1,"nModified":1,"numYields":0,"durationMillis":120}}`
+	structuredUpdateCmd = `{"t":{"$date":"2023-05-10T10:00:02.001+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"test.$cmd","command":{"update":"coll","updates":[{"q":{"a":1},"u":{"$set":{"b":2}}}],"$db":"test"},"numYields":0,"reslen":60,"durationMillis":121}}`
+	structuredTxnFind   = `{"t":{"$date":"2023-05-10T10:00:02.002+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"test.coll","command":{"find":"coll","filter":{"a":1},"lsid":{"id":{"$uuid":"2f1e3e0d-8c34-4a8e-9f3b-0a5d6c7b8e9f"}},"txnNumber":3,"autocommit":false,"$db":"test"},"numYields":0,"ok":0,"errMsg":"WriteConflict error","errName":"WriteConflict","errCode":112,"reslen":300,"durationMillis":130}}`
+	structuredOther     = `{"t":{"$date":"2023-05-10T10:00:03.000+00:00"},"s":"I","c":"NETWORK","id":22943,"ctx":"listener","msg":"Connection accepted","attr":{"remote":"127.0.0.1:51236"}}`
+
+	legacyFind      = `2019-08-01T10:00:00.123+0000 I COMMAND  [conn12] command test.coll appName: "MongoDB Shell" command: find { find: "coll", filter: { _id: ObjectId('5d42b0a8e1c2a9f0b4c3d2e1'), ts: { $gte: new Date(1564653600000) }, name: /^ab/i }, limit: 10.0, $db: "test" } planSummary: IXSCAN { _id: 1 } keysExamined:1 docsExamined:1 cursorExhausted:1 numYields:0 nreturned:1 reslen:250 locks:{ Global: { acquireCount: { r: 1 } } } protocol:op_msg 105ms`
+	legacyUpdate    = `2019-08-01T10:00:01.000+0000 I WRITE    [conn12] update test.coll command: { q: { a: 1 }, u: { $set: { b: MinKey } }, multi: false, upsert: false } planSummary: COLLSCAN keysExamined:0 docsExamined:100 nMatched:1 nModified:1 numYields:0 locks:{} 200ms`
+	legacyTxnFind   = `2019-08-01T10:00:01.500+0000 I COMMAND  [conn12] command test.coll command: find { find: "coll", filter: { a: 1 }, lsid: { id: UUID("2f1e3e0d-8c34-4a8e-9f3b-0a5d6c7b8e9f") }, txnNumber: 3, autocommit: false, $db: "test" } numYields:0 ok:0 errMsg:"WriteConflict error" errName:WriteConflict errCode:112 reslen:300 locks:{} protocol:op_msg 130ms`
+	legacyTruncated = `2019-08-01T10:00:02.000+0000 I COMMAND  [conn12] command test.coll command: find { find: "coll", filter: { a: "abc 300ms`
+	legacyOther     = `2019-08-01T10:00:03.000+0000 I NETWORK  [listener] connection accepted from 127.0.0.1:51236 #13 (2 connections now open)`
+)
+
+func TestParseStructuredLine(t *testing.T) {
+	doc, err := ParseLine(structuredFind)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2023, 5, 10, 10, 0, 1, 123000000, time.UTC), doc.Ts.UTC())
+	assert.Equal(t, "test.coll", doc.Ns)
+	assert.Equal(t, "query", doc.Op)
+	assert.Equal(t, "find", doc.Command[0].Key)
+	assert.Equal(t, 1000, doc.DocsExamined)
+	assert.Equal(t, 10, doc.Nreturned)
+	assert.Equal(t, 512, doc.ResponseLength)
+	assert.Equal(t, 150, doc.Millis)
+	assert.Equal(t, "127.0.0.1:51234", doc.Client)
+	assert.Equal(t, "orders-service", doc.AppName)
+	assert.Equal(t, "COLLSCAN", doc.PlanSummary)
+
+	doc, err = ParseLine(structuredUpdate)
+	require.NoError(t, err)
+	assert.Equal(t, "update", doc.Op)
+	assert.Equal(t, "q", doc.Command[0].Key)
+	assert.Equal(t, 120, doc.Millis)
+
+	doc, err = ParseLine(structuredTxnFind)
+	require.NoError(t, err)
+	assert.Equal(t, 112, doc.ErrCode)
+	assert.Equal(t, "WriteConflict", doc.ErrName)
+	assert.Equal(t, "transaction", doc.Class())
+
+	_, err = ParseLine(structuredUpdateCmd)
+	assert.ErrorIs(t, err, ErrNotSlowOp)
+
+	_, err = ParseLine(structuredOther)
+	assert.ErrorIs(t, err, ErrNotSlowOp)
+}
+
+func TestParseLegacyLine(t *testing.T) {
+	doc, err := ParseLine(legacyFind)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2019, 8, 1, 10, 0, 0, 123000000, time.UTC), doc.Ts.UTC())
+	assert.Equal(t, "test.coll", doc.Ns)
+	assert.Equal(t, "query", doc.Op)
+	assert.Equal(t, 1, doc.KeysExamined)
+	assert.Equal(t, 1, doc.DocsExamined)
+	assert.Equal(t, 1, doc.Nreturned)
+	assert.Equal(t, 250, doc.ResponseLength)
+	assert.Equal(t, "op_msg", doc.Protocol)
+	assert.Equal(t, 105, doc.Millis)
+	assert.Equal(t, "IXSCAN", doc.PlanSummary)
+	assert.Equal(t, "MongoDB Shell", doc.AppName)
+
+	oid, _ := primitive.ObjectIDFromHex("5d42b0a8e1c2a9f0b4c3d2e1")
+	want := bson.D{
+		{Key: "find", Value: "coll"},
+		{Key: "filter", Value: bson.D{
+			{Key: "_id", Value: oid},
+			{Key: "ts", Value: bson.D{{Key: "$gte", Value: primitive.DateTime(1564653600000)}}},
+			{Key: "name", Value: primitive.Regex{Pattern: "^ab", Options: "i"}},
+		}},
+		{Key: "limit", Value: float64(10)},
+		{Key: "$db", Value: "test"},
+	}
+	assert.Equal(t, want, doc.Command)
+
+	doc, err = ParseLine(legacyUpdate)
+	require.NoError(t, err)
+	assert.Equal(t, "update", doc.Op)
+	assert.Equal(t, bson.D{{Key: "a", Value: int32(1)}}, doc.Command[0].Value)
+	assert.Equal(t, 100, doc.DocsExamined)
+	assert.Equal(t, 200, doc.Millis)
+
+	doc, err = ParseLine(legacyTxnFind)
+	require.NoError(t, err)
+	assert.Equal(t, 112, doc.ErrCode)
+	assert.Equal(t, "WriteConflict", doc.ErrName)
+	assert.Equal(t, "transaction", doc.Class())
+
+	_, err = ParseLine(legacyTruncated)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotSlowOp)
+
+	_, err = ParseLine(legacyOther)
+	assert.ErrorIs(t, err, ErrNotSlowOp)
+}
+
+func TestReader(t *testing.T) {
+	lines := []string{structuredOther, structuredFind, structuredUpdateCmd, structuredUpdate, legacyTruncated, legacyFind}
+
+	filename := filepath.Join(t.TempDir(), "mongod.log.1.gz")
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	rc, err := Open(filename)
+	require.NoError(t, err)
+	defer rc.Close()
+
+	r := NewReader(rc)
+	var ops []string
+	for {
+		doc, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ops = append(ops, doc.Op)
+	}
+
+	assert.Equal(t, []string{"query", "update", "query"}, ops)
+	assert.Equal(t, 1, r.Skipped())
+}
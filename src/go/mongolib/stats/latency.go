@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"strconv"
+
+	"github.com/montanaflynn/stats"
+)
+
+// DefaultPercentiles are the execution time percentiles computed when none are given.
+var DefaultPercentiles = []float64{50, 95, 99, 99.9}
+
+// LatencyBuckets are the upper bounds, in milliseconds, of the buckets of the execution time histogram.
+// The last bucket holds the operations slower than the last bound.
+var LatencyBuckets = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+// Percentile is an execution time percentile, in milliseconds.
+type Percentile struct {
+	Percent float64
+	Value   float64
+}
+
+// Label returns the percentile as p50, p99.9.
+func (p Percentile) Label() string {
+	return "p" + strconv.FormatFloat(p.Percent, 'f', -1, 64)
+}
+
+func calcPercentiles(samples []float64, percents []float64) []Percentile {
+	percentiles := make([]Percentile, 0, len(percents))
+	for _, percent := range percents {
+		value, _ := stats.PercentileNearestRank(samples, percent)
+		percentiles = append(percentiles, Percentile{Percent: percent, Value: value})
+	}
+
+	return percentiles
+}
+
+// calcHistogram counts the samples of every bucket of LatencyBuckets, the last count being the samples
+// above the last bound.
+func calcHistogram(samples []float64) []int {
+	histogram := make([]int, len(LatencyBuckets)+1)
+	for _, sample := range samples {
+		i := 0
+		for i < len(LatencyBuckets) && sample > LatencyBuckets[i] {
+			i++
+		}
+		histogram[i]++
+	}
+
+	return histogram
+}
@@ -0,0 +1,355 @@
+package stats
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+// EvictedID is the ID of the query holding the operations of the fingerprints evicted by
+// Limits.MaxFingerprints. It is the last query of Queries, and it is not a fingerprint: it is only there for
+// the totals and the percentages of the other queries to count every operation.
+const EvictedID = "evicted"
+
+const (
+	sketchDepth = 4
+	sketchWidth = 1 << 16
+)
+
+// Limits bound the memory used by the statistics of tens of millions of operations. The zero value keeps
+// every value of every fingerprint.
+type Limits struct {
+	// MaxFingerprints is the number of fingerprints tracked. Past it, a new fingerprint only replaces the
+	// fingerprint of the least execution time when its own execution time, estimated by a count-min sketch
+	// of the operations not tracked, is higher. The operations not tracked go to the EvictedID query.
+	MaxFingerprints int
+	// MaxSamples is the number of values kept per metric of a fingerprint. Past it, the values kept are a
+	// uniform sample of the values of the operations: the totals, minimums and maximums stay exact, the
+	// averages are computed from them, and the percentiles, medians and standard deviations are estimated.
+	MaxSamples int
+}
+
+// SetLimits bounds the memory used by the statistics, see Limits. It must be called before adding the
+// operations.
+func (s *Stats) SetLimits(limits Limits) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.limits = limits
+	s.rand = rand.New(rand.NewSource(1)) //nolint
+	if limits.MaxFingerprints > 0 {
+		s.sketch = newCountMinSketch(sketchDepth, sketchWidth)
+	}
+}
+
+// metrics of QueryInfoAndCounters
+const (
+	metricScanned = iota
+	metricReturned
+	metricQueryTime
+	metricResponseLength
+	metricKeysExamined
+	metricNumYield
+	metricWriteConflicts
+	metricLockWait
+	metricsCount
+)
+
+func (q *QueryInfoAndCounters) values(metric int) *[]float64 {
+	switch metric {
+	case metricScanned:
+		return &q.NScanned
+	case metricReturned:
+		return &q.NReturned
+	case metricQueryTime:
+		return &q.QueryTime
+	case metricResponseLength:
+		return &q.ResponseLength
+	case metricKeysExamined:
+		return &q.KeysExamined
+	case metricNumYield:
+		return &q.NumYield
+	case metricWriteConflicts:
+		return &q.WriteConflicts
+	default:
+		return &q.LockWait
+	}
+}
+
+// aggregate is the exact total, minimum and maximum of the values of a metric.
+type aggregate struct {
+	total, min, max float64
+}
+
+func (a *aggregate) add(value float64, first bool) {
+	a.total += value
+	if first || value < a.min {
+		a.min = value
+	}
+	if first || value > a.max {
+		a.max = value
+	}
+}
+
+func (a *aggregate) merge(o aggregate, first bool) {
+	a.total += o.total
+	if first || o.min < a.min {
+		a.min = o.min
+	}
+	if first || o.max > a.max {
+		a.max = o.max
+	}
+}
+
+// sampled returns true if the values of the query are a sample of the values of its operations.
+func (q *QueryInfoAndCounters) sampled() bool {
+	return q.aggregates != nil
+}
+
+// aggregate returns the exact total, minimum and maximum of a metric.
+func (q *QueryInfoAndCounters) aggregate(metric int) aggregate {
+	if q.sampled() {
+		return q.aggregates[metric]
+	}
+
+	a := aggregate{}
+	for i, v := range *q.values(metric) {
+		a.add(v, i == 0)
+	}
+
+	return a
+}
+
+// stats returns the statistics of a metric, exact or estimated from the sample of its values.
+func (q *QueryInfoAndCounters) stats(metric int) Statistics {
+	s := calcStats(*q.values(metric))
+	if q.sampled() && q.Count > 0 {
+		a := q.aggregates[metric]
+		s.Total, s.Min, s.Max = a.total, a.min, a.max
+		s.Avg = a.total / float64(q.Count)
+	}
+
+	return s
+}
+
+// histogram returns the execution time histogram, the counts of a sample being scaled to the count of
+// operations.
+func (q *QueryInfoAndCounters) histogram() []int {
+	histogram := calcHistogram(q.QueryTime)
+	if !q.sampled() || len(q.QueryTime) == 0 {
+		return histogram
+	}
+
+	scale := float64(q.Count) / float64(len(q.QueryTime))
+	for i, count := range histogram {
+		histogram[i] = int(math.Round(float64(count) * scale))
+	}
+
+	return histogram
+}
+
+// addValues adds the values of an operation, the query count including it. Past limit values, they replace
+// a random value already kept, with a probability of limit/count (reservoir sampling).
+func (q *QueryInfoAndCounters) addValues(values [metricsCount]float64, limit int, r *rand.Rand) {
+	if limit <= 0 || len(q.QueryTime) < limit {
+		if q.sampled() {
+			for m, v := range values {
+				q.aggregates[m].add(v, false)
+			}
+		}
+		for m, v := range values {
+			*q.values(m) = append(*q.values(m), v)
+		}
+		return
+	}
+
+	if !q.sampled() {
+		aggregates := make([]aggregate, metricsCount)
+		for m := range aggregates {
+			aggregates[m] = q.aggregate(m)
+		}
+		q.aggregates = aggregates
+	}
+	for m, v := range values {
+		q.aggregates[m].add(v, false)
+	}
+
+	if i := r.Int63n(int64(q.Count)); i < int64(limit) {
+		for m, v := range values {
+			(*q.values(m))[i] = v
+		}
+	}
+}
+
+// merge adds the operations of the other query, keeping up to limit values per metric. The values kept are
+// taken from the values of both queries in proportion of their counts.
+func (q *QueryInfoAndCounters) merge(o QueryInfoAndCounters, limit int) {
+	if o.Count == 0 {
+		return
+	}
+
+	if limit > 0 && (q.sampled() || o.sampled() || len(q.QueryTime)+len(o.QueryTime) > limit) {
+		aggregates := make([]aggregate, metricsCount)
+		for m := range aggregates {
+			aggregates[m] = q.aggregate(m)
+			aggregates[m].merge(o.aggregate(m), q.Count == 0)
+		}
+
+		count := q.Count + o.Count
+		mine := stride(len(q.QueryTime), limit*q.Count/count)
+		theirs := stride(len(o.QueryTime), limit-len(mine))
+		for m := 0; m < metricsCount; m++ {
+			values := make([]float64, 0, len(mine)+len(theirs))
+			for _, i := range mine {
+				values = append(values, (*q.values(m))[i])
+			}
+			for _, i := range theirs {
+				values = append(values, (*o.values(m))[i])
+			}
+			*q.values(m) = values
+		}
+		q.aggregates = aggregates
+	} else {
+		for m := 0; m < metricsCount; m++ {
+			*q.values(m) = append(*q.values(m), *o.values(m)...)
+		}
+	}
+
+	q.Count += o.Count
+	q.Aborted += o.Aborted
+	q.Conflicts += o.Conflicts
+	for _, e := range o.Errors {
+		q.addError(e)
+	}
+	if q.FirstSeen.IsZero() || (!o.FirstSeen.IsZero() && o.FirstSeen.Before(q.FirstSeen)) {
+		q.FirstSeen = o.FirstSeen
+	}
+	if o.LastSeen.After(q.LastSeen) {
+		q.LastSeen = o.LastSeen
+	}
+}
+
+// stride returns the indexes of n values evenly spread over length values.
+func stride(length, n int) []int {
+	if n > length {
+		n = length
+	}
+
+	indexes := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		indexes = append(indexes, i*length/n)
+	}
+
+	return indexes
+}
+
+// impact is the weight of an operation for Limits.MaxFingerprints: its execution time, the operations of
+// less than a millisecond counting as well.
+func impact(doc proto.SystemProfile) float64 {
+	return float64(doc.Millis) + 1
+}
+
+// admit returns true if the new fingerprint of the document is tracked, evicting the fingerprint of the
+// least impact when the limit is reached. It must be called with the lock held.
+func (s *Stats) admit(key GroupKey, qiac *QueryInfoAndCounters, doc proto.SystemProfile) bool {
+	if s.limits.MaxFingerprints <= 0 || len(s.queryInfoAndCounters) < s.limits.MaxFingerprints {
+		return true
+	}
+
+	// the operations already seen, not tracked
+	estimate := s.sketch.estimate(key.String()) + impact(doc)
+
+	// the impacts only grow: below the spill threshold, the fingerprint cannot replace any other one
+	if estimate <= s.spillThreshold {
+		s.sketch.add(key.String(), impact(doc))
+		return false
+	}
+
+	var minKey GroupKey
+	minImpact, secondImpact := -1.0, -1.0
+	for k, q := range s.queryInfoAndCounters {
+		switch {
+		case minImpact < 0 || q.impact < minImpact:
+			secondImpact = minImpact
+			minKey, minImpact = k, q.impact
+		case secondImpact < 0 || q.impact < secondImpact:
+			secondImpact = q.impact
+		}
+	}
+
+	if estimate <= minImpact {
+		s.spillThreshold = minImpact
+		s.sketch.add(key.String(), impact(doc))
+		return false
+	}
+
+	evicted := s.queryInfoAndCounters[minKey]
+	delete(s.queryInfoAndCounters, minKey)
+	s.sketch.add(minKey.String(), evicted.impact)
+	s.evictedQueries().merge(*evicted, s.limits.MaxSamples)
+
+	s.spillThreshold = estimate
+	if secondImpact >= 0 && secondImpact < estimate {
+		s.spillThreshold = secondImpact
+	}
+
+	// the operation adds its own impact
+	qiac.impact = estimate - impact(doc)
+
+	return true
+}
+
+// evictedQueries returns the query holding the operations not tracked, creating it. It must be called with
+// the lock held.
+func (s *Stats) evictedQueries() *QueryInfoAndCounters {
+	if s.evicted == nil {
+		s.evicted = &QueryInfoAndCounters{ID: EvictedID}
+	}
+
+	return s.evicted
+}
+
+// countMinSketch estimates the sum of the values added for a key in a fixed memory, never below it.
+type countMinSketch struct {
+	width  uint64
+	counts [][]float64
+}
+
+func newCountMinSketch(depth, width int) *countMinSketch {
+	c := &countMinSketch{width: uint64(width)}
+	for i := 0; i < depth; i++ {
+		c.counts = append(c.counts, make([]float64, width))
+	}
+
+	return c
+}
+
+func (c *countMinSketch) add(key string, value float64) {
+	h1, h2 := sketchHashes(key)
+	for i, row := range c.counts {
+		row[(h1+uint64(i)*h2)%c.width] += value
+	}
+}
+
+func (c *countMinSketch) estimate(key string) float64 {
+	h1, h2 := sketchHashes(key)
+	estimate := -1.0
+	for i, row := range c.counts {
+		if v := row[(h1+uint64(i)*h2)%c.width]; estimate < 0 || v < estimate {
+			estimate = v
+		}
+	}
+
+	return estimate
+}
+
+// sketchHashes returns the two hashes of the key from which the hash of every row is derived.
+func sketchHashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key)) //nolint
+	sum := h.Sum64()
+
+	return sum & 0xffffffff, sum>>32 | 1
+}
@@ -0,0 +1,86 @@
+package stats
+
+// SavedQuery is a query in a form that can be saved, as JSON, and merged later into other statistics with
+// Merge: unlike QueryInfoAndCounters, it keeps the exact aggregates of the sampled metrics.
+type SavedQuery struct {
+	QueryInfoAndCounters
+	Aggregates []SavedAggregate `json:",omitempty"` // by metric, when the values are sampled
+}
+
+// SavedAggregate is the exact total, minimum and maximum of the values of a metric of a SavedQuery.
+type SavedAggregate struct {
+	Total float64
+	Min   float64
+	Max   float64
+}
+
+// Save returns the queries in a form that can be saved and merged later with Merge.
+func (q Queries) Save() []SavedQuery {
+	saved := make([]SavedQuery, 0, len(q))
+	for _, query := range q {
+		sq := SavedQuery{QueryInfoAndCounters: query}
+		for _, a := range query.aggregates {
+			sq.Aggregates = append(sq.Aggregates, SavedAggregate{Total: a.total, Min: a.min, Max: a.max})
+		}
+		saved = append(saved, sq)
+	}
+
+	return saved
+}
+
+// Merge adds the operations of saved queries to the collection of statistics, as if they were added again.
+// The queries of a fingerprint not tracked yet, past Limits.MaxFingerprints, go to the EvictedID query.
+func (s *Stats) Merge(saved []SavedQuery) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, sq := range saved {
+		q := sq.QueryInfoAndCounters
+		q.aggregates = nil
+		if len(sq.Aggregates) == metricsCount {
+			q.aggregates = make([]aggregate, metricsCount)
+			for m, a := range sq.Aggregates {
+				q.aggregates[m] = aggregate{total: a.Total, min: a.Min, max: a.Max}
+			}
+		}
+
+		if q.ID == EvictedID {
+			s.evictedQueries().merge(q, s.limits.MaxSamples)
+			continue
+		}
+
+		key := GroupKey{
+			Operation:   q.Operation,
+			Namespace:   q.Namespace,
+			Fingerprint: q.Fingerprint,
+			Group:       q.Group,
+			Class:       q.Class,
+		}
+		existing, ok := s.queryInfoAndCounters[key]
+		if !ok && s.limits.MaxFingerprints > 0 && len(s.queryInfoAndCounters) >= s.limits.MaxFingerprints {
+			s.evictedQueries().merge(q, s.limits.MaxSamples)
+			continue
+		}
+		if !ok {
+			existing = &QueryInfoAndCounters{
+				ID:          q.ID,
+				Namespace:   q.Namespace,
+				Operation:   q.Operation,
+				Query:       q.Query,
+				Fingerprint: q.Fingerprint,
+				Group:       q.Group,
+				Class:       q.Class,
+			}
+			s.queryInfoAndCounters[key] = existing
+		}
+
+		existing.merge(q, s.limits.MaxSamples)
+		existing.TableScan = existing.TableScan || q.TableScan
+		for _, shard := range q.Shards {
+			addShard(existing, shard)
+		}
+		if s.limits.MaxFingerprints > 0 {
+			existing.impact += q.aggregate(metricQueryTime).total + float64(q.Count)
+		}
+	}
+}
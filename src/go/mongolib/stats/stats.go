@@ -3,6 +3,7 @@ package stats
 import (
 	"crypto/md5"
 	"fmt"
+	"math/rand"
 	"sort"
 	"sync"
 	"time"
@@ -41,14 +42,35 @@ func New(fingerprinter Fingerprinter) *Stats {
 	return s
 }
 
+// NewWithGroupBy creates new instance of stats with given Fingerprinter, grouping the queries by the value
+// groupBy returns for their documents as well
+func NewWithGroupBy(fingerprinter Fingerprinter, groupBy GroupBy) *Stats {
+	s := New(fingerprinter)
+	s.groupBy = groupBy
+
+	return s
+}
+
+// GroupBy returns the value a document is grouped by, in addition to its fingerprint, like its
+// application name or its user
+type GroupBy func(doc proto.SystemProfile) string
+
 // Stats is a collection of MongoDB statistics
 type Stats struct {
 	// dependencies
 	fingerprinter Fingerprinter
+	groupBy       GroupBy
 
 	// internal
 	queryInfoAndCounters map[GroupKey]*QueryInfoAndCounters
 	sync.RWMutex
+
+	// with Limits
+	limits         Limits
+	rand           *rand.Rand
+	sketch         *countMinSketch
+	spillThreshold float64
+	evicted        *QueryInfoAndCounters
 }
 
 // Reset clears the collection of statistics
@@ -57,10 +79,21 @@ func (s *Stats) Reset() {
 	defer s.Unlock()
 
 	s.queryInfoAndCounters = make(map[GroupKey]*QueryInfoAndCounters)
+	s.evicted = nil
+	s.spillThreshold = 0
+	if s.sketch != nil {
+		s.sketch = newCountMinSketch(sketchDepth, sketchWidth)
+	}
 }
 
 // Add adds proto.SystemProfile to the collection of statistics
 func (s *Stats) Add(doc proto.SystemProfile) error {
+	return s.AddFromShard(doc, "")
+}
+
+// AddFromShard adds proto.SystemProfile read on a shard of a sharded cluster to the collection of
+// statistics, recording the shard among the ones the query ran on
+func (s *Stats) AddFromShard(doc proto.SystemProfile, shard string) error {
 	fp, err := s.fingerprinter.Fingerprint(doc)
 	if err != nil {
 		return &StatsFingerprintError{err}
@@ -72,6 +105,10 @@ func (s *Stats) Add(doc proto.SystemProfile) error {
 		Operation:   fp.Operation,
 		Fingerprint: fp.Fingerprint,
 		Namespace:   fp.Namespace,
+		Class:       doc.Class(),
+	}
+	if s.groupBy != nil {
+		key.Group = s.groupBy(doc)
 	}
 	if qiac, ok = s.getQueryInfoAndCounters(key); !ok {
 		query := proto.NewExampleQuery(doc)
@@ -84,35 +121,82 @@ func (s *Stats) Add(doc proto.SystemProfile) error {
 			Operation:   fp.Operation,
 			Fingerprint: fp.Fingerprint,
 			Namespace:   fp.Namespace,
+			Group:       key.Group,
+			Class:       key.Class,
 			TableScan:   false,
 			Query:       string(queryBson),
 		}
-		s.setQueryInfoAndCounters(key, qiac)
+	}
+
+	s.Lock()
+	if !ok {
+		switch existing, found := s.queryInfoAndCounters[key]; {
+		case found:
+			qiac = existing
+		case s.admit(key, qiac, doc):
+			s.queryInfoAndCounters[key] = qiac
+		default:
+			qiac = s.evictedQueries()
+		}
 	}
 	qiac.Count++
+	if s.limits.MaxFingerprints > 0 {
+		qiac.impact += impact(doc)
+	}
+
 	// docsExamined is renamed from nscannedObjects in 3.2.0.
 	// https://docs.mongodb.com/manual/reference/database-profiler/#system.profile.docsExamined
-	s.Lock()
+	values := [metricsCount]float64{}
 	if doc.NscannedObjects > 0 {
-		qiac.NScanned = append(qiac.NScanned, float64(doc.NscannedObjects))
+		values[metricScanned] = float64(doc.NscannedObjects)
 	} else {
-		qiac.NScanned = append(qiac.NScanned, float64(doc.DocsExamined))
+		values[metricScanned] = float64(doc.DocsExamined)
+	}
+	if doc.PlanSummary == "COLLSCAN" {
+		qiac.TableScan = true
+	}
+	if doc.Aborted() {
+		qiac.Aborted++
 	}
-	qiac.NReturned = append(qiac.NReturned, float64(doc.Nreturned))
-	qiac.QueryTime = append(qiac.QueryTime, float64(doc.Millis))
-	qiac.ResponseLength = append(qiac.ResponseLength, float64(doc.ResponseLength))
+	if doc.WriteConflict() {
+		qiac.Conflicts++
+	}
+	if doc.Failed() {
+		qiac.addError(ErrorCount{Code: doc.ErrCode, Name: doc.ErrName, Count: 1})
+	}
+	values[metricReturned] = float64(doc.Nreturned)
+	values[metricKeysExamined] = float64(doc.KeysExamined)
+	values[metricNumYield] = float64(doc.NumYield)
+	values[metricWriteConflicts] = float64(doc.WriteConflicts)
+	values[metricLockWait] = float64(doc.LockWaitMicros())
+	values[metricQueryTime] = float64(doc.Millis)
+	values[metricResponseLength] = float64(doc.ResponseLength)
+	qiac.addValues(values, s.limits.MaxSamples, s.rand)
+
 	if qiac.FirstSeen.IsZero() || qiac.FirstSeen.After(doc.Ts) {
 		qiac.FirstSeen = doc.Ts
 	}
 	if qiac.LastSeen.IsZero() || qiac.LastSeen.Before(doc.Ts) {
 		qiac.LastSeen = doc.Ts
 	}
+	if shard != "" {
+		addShard(qiac, shard)
+	}
 	s.Unlock()
 
 	return nil
 }
 
-// Queries returns all collected statistics
+// addShard adds the shard to the sorted shards the query ran on.
+func addShard(qiac *QueryInfoAndCounters, shard string) {
+	if i := sort.SearchStrings(qiac.Shards, shard); i == len(qiac.Shards) || qiac.Shards[i] != shard {
+		qiac.Shards = append(qiac.Shards, "")
+		copy(qiac.Shards[i+1:], qiac.Shards[i:])
+		qiac.Shards[i] = shard
+	}
+}
+
+// Queries returns all collected statistics, followed by the EvictedID query when fingerprints were evicted
 func (s *Stats) Queries() Queries {
 	s.Lock()
 	defer s.Unlock()
@@ -127,6 +211,9 @@ func (s *Stats) Queries() Queries {
 	for _, key := range keys {
 		queries = append(queries, *s.queryInfoAndCounters[key])
 	}
+	if s.evicted != nil {
+		queries = append(queries, *s.evicted)
+	}
 	return queries
 }
 
@@ -138,35 +225,30 @@ func (s *Stats) getQueryInfoAndCounters(key GroupKey) (*QueryInfoAndCounters, bo
 	return v, ok
 }
 
-func (s *Stats) setQueryInfoAndCounters(key GroupKey, value *QueryInfoAndCounters) {
-	s.Lock()
-	defer s.Unlock()
-
-	s.queryInfoAndCounters[key] = value
-}
-
 // Queries is a slice of MongoDB statistics
 type Queries []QueryInfoAndCounters
 
-// CalcQueriesStats calculates QueryStats for given uptime
-func (q Queries) CalcQueriesStats(uptime int64) []QueryStats {
+// CalcQueriesStats calculates QueryStats for given uptime, with the given execution time percentiles
+// (DefaultPercentiles if none)
+func (q Queries) CalcQueriesStats(uptime int64, percentiles ...float64) []QueryStats {
 	qs := []QueryStats{}
 	tc := calcTotalCounters(q)
 
 	for _, query := range q {
-		queryStats := countersToStats(query, uptime, tc)
+		queryStats := countersToStats(query, uptime, tc, percentiles)
 		qs = append(qs, queryStats)
 	}
 
 	return qs
 }
 
-// CalcTotalQueriesStats calculates total QueryStats for given uptime
-func (q Queries) CalcTotalQueriesStats(uptime int64) QueryStats {
+// CalcTotalQueriesStats calculates total QueryStats for given uptime, with the given execution time
+// percentiles (DefaultPercentiles if none)
+func (q Queries) CalcTotalQueriesStats(uptime int64, percentiles ...float64) QueryStats {
 	tc := calcTotalCounters(q)
 
 	totalQueryInfoAndCounters := aggregateCounters(q)
-	totalStats := countersToStats(totalQueryInfoAndCounters, uptime, tc)
+	totalStats := countersToStats(totalQueryInfoAndCounters, uptime, tc, percentiles)
 
 	return totalStats
 }
@@ -180,14 +262,27 @@ type QueryInfoAndCounters struct {
 	FirstSeen   time.Time
 	LastSeen    time.Time
 	TableScan   bool
+	Shards      []string // sorted, only for the documents read from the shards
+	Group       string   // value of the documents for the GroupBy of the stats
+	Class       string   // proto.ClassTransaction, proto.ClassRetryableWrite or empty
 
 	Count          int
+	Aborted        int          // operations aborting their transaction
+	Conflicts      int          // operations failed with a write conflict
+	Errors         []ErrorCount // operations failed, by error code
 	BlockedTime    Times
 	LockTime       Times
 	NReturned      []float64
 	NScanned       []float64
 	QueryTime      []float64 // in milliseconds
 	ResponseLength []float64
+	KeysExamined   []float64
+	NumYield       []float64
+	WriteConflicts []float64
+	LockWait       []float64 // in microseconds
+
+	aggregates []aggregate // exact aggregates of the metrics, when their values are sampled
+	impact     float64     // for Limits.MaxFingerprints
 }
 
 // times is an array of time.Time that implements the Sorter interface
@@ -207,10 +302,12 @@ type GroupKey struct {
 	Operation   string
 	Namespace   string
 	Fingerprint string
+	Group       string
+	Class       string
 }
 
 func (g GroupKey) String() string {
-	return g.Operation + g.Namespace + g.Fingerprint
+	return g.Operation + g.Namespace + g.Fingerprint + g.Group + g.Class
 }
 
 type totalCounters struct {
@@ -219,6 +316,11 @@ type totalCounters struct {
 	Returned  float64
 	QueryTime float64
 	Bytes     float64
+
+	KeysExamined   float64
+	NumYield       float64
+	WriteConflicts float64
+	LockWait       float64
 }
 
 type QueryStats struct {
@@ -229,8 +331,16 @@ type QueryStats struct {
 	Fingerprint string
 	FirstSeen   time.Time
 	LastSeen    time.Time
+	Shards      []string `json:",omitempty"`
+	Group       string   `json:",omitempty"`
+	Class       string   `json:",omitempty"`
+	TableScan   bool
 
 	Count          int
+	Aborted        int          `json:",omitempty"`
+	Conflicts      int          `json:",omitempty"`
+	Failed         int          `json:",omitempty"` // operations ended in an error
+	Errors         []ErrorCount `json:",omitempty"`
 	QPS            float64
 	Rank           int
 	Ratio          float64
@@ -238,6 +348,43 @@ type QueryStats struct {
 	ResponseLength Statistics
 	Returned       Statistics
 	Scanned        Statistics
+	KeysExamined   Statistics
+	NumYield       Statistics
+	WriteConflicts Statistics
+	LockWait       Statistics
+
+	QueryTimePercentiles []Percentile
+	QueryTimeHistogram   []int // operations per bucket of LatencyBuckets
+}
+
+// ErrorCount is the number of operations of a query that ended in an error.
+type ErrorCount struct {
+	Code  int
+	Name  string `json:",omitempty"` // like DuplicateKey, not recorded by the logs of MongoDB before 4.4
+	Count int
+}
+
+// addError counts the operations failed with the error, by code, or by name without code.
+func (q *QueryInfoAndCounters) addError(e ErrorCount) {
+	i := sort.Search(len(q.Errors), func(i int) bool {
+		return q.Errors[i].Code > e.Code || (q.Errors[i].Code == e.Code && q.Errors[i].Name >= e.Name)
+	})
+	if e.Code != 0 {
+		// the name of a code is not always recorded
+		i = sort.Search(len(q.Errors), func(i int) bool { return q.Errors[i].Code >= e.Code })
+	}
+
+	if i < len(q.Errors) && q.Errors[i].Code == e.Code && (e.Code != 0 || q.Errors[i].Name == e.Name) {
+		q.Errors[i].Count += e.Count
+		if q.Errors[i].Name == "" {
+			q.Errors[i].Name = e.Name
+		}
+		return
+	}
+
+	q.Errors = append(q.Errors, ErrorCount{})
+	copy(q.Errors[i+1:], q.Errors[i:])
+	q.Errors[i] = e
 }
 
 type Statistics struct {
@@ -252,21 +399,42 @@ type Statistics struct {
 	Median float64
 }
 
-func countersToStats(query QueryInfoAndCounters, uptime int64, tc totalCounters) QueryStats {
+func countersToStats(query QueryInfoAndCounters, uptime int64, tc totalCounters, percentiles []float64) QueryStats {
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+
 	queryStats := QueryStats{
 		Count:          query.Count,
 		ID:             query.ID,
 		Operation:      query.Operation,
 		Query:          query.Query,
 		Fingerprint:    query.Fingerprint,
-		Scanned:        calcStats(query.NScanned),
-		Returned:       calcStats(query.NReturned),
-		QueryTime:      calcStats(query.QueryTime),
-		ResponseLength: calcStats(query.ResponseLength),
+		Scanned:        query.stats(metricScanned),
+		Returned:       query.stats(metricReturned),
+		QueryTime:      query.stats(metricQueryTime),
+		ResponseLength: query.stats(metricResponseLength),
+		KeysExamined:   query.stats(metricKeysExamined),
+		NumYield:       query.stats(metricNumYield),
+		WriteConflicts: query.stats(metricWriteConflicts),
+		LockWait:       query.stats(metricLockWait),
 		FirstSeen:      query.FirstSeen,
 		LastSeen:       query.LastSeen,
 		Namespace:      query.Namespace,
+		Shards:         query.Shards,
+		Group:          query.Group,
+		Class:          query.Class,
+		Aborted:        query.Aborted,
+		Conflicts:      query.Conflicts,
+		Errors:         append([]ErrorCount(nil), query.Errors...),
+		TableScan:      query.TableScan,
 		QPS:            float64(query.Count) / float64(uptime),
+
+		QueryTimePercentiles: calcPercentiles(query.QueryTime, percentiles),
+		QueryTimeHistogram:   query.histogram(),
+	}
+	for _, e := range query.Errors {
+		queryStats.Failed += e.Count
 	}
 	if tc.Scanned > 0 {
 		queryStats.Scanned.Pct = queryStats.Scanned.Total * 100 / tc.Scanned
@@ -280,6 +448,18 @@ func countersToStats(query QueryInfoAndCounters, uptime int64, tc totalCounters)
 	if tc.Bytes > 0 {
 		queryStats.ResponseLength.Pct = queryStats.ResponseLength.Total * 100 / tc.Bytes
 	}
+	if tc.KeysExamined > 0 {
+		queryStats.KeysExamined.Pct = queryStats.KeysExamined.Total * 100 / tc.KeysExamined
+	}
+	if tc.NumYield > 0 {
+		queryStats.NumYield.Pct = queryStats.NumYield.Total * 100 / tc.NumYield
+	}
+	if tc.WriteConflicts > 0 {
+		queryStats.WriteConflicts.Pct = queryStats.WriteConflicts.Total * 100 / tc.WriteConflicts
+	}
+	if tc.LockWait > 0 {
+		queryStats.LockWait.Pct = queryStats.LockWait.Total * 100 / tc.LockWait
+	}
 	if queryStats.Returned.Total > 0 {
 		queryStats.Ratio = queryStats.Scanned.Total / queryStats.Returned.Total
 	}
@@ -287,15 +467,21 @@ func countersToStats(query QueryInfoAndCounters, uptime int64, tc totalCounters)
 	return queryStats
 }
 
+// aggregateCounters merges the queries. When their values are sampled, the merged values are a sample of the
+// same size as the largest one.
 func aggregateCounters(queries []QueryInfoAndCounters) QueryInfoAndCounters {
+	limit := 0
+	for _, query := range queries {
+		if query.sampled() && len(query.QueryTime) > limit {
+			limit = len(query.QueryTime)
+		}
+	}
+
 	qt := QueryInfoAndCounters{}
 	for _, query := range queries {
-		qt.Count += query.Count
-		qt.NScanned = append(qt.NScanned, query.NScanned...)
-		qt.NReturned = append(qt.NReturned, query.NReturned...)
-		qt.QueryTime = append(qt.QueryTime, query.QueryTime...)
-		qt.ResponseLength = append(qt.ResponseLength, query.ResponseLength...)
+		qt.merge(query, limit)
 	}
+	qt.FirstSeen, qt.LastSeen = time.Time{}, time.Time{}
 	return qt
 }
 
@@ -305,17 +491,14 @@ func calcTotalCounters(queries []QueryInfoAndCounters) totalCounters {
 	for _, query := range queries {
 		tc.Count += query.Count
 
-		scanned, _ := stats.Sum(query.NScanned)
-		tc.Scanned += scanned
-
-		returned, _ := stats.Sum(query.NReturned)
-		tc.Returned += returned
-
-		queryTime, _ := stats.Sum(query.QueryTime)
-		tc.QueryTime += queryTime
-
-		bytes, _ := stats.Sum(query.ResponseLength)
-		tc.Bytes += bytes
+		tc.Scanned += query.aggregate(metricScanned).total
+		tc.Returned += query.aggregate(metricReturned).total
+		tc.QueryTime += query.aggregate(metricQueryTime).total
+		tc.Bytes += query.aggregate(metricResponseLength).total
+		tc.KeysExamined += query.aggregate(metricKeysExamined).total
+		tc.NumYield += query.aggregate(metricNumYield).total
+		tc.WriteConflicts += query.aggregate(metricWriteConflicts).total
+		tc.LockWait += query.aggregate(metricLockWait).total
 	}
 	return tc
 }
@@ -2,6 +2,7 @@ package stats
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -14,6 +15,8 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson"
+
 	"github.com/percona/percona-toolkit/src/go/lib/tutil"
 	"github.com/percona/percona-toolkit/src/go/mongolib/fingerprinter"
 	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
@@ -161,6 +164,10 @@ func TestStats(t *testing.T) {
 		NScanned:       []float64{10000},
 		QueryTime:      []float64{7},
 		ResponseLength: []float64{215},
+		KeysExamined:   []float64{0},
+		NumYield:       []float64{0},
+		WriteConflicts: []float64{0},
+		LockWait:       []float64{0},
 	}
 
 	want := Queries{
@@ -457,3 +464,195 @@ func TestAvailableMetrics(t *testing.T) {
 		})
 	})
 }
+
+func TestLimits(t *testing.T) {
+	fp := fingerprinter.NewFingerprinter(fingerprinter.DefaultKeyFilters())
+	s := New(fp)
+	s.SetLimits(Limits{MaxFingerprints: 2, MaxSamples: 10})
+
+	add := func(collection string, millis ...int) {
+		for _, m := range millis {
+			doc := proto.SystemProfile{Ns: "test." + collection, Op: "insert", Millis: m}
+			if err := s.Add(doc); err != nil {
+				t.Fatalf("cannot add the operation: %s", err)
+			}
+		}
+	}
+
+	for i := 1; i <= 100; i++ {
+		add("a", i)
+	}
+	add("b", 10, 10, 10, 10, 10)
+	// below the impact of a and b
+	add("c", 1)
+
+	queries := s.Queries()
+	if len(queries) != 3 || queries[2].ID != EvictedID || queries[2].Count != 1 {
+		t.Fatalf("got %d queries, want a, b and the evicted operation of c: %+v", len(queries), queries)
+	}
+
+	qs := queries.CalcQueriesStats(1)
+	if len(queries[0].QueryTime) != 10 {
+		t.Errorf("kept %d values of a, want 10", len(queries[0].QueryTime))
+	}
+	if qs[0].Count != 100 || qs[0].QueryTime.Total != 5050 || qs[0].QueryTime.Min != 1 || qs[0].QueryTime.Max != 100 {
+		t.Errorf("the aggregates of the sampled values are not exact: %+v", qs[0])
+	}
+	histogramCount := 0
+	for _, count := range qs[0].QueryTimeHistogram {
+		histogramCount += count
+	}
+	if histogramCount != 100 {
+		t.Errorf("the histogram counts %d operations, want 100", histogramCount)
+	}
+
+	// d has more impact than b, which is evicted
+	add("d", 1000)
+	queries = s.Queries()
+	if len(queries) != 3 || queries[1].Namespace != "test.d" || queries[2].Count != 6 {
+		t.Errorf("b was not evicted by d: %+v", queries)
+	}
+
+	if totals := queries.CalcTotalQueriesStats(1); totals.Count != 107 {
+		t.Errorf("the totals count %d operations, want 107", totals.Count)
+	}
+}
+
+func TestClasses(t *testing.T) {
+	fp := fingerprinter.NewFingerprinter(fingerprinter.DefaultKeyFilters())
+	s := New(fp)
+
+	find := func(fields ...bson.E) proto.SystemProfile {
+		cmd := bson.D{{Key: "find", Value: "orders"}, {Key: "filter", Value: bson.D{{Key: "a", Value: 1}}}}
+		return proto.SystemProfile{Ns: "test.orders", Op: "query", Command: append(cmd, fields...), Millis: 10}
+	}
+	conflict := find(bson.E{Key: "txnNumber", Value: int64(1)}, bson.E{Key: "autocommit", Value: false})
+	conflict.ErrCode = 112
+
+	for _, doc := range []proto.SystemProfile{
+		find(),
+		find(),
+		find(bson.E{Key: "txnNumber", Value: int64(1)}, bson.E{Key: "autocommit", Value: false}),
+		conflict,
+	} {
+		if err := s.Add(doc); err != nil {
+			t.Fatalf("cannot add the operation: %s", err)
+		}
+	}
+
+	queries := s.Queries()
+	if len(queries) != 2 || queries[0].Class != "" || queries[0].Count != 2 {
+		t.Fatalf("the transactions are not a query of their own: %+v", queries)
+	}
+	if queries[1].Class != proto.ClassTransaction || queries[1].Count != 2 || queries[1].Aborted != 1 ||
+		queries[1].Conflicts != 1 {
+		t.Errorf("got the transactions query %+v", queries[1])
+	}
+	if queries[0].ID == queries[1].ID {
+		t.Errorf("the transactions query has the ID of the other operations")
+	}
+
+	if totals := queries.CalcTotalQueriesStats(1); totals.Aborted != 1 || totals.Conflicts != 1 {
+		t.Errorf("the totals count %d aborted operations and %d conflicts, want 1", totals.Aborted, totals.Conflicts)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	fp := fingerprinter.NewFingerprinter(fingerprinter.DefaultKeyFilters())
+	limits := Limits{MaxFingerprints: 2, MaxSamples: 10}
+
+	add := func(s *Stats, collection string, ts time.Time, millis ...int) {
+		for _, m := range millis {
+			doc := proto.SystemProfile{Ns: "test." + collection, Op: "insert", Millis: m, Ts: ts}
+			if err := s.AddFromShard(doc, collection+"-shard"); err != nil {
+				t.Fatalf("cannot add the operation: %s", err)
+			}
+		}
+	}
+
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	previous := New(fp)
+	previous.SetLimits(limits)
+	for i := 1; i <= 50; i++ {
+		add(previous, "a", first, i)
+	}
+	add(previous, "b", first, 10, 10)
+
+	// the saved queries survive a round trip through JSON, with the exact aggregates of their samples
+	buf, err := json.Marshal(previous.Queries().Save())
+	if err != nil {
+		t.Fatalf("cannot marshal the saved queries: %s", err)
+	}
+	var saved []SavedQuery
+	if err := json.Unmarshal(buf, &saved); err != nil {
+		t.Fatalf("cannot unmarshal the saved queries: %s", err)
+	}
+
+	s := New(fp)
+	s.SetLimits(limits)
+	for i := 51; i <= 100; i++ {
+		add(s, "a", first.Add(time.Hour), i)
+	}
+	add(s, "c", first.Add(time.Hour), 1)
+	s.Merge(saved)
+
+	queries := s.Queries()
+	if len(queries) != 3 || queries[0].Namespace != "test.a" || queries[1].Namespace != "test.c" ||
+		queries[2].ID != EvictedID || queries[2].Count != 2 {
+		t.Fatalf("got the queries %+v, want a, c and the evicted operations of b", queries)
+	}
+
+	qs := queries.CalcQueriesStats(1)
+	if qs[0].Count != 100 || qs[0].QueryTime.Total != 5050 || qs[0].QueryTime.Min != 1 || qs[0].QueryTime.Max != 100 {
+		t.Errorf("the aggregates of the merged queries are not exact: %+v", qs[0])
+	}
+	if len(queries[0].QueryTime) != 10 {
+		t.Errorf("kept %d values of a, want 10", len(queries[0].QueryTime))
+	}
+	if !queries[0].FirstSeen.Equal(first) || !queries[0].LastSeen.Equal(first.Add(time.Hour)) {
+		t.Errorf("a was seen from %s to %s", queries[0].FirstSeen, queries[0].LastSeen)
+	}
+	if !reflect.DeepEqual(queries[0].Shards, []string{"a-shard"}) {
+		t.Errorf("a ran on the shards %v, want a-shard", queries[0].Shards)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	fp := fingerprinter.NewFingerprinter(fingerprinter.DefaultKeyFilters())
+	s := New(fp)
+
+	insert := func(code int, name string) proto.SystemProfile {
+		return proto.SystemProfile{Ns: "test.users", Op: "insert", Millis: 1, ErrCode: code, ErrName: name}
+	}
+	for _, doc := range []proto.SystemProfile{
+		insert(0, ""),
+		insert(11000, ""), // the logs before 4.4 only record the code
+		insert(11000, "DuplicateKey"),
+		insert(50, "MaxTimeMSExpired"),
+		insert(0, "Interrupted"),
+	} {
+		if err := s.Add(doc); err != nil {
+			t.Fatalf("cannot add the operation: %s", err)
+		}
+	}
+
+	queries := s.Queries()
+	want := []ErrorCount{
+		{Code: 0, Name: "Interrupted", Count: 1},
+		{Code: 50, Name: "MaxTimeMSExpired", Count: 1},
+		{Code: 11000, Name: "DuplicateKey", Count: 2},
+	}
+	if len(queries) != 1 || !reflect.DeepEqual(queries[0].Errors, want) {
+		t.Fatalf("got the errors %+v, want %+v", queries, want)
+	}
+
+	qs := queries.CalcQueriesStats(1)
+	if qs[0].Failed != 4 || qs[0].Count != 5 {
+		t.Errorf("got %d failed operations out of %d, want 4 out of 5", qs[0].Failed, qs[0].Count)
+	}
+	// the statistics do not share the errors of the queries
+	qs[0].Errors[0].Count = 10
+	if totals := queries.CalcTotalQueriesStats(1); totals.Failed != 4 || !reflect.DeepEqual(totals.Errors, want) {
+		t.Errorf("got the total errors %+v, want %+v", totals.Errors, want)
+	}
+}
@@ -385,10 +385,17 @@ func GetQueryField(doc proto.SystemProfile) (primitive.M, error) {
 // GetClientForHost returns a new *mongo.Client using a copy of the original connection options where
 // the host is being replaced by the newHost and the connection is set to be direct to the instance.
 func GetClientForHost(co *options.ClientOptions, newHost string) (*mongo.Client, error) {
-	newOptions := options.MergeClientOptions(co, &options.ClientOptions{Hosts: []string{newHost}})
+	return mongo.NewClient(GetClientOptionsForHost(co, newHost))
+}
+
+// GetClientOptionsForHost returns a copy of the connection options for a direct connection to newHost.
+// The host is applied as a mongodb:// URI so that options created from a mongodb+srv:// seedlist, which
+// cannot be used for direct connections, keep their credentials and TLS settings.
+func GetClientOptionsForHost(co *options.ClientOptions, newHost string) *options.ClientOptions {
+	newOptions := options.MergeClientOptions(co, options.Client().ApplyURI("mongodb://"+newHost))
 	newOptions.SetDirect(true)
 
-	return mongo.NewClient(newOptions)
+	return newOptions
 }
 
 func GetHostInfo(ctx context.Context, client *mongo.Client) (*proto.GetHostInfo, error) {
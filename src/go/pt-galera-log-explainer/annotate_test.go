@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+)
+
+func TestAnnotatePost(t *testing.T) {
+	var received display.Annotation
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graph/api/annotations" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, `{"message":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"id":1,"message":"Annotation added"}`))
+	}))
+	defer server.Close()
+
+	annotation := display.Annotation{DashboardUID: "abc", Time: 1679174708000, Tags: []string{"galera", "crash", "node:node1"}, Text: "node1: crash: got signal 11"}
+
+	a := &annotate{GrafanaURL: server.URL + "/graph/", GrafanaToken: "secret"}
+	if err := a.post(server.Client(), annotation); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(annotation, received); diff != "" {
+		t.Errorf("unexpected annotation received (-want +got):\n%s", diff)
+	}
+
+	a.GrafanaToken = "wrong"
+	if err := a.post(server.Client(), annotation); err == nil {
+		t.Errorf("expected an error when grafana refuses the annotation")
+	}
+}
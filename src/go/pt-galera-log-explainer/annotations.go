@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+// annotationsNode is the pseudo-node column where user-supplied events are displayed
+const annotationsNode = "annotations"
+
+// annotationLayouts are the accepted timestamp formats, the ones accepted by --around plus the usual mysql formats
+var annotationLayouts = append([]string{"2006-01-02T15:04:05.000000Z", "2006-01-02T15:04:05"}, aroundLayouts...)
+
+// annotationsFromCSV reads "timestamp,text" lines and builds a timeline out of them
+// A header line is allowed, as long as its first field is not a timestamp
+func annotationsFromCSV(path string) (types.LocalTimeline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	logCtx := types.NewLogCtx()
+	logCtx.FilePath = path
+	regex := &types.LogRegex{
+		Type:      types.AnnotationRegexType,
+		Verbosity: types.Info,
+		Handler: func(_ map[string]string, logCtx types.LogCtx, _ string, _ time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, nil
+		},
+	}
+	// so that the column is considered as having visible events
+	logCtx, _ = regex.Handle(logCtx, "", time.Time{})
+
+	lt := types.LocalTimeline{}
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, errors.Errorf("%s:%d: expected 'timestamp,text'", path, line)
+		}
+
+		t, ok := parseAnnotationTime(record[0])
+		if !ok {
+			if line == 1 {
+				continue
+			}
+			return nil, errors.Errorf("%s:%d: could not parse timestamp %s, expected format: 2023-01-23T03:53:40Z or 2023-01-23 03:53:40", path, line, record[0])
+		}
+		if (CLI.Since != nil && CLI.Since.After(t)) || (CLI.Until != nil && CLI.Until.Before(t)) {
+			continue
+		}
+
+		// commas from unquoted texts are kept
+		text := strings.Join(record[1:], ",")
+		li := types.NewLogInfo(types.NewDate(t, "2006-01-02T15:04:05.000000Z"), types.SimpleDisplayer(utils.Paint(utils.MagentaText, text)),
+			strings.Join(record, ","), regex, "Annotation", logCtx, "")
+		lt = append(lt, li)
+	}
+
+	sort.SliceStable(lt, func(i, j int) bool { return lt[i].Date.Time.Before(lt[j].Date.Time) })
+	return lt, nil
+}
+
+func parseAnnotationTime(s string) (time.Time, bool) {
+	for _, layout := range annotationLayouts {
+		t, err := time.Parse(layout, strings.TrimSpace(s))
+		if err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+func TestAnnotationsFromCSV(t *testing.T) {
+	utils.SkipColor = true
+	defer func() { utils.SkipColor = false }()
+
+	path := filepath.Join(t.TempDir(), "annotations.csv")
+	err := os.WriteFile(path, []byte(`timestamp,event
+# network team window
+2023-03-18 22:00,network maintenance, switch 2
+2023-03-18T21:25:08+01:00,"deployment v1.2, canary"
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lt, err := annotationsFromCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type annotation struct {
+		Date time.Time
+		Msg  string
+		Type types.RegexType
+	}
+	got := []annotation{}
+	for _, li := range lt {
+		got = append(got, annotation{Date: li.Date.Time, Msg: li.Msg(li.LogCtx), Type: li.RegexType})
+	}
+	expected := []annotation{
+		{Date: time.Date(2023, 3, 18, 20, 25, 8, 0, time.UTC), Msg: "deployment v1.2, canary", Type: types.AnnotationRegexType},
+		{Date: time.Date(2023, 3, 18, 22, 0, 0, 0, time.UTC), Msg: "network maintenance,switch 2", Type: types.AnnotationRegexType},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected annotations (-want +got):\n%s", diff)
+	}
+
+	err = os.WriteFile(path, []byte("2023-03-18 22:00,ok\nyesterday,not ok\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := annotationsFromCSV(path); err == nil {
+		t.Errorf("expected an error for an invalid timestamp after the first line")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// anonymizer is shared by the whole run, so that pseudonyms stay the same across timelines (eg: diff)
+var anonymizer = utils.NewAnonymizer()
+
+func anonymizeTimeline(timeline types.Timeline) types.Timeline {
+	anonymizer.AddNames(translate.NodeNames()...)
+	for _, logCtx := range timeline.GetLatestContextsByNodes() {
+		anonymizer.AddNames(logCtx.OwnNames...)
+		// conflicts can involve nodes with no logs given
+		for _, conflict := range logCtx.Conflicts {
+			anonymizer.AddNames(conflict.InitiatedBy...)
+			nodes := make([]string, 0, len(conflict.VotePerNode))
+			for node := range conflict.VotePerNode {
+				nodes = append(nodes, node)
+			}
+			sort.Strings(nodes)
+			anonymizer.AddNames(nodes...)
+		}
+	}
+	return timeline.Anonymize(anonymizer)
+}
+
+// writeAnonymizeMapping writes every pseudonym used, so that the original values can be found back privately
+// It has to be called once everything is printed, as messages are anonymized when displayed
+func writeAnonymizeMapping(path string) error {
+	return writeFile(path, func(w io.Writer) error {
+		mapping := anonymizer.Mapping()
+		pseudonyms := make([]string, 0, len(mapping))
+		for p := range mapping {
+			pseudonyms = append(pseudonyms, p)
+		}
+		sort.Strings(pseudonyms)
+		for _, p := range pseudonyms {
+			if _, err := fmt.Fprintf(w, "%s\t%s\n", p, mapping[p]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// archiveFiles holds logs extracted from archives
+// the key is the extracted file path, the value is how it should be displayed: <archive>/<path in archive>
+type archiveFiles map[string]string
+
+var (
+	// extractedLogs maps the displayed path of every log extracted during the run to the extracted file
+	// so that browse and context can read events again from LogCtx.FilePath
+	extractedLogs = map[string]string{}
+	// extractedCleanups remove extracted files, once everything is displayed
+	extractedCleanups []func()
+)
+
+func isArchive(path string) bool {
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(strings.ToLower(path), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandArchives replaces archives, eg: support bundles, by the logs they contain
+// Logs are found using --archive-globs, and extracted to a temporary directory as they are read several times:
+// to detect their charset, to search them, then by browse and context to display the lines around events
+// The returned function removes extracted files
+func expandArchives(paths []string) ([]string, archiveFiles, func(), error) {
+	extracted := archiveFiles{}
+	cleanup := func() {}
+
+	var tmpdir string
+	expanded := []string{}
+	for _, path := range paths {
+		if !isArchive(path) {
+			expanded = append(expanded, path)
+			continue
+		}
+
+		if tmpdir == "" {
+			var err error
+			tmpdir, err = os.MkdirTemp("", toolname)
+			if err != nil {
+				return nil, nil, cleanup, errors.Wrap(err, "could not create a directory to extract archives")
+			}
+			cleanup = func() { os.RemoveAll(tmpdir) }
+		}
+
+		// each archive gets its own directory, bundles often have the same structure
+		dest, err := os.MkdirTemp(tmpdir, "")
+		if err != nil {
+			return nil, nil, cleanup, errors.Wrap(err, "could not create a directory to extract archive")
+		}
+
+		var files map[string]string
+		if strings.HasSuffix(strings.ToLower(path), ".zip") {
+			files, err = extractZip(path, dest)
+		} else {
+			files, err = extractTar(path, dest)
+		}
+		if err != nil {
+			return nil, nil, cleanup, errors.Wrapf(err, "could not extract %s", path)
+		}
+		if len(files) == 0 {
+			logger.Warn().Str("path", path).Strs("globs", CLI.ArchiveGlobs).Msg("no logs found in archive")
+		}
+
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			extracted[files[name]] = filepath.Join(path, name)
+			expanded = append(expanded, files[name])
+		}
+	}
+	return expanded, extracted, cleanup, nil
+}
+
+// keepUntilDisplayed registers extracted files, so that they can be read again until removeExtractedLogs is called
+func (a archiveFiles) keepUntilDisplayed(cleanup func()) {
+	for extracted, display := range a {
+		extractedLogs[display] = extracted
+	}
+	extractedCleanups = append(extractedCleanups, cleanup)
+}
+
+// removeExtractedLogs removes every file extracted during the run
+func removeExtractedLogs() {
+	for _, cleanup := range extractedCleanups {
+		cleanup()
+	}
+	extractedCleanups = nil
+	extractedLogs = map[string]string{}
+}
+
+// openDisplayedLog opens a log from the path shown to users, the one of LogCtx.FilePath
+func openDisplayedLog(path string) (io.ReadCloser, error) {
+	if extracted, ok := extractedLogs[path]; ok {
+		path = extracted
+	}
+	return openLog(path)
+}
+
+// displayPath is the path to show to users, extracted files are shown as they are in their archive
+func (a archiveFiles) displayPath(path string) string {
+	if display, ok := a[path]; ok {
+		return display
+	}
+	return path
+}
+
+func matchArchiveGlobs(name string) bool {
+	for _, glob := range CLI.ArchiveGlobs {
+		if ok, _ := filepath.Match(glob, filepath.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func extractTar(path, dest string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if !strings.HasSuffix(strings.ToLower(path), ".tar") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	files := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || !matchArchiveGlobs(header.Name) {
+			continue
+		}
+		name := archiveMemberName(header.Name)
+		file, err := extractFile(tr, filepath.Join(dest, name))
+		if err != nil {
+			return nil, err
+		}
+		files[name] = file
+	}
+}
+
+func extractZip(path, dest string) (map[string]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	files := map[string]string{}
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() || !matchArchiveGlobs(zf.Name) {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		name := archiveMemberName(zf.Name)
+		file, err := extractFile(r, filepath.Join(dest, name))
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[name] = file
+	}
+	return files, nil
+}
+
+// archiveMemberName cleans the path as an absolute one first, so that "../" cannot escape the destination
+func archiveMemberName(name string) string {
+	return strings.TrimPrefix(filepath.Clean("/"+name), "/")
+}
+
+func extractFile(r io.Reader, path string) (string, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return path, err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var archiveTestFiles = map[string]string{
+	"node1/var/log/mysqld.log": "node1 log",
+	"node2/hostname.err":       "node2 log",
+	"node2/my.cnf":             "not a log",
+	"../escape/error.log":      "should stay in the destination",
+}
+
+func TestExpandArchives(t *testing.T) {
+	CLI.ArchiveGlobs = []string{"*.err", "*error.log*", "*mysqld.log*"}
+	dir := t.TempDir()
+
+	tarPath := filepath.Join(dir, "bundle.tar.gz")
+	f, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range archiveTestFiles {
+		err = tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	zipPath := filepath.Join(dir, "bundle.zip")
+	f, err = os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range archiveTestFiles {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	zw.Close()
+	f.Close()
+
+	paths, archives, cleanup, err := expandArchives([]string{"plain.log", tarPath, zipPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	displayed := []string{}
+	for _, path := range paths {
+		displayed = append(displayed, archives.displayPath(path))
+	}
+	sort.Strings(displayed)
+
+	expected := []string{
+		filepath.Join(tarPath, "escape/error.log"),
+		filepath.Join(tarPath, "node1/var/log/mysqld.log"),
+		filepath.Join(tarPath, "node2/hostname.err"),
+		filepath.Join(zipPath, "escape/error.log"),
+		filepath.Join(zipPath, "node1/var/log/mysqld.log"),
+		filepath.Join(zipPath, "node2/hostname.err"),
+		"plain.log",
+	}
+	if !reflect.DeepEqual(displayed, expected) {
+		t.Errorf("expected %v, got %v", expected, displayed)
+	}
+
+	content, err := os.ReadFile(paths[len(paths)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "node2 log" {
+		t.Errorf("unexpected content for %s: %s", paths[len(paths)-1], content)
+	}
+
+	// extracted logs are read again from the path displayed, eg: by context
+	archives.keepUntilDisplayed(cleanup)
+	r, err := openDisplayedLog(filepath.Join(zipPath, "node2/hostname.err"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err = io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "node2 log" {
+		t.Errorf("unexpected content read from the displayed path: %s", content)
+	}
+
+	removeExtractedLogs()
+	for path := range archives {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", path)
+		}
+	}
+}
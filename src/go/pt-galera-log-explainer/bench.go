@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+type bench struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+	Top   int      `default:"10" help:"Number of slowest regexes to list"`
+}
+
+func (b *bench) Help() string {
+	return fmt.Sprintf(`Measure how fast logs are parsed
+	It reports lines per second for each file, how long grep and regex handlers took, the time spent per regex category,
+	the slowest regexes and the memory high-water mark. Useful to size runs on huge bundles, and to catch slow regexes
+
+Usage:
+	%[1]s bench *.log
+	%[1]s bench --top 20 *.log
+	`, toolname)
+}
+
+// benchFile is the parsing cost of a single log
+type benchFile struct {
+	path     string
+	bytes    int64
+	lines    int
+	matched  int
+	grep     time.Duration
+	handling time.Duration
+}
+
+// benchRegex is the cost of a regex, or of a category of regexes, on lines found by grep
+type benchRegex struct {
+	name    string
+	calls   int
+	matches int
+	time    time.Duration
+}
+
+func (b *bench) Run() error {
+	if b.Top < 0 {
+		return errors.New("--top cannot be negative")
+	}
+
+	// the complete analysis first, so that the memory high-water mark is the one of usual runs
+	sampler := newMemorySampler(10 * time.Millisecond)
+	start := time.Now()
+	_, err := timelineFromPaths(b.Paths, regex.AllRegexes())
+	total := time.Since(start)
+	peakHeap, sys := sampler.stop()
+	if err != nil {
+		return errors.Wrap(err, "could not benchmark")
+	}
+
+	paths, err := expandRemotes(b.Paths)
+	if err != nil {
+		return err
+	}
+	if CLI.RotatedDiscovery {
+		paths, _ = discoverRotatedFiles(paths)
+	}
+	paths, archives, cleanup, err := expandArchives(paths)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	regexes := regex.AllRegexes()
+	compiledRegex := prepareGrepArgument(regexes)
+	byRegex := map[string]*benchRegex{}
+	files := []benchFile{}
+	for _, path := range paths {
+		file, err := benchLog(path, compiledRegex, regexes, byRegex)
+		if err != nil {
+			return errors.Wrapf(err, "could not benchmark %s", path)
+		}
+		file.path = archives.displayPath(path)
+		files = append(files, file)
+	}
+
+	return printBench(os.Stdout, files, byRegex, regexes, b.Top, total, peakHeap, sys)
+}
+
+// benchLog measures the time grep takes to search a log, and the time regex handlers take on lines found
+// Lines are counted separately, so that reading files twice does not distort grep durations
+func benchLog(path, compiledRegex string, regexes types.RegexMap, byRegex map[string]*benchRegex) (benchFile, error) {
+	file := benchFile{}
+	r, err := openLog(path)
+	if err != nil {
+		return file, err
+	}
+	file.bytes, file.lines, err = countLines(r)
+	r.Close()
+	if err != nil {
+		return file, err
+	}
+
+	stdout := make(chan string)
+	errc := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		errc <- execGrepAndIterate(path, compiledRegex, stdout)
+		close(stdout)
+	}()
+	lines := []string{}
+	for line := range stdout {
+		lines = append(lines, line)
+	}
+	if err := <-errc; err != nil {
+		return file, err
+	}
+	file.grep = time.Since(start)
+	file.matched = len(lines)
+
+	// same as iterateOnGrepResults, timing each regex
+	logCtx := types.NewLogCtx()
+	logCtx.FilePath = path
+	keys := regexes.SortedKeys()
+	var timestamp time.Time
+	start = time.Now()
+	for _, line := range lines {
+		line = utils.SanitizeLine(line)
+		if t, _, ok := regex.SearchDateFromLog(line); ok {
+			timestamp = t
+		}
+		logCtx.FileType = regex.FileType(line, CLI.PxcOperator)
+		for _, key := range keys {
+			stat, ok := byRegex[key]
+			if !ok {
+				stat = &benchRegex{name: key}
+				byRegex[key] = stat
+			}
+			regexStart := time.Now()
+			stat.calls++
+			if regexes[key].Regex.MatchString(line) {
+				stat.matches++
+				logCtx, _ = regexes[key].Handle(logCtx, line, timestamp)
+			}
+			stat.time += time.Since(regexStart)
+		}
+	}
+	file.handling = time.Since(start)
+	return file, nil
+}
+
+func countLines(r io.Reader) (int64, int, error) {
+	buf := make([]byte, 64*1024)
+	var size int64
+	lines := 0
+	for {
+		n, err := r.Read(buf)
+		size += int64(n)
+		for _, c := range buf[:n] {
+			if c == '\n' {
+				lines++
+			}
+		}
+		if err == io.EOF {
+			return size, lines, nil
+		}
+		if err != nil {
+			return size, lines, err
+		}
+	}
+}
+
+// memorySampler tracks the highest heap usage, the go runtime only gives the current one
+type memorySampler struct {
+	done chan struct{}
+	wg   sync.WaitGroup
+	peak uint64
+}
+
+func newMemorySampler(interval time.Duration) *memorySampler {
+	s := &memorySampler{done: make(chan struct{})}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.sample()
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+func (s *memorySampler) sample() runtime.MemStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	if m.HeapAlloc > s.peak {
+		s.peak = m.HeapAlloc
+	}
+	return m
+}
+
+// stop returns the highest heap usage seen, and the memory obtained from the OS
+func (s *memorySampler) stop() (uint64, uint64) {
+	close(s.done)
+	s.wg.Wait()
+	m := s.sample()
+	return s.peak, m.Sys
+}
+
+func printBench(out io.Writer, files []benchFile, byRegex map[string]*benchRegex, regexes types.RegexMap, top int, total time.Duration, peakHeap, sys uint64) error {
+	w := tabwriter.NewWriter(out, 8, 8, 3, ' ', 0)
+
+	fmt.Fprintln(w, "file\tsize\tlines\tlines/s\tgrep matches\tgrep\thandlers\t")
+	var totalFile benchFile
+	for _, f := range files {
+		fmt.Fprintln(w, benchFileRow(f))
+		totalFile.bytes += f.bytes
+		totalFile.lines += f.lines
+		totalFile.matched += f.matched
+		totalFile.grep += f.grep
+		totalFile.handling += f.handling
+	}
+	if len(files) > 1 {
+		totalFile.path = "total"
+		fmt.Fprintln(w, benchFileRow(totalFile))
+	}
+	fmt.Fprintln(w)
+
+	// categories are regex types, the same ones --show and --hide use
+	byCategory := map[types.RegexType]*benchRegex{}
+	var handlersTime time.Duration
+	for key, stat := range byRegex {
+		category := regexes[key].Type
+		if _, ok := byCategory[category]; !ok {
+			byCategory[category] = &benchRegex{name: string(category)}
+		}
+		byCategory[category].calls += stat.calls
+		byCategory[category].matches += stat.matches
+		byCategory[category].time += stat.time
+		handlersTime += stat.time
+	}
+	categories := make([]*benchRegex, 0, len(byCategory))
+	for _, stat := range byCategory {
+		categories = append(categories, stat)
+	}
+	fmt.Fprintln(w, "category\tcalls\tmatches\ttime\tshare\t")
+	for _, stat := range sortBenchRegexes(categories) {
+		fmt.Fprintln(w, benchRegexRow(stat, handlersTime))
+	}
+	fmt.Fprintln(w)
+
+	stats := make([]*benchRegex, 0, len(byRegex))
+	for _, stat := range byRegex {
+		stats = append(stats, stat)
+	}
+	stats = sortBenchRegexes(stats)
+	if len(stats) > top {
+		stats = stats[:top]
+	}
+	if len(stats) > 0 {
+		fmt.Fprintln(w, "slowest regexes\tcalls\tmatches\ttime\tshare\t")
+		for _, stat := range stats {
+			fmt.Fprintln(w, benchRegexRow(stat, handlersTime))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "complete analysis\t%s\t\n", total.Round(time.Millisecond))
+	fmt.Fprintf(w, "heap high-water mark\t%s\t\n", humanBytes(int64(peakHeap)))
+	fmt.Fprintf(w, "memory obtained from the OS\t%s\t\n", humanBytes(int64(sys)))
+	return w.Flush()
+}
+
+func benchFileRow(f benchFile) string {
+	linesPerSec := "-"
+	if f.grep > 0 {
+		linesPerSec = strconv.Itoa(int(float64(f.lines) / f.grep.Seconds()))
+	}
+	return fmt.Sprintf("%s\t%s\t%d\t%s\t%d\t%s\t%s\t", f.path, humanBytes(f.bytes), f.lines, linesPerSec, f.matched, f.grep.Round(time.Millisecond), f.handling.Round(time.Microsecond))
+}
+
+func benchRegexRow(stat *benchRegex, total time.Duration) string {
+	share := 0.0
+	if total > 0 {
+		share = float64(stat.time) / float64(total) * 100
+	}
+	return fmt.Sprintf("%s\t%d\t%d\t%s\t%.1f%%\t", stat.name, stat.calls, stat.matches, stat.time.Round(time.Microsecond), share)
+}
+
+// sortBenchRegexes sorts the slowest first, names break ties so that outputs are stable
+func sortBenchRegexes(stats []*benchRegex) []*benchRegex {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].time != stats[j].time {
+			return stats[i].time > stats[j].time
+		}
+		return stats[i].name < stats[j].name
+	})
+	return stats
+}
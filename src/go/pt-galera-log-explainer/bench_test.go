@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+)
+
+func TestCountLines(t *testing.T) {
+	size, lines, err := countLines(strings.NewReader("line1\nline2\nline3 without newline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 33 || lines != 2 {
+		t.Errorf("expected 33 bytes and 2 lines, got %d bytes and %d lines", size, lines)
+	}
+}
+
+func TestBenchLog(t *testing.T) {
+	grepCmd := CLI.GrepCmd
+	CLI.GrepCmd = "grep"
+	defer func() { CLI.GrepCmd = grepCmd }()
+
+	paths, err := writeFixture(t.TempDir(), "sst", 3, time.Date(2023, 3, 12, 7, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	regexes := regex.AllRegexes()
+	byRegex := map[string]*benchRegex{}
+	file, err := benchLog(paths[0], prepareGrepArgument(regexes), regexes, byRegex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.lines == 0 || file.matched == 0 || file.matched > file.lines {
+		t.Fatalf("unexpected counts: %d lines, %d matched", file.lines, file.matched)
+	}
+	if len(byRegex) != len(regexes) {
+		t.Errorf("expected every regex to be measured, got %d out of %d", len(byRegex), len(regexes))
+	}
+	if stat := byRegex["RegexSSTRequestSuccess"]; stat == nil || stat.calls != file.matched || stat.matches == 0 {
+		t.Errorf("unexpected RegexSSTRequestSuccess measure: %+v", stat)
+	}
+
+	var out bytes.Buffer
+	if err := printBench(&out, []benchFile{file}, byRegex, regexes, 3, time.Second, 1024, 2048); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"category", "sst", "slowest regexes", "heap high-water mark", "1.0KiB"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in output:\n%s", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type browse struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+}
+
+func (b *browse) Help() string {
+	return fmt.Sprintf(`Browse the timeline interactively, instead of scrolling through a very wide output
+	Keys:
+		up/down/pgup/pgdn: scroll
+		v/V: jump to the next/previous view change
+		s/S: jump to the next/previous SST
+		/: filter events using a regex
+		1-9: collapse/expand a node column
+		enter: open the raw log lines around an event
+		q: quit
+
+Usage:
+	%[1]s browse *.log
+	%[1]s -v --since 2023-03-12T19:41:28Z browse *.log
+	`, toolname)
+}
+
+func (b *browse) Run() error {
+
+	timeline, err := timelineFromPaths(b.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not browse timeline")
+	}
+
+	return display.TimelineTUI(timeline, CLI.Verbosity)
+}
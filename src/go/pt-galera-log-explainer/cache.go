@@ -0,0 +1,169 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/pkg/errors"
+)
+
+// to bump whenever cacheEntry or the way lines are searched changes
+const cacheFormatVersion = 2
+
+// cacheEntry is what is stored on disk for a single log file
+//
+// Only the lines grep found are stored, not the parsed LocalTimeline:
+// handlers have to be applied again on each run because messages are closures
+// depending on the translation maps, which are built from every files given.
+// The expensive part is scanning gigabytes of logs, not handling the few lines that matched
+type cacheEntry struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	GrepArg string
+	// given with --charset, "auto" otherwise. Detected charsets do not change as long as files do not
+	Charset charset
+	Lines   []string
+}
+
+func newCacheEntry(path, grepArg string) (cacheEntry, error) {
+	cs, err := charsetOf(path, CLI.Charset)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	if file, ok := remoteFiles[path]; ok {
+		return cacheEntry{Path: file.URL, Size: file.Size, ModTime: file.ModTime, GrepArg: grepArg, Charset: cs}, nil
+	}
+	abspath, err := filepath.Abs(path)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	osinfo, err := os.Stat(abspath)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	return cacheEntry{Path: abspath, Size: osinfo.Size(), ModTime: osinfo.ModTime(), GrepArg: grepArg, Charset: cs}, nil
+}
+
+func (c cacheEntry) key() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s\n%d\n%d\n%s\n%s", cacheFormatVersion, c.Path, c.Size, c.ModTime.UnixNano(), c.GrepArg, c.Charset)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c cacheEntry) matches(c2 cacheEntry) bool {
+	return c.Path == c2.Path && c.Size == c2.Size && c.ModTime.Equal(c2.ModTime) && c.GrepArg == c2.GrepArg && c.Charset == c2.Charset
+}
+
+func cacheFilePath(dir string, entry cacheEntry) string {
+	return filepath.Join(dir, entry.key()+".gob.gz")
+}
+
+// loadCache returns the lines stored for this entry
+// Any error is treated as a cache miss, the file will simply be scanned again
+func loadCache(dir string, entry cacheEntry) ([]string, bool) {
+	f, err := os.Open(cacheFilePath(dir, entry))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, false
+	}
+	defer gz.Close()
+
+	stored := cacheEntry{}
+	if err := gob.NewDecoder(gz).Decode(&stored); err != nil {
+		logger.Debug().Str("path", entry.Path).Err(err).Msg("could not decode cache")
+		return nil, false
+	}
+	if !stored.matches(entry) {
+		return nil, false
+	}
+	return stored.Lines, true
+}
+
+func storeCache(dir string, entry cacheEntry) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return errors.Wrap(err, "could not create cache directory")
+	}
+
+	// written to a temporary file first, so that concurrent runs never read a partial cache
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "could not create cache file")
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	if err := gob.NewEncoder(gz).Encode(entry); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "could not encode cache")
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "could not compress cache")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "could not write cache file")
+	}
+	return os.Rename(tmp.Name(), cacheFilePath(dir, entry))
+}
+
+// cacheGrepArgument builds a grep argument with every regexes and no date filtering
+// so that a single cache can be shared between subcommands, list filters and --since values.
+// Lines that are not needed for the current run are filtered out later by iterateOnGrepResults
+func cacheGrepArgument() string {
+	regexes := types.RegexMap{}
+	regexes.Merge(regex.IdentsMap).Merge(regex.ViewsMap).Merge(regex.SSTMap).Merge(regex.EventsMap).Merge(regex.StatesMap).Merge(regex.ApplicativeMap)
+	return grepArgument(regexes, nil)
+}
+
+// cachedGrepAndIterate behaves like execGrepAndIterate, but will reuse
+// the lines found by a previous run if the file did not change since
+func cachedGrepAndIterate(path string, stdout chan<- string) error {
+	entry, err := newCacheEntry(path, cacheGrepArgument())
+	if err != nil {
+		return err
+	}
+
+	if lines, ok := loadCache(CLI.CacheDir, entry); ok {
+		logger.Debug().Str("path", path).Int("lines", len(lines)).Msg("using cached grep results")
+		for _, line := range lines {
+			stdout <- line
+		}
+		return nil
+	}
+
+	lines := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- execGrepAndIterate(path, entry.GrepArg, lines)
+		close(lines)
+	}()
+
+	for line := range lines {
+		entry.Lines = append(entry.Lines, line)
+		stdout <- line
+	}
+	if err := <-errc; err != nil {
+		return err
+	}
+
+	// not being able to cache should never prevent from getting results
+	if err := storeCache(CLI.CacheDir, entry); err != nil {
+		logger.Warn().Str("path", path).Err(err).Msg("could not store grep results in cache")
+	}
+	return nil
+}
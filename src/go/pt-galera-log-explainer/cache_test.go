@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	stored := cacheEntry{Path: "/var/log/mysql/error.log", Size: 1024, ModTime: now, GrepArg: "^.*(started)", Lines: []string{"line1", "line2"}}
+	err := storeCache(dir, stored)
+	if err != nil {
+		t.Fatalf("failed to store cache: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		entry         cacheEntry
+		expectedFound bool
+	}{
+		{
+			name:          "unchanged",
+			entry:         cacheEntry{Path: "/var/log/mysql/error.log", Size: 1024, ModTime: now, GrepArg: "^.*(started)"},
+			expectedFound: true,
+		},
+		{
+			name:  "grown file",
+			entry: cacheEntry{Path: "/var/log/mysql/error.log", Size: 2048, ModTime: now, GrepArg: "^.*(started)"},
+		},
+		{
+			name:  "touched file",
+			entry: cacheEntry{Path: "/var/log/mysql/error.log", Size: 1024, ModTime: now.Add(time.Second), GrepArg: "^.*(started)"},
+		},
+		{
+			name:  "other regexes",
+			entry: cacheEntry{Path: "/var/log/mysql/error.log", Size: 1024, ModTime: now, GrepArg: "^.*(stopped)"},
+		},
+	}
+
+	for _, test := range tests {
+		lines, found := loadCache(dir, test.entry)
+		if found != test.expectedFound {
+			t.Fatalf("%s: expected found=%v, got %v", test.name, test.expectedFound, found)
+		}
+		if found && !cmp.Equal(lines, stored.Lines) {
+			t.Fatalf("%s: unexpected lines: %s", test.name, cmp.Diff(stored.Lines, lines))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+var regexTypes = []types.RegexType{
+	types.EventsRegexType,
+	types.SSTRegexType,
+	types.ViewsRegexType,
+	types.IdentRegexType,
+	types.StatesRegexType,
+	types.PXCOperatorRegexType,
+	types.ApplicativeRegexType,
+}
+
+// regexesWithCategories applies --show and --hide: shown categories are displayed whatever the verbosity,
+// hidden ones are never displayed. A category is either a regex type or a regex name, names taking precedence
+// Events less severe than minSeverity are hidden too, unless their category is explicitly shown
+// Regexes are copied, hidden events are still used to build contexts
+func regexesWithCategories(regexes types.RegexMap, show, hide []string, minSeverity types.Severity) (types.RegexMap, error) {
+	for _, category := range append(append([]string{}, show...), hide...) {
+		if !isCategory(category) {
+			return nil, errors.Errorf("unknown category %q, expected a regex type (%s) or a regex name listed by 'regex-list'", category, strings.Join(regexTypeNames(), ", "))
+		}
+	}
+	if len(show) == 0 && len(hide) == 0 && minSeverity == types.SeverityInfo {
+		return regexes, nil
+	}
+
+	out := make(types.RegexMap, len(regexes))
+	for key, r := range regexes {
+		copied := *r
+		switch {
+		case utils.SliceContains(hide, key):
+			copied.Verbosity = types.Hidden
+		case utils.SliceContains(show, key):
+			copied.Verbosity = types.Info
+		case utils.SliceContains(hide, string(r.Type)):
+			copied.Verbosity = types.Hidden
+		case utils.SliceContains(show, string(r.Type)):
+			copied.Verbosity = types.Info
+		case r.Severity < minSeverity:
+			copied.Verbosity = types.Hidden
+		}
+		out[key] = &copied
+	}
+	return out, nil
+}
+
+func isCategory(category string) bool {
+	if utils.SliceContains(regexTypeNames(), category) {
+		return true
+	}
+	if _, ok := regex.AllRegexes()[category]; ok {
+		return true
+	}
+	_, ok := regex.PXCOperatorMap[category]
+	return ok
+}
+
+func regexTypeNames() []string {
+	names := make([]string, 0, len(regexTypes))
+	for _, t := range regexTypes {
+		names = append(names, string(t))
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestRegexesWithCategories(t *testing.T) {
+	tests := []struct {
+		name        string
+		show        []string
+		hide        []string
+		minSeverity types.Severity
+		expected    map[string]types.Verbosity
+		expectedErr bool
+	}{
+		{
+			name: "no categories",
+			expected: map[string]types.Verbosity{
+				"RegexFlowControl":           types.DebugMySQL,
+				"RegexXtrabackupISTReceived": types.DebugMySQL,
+				"RegexSourceNode":            types.DebugMySQL,
+			},
+		},
+		{
+			name: "show a type, hide a regex",
+			show: []string{"sst"},
+			hide: []string{"RegexFlowControl"},
+			expected: map[string]types.Verbosity{
+				"RegexFlowControl":           types.Hidden,
+				"RegexXtrabackupISTReceived": types.Info,
+				"RegexSourceNode":            types.DebugMySQL,
+			},
+		},
+		{
+			name: "regex names take precedence over types",
+			show: []string{"RegexXtrabackupISTReceived"},
+			hide: []string{"sst"},
+			expected: map[string]types.Verbosity{
+				"RegexFlowControl":           types.DebugMySQL,
+				"RegexXtrabackupISTReceived": types.Info,
+				"RegexSourceNode":            types.DebugMySQL,
+			},
+		},
+		{
+			name:        "min severity",
+			show:        []string{"identity"},
+			minSeverity: types.SeverityWarning,
+			expected: map[string]types.Verbosity{
+				"RegexFlowControl":           types.DebugMySQL,
+				"RegexXtrabackupISTReceived": types.Hidden,
+				"RegexSourceNode":            types.Info,
+			},
+		},
+		{
+			name:        "unknown category",
+			hide:        []string{"flowcontrol"},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		regexes := types.RegexMap{
+			"RegexFlowControl":           regex.ApplicativeMap["RegexFlowControl"],
+			"RegexXtrabackupISTReceived": regex.SSTMap["RegexXtrabackupISTReceived"],
+			"RegexSourceNode":            regex.IdentsMap["RegexSourceNode"],
+		}
+
+		out, err := regexesWithCategories(regexes, test.show, test.hide, test.minSeverity)
+		if (err != nil) != test.expectedErr {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		for key, expected := range test.expected {
+			if out[key].Verbosity != expected {
+				t.Errorf("%s: %s: expected verbosity %d, got %d", test.name, key, expected, out[key].Verbosity)
+			}
+		}
+	}
+
+	// regexes are shared between subcommands, they must be left untouched
+	if regex.ApplicativeMap["RegexFlowControl"].Verbosity != types.DebugMySQL {
+		t.Errorf("original regex was modified")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// charset of a log file, logs are expected in utf-8
+type charset string
+
+const (
+	charsetAuto    charset = "auto"
+	charsetUTF8    charset = "utf-8"
+	charsetLatin1  charset = "latin1"
+	charsetUTF16LE charset = "utf-16le"
+	charsetUTF16BE charset = "utf-16be"
+)
+
+var charsets = []charset{charsetAuto, charsetUTF8, charsetLatin1, charsetUTF16LE, charsetUTF16BE}
+
+// charsetDetectionSize is how much of a log is read to guess its charset
+const charsetDetectionSize = 64 * 1024
+
+// charsetOf returns the charset given with --charset for a log, "auto" when none was given
+// --charset values are formatted as [<path>:]<charset>, path being a glob matched against the path or the file name
+func charsetOf(path string, flags []string) (charset, error) {
+	cs := charsetAuto
+	for _, flag := range flags {
+		pattern, name := "", flag
+		if i := strings.LastIndex(flag, ":"); i >= 0 {
+			pattern, name = flag[:i], flag[i+1:]
+		}
+		c, err := parseCharset(name)
+		if err != nil {
+			return cs, err
+		}
+		if pattern == "" {
+			cs = c
+			continue
+		}
+		if pattern == path {
+			return c, nil
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return c, nil
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return c, nil
+		}
+	}
+	return cs, nil
+}
+
+func parseCharset(name string) (charset, error) {
+	name = strings.ToLower(name)
+	switch name {
+	case "utf8":
+		return charsetUTF8, nil
+	case "cp1252", "windows-1252", "iso-8859-1":
+		return charsetLatin1, nil
+	}
+	for _, c := range charsets {
+		if charset(name) == c {
+			return c, nil
+		}
+	}
+	names := make([]string, len(charsets))
+	for i, c := range charsets {
+		names[i] = string(c)
+	}
+	return "", errors.Errorf("unknown charset %s, expected one of: %s", name, strings.Join(names, ", "))
+}
+
+// detectCharset guesses the charset from the beginning of a log
+// Bundles collected on Windows are often utf-16 with a BOM, logs forwarded by some collectors are re-encoded in latin1
+// NUL bytes without a utf-16 pattern are left alone: they are usually left by crashes, see 'unparseable'
+func detectCharset(head []byte) charset {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		return charsetUTF16LE
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		return charsetUTF16BE
+	}
+
+	// without BOM, ascii characters in utf-16 have a NUL byte either before or after them
+	if len(head) >= 16 {
+		even, odd := 0, 0
+		for i := 0; i+1 < len(head); i += 2 {
+			if head[i] == 0 {
+				even++
+			}
+			if head[i+1] == 0 {
+				odd++
+			}
+		}
+		pairs := len(head) / 2
+		switch {
+		case odd > pairs*3/4 && even == 0:
+			return charsetUTF16LE
+		case even > pairs*3/4 && odd == 0:
+			return charsetUTF16BE
+		}
+	}
+
+	if bytes.IndexByte(head, 0) >= 0 {
+		return charsetUTF8
+	}
+	// the head could end in the middle of a character
+	for i := 1; i < utf8.UTFMax && i <= len(head); i++ {
+		if utf8.RuneStart(head[len(head)-i]) {
+			if !utf8.FullRune(head[len(head)-i:]) {
+				head = head[:len(head)-i]
+			}
+			break
+		}
+	}
+	if !utf8.Valid(head) {
+		return charsetLatin1
+	}
+	return charsetUTF8
+}
+
+// decodeLog transcodes a log to utf-8 so that regexes can match
+func decodeLog(r io.Reader, cs charset) io.Reader {
+	switch cs {
+	case charsetLatin1:
+		// mysql latin1 is actually cp1252
+		return transform.NewReader(r, charmap.Windows1252.NewDecoder())
+	case charsetUTF16LE:
+		return transform.NewReader(r, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder())
+	case charsetUTF16BE:
+		return transform.NewReader(r, unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder())
+	}
+	return r
+}
+
+// logCharset returns the charset of a log, reading its beginning when it was not given with --charset
+func logCharset(path string) (charset, error) {
+	cs, err := charsetOf(path, CLI.Charset)
+	if err != nil || cs != charsetAuto {
+		return cs, err
+	}
+	// reading remote logs twice would be too costly, --charset has to be used
+	if isRemote(path) {
+		return charsetUTF8, nil
+	}
+
+	r, err := openUndecodedLog(path)
+	if err != nil {
+		return cs, err
+	}
+	defer r.Close()
+	head := make([]byte, charsetDetectionSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return cs, err
+	}
+	cs = detectCharset(head[:n])
+	if cs != charsetUTF8 {
+		logger.Debug().Str("path", path).Str("charset", string(cs)).Msg("log will be transcoded to utf-8")
+	}
+	return cs, nil
+}
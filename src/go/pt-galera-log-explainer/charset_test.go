@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestDetectCharset(t *testing.T) {
+	log := "2023-03-12T07:24:13.733958Z 0 [Note] WSREP: Synchronized with group, ready for connections\n"
+	utf16le, _ := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().String(log)
+	utf16be, _ := unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewEncoder().String(log)
+	latin1, _ := charmap.Windows1252.NewEncoder().String("/var/lib/données " + log)
+
+	tests := []struct {
+		name     string
+		head     string
+		expected charset
+	}{
+		{name: "utf-8", head: "/var/lib/données " + log, expected: charsetUTF8},
+		{name: "utf-8 cut in the middle of a character", head: "/var/lib/donn\xc3", expected: charsetUTF8},
+		{name: "utf-16le BOM", head: "\xff\xfe" + utf16le, expected: charsetUTF16LE},
+		{name: "utf-16be BOM", head: "\xfe\xff" + utf16be, expected: charsetUTF16BE},
+		{name: "utf-16le without BOM", head: utf16le, expected: charsetUTF16LE},
+		{name: "utf-16be without BOM", head: utf16be, expected: charsetUTF16BE},
+		{name: "latin1", head: latin1, expected: charsetLatin1},
+		{name: "crash NUL bytes", head: log + "\x00\x00\x00\x00\xe9" + log, expected: charsetUTF8},
+	}
+	for _, test := range tests {
+		if cs := detectCharset([]byte(test.head)); cs != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, cs)
+		}
+	}
+}
+
+func TestCharsetOf(t *testing.T) {
+	flags := []string{"latin1", "node3*:utf-16le", "/logs/node2.err:utf-16be"}
+	tests := map[string]charset{
+		"/logs/node1.err": charsetLatin1,
+		"/logs/node2.err": charsetUTF16BE,
+		"/logs/node3.err": charsetUTF16LE,
+	}
+	for path, expected := range tests {
+		cs, err := charsetOf(path, flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cs != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, cs)
+		}
+	}
+
+	if _, err := charsetOf("/logs/node1.err", []string{"ebcdic"}); err == nil {
+		t.Errorf("expected an error for unknown charsets")
+	}
+}
+
+func TestExecGrepAndIterateCharsets(t *testing.T) {
+	grepCmd := CLI.GrepCmd
+	CLI.GrepCmd = "grep"
+	defer func() { CLI.GrepCmd = grepCmd }()
+
+	log := "2023-03-12T07:24:13.733958Z 0 [System] [MY-010116] [Server] /usr/sbin/mysqld (mysqld 8.0.28-19.1) starting as process 1 from /var/lib/données"
+	utf16le, _ := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String(log + "\n")
+	latin1, _ := charmap.Windows1252.NewEncoder().String(log + "\n")
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"utf-8.log": log + "\n", "utf-16le.log": utf16le, "latin1.log": latin1} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := make(chan string, 10)
+		err := execGrepAndIterate(path, "starting as process", lines)
+		close(lines)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		out := []string{}
+		for line := range lines {
+			out = append(out, line)
+		}
+		if !cmp.Equal(out, []string{log}) {
+			t.Errorf("%s: %s", name, cmp.Diff([]string{log}, out))
+		}
+	}
+}
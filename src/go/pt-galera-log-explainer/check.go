@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// exit code used when a --check condition was met, 1 being used for errors
+const checkFailedExitCode = 2
+
+// checkConditions are the conditions --check can look for
+var checkConditions = map[string]func(types.LogInfo) bool{
+	"crash": func(li types.LogInfo) bool {
+		return utils.SliceContains([]string{"RegexGotSignal6", "RegexGotSignal11", "RegexAssertionFailure"}, li.RegexUsed)
+	},
+	"non-primary": func(li types.LogInfo) bool {
+		return li.RegexUsed == "RegexWsrepNonPrimary" || (li.RegexUsed == "RegexNewComponent" && li.LogCtx.State() == "NON-PRIMARY")
+	},
+	"sst-failure": func(li types.LogInfo) bool {
+		return utils.SliceContains([]string{"RegexSSTError", "RegexSSTStateTransferFailed", "RegexSSTFailedUnknown", "RegexISTFailed", "RegexFailedToPrepareIST"}, li.RegexUsed)
+	},
+	"eviction": func(li types.LogInfo) bool {
+		return li.RegexUsed == "RegexNodeEvicted"
+	},
+}
+
+// checkFailures are the conditions met, accumulated over every timelines built during the run
+var checkFailures []string
+
+// checkTimeline reports the first event matching each condition, for each node
+func checkTimeline(timeline types.Timeline, conditions []string) []string {
+	failures := []string{}
+
+	nodes := make([]string, 0, len(timeline))
+	for node := range timeline {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, condition := range conditions {
+		for _, node := range nodes {
+			for _, li := range timeline[node] {
+				if !checkConditions[condition](li) {
+					continue
+				}
+				date := ""
+				if li.Date != nil {
+					date = li.Date.DisplayTime + " "
+				}
+				failures = append(failures, fmt.Sprintf("%s found on %s: %s%s", condition, node, date, utils.RemoveColor(li.RawMsg(li.LogCtx))))
+				break
+			}
+		}
+	}
+	return failures
+}
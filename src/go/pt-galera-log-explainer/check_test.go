@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestCheckTimeline(t *testing.T) {
+	layout := "2006-01-02T15:04:05.000000Z"
+	date := time.Date(2023, 3, 18, 21, 25, 8, 0, time.UTC)
+
+	nonPrimaryCtx := types.NewLogCtx()
+	nonPrimaryCtx.SetState("NON-PRIMARY")
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			types.NewLogInfo(types.NewDate(date, layout), types.SimpleDisplayer("starting(8.0.28)"), "", &types.LogRegex{}, "RegexStarting", types.NewLogCtx(), "error.log"),
+			types.NewLogInfo(types.NewDate(date.Add(time.Minute), layout), types.SimpleDisplayer("crash: got signal 11"), "", &types.LogRegex{}, "RegexGotSignal11", types.NewLogCtx(), "error.log"),
+			types.NewLogInfo(types.NewDate(date.Add(2*time.Minute), layout), types.SimpleDisplayer("crash: got signal 6"), "", &types.LogRegex{}, "RegexGotSignal6", types.NewLogCtx(), "error.log"),
+		},
+		"node2": types.LocalTimeline{
+			types.NewLogInfo(types.NewDate(date, layout), types.SimpleDisplayer("NON-PRIMARY(n=1)"), "", &types.LogRegex{}, "RegexNewComponent", nonPrimaryCtx, "error.log"),
+			types.NewLogInfo(types.NewDate(date.Add(time.Minute), layout), types.SimpleDisplayer("PRIMARY(n=3)"), "", &types.LogRegex{}, "RegexNewComponent", types.NewLogCtx(), "error.log"),
+		},
+	}
+
+	tests := []struct {
+		conditions []string
+		expected   []string
+	}{
+		{
+			conditions: []string{"crash"},
+			expected:   []string{"crash found on node1: 2023-03-18T21:26:08.000000Z crash: got signal 11"},
+		},
+		{
+			conditions: []string{"non-primary", "sst-failure", "eviction"},
+			expected:   []string{"non-primary found on node2: 2023-03-18T21:25:08.000000Z NON-PRIMARY(n=1)"},
+		},
+		{
+			conditions: []string{"eviction"},
+			expected:   []string{},
+		},
+	}
+
+	for _, test := range tests {
+		out := checkTimeline(timeline, test.conditions)
+		if !cmp.Equal(out, test.expected) {
+			t.Errorf("conditions %v: %s", test.conditions, cmp.Diff(test.expected, out))
+		}
+	}
+}
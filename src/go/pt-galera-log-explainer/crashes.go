@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type crashes struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+}
+
+func (c *crashes) Help() string {
+	return fmt.Sprintf(`Detect crash and recovery loops
+	Crash signatures (signal 6 and 11, assertion failures, out of memory errors) are paired with the following restart
+	and InnoDB recovery events. A node restarting without any trace of how it stopped is also reported, as it was likely killed.
+	For each node, it prints the number of crash/restart cycles, the mean time between crashes,
+	and whether InnoDB recovery completed each time
+
+Usage:
+	%[1]s crashes *.log
+	`, toolname)
+}
+
+func (c *crashes) Run() error {
+
+	timeline, err := timelineFromPaths(c.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not detect crashes")
+	}
+
+	return display.TimelineCrashes(os.Stdout, timeline)
+}
@@ -3,21 +3,48 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
 )
 
 type ctx struct {
 	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+	At    string   `help:"Reconstruct the context of each node at this date instead of the latest one, format: 2023-01-23T03:53:40Z (RFC3339) or '2023-01-23 03:53'"`
 }
 
 func (c *ctx) Help() string {
-	return "Dump the context derived from the log"
+	return fmt.Sprintf(`Dump the context derived from the log
+	Without --at, this is the context at the end of each log
+
+Usage:
+	%[1]s ctx mysql.log
+	%[1]s ctx --at "2023-03-12 19:41" *.log
+	`, toolname)
 }
 
 func (c *ctx) Run() error {
 
+	var at *time.Time
+	if c.At != "" {
+		for _, layout := range aroundLayouts {
+			t, err := time.Parse(layout, c.At)
+			if err == nil {
+				at = &t
+				break
+			}
+		}
+		if at == nil {
+			return errors.Errorf("could not parse --at date %s, expected format: 2023-01-23T03:53:40Z or 2023-01-23 03:53", c.At)
+		}
+	}
+
 	timeline, err := timelineFromPaths(c.Paths, regex.AllRegexes())
 	if err != nil {
 		return err
@@ -29,14 +56,51 @@ func (c *ctx) Run() error {
 	}{}
 	out.DB = translate.GetDB()
 
-	for _, t := range timeline {
-		out.Contexts = append(out.Contexts, t[len(t)-1].LogCtx)
+	contexts := timeline.GetLatestContextsByNodes()
+	if at != nil {
+		contexts = timeline.GetContextsByNodesAt(*at)
+		transfers := display.StateTransfers(timeline)
+		for node, logCtx := range contexts {
+			logCtx.SSTs = sstsInProgressAt(transfers, logCtx.OwnNames, *at)
+			contexts[node] = logCtx
+		}
+	}
+	nodes := make([]string, 0, len(contexts))
+	for node := range contexts {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		// LogCtx is marshalled using a pointer receiver, states would be missing otherwise
+		logCtx := contexts[node]
+		out.Contexts = append(out.Contexts, &logCtx)
 	}
 
 	outjson, err := json.MarshalIndent(out, "", "\t")
 	if err != nil {
 		return err
 	}
+	// the contexts are anonymized already, the translation maps are not: their pseudonyms are kept as is
+	if CLI.Anonymize {
+		outjson = []byte(anonymizer.Anonymize(string(outjson)))
+	}
 	fmt.Println(string(outjson))
 	return nil
 }
+
+// sstsInProgressAt lists the transfers a node was involved in at a given time, keyed by donor like LogCtx.SSTs
+// LogCtx.SSTs cannot be used as is: the map is shared between every events of a file, it only tells the latest state
+func sstsInProgressAt(transfers []display.StateTransfer, ownNames []string, at time.Time) map[string]types.SST {
+	ssts := map[string]types.SST{}
+	for _, transfer := range transfers {
+		if transfer.Start.After(at) || (transfer.End != nil && transfer.End.Before(at)) {
+			continue
+		}
+		if !utils.SliceContains(ownNames, transfer.Donor) && !utils.SliceContains(ownNames, transfer.Joiner) {
+			continue
+		}
+		start := transfer.Start
+		ssts[transfer.Donor] = types.SST{Type: transfer.Type, Donor: transfer.Donor, Joiner: transfer.Joiner, SelectionTimestamp: &start}
+	}
+	return ssts
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
+	"github.com/pkg/errors"
+)
+
+type diff struct {
+	Before string `arg:"" name:"before" help:"glob pattern of the logs to compare from, to quote to avoid shell expansion"`
+	After  string `arg:"" name:"after" help:"glob pattern of the logs to compare to, to quote to avoid shell expansion"`
+}
+
+func (d *diff) Help() string {
+	return fmt.Sprintf(`Compare two sets of logs, eg: before/after a configuration change, or this week against last week
+	It reports changes in restarts, crashes and SST durations, and events present only on one side
+
+Usage:
+	%[1]s diff 'lastweek/*.log' 'thisweek/*.log'
+	`, toolname)
+}
+
+func (d *diff) Run() error {
+
+	before, err := d.summarize(d.Before)
+	if err != nil {
+		return errors.Wrap(err, "could not parse logs to compare from")
+	}
+
+	// each set of logs has to be translated on its own, a node name can be reused with another ip, ...
+	translate.ResetDB()
+
+	after, err := d.summarize(d.After)
+	if err != nil {
+		return errors.Wrap(err, "could not parse logs to compare to")
+	}
+
+	display.DiffCLI(os.Stdout, before, after)
+	return nil
+}
+
+func (d *diff) summarize(pattern string) (display.TimelineSummary, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return display.TimelineSummary{}, err
+	}
+	if len(paths) == 0 {
+		return display.TimelineSummary{}, errors.Errorf("no files matching %s", pattern)
+	}
+
+	timeline, err := timelineFromPaths(paths, regex.AllRegexes())
+	if err != nil {
+		return display.TimelineSummary{}, err
+	}
+	return display.SummarizeTimeline(timeline, CLI.Verbosity), nil
+}
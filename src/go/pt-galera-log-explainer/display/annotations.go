@@ -0,0 +1,56 @@
+package display
+
+import (
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// Annotation is a timeline event, formatted for the Grafana annotations HTTP API
+type Annotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+// annotationCategories are the events worth being seen on metric dashboards, the name is used as a tag
+var annotationCategories = []struct {
+	name   string
+	filter func(types.LogInfo) bool
+}{
+	{name: "restart", filter: func(li types.LogInfo) bool { return utils.SliceContains(statsCategory("restarts"), li.RegexUsed) }},
+	{name: "crash", filter: func(li types.LogInfo) bool { return utils.SliceContains(statsCategory("crashes"), li.RegexUsed) }},
+	{name: "view-change", filter: func(li types.LogInfo) bool { return li.RegexUsed == "RegexNewComponent" }},
+	{name: "sst", filter: func(li types.LogInfo) bool { return li.RegexType == types.SSTRegexType }},
+}
+
+// TimelineAnnotations selects view changes, SSTs, crashes and restarts from the timeline
+// Each annotation is tagged with "galera", its category and "node:<node>", along with the given extra tags
+// Only events visible at the given verbosity are selected
+func TimelineAnnotations(timeline types.Timeline, verbosity types.Verbosity, extraTags []string) []Annotation {
+	latestContext := timeline.GetLatestContextsByNodes()
+
+	annotations := []Annotation{}
+	for _, event := range dequeueAll(timeline) {
+		if event.li.Date == nil || !verbosity.Displays(event.li.Verbosity) {
+			continue
+		}
+		for _, category := range annotationCategories {
+			if !category.filter(event.li) {
+				continue
+			}
+			msg := utils.RemoveColor(event.li.Msg(latestContext[event.node]))
+			if msg == "" {
+				break
+			}
+			tags := append([]string{"galera", category.name, "node:" + event.node}, extraTags...)
+			annotations = append(annotations, Annotation{
+				Time: event.li.Date.Time.UnixMilli(),
+				Tags: tags,
+				Text: event.node + ": " + msg,
+			})
+			break
+		}
+	}
+	return annotations
+}
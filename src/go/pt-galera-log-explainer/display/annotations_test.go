@@ -0,0 +1,38 @@
+package display
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestTimelineAnnotations(t *testing.T) {
+	date := time.Date(2023, 3, 18, 21, 25, 8, 0, time.UTC)
+	logCtx := types.NewLogCtx()
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			testEvent(date, &types.LogRegex{Type: types.EventsRegexType}, "RegexStarting", "starting(8.0.28)", "", logCtx),
+			testEvent(date.Add(time.Second), &types.LogRegex{Type: types.EventsRegexType}, "RegexRecoveryStarted", "wsrep recovery", "", logCtx),
+			testEvent(date.Add(time.Minute), &types.LogRegex{Type: types.SSTRegexType}, "RegexSSTComplete", "got SST from node2", "", logCtx),
+		},
+		"node2": types.LocalTimeline{
+			testEvent(date.Add(2*time.Second), &types.LogRegex{Type: types.ViewsRegexType}, "RegexNewComponent", "PRIMARY(n=2)", "", logCtx),
+			testEvent(date.Add(time.Hour), &types.LogRegex{Type: types.EventsRegexType}, "RegexGotSignal11", "crash: got signal 11", "", logCtx),
+		},
+	}
+
+	expected := []Annotation{
+		{Time: 1679174708000, Tags: []string{"galera", "restart", "node:node1", "incident-42"}, Text: "node1: starting(8.0.28)"},
+		{Time: 1679174710000, Tags: []string{"galera", "view-change", "node:node2", "incident-42"}, Text: "node2: PRIMARY(n=2)"},
+		{Time: 1679174768000, Tags: []string{"galera", "sst", "node:node1", "incident-42"}, Text: "node1: got SST from node2"},
+		{Time: 1679178308000, Tags: []string{"galera", "crash", "node:node2", "incident-42"}, Text: "node2: crash: got signal 11"},
+	}
+
+	annotations := TimelineAnnotations(timeline, types.Info, []string{"incident-42"})
+	if diff := cmp.Diff(expected, annotations); diff != "" {
+		t.Errorf("unexpected annotations (-want +got):\n%s", diff)
+	}
+}
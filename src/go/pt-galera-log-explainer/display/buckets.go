@@ -0,0 +1,112 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ladicle/tabwriter"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// bucketCounts holds the number of events per regex type, for a node in a time bucket
+type bucketCounts map[types.RegexType]int
+
+func (b bucketCounts) total() int {
+	total := 0
+	for _, count := range b {
+		total += count
+	}
+	return total
+}
+
+// String gives a compact "type:count" summary, sorted by type so that columns are easy to compare
+func (b bucketCounts) String() string {
+	regexTypes := make([]string, 0, len(b))
+	for regexType := range b {
+		regexTypes = append(regexTypes, string(regexType))
+	}
+	sort.Strings(regexTypes)
+
+	cells := make([]string, 0, len(regexTypes))
+	for _, regexType := range regexTypes {
+		cells = append(cells, regexType+":"+strconv.Itoa(b[types.RegexType(regexType)]))
+	}
+	return strings.Join(cells, " ")
+}
+
+// TimelineBuckets collapses the timeline into fixed time buckets, counting events per type for each node
+// Long incidents with thousands of repeated events are easier to read this way.
+// Cells are colored depending on how many events happened, like a heatmap
+func TimelineBuckets(out io.Writer, timeline types.Timeline, bucket time.Duration, verbosity types.Verbosity) error {
+
+	timeline = removeEmptyColumns(timeline, verbosity)
+	keys, _ := initKeysContext(timeline)
+	latestContext := timeline.GetLatestContextsByNodes()
+
+	buckets := map[time.Time]map[string]bucketCounts{}
+	for _, node := range keys {
+
+		// events without dates are counted in the bucket of the latest known date
+		var last *types.Date
+		for _, li := range timeline[node] {
+			if li.Date != nil {
+				last = li.Date
+			}
+			if last == nil || !verbosity.Displays(li.Verbosity) || li.Msg(latestContext[node]) == "" {
+				continue
+			}
+
+			start := last.Time.Truncate(bucket)
+			if _, ok := buckets[start]; !ok {
+				buckets[start] = map[string]bucketCounts{}
+			}
+			if _, ok := buckets[start][node]; !ok {
+				buckets[start][node] = bucketCounts{}
+			}
+			// deduplicated events are still counted
+			buckets[start][node][li.RegexType] += 1 + li.RepetitionCount
+		}
+	}
+
+	starts := make([]time.Time, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	w := tabwriter.NewWriter(out, 8, 8, 3, ' ', tabwriter.DiscardEmptyColumns)
+
+	fmt.Fprintln(w, headerNodes(keys))
+	fmt.Fprintln(w, separator(keys))
+
+	for _, start := range starts {
+		args := []string{start.Format(time.RFC3339)}
+		for _, node := range keys {
+			counts, ok := buckets[start][node]
+			if !ok {
+				args = append(args, "| ")
+				continue
+			}
+			args = append(args, paintBucket(counts))
+		}
+		fmt.Fprintln(w, strings.Join(args, "\t")+"\t")
+	}
+
+	return w.Flush()
+}
+
+func paintBucket(counts bucketCounts) string {
+	switch total := counts.total(); {
+	case total >= 100:
+		return utils.Paint(utils.RedText, counts.String())
+	case total >= 10:
+		return utils.Paint(utils.YellowText, counts.String())
+	default:
+		return counts.String()
+	}
+}
@@ -0,0 +1,50 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestTimelineBuckets(t *testing.T) {
+	skipColor(t)
+
+	date := time.Date(2023, 3, 12, 7, 24, 13, 0, time.UTC)
+
+	// so that the node is known to have visible events
+	visible := &types.LogRegex{Handler: func(_ map[string]string, logCtx types.LogCtx, _ string, _ time.Time) (types.LogCtx, types.LogDisplayer) {
+		return logCtx, nil
+	}}
+	logCtx, _ := visible.Handle(types.NewLogCtx(), "", date)
+
+	repeated := testEvent(date.Add(time.Minute), &types.LogRegex{Type: types.SSTRegexType}, "RegexSSTResourceUnavailable", "node1 cannot find donor", "", logCtx)
+	repeated.RepetitionCount = 10
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			testEvent(date, &types.LogRegex{Type: types.ViewsRegexType}, "RegexNodeJoined", "node2 joined", "", logCtx),
+			repeated,
+			types.NewLogInfo(nil, types.SimpleDisplayer("PRIMARY(n=2)"), "", &types.LogRegex{Type: types.ViewsRegexType}, "RegexNewComponent", logCtx, "error.log"),
+			testEvent(date.Add(10*time.Minute), &types.LogRegex{Type: types.IdentRegexType, Verbosity: types.DebugMySQL}, "RegexSourceNode", "172.17.0.2 is local", "", logCtx),
+			testEvent(date.Add(11*time.Minute), &types.LogRegex{Type: types.EventsRegexType}, "RegexShutdownComplete", "shutdown complete", "", logCtx),
+		},
+	}
+
+	var b strings.Builder
+	err := TimelineBuckets(&b, timeline, 5*time.Minute, types.Info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `identifier             node1            
+                                        
+2023-03-12T07:20:00Z   views:1          
+2023-03-12T07:25:00Z   sst:11 views:1   
+2023-03-12T07:35:00Z   events:1         
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,140 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// crashSignatures are the events starting a crash cycle
+var crashSignatures = []string{"RegexGotSignal6", "RegexGotSignal11", "RegexAssertionFailure", "RegexOutOfMemory"}
+
+// CrashCycle is a crash, followed by the restart and recovery of the node when they could be found
+type CrashCycle struct {
+	Crash             time.Time
+	Signature         string
+	Restart           *time.Time
+	RecoveryStarted   bool
+	RecoveryCompleted bool
+}
+
+// CrashCycles pairs crash signatures with the following startup and InnoDB recovery events, for each node
+// A node restarting while it was not known as stopped is also considered as a crash: it was likely killed,
+// eg: by the OOM killer
+func CrashCycles(timeline types.Timeline) map[string][]CrashCycle {
+	cycles := map[string][]CrashCycle{}
+
+	for node, lt := range timeline {
+		var (
+			current   *CrashCycle
+			lastState string
+		)
+		for _, li := range lt {
+			if li.Date == nil {
+				lastState = li.LogCtx.State()
+				continue
+			}
+			switch {
+			case utils.SliceContains(crashSignatures, li.RegexUsed):
+				// a crash usually prints several signatures, they are part of the same cycle until the node restarts
+				if current == nil || current.Restart != nil {
+					cycles[node] = append(cycles[node], CrashCycle{Crash: li.Date.Time, Signature: utils.RemoveColor(li.RawMsg(li.LogCtx))})
+					current = &cycles[node][len(cycles[node])-1]
+				}
+
+			case li.RegexUsed == "RegexStarting":
+				if current != nil && current.Restart == nil {
+					current.Restart = &li.Date.Time
+					break
+				}
+				if lastState != "" && lastState != "CLOSED" && lastState != "DESTROYED" && lastState != "RECOVERY" {
+					cycles[node] = append(cycles[node], CrashCycle{Crash: li.Date.Time, Signature: "stopped without any trace (killed? OOM?)", Restart: &li.Date.Time})
+					current = &cycles[node][len(cycles[node])-1]
+					break
+				}
+				current = nil
+
+			// SST prepare logs have their own InnoDB recovery, unrelated to crashes
+			case li.RegexUsed == "RegexInnoDBCrashRecovery" && isServerLog(li.LogCtx.FileType):
+				if current != nil && current.Restart != nil {
+					current.RecoveryStarted = true
+				}
+
+			case li.RegexUsed == "RegexInnoDBRecoveryComplete" && isServerLog(li.LogCtx.FileType):
+				if current != nil && current.RecoveryStarted {
+					current.RecoveryCompleted = true
+				}
+			}
+			lastState = li.LogCtx.State()
+		}
+	}
+	return cycles
+}
+
+// isServerLog is true for logs written by mysqld itself, the wsrep recovery run included
+func isServerLog(fileType string) bool {
+	return fileType == "" || fileType == "error.log" || fileType == "recovery.log"
+}
+
+// MeanTimeBetweenCrashes returns 0 when there are less than 2 crashes
+func MeanTimeBetweenCrashes(cycles []CrashCycle) time.Duration {
+	if len(cycles) < 2 {
+		return 0
+	}
+	return cycles[len(cycles)-1].Crash.Sub(cycles[0].Crash) / time.Duration(len(cycles)-1)
+}
+
+// TimelineCrashes prints crash/restart cycles for each node that crashed
+func TimelineCrashes(out io.Writer, timeline types.Timeline) error {
+	var b strings.Builder
+
+	cycles := CrashCycles(timeline)
+	if len(cycles) == 0 {
+		b.WriteString("no crashes found\n")
+	}
+
+	for _, node := range sortedKeys(cycles) {
+		restarts := 0
+		for _, cycle := range cycles[node] {
+			if cycle.Restart != nil {
+				restarts++
+			}
+		}
+		b.WriteString(utils.Paint(utils.BlueText, node) + "\n")
+		b.WriteString(fmt.Sprintf("\tcrashes: %d, restarted after crash: %d", len(cycles[node]), restarts))
+		if mtbc := MeanTimeBetweenCrashes(cycles[node]); mtbc > 0 {
+			b.WriteString(fmt.Sprintf(", mean time between crashes: %s", mtbc.Round(time.Second)))
+		}
+		b.WriteString("\n")
+
+		for _, cycle := range cycles[node] {
+			b.WriteString("\t" + cycle.Crash.Format("2006-01-02T15:04:05.000000Z") + " " + utils.Paint(utils.RedText, cycle.Signature) + ": " + crashOutcome(cycle) + "\n")
+		}
+	}
+
+	_, err := fmt.Fprint(out, b.String())
+	return err
+}
+
+func crashOutcome(cycle CrashCycle) string {
+	if cycle.Restart == nil {
+		return utils.Paint(utils.YellowText, "no restart found")
+	}
+	outcome := "restarted"
+	if d := cycle.Restart.Sub(cycle.Crash); d > 0 {
+		outcome += fmt.Sprintf(" after %s", d.Round(time.Second))
+	}
+	switch {
+	case cycle.RecoveryCompleted:
+		outcome += ", innodb recovery " + utils.Paint(utils.GreenText, "completed")
+	case cycle.RecoveryStarted:
+		outcome += ", innodb recovery " + utils.Paint(utils.RedText, "not completed")
+	default:
+		outcome += ", no innodb recovery found"
+	}
+	return outcome
+}
@@ -0,0 +1,57 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestTimelineCrashes(t *testing.T) {
+	skipColor(t)
+
+	date := time.Date(2023, 3, 18, 21, 25, 8, 0, time.UTC)
+
+	event := func(offset time.Duration, regexUsed, msg, state string) types.LogInfo {
+		logCtx := types.NewLogCtx()
+		logCtx.SetState(state)
+		return testEvent(date.Add(offset), &types.LogRegex{Type: types.EventsRegexType}, regexUsed, msg, "", logCtx)
+	}
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			event(0, "RegexStarting", "starting(8.0.28)", "OPEN"),
+			event(time.Minute, "RegexShiftToSynced", "SYNCED", "SYNCED"),
+			event(time.Hour, "RegexGotSignal11", "crash: got signal 11", "CLOSED"),
+			event(time.Hour, "RegexAssertionFailure", "ASSERTION FAILURE", "CLOSED"),
+			event(time.Hour+15*time.Second, "RegexStarting", "starting(8.0.28)", "OPEN"),
+			event(time.Hour+16*time.Second, "RegexInnoDBCrashRecovery", "innodb crash recovery", "OPEN"),
+			event(time.Hour+20*time.Second, "RegexInnoDBRecoveryComplete", "innodb recovery completed", "OPEN"),
+			event(time.Hour+time.Minute, "RegexShiftToSynced", "SYNCED", "SYNCED"),
+			event(3*time.Hour, "RegexStarting", "starting(8.0.28, could not catch how/when it stopped)", "OPEN"),
+			event(5*time.Hour, "RegexOutOfMemory", "out of memory", "OPEN"),
+		},
+		"node2": types.LocalTimeline{
+			event(0, "RegexStarting", "starting(8.0.28)", "OPEN"),
+			event(time.Hour, "RegexShutdownComplete", "shutdown complete", "CLOSED"),
+			event(2*time.Hour, "RegexStarting", "starting(8.0.28)", "OPEN"),
+		},
+	}
+
+	var b strings.Builder
+	err := TimelineCrashes(&b, timeline)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `node1
+	crashes: 3, restarted after crash: 2, mean time between crashes: 2h0m0s
+	2023-03-18T22:25:08.000000Z crash: got signal 11: restarted after 15s, innodb recovery completed
+	2023-03-19T00:25:08.000000Z stopped without any trace (killed? OOM?): restarted, no innodb recovery found
+	2023-03-19T02:25:08.000000Z out of memory: no restart found
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,44 @@
+package display
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// TimelineCSV writes the merged timeline as a flat table, one row per event
+// comma is the field delimiter, so that it can be used for both CSV and TSV
+// Every event is written whatever its verbosity, the column is there to filter them later
+// Categories hidden by users are the exception
+func TimelineCSV(out io.Writer, timeline types.Timeline, comma rune) error {
+	w := csv.NewWriter(out)
+	w.Comma = comma
+
+	err := w.Write([]string{"timestamp", "node", "type", "verbosity", "message"})
+	if err != nil {
+		return err
+	}
+
+	latestContext := timeline.GetLatestContextsByNodes()
+
+	for _, event := range dequeueAll(timeline) {
+		if event.li.Verbosity == types.Hidden {
+			continue
+		}
+		timestamp := ""
+		if event.li.Date != nil {
+			timestamp = event.li.Date.Time.Format(time.RFC3339Nano)
+		}
+		err := w.Write([]string{timestamp, event.node, string(event.li.RegexType), strconv.Itoa(int(event.li.Verbosity)), utils.RemoveColor(event.li.Msg(latestContext[event.node]))})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,66 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+func TestTimelineCSV(t *testing.T) {
+	logCtx := types.NewLogCtx()
+	date := time.Date(2023, 3, 12, 7, 24, 13, 0, time.UTC)
+
+	timeline := types.Timeline{
+		"node2": types.LocalTimeline{
+			testEvent(date, &types.LogRegex{Type: types.ViewsRegexType}, "RegexNodeJoined", "node1 joined", "", logCtx),
+		},
+		"node1": types.LocalTimeline{
+			testEvent(date, &types.LogRegex{Type: types.EventsRegexType}, "RegexShutdownComplete", utils.Paint(utils.RedText, "shutdown, complete"), "", logCtx),
+			testEvent(date.Add(time.Second), &types.LogRegex{Type: types.IdentRegexType, Verbosity: types.DebugMySQL}, "RegexSourceNode", "172.17.0.2 is local", "", logCtx),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		comma    rune
+		expected string
+	}{
+		{
+			name:  "csv",
+			comma: ',',
+			expected: `timestamp,node,type,verbosity,message
+2023-03-12T07:24:13Z,node1,events,0,"shutdown, complete"
+2023-03-12T07:24:13Z,node2,views,0,node1 joined
+2023-03-12T07:24:14Z,node1,identity,1,172.17.0.2 is local
+`,
+		},
+		{
+			name:  "tsv",
+			comma: '\t',
+			expected: "timestamp\tnode\ttype\tverbosity\tmessage\n" +
+				"2023-03-12T07:24:13Z\tnode1\tevents\t0\tshutdown, complete\n" +
+				"2023-03-12T07:24:13Z\tnode2\tviews\t0\tnode1 joined\n" +
+				"2023-03-12T07:24:14Z\tnode1\tidentity\t1\t172.17.0.2 is local\n",
+		},
+	}
+
+	for _, test := range tests {
+		// exporting consumes the timeline
+		copied := types.Timeline{}
+		for node, lt := range timeline {
+			copied[node] = append(types.LocalTimeline{}, lt...)
+		}
+
+		var b strings.Builder
+		err := TimelineCSV(&b, copied, test.comma)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if b.String() != test.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.name, test.expected, b.String())
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// TimelineSummary is a comparable version of a timeline
+// Messages are rendered when summarizing: they rely on translation maps,
+// which will not be available anymore once another set of logs is parsed
+type TimelineSummary struct {
+	// node => message => count
+	Events map[string]map[string]int
+	// "donor -> joiner" => durations
+	SSTs     map[string][]time.Duration
+	Restarts map[string]int
+	Crashes  map[string]int
+}
+
+// SummarizeTimeline consumes a timeline to get what can be compared with another one
+func SummarizeTimeline(timeline types.Timeline, verbosity types.Verbosity) TimelineSummary {
+	summary := TimelineSummary{
+		Events:   map[string]map[string]int{},
+		SSTs:     map[string][]time.Duration{},
+		Restarts: map[string]int{},
+		Crashes:  map[string]int{},
+	}
+	latestContext := timeline.GetLatestContextsByNodes()
+
+	for node, lt := range timeline {
+		summary.Events[node] = map[string]int{}
+
+		for _, li := range lt {
+			count := 1 + li.RepetitionCount
+			switch {
+			case utils.SliceContains(statsCategory("restarts"), li.RegexUsed):
+				summary.Restarts[node] += count
+			case utils.SliceContains(statsCategory("crashes"), li.RegexUsed):
+				summary.Crashes[node] += count
+			}
+
+			if !verbosity.Displays(li.Verbosity) {
+				continue
+			}
+			msg := utils.RemoveColor(li.RawMsg(latestContext[node]))
+			if msg == "" {
+				continue
+			}
+			summary.Events[node][msg] += count
+		}
+	}
+
+	for _, sst := range completedSSTs(timeline) {
+		key := sst.Donor + " -> " + sst.Joiner
+		summary.SSTs[key] = append(summary.SSTs[key], sst.End.Sub(sst.Start))
+	}
+	return summary
+}
+
+// completedSST is a state transfer for which both the donor selection and the completion were found
+type completedSST struct {
+	Donor  string
+	Joiner string
+	Start  time.Time
+	End    time.Time
+}
+
+// completedSSTs lists every successful state transfer found in the timeline, sorted by donor selection time
+func completedSSTs(timeline types.Timeline) []completedSST {
+	out := []completedSST{}
+	for _, transfer := range StateTransfers(timeline) {
+		if transfer.End == nil || transfer.Failed {
+			continue
+		}
+		out = append(out, completedSST{Donor: transfer.Donor, Joiner: transfer.Joiner, Start: transfer.Start, End: *transfer.End})
+	}
+	return out
+}
+
+// sstNodes extracts both node names from SST logs, in the order they appear
+func sstNodes(r *types.LogRegex, log string) (string, string, bool) {
+	if r == nil || r.InternalRegex == nil {
+		return "", "", false
+	}
+	submatches := r.InternalRegex.FindStringSubmatch(log)
+	if submatches == nil {
+		return "", "", false
+	}
+	return utils.ShortNodeName(submatches[r.InternalRegex.SubexpIndex("nodename")]), utils.ShortNodeName(submatches[r.InternalRegex.SubexpIndex("nodename2")]), true
+}
+
+func statsCategory(name string) []string {
+	for _, category := range statsCategories {
+		if category.name == name {
+			return category.regexes
+		}
+	}
+	return nil
+}
+
+// DiffCLI prints what changed between two timelines: restarts, crashes, SST durations,
+// and events found only on one side
+func DiffCLI(out io.Writer, before, after TimelineSummary) {
+	var b strings.Builder
+
+	diffCounts(&b, "restarts", before.Restarts, after.Restarts)
+	diffCounts(&b, "crashes", before.Crashes, after.Crashes)
+
+	b.WriteString(utils.Paint(utils.BlueText, "SST durations") + "\n")
+	for _, key := range sortedKeys(before.SSTs, after.SSTs) {
+		b.WriteString("\t" + key + ": " + sstDurations(before.SSTs[key]) + " -> " + sstDurations(after.SSTs[key]) + "\n")
+	}
+
+	diffEvents(&b, "only in before", before.Events, after.Events)
+	diffEvents(&b, "only in after", after.Events, before.Events)
+
+	fmt.Fprint(out, b.String())
+}
+
+func diffCounts(b *strings.Builder, title string, before, after map[string]int) {
+	b.WriteString(utils.Paint(utils.BlueText, title) + "\n")
+	for _, node := range sortedKeys(before, after) {
+		line := fmt.Sprintf("\t%s: %d -> %d", node, before[node], after[node])
+		if before[node] != after[node] {
+			line = utils.Paint(utils.YellowText, line)
+		}
+		b.WriteString(line + "\n")
+	}
+}
+
+// diffEvents lists the messages from a that could not be found in b, for the same node
+// messages found on both sides with a different number of occurrences are also listed
+func diffEvents(b *strings.Builder, title string, a, other map[string]map[string]int) {
+	b.WriteString(utils.Paint(utils.BlueText, title) + "\n")
+	for _, node := range sortedKeys(a) {
+		for _, msg := range sortedKeys(a[node]) {
+			count, otherCount := a[node][msg], other[node][msg]
+			if count <= otherCount {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("\t%s: %s (x%d)\n", node, msg, count-otherCount))
+		}
+	}
+}
+
+func sstDurations(durations []time.Duration) string {
+	if len(durations) == 0 {
+		return "none"
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return fmt.Sprintf("%d SST(s), avg %s", len(durations), (total / time.Duration(len(durations))).Round(time.Second))
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	set := map[string]struct{}{}
+	for _, m := range maps {
+		for key := range m {
+			set[key] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
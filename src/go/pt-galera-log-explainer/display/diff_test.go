@@ -0,0 +1,51 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestDiffCLI(t *testing.T) {
+	skipColor(t)
+
+	date := time.Date(2023, 3, 18, 21, 25, 8, 0, time.UTC)
+	logCtx := types.NewLogCtx()
+
+	timelineWithSST := func(duration time.Duration) types.Timeline {
+		return types.Timeline{
+			"node1": types.LocalTimeline{
+				testEvent(date, &types.LogRegex{Type: types.EventsRegexType}, "RegexStarting", "starting(8.0.28)", "", logCtx),
+				testEvent(date, &types.LogRegex{Type: types.SSTRegexType}, "RegexSSTRequestSuccess", "node2 will resync local node",
+					"2023-03-18T21:25:08.000000Z 0 [Note] [MY-000000] [Galera] Member 0.0 (node1) requested state transfer from '*any*'. Selected 1.0 (node2)(SYNCED) as donor.",
+					logCtx),
+				testEvent(date.Add(duration), &types.LogRegex{Type: types.SSTRegexType}, "RegexSSTComplete", "got SST from node2",
+					"2023-03-18T21:40:25.000000Z 0 [Note] [MY-000000] [Galera] 1.0 (node2): State transfer to 0.0 (node1) complete.",
+					logCtx),
+			},
+		}
+	}
+
+	before := SummarizeTimeline(timelineWithSST(15*time.Minute), types.Info)
+	after := timelineWithSST(5 * time.Minute)
+	after["node1"] = append(after["node1"], testEvent(date.Add(time.Hour), &types.LogRegex{Type: types.EventsRegexType}, "RegexGotSignal11", "crash: got signal 11", "", logCtx))
+
+	var b strings.Builder
+	DiffCLI(&b, before, SummarizeTimeline(after, types.Info))
+
+	expected := `restarts
+	node1: 1 -> 1
+crashes
+	node1: 0 -> 1
+SST durations
+	node2 -> node1: 1 SST(s), avg 15m0s -> 1 SST(s), avg 5m0s
+only in before
+only in after
+	node1: crash: got signal 11 (x1)
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
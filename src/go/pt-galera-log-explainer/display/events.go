@@ -0,0 +1,22 @@
+package display
+
+import "github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+
+// nodeEvent is a single event along with the node column it belongs to
+type nodeEvent struct {
+	node string
+	li   types.LogInfo
+}
+
+// dequeueAll empties the timeline and returns every events chronologically
+// nodes having events at the exact same time are ordered by name
+func dequeueAll(timeline types.Timeline) []nodeEvent {
+	events := []nodeEvent{}
+	for nextNodes := timeline.IterateNode(); len(nextNodes) != 0; nextNodes = timeline.IterateNode() {
+		for _, node := range nextNodes {
+			events = append(events, nodeEvent{node: node, li: timeline[node][0]})
+			timeline.Dequeue(node)
+		}
+	}
+	return events
+}
@@ -0,0 +1,136 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// EvictionReport sums up how a node considered another one, from evs protocol messages
+type EvictionReport struct {
+	Suspected int
+	Inactive  int
+	// times the node was found in delayed lists, and the highest delay count seen
+	Delayed         int
+	MaxDelayedCount int
+	Evicted         int
+	First, Last     time.Time
+}
+
+func (r *EvictionReport) add(date time.Time) {
+	if r.First.IsZero() || date.Before(r.First) {
+		r.First = date
+	}
+	if date.After(r.Last) {
+		r.Last = date
+	}
+}
+
+// evictionReporterCluster is used when a node was told it was evicted, without knowing by whom
+const evictionReporterCluster = "cluster"
+
+// EvictionReports returns, for each node, who considered it suspect, delayed or evicted
+// The result is suspected node => reporting node => report
+func EvictionReports(timeline types.Timeline) map[string]map[string]*EvictionReport {
+	reports := map[string]map[string]*EvictionReport{}
+	report := func(subject, reporter string, date time.Time) *EvictionReport {
+		if _, ok := reports[subject]; !ok {
+			reports[subject] = map[string]*EvictionReport{}
+		}
+		if _, ok := reports[subject][reporter]; !ok {
+			reports[subject][reporter] = &EvictionReport{}
+		}
+		r := reports[subject][reporter]
+		r.add(date)
+		return r
+	}
+
+	for node, lt := range timeline {
+		// "declaring inactive" lines do not tell which node they are about
+		lastSuspected := ""
+		for _, li := range lt {
+			if li.Date == nil {
+				continue
+			}
+			date := li.Date.Time
+			switch li.RegexUsed {
+			case "RegexNodeSuspect":
+				hash := submatch(regex.ViewsMap[li.RegexUsed], li.Log, "uuid")
+				lastSuspected = translate.SimplestInfoFromHash(hash, date)
+				report(lastSuspected, node, date).Suspected++
+
+			case "RegexNodeDeclaredInactive":
+				if lastSuspected != "" {
+					report(lastSuspected, node, date).Inactive++
+				}
+
+			case "RegexEvsDelayedList":
+				for hash, count := range regex.ParseEvsDelayedList(submatch(regex.ViewsMap[li.RegexUsed], li.Log, "list")) {
+					r := report(translate.SimplestInfoFromHash(hash, date), node, date)
+					r.Delayed++
+					if count > r.MaxDelayedCount {
+						r.MaxDelayedCount = count
+					}
+				}
+
+			case "RegexAutoEvict":
+				hash := utils.UUIDToShortUUID(submatch(regex.ViewsMap[li.RegexUsed], li.Log, "uuid"))
+				report(translate.SimplestInfoFromHash(hash, date), node, date).Evicted++
+
+			case "RegexNodeEvicted":
+				report(node, evictionReporterCluster, date).Evicted++
+			}
+		}
+	}
+	return reports
+}
+
+// EvictionsCLI prints, for each node, who considered it suspect, delayed or evicted and when
+func EvictionsCLI(out io.Writer, reports map[string]map[string]*EvictionReport) error {
+	var b strings.Builder
+
+	if len(reports) == 0 {
+		b.WriteString("no suspected, delayed or evicted node found\n")
+	}
+
+	for _, subject := range sortedKeys(reports) {
+		b.WriteString(utils.Paint(utils.BlueText, subject) + "\n")
+		for _, reporter := range sortedKeys(reports[subject]) {
+			r := reports[subject][reporter]
+			details := []string{}
+			if r.Suspected > 0 {
+				details = append(details, "suspected "+times(r.Suspected))
+			}
+			if r.Inactive > 0 {
+				details = append(details, "declared inactive "+times(r.Inactive))
+			}
+			if r.Delayed > 0 {
+				details = append(details, fmt.Sprintf("delayed %s (delay count up to %d)", times(r.Delayed), r.MaxDelayedCount))
+			}
+			if r.Evicted > 0 {
+				details = append(details, utils.Paint(utils.RedText, "evicted "+times(r.Evicted)))
+			}
+			period := r.First.Format("2006-01-02T15:04:05.000000Z")
+			if r.Last.After(r.First) {
+				period += " to " + r.Last.Format("2006-01-02T15:04:05.000000Z")
+			}
+			b.WriteString(fmt.Sprintf("\tby %s: %s, %s\n", reporter, strings.Join(details, ", "), period))
+		}
+	}
+
+	_, err := fmt.Fprint(out, b.String())
+	return err
+}
+
+func times(n int) string {
+	if n == 1 {
+		return "once"
+	}
+	return fmt.Sprintf("%d times", n)
+}
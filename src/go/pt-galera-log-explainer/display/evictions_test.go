@@ -0,0 +1,60 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestEvictionReports(t *testing.T) {
+	skipColor(t)
+
+	translate.ResetDB()
+	defer translate.ResetDB()
+	translate.AddHashToNodeName("8e6f32b6-bf89", "node2", time.Time{})
+	translate.AddHashToNodeName("8e6f32b6-95fc", "node2", time.Time{})
+
+	date := time.Date(2023, time.March, 12, 7, 35, 0, 0, time.UTC)
+	event := func(offset time.Duration, regexUsed, log string) types.LogInfo {
+		return testEvent(date.Add(offset), &types.LogRegex{Type: types.ViewsRegexType}, regexUsed, "", log, types.NewLogCtx())
+	}
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			event(0, "RegexNodeSuspect", "evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) suspecting node: 8e6f32b6-bf89"),
+			event(time.Second, "RegexNodeDeclaredInactive", "evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) suspected node without join message, declaring inactive"),
+			event(2*time.Second, "RegexEvsDelayedList", "evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) delayed list: 8e6f32b6-dc8e-11ed-95fc-de68239ebf89:tcp://172.17.0.2:4567:3"),
+			event(3*time.Second, "RegexEvsDelayedList", "evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) delayed list: 8e6f32b6-dc8e-11ed-95fc-de68239ebf89:tcp://172.17.0.2:4567:5"),
+			event(4*time.Second, "RegexAutoEvict", "evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) auto evicting node 8e6f32b6-bf89"),
+		},
+		"node2": types.LocalTimeline{
+			event(5*time.Second, "RegexNodeEvicted", "this node has been evicted out of the cluster, gcomm backend restart is required"),
+		},
+	}
+
+	reports := EvictionReports(timeline)
+	expected := map[string]map[string]*EvictionReport{
+		"node2": {
+			"node1":   {Suspected: 1, Inactive: 1, Delayed: 2, MaxDelayedCount: 5, Evicted: 1, First: date, Last: date.Add(4 * time.Second)},
+			"cluster": {Evicted: 1, First: date.Add(5 * time.Second), Last: date.Add(5 * time.Second)},
+		},
+	}
+	if diff := cmp.Diff(expected, reports); diff != "" {
+		t.Fatalf("unexpected reports (-want +got):\n%s", diff)
+	}
+
+	var b strings.Builder
+	if err := EvictionsCLI(&b, reports); err != nil {
+		t.Fatal(err)
+	}
+	expectedOut := `node2
+	by cluster: evicted once, 2023-03-12T07:35:05.000000Z
+	by node1: suspected once, declared inactive once, delayed 2 times (delay count up to 5), evicted once, 2023-03-12T07:35:00.000000Z to 2023-03-12T07:35:04.000000Z
+`
+	if diff := cmp.Diff(expectedOut, b.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
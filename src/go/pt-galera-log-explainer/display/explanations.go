@@ -0,0 +1,73 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// Explanation describes an event found in logs
+type Explanation struct {
+	Regex string
+	// message of the first event found, to recognize it in the output
+	Example string
+	Text    string
+}
+
+// TimelineExplanations lists the explanations of events visible at this verbosity, ordered by their first occurrence
+// The timeline is not altered
+func TimelineExplanations(timeline types.Timeline, verbosity types.Verbosity) []Explanation {
+	latestContext := timeline.GetLatestContextsByNodes()
+
+	type first struct {
+		explanation Explanation
+		li          types.LogInfo
+	}
+	firsts := map[string]first{}
+	for _, node := range sortedKeys(timeline) {
+		for _, li := range timeline[node] {
+			text, ok := regex.Explanations[li.RegexUsed]
+			if !ok || !verbosity.Displays(li.Verbosity) || li.Date == nil {
+				continue
+			}
+			if f, found := firsts[li.RegexUsed]; found && !li.Date.Time.Before(f.li.Date.Time) {
+				continue
+			}
+			msg := utils.RemoveColor(li.Msg(latestContext[node]))
+			if msg == "" {
+				continue
+			}
+			firsts[li.RegexUsed] = first{explanation: Explanation{Regex: li.RegexUsed, Example: msg, Text: text}, li: li}
+		}
+	}
+
+	explanations := make([]Explanation, 0, len(firsts))
+	for _, key := range sortedKeys(firsts) {
+		explanations = append(explanations, firsts[key].explanation)
+	}
+	sort.SliceStable(explanations, func(i, j int) bool {
+		return firsts[explanations[i].Regex].li.Date.Time.Before(firsts[explanations[j].Regex].li.Date.Time)
+	})
+	return explanations
+}
+
+// ExplanationsCLI prints each explanation below an example of the event
+func ExplanationsCLI(out io.Writer, explanations []Explanation) error {
+	var b strings.Builder
+
+	b.WriteString("\n" + utils.Paint(utils.BrightBlueText, "explanations") + "\n")
+	if len(explanations) == 0 {
+		b.WriteString("no event to explain\n")
+	}
+	for _, explanation := range explanations {
+		b.WriteString(fmt.Sprintf("%s (%s)\n\t%s\n", utils.Paint(utils.BlueText, explanation.Example), explanation.Regex, explanation.Text))
+	}
+
+	_, err := fmt.Fprint(out, b.String())
+	return err
+}
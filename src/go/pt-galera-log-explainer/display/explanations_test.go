@@ -0,0 +1,52 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestTimelineExplanations(t *testing.T) {
+	skipColor(t)
+
+	date := time.Date(2023, time.March, 12, 7, 35, 0, 0, time.UTC)
+	event := func(offset time.Duration, regexUsed, msg string, verbosity types.Verbosity) types.LogInfo {
+		return testEvent(date.Add(offset), &types.LogRegex{Verbosity: verbosity}, regexUsed, msg, "", types.NewLogCtx())
+	}
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			event(2*time.Second, "RegexTerminated", "terminated", types.Info),
+			event(3*time.Second, "RegexShift", "SYNCED -> DONOR", types.Info),
+			event(4*time.Second, "RegexNodeSuspect", "suspecting node2", types.DebugMySQL),
+		},
+		"node2": types.LocalTimeline{
+			event(time.Second, "RegexGotSignal11", "crash: got signal 11", types.Info),
+			event(5*time.Second, "RegexGotSignal11", "crash: got signal 11 again", types.Info),
+		},
+	}
+
+	explanations := TimelineExplanations(timeline, types.Info)
+	expected := []Explanation{
+		{Regex: "RegexGotSignal11", Example: "crash: got signal 11", Text: regex.Explanations["RegexGotSignal11"]},
+		{Regex: "RegexTerminated", Example: "terminated", Text: regex.Explanations["RegexTerminated"]},
+	}
+	if diff := cmp.Diff(expected, explanations); diff != "" {
+		t.Fatalf("unexpected explanations (-want +got):\n%s", diff)
+	}
+	if len(timeline["node1"]) != 3 || len(timeline["node2"]) != 2 {
+		t.Fatal("timeline should not be altered")
+	}
+
+	var b strings.Builder
+	if err := ExplanationsCLI(&b, explanations[:1]); err != nil {
+		t.Fatal(err)
+	}
+	expectedOut := "\nexplanations\ncrash: got signal 11 (RegexGotSignal11)\n\t" + regex.Explanations["RegexGotSignal11"] + "\n"
+	if diff := cmp.Diff(expectedOut, b.String()); diff != "" {
+		t.Fatalf("unexpected output (-want +got):\n%s", diff)
+	}
+}
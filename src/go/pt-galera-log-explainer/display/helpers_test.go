@@ -0,0 +1,24 @@
+package display
+
+import (
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// testLayout is the date layout of the error logs the tests pretend to read
+const testLayout = "2006-01-02T15:04:05.000000Z"
+
+// skipColor disables colors until the end of the test, so outputs can be compared as plain text
+func skipColor(t *testing.T) {
+	t.Helper()
+	utils.SkipColor = true
+	t.Cleanup(func() { utils.SkipColor = false })
+}
+
+// testEvent builds an event of error.log, as if it was found at date by the regexUsed regex
+func testEvent(date time.Time, regex *types.LogRegex, regexUsed, msg, log string, logCtx types.LogCtx) types.LogInfo {
+	return types.NewLogInfo(types.NewDate(date, testLayout), types.SimpleDisplayer(msg), log, regex, regexUsed, logCtx, "error.log")
+}
@@ -0,0 +1,193 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// Layout selects what the timeline prints for each event
+type Layout struct {
+	Date bool
+	// time elapsed since the previous event printed
+	Delta bool
+	// node state, printed before messages
+	State bool
+	// raw log lines instead of translated messages
+	Raw bool
+	// maximum width of node columns, 0 means no limit
+	Width int
+	// when Width is not set, node columns share this width, usually the one of the terminal. 0 means no limit
+	TerminalWidth int
+	// longer messages continue on the next lines instead of being truncated
+	Wrap bool
+	// dates are printed as offsets from this date when set, eg: +00:03:12
+	Anchor *time.Time
+}
+
+// leadingColumns is the number of columns before node columns
+// There is always one, as headers are labeled there
+func (l Layout) leadingColumns() int {
+	if l.Date && l.Delta {
+		return 2
+	}
+	return 1
+}
+
+// pad adds empty leading columns to lines written for a single leading column
+func (l Layout) pad(s string) string {
+	n := l.leadingColumns()
+	if n == 1 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Replace(line, "\t", strings.Repeat("\t", n), 1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// leading returns the values of leading columns for an event
+func (l Layout) leading(date, previous *types.Date) []string {
+	args := []string{}
+	if l.Date {
+		switch {
+		case date == nil:
+			args = append(args, "")
+		case l.Anchor != nil:
+			args = append(args, relativeTime(date.Time.Sub(*l.Anchor)))
+		default:
+			args = append(args, date.DisplayTime)
+		}
+	}
+	if l.Delta {
+		delta := ""
+		if date != nil && previous != nil {
+			delta = "+" + date.Time.Sub(previous.Time).Round(time.Millisecond).String()
+		}
+		args = append(args, delta)
+	}
+	if len(args) == 0 {
+		args = append(args, "")
+	}
+	return args
+}
+
+// cell returns the lines printed for an event in its node column
+// There is more than one line only when wrapping
+func (l Layout) cell(li types.LogInfo, msg string) []string {
+	if l.Raw {
+		msg = li.Log
+	}
+	if l.State {
+		state := li.LogCtx.State()
+		if state == "" {
+			state = "?"
+		}
+		msg = utils.PaintForState("["+state+"]", state) + " " + msg
+	}
+	if l.Width > 0 && utf8.RuneCountInString(utils.RemoveColor(msg)) > l.Width {
+		// colors cannot be kept, an escape code could be cut
+		msg = utils.RemoveColor(msg)
+		if l.Wrap {
+			return wrap(msg, l.Width)
+		}
+		return []string{utils.Truncate(msg, l.Width)}
+	}
+	return []string{msg}
+}
+
+// header truncates the node values of a header line to the column width
+func (l Layout) header(s string) string {
+	if l.Width == 0 {
+		return l.pad(s)
+	}
+	values := strings.Split(s, "\t")
+	for i := 1; i < len(values); i++ {
+		if utf8.RuneCountInString(values[i]) > l.Width {
+			values[i] = utils.Truncate(values[i], l.Width)
+		}
+	}
+	return l.pad(strings.Join(values, "\t"))
+}
+
+// minColumnWidth is the narrowest a node column gets when fitting the terminal
+// Narrower messages would not be readable anymore, it is better to let the terminal wrap lines
+const minColumnWidth = 16
+
+// columnPadding is the padding added by the tabwriter between columns
+const columnPadding = 3
+
+// fit shares the terminal width between node columns, when no width was given
+// dateWidth is the width of dates printed, if any
+func (l Layout) fit(nodes, dateWidth int) Layout {
+	if l.Width > 0 || l.TerminalWidth == 0 || nodes == 0 {
+		return l
+	}
+	// first column also holds header labels, eg: "last known name"
+	leading := len("last known name")
+	if l.Date && dateWidth > leading {
+		leading = dateWidth
+	}
+	leading += columnPadding
+	if l.Date && l.Delta {
+		leading += len("+1m23.456s") + columnPadding
+	}
+	l.Width = (l.TerminalWidth-leading)/nodes - columnPadding
+	if l.Width < minColumnWidth {
+		l.Width = minColumnWidth
+	}
+	return l
+}
+
+// wrap splits s into lines of width runes at most, breaking on spaces when possible
+// Next lines are indented, so that they are not mistaken for other events
+func wrap(s string, width int) []string {
+	const indent = "  "
+	if width <= len(indent) {
+		return []string{utils.Truncate(s, width)}
+	}
+	lines := []string{}
+	line := []rune{}
+	flush := func() {
+		lines = append(lines, string(line))
+		line = []rune(indent)
+	}
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		if len(line) > 0 && string(line) != indent {
+			if len(line)+1+len(w) <= width {
+				line = append(append(line, ' '), w...)
+				continue
+			}
+			flush()
+		}
+		// words longer than a whole line are split
+		for len(line)+len(w) > width {
+			n := width - len(line)
+			line = append(line, w[:n]...)
+			w = w[n:]
+			flush()
+		}
+		line = append(line, w...)
+	}
+	if len(line) > 0 && string(line) != indent {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// relativeTime formats offsets as +hh:mm:ss, hours are not wrapped at 24
+func relativeTime(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	sign := "+"
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
@@ -0,0 +1,132 @@
+package display
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestLayout(t *testing.T) {
+	skipColor(t)
+
+	date := types.NewDate(time.Date(2023, time.March, 12, 7, 35, 1, 500000000, time.UTC), testLayout)
+	previous := types.NewDate(time.Date(2023, time.March, 12, 7, 34, 0, 0, time.UTC), testLayout)
+
+	logCtx := types.NewLogCtx()
+	logCtx.SetState("SYNCED")
+	li := types.NewLogInfo(date, types.SimpleDisplayer("synced"), "2023-03-12T07:35:01.500000Z 0 [Note] WSREP: Synchronized with group, ready for connections", &types.LogRegex{}, "RegexSynced", logCtx, "error.log")
+
+	tests := []struct {
+		name            string
+		layout          Layout
+		expectedLeading []string
+		expectedCell    []string
+		expectedPad     string
+	}{
+		{
+			name:            "default",
+			layout:          Layout{Date: true},
+			expectedLeading: []string{"2023-03-12T07:35:01.500000Z"},
+			expectedCell:    []string{"synced"},
+			expectedPad:     "identifier\tnode1\t\n\tnode1\t",
+		},
+		{
+			name:            "delta and state",
+			layout:          Layout{Date: true, Delta: true, State: true},
+			expectedLeading: []string{"2023-03-12T07:35:01.500000Z", "+1m1.5s"},
+			expectedCell:    []string{"[SYNCED] synced"},
+			expectedPad:     "identifier\t\tnode1\t\n\t\tnode1\t",
+		},
+		{
+			name:            "relative",
+			layout:          Layout{Date: true, Anchor: &previous.Time},
+			expectedLeading: []string{"+00:01:01"},
+			expectedCell:    []string{"synced"},
+			expectedPad:     "identifier\tnode1\t\n\tnode1\t",
+		},
+		{
+			name:            "raw truncated",
+			layout:          Layout{Raw: true, Width: 30},
+			expectedLeading: []string{""},
+			expectedCell:    []string{"2023-03-12T07:35:01.500000Z..."},
+			expectedPad:     "identifier\tnode1\t\n\tnode1\t",
+		},
+		{
+			name:            "raw wrapped",
+			layout:          Layout{Raw: true, Width: 30, Wrap: true},
+			expectedLeading: []string{""},
+			expectedCell:    []string{"2023-03-12T07:35:01.500000Z 0", "  [Note] WSREP: Synchronized", "  with group, ready for", "  connections"},
+			expectedPad:     "identifier\tnode1\t\n\tnode1\t",
+		},
+	}
+
+	for _, test := range tests {
+		if diff := cmp.Diff(test.expectedLeading, test.layout.leading(date, previous)); diff != "" {
+			t.Errorf("%s: unexpected leading columns (-want +got):\n%s", test.name, diff)
+		}
+		if diff := cmp.Diff(test.expectedCell, test.layout.cell(li, li.Msg(logCtx))); diff != "" {
+			t.Errorf("%s: unexpected cell (-want +got):\n%s", test.name, diff)
+		}
+		if pad := test.layout.pad("identifier\tnode1\t\n\tnode1\t"); pad != test.expectedPad {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expectedPad, pad)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		s        string
+		width    int
+		expected []string
+	}{
+		{s: "short", width: 10, expected: []string{"short"}},
+		{s: "joined (member 2)", width: 10, expected: []string{"joined", "  (member", "  2)"}},
+		{s: "averyveryverylongword end", width: 10, expected: []string{"averyveryv", "  erylongw", "  ord end"}},
+	}
+	for _, test := range tests {
+		if diff := cmp.Diff(test.expected, wrap(test.s, test.width)); diff != "" {
+			t.Errorf("wrap(%q, %d) (-want +got):\n%s", test.s, test.width, diff)
+		}
+	}
+}
+
+func TestLayoutFit(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   Layout
+		expected int
+	}{
+		{name: "not a terminal", layout: Layout{Date: true}, expected: 0},
+		{name: "width given", layout: Layout{Date: true, Width: 50, TerminalWidth: 120}, expected: 50},
+		// 120 - (27 + 3) = 90, 90 / 3 - 3
+		{name: "shared", layout: Layout{Date: true, TerminalWidth: 120}, expected: 27},
+		{name: "too narrow", layout: Layout{Date: true, TerminalWidth: 60}, expected: minColumnWidth},
+	}
+	for _, test := range tests {
+		if width := test.layout.fit(3, len("2023-03-12T07:35:01.500000Z")).Width; width != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, width)
+		}
+	}
+
+	header := Layout{Width: 10}.header("current path\t/var/log/mysql/node1.log\tnode2.log\t")
+	if expected := "current path\t/var/lo...\tnode2.log\t"; header != expected {
+		t.Errorf("expected header %q, got %q", expected, header)
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                                    "+00:00:00",
+		-500 * time.Millisecond:              "+00:00:00",
+		3*time.Minute + 12*time.Second:       "+00:03:12",
+		-(time.Hour + time.Second):           "-01:00:01",
+		27*time.Hour + 1500*time.Millisecond: "+27:00:01",
+	}
+	for d, expected := range tests {
+		if out := relativeTime(d); out != expected {
+			t.Errorf("relativeTime(%s): expected %s, got %s", d, expected, out)
+		}
+	}
+}
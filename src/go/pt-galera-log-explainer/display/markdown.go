@@ -0,0 +1,172 @@
+package display
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// failureRegexes are the events worth being reported as failures
+var failureRegexes = []string{
+	"RegexGotSignal6",
+	"RegexGotSignal11",
+	"RegexAssertionFailure",
+	"RegexAborting",
+	"RegexReversingHistory",
+	"RegexBindAddressAlreadyUsed",
+	"RegexTooManyConnections",
+	"RegexWsrepUnsafeBootstrap",
+	"RegexWsrepConsistenctyCompromised",
+	"RegexWsrepNonPrimary",
+	"RegexSSTError",
+	"RegexSSTStateTransferFailed",
+	"RegexSSTFailedUnknown",
+	"RegexISTFailed",
+	"RegexFailedToPrepareIST",
+	"RegexInconsistencyVoteInconsistentWithGroup",
+	"RegexNodeEvicted",
+}
+
+// transactionRegexes are the events pointing at transactions too large or too long for galera
+// They frequently explain flow control and conflicts
+var transactionRegexes = []string{
+	"RegexMaxWriteSetSizeExceeded",
+	"RegexTransactionSizeLimitExceeded",
+	"RegexBFLockWaitLong",
+	"RegexPreCommitTimeout",
+}
+
+// TimelineMarkdown writes an incident report, meant to be pasted in a ticket or postmortem
+// It contains the cluster composition, view changes, SST/IST, failures and the timeline itself
+// Only events visible at the given verbosity are reported, explain adds what they mean
+func TimelineMarkdown(out io.Writer, timeline types.Timeline, verbosity types.Verbosity, explain bool) error {
+	w := bufio.NewWriter(out)
+
+	timeline = removeEmptyColumns(timeline, verbosity)
+	keys, _ := initKeysContext(timeline)
+	latestContext := timeline.GetLatestContextsByNodes()
+	files := filesPerNodes(timeline)
+	explanations := TimelineExplanations(timeline, verbosity)
+
+	events := []nodeEvent{}
+	for _, event := range dequeueAll(timeline) {
+		if verbosity.Displays(event.li.Verbosity) && event.li.Msg(latestContext[event.node]) != "" {
+			events = append(events, event)
+		}
+	}
+
+	fmt.Fprintln(w, "# Galera incident report")
+	if len(events) > 0 {
+		fmt.Fprintf(w, "\nFrom %s to %s\n", markdownDate(events[0].li), markdownDate(events[len(events)-1].li))
+	}
+
+	fmt.Fprint(w, "\n## Cluster composition\n\n")
+	fmt.Fprintln(w, "| identifier | last known ip | last known name | mysql version | files |")
+	fmt.Fprintln(w, "|---|---|---|---|---|")
+	for _, node := range keys {
+		logCtx := latestContext[node]
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+			markdownCell(node), markdownCell(lastOf(logCtx.OwnIPs)), markdownCell(lastOf(logCtx.OwnNames)),
+			markdownCell(logCtx.Version), markdownCell(strings.Join(files[node], ", ")))
+	}
+
+	// donor/joiner pairings are easier to follow as a diagram, rendered by most markdown viewers
+	var diagram strings.Builder
+	if transfers := StateTransfers(timeline); len(transfers) > 0 {
+		diagram.WriteString("```mermaid\n")
+		if err := TransfersMermaid(&diagram, transfers); err != nil {
+			return err
+		}
+		diagram.WriteString("```\n\n")
+	}
+
+	sections := []struct {
+		title   string
+		diagram string
+		filter  func(types.LogInfo) bool
+	}{
+		{
+			title:  "View changes",
+			filter: func(li types.LogInfo) bool { return li.RegexType == types.ViewsRegexType },
+		},
+		{
+			title:   "SST/IST",
+			diagram: diagram.String(),
+			filter:  func(li types.LogInfo) bool { return li.RegexType == types.SSTRegexType },
+		},
+		{
+			title:  "Failures",
+			filter: func(li types.LogInfo) bool { return utils.SliceContains(failureRegexes, li.RegexUsed) },
+		},
+		{
+			title:  "Problematic transactions",
+			filter: func(li types.LogInfo) bool { return utils.SliceContains(transactionRegexes, li.RegexUsed) },
+		},
+		{
+			title:  "Timeline",
+			filter: func(li types.LogInfo) bool { return true },
+		},
+	}
+
+	for _, section := range sections {
+		fmt.Fprintf(w, "\n## %s\n\n", section.title)
+		fmt.Fprint(w, section.diagram)
+
+		found := false
+		for _, event := range events {
+			if !section.filter(event.li) {
+				continue
+			}
+			if !found {
+				fmt.Fprintln(w, "| date | node | event |")
+				fmt.Fprintln(w, "|---|---|---|")
+				found = true
+			}
+			fmt.Fprintf(w, "| %s | %s | %s |\n", markdownDate(event.li), markdownCell(event.node), markdownCell(event.li.Msg(latestContext[event.node])))
+		}
+		if !found {
+			fmt.Fprintln(w, "None found.")
+		}
+	}
+
+	if explain {
+		fmt.Fprint(w, "\n## Explanations\n\n")
+		if len(explanations) == 0 {
+			fmt.Fprintln(w, "None found.")
+		}
+		for _, explanation := range explanations {
+			fmt.Fprintf(w, "- **%s** (`%s`): %s\n", markdownCell(explanation.Example), explanation.Regex, explanation.Text)
+		}
+	}
+
+	return w.Flush()
+}
+
+func filesPerNodes(timeline types.Timeline) map[string][]string {
+	files := map[string][]string{}
+	for node, lt := range timeline {
+		for _, li := range lt {
+			files[node] = utils.SliceMergeDeduplicate(files[node], []string{li.LogCtx.FilePath})
+		}
+		sort.Strings(files[node])
+	}
+	return files
+}
+
+func markdownDate(li types.LogInfo) string {
+	if li.Date == nil {
+		return ""
+	}
+	return li.Date.DisplayTime
+}
+
+func markdownCell(s string) string {
+	s = utils.RemoveColor(s)
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
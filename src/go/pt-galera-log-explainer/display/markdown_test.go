@@ -0,0 +1,86 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestTimelineMarkdown(t *testing.T) {
+	logCtx := types.NewLogCtx()
+	logCtx.FilePath = "node1.log"
+	logCtx.OwnNames = []string{"node1"}
+	logCtx.Version = "8.0.28"
+
+	date := time.Date(2023, 3, 12, 7, 24, 13, 0, time.UTC)
+
+	// so that the node is known to have visible events
+	visible := &types.LogRegex{Handler: func(_ map[string]string, logCtx types.LogCtx, _ string, _ time.Time) (types.LogCtx, types.LogDisplayer) {
+		return logCtx, nil
+	}}
+	logCtx, _ = visible.Handle(logCtx, "", date)
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			testEvent(date, &types.LogRegex{Type: types.ViewsRegexType}, "RegexNewComponent", "PRIMARY(n=3)", "", logCtx),
+			testEvent(date.Add(time.Second), &types.LogRegex{Type: types.EventsRegexType}, "RegexGotSignal11", "crash: got signal 11", "", logCtx),
+			testEvent(date.Add(2*time.Second), &types.LogRegex{Type: types.IdentRegexType, Verbosity: types.DebugMySQL}, "RegexSourceNode", "a|b is local", "", logCtx),
+		},
+	}
+
+	var b strings.Builder
+	err := TimelineMarkdown(&b, timeline, types.Info, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+
+	expected := `# Galera incident report
+
+From 2023-03-12T07:24:13.000000Z to 2023-03-12T07:24:14.000000Z
+
+## Cluster composition
+
+| identifier | last known ip | last known name | mysql version | files |
+|---|---|---|---|---|
+| node1 |  | node1 | 8.0.28 | node1.log |
+
+## View changes
+
+| date | node | event |
+|---|---|---|
+| 2023-03-12T07:24:13.000000Z | node1 | PRIMARY(n=3) |
+
+## SST/IST
+
+None found.
+
+## Failures
+
+| date | node | event |
+|---|---|---|
+| 2023-03-12T07:24:14.000000Z | node1 | crash: got signal 11 |
+
+## Problematic transactions
+
+None found.
+
+## Timeline
+
+| date | node | event |
+|---|---|---|
+| 2023-03-12T07:24:13.000000Z | node1 | PRIMARY(n=3) |
+| 2023-03-12T07:24:14.000000Z | node1 | crash: got signal 11 |
+`
+	if out != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out)
+	}
+}
+
+func TestMarkdownCell(t *testing.T) {
+	if s := markdownCell("a|b\nc"); s != "a\\|b c" {
+		t.Errorf("expected escaped cell, got %s", s)
+	}
+}
@@ -0,0 +1,125 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+const metricsPrefix = "galera_"
+
+// metricsCounters are the events exported as counters, the family name gets a "_total" suffix on samples
+var metricsCounters = []struct {
+	name    string
+	help    string
+	regexes []string
+}{
+	{name: "restarts", help: "Number of times the node was started", regexes: statsCategory("restarts")},
+	{name: "flow_control_events", help: "Number of flow control events found in logs", regexes: statsCategory("flow control")},
+}
+
+// metricSample is a single value, timestamp is in milliseconds
+type metricSample struct {
+	timestamp int64
+	value     float64
+}
+
+type metricSeries struct {
+	labels  string
+	samples []metricSample
+}
+
+// add appends a sample, or replaces the latest one when it has the same timestamp
+// as duplicated timestamps are rejected when backfilling
+func (s *metricSeries) add(timestamp int64, value float64) {
+	if len(s.samples) > 0 && s.samples[len(s.samples)-1].timestamp >= timestamp {
+		s.samples[len(s.samples)-1].value = value
+		return
+	}
+	s.samples = append(s.samples, metricSample{timestamp: timestamp, value: value})
+}
+
+// TimelineMetrics converts a timeline into time series, using the OpenMetrics text format
+// Counters are cumulative per node, with a sample at each event. Samples are timestamped
+// so that they can be backfilled into Prometheus
+func TimelineMetrics(out io.Writer, timeline types.Timeline) error {
+	var b strings.Builder
+
+	nodes := sortedKeys(timeline)
+
+	for _, counter := range metricsCounters {
+		name := metricsPrefix + counter.name
+		b.WriteString("# TYPE " + name + " counter\n")
+		b.WriteString("# HELP " + name + " " + counter.help + "\n")
+
+		for _, node := range nodes {
+			series := metricSeries{labels: metricLabels("node", node)}
+			count := 0
+			for _, li := range timeline[node] {
+				if li.Date == nil {
+					continue
+				}
+				// counters start at 0, so that increases can be computed from the very first event
+				if len(series.samples) == 0 {
+					series.add(li.Date.Time.UnixMilli(), 0)
+				}
+				if !utils.SliceContains(counter.regexes, li.RegexUsed) {
+					continue
+				}
+				// deduplicated events are still counted
+				count += 1 + li.RepetitionCount
+				series.add(li.Date.Time.UnixMilli(), float64(count))
+			}
+			writeSeries(&b, name+"_total", series)
+		}
+	}
+
+	name := metricsPrefix + "sst_duration_seconds"
+	b.WriteString("# TYPE " + name + " gauge\n")
+	b.WriteString("# HELP " + name + " Duration of completed SSTs, from donor selection to completion, sampled at completion\n")
+	sstSeries := map[string]*metricSeries{}
+	for _, sst := range completedSSTs(timeline) {
+		labels := metricLabels("node", sst.Joiner, "donor", sst.Donor)
+		if _, ok := sstSeries[labels]; !ok {
+			sstSeries[labels] = &metricSeries{labels: labels}
+		}
+		sstSeries[labels].add(sst.End.UnixMilli(), sst.End.Sub(sst.Start).Seconds())
+	}
+	for _, labels := range sortedKeys(sstSeries) {
+		writeSeries(&b, name, *sstSeries[labels])
+	}
+
+	b.WriteString("# EOF\n")
+	_, err := fmt.Fprint(out, b.String())
+	return err
+}
+
+func writeSeries(b *strings.Builder, name string, series metricSeries) {
+	for _, sample := range series.samples {
+		b.WriteString(name + series.labels + " " + strconv.FormatFloat(sample.value, 'f', -1, 64) + " " + metricTimestamp(sample.timestamp) + "\n")
+	}
+}
+
+// metricTimestamp formats milliseconds as seconds, the unit used by OpenMetrics
+func metricTimestamp(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64)
+}
+
+// metricLabels takes label names and values, alternatively
+func metricLabels(keyvalues ...string) string {
+	labels := make([]string, 0, len(keyvalues)/2)
+	for i := 0; i+1 < len(keyvalues); i += 2 {
+		labels = append(labels, keyvalues[i]+"=\""+escapeLabelValue(keyvalues[i+1])+"\"")
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
@@ -0,0 +1,58 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestTimelineMetrics(t *testing.T) {
+	date := time.Date(2023, 3, 18, 21, 25, 8, 0, time.UTC)
+	logCtx := types.NewLogCtx()
+
+	restart := testEvent(date.Add(time.Minute), &types.LogRegex{Type: types.EventsRegexType}, "RegexStarting", "starting(8.0.28)", "", logCtx)
+	restart.RepetitionCount = 1
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			testEvent(date, &types.LogRegex{Type: types.SSTRegexType}, "RegexSSTRequestSuccess", "node2 will resync local node",
+				"2023-03-18T21:25:08.000000Z 0 [Note] [MY-000000] [Galera] Member 0.0 (node1) requested state transfer from '*any*'. Selected 1.0 (node2)(SYNCED) as donor.",
+				logCtx),
+			restart,
+			testEvent(date.Add(90*time.Second), &types.LogRegex{Type: types.SSTRegexType}, "RegexSSTComplete", "got SST from node2",
+				"2023-03-18T21:26:38.000000Z 0 [Note] [MY-000000] [Galera] 1.0 (node2): State transfer to 0.0 (node1) complete.",
+				logCtx),
+		},
+		"node\"2": types.LocalTimeline{
+			testEvent(date, &types.LogRegex{Type: types.EventsRegexType}, "RegexFlowControl", "flow control", "", logCtx),
+			testEvent(date.Add(500*time.Millisecond), &types.LogRegex{Type: types.EventsRegexType}, "RegexFlowControl", "flow control", "", logCtx),
+		},
+	}
+
+	var b strings.Builder
+	err := TimelineMetrics(&b, timeline)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `# TYPE galera_restarts counter
+# HELP galera_restarts Number of times the node was started
+galera_restarts_total{node="node\"2"} 0 1679174708
+galera_restarts_total{node="node1"} 0 1679174708
+galera_restarts_total{node="node1"} 2 1679174768
+# TYPE galera_flow_control_events counter
+# HELP galera_flow_control_events Number of flow control events found in logs
+galera_flow_control_events_total{node="node\"2"} 1 1679174708
+galera_flow_control_events_total{node="node\"2"} 2 1679174708.5
+galera_flow_control_events_total{node="node1"} 0 1679174708
+# TYPE galera_sst_duration_seconds gauge
+# HELP galera_sst_duration_seconds Duration of completed SSTs, from donor selection to completion, sampled at completion
+galera_sst_duration_seconds{node="node1",donor="node2"} 90 1679174798
+# EOF
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
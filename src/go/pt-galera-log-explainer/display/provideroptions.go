@@ -0,0 +1,214 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// providerOptionsHighlights are the options which, when inconsistent, often explain incidents:
+// evictions, partitions, forced SSTs, wrong quorum decisions
+var providerOptionsHighlights = []string{
+	"evs.inactive_timeout",
+	"evs.install_timeout",
+	"evs.keepalive_period",
+	"evs.suspect_timeout",
+	"gcache.size",
+	"gcs.fc_limit",
+	"pc.ignore_quorum",
+	"pc.ignore_sb",
+	"pc.weight",
+}
+
+// providerOptionsPerNode are expected to be different on each node
+var providerOptionsPerNode = []string{
+	"base_dir",
+	"base_host",
+	"base_port",
+	"gcache.dir",
+	"gcache.name",
+	"gmcast.listen_addr",
+	"ist.recv_addr",
+	"ist.recv_bind",
+	"socket.ssl_ca",
+	"socket.ssl_cert",
+	"socket.ssl_key",
+}
+
+// ProviderOptionsChange is when the effective wsrep_provider_options of a node changed
+type ProviderOptionsChange struct {
+	Date *types.Date
+	// loaded when starting, or set at runtime
+	Startup bool
+	// effective options after the change
+	Options map[string]string
+	// option => previous value, empty when it was not set
+	Changed map[string]string
+}
+
+// ParseProviderOptions reads "key = value; key2 = value2" lists, as found in startup logs and SET GLOBAL statements
+func ParseProviderOptions(s string) map[string]string {
+	options := map[string]string{}
+	for _, option := range strings.Split(s, ";") {
+		key, value, found := strings.Cut(option, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			continue
+		}
+		options[key] = strings.TrimSpace(value)
+	}
+	return options
+}
+
+// ProviderOptionsHistory lists, for each node, the changes of its effective provider options
+// Options loaded at startup replace everything that was set at runtime before
+func ProviderOptionsHistory(timeline types.Timeline) map[string][]ProviderOptionsChange {
+	history := map[string][]ProviderOptionsChange{}
+
+	for node, lt := range timeline {
+		effective := map[string]string{}
+		for _, li := range lt {
+			if li.Date == nil || (li.RegexUsed != "RegexProviderOptions" && li.RegexUsed != "RegexSetProviderOptions") {
+				continue
+			}
+			options := ParseProviderOptions(submatch(regex.EventsMap[li.RegexUsed], li.Log, "options"))
+			startup := li.RegexUsed == "RegexProviderOptions"
+
+			next := map[string]string{}
+			if !startup {
+				for key, value := range effective {
+					next[key] = value
+				}
+			}
+			for key, value := range options {
+				next[key] = value
+			}
+
+			changed := map[string]string{}
+			for key, value := range next {
+				if previous, ok := effective[key]; !ok || previous != value {
+					changed[key] = previous
+				}
+			}
+			effective = next
+			if len(changed) == 0 {
+				continue
+			}
+			history[node] = append(history[node], ProviderOptionsChange{Date: li.Date, Startup: startup, Options: next, Changed: changed})
+		}
+	}
+	return history
+}
+
+// ProviderOptionsInconsistencies compares the latest effective options of each node, and returns
+// option => node => value for options whose value is not the same everywhere
+func ProviderOptionsInconsistencies(history map[string][]ProviderOptionsChange) map[string]map[string]string {
+	inconsistencies := map[string]map[string]string{}
+
+	values := map[string]map[string]string{}
+	for node, changes := range history {
+		if len(changes) == 0 {
+			continue
+		}
+		for key, value := range changes[len(changes)-1].Options {
+			if utils.SliceContains(providerOptionsPerNode, key) {
+				continue
+			}
+			if _, ok := values[key]; !ok {
+				values[key] = map[string]string{}
+			}
+			values[key][node] = value
+		}
+	}
+
+	nodes := 0
+	for _, changes := range history {
+		if len(changes) > 0 {
+			nodes++
+		}
+	}
+	for key, byNode := range values {
+		distinct := map[string]struct{}{}
+		for _, value := range byNode {
+			distinct[value] = struct{}{}
+		}
+		// an option missing on some nodes is likely from a different galera version, not a configuration choice
+		if len(distinct) > 1 && len(byNode) == nodes {
+			inconsistencies[key] = byNode
+		}
+	}
+	return inconsistencies
+}
+
+// ProviderOptionsCLI prints the provider options history of each node, and options that differ between nodes
+// When all is false, only highlighted options are listed at startup, changes are always printed in full
+func ProviderOptionsCLI(out io.Writer, timeline types.Timeline, all bool) error {
+	var b strings.Builder
+
+	clusters := timeline.SplitByCluster()
+	if len(clusters) == 0 {
+		b.WriteString("no provider options found\n")
+	}
+	for i, cluster := range clusters {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		history := ProviderOptionsHistory(cluster.Timeline)
+		if len(history) == 0 {
+			b.WriteString("no provider options found\n")
+			continue
+		}
+
+		for _, node := range sortedKeys(history) {
+			b.WriteString(utils.Paint(utils.BlueText, node) + "\n")
+			for j, change := range history[node] {
+				how := "set at runtime"
+				if change.Startup {
+					how = "loaded at startup"
+				}
+				b.WriteString("\t" + change.Date.DisplayTime + " " + how + "\n")
+
+				for _, key := range sortedKeys(change.Changed) {
+					if j == 0 {
+						if all || utils.SliceContains(providerOptionsHighlights, key) {
+							b.WriteString(fmt.Sprintf("\t\t%s = %s\n", key, change.Options[key]))
+						}
+						continue
+					}
+					previous := change.Changed[key]
+					if previous == "" {
+						previous = "(unset)"
+					}
+					b.WriteString(fmt.Sprintf("\t\t%s: %s -> %s\n", providerOptionKey(key), previous, change.Options[key]))
+				}
+			}
+		}
+
+		inconsistencies := ProviderOptionsInconsistencies(history)
+		b.WriteString("inconsistencies between nodes\n")
+		if len(inconsistencies) == 0 {
+			b.WriteString("\tnone\n")
+		}
+		for _, key := range sortedKeys(inconsistencies) {
+			values := []string{}
+			for _, node := range sortedKeys(inconsistencies[key]) {
+				values = append(values, node+"="+inconsistencies[key][node])
+			}
+			b.WriteString(fmt.Sprintf("\t%s: %s\n", providerOptionKey(key), strings.Join(values, ", ")))
+		}
+	}
+
+	_, err := fmt.Fprint(out, b.String())
+	return err
+}
+
+func providerOptionKey(key string) string {
+	if utils.SliceContains(providerOptionsHighlights, key) {
+		return utils.Paint(utils.RedText, key)
+	}
+	return key
+}
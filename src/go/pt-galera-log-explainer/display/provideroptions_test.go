@@ -0,0 +1,91 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestParseProviderOptions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]string
+	}{
+		{
+			input:    "base_dir = /var/lib/mysql/; base_host = 127.0.0.1; gcomm.thread_prio = ; pc.weight = 1;",
+			expected: map[string]string{"base_dir": "/var/lib/mysql/", "base_host": "127.0.0.1", "gcomm.thread_prio": "", "pc.weight": "1"},
+		},
+		{
+			input:    "pc.weight=10;gcache.size=2G",
+			expected: map[string]string{"pc.weight": "10", "gcache.size": "2G"},
+		},
+		{
+			input:    "",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		out := ParseProviderOptions(test.input)
+		if !cmp.Equal(out, test.expected) {
+			t.Errorf("input: %s, %s", test.input, cmp.Diff(test.expected, out))
+		}
+	}
+}
+
+func TestProviderOptionsCLI(t *testing.T) {
+	skipColor(t)
+
+	date := time.Date(2023, 3, 18, 21, 25, 8, 0, time.UTC)
+
+	event := func(offset time.Duration, regexUsed, log string) types.LogInfo {
+		return testEvent(date.Add(offset), &types.LogRegex{Type: types.EventsRegexType}, regexUsed, "", log, types.NewLogCtx())
+	}
+	startup := func(offset time.Duration, host, gcache string) types.LogInfo {
+		return event(offset, "RegexProviderOptions", "[Galera] Passing config to GCS: base_host = "+host+"; evs.suspect_timeout = PT5S; gcache.size = "+gcache+"; gcs.fc_factor = 1.0; pc.weight = 1;")
+	}
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			startup(0, "172.17.0.2", "128M"),
+			event(time.Hour, "RegexSetProviderOptions", "12 Query	SET GLOBAL wsrep_provider_options='pc.weight=10'"),
+			// restarting drops runtime changes
+			startup(2*time.Hour, "172.17.0.2", "2G"),
+		},
+		"node2": types.LocalTimeline{
+			startup(0, "172.17.0.3", "128M"),
+			startup(2*time.Hour, "172.17.0.3", "128M"),
+		},
+	}
+
+	var b strings.Builder
+	err := ProviderOptionsCLI(&b, timeline, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `node1
+	2023-03-18T21:25:08.000000Z loaded at startup
+		evs.suspect_timeout = PT5S
+		gcache.size = 128M
+		pc.weight = 1
+	2023-03-18T22:25:08.000000Z set at runtime
+		pc.weight: 1 -> 10
+	2023-03-18T23:25:08.000000Z loaded at startup
+		gcache.size: 128M -> 2G
+		pc.weight: 10 -> 1
+node2
+	2023-03-18T21:25:08.000000Z loaded at startup
+		evs.suspect_timeout = PT5S
+		gcache.size = 128M
+		pc.weight = 1
+inconsistencies between nodes
+	gcache.size: node1=2G, node2=128M
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,135 @@
+package display
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// RawLines are the lines surrounding an event in its source file, including lines that matched no regex
+type RawLines struct {
+	Lines []string
+	// line number of Lines[0], starting at 1
+	First int
+	// index of the event line in Lines
+	Event int
+}
+
+// OpenLog re-opens the source file of events the way it was searched, eg: decompressed and transcoded to utf-8
+// Paths are the ones of LogCtx.FilePath. Plain files are opened as is until it is set
+var OpenLog = func(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// ReadRawLines re-opens a log file and returns "around" lines before and after the first occurrence of line
+// line is the one stored in LogInfo.Log: raw lines are sanitized the same way to be compared
+func ReadRawLines(path, line string, around int) (RawLines, error) {
+	raw := RawLines{First: 1, Event: -1}
+
+	f, err := OpenLog(path)
+	if err != nil {
+		return raw, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for n := 1; s.Scan(); n++ {
+		current := s.Text()
+		if raw.Event >= 0 {
+			if len(raw.Lines)-raw.Event > around {
+				break
+			}
+			raw.Lines = append(raw.Lines, current)
+			continue
+		}
+		raw.Lines = append(raw.Lines, current)
+		if utils.SanitizeLine(current) == line {
+			raw.Event = len(raw.Lines) - 1
+			continue
+		}
+		if len(raw.Lines) > around {
+			raw.Lines = raw.Lines[1:]
+			raw.First++
+		}
+	}
+	if err := s.Err(); err != nil {
+		return raw, err
+	}
+	if raw.Event < 0 {
+		return raw, fmt.Errorf("could not find the log line in %s", path)
+	}
+	return raw, nil
+}
+
+// RawContextFilter selects the events to drill down into
+type RawContextFilter struct {
+	// prefix of the event date, as displayed in the timeline, eg: "2023-03-12T19:36:48" selects every event of this second
+	At string
+	// node identifier, or any name the node had. Every node when empty
+	Node string
+	// matched against the event message and the raw log line. Every event when nil
+	Match *regexp.Regexp
+}
+
+func (f RawContextFilter) selects(node string, li types.LogInfo, msg string) bool {
+	if li.Date == nil || li.Log == "" || !strings.HasPrefix(li.Date.DisplayTime, f.At) {
+		return false
+	}
+	if f.Node != "" && f.Node != node && !utils.SliceContains(li.LogCtx.OwnNames, f.Node) {
+		return false
+	}
+	return f.Match == nil || f.Match.MatchString(utils.RemoveColor(msg)) || f.Match.MatchString(li.Log)
+}
+
+// RawContextCLI prints the raw lines surrounding each selected event, read again from its source file
+// Events sharing the same log line, eg: a line matched by several regexes, are printed once
+func RawContextCLI(out io.Writer, timeline types.Timeline, verbosity types.Verbosity, filter RawContextFilter, around int) error {
+	var b strings.Builder
+
+	printed := map[string]struct{}{}
+	for _, node := range sortedKeys(timeline) {
+		for _, li := range timeline[node] {
+			msg := li.Msg(li.LogCtx)
+			if !verbosity.Displays(li.Verbosity) || msg == "" || !filter.selects(node, li, msg) {
+				continue
+			}
+			key := li.LogCtx.FilePath + "\x00" + li.Log
+			if _, ok := printed[key]; ok {
+				continue
+			}
+			printed[key] = struct{}{}
+
+			if len(printed) > 1 {
+				b.WriteString("\n")
+			}
+			raw, err := ReadRawLines(li.LogCtx.FilePath, li.Log, around)
+			if err != nil {
+				b.WriteString(fmt.Sprintf("%s %s: %s\n", utils.Paint(utils.BlueText, node), li.LogCtx.FilePath, utils.Paint(utils.RedText, err.Error())))
+				continue
+			}
+			b.WriteString(fmt.Sprintf("%s %s:%d: %s\n", utils.Paint(utils.BlueText, node), li.LogCtx.FilePath, raw.First+raw.Event, msg))
+
+			width := len(fmt.Sprint(raw.First + len(raw.Lines) - 1))
+			for i, line := range raw.Lines {
+				if i == raw.Event {
+					b.WriteString(fmt.Sprintf("> %*d  %s\n", width, raw.First+i, utils.Paint(utils.YellowText, line)))
+					continue
+				}
+				b.WriteString(fmt.Sprintf("  %*d  %s\n", width, raw.First+i, line))
+			}
+		}
+	}
+	if len(printed) == 0 {
+		b.WriteString("no event found at " + filter.At + "\n")
+	}
+
+	_, err := fmt.Fprint(out, b.String())
+	return err
+}
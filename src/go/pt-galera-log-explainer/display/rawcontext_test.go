@@ -0,0 +1,141 @@
+package display
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestReadRawLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.log")
+	err := os.WriteFile(path, []byte("line1\nline2\n\tline3\nline4\nline5\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		line        string
+		around      int
+		expected    RawLines
+		expectedErr bool
+	}{
+		{
+			// lines are compared once sanitized, as they are stored in LogInfo.Log
+			name:     "middle of the file",
+			line:     "line3",
+			around:   1,
+			expected: RawLines{Lines: []string{"line2", "\tline3", "line4"}, First: 2, Event: 1},
+		},
+		{
+			name:     "start of the file",
+			line:     "line1",
+			around:   2,
+			expected: RawLines{Lines: []string{"line1", "line2", "\tline3"}, First: 1, Event: 0},
+		},
+		{
+			name:     "end of the file",
+			line:     "line5",
+			around:   3,
+			expected: RawLines{Lines: []string{"line2", "\tline3", "line4", "line5"}, First: 2, Event: 3},
+		},
+		{
+			name:     "only the line",
+			line:     "line4",
+			expected: RawLines{Lines: []string{"line4"}, First: 4, Event: 0},
+		},
+		{
+			name:        "missing line",
+			line:        "line6",
+			around:      1,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		out, err := ReadRawLines(path, test.line, test.around)
+		if (err != nil) != test.expectedErr {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if test.expectedErr {
+			continue
+		}
+		if diff := cmp.Diff(test.expected, out); diff != "" {
+			t.Errorf("%s: unexpected lines (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestRawContextCLI(t *testing.T) {
+	skipColor(t)
+
+	path := filepath.Join(t.TempDir(), "error.log")
+	err := os.WriteFile(path, []byte(`2023-03-12T19:36:47.000000Z 0 [Note] unparsed
+2023-03-12T19:36:48.000000Z 0 [ERROR] SST failed
+2023-03-12T19:36:48.500000Z 0 [ERROR] unparsed either
+2023-03-12T19:37:00.000000Z 0 [Note] Shifting JOINER -> OPEN
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := func(date, msg, log string) types.LogInfo {
+		d, err := time.Parse(testLayout, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logCtx := types.NewLogCtx()
+		logCtx.FilePath = path
+		logCtx.OwnNames = []string{"node1"}
+		return testEvent(d, &types.LogRegex{Type: types.SSTRegexType}, "RegexTest", msg, log, logCtx)
+	}
+	timeline := types.Timeline{
+		"172.17.0.2": types.LocalTimeline{
+			event("2023-03-12T19:36:48.000000Z", "SST error", "2023-03-12T19:36:48.000000Z 0 [ERROR] SST failed"),
+			event("2023-03-12T19:37:00.000000Z", "JOINER -> OPEN", "2023-03-12T19:37:00.000000Z 0 [Note] Shifting JOINER -> OPEN"),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filter   RawContextFilter
+		expected string
+	}{
+		{
+			name:   "date prefix",
+			filter: RawContextFilter{At: "2023-03-12T19:36"},
+			expected: "172.17.0.2 " + path + ":2: SST error\n" +
+				"  1  2023-03-12T19:36:47.000000Z 0 [Note] unparsed\n" +
+				"> 2  2023-03-12T19:36:48.000000Z 0 [ERROR] SST failed\n" +
+				"  3  2023-03-12T19:36:48.500000Z 0 [ERROR] unparsed either\n",
+		},
+		{
+			name:     "node name and match",
+			filter:   RawContextFilter{At: "2023-03-12", Node: "node1", Match: regexp.MustCompile("OPEN")},
+			expected: "172.17.0.2 " + path + ":4: JOINER -> OPEN\n  3  2023-03-12T19:36:48.500000Z 0 [ERROR] unparsed either\n> 4  2023-03-12T19:37:00.000000Z 0 [Note] Shifting JOINER -> OPEN\n",
+		},
+		{
+			name:     "other node",
+			filter:   RawContextFilter{At: "2023-03-12", Node: "node2"},
+			expected: "no event found at 2023-03-12\n",
+		},
+	}
+
+	for _, test := range tests {
+		var b strings.Builder
+		err := RawContextCLI(&b, timeline, types.Info, test.filter, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.expected, b.String()); diff != "" {
+			t.Errorf("%s: unexpected output (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
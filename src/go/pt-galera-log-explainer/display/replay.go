@@ -0,0 +1,120 @@
+package display
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// ReplayOptions controls how the timeline is replayed
+type ReplayOptions struct {
+	// speed compared to real time, eg: 60 replays one minute per second
+	// 0 waits for enter to be pressed between events instead
+	Speed float64
+	// longest wait between two events when replaying at a speed, so that quiet hours do not stall the replay
+	MaxWait time.Duration
+	// used to wait between events, time.Sleep when nil
+	Sleep func(time.Duration)
+}
+
+// Replay advances through the merged timeline event by event, printing the cluster status each time it changes:
+// the state and member count seen by each node
+// In step mode, "q" followed by enter stops the replay
+func Replay(out io.Writer, in io.Reader, timeline types.Timeline, verbosity types.Verbosity, opts ReplayOptions) error {
+	sleep := opts.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	input := bufio.NewReader(in)
+
+	keys, _ := initKeysContext(timeline)
+	latestContext := timeline.GetLatestContextsByNodes()
+
+	events := []nodeEvent{}
+	for _, event := range dequeueAll(timeline) {
+		if verbosity.Displays(event.li.Verbosity) && event.li.Msg(latestContext[event.node]) != "" {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		_, err := fmt.Fprintln(out, "no event to replay")
+		return err
+	}
+	if opts.Speed == 0 {
+		if _, err := fmt.Fprintln(out, "press enter for the next event, q and enter to quit"); err != nil {
+			return err
+		}
+	}
+
+	current := map[string]types.LogCtx{}
+	var (
+		previousStatus string
+		previousDate   *types.Date
+	)
+	for i, event := range events {
+		if i > 0 {
+			if opts.Speed == 0 {
+				line, err := input.ReadString('\n')
+				if strings.TrimSpace(line) == "q" || err != nil {
+					return nil
+				}
+			} else if previousDate != nil && event.li.Date != nil {
+				wait := time.Duration(float64(event.li.Date.Time.Sub(previousDate.Time)) / opts.Speed)
+				if opts.MaxWait > 0 && wait > opts.MaxWait {
+					wait = opts.MaxWait
+				}
+				if wait > 0 {
+					sleep(wait)
+				}
+			}
+		}
+		if event.li.Date != nil {
+			previousDate = event.li.Date
+		}
+		current[event.node] = event.li.LogCtx
+
+		date := ""
+		if event.li.Date != nil {
+			date = event.li.Date.DisplayTime
+		}
+		_, err := fmt.Fprintf(out, "[%d/%d] %s %s: %s\n", i+1, len(events), date, utils.Paint(utils.BlueText, event.node), event.li.Msg(latestContext[event.node]))
+		if err != nil {
+			return err
+		}
+
+		status := replayStatus(keys, current)
+		if status != previousStatus {
+			if _, err := fmt.Fprintln(out, "\t"+status); err != nil {
+				return err
+			}
+			previousStatus = status
+		}
+	}
+	return nil
+}
+
+// replayStatus is a one-line summary of what each node knows of the cluster
+func replayStatus(keys []string, current map[string]types.LogCtx) string {
+	nodes := []string{}
+	for _, node := range keys {
+		logCtx, ok := current[node]
+		if !ok {
+			continue
+		}
+		state := logCtx.State()
+		if state == "" {
+			state = "?"
+		}
+		status := node + ": " + utils.PaintForState(state, state)
+		if logCtx.MemberCount > 0 {
+			status += fmt.Sprintf(" (%d members)", logCtx.MemberCount)
+		}
+		nodes = append(nodes, status)
+	}
+	return strings.Join(nodes, " | ")
+}
@@ -0,0 +1,82 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestReplay(t *testing.T) {
+	skipColor(t)
+
+	date := time.Date(2023, time.March, 12, 19, 35, 0, 0, time.UTC)
+	event := func(offset time.Duration, msg, state string, members int) types.LogInfo {
+		logCtx := types.NewLogCtx()
+		logCtx.SetState(state)
+		logCtx.MemberCount = members
+		return testEvent(date.Add(offset), &types.LogRegex{Type: types.StatesRegexType}, "RegexTest", msg, "", logCtx)
+	}
+	newTimeline := func() types.Timeline {
+		return types.Timeline{
+			"node1": types.LocalTimeline{
+				event(0, "PRIMARY(n=2)", "SYNCED", 2),
+				event(time.Minute, "node2 left", "SYNCED", 1),
+			},
+			"node2": types.LocalTimeline{
+				event(time.Second, "SYNCED -> CLOSED", "CLOSED", 2),
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		input          string
+		opts           ReplayOptions
+		expected       string
+		expectedSleeps []time.Duration
+	}{
+		{
+			name:  "step by step, quitting",
+			input: "\nq\n",
+			expected: `press enter for the next event, q and enter to quit
+[1/3] 2023-03-12T19:35:00.000000Z node1: PRIMARY(n=2)
+	node1: SYNCED (2 members)
+[2/3] 2023-03-12T19:35:01.000000Z node2: SYNCED -> CLOSED
+	node1: SYNCED (2 members) | node2: CLOSED (2 members)
+`,
+		},
+		{
+			name: "speed with a maximum wait",
+			opts: ReplayOptions{Speed: 2, MaxWait: 10 * time.Second},
+			expected: `[1/3] 2023-03-12T19:35:00.000000Z node1: PRIMARY(n=2)
+	node1: SYNCED (2 members)
+[2/3] 2023-03-12T19:35:01.000000Z node2: SYNCED -> CLOSED
+	node1: SYNCED (2 members) | node2: CLOSED (2 members)
+[3/3] 2023-03-12T19:36:00.000000Z node1: node2 left
+	node1: SYNCED (1 members) | node2: CLOSED (2 members)
+`,
+			expectedSleeps: []time.Duration{500 * time.Millisecond, 10 * time.Second},
+		},
+	}
+
+	for _, test := range tests {
+		sleeps := []time.Duration{}
+		test.opts.Sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+		var b strings.Builder
+		err := Replay(&b, strings.NewReader(test.input), newTimeline(), types.Info, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.expected, b.String()); diff != "" {
+			t.Errorf("%s: unexpected output (-want +got):\n%s", test.name, diff)
+		}
+		if diff := cmp.Diff(test.expectedSleeps, sleeps, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("%s: unexpected waits (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
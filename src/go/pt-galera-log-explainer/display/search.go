@@ -0,0 +1,37 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/Ladicle/tabwriter"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// SearchCLI prints lines found by the search subcommand in chronological order, along with the node they come from
+// Parts matching the pattern are highlighted. The timeline is consumed
+func SearchCLI(out io.Writer, timeline types.Timeline, pattern *regexp.Regexp) error {
+	w := tabwriter.NewWriter(out, 8, 8, 3, ' ', 0)
+
+	found := 0
+	for _, event := range dequeueAll(timeline) {
+		if event.li.RegexType != types.SearchRegexType {
+			continue
+		}
+		date := ""
+		if event.li.Date != nil {
+			date = event.li.Date.DisplayTime
+		}
+		line := pattern.ReplaceAllStringFunc(event.li.Log, func(match string) string {
+			return utils.Paint(utils.RedText, match)
+		})
+		fmt.Fprintf(w, "%s\t%s\t%s\n", date, utils.Paint(utils.BlueText, event.node), line)
+		found++
+	}
+	if found == 0 {
+		fmt.Fprintln(w, "no match found")
+	}
+	return w.Flush()
+}
@@ -0,0 +1,40 @@
+package display
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestSearchCLI(t *testing.T) {
+	skipColor(t)
+
+	date := time.Date(2023, time.March, 12, 7, 35, 0, 0, time.UTC)
+	event := func(offset time.Duration, regexType types.RegexType, log string) types.LogInfo {
+		return testEvent(date.Add(offset), &types.LogRegex{Type: regexType}, "RegexUserSearch", log, log, types.NewLogCtx())
+	}
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			event(0, types.SearchRegexType, "2023-03-12T07:35:00.000000Z 0 [Note] Too many connections"),
+			event(2*time.Second, types.StatesRegexType, "2023-03-12T07:35:02.000000Z 0 [Note] WSREP: Shifting SYNCED -> DONOR/DESYNCED (TO: 10)"),
+		},
+		"node2": types.LocalTimeline{
+			event(time.Second, types.SearchRegexType, "2023-03-12T07:35:01.000000Z 0 [Note] Too many connections"),
+		},
+	}
+
+	var b strings.Builder
+	if err := SearchCLI(&b, timeline, regexp.MustCompile("many")); err != nil {
+		t.Fatal(err)
+	}
+	expected := `2023-03-12T07:35:00.000000Z   node1   2023-03-12T07:35:00.000000Z 0 [Note] Too many connections
+2023-03-12T07:35:01.000000Z   node2   2023-03-12T07:35:01.000000Z 0 [Note] Too many connections
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Fatalf("unexpected output (-want +got):\n%s", diff)
+	}
+}
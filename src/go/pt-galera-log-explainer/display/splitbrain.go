@@ -0,0 +1,319 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// splitBrainMinOverlap is how long two primary components must have coexisted to be reported
+// nodes do not log view changes at the exact same time, and their clocks can be slightly off
+const splitBrainMinOverlap = 30 * time.Second
+
+// SplitBrainPartition is a group of nodes that kept being primary together
+type SplitBrainPartition struct {
+	Nodes []string
+	// cluster state UUID, from quorum results
+	UUID string
+	// highest act_id found in quorum results, -1 when unknown
+	Seqno int64
+}
+
+// SplitBrain is a period during which several primary components coexisted
+// Partitions are sorted by seqno, highest first
+type SplitBrain struct {
+	Start      *types.Date
+	End        *types.Date
+	Partitions []SplitBrainPartition
+}
+
+// primaryPeriod is a period during which a node was part of a given primary component
+type primaryPeriod struct {
+	node   string
+	viewID string
+	uuid   string
+	start  *types.Date
+	end    *types.Date
+	seqno  int64
+}
+
+func isPrimaryState(state string) bool {
+	return utils.SliceContains([]string{"PRIMARY", "SYNCED", "DONOR", "DESYNCED", "JOINER", "JOINED"}, state)
+}
+
+// primaryPeriods lists, for each node, when it was primary and in which primary component
+// A period ends with the last event found while the node was still primary: when a node stopped without
+// leaving any trace, it should not be considered primary until it restarts
+func primaryPeriods(timeline types.Timeline) []primaryPeriod {
+	periods := []primaryPeriod{}
+
+	for _, node := range sortedKeys(timeline) {
+		var (
+			current     *primaryPeriod
+			pendingView string
+			lastDate    *types.Date
+			lastPrimary *types.Date
+		)
+		closePeriod := func() {
+			if current != nil && lastPrimary != nil {
+				current.end = lastPrimary
+				periods = append(periods, *current)
+			}
+			current = nil
+		}
+
+		for _, li := range timeline[node] {
+			// events generated by the tool are not from the node, and operator logs embed other files with their own dates
+			if li.RegexType == types.GapRegexType || li.RegexType == types.NoticeRegexType || li.RegexType == types.AnnotationRegexType ||
+				li.RegexType == types.SlowQueryRegexType || li.RegexType == types.GeneralQueryRegexType || li.RegexType == types.SystemRegexType ||
+				!isServerLog(li.LogCtx.FileType) {
+				continue
+			}
+			if li.Date != nil {
+				lastDate = li.Date
+			}
+
+			// the view is printed before the node knows whether it is primary
+			if li.RegexUsed == "RegexPrimaryView" {
+				pendingView = submatch(regex.ViewsMap[li.RegexUsed], li.Log, "viewid")
+			}
+
+			if !isPrimaryState(li.LogCtx.State()) {
+				closePeriod()
+				continue
+			}
+			if pendingView != "" && lastDate != nil && (current == nil || current.viewID != pendingView) {
+				closePeriod()
+				current = &primaryPeriod{node: node, viewID: pendingView, start: lastDate, seqno: -1}
+			}
+			pendingView = ""
+			lastPrimary = lastDate
+
+			if current == nil {
+				continue
+			}
+			switch li.RegexUsed {
+			case "RegexQuorumActID":
+				if seqno, err := strconv.ParseInt(submatch(regex.IdentsMap[li.RegexUsed], li.Log, "seqno"), 10, 64); err == nil && seqno > current.seqno {
+					current.seqno = seqno
+				}
+			case "RegexClusterUUIDFromQuorum":
+				if uuid := submatch(regex.IdentsMap[li.RegexUsed], li.Log, "uuid"); uuid != "" && uuid != "00000000-0000-0000-0000-000000000000" {
+					current.uuid = uuid
+				}
+			}
+		}
+		closePeriod()
+	}
+	return periods
+}
+
+func submatch(r *types.LogRegex, log, group string) string {
+	if r == nil || r.InternalRegex == nil {
+		return ""
+	}
+	submatches := r.InternalRegex.FindStringSubmatch(log)
+	idx := r.InternalRegex.SubexpIndex(group)
+	if submatches == nil || idx < 0 {
+		return ""
+	}
+	return submatches[idx]
+}
+
+// SplitBrains detects when nodes were part of different primary components at the same time:
+// each partition accepted writes, and their histories diverged
+// Logs from unrelated clusters are not compared with each other
+func SplitBrains(timeline types.Timeline) []SplitBrain {
+	splitBrains := []SplitBrain{}
+	for _, cluster := range timeline.SplitByCluster() {
+		splitBrains = append(splitBrains, clusterSplitBrains(cluster.Timeline)...)
+	}
+	sort.SliceStable(splitBrains, func(i, j int) bool { return splitBrains[i].Start.Time.Before(splitBrains[j].Start.Time) })
+	return splitBrains
+}
+
+func clusterSplitBrains(timeline types.Timeline) []SplitBrain {
+	periods := primaryPeriods(timeline)
+
+	type overlap struct {
+		start, end *types.Date
+		periods    []primaryPeriod
+	}
+	overlaps := []overlap{}
+	for i, p := range periods {
+		for _, q := range periods[i+1:] {
+			if p.node == q.node || p.viewID == q.viewID {
+				continue
+			}
+			start, end := p.start, p.end
+			if q.start.Time.After(start.Time) {
+				start = q.start
+			}
+			if q.end.Time.Before(end.Time) {
+				end = q.end
+			}
+			if end.Time.Sub(start.Time) < splitBrainMinOverlap {
+				continue
+			}
+			overlaps = append(overlaps, overlap{start: start, end: end, periods: []primaryPeriod{p, q}})
+		}
+	}
+	sort.SliceStable(overlaps, func(i, j int) bool { return overlaps[i].start.Time.Before(overlaps[j].start.Time) })
+
+	// overlapping periods are the same split-brain
+	merged := []overlap{}
+	for _, o := range overlaps {
+		if len(merged) > 0 && !o.start.Time.After(merged[len(merged)-1].end.Time) {
+			last := &merged[len(merged)-1]
+			if o.end.Time.After(last.end.Time) {
+				last.end = o.end
+			}
+			last.periods = append(last.periods, o.periods...)
+			continue
+		}
+		merged = append(merged, o)
+	}
+
+	splitBrains := []SplitBrain{}
+	for _, o := range merged {
+		partitions := splitBrainPartitions(o.periods)
+		if len(partitions) < 2 {
+			continue
+		}
+		splitBrains = append(splitBrains, SplitBrain{Start: o.start, End: o.end, Partitions: partitions})
+	}
+	return splitBrains
+}
+
+// splitBrainPartitions groups nodes which were seen in the same primary components
+func splitBrainPartitions(periods []primaryPeriod) []SplitBrainPartition {
+	// node => partition index, nodes sharing a view are merged
+	partitionOf := map[string]int{}
+	viewPartition := map[string]int{}
+	next := 0
+	for _, p := range periods {
+		nodeIdx, nodeKnown := partitionOf[p.node]
+		viewIdx, viewKnown := viewPartition[p.viewID]
+		switch {
+		case nodeKnown && viewKnown && nodeIdx != viewIdx:
+			for node, idx := range partitionOf {
+				if idx == viewIdx {
+					partitionOf[node] = nodeIdx
+				}
+			}
+			for view, idx := range viewPartition {
+				if idx == viewIdx {
+					viewPartition[view] = nodeIdx
+				}
+			}
+		case nodeKnown:
+			viewPartition[p.viewID] = nodeIdx
+		case viewKnown:
+			partitionOf[p.node] = viewIdx
+		default:
+			partitionOf[p.node] = next
+			viewPartition[p.viewID] = next
+			next++
+		}
+	}
+
+	byIdx := map[int]*SplitBrainPartition{}
+	latest := map[int]time.Time{}
+	for _, p := range periods {
+		idx := partitionOf[p.node]
+		partition, ok := byIdx[idx]
+		if !ok {
+			partition = &SplitBrainPartition{Seqno: -1}
+			byIdx[idx] = partition
+		}
+		if !utils.SliceContains(partition.Nodes, p.node) {
+			partition.Nodes = append(partition.Nodes, p.node)
+		}
+		if p.seqno > partition.Seqno {
+			partition.Seqno = p.seqno
+		}
+		if p.uuid != "" && !p.start.Time.Before(latest[idx]) {
+			partition.UUID = p.uuid
+			latest[idx] = p.start.Time
+		}
+	}
+
+	partitions := []SplitBrainPartition{}
+	for _, partition := range byIdx {
+		sort.Strings(partition.Nodes)
+		partitions = append(partitions, *partition)
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].Seqno != partitions[j].Seqno {
+			return partitions[i].Seqno > partitions[j].Seqno
+		}
+		return partitions[i].Nodes[0] < partitions[j].Nodes[0]
+	})
+	return partitions
+}
+
+// FlagSplitBrains adds an event to every node involved in a split-brain, when it started
+func FlagSplitBrains(timeline types.Timeline, splitBrains []SplitBrain) {
+	for _, sb := range splitBrains {
+		for _, partition := range sb.Partitions {
+			others := []string{}
+			for _, other := range sb.Partitions {
+				if other.Nodes[0] != partition.Nodes[0] {
+					others = append(others, other.Nodes...)
+				}
+			}
+			msg := utils.Paint(utils.BrightRedText, "SPLIT-BRAIN") + ": another primary component existed with " + strings.Join(others, ", ")
+			for _, node := range partition.Nodes {
+				timeline[node] = timeline[node].WithNotice(sb.Start, msg)
+			}
+		}
+	}
+}
+
+// SplitBrainsCLI prints every split-brain found, along with hints to recover from them
+func SplitBrainsCLI(out io.Writer, splitBrains []SplitBrain) {
+	var b strings.Builder
+
+	if len(splitBrains) == 0 {
+		b.WriteString("no split-brain found\n")
+	}
+
+	for i, sb := range splitBrains {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(utils.Paint(utils.BrightRedText, "split-brain") + " from " + sb.Start.DisplayTime + " to " + sb.End.DisplayTime + "\n")
+		for j, partition := range sb.Partitions {
+			seqno := "unknown"
+			if partition.Seqno >= 0 {
+				seqno = strconv.FormatInt(partition.Seqno, 10)
+			}
+			uuid := partition.UUID
+			if uuid == "" {
+				uuid = "unknown"
+			}
+			b.WriteString(fmt.Sprintf("\tpartition %d: nodes: %s, cluster uuid: %s, highest seqno: %s\n", j+1, strings.Join(partition.Nodes, ", "), uuid, seqno))
+		}
+
+		b.WriteString("\trecovery hints:\n")
+		reference := sb.Partitions[0]
+		if reference.Seqno < 0 || reference.Seqno == sb.Partitions[1].Seqno {
+			b.WriteString("\t- could not tell which partition has the highest seqno: compare positions given by 'mysqld --wsrep-recover' on each node before choosing the partition to keep\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("\t- the partition of %s has the highest seqno, it is the best candidate to keep as the reference\n", strings.Join(reference.Nodes, ", ")))
+		for _, partition := range sb.Partitions[1:] {
+			nodes := strings.Join(partition.Nodes, ", ")
+			b.WriteString(fmt.Sprintf("\t- %s must rejoin with a full SST: stop them, remove grastate.dat so that their state cannot be trusted, and start them\n", nodes))
+			b.WriteString(fmt.Sprintf("\t- transactions committed on %s during the split-brain are not in the reference partition, they have to be recovered manually (eg: from binlogs) before the SST overwrites them\n", nodes))
+		}
+	}
+	fmt.Fprint(out, b.String())
+}
@@ -0,0 +1,86 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestSplitBrains(t *testing.T) {
+	skipColor(t)
+
+	date := time.Date(2023, 3, 18, 21, 25, 8, 0, time.UTC)
+
+	event := func(offset time.Duration, regexUsed, log, state string) types.LogInfo {
+		logCtx := types.NewLogCtx()
+		logCtx.SetState(state)
+		return testEvent(date.Add(offset), &types.LogRegex{Type: types.ViewsRegexType}, regexUsed, "", log, logCtx)
+	}
+	joinView := func(offset time.Duration, view, seqno, state string) []types.LogInfo {
+		return []types.LogInfo{
+			event(offset, "RegexPrimaryView", "view (view_id(PRIM,"+view+")", "SYNCED"),
+			event(offset, "RegexNewComponent", "New COMPONENT: primary = yes, bootstrap = no, my_idx = 0, memb_num = 2", state),
+			event(offset, "RegexQuorumActID", "	act_id     = "+seqno+",", state),
+			event(offset, "RegexClusterUUIDFromQuorum", "	group UUID = 937dcf28-d38e-11ed-82ac-63ef4aef5b2a", state),
+		}
+	}
+	concat := func(slices ...[]types.LogInfo) types.LocalTimeline {
+		lt := types.LocalTimeline{}
+		for _, s := range slices {
+			lt = append(lt, s...)
+		}
+		return lt
+	}
+
+	timeline := types.Timeline{
+		// node1 and node2 stay together, view changes are not logged at the exact same time
+		"node1": concat(
+			joinView(0, "0509936f-8cc2,9", "100", "SYNCED"),
+			[]types.LogInfo{event(time.Minute, "RegexNodeLeft", "", "SYNCED")},
+			joinView(time.Minute+time.Second, "0509936f-8cc2,10", "150", "SYNCED"),
+			[]types.LogInfo{event(time.Hour, "RegexShiftToSynced", "", "SYNCED")},
+		),
+		"node2": concat(
+			joinView(100*time.Millisecond, "0509936f-8cc2,9", "100", "SYNCED"),
+			joinView(time.Minute+time.Second+100*time.Millisecond, "0509936f-8cc2,10", "180", "SYNCED"),
+			[]types.LogInfo{event(time.Hour, "RegexShiftToSynced", "", "SYNCED")},
+		),
+		// node3 got partitioned, then someone forced it to be primary
+		"node3": concat(
+			joinView(0, "0509936f-8cc2,9", "100", "SYNCED"),
+			[]types.LogInfo{event(time.Minute, "RegexNewComponent", "New COMPONENT: primary = no, bootstrap = no, my_idx = 0, memb_num = 1", "NON-PRIMARY")},
+			joinView(10*time.Minute, "7026494c-a649,10", "120", "SYNCED"),
+			[]types.LogInfo{event(time.Hour, "RegexShiftToSynced", "", "SYNCED")},
+		),
+	}
+
+	splitBrains := SplitBrains(timeline)
+
+	var b strings.Builder
+	SplitBrainsCLI(&b, splitBrains)
+	expected := `split-brain from 2023-03-18T21:35:08.000000Z to 2023-03-18T22:25:08.000000Z
+	partition 1: nodes: node1, node2, cluster uuid: 937dcf28-d38e-11ed-82ac-63ef4aef5b2a, highest seqno: 180
+	partition 2: nodes: node3, cluster uuid: 937dcf28-d38e-11ed-82ac-63ef4aef5b2a, highest seqno: 120
+	recovery hints:
+	- the partition of node1, node2 has the highest seqno, it is the best candidate to keep as the reference
+	- node3 must rejoin with a full SST: stop them, remove grastate.dat so that their state cannot be trusted, and start them
+	- transactions committed on node3 during the split-brain are not in the reference partition, they have to be recovered manually (eg: from binlogs) before the SST overwrites them
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+
+	FlagSplitBrains(timeline, splitBrains)
+	notice := timeline["node3"][len(timeline["node3"])-2]
+	if msg := notice.Msg(notice.LogCtx); notice.RegexType != types.NoticeRegexType || msg != "SPLIT-BRAIN: another primary component existed with node1, node2" {
+		t.Errorf("expected a split-brain notice on node3, got %s: %s", notice.RegexType, msg)
+	}
+
+	// the same cluster without node3 being forced primary
+	delete(timeline, "node3")
+	if splitBrains := SplitBrains(timeline); len(splitBrains) != 0 {
+		t.Errorf("expected no split-brain, got %v", splitBrains)
+	}
+}
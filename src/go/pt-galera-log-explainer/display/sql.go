@@ -0,0 +1,128 @@
+package display
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+const sqlSchema = `DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS files;
+DROP TABLE IF EXISTS nodes;
+CREATE TABLE nodes (
+	identifier TEXT PRIMARY KEY,
+	last_ip TEXT,
+	last_name TEXT,
+	version TEXT,
+	ips TEXT,
+	names TEXT,
+	hashes TEXT
+);
+CREATE TABLE files (
+	id INTEGER PRIMARY KEY,
+	node TEXT REFERENCES nodes(identifier),
+	path TEXT
+);
+CREATE TABLE events (
+	id INTEGER PRIMARY KEY,
+	node TEXT REFERENCES nodes(identifier),
+	file_id INTEGER REFERENCES files(id),
+	timestamp TEXT,
+	display_time TEXT,
+	type TEXT,
+	regex TEXT,
+	verbosity INTEGER,
+	repetition_count INTEGER,
+	message TEXT,
+	state TEXT,
+	member_count INTEGER,
+	desynced INTEGER,
+	file_type TEXT,
+	log TEXT,
+	ctx TEXT
+);
+CREATE INDEX events_timestamp ON events(timestamp);
+CREATE INDEX events_node ON events(node);
+`
+
+// TimelineSQL writes a SQL script creating and filling the events, nodes and files tables
+// Every event is written whatever its verbosity, along with the context it was found with,
+// so that it can be filtered later with ad-hoc queries. Categories hidden by users are not written.
+// It only relies on plain SQL, it is meant to be piped to sqlite3
+func TimelineSQL(out io.Writer, timeline types.Timeline) error {
+
+	w := bufio.NewWriter(out)
+
+	fmt.Fprintln(w, "BEGIN TRANSACTION;")
+	fmt.Fprint(w, sqlSchema)
+
+	keys, _ := initKeysContext(timeline)
+	latestContext := timeline.GetLatestContextsByNodes()
+
+	for _, node := range keys {
+		logCtx := latestContext[node]
+		fmt.Fprintf(w, "INSERT INTO nodes VALUES (%s, %s, %s, %s, %s, %s, %s);\n",
+			sqlString(node), sqlString(lastOf(logCtx.OwnIPs)), sqlString(lastOf(logCtx.OwnNames)), sqlString(logCtx.Version),
+			sqlString(strings.Join(logCtx.OwnIPs, ",")), sqlString(strings.Join(logCtx.OwnNames, ",")), sqlString(strings.Join(logCtx.OwnHashes, ",")))
+	}
+
+	files := map[string]int{}
+
+	// dequeued chronologically, so that ids are ordered the same way as the list output
+	id := 0
+	for _, event := range dequeueAll(timeline) {
+		node, li := event.node, event.li
+		if li.Verbosity == types.Hidden {
+			continue
+		}
+		id++
+
+		filekey := node + "\x00" + li.LogCtx.FilePath
+		fileID, ok := files[filekey]
+		if !ok {
+			fileID = len(files) + 1
+			files[filekey] = fileID
+			fmt.Fprintf(w, "INSERT INTO files VALUES (%d, %s, %s);\n", fileID, sqlString(node), sqlString(li.LogCtx.FilePath))
+		}
+
+		ctx, err := json.Marshal(&li.LogCtx)
+		if err != nil {
+			return err
+		}
+
+		timestamp, displayTime := "NULL", "NULL"
+		if li.Date != nil {
+			timestamp = sqlString(li.Date.Time.Format(time.RFC3339Nano))
+			displayTime = sqlString(li.Date.DisplayTime)
+		}
+		desynced := 0
+		if li.LogCtx.Desynced {
+			desynced = 1
+		}
+
+		fmt.Fprintf(w, "INSERT INTO events VALUES (%d, %s, %d, %s, %s, %s, %s, %d, %d, %s, %s, %d, %d, %s, %s, %s);\n",
+			id, sqlString(node), fileID, timestamp, displayTime, sqlString(string(li.RegexType)), sqlString(li.RegexUsed),
+			li.Verbosity, li.RepetitionCount, sqlString(utils.RemoveColor(li.Msg(latestContext[node]))), sqlString(li.LogCtx.State()),
+			li.LogCtx.MemberCount, desynced, sqlString(li.LogCtx.FileType), sqlString(li.Log), sqlString(string(ctx)))
+	}
+
+	fmt.Fprintln(w, "COMMIT;")
+	return w.Flush()
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func lastOf(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1]
+}
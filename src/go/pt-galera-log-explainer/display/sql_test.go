@@ -0,0 +1,42 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestTimelineSQL(t *testing.T) {
+	logCtx := types.NewLogCtx()
+	logCtx.FilePath = "node1's.log"
+	logCtx.OwnNames = []string{"node1"}
+
+	date := time.Date(2023, 3, 12, 7, 24, 13, 0, time.UTC)
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			types.NewLogInfo(types.NewDate(date, "2006-01-02T15:04:05.000000Z"), types.SimpleDisplayer("it's starting"), "raw log", &types.LogRegex{Type: types.EventsRegexType}, "RegexStarting", logCtx, "error.log"),
+		},
+	}
+
+	var b strings.Builder
+	err := TimelineSQL(&b, timeline)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+
+	for _, expected := range []string{
+		"INSERT INTO nodes VALUES ('node1', '', 'node1', '', '', 'node1', '');",
+		"INSERT INTO files VALUES (1, 'node1', 'node1''s.log');",
+		"INSERT INTO events VALUES (1, 'node1', 1, '2023-03-12T07:24:13Z', '2023-03-12T07:24:13.000000Z', 'events', 'RegexStarting', 0, 0, 'it''s starting', '', 0, 0, '', 'raw log', ",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected to find %s\ngot:\n%s", expected, out)
+		}
+	}
+	if !strings.HasPrefix(out, "BEGIN TRANSACTION;") || !strings.HasSuffix(out, "COMMIT;\n") {
+		t.Errorf("expected a single transaction, got:\n%s", out)
+	}
+}
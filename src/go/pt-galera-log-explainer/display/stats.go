@@ -0,0 +1,86 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ladicle/tabwriter"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// statsCategories are the events counted per node, in display order
+var statsCategories = []struct {
+	name    string
+	regexes []string
+}{
+	{name: "restarts", regexes: []string{"RegexStarting"}},
+	{name: "view changes", regexes: []string{"RegexNewComponent"}},
+	{name: "SST requests", regexes: []string{"RegexSSTRequestSuccess"}},
+	{name: "flow control", regexes: []string{"RegexFlowControl"}},
+	{name: "certification failures", regexes: []string{"RegexCertificationFailure"}},
+	{name: "crashes", regexes: []string{"RegexGotSignal6", "RegexGotSignal11", "RegexAssertionFailure"}},
+}
+
+// TimelineStats prints a per-node overview: how many times each category of events happened,
+// and the time range covered by logs
+func TimelineStats(out io.Writer, timeline types.Timeline) error {
+
+	keys, _ := initKeysContext(timeline)
+
+	w := tabwriter.NewWriter(out, 8, 8, 3, ' ', tabwriter.DiscardEmptyColumns)
+
+	fmt.Fprintln(w, headerNodes(keys))
+	fmt.Fprintln(w, separator(keys))
+
+	firsts, lasts, durations := []string{"first event"}, []string{"last event"}, []string{"covered duration"}
+	for _, node := range keys {
+		first, last := timelineBoundaries(timeline[node])
+		if first == nil || last == nil {
+			firsts, lasts, durations = append(firsts, ""), append(lasts, ""), append(durations, "")
+			continue
+		}
+		firsts = append(firsts, first.DisplayTime)
+		lasts = append(lasts, last.DisplayTime)
+		durations = append(durations, last.Time.Sub(first.Time).Round(time.Second).String())
+	}
+	fmt.Fprintln(w, strings.Join(firsts, "\t")+"\t")
+	fmt.Fprintln(w, strings.Join(lasts, "\t")+"\t")
+	fmt.Fprintln(w, strings.Join(durations, "\t")+"\t")
+	fmt.Fprintln(w, separator(keys))
+
+	for _, category := range statsCategories {
+		row := []string{category.name}
+		for _, node := range keys {
+			count := 0
+			for _, li := range timeline[node] {
+				if utils.SliceContains(category.regexes, li.RegexUsed) {
+					// deduplicated events are still counted
+					count += 1 + li.RepetitionCount
+				}
+			}
+			row = append(row, strconv.Itoa(count))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+	}
+
+	return w.Flush()
+}
+
+// timelineBoundaries returns the first and last dates found in a node timeline
+func timelineBoundaries(lt types.LocalTimeline) (*types.Date, *types.Date) {
+	var first, last *types.Date
+	for _, li := range lt {
+		if li.Date == nil {
+			continue
+		}
+		if first == nil {
+			first = li.Date
+		}
+		last = li.Date
+	}
+	return first, last
+}
@@ -0,0 +1,44 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestTimelineStats(t *testing.T) {
+	logCtx := types.NewLogCtx()
+	date := time.Date(2023, 3, 12, 7, 24, 13, 0, time.UTC)
+
+	restart := testEvent(date, &types.LogRegex{Type: types.EventsRegexType}, "RegexStarting", "starting(8.0.28)", "", logCtx)
+	restart.RepetitionCount = 2
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			restart,
+			testEvent(date.Add(time.Hour), &types.LogRegex{Type: types.EventsRegexType}, "RegexGotSignal11", "crash: got signal 11", "", logCtx),
+		},
+	}
+
+	var b strings.Builder
+	err := TimelineStats(&b, timeline)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+
+	for _, expected := range []string{
+		"first event              2023-03-12T07:24:13.000000Z",
+		"last event               2023-03-12T08:24:13.000000Z",
+		"covered duration         1h0m0s",
+		"restarts                 3",
+		"view changes             0",
+		"crashes                  1",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected to find %q\ngot:\n%s", expected, out)
+		}
+	}
+}
@@ -15,7 +15,8 @@ import (
 
 // TimelineCLI print a timeline to the terminal using tabulated format
 // It will print header and footers, and dequeue the timeline chronologically
-func TimelineCLI(timeline types.Timeline, verbosity types.Verbosity) {
+// Only the events inside the window are printed, layout selects the columns
+func TimelineCLI(timeline types.Timeline, verbosity types.Verbosity, window Window, layout Layout) {
 
 	timeline = removeEmptyColumns(timeline, verbosity)
 
@@ -26,20 +27,26 @@ func TimelineCLI(timeline types.Timeline, verbosity types.Verbosity) {
 	latestContext := timeline.GetLatestContextsByNodes()        // so that we have fully updated context when we print
 	lastContext := make(map[string]types.LogCtx, len(timeline)) // just to follow when important thing changed
 
+	// after contexts were initialized, so that headers still describe the whole logs
+	timeline = window.apply(timeline, verbosity, latestContext)
+
+	layout = layout.fit(len(keys), dateWidth(timeline))
+
 	w := tabwriter.NewWriter(os.Stdout, 8, 8, 3, ' ', tabwriter.DiscardEmptyColumns)
 	defer w.Flush()
 
 	// header
-	fmt.Fprintln(w, headerNodes(keys))
-	fmt.Fprintln(w, headerFilePath(keys, currentContext))
-	fmt.Fprintln(w, headerIP(keys, latestContext))
-	fmt.Fprintln(w, headerName(keys, latestContext))
-	fmt.Fprintln(w, headerVersion(keys, latestContext))
-	fmt.Fprintln(w, separator(keys))
+	fmt.Fprintln(w, layout.header(headerNodes(keys)))
+	fmt.Fprintln(w, layout.header(headerFilePath(keys, currentContext)))
+	fmt.Fprintln(w, layout.header(headerIP(keys, latestContext)))
+	fmt.Fprintln(w, layout.header(headerName(keys, latestContext)))
+	fmt.Fprintln(w, layout.header(headerVersion(keys, latestContext)))
+	fmt.Fprintln(w, layout.pad(separator(keys)))
 
 	var (
-		args      []string // stuff to print
-		linecount int
+		args         []string // stuff to print
+		linecount    int
+		previousDate *types.Date // of the previous line printed
 	)
 
 	// as long as there is a next event to print
@@ -47,12 +54,11 @@ func TimelineCLI(timeline types.Timeline, verbosity types.Verbosity) {
 
 		// Date column
 		date := timeline[nextNodes[0]][0].Date
-		args = []string{""}
-		if date != nil {
-			args = []string{date.DisplayTime}
-		}
+		args = layout.leading(date, previousDate)
 
 		displayedValue := 0
+		// lines of wrapped messages, after the first one
+		wrapped := map[string][]string{}
 
 		// node values
 		for _, node := range keys {
@@ -71,8 +77,12 @@ func TimelineCLI(timeline types.Timeline, verbosity types.Verbosity) {
 			timeline.Dequeue(node)
 
 			msg := loginfo.Msg(latestContext[node])
-			if verbosity >= loginfo.Verbosity && msg != "" {
-				args = append(args, msg)
+			if verbosity.Displays(loginfo.Verbosity) && msg != "" {
+				lines := layout.cell(loginfo, msg)
+				args = append(args, lines[0])
+				if len(lines) > 1 {
+					wrapped[node] = lines[1:]
+				}
 				displayedValue++
 			} else {
 				args = append(args, utils.PaintForState("| ", loginfo.LogCtx.State()))
@@ -87,7 +97,7 @@ func TimelineCLI(timeline types.Timeline, verbosity types.Verbosity) {
 				lastContext[k] = v
 			}
 			// print transition
-			fmt.Fprintln(w, sep)
+			fmt.Fprintln(w, layout.pad(sep))
 		}
 
 		// If line is not filled with default placeholder values
@@ -101,23 +111,66 @@ func TimelineCLI(timeline types.Timeline, verbosity types.Verbosity) {
 		if err != nil {
 			log.Println("Failed to write a line", err)
 		}
+		for _, line := range wrappedLines(keys, wrapped, currentContext, layout) {
+			fmt.Fprintln(w, line)
+		}
 		linecount++
+		if date != nil {
+			previousDate = date
+		}
 	}
 
 	// footer
 	// only having a header is not fast enough to read when there are too many lines
 	if linecount >= 50 {
-		fmt.Fprintln(w, separator(keys))
-		fmt.Fprintln(w, headerNodes(keys))
-		fmt.Fprintln(w, headerFilePath(keys, currentContext))
-		fmt.Fprintln(w, headerIP(keys, currentContext))
-		fmt.Fprintln(w, headerName(keys, currentContext))
-		fmt.Fprintln(w, headerVersion(keys, currentContext))
+		fmt.Fprintln(w, layout.pad(separator(keys)))
+		fmt.Fprintln(w, layout.header(headerNodes(keys)))
+		fmt.Fprintln(w, layout.header(headerFilePath(keys, currentContext)))
+		fmt.Fprintln(w, layout.header(headerIP(keys, currentContext)))
+		fmt.Fprintln(w, layout.header(headerName(keys, currentContext)))
+		fmt.Fprintln(w, layout.header(headerVersion(keys, currentContext)))
 	}
 
 	// TODO: where to print conflicts details ?
 }
 
+// wrappedLines prints the next lines of wrapped messages, other columns only get their placeholder
+func wrappedLines(keys []string, wrapped map[string][]string, currentContext map[string]types.LogCtx, layout Layout) []string {
+	lines := []string{}
+	for i := 0; ; i++ {
+		args := make([]string, layout.leadingColumns(), layout.leadingColumns()+len(keys))
+		found := false
+		for _, node := range keys {
+			if i < len(wrapped[node]) {
+				args = append(args, wrapped[node][i])
+				found = true
+			} else {
+				args = append(args, utils.PaintForState("| ", currentContext[node].State()))
+			}
+		}
+		if !found {
+			return lines
+		}
+		lines = append(lines, strings.Join(args, "\t")+"\t")
+	}
+}
+
+// dateWidth is the width of the dates printed in the leading column
+func dateWidth(timeline types.Timeline) int {
+	width := 0
+	for _, lt := range timeline {
+		for _, li := range lt {
+			if li.Date != nil {
+				if len(li.Date.DisplayTime) > width {
+					width = len(li.Date.DisplayTime)
+				}
+				break
+			}
+		}
+	}
+	return width
+}
+
 func initKeysContext(timeline types.Timeline) ([]string, map[string]types.LogCtx) {
 	currentContext := map[string]types.LogCtx{}
 
@@ -177,7 +230,12 @@ func headerVersion(keys []string, logCtxs map[string]types.LogCtx) string {
 	header := "mysql version\t"
 	for _, node := range keys {
 		if logCtx, ok := logCtxs[node]; ok {
-			header += logCtx.Version + "\t"
+			header += logCtx.Version
+			// the regex profile tells which version-specific wordings were looked for
+			if profile := logCtx.Profile(); profile != "" {
+				header += " (" + string(profile) + ")"
+			}
+			header += "\t"
 		}
 	}
 	return header
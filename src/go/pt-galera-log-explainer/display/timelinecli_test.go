@@ -185,3 +185,16 @@ func TestTransitionSeparator(t *testing.T) {
 
 	}
 }
+
+func TestHeaderVersion(t *testing.T) {
+	logCtxs := map[string]types.LogCtx{
+		"node0": {Version: "8.0.28"},
+		"node1": {Version: "5.7.40", ProviderVersion: "3.65"},
+		"node2": {},
+	}
+
+	expected := "mysql version\t8.0.28 (galera4)\t5.7.40 (galera3)\t\t"
+	if out := headerVersion([]string{"node0", "node1", "node2"}, logCtxs); out != expected {
+		t.Errorf("expected: %#v, got: %#v", expected, out)
+	}
+}
@@ -0,0 +1,225 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+// istHints and sstHints are events telling which kind of state transfer happened
+// They are only trusted when logged by the donor or the joiner
+var (
+	istHints = []string{"RegexISTSender", "RegexISTReceived", "RegexXtrabackupISTReceived", "RegexBypassSST"}
+	sstHints = []string{"RegexSSTStreamingTo", "RegexFailedToPrepareIST"}
+)
+
+// StateTransfer is a donor/joiner pairing, from the donor selection to its outcome
+type StateTransfer struct {
+	Donor  string
+	Joiner string
+	// "SST", "IST", or empty when no log could tell
+	Type  string
+	Start time.Time
+	// nil when neither the completion nor the failure were found
+	End    *time.Time
+	Failed bool
+}
+
+// StateTransfers lists every state transfer found in the timeline, sorted by donor selection time
+// The same transfer is usually logged by every node, they are merged together
+func StateTransfers(timeline types.Timeline) []StateTransfer {
+	// "donor -> joiner" => transfers as seen by each node
+	seen := map[string][]StateTransfer{}
+
+	for _, lt := range timeline {
+		// donor => transfer
+		started := map[string]*StateTransfer{}
+		end := func(transfer *StateTransfer) {
+			key := transfer.Donor + " -> " + transfer.Joiner
+			seen[key] = append(seen[key], *transfer)
+			delete(started, transfer.Donor)
+		}
+
+		for _, li := range lt {
+			// LogCtx.SSTs cannot be used: the map is shared between every events of a file
+			if li.Date == nil {
+				continue
+			}
+			switch {
+			case li.RegexUsed == "RegexSSTRequestSuccess":
+				joiner, donor, ok := sstNodes(regex.SSTMap[li.RegexUsed], li.Log)
+				if !ok {
+					break
+				}
+				if previous, ok := started[donor]; ok {
+					end(previous)
+				}
+				started[donor] = &StateTransfer{Donor: donor, Joiner: joiner, Start: li.Date.Time}
+
+			case li.RegexUsed == "RegexSSTComplete" || li.RegexUsed == "RegexSSTStateTransferFailed":
+				donor, joiner, ok := sstNodes(regex.SSTMap[li.RegexUsed], li.Log)
+				transfer, found := started[donor]
+				if !ok || !found || transfer.Joiner != joiner {
+					break
+				}
+				transfer.End = &li.Date.Time
+				transfer.Failed = li.RegexUsed == "RegexSSTStateTransferFailed"
+				end(transfer)
+
+			// the joiner left the group before the end of the transfer
+			case li.RegexUsed == "RegexSSTCompleteUnknown" || li.RegexUsed == "RegexSSTFailedUnknown":
+				transfer, found := started[utils.ShortNodeName(submatch(regex.SSTMap[li.RegexUsed], li.Log, "nodename"))]
+				if !found {
+					break
+				}
+				transfer.End = &li.Date.Time
+				transfer.Failed = li.RegexUsed == "RegexSSTFailedUnknown"
+				end(transfer)
+
+			case utils.SliceContains(sstHints, li.RegexUsed), utils.SliceContains(istHints, li.RegexUsed):
+				for _, transfer := range started {
+					if !utils.SliceContains(li.LogCtx.OwnNames, transfer.Donor) && !utils.SliceContains(li.LogCtx.OwnNames, transfer.Joiner) {
+						continue
+					}
+					// joiners usually receive an IST right after a SST, to catch up with what happened meanwhile
+					if utils.SliceContains(sstHints, li.RegexUsed) {
+						transfer.Type = "SST"
+					} else if transfer.Type == "" {
+						transfer.Type = "IST"
+					}
+				}
+			}
+		}
+		for _, transfer := range started {
+			end(transfer)
+		}
+	}
+
+	out := []StateTransfer{}
+	for _, key := range sortedKeys(seen) {
+		transfers := seen[key]
+		sort.SliceStable(transfers, func(i, j int) bool { return transfers[i].Start.Before(transfers[j].Start) })
+
+		// nodes log the same selection with a few milliseconds of difference
+		var merged *StateTransfer
+		for _, transfer := range transfers {
+			if merged == nil || transfer.Start.Sub(merged.Start) >= time.Second {
+				if merged != nil {
+					out = append(out, *merged)
+				}
+				t := transfer
+				merged = &t
+				continue
+			}
+			if merged.End == nil {
+				merged.End = transfer.End
+			}
+			merged.Failed = merged.Failed || transfer.Failed
+			if transfer.Type == "SST" || merged.Type == "" {
+				merged.Type = transfer.Type
+			}
+		}
+		if merged != nil {
+			out = append(out, *merged)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+func (t StateTransfer) typeName() string {
+	if t.Type == "" {
+		return "SST/IST"
+	}
+	return t.Type
+}
+
+func (t StateTransfer) outcome() string {
+	switch {
+	case t.End == nil:
+		return "outcome unknown"
+	case t.Failed:
+		return fmt.Sprintf("failed after %s", t.End.Sub(t.Start).Round(time.Second))
+	default:
+		return fmt.Sprintf("completed in %s", t.End.Sub(t.Start).Round(time.Second))
+	}
+}
+
+// TransfersCLI draws an arrow from the donor to the joiner for each state transfer
+func TransfersCLI(out io.Writer, transfers []StateTransfer) error {
+	var b strings.Builder
+
+	if len(transfers) == 0 {
+		b.WriteString("no state transfer found\n")
+	}
+
+	width := 0
+	for _, transfer := range transfers {
+		if len(transfer.Donor) > width {
+			width = len(transfer.Donor)
+		}
+	}
+
+	for _, transfer := range transfers {
+		outcome := transfer.outcome()
+		switch {
+		case transfer.End == nil:
+			outcome = utils.Paint(utils.YellowText, outcome)
+		case transfer.Failed:
+			outcome = utils.Paint(utils.RedText, outcome)
+		default:
+			outcome = utils.Paint(utils.GreenText, outcome)
+		}
+		b.WriteString(fmt.Sprintf("%s %s ──%s──> %s: %s\n",
+			transfer.Start.Format("2006-01-02T15:04:05.000000Z"),
+			utils.Paint(utils.BlueText, fmt.Sprintf("%*s", width, transfer.Donor)),
+			transfer.typeName(),
+			utils.Paint(utils.BlueText, transfer.Joiner),
+			outcome))
+	}
+
+	_, err := fmt.Fprint(out, b.String())
+	return err
+}
+
+// TransfersMermaid writes state transfers as a mermaid sequence diagram, rendered by most markdown viewers
+func TransfersMermaid(out io.Writer, transfers []StateTransfer) error {
+	var b strings.Builder
+
+	b.WriteString("sequenceDiagram\n")
+
+	// node names can contain characters mermaid would not accept as identifiers
+	participants := map[string]string{}
+	for _, transfer := range transfers {
+		for _, node := range []string{transfer.Donor, transfer.Joiner} {
+			if _, ok := participants[node]; ok {
+				continue
+			}
+			participants[node] = fmt.Sprintf("n%d", len(participants))
+			b.WriteString(fmt.Sprintf("    participant %s as %s\n", participants[node], mermaidText(node)))
+		}
+	}
+
+	for _, transfer := range transfers {
+		arrow := "->>"
+		if transfer.Failed {
+			arrow = "-x"
+		}
+		b.WriteString(fmt.Sprintf("    %s%s%s: %s at %s, %s\n", participants[transfer.Donor], arrow, participants[transfer.Joiner],
+			transfer.typeName(), transfer.Start.Format("2006-01-02T15:04:05Z"), transfer.outcome()))
+	}
+
+	_, err := fmt.Fprint(out, b.String())
+	return err
+}
+
+// mermaidText removes what would break mermaid syntax
+func mermaidText(s string) string {
+	return strings.NewReplacer(":", " ", ";", " ", "#", " ", "\n", " ").Replace(utils.RemoveColor(s))
+}
@@ -0,0 +1,71 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestTransfers(t *testing.T) {
+	skipColor(t)
+
+	date := time.Date(2023, 3, 18, 21, 25, 8, 0, time.UTC)
+
+	event := func(node string, offset time.Duration, regexUsed, log string) types.LogInfo {
+		logCtx := types.NewLogCtx()
+		logCtx.OwnNames = []string{node}
+		return testEvent(date.Add(offset), &types.LogRegex{Type: types.SSTRegexType}, regexUsed, "", log, logCtx)
+	}
+	requested := func(node, joiner, donor string, offset time.Duration) types.LogInfo {
+		return event(node, offset, "RegexSSTRequestSuccess", "[Galera] Member 0.0 ("+joiner+") requested state transfer from '*any*'. Selected 1.0 ("+donor+")(SYNCED) as donor.")
+	}
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			requested("node1", "node1", "node2", 0),
+			event("node1", 10*time.Minute, "RegexSSTComplete", "[Galera] 1.0 (node2): State transfer to 0.0 (node1) complete."),
+			requested("node1", "node3", "node2", time.Hour),
+		},
+		"node2": types.LocalTimeline{
+			requested("node2", "node1", "node2", 5*time.Millisecond),
+			event("node2", time.Second, "RegexSSTStreamingTo", "Streaming the backup to joiner at 172.17.0.2"),
+			event("node2", 10*time.Minute, "RegexSSTComplete", "[Galera] 1.0 (node2): State transfer to 0.0 (node1) complete."),
+			requested("node2", "node3", "node2", time.Hour),
+			event("node2", time.Hour+time.Second, "RegexISTSender", "IST sender starting to serve tcp://172.17.0.4:4568 sending 125-130"),
+			event("node2", time.Hour+30*time.Second, "RegexSSTStateTransferFailed", "[Galera] 1.0 (node2): State transfer to 0.0 (node3) failed: -110 (Connection timed out)"),
+			requested("node2", "node1", "node2", 2*time.Hour),
+		},
+	}
+
+	transfers := StateTransfers(timeline)
+
+	var b strings.Builder
+	if err := TransfersCLI(&b, transfers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `2023-03-18T21:25:08.000000Z node2 ──SST──> node1: completed in 10m0s
+2023-03-18T22:25:08.000000Z node2 ──IST──> node3: failed after 30s
+2023-03-18T23:25:08.000000Z node2 ──SST/IST──> node1: outcome unknown
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+
+	b.Reset()
+	if err := TransfersMermaid(&b, transfers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = `sequenceDiagram
+    participant n0 as node2
+    participant n1 as node1
+    participant n2 as node3
+    n0->>n1: SST at 2023-03-18T21:25:08Z, completed in 10m0s
+    n0-xn2: IST at 2023-03-18T22:25:08Z, failed after 30s
+    n0->>n1: SST/IST at 2023-03-18T23:25:08Z, outcome unknown
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,273 @@
+package display
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/rivo/tview"
+)
+
+const tuiHelp = "[::b]up/down/pgup/pgdn[::-] scroll  [::b]v/V[::-] next/previous view change  [::b]s/S[::-] next/previous SST  " +
+	"[::b]/[::-] filter  [::b]1-9[::-] collapse/expand node  [::b]enter[::-] raw log context  [::b]q[::-] quit"
+
+// number of raw log lines to show before and after an event
+const tuiRawContextLines = 10
+
+// tuiRow is a single event of the merged timeline
+type tuiRow struct {
+	date string
+	node string
+	msg  string
+	li   types.LogInfo
+}
+
+type tui struct {
+	app    *tview.Application
+	pages  *tview.Pages
+	table  *tview.Table
+	status *tview.TextView
+	filter *tview.InputField
+
+	keys      []string
+	collapsed map[string]bool
+	rows      []tuiRow
+	visible   []int // indexes of rows matching the current filter
+	filterRe  *regexp.Regexp
+}
+
+// TimelineTUI opens an interactive browser on the merged timeline
+// It lets the user scroll, jump between view changes and SSTs, filter events, collapse nodes and open raw logs
+func TimelineTUI(timeline types.Timeline, verbosity types.Verbosity) error {
+
+	timeline = removeEmptyColumns(timeline, verbosity)
+	keys, _ := initKeysContext(timeline)
+	latestContext := timeline.GetLatestContextsByNodes()
+
+	t := &tui{
+		app:       tview.NewApplication(),
+		keys:      keys,
+		collapsed: map[string]bool{},
+	}
+	for _, event := range dequeueAll(timeline) {
+		msg := event.li.Msg(latestContext[event.node])
+		if !verbosity.Displays(event.li.Verbosity) || msg == "" {
+			continue
+		}
+		row := tuiRow{node: event.node, msg: msg, li: event.li}
+		if event.li.Date != nil {
+			row.date = event.li.Date.DisplayTime
+		}
+		t.rows = append(t.rows, row)
+	}
+
+	t.table = tview.NewTable().SetFixed(1, 1).SetSelectable(true, false).SetSeparator(tview.Borders.Vertical)
+	t.table.SetInputCapture(t.handleKey)
+	t.table.SetSelectedFunc(func(row, _ int) { t.showRawContext(row) })
+
+	t.status = tview.NewTextView().SetDynamicColors(true).SetText(tuiHelp)
+
+	t.filter = tview.NewInputField().SetLabel("filter (regex): ")
+	t.filter.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			t.setFilter(t.filter.GetText())
+		}
+		t.pages.SwitchToPage("timeline")
+		t.app.SetFocus(t.table)
+	})
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(t.table, 0, 1, true).
+		AddItem(t.status, 1, 0, false)
+	filterLayout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(t.table, 0, 1, false).
+		AddItem(t.filter, 1, 0, true)
+
+	t.pages = tview.NewPages().
+		AddPage("timeline", layout, true, true).
+		AddPage("filter", filterLayout, true, false)
+
+	t.setFilter("")
+	return t.app.SetRoot(t.pages, true).Run()
+}
+
+func (t *tui) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	row, _ := t.table.GetSelection()
+	switch event.Rune() {
+	case 'q':
+		t.app.Stop()
+	case '/':
+		t.pages.SwitchToPage("filter")
+		t.app.SetFocus(t.filter)
+	case 'v':
+		t.selectRow(nextRow(t.rows, t.visible, row-1, 1, types.ViewsRegexType))
+	case 'V':
+		t.selectRow(nextRow(t.rows, t.visible, row-1, -1, types.ViewsRegexType))
+	case 's':
+		t.selectRow(nextRow(t.rows, t.visible, row-1, 1, types.SSTRegexType))
+	case 'S':
+		t.selectRow(nextRow(t.rows, t.visible, row-1, -1, types.SSTRegexType))
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		i, _ := strconv.Atoi(string(event.Rune()))
+		if i <= len(t.keys) {
+			node := t.keys[i-1]
+			t.collapsed[node] = !t.collapsed[node]
+			t.render()
+		}
+	default:
+		return event
+	}
+	return nil
+}
+
+// selectRow takes an index from visible rows, -1 means nothing was found
+func (t *tui) selectRow(i int) {
+	if i < 0 {
+		return
+	}
+	// first row is the header
+	t.table.Select(i+1, 0)
+}
+
+func (t *tui) setFilter(filter string) {
+	t.filterRe = nil
+	if filter != "" {
+		re, err := regexp.Compile(filter)
+		if err != nil {
+			t.status.SetText("[red]invalid filter: " + tview.Escape(err.Error()))
+			return
+		}
+		t.filterRe = re
+	}
+	t.visible = filterRows(t.rows, t.filterRe)
+	t.status.SetText(tuiHelp)
+	t.render()
+}
+
+func (t *tui) render() {
+	t.table.Clear()
+
+	t.table.SetCell(0, 0, tview.NewTableCell("[::b]date").SetSelectable(false))
+	for col, node := range t.keys {
+		header := fmt.Sprintf("[::b]%d:%s", col+1, tview.Escape(node))
+		if t.collapsed[node] {
+			header = fmt.Sprintf("[::b]%d:+", col+1)
+		}
+		t.table.SetCell(0, col+1, tview.NewTableCell(header).SetSelectable(false))
+	}
+
+	for i, rowIdx := range t.visible {
+		row := t.rows[rowIdx]
+		t.table.SetCell(i+1, 0, tview.NewTableCell(row.date))
+		for col, node := range t.keys {
+			cell := ""
+			if node == row.node {
+				cell = ansiToTview(row.msg)
+				if t.collapsed[node] {
+					cell = "*"
+				}
+			}
+			t.table.SetCell(i+1, col+1, tview.NewTableCell(cell))
+		}
+	}
+	t.table.ScrollToBeginning()
+	t.table.Select(1, 0)
+}
+
+func (t *tui) showRawContext(tableRow int) {
+	if tableRow < 1 || tableRow > len(t.visible) {
+		return
+	}
+	row := t.rows[t.visible[tableRow-1]]
+
+	text, err := rawContext(row.li.LogCtx.FilePath, row.li.Log, tuiRawContextLines)
+	if err != nil {
+		text = "[red]" + tview.Escape(err.Error())
+	}
+
+	view := tview.NewTextView().SetDynamicColors(true).SetText(text)
+	view.SetBorder(true).SetTitle(" " + row.li.LogCtx.FilePath + " (esc to close) ")
+	view.SetDoneFunc(func(tcell.Key) {
+		t.pages.RemovePage("raw")
+		t.app.SetFocus(t.table)
+	})
+	t.pages.AddPage("raw", view, true, true)
+	t.app.SetFocus(view)
+}
+
+// filterRows returns the indexes of rows having a message matching the regex
+func filterRows(rows []tuiRow, re *regexp.Regexp) []int {
+	visible := make([]int, 0, len(rows))
+	for i, row := range rows {
+		if re == nil || re.MatchString(utils.RemoveColor(row.msg)) {
+			visible = append(visible, i)
+		}
+	}
+	return visible
+}
+
+// nextRow searches the next visible row of the given type, starting after "from"
+// direction is 1 to search forward, -1 backward
+func nextRow(rows []tuiRow, visible []int, from, direction int, regexType types.RegexType) int {
+	for i := from + direction; i >= 0 && i < len(visible); i += direction {
+		if rows[visible[i]].li.RegexType == regexType {
+			return i
+		}
+	}
+	return -1
+}
+
+// rawContext returns the lines surrounding a log line in its file, the line itself being highlighted
+func rawContext(path, line string, around int) (string, error) {
+	raw, err := ReadRawLines(path, line, around)
+	if err != nil {
+		return "", err
+	}
+	lines := make([]string, 0, len(raw.Lines))
+	for i, current := range raw.Lines {
+		if i == raw.Event {
+			lines = append(lines, "[yellow::b]"+tview.Escape(current)+"[-:-:-]")
+			continue
+		}
+		lines = append(lines, tview.Escape(current))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+var ansiToTviewColors = map[string]string{
+	string(utils.ResetText):  "[-:-:-]",
+	utils.RedText:            "[red]",
+	utils.GreenText:          "[green]",
+	utils.YellowText:         "[yellow]",
+	utils.BlueText:           "[blue]",
+	utils.MagentaText:        "[purple]",
+	utils.CyanText:           "[teal]",
+	utils.WhiteText:          "[white]",
+	utils.DefaultText:        "[-]",
+	utils.BrightRedText:      "[red::b]",
+	utils.BrightGreenText:    "[green::b]",
+	utils.BrightYellowText:   "[yellow::b]",
+	utils.BrightBlueText:     "[blue::b]",
+	utils.BrightMagentaText:  "[purple::b]",
+	utils.BrightCyanText:     "[teal::b]",
+	utils.BrightWhiteText:    "[white::b]",
+	string(utils.BrightText): "[::b]",
+}
+
+// ansiToTview converts the terminal colors used by the tool into tview color tags
+// tview.TranslateANSI does not handle the zero-padded codes, needed for tabwriter alignments
+func ansiToTview(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range utils.ColorLocations(s) {
+		b.WriteString(tview.Escape(s[last:loc[0]]))
+		b.WriteString(ansiToTviewColors[s[loc[0]:loc[1]]])
+		last = loc[1]
+	}
+	b.WriteString(tview.Escape(s[last:]))
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package display
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+func TestFilterRows(t *testing.T) {
+	rows := []tuiRow{
+		{msg: utils.Paint(utils.GreenText, "SYNCED")},
+		{msg: "node2 joined"},
+		{msg: utils.Paint(utils.YellowText, "DONOR")},
+	}
+
+	tests := []struct {
+		filter   string
+		expected []int
+	}{
+		{filter: "", expected: []int{0, 1, 2}},
+		{filter: "^SYNCED$", expected: []int{0}},
+		{filter: "node2|DONOR", expected: []int{1, 2}},
+		{filter: "nothing", expected: []int{}},
+	}
+
+	for _, test := range tests {
+		var re *regexp.Regexp
+		if test.filter != "" {
+			re = regexp.MustCompile(test.filter)
+		}
+		out := filterRows(rows, re)
+		if !cmp.Equal(out, test.expected) {
+			t.Errorf("filter %q: %s", test.filter, cmp.Diff(test.expected, out))
+		}
+	}
+}
+
+func TestNextRow(t *testing.T) {
+	rows := []tuiRow{
+		{li: types.LogInfo{RegexType: types.EventsRegexType}},
+		{li: types.LogInfo{RegexType: types.ViewsRegexType}},
+		{li: types.LogInfo{RegexType: types.SSTRegexType}},
+		{li: types.LogInfo{RegexType: types.ViewsRegexType}},
+	}
+	visible := []int{0, 1, 2, 3}
+
+	tests := []struct {
+		name      string
+		visible   []int
+		from      int
+		direction int
+		regexType types.RegexType
+		expected  int
+	}{
+		{name: "next view", visible: visible, from: 0, direction: 1, regexType: types.ViewsRegexType, expected: 1},
+		{name: "skip current", visible: visible, from: 1, direction: 1, regexType: types.ViewsRegexType, expected: 3},
+		{name: "previous view", visible: visible, from: 3, direction: -1, regexType: types.ViewsRegexType, expected: 1},
+		{name: "none after", visible: visible, from: 3, direction: 1, regexType: types.ViewsRegexType, expected: -1},
+		{name: "filtered out", visible: []int{0, 1, 3}, from: 0, direction: 1, regexType: types.SSTRegexType, expected: -1},
+		{name: "index among visible rows", visible: []int{0, 3}, from: 0, direction: 1, regexType: types.ViewsRegexType, expected: 1},
+	}
+
+	for _, test := range tests {
+		out := nextRow(rows, test.visible, test.from, test.direction, test.regexType)
+		if out != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, out)
+		}
+	}
+}
+
+func TestRawContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.log")
+	err := os.WriteFile(path, []byte("line1\nline2\nline3 [Galera]\nline4\nline5\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := rawContext(path, "line3 [Galera]", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "line2\n[yellow::b]line3 [Galera[][-:-:-]\nline4"
+	if out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+
+	_, err = rawContext(path, "missing", 1)
+	if err == nil {
+		t.Errorf("expected an error for a missing line")
+	}
+}
+
+func TestAnsiToTview(t *testing.T) {
+	out := ansiToTview(utils.Paint(utils.RedText, "[node1]") + " left")
+	expected := "[red][node1[][-:-:-] left"
+	if out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+}
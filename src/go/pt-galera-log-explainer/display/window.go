@@ -0,0 +1,68 @@
+package display
+
+import (
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+// Window restricts the events to print, so that huge timelines can be read by slices
+// without piping to a pager, which would break the columns alignment
+type Window struct {
+	Around  *time.Time // only print events around this date
+	Context int        // number of events to print before and after Around
+	Limit   int        // maximum number of events to print, 0 means no limit
+}
+
+func (w Window) isSet() bool {
+	return w.Around != nil || w.Limit > 0
+}
+
+// apply keeps the events inside the window, counting only those visible at the given verbosity
+// Hidden events between two kept events are kept too, as they carry the states used to color columns
+// latestContext is needed to know which events would be displayed, and must be computed
+// on the whole timeline
+func (w Window) apply(timeline types.Timeline, verbosity types.Verbosity, latestContext map[string]types.LogCtx) types.Timeline {
+	if !w.isSet() {
+		return timeline
+	}
+
+	events := dequeueAll(timeline)
+
+	// indexes of visible events in "events"
+	visible := []int{}
+	for i, event := range events {
+		if verbosity.Displays(event.li.Verbosity) && event.li.Msg(latestContext[event.node]) != "" {
+			visible = append(visible, i)
+		}
+	}
+
+	start, end := 0, len(visible)
+	if w.Around != nil {
+		// first visible event at or after the date
+		pivot := len(visible)
+		for i, idx := range visible {
+			if events[idx].li.Date != nil && !events[idx].li.Date.Time.Before(*w.Around) {
+				pivot = i
+				break
+			}
+		}
+		start = max(pivot-w.Context, 0)
+		end = min(pivot+w.Context+1, len(visible))
+	}
+	if w.Limit > 0 {
+		end = min(start+w.Limit, end)
+	}
+
+	windowed := types.Timeline{}
+	for node := range timeline {
+		windowed[node] = types.LocalTimeline{}
+	}
+	if start >= end {
+		return windowed
+	}
+	for _, event := range events[visible[start] : visible[end-1]+1] {
+		windowed[event.node] = append(windowed[event.node], event.li)
+	}
+	return windowed
+}
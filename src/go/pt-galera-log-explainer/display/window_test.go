@@ -0,0 +1,77 @@
+package display
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestWindowApply(t *testing.T) {
+	date := time.Date(2023, 3, 18, 21, 25, 0, 0, time.UTC)
+	logCtx := types.NewLogCtx()
+
+	// node1 and node2 alternate every minute, node2 events are hidden at Info verbosity
+	newTimeline := func() types.Timeline {
+		timeline := types.Timeline{}
+		for i := 0; i < 10; i++ {
+			node, verbosity := "node1", types.Info
+			if i%2 == 1 {
+				node, verbosity = "node2", types.DebugMySQL
+			}
+			timeline[node] = append(timeline[node], types.NewLogInfo(types.NewDate(date.Add(time.Duration(i)*time.Minute), testLayout),
+				types.SimpleDisplayer(string(rune('a'+i))), "", &types.LogRegex{Type: types.EventsRegexType, Verbosity: verbosity}, "RegexTest", logCtx, "error.log"))
+		}
+		return timeline
+	}
+	around := date.Add(4 * time.Minute)
+
+	tests := []struct {
+		name     string
+		window   Window
+		expected map[string][]string
+	}{
+		{
+			name:     "no window",
+			window:   Window{},
+			expected: map[string][]string{"node1": {"a", "c", "e", "g", "i"}, "node2": {"b", "d", "f", "h", "j"}},
+		},
+		{
+			name:     "limit counts visible events only",
+			window:   Window{Limit: 2},
+			expected: map[string][]string{"node1": {"a", "c"}, "node2": {"b"}},
+		},
+		{
+			name:     "around",
+			window:   Window{Around: &around, Context: 1},
+			expected: map[string][]string{"node1": {"c", "e", "g"}, "node2": {"d", "f"}},
+		},
+		{
+			name:     "around and limit",
+			window:   Window{Around: &around, Context: 2, Limit: 1},
+			expected: map[string][]string{"node1": {"a"}, "node2": {}},
+		},
+		{
+			name:     "around after every events",
+			window:   Window{Around: &[]time.Time{date.Add(time.Hour)}[0], Context: 1},
+			expected: map[string][]string{"node1": {"i"}, "node2": {}},
+		},
+	}
+
+	for _, test := range tests {
+		timeline := newTimeline()
+		out := test.window.apply(timeline, types.Info, timeline.GetLatestContextsByNodes())
+
+		msgs := map[string][]string{}
+		for node, lt := range out {
+			msgs[node] = []string{}
+			for _, li := range lt {
+				msgs[node] = append(msgs[node], li.Msg(logCtx))
+			}
+		}
+		if !cmp.Equal(msgs, test.expected) {
+			t.Errorf("%s: %s", test.name, cmp.Diff(test.expected, msgs))
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type evictions struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+}
+
+func (e *evictions) Help() string {
+	return fmt.Sprintf(`List, for each node, which nodes considered it suspect, delayed or evicted it, and when
+	It is based on evs protocol messages: suspected nodes, inactive declarations, delayed lists and auto-evictions
+
+Usage:
+	%[1]s evictions *.log
+	`, toolname)
+}
+
+func (e *evictions) Run() error {
+
+	timeline, err := timelineFromPaths(e.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not analyze evictions")
+	}
+
+	return display.EvictionsCLI(os.Stdout, display.EvictionReports(timeline))
+}
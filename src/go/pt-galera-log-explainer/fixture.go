@@ -0,0 +1,505 @@
+package main
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+type generateFixture struct {
+	Scenario string    `arg:"" enum:"clean-restart,sst,network-partition,split-brain" help:"Scenario to simulate: clean-restart, sst, network-partition, split-brain"`
+	Dir      string    `arg:"" name:"dir" help:"Directory where node logs are written, created when missing"`
+	Nodes    int       `default:"3" help:"Number of nodes in the cluster"`
+	Start    time.Time `default:"2023-03-12T07:00:00Z" help:"Date of the first line, format: 2023-01-23T03:53:40Z (RFC3339)"`
+}
+
+func (g *generateFixture) Help() string {
+	return fmt.Sprintf(`Write synthetic error logs of a cluster going through a scenario, one file per node: node1.log, node2.log, ...
+	Lines follow the PXC 8.0 format. Outputs are deterministic, the same arguments always give the same logs:
+	they are meant to validate custom regexes and --date-format, or to build regression tests
+
+	clean-restart:     every node is restarted one after the other, and rejoins using IST
+	sst:               the last node loses its data directory, and rejoins using SST
+	network-partition: the last node is cut from the others, becomes non-primary, then rejoins when the network is back
+	split-brain:       the last node is cut from the others and forced to bootstrap, both sides accept writes until it is stopped
+
+Usage:
+	%[1]s generate-fixture sst /tmp/fixture
+	%[1]s generate-fixture --nodes 5 --start 2024-01-01T00:00:00Z split-brain /tmp/fixture
+	`, toolname)
+}
+
+func (g *generateFixture) Run() error {
+	paths, err := writeFixture(g.Dir, g.Scenario, g.Nodes, g.Start)
+	if err != nil {
+		return errors.Wrap(err, "could not generate fixture")
+	}
+	for _, path := range paths {
+		fmt.Fprintln(os.Stdout, path)
+	}
+	return nil
+}
+
+// fixtureScenarios write what happens to the cluster once it is formed
+var fixtureScenarios = map[string]func(f *fixture){
+	"clean-restart":     (*fixture).cleanRestart,
+	"sst":               (*fixture).sst,
+	"network-partition": (*fixture).networkPartition,
+	"split-brain":       (*fixture).splitBrain,
+}
+
+const (
+	fixtureVersion     = "8.0.28-19.1"
+	fixtureClusterUUID = "9db0bcdf-b31a-11ed-a398-2a4cfdd82049"
+	// delay between consecutive lines, so that every line gets its own date
+	fixtureLineInterval = 137 * time.Microsecond
+)
+
+// writeFixture generates the logs of a scenario in dir, and returns their paths
+func writeFixture(dir, scenario string, nodes int, start time.Time) ([]string, error) {
+	run, ok := fixtureScenarios[scenario]
+	if !ok {
+		return nil, errors.Errorf("unknown scenario %s", scenario)
+	}
+	// partitions always cut the last node from a majority
+	if nodes < 2 || (nodes < 3 && (scenario == "network-partition" || scenario == "split-brain")) {
+		return nil, errors.Errorf("not enough nodes for scenario %s: %d", scenario, nodes)
+	}
+
+	f := newFixture(nodes, start)
+	f.form()
+	run(f)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, n := range f.nodes {
+		path := filepath.Join(dir, n.name+".log")
+		if err := os.WriteFile(path, []byte(strings.Join(n.lines, "\n")+"\n"), 0o644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+type fixtureNode struct {
+	name string
+	ip   string
+	// gcomm uuid, a new one is generated at each start
+	uuid   string
+	starts int
+	state  string
+	seqno  int64
+	// members of the last view seen by the node, itself included
+	view  []*fixtureNode
+	lines []string
+}
+
+type fixture struct {
+	nodes  []*fixtureNode
+	now    time.Time
+	confID int
+	pid    int
+}
+
+func newFixture(count int, start time.Time) *fixture {
+	f := &fixture{now: start.UTC(), pid: 1706517}
+	for i := 1; i <= count; i++ {
+		f.nodes = append(f.nodes, &fixtureNode{
+			name:  fmt.Sprintf("node%d", i),
+			ip:    fmt.Sprintf("172.17.0.%d", i+1),
+			state: "CLOSED",
+			seqno: 170403894,
+		})
+	}
+	return f
+}
+
+// fixtureUUID derives node uuids from their name and restart count, to keep outputs deterministic
+func fixtureUUID(name string, starts int) string {
+	h := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s/%d", name, starts))))
+	return h[:8] + "-" + h[8:12] + "-11ed-" + h[12:16] + "-" + h[16:28]
+}
+
+func (n *fixtureNode) short() string {
+	return utils.UUIDToShortUUID(n.uuid)
+}
+
+func (f *fixture) wait(d time.Duration) {
+	f.now = f.now.Add(d)
+}
+
+func (f *fixture) log(n *fixtureNode, thread int, severity, code, subsystem, format string, args ...interface{}) {
+	n.lines = append(n.lines, fmt.Sprintf("%s %d [%s] [%s] [%s] ", f.now.Format("2006-01-02T15:04:05.000000Z"), thread, severity, code, subsystem)+fmt.Sprintf(format, args...))
+	f.now = f.now.Add(fixtureLineInterval)
+}
+
+func (f *fixture) galera(n *fixtureNode, format string, args ...interface{}) {
+	f.log(n, 0, "Note", "MY-000000", "Galera", format, args...)
+}
+
+func (f *fixture) wsrep(n *fixtureNode, format string, args ...interface{}) {
+	f.log(n, 0, "Note", "MY-000000", "WSREP", format, args...)
+}
+
+// raw adds the continuation lines of multi-line messages, they have no date
+func (f *fixture) raw(n *fixtureNode, lines ...string) {
+	n.lines = append(n.lines, lines...)
+}
+
+func (f *fixture) shift(n *fixtureNode, state string) {
+	seqno := n.seqno
+	if n.state == "CLOSED" {
+		seqno = 0
+	}
+	f.galera(n, "Shifting %s -> %s (TO: %d)", n.state, state, seqno)
+	n.state = state
+}
+
+func (f *fixture) start(n *fixtureNode) {
+	n.starts++
+	n.uuid = fixtureUUID(n.name, n.starts)
+	n.state = "CLOSED"
+	f.pid += 1893
+	f.log(n, 0, "System", "MY-010116", "Server", "/usr/sbin/mysqld (mysqld %s) starting as process %d", fixtureVersion, f.pid)
+	f.wsrep(n, "wsrep_load(): loading provider library '/usr/lib64/libgalera_smm.so'")
+	f.galera(n, "Passing config to GCS: base_dir = /var/lib/mysql/; base_host = %s; base_port = 4567; evs.suspect_timeout = PT5S; gcache.size = 128M; gcs.fc_limit = 100; pc.weight = 1", n.ip)
+	f.galera(n, "(%s, 'tcp://0.0.0.0:4567') listening at tcp://0.0.0.0:4567", n.short())
+	f.wait(40 * time.Millisecond)
+}
+
+func (f *fixture) bootstrap(n *fixtureNode) {
+	f.start(n)
+	f.galera(n, "gcomm: bootstrapping new group 'pxc_cluster'")
+	f.view([]*fixtureNode{n}, true, true)
+	f.synced(n)
+}
+
+// join starts a node, connects it to the given members, and transfers it the state of donor
+func (f *fixture) join(n *fixtureNode, members []*fixtureNode, donor *fixtureNode, sst bool) {
+	f.start(n)
+	if sst {
+		f.log(n, 0, "Warning", "MY-000000", "Galera", "Could not open state file for reading: '/var/lib/mysql//grastate.dat'")
+	}
+	peers := []string{}
+	for _, m := range f.nodes {
+		peers = append(peers, m.ip+":")
+	}
+	f.galera(n, "gcomm: connecting to group 'pxc_cluster', peer '%s'", strings.Join(peers, ","))
+	f.galera(n, "(%s, 'tcp://0.0.0.0:4567') Found matching local endpoint for a connection, blacklisting address tcp://%s:4567", n.short(), n.ip)
+	for _, m := range members {
+		f.galera(n, "(%s, 'tcp://0.0.0.0:4567') connection established to %s tcp://%s:4567", n.short(), m.short(), m.ip)
+	}
+	f.wait(500 * time.Millisecond)
+	f.view(append(append([]*fixtureNode{}, members...), n), true, false)
+	f.transfer(n, donor, sst)
+}
+
+// view makes every member log a new view: nodes joining and leaving, the component and, when primary, quorum results
+func (f *fixture) view(members []*fixtureNode, primary, bootstrap bool) {
+	var seqno int64
+	for _, m := range members {
+		if m.seqno > seqno {
+			seqno = m.seqno
+		}
+	}
+	f.confID++
+	kind, status := "NON_PRIM", "non-primary"
+	if primary {
+		kind, status = "PRIM", "primary"
+	}
+
+	for idx, n := range members {
+		for _, m := range members {
+			if m != n && !containsFixtureNode(n.view, m) {
+				f.galera(n, "declaring %s at tcp://%s:4567 stable", m.short(), m.ip)
+			}
+		}
+		for _, m := range n.view {
+			if m != n && !containsFixtureNode(members, m) {
+				f.galera(n, "forgetting %s (tcp://%s:4567)", m.short(), m.ip)
+			}
+		}
+
+		f.galera(n, "Current view of cluster as seen by this node")
+		f.raw(n, fmt.Sprintf("view (view_id(%s,%s,%d)", kind, members[0].short(), f.confID), "memb {")
+		for _, m := range members {
+			f.raw(n, "\t"+m.short()+",0")
+		}
+		f.raw(n, "\t}", "joined {", "\t}", "left {", "\t}", "partitioned {", "\t}", ")")
+
+		if n.state == "CLOSED" {
+			f.shift(n, "OPEN")
+		}
+		f.galera(n, "New COMPONENT: primary = %s, bootstrap = %s, my_idx = %d, memb_num = %d", yesNo(primary), yesNo(bootstrap), idx, len(members))
+
+		if primary {
+			f.galera(n, "Quorum results:")
+			f.raw(n,
+				"\tversion    = 6,",
+				"\tcomponent  = PRIMARY,",
+				fmt.Sprintf("\tconf_id    = %d,", f.confID-1),
+				fmt.Sprintf("\tmembers    = %d/%d (primary/total),", len(members), len(members)),
+				fmt.Sprintf("\tact_id     = %d,", seqno),
+				fmt.Sprintf("\tlast_appl. = %d,", seqno),
+				"\tprotocols  = 2/10/4 (gcs/repl/appl),",
+				"\tvote policy= 0,",
+				"\tgroup UUID = "+fixtureClusterUUID)
+			if n.state == "OPEN" {
+				f.shift(n, "PRIMARY")
+			}
+		} else if n.state != "OPEN" {
+			f.shift(n, "OPEN")
+		}
+
+		f.log(n, 2, "Note", "MY-000000", "Galera", "================================================")
+		f.raw(n,
+			"View:",
+			fmt.Sprintf("  id: %s:%d", fixtureClusterUUID, seqno),
+			"  status: "+status,
+			"  protocol_version: 4",
+			"  capabilities: MULTI-MASTER, CERTIFICATION, PARALLEL_APPLYING, REPLAY, ISOLATION, PAUSE, CAUSAL_READ, INCREMENTAL_WS, UNORDERED, PREORDERED, STREAMING, NBO",
+			"  final: no",
+			fmt.Sprintf("  own_index: %d", idx),
+			fmt.Sprintf("  members(%d):", len(members)))
+		for i, m := range members {
+			f.raw(n, fmt.Sprintf("\t%d: %s, %s", i, m.uuid, m.name))
+		}
+		f.raw(n, "=================================================")
+
+		n.view = members
+	}
+}
+
+// transfer makes joiner receive the state of donor, through IST unless sst is set
+func (f *fixture) transfer(joiner, donor *fixtureNode, sst bool) {
+	view := joiner.view
+	joinerIdx, donorIdx := indexOfFixtureNode(view, joiner), indexOfFixtureNode(view, donor)
+	first, last := joiner.seqno+1, donor.seqno
+
+	local := fmt.Sprintf("%s:%d", fixtureClusterUUID, joiner.seqno)
+	if sst {
+		local = "00000000-0000-0000-0000-000000000000:-1"
+	}
+	f.log(joiner, 2, "Note", "MY-000000", "Galera", "State transfer required: ")
+	f.raw(joiner, fmt.Sprintf("\tGroup state: %s:%d", fixtureClusterUUID, donor.seqno), "\tLocal state: "+local)
+
+	if sst {
+		f.wsrep(joiner, "Initiating SST/IST transfer on JOINER side (wsrep_sst_xtrabackup-v2 --role 'joiner' --address '%s' --datadir '/var/lib/mysql' --basedir '/usr/' --plugindir '/usr/lib64/mysql/plugin/' --defaults-file '/etc/my.cnf' --defaults-group-suffix '' --parent '%d' --mysqld-version '%s'   '' )", joiner.ip, f.pid, fixtureVersion)
+	} else {
+		f.log(joiner, 2, "Note", "MY-000000", "Galera", "Prepared IST receiver for %d-%d, listening at: tcp://%s:4568", first, last, joiner.ip)
+	}
+
+	for _, m := range view {
+		f.galera(m, "Member %d.0 (%s) requested state transfer from '*any*'. Selected %d.0 (%s)(SYNCED) as donor.", joinerIdx, joiner.name, donorIdx, donor.name)
+	}
+	f.shift(joiner, "JOINER")
+	f.shift(donor, "DONOR/DESYNCED")
+
+	if sst {
+		f.wsrep(donor, "Initiating SST/IST transfer on DONOR side (wsrep_sst_xtrabackup-v2 --role 'donor' --address '%s:4444/xtrabackup_sst//1' --socket '/var/lib/mysql/mysql.sock' --datadir '/var/lib/mysql' --basedir '/usr/' --plugindir '/usr/lib64/mysql/plugin/' --defaults-file '/etc/my.cnf' --defaults-group-suffix '' --mysqld-version '%s'   '' --gtid '%s:%d')", joiner.ip, fixtureVersion, fixtureClusterUUID, donor.seqno)
+		f.log(donor, 0, "Note", "MY-000000", "WSREP-SST", "Streaming the backup to joiner at %s 4444", joiner.ip)
+		f.wait(2 * time.Minute)
+		f.log(joiner, 3, "Note", "MY-000000", "Galera", "SST received: %s:%d", fixtureClusterUUID, donor.seqno)
+		f.log(joiner, 3, "System", "MY-000000", "WSREP", "SST completed")
+	} else {
+		f.log(joiner, 0, "Note", "MY-000000", "WSREP-SST", "Bypassing SST. Can work it through IST")
+		f.galera(donor, "async IST sender starting to serve tcp://%s:4568 sending %d-%d, preload starts from %d", joiner.ip, first, last, first)
+		f.wait(2 * time.Second)
+		f.log(joiner, 2, "Note", "MY-000000", "Galera", "Receiving IST: %d writesets, seqnos %d-%d", last-first+1, first, last)
+		f.log(joiner, 2, "Note", "MY-000000", "Galera", "IST received: %s:%d", fixtureClusterUUID, last)
+	}
+	joiner.seqno = donor.seqno
+
+	for _, m := range view {
+		f.galera(m, "%d.0 (%s): State transfer to %d.0 (%s) complete.", donorIdx, donor.name, joinerIdx, joiner.name)
+	}
+	f.shift(donor, "JOINED")
+	f.synced(donor)
+	f.shift(joiner, "JOINED")
+	f.synced(joiner)
+}
+
+func (f *fixture) synced(n *fixtureNode) {
+	if n.state != "JOINED" {
+		f.shift(n, "JOINED")
+	}
+	for _, m := range n.view {
+		f.galera(m, "Member %d.0 (%s) synced with group.", indexOfFixtureNode(n.view, n), n.name)
+	}
+	f.shift(n, "SYNCED")
+	f.log(n, 2, "Note", "MY-000000", "WSREP", "Synchronized with group, ready for connections")
+}
+
+// stop shuts a node down gracefully, the others log its departure
+func (f *fixture) stop(n *fixtureNode) {
+	f.log(n, 0, "System", "MY-013172", "Server", "Received SHUTDOWN from user <via user signal>. Shutting down mysqld (Version: %s).", fixtureVersion)
+	f.wsrep(n, "Received shutdown signal. Will sleep for 10 secs before initiating shutdown. pxc_maint_mode switched to SHUTDOWN")
+	f.wait(10 * time.Second)
+
+	remaining := []*fixtureNode{}
+	for _, m := range n.view {
+		if m != n {
+			remaining = append(remaining, m)
+		}
+	}
+	f.galera(n, "New COMPONENT: primary = no, bootstrap = no, my_idx = 0, memb_num = 1")
+	f.shift(n, "OPEN")
+	f.shift(n, "CLOSED")
+	f.log(n, 0, "System", "MY-010910", "Server", "/usr/sbin/mysqld: Shutdown complete (mysqld %s)  Percona XtraDB Cluster (GPL), Release rel19, Revision f544540, WSREP version 26.4.3.", fixtureVersion)
+	n.view = nil
+	if len(remaining) > 0 {
+		f.view(remaining, true, false)
+	}
+}
+
+// partition cuts nodes from the others: both sides suspect each other, and only the majority stays primary
+func (f *fixture) partition(cut []*fixtureNode) (majority []*fixtureNode) {
+	for _, n := range f.nodes {
+		if !containsFixtureNode(cut, n) {
+			majority = append(majority, n)
+		}
+	}
+	for _, n := range f.nodes {
+		others := majority
+		if !containsFixtureNode(cut, n) {
+			others = cut
+		}
+		for _, m := range others {
+			f.galera(n, "(%s, 'tcp://0.0.0.0:4567') connection to peer %s with addr tcp://%s:4567 timed out, no messages seen in PT3S, socket stats: rtt: 0 rttvar: 250 rto: 200000 lost: 0 last_data_recv: 3000 cwnd: 10 last_queued_since: 3000000 last_delivered_since: 3000000 send_queue_length: 0 send_queue_bytes: 0", n.short(), m.short(), m.ip)
+			f.galera(n, "(%s, 'tcp://0.0.0.0:4567') suspecting node: %s", n.short(), m.short())
+		}
+	}
+	f.wait(5 * time.Second)
+	f.view(majority, true, false)
+	f.view(cut, false, false)
+	return majority
+}
+
+// backup desyncs a node from its group while a backup runs on it
+func (f *fixture) backup(n *fixtureNode) {
+	for _, m := range n.view {
+		f.galera(m, "Member %d.0 (%s) desyncs itself from group", indexOfFixtureNode(n.view, n), n.name)
+	}
+	f.shift(n, "DONOR/DESYNCED")
+	f.wait(time.Minute)
+	for _, m := range n.view {
+		f.galera(m, "Member %d.0 (%s) resyncs itself to group", indexOfFixtureNode(n.view, n), n.name)
+	}
+	f.shift(n, "JOINED")
+	f.synced(n)
+}
+
+// writes are committed by the given nodes, they keep being in sync
+func (f *fixture) writes(nodes []*fixtureNode, count int64) {
+	for _, n := range nodes {
+		n.seqno += count
+	}
+}
+
+// form bootstraps the cluster from the first node, the other ones joining with IST
+func (f *fixture) form() {
+	f.bootstrap(f.nodes[0])
+	for i, n := range f.nodes[1:] {
+		f.wait(20 * time.Second)
+		f.writes(f.nodes[:i+1], 25)
+		f.join(n, f.nodes[:i+1], f.nodes[0], false)
+	}
+	f.wait(10 * time.Minute)
+	f.writes(f.nodes, 1200)
+}
+
+func (f *fixture) cleanRestart() {
+	for i, n := range f.nodes {
+		// the donor is the next node, as the previous one may still be catching up
+		donor := f.nodes[(i+1)%len(f.nodes)]
+
+		f.stop(n)
+		f.writes(donor.view, 40)
+		f.wait(time.Minute)
+		f.join(n, donor.view, donor, false)
+		f.wait(5 * time.Minute)
+		f.writes(f.nodes, 300)
+	}
+}
+
+func (f *fixture) sst() {
+	n := f.nodes[len(f.nodes)-1]
+	f.stop(n)
+	f.writes(f.nodes[:len(f.nodes)-1], 500)
+	f.wait(20 * time.Minute)
+	f.join(n, f.nodes[:len(f.nodes)-1], f.nodes[0], true)
+	f.wait(5 * time.Minute)
+	f.writes(f.nodes, 300)
+}
+
+func (f *fixture) networkPartition() {
+	cut := f.nodes[len(f.nodes)-1:]
+	majority := f.partition(cut)
+	f.writes(majority, 700)
+	f.wait(3 * time.Minute)
+
+	n := cut[0]
+	for _, m := range majority {
+		f.galera(n, "(%s, 'tcp://0.0.0.0:4567') connection established to %s tcp://%s:4567", n.short(), m.short(), m.ip)
+	}
+	f.view(f.nodes, true, false)
+	f.transfer(n, majority[0], false)
+	f.wait(5 * time.Minute)
+	f.writes(f.nodes, 300)
+}
+
+func (f *fixture) splitBrain() {
+	cut := f.nodes[len(f.nodes)-1:]
+	majority := f.partition(cut)
+	f.wait(time.Minute)
+
+	// forced with SET GLOBAL wsrep_provider_options='pc.bootstrap=YES'
+	n := cut[0]
+	f.galera(n, "gcomm: bootstrapping new group 'pxc_cluster'")
+	f.view(cut, true, true)
+	f.synced(n)
+
+	// both sides accept writes, their histories diverge
+	f.wait(10 * time.Minute)
+	f.writes(majority, 900)
+	f.writes(cut, 150)
+	f.backup(majority[0])
+	f.backup(n)
+
+	// the isolated node is noticed: it is stopped, and rejoins using SST as its history diverged
+	f.wait(time.Minute)
+	f.stop(n)
+	f.wait(5 * time.Minute)
+	f.join(n, majority, majority[0], true)
+	f.wait(5 * time.Minute)
+	f.writes(f.nodes, 300)
+}
+
+func containsFixtureNode(nodes []*fixtureNode, n *fixtureNode) bool {
+	return indexOfFixtureNode(nodes, n) >= 0
+}
+
+func indexOfFixtureNode(nodes []*fixtureNode, n *fixtureNode) int {
+	for i, m := range nodes {
+		if m == n {
+			return i
+		}
+	}
+	return -1
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
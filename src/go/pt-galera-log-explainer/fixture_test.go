@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+)
+
+func TestWriteFixtureDeterministic(t *testing.T) {
+	start := time.Date(2023, 3, 12, 7, 0, 0, 0, time.UTC)
+	dirs := []string{t.TempDir(), t.TempDir()}
+	for _, dir := range dirs {
+		if _, err := writeFixture(dir, "split-brain", 3, start); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"node1.log", "node2.log", "node3.log"} {
+		first, err := os.ReadFile(filepath.Join(dirs[0], name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := os.ReadFile(filepath.Join(dirs[1], name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(first) != string(second) {
+			t.Fatalf("%s differs between runs", name)
+		}
+	}
+}
+
+func TestWriteFixtureScenarios(t *testing.T) {
+	grepCmd := CLI.GrepCmd
+	CLI.GrepCmd = "grep"
+	defer func() { CLI.GrepCmd = grepCmd }()
+
+	tests := []struct {
+		scenario    string
+		splitBrains int
+		// regexes expected in the timeline of the last node
+		regexes []string
+	}{
+		{
+			scenario: "clean-restart",
+			regexes:  []string{"RegexShutdownComplete", "RegexISTReceived"},
+		},
+		{
+			scenario: "sst",
+			regexes:  []string{"RegexNoGrastate", "RegexSSTRequestSuccess", "RegexSSTComplete"},
+		},
+		{
+			scenario: "network-partition",
+			regexes:  []string{"RegexNodeSuspect", "RegexISTReceived"},
+		},
+		{
+			scenario:    "split-brain",
+			splitBrains: 1,
+			regexes:     []string{"RegexBootstrap", "RegexDesync", "RegexSSTComplete"},
+		},
+	}
+
+	for _, test := range tests {
+		paths, err := writeFixture(t.TempDir(), test.scenario, 3, time.Date(2023, 3, 12, 7, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		timeline, err := timelineFromPaths(paths, regex.AllRegexes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nodes := sortedNodes(timeline)
+		if !cmp.Equal(nodes, []string{"node1", "node2", "node3"}) {
+			t.Fatalf("%s: nodes were not identified from logs: %v", test.scenario, nodes)
+		}
+		if splitBrains := display.SplitBrains(timeline); len(splitBrains) != test.splitBrains {
+			t.Fatalf("%s: expected %d split-brains, got %d", test.scenario, test.splitBrains, len(splitBrains))
+		}
+
+		found := map[string]bool{}
+		for _, li := range timeline["node3"] {
+			found[li.RegexUsed] = true
+		}
+		for _, r := range test.regexes {
+			if !found[r] {
+				t.Errorf("%s: %s not found in node3 events", test.scenario, r)
+			}
+		}
+	}
+}
+
+func TestWriteFixtureNotEnoughNodes(t *testing.T) {
+	_, err := writeFixture(t.TempDir(), "network-partition", 2, time.Now())
+	if err == nil {
+		t.Fatal("expected an error, a partition needs a majority")
+	}
+}
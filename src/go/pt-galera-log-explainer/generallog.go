@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+// defaultGeneralLogFilter selects the statements replicated in total order isolation, which stall every node while
+// they run, along with global locks and settings changes
+const defaultGeneralLogFilter = `(?i)^(ALTER|CREATE|DROP|RENAME|TRUNCATE|OPTIMIZE|ANALYZE|REPAIR|GRANT|REVOKE|FLUSH|LOCK|UNLOCK|SET\s+GLOBAL)\b`
+
+// generalLogEntryRegex matches the first line of a general query log entry: date, thread id, command and argument
+// The date is only printed by older versions when it changes
+var generalLogEntryRegex = regexp.MustCompile(`^([^\t]*)\t+ *[0-9]+ ([A-Za-z][A-Za-z ]*?)(?:\t(.*))?$`)
+
+// generalQuery is a general query log entry
+type generalQuery struct {
+	date    time.Time
+	command string
+	query   []string
+}
+
+// interleaveGeneralLogs adds the queries matching filter found in --general-log files to the timeline of their node
+func interleaveGeneralLogs(timeline types.Timeline, generalLogs []string, filter string) error {
+	if len(generalLogs) == 0 {
+		return nil
+	}
+	if filter == "" {
+		filter = defaultGeneralLogFilter
+	}
+	filterRegex, err := regexp.Compile(filter)
+	if err != nil {
+		return errors.Wrapf(err, "invalid --general-log-filter %s", filter)
+	}
+
+	for _, generalLog := range generalLogs {
+		node, path, found := strings.Cut(generalLog, ":")
+		if !found || node == "" || path == "" {
+			return errors.Errorf("invalid --general-log %s, expected format: <node>:<path>", generalLog)
+		}
+		if _, ok := timeline[node]; !ok {
+			return errors.Errorf("unknown node %s for general query log %s, expected one of: %s", node, path, strings.Join(sortedNodes(timeline), ", "))
+		}
+
+		lt, err := generalQueriesFromFile(path, filterRegex)
+		if err != nil {
+			return errors.Wrapf(err, "could not read general query log %s", path)
+		}
+		timeline[node] = timeline[node].Interleave(lt)
+	}
+	return nil
+}
+
+func generalQueriesFromFile(path string, filter *regexp.Regexp) (types.LocalTimeline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	queries, err := parseGeneralLog(f)
+	if err != nil {
+		return nil, err
+	}
+
+	regex := &types.LogRegex{
+		Type:      types.GeneralQueryRegexType,
+		Verbosity: types.Info,
+		Severity:  types.SeverityInfo,
+	}
+	lt := types.LocalTimeline{}
+	for _, q := range queries {
+		query := strings.Join(q.query, " ")
+		if (q.command != "Query" && q.command != "Execute") || q.date.IsZero() || !filter.MatchString(query) {
+			continue
+		}
+		if (CLI.Since != nil && CLI.Since.After(q.date)) || (CLI.Until != nil && CLI.Until.Before(q.date)) {
+			continue
+		}
+		msg := "query: " + utils.Truncate(query, slowQueryMaxLength)
+		lt = append(lt, types.NewLogInfo(types.NewDate(q.date, "2006-01-02T15:04:05.000000Z"), types.SimpleDisplayer(msg), msg, regex, "GeneralQuery", types.NewLogCtx(), ""))
+	}
+
+	sort.SliceStable(lt, func(i, j int) bool { return lt[i].Date.Time.Before(lt[j].Date.Time) })
+	return lt, nil
+}
+
+// parseGeneralLog reads general query log entries, eg:
+//
+//	2023-03-12T07:35:00.123456Z	   12 Query	ALTER TABLE t1
+//	ADD COLUMN c INT
+//
+// Older versions use another date layout, and only print it when it changes:
+//
+//	230312  7:35:00	   12 Query	ALTER TABLE t1 ADD COLUMN c INT
+//			   13 Query	FLUSH TABLES WITH READ LOCK
+func parseGeneralLog(r io.Reader) ([]generalQuery, error) {
+	queries := []generalQuery{}
+	var current *generalQuery
+	var date time.Time
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		line := s.Text()
+
+		// headers printed when mysqld starts or the log is flushed
+		if strings.Contains(line, ", Version: ") || strings.HasPrefix(line, "Tcp port:") || strings.HasPrefix(line, "Time ") {
+			continue
+		}
+
+		submatches := generalLogEntryRegex.FindStringSubmatch(line)
+		if submatches == nil {
+			// the next lines of a multi-line query
+			if current != nil && strings.TrimSpace(line) != "" {
+				current.query = append(current.query, strings.TrimSpace(line))
+			}
+			continue
+		}
+
+		if value := strings.Join(strings.Fields(submatches[1]), " "); value != "" {
+			for _, layout := range slowLogTimeLayouts {
+				if t, err := time.Parse(layout, value); err == nil {
+					date = t.UTC()
+					break
+				}
+			}
+		}
+		queries = append(queries, generalQuery{date: date, command: submatches[2]})
+		current = &queries[len(queries)-1]
+		if argument := strings.TrimSpace(submatches[3]); argument != "" {
+			current.query = append(current.query, argument)
+		}
+	}
+	return queries, s.Err()
+}
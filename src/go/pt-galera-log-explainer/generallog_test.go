@@ -0,0 +1,56 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseGeneralLog(t *testing.T) {
+	log := "/usr/sbin/mysqld, Version: 8.0.28-19.1 (Percona XtraDB Cluster (GPL), Release rel19, Revision f544540, WSREP version 26.4.3). started with:\n" +
+		"Tcp port: 3306  Unix socket: /var/lib/mysql/mysql.sock\n" +
+		"Time                 Id Command    Argument\n" +
+		"2023-03-12T07:35:00.123456Z\t   12 Connect\troot@localhost on test using Socket\n" +
+		"2023-03-12T07:35:01.000000Z\t   12 Query\tALTER TABLE t1\n" +
+		"ADD COLUMN c INT\n" +
+		"2023-03-12T07:35:02.000000Z\t   12 Quit\t\n" +
+		"230312  7:36:00\t   13 Query\tFLUSH TABLES WITH READ LOCK\n" +
+		"\t\t   14 Query\tselect 1\n"
+
+	queries, err := parseGeneralLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []generalQuery{
+		{date: time.Date(2023, 3, 12, 7, 35, 0, 123456000, time.UTC), command: "Connect", query: []string{"root@localhost on test using Socket"}},
+		{date: time.Date(2023, 3, 12, 7, 35, 1, 0, time.UTC), command: "Query", query: []string{"ALTER TABLE t1", "ADD COLUMN c INT"}},
+		{date: time.Date(2023, 3, 12, 7, 35, 2, 0, time.UTC), command: "Quit"},
+		{date: time.Date(2023, 3, 12, 7, 36, 0, 0, time.UTC), command: "Query", query: []string{"FLUSH TABLES WITH READ LOCK"}},
+		{date: time.Date(2023, 3, 12, 7, 36, 0, 0, time.UTC), command: "Query", query: []string{"select 1"}},
+	}
+	if diff := cmp.Diff(expected, queries, cmp.AllowUnexported(generalQuery{})); diff != "" {
+		t.Fatalf("unexpected queries (-want +got):\n%s", diff)
+	}
+}
+
+func TestDefaultGeneralLogFilter(t *testing.T) {
+	filter := regexp.MustCompile(defaultGeneralLogFilter)
+	tests := map[string]bool{
+		"ALTER TABLE t1 ADD COLUMN c INT":                  true,
+		"create index idx on t1(a)":                        true,
+		"SET GLOBAL wsrep_provider_options='pc.weight=10'": true,
+		"set  global wsrep_desync=ON":                      true,
+		"FLUSH TABLES WITH READ LOCK":                      true,
+		"select * from alter_log":                          false,
+		"SET SESSION sql_mode=''":                          false,
+		"update t1 set a = 1 where b = 'DROP TABLE t1'":    false,
+	}
+	for query, expected := range tests {
+		if filter.MatchString(query) != expected {
+			t.Errorf("%q: expected match %t", query, expected)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/pkg/errors"
+)
+
+// GRA_<thread>_<seqno>.log, and GRA_<thread>_<seqno>_v2.log since the files include a binlog header
+// seqno is -1 when the writeset was not ordered yet
+var writesetDumpName = regexp.MustCompile(`^GRA_([0-9]+)_(-?[0-9]+)(_v2)?\.log$`)
+
+// writesetDump is a writeset a node failed to apply, dumped in its datadir
+type writesetDump struct {
+	path   string
+	thread string
+	seqno  string
+	date   time.Time
+	size   int64
+}
+
+// indexWritesetDumps lists the GRA_*.log files of a directory, sorted by seqno
+// Files do not record when the failure happened: their modification time is used, as they are not written afterward
+func indexWritesetDumps(dir string) ([]writesetDump, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dumps := []writesetDump{}
+	for _, entry := range entries {
+		submatches := writesetDumpName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || submatches == nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		dumps = append(dumps, writesetDump{
+			path:   filepath.Join(dir, entry.Name()),
+			thread: submatches[1],
+			seqno:  submatches[2],
+			date:   info.ModTime().UTC(),
+			size:   info.Size(),
+		})
+	}
+
+	sort.SliceStable(dumps, func(i, j int) bool {
+		si, _ := strconv.ParseInt(dumps[i].seqno, 10, 64)
+		sj, _ := strconv.ParseInt(dumps[j].seqno, 10, 64)
+		if si != sj {
+			return si < sj
+		}
+		return dumps[i].date.Before(dumps[j].date)
+	})
+	return dumps, nil
+}
+
+// interleaveWritesetDumps adds the GRA_*.log files found in --gra-dir directories to the timeline of their node
+// Apply failures and inconsistency votes of the node are given the file of their seqno, so that the failing writeset
+// can be read with mysqlbinlog without decoding file names
+func interleaveWritesetDumps(timeline types.Timeline, graDirs []string) error {
+	for _, graDir := range graDirs {
+		node, dir, found := strings.Cut(graDir, ":")
+		if !found || node == "" || dir == "" {
+			return errors.Errorf("invalid --gra-dir %s, expected format: <node>:<dir>", graDir)
+		}
+		if _, ok := timeline[node]; !ok {
+			return errors.Errorf("unknown node %s for writeset dumps %s, expected one of: %s", node, dir, strings.Join(sortedNodes(timeline), ", "))
+		}
+
+		dumps, err := indexWritesetDumps(dir)
+		if err != nil {
+			return errors.Wrapf(err, "could not index writeset dumps from %s", dir)
+		}
+		linkWritesetDumps(timeline[node], dumps)
+		timeline[node] = timeline[node].Interleave(writesetDumpsTimeline(dumps))
+	}
+	return nil
+}
+
+func linkWritesetDumps(lt types.LocalTimeline, dumps []writesetDump) {
+	bySeqno := map[string][]string{}
+	for _, dump := range dumps {
+		bySeqno[dump.seqno] = append(bySeqno[dump.seqno], filepath.Base(dump.path))
+	}
+	for i := range lt {
+		seqno := regex.WritesetSeqno(lt[i].RegexUsed, lt[i].Log)
+		if files, ok := bySeqno[seqno]; ok && seqno != "-1" {
+			lt[i].AddNote("gra", "dumped in "+strings.Join(files, ", "))
+		}
+	}
+}
+
+func writesetDumpsTimeline(dumps []writesetDump) types.LocalTimeline {
+	logRegex := &types.LogRegex{
+		Type:      types.ApplicativeRegexType,
+		Verbosity: types.Info,
+		Severity:  types.SeverityWarning,
+	}
+	lt := types.LocalTimeline{}
+	for _, dump := range dumps {
+		if (CLI.Since != nil && CLI.Since.After(dump.date)) || (CLI.Until != nil && CLI.Until.Before(dump.date)) {
+			continue
+		}
+		msg := "writeset dumped in " + filepath.Base(dump.path) + "(seqno:" + dump.seqno + ", " + humanBytes(dump.size) + ")"
+		lt = append(lt, types.NewLogInfo(types.NewDate(dump.date, "2006-01-02T15:04:05.000000Z"), types.SimpleDisplayer(msg), dump.path, logRegex, "WritesetDump", types.NewLogCtx(), ""))
+	}
+
+	sort.SliceStable(lt, func(i, j int) bool { return lt[i].Date.Time.Before(lt[j].Date.Time) })
+	return lt
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+func TestIndexWritesetDumps(t *testing.T) {
+	dir := t.TempDir()
+	date := time.Date(2023, 5, 9, 17, 39, 19, 0, time.UTC)
+	files := map[string]time.Time{
+		"GRA_12_1234_v2.log": date,
+		"GRA_3_98.log":       date.Add(-time.Hour),
+		"GRA_7_-1.log":       date.Add(time.Hour),
+		"GRA_oops.log":       date,
+		"mysqld-error.log":   date,
+	}
+	for name, mtime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("writeset"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dumps, err := indexWritesetDumps(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []writesetDump{
+		{path: filepath.Join(dir, "GRA_7_-1.log"), thread: "7", seqno: "-1", date: date.Add(time.Hour), size: 8},
+		{path: filepath.Join(dir, "GRA_3_98.log"), thread: "3", seqno: "98", date: date.Add(-time.Hour), size: 8},
+		{path: filepath.Join(dir, "GRA_12_1234_v2.log"), thread: "12", seqno: "1234", date: date, size: 8},
+	}
+	if diff := cmp.Diff(expected, dumps, cmp.AllowUnexported(writesetDump{})); diff != "" {
+		t.Fatalf("unexpected dumps (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkWritesetDumps(t *testing.T) {
+	log := "2023-05-09T17:39:19.955085Z 12 [ERROR] [MY-000000] [WSREP] Failed to apply write set: gtid: 6ee26d1c-ee2b-11ed-8d6a-1e2e0a7ee0cf:1234 server_id: 5a6f0c2e-ee2b-11ed-a5e1-0b3c2f7ac4d6 client_key: 0 flags: 1"
+	lt := types.LocalTimeline{
+		types.NewLogInfo(nil, types.SimpleDisplayer("failed to apply writeset(seqno:1234)"), log, regex.ApplicativeMap["RegexApplyFailure"], "RegexApplyFailure", types.NewLogCtx(), ""),
+		types.NewLogInfo(nil, types.SimpleDisplayer("failed to apply writeset(seqno:1235)"), strings.Replace(log, ":1234", ":1235", 1), regex.ApplicativeMap["RegexApplyFailure"], "RegexApplyFailure", types.NewLogCtx(), ""),
+	}
+	linkWritesetDumps(lt, []writesetDump{{path: "/var/lib/mysql/GRA_12_1234_v2.log", seqno: "1234"}})
+
+	if msg := utils.RemoveColor(lt[0].Msg(types.NewLogCtx())); msg != "failed to apply writeset(seqno:1234)(dumped in GRA_12_1234_v2.log)" {
+		t.Errorf("the dump should be linked, got: %s", msg)
+	}
+	if msg := utils.RemoveColor(lt[1].Msg(types.NewLogCtx())); msg != "failed to apply writeset(seqno:1235)" {
+		t.Errorf("the dump should not be linked to other seqnos, got: %s", msg)
+	}
+}
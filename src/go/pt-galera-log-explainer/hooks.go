@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+// hookTimeout bounds each hook execution, a stuck command should not block the analysis
+const hookTimeout = 10 * time.Second
+
+// hook is an external command run for every event of some categories
+type hook struct {
+	// categories, same as --show: regex types or regex names
+	On      []string `json:"on"`
+	Command []string `json:"command"`
+	// optional, events less severe are ignored
+	MinSeverity string `json:"min-severity"`
+
+	minSeverity types.Severity
+}
+
+// hookEvent is the payload written on the stdin of hooks
+type hookEvent struct {
+	Timestamp string `json:"timestamp"`
+	Node      string `json:"node"`
+	Type      string `json:"type"`
+	Regex     string `json:"regex"`
+	Severity  string `json:"severity"`
+	State     string `json:"state"`
+	Message   string `json:"message"`
+	Log       string `json:"log"`
+}
+
+// loadHooks reads a JSON array of hooks, eg:
+//
+//	[{"on": ["RegexNodeEvicted", "sst"], "command": ["/usr/local/bin/open-ticket", "--queue", "dba"], "min-severity": "warning"}]
+func loadHooks(path string) ([]hook, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read hooks")
+	}
+	hooks := []hook{}
+	if err := json.Unmarshal(content, &hooks); err != nil {
+		return nil, errors.Wrap(err, "could not parse hooks")
+	}
+
+	for i := range hooks {
+		h := &hooks[i]
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			return nil, errors.Errorf("invalid hook %d: missing command", i+1)
+		}
+		if len(h.On) == 0 {
+			return nil, errors.Errorf("invalid hook %d: missing categories in \"on\"", i+1)
+		}
+		for _, category := range h.On {
+			if !isCategory(category) {
+				return nil, errors.Errorf("invalid hook %d: unknown category %q, expected a regex type (%s) or a regex name listed by 'regex-list'", i+1, category, strings.Join(regexTypeNames(), ", "))
+			}
+		}
+		if h.MinSeverity != "" {
+			h.minSeverity, err = types.ParseSeverity(h.MinSeverity)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid hook %d", i+1)
+			}
+		}
+	}
+	return hooks, nil
+}
+
+func (h hook) matches(li types.LogInfo) bool {
+	return li.Severity >= h.minSeverity && (utils.SliceContains(h.On, li.RegexUsed) || utils.SliceContains(h.On, string(li.RegexType)))
+}
+
+// runHooks executes hooks for each matching event
+// The first line printed by a hook is appended to the event, so that hooks can enrich events, eg: from a CMDB
+// Failures are only reported, they should not prevent the analysis
+func runHooks(timeline types.Timeline, hooks []hook) {
+	for _, node := range sortedNodes(timeline) {
+		lt := timeline[node]
+		for i := range lt {
+			for j, h := range hooks {
+				if !h.matches(lt[i]) {
+					continue
+				}
+				output, err := runHook(h, newHookEvent(node, lt[i]))
+				if err != nil {
+					logger.Warn().Str("command", h.Command[0]).Str("regex", lt[i].RegexUsed).Err(err).Msg("hook failed")
+					continue
+				}
+				if output != "" {
+					lt[i].AddNote("hook"+strconv.Itoa(j), output)
+				}
+			}
+		}
+	}
+}
+
+func newHookEvent(node string, li types.LogInfo) hookEvent {
+	event := hookEvent{
+		Node:     node,
+		Type:     string(li.RegexType),
+		Regex:    li.RegexUsed,
+		Severity: li.Severity.String(),
+		State:    li.LogCtx.State(),
+		Message:  utils.RemoveColor(li.RawMsg(li.LogCtx)),
+		Log:      li.Log,
+	}
+	if li.Date != nil {
+		event.Timestamp = li.Date.Time.Format(time.RFC3339Nano)
+	}
+	return event
+}
+
+// runHook gives the event as JSON on stdin, and returns the first line of stdout
+func runHook(h hook, event hookEvent) (string, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(append(payload, '\n'))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", errors.Errorf("timed out after %s", hookTimeout)
+		}
+		return "", errors.Wrapf(err, "stderr: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	line, _, _ := bufio.NewReader(&stdout).ReadLine()
+	return strings.TrimSpace(string(line)), nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+func TestLoadHooks(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectedErr string
+	}{
+		{
+			name:    "valid",
+			content: `[{"on": ["RegexNodeEvicted", "sst"], "command": ["open-ticket"], "min-severity": "warning"}]`,
+		},
+		{
+			name:        "missing command",
+			content:     `[{"on": ["sst"]}]`,
+			expectedErr: "missing command",
+		},
+		{
+			name:        "missing categories",
+			content:     `[{"command": ["open-ticket"]}]`,
+			expectedErr: "missing categories",
+		},
+		{
+			name:        "unknown category",
+			content:     `[{"on": ["RegexDoesNotExist"], "command": ["open-ticket"]}]`,
+			expectedErr: "unknown category",
+		},
+		{
+			name:        "unknown severity",
+			content:     `[{"on": ["sst"], "command": ["open-ticket"], "min-severity": "critical"}]`,
+			expectedErr: "unknown severity",
+		},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "hooks.json")
+		if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadHooks(path)
+		if test.expectedErr == "" && err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if test.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), test.expectedErr)) {
+			t.Fatalf("%s: expected error containing %q, got %v", test.name, test.expectedErr, err)
+		}
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	utils.SkipColor = true
+	defer func() { utils.SkipColor = false }()
+
+	payloads := filepath.Join(t.TempDir(), "payloads")
+	hooks := []hook{
+		{On: []string{"sst"}, Command: []string{"sh", "-c", "cat >> " + payloads + "; echo rack B2"}, minSeverity: types.SeverityWarning},
+		{On: []string{"RegexNodeEvicted"}, Command: []string{"sh", "-c", "exit 1"}},
+	}
+
+	logCtx := types.NewLogCtx()
+	date := types.NewDate(time.Date(2023, 3, 12, 7, 0, 0, 0, time.UTC), "2006-01-02T15:04:05.000000Z")
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			types.NewLogInfo(date, types.SimpleDisplayer("SST failed"), "2023-03-12T07:00:00.000000Z 0 [ERROR] SST failed", &types.LogRegex{Type: types.SSTRegexType, Severity: types.SeverityError}, "RegexSSTError", logCtx, "error.log"),
+			types.NewLogInfo(date, types.SimpleDisplayer("SST to node2"), "2023-03-12T07:00:01.000000Z 0 [Note] SST to node2", &types.LogRegex{Type: types.SSTRegexType, Severity: types.SeverityInfo}, "RegexSSTStreamingTo", logCtx, "error.log"),
+			types.NewLogInfo(date, types.SimpleDisplayer("evicted"), "2023-03-12T07:00:02.000000Z 0 [ERROR] evicted", &types.LogRegex{Type: types.ViewsRegexType, Severity: types.SeverityError}, "RegexNodeEvicted", logCtx, "error.log"),
+		},
+	}
+
+	runHooks(timeline, hooks)
+
+	msgs := []string{}
+	for _, li := range timeline["node1"] {
+		msgs = append(msgs, li.Msg(logCtx))
+	}
+	expected := []string{"SST failed(rack B2)", "SST to node2", "evicted"}
+	if strings.Join(msgs, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected %v, got %v", expected, msgs)
+	}
+
+	content, err := os.ReadFile(payloads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 payload, got %d: %s", len(lines), content)
+	}
+	event := hookEvent{}
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Node != "node1" || event.Regex != "RegexSSTError" || event.Severity != "error" || event.Message != "SST failed" || event.Type != "sst" {
+		t.Fatalf("unexpected payload: %+v", event)
+	}
+}
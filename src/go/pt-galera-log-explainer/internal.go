@@ -2,12 +2,16 @@ package main
 
 import (
 	"bufio"
+	"compress/gzip"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
@@ -38,20 +42,68 @@ func timelineFromPaths(paths []string, regexes types.RegexMap) (types.Timeline,
 	found := false
 
 	compiledRegex := prepareGrepArgument(regexes)
-
-	for _, path := range paths {
-		osinfo, err := os.Stat(path)
+	minSeverity := types.SeverityInfo
+	if CLI.MinSeverity != "" {
+		severity, err := types.ParseSeverity(CLI.MinSeverity)
 		if err != nil {
 			return nil, err
 		}
-		if osinfo.IsDir() {
-			return nil, errDirectoriesUnsupported
+		minSeverity = severity
+	}
+	regexes, err := regexesWithCategories(regexes, CLI.Show, CLI.Hide, minSeverity)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err = expandRemotes(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	rotationOf := map[string]string{}
+	if CLI.RotatedDiscovery {
+		paths, rotationOf = discoverRotatedFiles(paths)
+	}
+
+	paths, archives, cleanup, err := expandArchives(paths)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	archives.keepUntilDisplayed(cleanup)
+
+	displayPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		displayPaths = append(displayPaths, archives.displayPath(path))
+	}
+	for _, missing := range missingRotatedFiles(displayPaths) {
+		logger.Warn().Str("path", missing).Msg("rotated log file seems to be missing, events from this period will not be found")
+	}
+
+	rotations := map[string]types.LocalTimeline{}
+	rotationBases := []string{}
+
+	for _, path := range paths {
+		if !isRemote(path) {
+			osinfo, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			if osinfo.IsDir() {
+				return nil, errDirectoriesUnsupported
+			}
 		}
 
 		stdout := make(chan string)
 
 		go func() {
-			err := execGrepAndIterate(path, compiledRegex, stdout)
+			var err error
+			// extracted files are temporary, caching them would be useless
+			if _, extracted := archives[path]; CLI.CacheDir != "" && !extracted {
+				err = cachedGrepAndIterate(path, stdout)
+			} else {
+				err = execGrepAndIterate(path, compiledRegex, stdout)
+			}
 			if err != nil {
 				logger.Error().Str("path", path).Err(err).Msg("execGrepAndIterate returned error")
 			}
@@ -59,32 +111,98 @@ func timelineFromPaths(paths []string, regexes types.RegexMap) (types.Timeline,
 		}()
 
 		// it will iterate on stdout pipe results
-		localTimeline := iterateOnGrepResults(path, regexes, stdout)
+		localTimeline := iterateOnGrepResults(archives.displayPath(path), regexes, stdout)
 		if len(localTimeline) == 0 {
 			continue
 		}
 		found = true
 		logger.Debug().Str("path", path).Msg("finished searching")
 
-		// Why it should not just identify using the file path:
-		// so that we are able to merge files that belong to the same nodes
-		// we wouldn't want them to be shown as from different nodes
-		if CLI.PxcOperator {
-			timeline[path] = localTimeline
-		} else if CLI.MergeByDirectory {
-			timeline.MergeByDirectory(path, localTimeline)
-		} else {
-			timeline.MergeByIdentifier(localTimeline)
+		// rotations of a log are from the same node, even when they could not be identified on their own
+		if base, ok := rotationOf[path]; ok {
+			if _, ok := rotations[base]; !ok {
+				rotationBases = append(rotationBases, base)
+			}
+			rotations[base] = types.MergeTimeline(rotations[base], localTimeline)
+			continue
 		}
+		mergeLocalTimeline(timeline, archives.displayPath(path), localTimeline)
+	}
+	for _, base := range rotationBases {
+		mergeLocalTimeline(timeline, base, rotations[base])
 	}
 	if !found {
 		return nil, errors.New("could not find data")
 	}
+	if CLI.GapThreshold > 0 {
+		for node := range timeline {
+			timeline[node] = timeline[node].WithGaps(CLI.GapThreshold)
+		}
+	}
+	// always flagged, as it would be very easy to miss otherwise
+	display.FlagSplitBrains(timeline, display.SplitBrains(timeline))
+	if err := interleaveSlowLogs(timeline, CLI.SlowLog, CLI.SlowLogThreshold); err != nil {
+		return nil, err
+	}
+	if err := interleaveGeneralLogs(timeline, CLI.GeneralLog, CLI.GeneralLogFilter); err != nil {
+		return nil, err
+	}
+	if err := interleaveSystemLogs(timeline, CLI.SystemLog, CLI.SystemLogTZ); err != nil {
+		return nil, err
+	}
+	if err := interleaveWritesetDumps(timeline, CLI.GraDir); err != nil {
+		return nil, err
+	}
+	if CLI.Annotations != "" {
+		lt, err := annotationsFromCSV(CLI.Annotations)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read annotations")
+		}
+		if len(lt) > 0 {
+			timeline[annotationsNode] = lt
+		}
+	}
+	if CLI.Hooks != "" {
+		hooks, err := loadHooks(CLI.Hooks)
+		if err != nil {
+			return nil, err
+		}
+		runHooks(timeline, hooks)
+	}
+	if CLI.Anonymize {
+		timeline = anonymizeTimeline(timeline)
+	}
+	if len(CLI.Check) > 0 {
+		checkFailures = append(checkFailures, checkTimeline(timeline, CLI.Check)...)
+	}
 	return timeline, nil
 }
 
+func mergeLocalTimeline(timeline types.Timeline, path string, localTimeline types.LocalTimeline) {
+	// Why it should not just identify using the file path:
+	// so that we are able to merge files that belong to the same nodes
+	// we wouldn't want them to be shown as from different nodes
+	if CLI.PxcOperator {
+		timeline[path] = localTimeline
+	} else if CLI.MergeByDirectory {
+		timeline.MergeByDirectory(path, localTimeline)
+	} else {
+		timeline.MergeByIdentifier(localTimeline)
+	}
+}
+
 func prepareGrepArgument(regexes types.RegexMap) string {
 
+	grepRegex := grepArgument(regexes, CLI.Since)
+	if CLI.PxcOperator {
+		regexes.Merge(regex.PXCOperatorMap)
+	}
+	logger.Debug().Str("grepArg", grepRegex).Msg("compiled grep arguments")
+	return grepRegex
+}
+
+func grepArgument(regexes types.RegexMap, since *time.Time) string {
+
 	regexToSendSlice := regexes.Compile()
 
 	grepRegex := "^"
@@ -94,17 +212,15 @@ func prepareGrepArgument(regexes types.RegexMap) string {
 		// it needs to be put on the front so that it's not 'merged' with the '{"log":"' json prefix
 		// this is to keep things as close as '^' as possible to keep doing prefix searches
 		grepRegex += "((" + strings.Join(regex.PXCOperatorMap.Compile(), "|") + ")|^{\"log\":\""
-		regexes.Merge(regex.PXCOperatorMap)
 	}
-	if CLI.Since != nil {
-		grepRegex += "(" + regex.BetweenDateRegex(CLI.Since, CLI.PxcOperator) + "|" + regex.NoDatesRegex(CLI.PxcOperator) + ")"
+	if since != nil {
+		grepRegex += "(" + regex.BetweenDateRegex(since, CLI.PxcOperator) + "|" + regex.NoDatesRegex(CLI.PxcOperator) + ")"
 	}
 	grepRegex += ".*"
 	grepRegex += "(" + strings.Join(regexToSendSlice, "|") + ")"
 	if CLI.PxcOperator {
 		grepRegex += ")"
 	}
-	logger.Debug().Str("grepArg", grepRegex).Msg("compiled grep arguments")
 	return grepRegex
 }
 
@@ -129,7 +245,40 @@ func execGrepAndIterate(path, compiledRegex string, stdout chan<- string) error
 		logger.Warn().Msg("On Darwin systems, use 'pt-galera-log-explainer --grep-cmd=ggrep' as it requires grep v3")
 	}
 
-	cmd := exec.Command(CLI.GrepCmd, "-P", compiledRegex, path)
+	// -a: a few NUL bytes, from a crash or a full disk, would make grep consider the whole log as binary and print nothing
+	cmd := exec.Command(CLI.GrepCmd, "-a", "-P", compiledRegex, path)
+
+	cs, err := logCharset(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	// remote, compressed and non utf-8 logs are streamed to grep, large ones too so that progress can be reported
+	size, progress := progressSize(path)
+	if isRemote(path) || isCompressed(path) || progress || cs != charsetUTF8 {
+		r, err := openRawLog(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", path)
+		}
+		defer r.Close()
+		var in io.Reader = r
+		if progress {
+			p := newProgressReader(r, path, size, os.Stderr)
+			defer p.Close()
+			in = p
+		}
+		if isCompressed(path) {
+			gz, err := gzip.NewReader(in)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read %s", path)
+			}
+			defer gz.Close()
+			in = gz
+		}
+		in = decodeLog(in, cs)
+		cmd = exec.Command(CLI.GrepCmd, "-a", "-P", compiledRegex, "-")
+		cmd.Stdin = in
+	}
 
 	out, err := cmd.StdoutPipe()
 	if err != nil {
@@ -143,9 +292,27 @@ func execGrepAndIterate(path, compiledRegex string, stdout chan<- string) error
 	}
 
 	// grep treatment
-	s := bufio.NewScanner(out)
-	for s.Scan() {
-		stdout <- s.Text()
+	// not using a bufio.Scanner: its line length limit would silently drop the rest of the log after a corrupted region
+	r := bufio.NewReader(out)
+	damaged := 0
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimSuffix(line, "\n")
+		if line != "" {
+			if utils.IsDamagedLine(line) {
+				damaged++
+			}
+			stdout <- line
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "could not read grep output")
+		}
+	}
+	if damaged > 0 {
+		logger.Warn().Str("path", path).Int("lines", damaged).Msg("found lines with binary data or invalid characters, they were cleaned. Use the 'unparseable' subcommand to locate damaged regions")
 	}
 
 	// double-check it stopped correctly
@@ -159,11 +326,63 @@ func execGrepAndIterate(path, compiledRegex string, stdout chan<- string) error
 	return nil
 }
 
-func sanitizeLine(s string) string {
-	if len(s) > 0 && s[0] == '\t' {
-		return s[1:]
+func isCompressed(path string) bool {
+	return strings.HasSuffix(path, ".gz") && !isArchive(path)
+}
+
+// openLog opens logs grep cannot read by itself, transcoded to utf-8
+func openLog(path string) (io.ReadCloser, error) {
+	cs, err := logCharset(path)
+	if err != nil {
+		return nil, err
 	}
-	return s
+	r, err := openUndecodedLog(path)
+	if err != nil || cs == charsetUTF8 {
+		return r, err
+	}
+	return &decodedReadCloser{Reader: decodeLog(r, cs), underlying: r}, nil
+}
+
+// openUndecodedLog opens logs, decompressing them without transcoding them
+func openUndecodedLog(path string) (io.ReadCloser, error) {
+	r, err := openRawLog(path)
+	if err != nil || !isCompressed(path) {
+		return r, err
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return &gzipReadCloser{Reader: gz, underlying: r}, nil
+}
+
+// openRawLog opens logs without decompressing them
+func openRawLog(path string) (io.ReadCloser, error) {
+	if isRemote(path) {
+		return openRemote(path)
+	}
+	return os.Open(path)
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	underlying io.Closer
+}
+
+func (g *gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.underlying.Close()
+}
+
+type decodedReadCloser struct {
+	io.Reader
+	underlying io.Closer
+}
+
+func (d *decodedReadCloser) Close() error {
+	return d.underlying.Close()
 }
 
 // iterateOnGrepResults will take line by line each logs that matched regex
@@ -178,9 +397,11 @@ func iterateOnGrepResults(path string, regexes types.RegexMap, grepStdout <-chan
 	)
 	logCtx := types.NewLogCtx()
 	logCtx.FilePath = path
+	keys := regexes.SortedKeys()
+	skippedByProfile := map[string]int{}
 
 	for line := range grepStdout {
-		line = sanitizeLine(line)
+		line = utils.SanitizeLine(line)
 
 		var date *types.Date
 		t, layout, ok := regex.SearchDateFromLog(line)
@@ -206,15 +427,41 @@ func iterateOnGrepResults(path string, regexes types.RegexMap, grepStdout <-chan
 
 		// We have to find again what regex worked to get this log line
 		// it can match multiple regexes
-		for key, regex := range regexes {
+		for _, key := range keys {
+			regex := regexes[key]
 			if !regex.Regex.MatchString(line) || utils.SliceContains(CLI.ExcludeRegexes, key) {
 				continue
 			}
+			// the same words can mean something else in other versions, or be a leftover of a version upgrade
+			if !logCtx.Profile().Handles(regex) {
+				skippedByProfile[key]++
+				continue
+			}
 			logCtx, displayer = regex.Handle(logCtx, line, timestamp)
 			li := types.NewLogInfo(date, displayer, line, regex, key, logCtx, filetype)
 			lt = lt.Add(li)
 		}
 
 	}
+	reportProfile(path, logCtx, skippedByProfile)
 	return lt
 }
+
+// reportProfile tells which regex profile was used for a file, and which events were ignored because of it
+// so that version drifts do not silently hide events
+func reportProfile(path string, logCtx types.LogCtx, skippedByProfile map[string]int) {
+	profile := logCtx.Profile()
+	if profile == "" {
+		logger.Debug().Str("path", path).Msg("no galera or mysql version found, using every regex profiles")
+	} else {
+		logger.Info().Str("path", path).Str("profile", string(profile)).Str("provider", logCtx.ProviderVersion).Str("mysql", logCtx.Version).Msg("regex profile")
+	}
+	keys := make([]string, 0, len(skippedByProfile))
+	for key := range skippedByProfile {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		logger.Warn().Str("path", path).Str("profile", string(profile)).Str("regex", key).Int("lines", skippedByProfile[key]).Msg("ignored lines worded for another galera version")
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"errors"
 	"os"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
 )
 
 func TestTimelineFromPaths(t *testing.T) {
@@ -29,3 +33,79 @@ func TestTimelineFromPaths(t *testing.T) {
 	}
 
 }
+
+func TestIterateOnGrepResultsOrder(t *testing.T) {
+	// a single line matching several regexes must always give events in the same order
+	line := "2023-03-12T07:24:13.776492Z 0 [Note] [MY-000000] [Galera] Passing config to GCS: base_dir = /var/lib/mysql/; base_host = 172.17.0.2; base_port = 4567; pc.weight = 1;"
+	regexes := types.RegexMap{
+		"RegexProviderOptions": regex.EventsMap["RegexProviderOptions"],
+		"RegexBaseHost":        regex.IdentsMap["RegexBaseHost"],
+	}
+
+	for i := 0; i < 20; i++ {
+		lines := make(chan string, 1)
+		lines <- line
+		close(lines)
+
+		out := []string{}
+		for _, li := range iterateOnGrepResults("node1.log", regexes, lines) {
+			out = append(out, li.RegexUsed)
+		}
+		expected := []string{"RegexBaseHost", "RegexProviderOptions"}
+		if !cmp.Equal(out, expected) {
+			t.Fatalf("%s", cmp.Diff(expected, out))
+		}
+	}
+}
+
+func TestIterateOnGrepResultsProfiles(t *testing.T) {
+	regexes := types.RegexMap{
+		"RegexProviderVersion":              regex.EventsMap["RegexProviderVersion"],
+		"RegexMaxWriteSetSizeExceeded":      regex.ApplicativeMap["RegexMaxWriteSetSizeExceeded"],
+		"RegexTransactionSizeLimitExceeded": regex.ApplicativeMap["RegexTransactionSizeLimitExceeded"],
+	}
+	writesetLogs := []string{
+		"2023-05-09T17:39:19.955085Z 12 [ERROR] WSREP: Maximum writeset size exceeded by 1048612: 90 (Message too long)",
+		"2023-05-09T17:39:20.955085Z 12 [Warning] [MY-000000] [Galera] transaction size limit (1073741824) exceeded: 1073758208",
+	}
+
+	tests := []struct {
+		name     string
+		banner   string
+		expected []string
+	}{
+		{
+			name:     "unknown version",
+			expected: []string{"RegexMaxWriteSetSizeExceeded", "RegexTransactionSizeLimitExceeded"},
+		},
+		{
+			name:     "galera 3",
+			banner:   "2023-05-09T17:39:00.000000Z 0 [Note] WSREP: wsrep_load(): Galera 3.63(rf47405c) by Codership Oy <info@codership.com> loaded successfully.",
+			expected: []string{"RegexProviderVersion", "RegexMaxWriteSetSizeExceeded"},
+		},
+		{
+			name:     "galera 4",
+			banner:   "2023-05-09T17:39:00.000000Z 0 [Note] [MY-000000] [Galera] wsrep_load(): Galera 4.11(a9008fc) by Codership Oy <info@codership.com> (modified by Percona <https://percona.com/>) loaded successfully.",
+			expected: []string{"RegexProviderVersion", "RegexTransactionSizeLimitExceeded"},
+		},
+	}
+
+	for _, test := range tests {
+		lines := make(chan string, 3)
+		if test.banner != "" {
+			lines <- test.banner
+		}
+		for _, line := range writesetLogs {
+			lines <- line
+		}
+		close(lines)
+
+		out := []string{}
+		for _, li := range iterateOnGrepResults("node1.log", regexes, lines) {
+			out = append(out, li.RegexUsed)
+		}
+		if !cmp.Equal(out, test.expected) {
+			t.Errorf("%s: %s", test.name, cmp.Diff(test.expected, out))
+		}
+	}
+}
@@ -2,24 +2,37 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
 	"github.com/pkg/errors"
 )
 
 type list struct {
 	// Paths is duplicated because it could not work as variadic with kong cli if I set it as CLI object
-	Paths                  []string `arg:"" name:"paths" help:"paths of the log to use"`
-	SkipStateColoredColumn bool     `help:"avoid having the placeholder colored with mysql state, which is guessed using several regexes that will not be displayed"`
-	All                    bool     `help:"List everything" xor:"states,views,events,sst,applicative"`
-	States                 bool     `help:"List WSREP state changes(SYNCED, DONOR, ...)" xor:"states"`
-	Views                  bool     `help:"List how Galera views evolved (who joined, who left)" xor:"views"`
-	Events                 bool     `help:"List generic mysql events (start, shutdown, assertion failures)" xor:"events"`
-	SST                    bool     `help:"List Galera synchronization event" xor:"sst"`
-	Applicative            bool     `help:"List applicative events (resyncs, desyncs, conflicts). Events tied to one's usage of Galera" xor:"applicative"`
+	Paths                  []string      `arg:"" name:"paths" help:"paths of the log to use"`
+	SkipStateColoredColumn bool          `help:"avoid having the placeholder colored with mysql state, which is guessed using several regexes that will not be displayed"`
+	All                    bool          `help:"List everything" xor:"states,views,events,sst,applicative"`
+	States                 bool          `help:"List WSREP state changes(SYNCED, DONOR, ...)" xor:"states"`
+	Views                  bool          `help:"List how Galera views evolved (who joined, who left)" xor:"views"`
+	Events                 bool          `help:"List generic mysql events (start, shutdown, assertion failures)" xor:"events"`
+	SST                    bool          `help:"List Galera synchronization event" xor:"sst"`
+	Applicative            bool          `help:"List applicative events (resyncs, desyncs, conflicts). Events tied to one's usage of Galera" xor:"applicative"`
+	Bucket                 time.Duration `help:"Collapse the timeline into fixed time buckets (eg: 5m, 1h), counting events per type for each node"`
+	Output                 string        `help:"Export events instead of printing the timeline, format: <type>:<path>. Available types: sqlite, csv, tsv. '-' as path prints to stdout for csv and tsv"`
+	Limit                  int           `help:"Maximum number of events to print"`
+	Around                 string        `help:"Only print events around this date, format: 2023-01-23T03:53:40Z (RFC3339) or '2023-01-23 03:53'. See --context"`
+	Context                int           `default:"50" help:"Number of events to print before and after the --around date"`
+	Columns                []string      `default:"date,msg" help:"Columns to print: date, delta (time since the previous event), state (node state before messages), msg (translated message) or raw (raw log line)"`
+	ColumnWidth            int           `help:"Maximum width of node columns. By default, columns share the terminal width when printing to a terminal, and have no limit otherwise"`
+	Overflow               string        `enum:"truncate,wrap" default:"truncate" help:"What to do with messages longer than columns: truncate them, ending with '...', or wrap them on the next lines"`
+	Relative               string        `help:"Print dates as offsets from an anchor event, eg: +00:03:12. The anchor is the first event of a --check condition (crash, non-primary, sst-failure, eviction) or of a regex listed by 'regex-list', 'first' for the first event, or a date"`
 }
 
 func (l *list) Help() string {
@@ -33,6 +46,14 @@ Usage:
 	%[1]s list --all *.log
 	%[1]s list --sst --views --states <list of files>
 	%[1]s list --events --views *.log
+	%[1]s list --all --output sqlite:events.db *.log
+	%[1]s list --all --output csv:- *.log
+	%[1]s list --all --bucket 5m *.log
+	%[1]s list --all --limit 100 *.log
+	%[1]s list --all --around "2023-03-12 19:41" --context 20 *.log
+	%[1]s list --all --columns date,delta,state,raw --column-width 80 *.log
+	%[1]s list --all --column-width 40 --overflow wrap *.log
+	%[1]s list --all --relative crash *.log
 	`, toolname)
 }
 
@@ -42,6 +63,16 @@ func (l *list) Run() error {
 		return errors.New("flag required: --all, or any parameters from: --sst --views --events --states --applicative")
 	}
 
+	window, err := l.window()
+	if err != nil {
+		return err
+	}
+
+	layout, err := l.layout()
+	if err != nil {
+		return err
+	}
+
 	toCheck := l.regexesToUse()
 
 	timeline, err := timelineFromPaths(CLI.List.Paths, toCheck)
@@ -57,9 +88,155 @@ func (l *list) Run() error {
 		fmt.Println(out)
 	}
 
-	display.TimelineCLI(timeline, CLI.Verbosity)
+	if l.Output != "" {
+		return writeOutput(timeline, l.Output)
+	}
+
+	if l.Bucket > 0 {
+		return display.TimelineBuckets(os.Stdout, timeline, l.Bucket, CLI.Verbosity)
+	}
+
+	if l.Relative != "" {
+		anchor, err := relativeAnchor(timeline, l.Relative)
+		if err != nil {
+			return err
+		}
+		layout.Anchor = &anchor
+	}
+
+	// printing the timeline consumes it
+	var explanations []display.Explanation
+	if CLI.Explain {
+		explanations = display.TimelineExplanations(timeline, CLI.Verbosity)
+	}
+
+	// logs from unrelated clusters are printed separately, merging them would be misleading
+	clusters := timeline.SplitByCluster()
+	if len(clusters) <= 1 {
+		display.TimelineCLI(timeline, CLI.Verbosity, window, layout)
+		return l.explain(explanations)
+	}
+	for i, cluster := range clusters {
+		if i > 0 {
+			fmt.Println()
+		}
+		title := "unknown cluster"
+		if len(cluster.UUIDs) > 0 {
+			title = "cluster " + strings.Join(cluster.UUIDs, ", ")
+		}
+		fmt.Println(utils.Paint(utils.BrightBlueText, title))
+		display.TimelineCLI(cluster.Timeline, CLI.Verbosity, window, layout)
+	}
+
+	return l.explain(explanations)
+}
+
+func (l *list) explain(explanations []display.Explanation) error {
+	if !CLI.Explain {
+		return nil
+	}
+	return display.ExplanationsCLI(os.Stdout, explanations)
+}
 
-	return nil
+// aroundLayouts are the accepted formats for --around, a quicker format than RFC3339 is allowed
+// as it is meant to be copy-pasted from a previous output
+var aroundLayouts = []string{time.RFC3339, "2006-01-02 15:04:05.000000", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+func (l *list) window() (display.Window, error) {
+	window := display.Window{Limit: l.Limit, Context: l.Context}
+	if l.Limit < 0 || l.Context < 0 {
+		return window, errors.New("--limit and --context cannot be negative")
+	}
+	if (l.Limit > 0 || l.Around != "") && (l.Output != "" || l.Bucket > 0) {
+		return window, errors.New("--limit and --around cannot be used along with --output or --bucket")
+	}
+	if l.Around == "" {
+		return window, nil
+	}
+	for _, layout := range aroundLayouts {
+		t, err := time.Parse(layout, l.Around)
+		if err == nil {
+			window.Around = &t
+			return window, nil
+		}
+	}
+	return window, errors.Errorf("could not parse --around date %s, expected format: 2023-01-23T03:53:40Z or 2023-01-23 03:53", l.Around)
+}
+
+// layoutColumns are the columns accepted by --columns
+var layoutColumns = []string{"date", "delta", "state", "msg", "raw"}
+
+// layout expects exactly one of "msg" and "raw" in --columns
+func (l *list) layout() (display.Layout, error) {
+	layout := display.Layout{Width: l.ColumnWidth, Wrap: l.Overflow == "wrap"}
+	if l.ColumnWidth < 0 {
+		return layout, errors.New("--column-width cannot be negative")
+	}
+	if l.ColumnWidth == 0 {
+		layout.TerminalWidth = terminalWidth()
+	}
+	messages := 0
+	for _, column := range l.Columns {
+		switch column {
+		case "date":
+			layout.Date = true
+		case "delta":
+			layout.Delta = true
+		case "state":
+			layout.State = true
+		case "msg":
+			messages++
+		case "raw":
+			layout.Raw = true
+			messages++
+		default:
+			return layout, errors.Errorf("unknown column %s in --columns, available columns: %s", column, strings.Join(layoutColumns, ", "))
+		}
+	}
+	if messages != 1 {
+		return layout, errors.New("--columns should contain either msg or raw")
+	}
+	return layout, nil
+}
+
+// relativeAnchor finds the date used by --relative
+func relativeAnchor(timeline types.Timeline, anchor string) (time.Time, error) {
+	for _, layout := range aroundLayouts {
+		t, err := time.Parse(layout, anchor)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	match, ok := checkConditions[anchor]
+	switch {
+	case ok:
+	case anchor == "first":
+		match = func(types.LogInfo) bool { return true }
+	case regex.AllRegexes()[anchor] != nil || regex.PXCOperatorMap[anchor] != nil:
+		match = func(li types.LogInfo) bool { return li.RegexUsed == anchor }
+	default:
+		return time.Time{}, errors.Errorf("unknown --relative anchor %s: expected a --check condition, a regex name, 'first' or a date", anchor)
+	}
+
+	var found *time.Time
+	for _, lt := range timeline {
+		for _, li := range lt {
+			if li.Date == nil || !match(li) {
+				continue
+			}
+			if found == nil || li.Date.Time.Before(*found) {
+				t := li.Date.Time
+				found = &t
+			}
+			// local timelines are sorted
+			break
+		}
+	}
+	if found == nil {
+		return time.Time{}, errors.Errorf("could not find any event for --relative anchor %s", anchor)
+	}
+	return *found, nil
 }
 
 func (l *list) regexesToUse() types.RegexMap {
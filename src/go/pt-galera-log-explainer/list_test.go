@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestRelativeAnchor(t *testing.T) {
+	layout := "2006-01-02T15:04:05.000000Z"
+	date := time.Date(2023, 3, 18, 21, 25, 8, 0, time.UTC)
+
+	timeline := types.Timeline{
+		"node1": types.LocalTimeline{
+			types.NewLogInfo(types.NewDate(date.Add(time.Second), layout), types.SimpleDisplayer("starting(8.0.28)"), "", &types.LogRegex{}, "RegexStarting", types.NewLogCtx(), "error.log"),
+			types.NewLogInfo(types.NewDate(date.Add(2*time.Minute), layout), types.SimpleDisplayer("crash: got signal 6"), "", &types.LogRegex{}, "RegexGotSignal6", types.NewLogCtx(), "error.log"),
+		},
+		"node2": types.LocalTimeline{
+			types.NewLogInfo(types.NewDate(date, layout), types.SimpleDisplayer("starting(8.0.28)"), "", &types.LogRegex{}, "RegexStarting", types.NewLogCtx(), "error.log"),
+			types.NewLogInfo(types.NewDate(date.Add(time.Minute), layout), types.SimpleDisplayer("crash: got signal 11"), "", &types.LogRegex{}, "RegexGotSignal11", types.NewLogCtx(), "error.log"),
+		},
+	}
+
+	tests := []struct {
+		anchor      string
+		expected    time.Time
+		expectedErr bool
+	}{
+		{anchor: "crash", expected: date.Add(time.Minute)},
+		{anchor: "RegexGotSignal6", expected: date.Add(2 * time.Minute)},
+		{anchor: "first", expected: date},
+		{anchor: "2023-03-18 21:00", expected: time.Date(2023, 3, 18, 21, 0, 0, 0, time.UTC)},
+		{anchor: "eviction", expectedErr: true},
+		{anchor: "unknown", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		anchor, err := relativeAnchor(timeline, test.anchor)
+		if (err != nil) != test.expectedErr {
+			t.Errorf("%s: unexpected error: %v", test.anchor, err)
+			continue
+		}
+		if !anchor.Equal(test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.anchor, test.expected, anchor)
+		}
+	}
+}
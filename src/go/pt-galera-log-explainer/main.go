@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/alecthomas/kong"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
@@ -28,24 +29,69 @@ var (
 var buildInfo = fmt.Sprintf("%s\nVersion %s\nBuild: %s using %s\nCommit: %s", toolname, Version, Build, GoVersion, Commit)
 
 var CLI struct {
-	NoColor          bool
+	NoColor          bool            `help:"Same as --color never"`
+	Color            string          `enum:"auto,always,never" default:"auto" help:"Paint outputs: auto, always, never. auto only paints terminals, and follows the NO_COLOR, CLICOLOR and CLICOLOR_FORCE environment variables"`
 	Since            *time.Time      `help:"Only list events after this date, format: 2023-01-23T03:53:40Z (RFC3339)"`
 	Until            *time.Time      `help:"Only list events before this date"`
+	DateFormat       string          `help:"Additional timestamp layout found at the start of log lines, written as Go reference time 'Mon Jan 2 15:04:05 2006', eg: '02/01/2006 15:04:05'. Usual mysql, ISO and syslog formats are detected without it"`
 	Verbosity        types.Verbosity `type:"counter" short:"v" default:"0" help:"-v: DebugMySQL (add every mysql info the tool used), -vv: Debug (internal tool debug)"`
+	Show             []string        `help:"Always display these event categories, whatever the verbosity. A category is a regex type (applicative, events, identity, pxc-operator, sst, states, views) or a regex name listed by 'regex-list'"`
+	Hide             []string        `help:"Never display these event categories, eg: --hide RegexFlowControl. Regex names take precedence over regex types"`
+	MinSeverity      string          `enum:"info,warning,error,fatal" default:"info" help:"Only display events at least this severe: info, warning, error, fatal. Categories given with --show are still displayed"`
+	Theme            string          `help:"JSON file choosing the color of each severity, eg: {\"warning\": \"magenta\", \"error\": \"bright-red\", \"fatal\": \"bright-red\"}. 'none' keeps the colors chosen for each event"`
+	Explain          bool            `help:"Describe what events found mean and their typical causes, after the output of 'list' and 'report'"`
+	Config           kong.ConfigFlag `help:"JSON file giving default values to flags, eg: {\"show\": [\"sst\"], \"hide\": [\"RegexFlowControl\"]}. ~/.pt-galera-log-explainer.json is used when it exists"`
 	PxcOperator      bool            `default:"false" help:"Analyze logs from Percona PXC operator. Off by default because it negatively impacts performance for non-k8s setups"`
 	ExcludeRegexes   []string        `help:"Remove regexes from analysis. List regexes using 'pt-galera-log-explainer regex-list'"`
 	MergeByDirectory bool            `help:"Instead of relying on identification, merge contexts and columns by base directory. Very useful when dealing with many small logs organized per directories."`
+	GapThreshold     time.Duration   `help:"Flag periods where a node has no events for longer than this duration (eg: 1h). Disabled by default"`
+	Check            []string        `enum:"crash,non-primary,sst-failure,eviction" help:"Exit with code 2 when one of these conditions is found in the analyzed range: crash, non-primary, sst-failure, eviction. Can be repeated"`
+	RotatedDiscovery bool            `default:"true" negatable:"" help:"Automatically add rotated logs found next to the given ones: error.log.1, error.log-20240501, error.log.2.gz, ..."`
+	Anonymize        bool            `help:"Replace IPs, node names, schemas and tables with pseudonyms, so that the output can be shared publicly"`
+	AnonymizeMapping string          `help:"File where pseudonyms are written along with original values, used along with --anonymize"`
+	Quiet            bool            `short:"q" help:"Do not report progress on stderr while parsing large logs"`
+	SlowLog          []string        `help:"Slow query log of a node, format: <node>:<path>, node being a column name of the timeline. Queries longer than --slow-log-threshold are added to its events. Can be repeated"`
+	SlowLogThreshold time.Duration   `default:"1s" help:"Minimum duration of queries from --slow-log to display"`
+	GeneralLog       []string        `help:"General query log of a node, format: <node>:<path>, node being a column name of the timeline. Queries matching --general-log-filter are added to its events. Can be repeated"`
+	GeneralLogFilter string          `help:"Regex selecting the queries of --general-log to display. Default: DDLs, grants, FLUSH, LOCK and SET GLOBAL statements"`
+	SystemLog        []string        `help:"dmesg -T output or syslog file (/var/log/messages, /var/log/syslog), format: [<node>:]<path>. OOM killer, disk errors and network link changes are added to the events of the node. Without node, syslog hostnames are used. Can be repeated"`
+	SystemLogTZ      string          `default:"Local" help:"Time zone of the dates of --system-log files, as syslog and dmesg -T use the local time of their host, eg: UTC, Europe/Paris. Default: the local time zone"`
+	GraDir           []string        `help:"Directory of GRA_*.log writeset dumps of a node, format: <node>:<dir>. Dumps are added to the events of the node, and linked to its apply failures and inconsistency votes by seqno. Can be repeated"`
+	Charset          []string        `help:"Charset of logs: auto, utf-8, latin1, utf-16le, utf-16be, format: [<path>:]<charset>, path being a glob matched against paths or file names. auto detects utf-16 and latin1 logs, except remote ones. Can be repeated, eg: --charset latin1 --charset 'node3*:utf-16le'" default:"auto"`
+	Hooks            string          `help:"JSON file of commands to run for every event of some categories, eg: [{\"on\": [\"RegexNodeEvicted\", \"sst\"], \"command\": [\"/usr/local/bin/open-ticket\"]}]. Events are given as JSON on stdin, the first line printed by a command is appended to the event"`
+	Annotations      string          `help:"CSV file of timestamped events to show alongside Galera events (deployments, configuration changes, network maintenance, ...), one 'timestamp,text' per line"`
 
 	List list `cmd:""`
-	//Whois     whois     `cmd:""`
 	//	Sed       sed       `cmd:""`
-	Ctx       ctx       `cmd:""`
-	RegexList regexList `cmd:""`
-	Conflicts conflicts `cmd:""`
+	Whois           whois           `cmd:""`
+	Ctx             ctx             `cmd:""`
+	RegexList       regexList       `cmd:""`
+	Conflicts       conflicts       `cmd:""`
+	Report          report          `cmd:""`
+	Stats           stats           `cmd:""`
+	Diff            diff            `cmd:""`
+	Browse          browse          `cmd:""`
+	Metrics         metrics         `cmd:""`
+	Annotate        annotate        `cmd:""`
+	Crashes         crashes         `cmd:""`
+	SplitBrain      splitBrain      `cmd:""`
+	Transfers       transfers       `cmd:""`
+	ProviderOptions providerOptions `cmd:""`
+	Context         rawContext      `cmd:""`
+	Replay          replay          `cmd:""`
+	Evictions       evictions       `cmd:""`
+	Unparseable     unparseable     `cmd:""`
+	Search          search          `cmd:""`
+	GenerateFixture generateFixture `cmd:""`
+	Bench           bench           `cmd:""`
 
 	Version kong.VersionFlag
 
-	GrepCmd string `help:"'grep' command path. Could need to be set to 'ggrep' for darwin systems" default:"grep"`
+	GrepCmd      string   `help:"'grep' command path. Could need to be set to 'ggrep' for darwin systems" default:"grep"`
+	SqliteCmd    string   `help:"'sqlite3' command path, used by --output sqlite:<path>" default:"sqlite3"`
+	AwsCmd       string   `help:"'aws' command path, used to list and read s3:// paths" default:"aws"`
+	ArchiveGlobs []string `help:"Patterns used to find logs inside tar, tar.gz and zip archives given as paths, matched against file names" default:"*.err,*error.log*,*mysqld.log*"`
+	CacheDir     string   `help:"Directory where grep results are cached per file, so that later runs on unchanged files do not scan them again. Disabled when empty"`
 }
 
 func main() {
@@ -53,6 +99,7 @@ func main() {
 		kong.Name(toolname),
 		kong.Description("An utility to merge and help analyzing Galera logs"),
 		kong.UsageOnError(),
+		kong.Configuration(kong.JSON, "~/.pt-galera-log-explainer.json"),
 		kong.Vars{
 			"version": buildInfo,
 		},
@@ -60,14 +107,37 @@ func main() {
 
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	zerolog.SetGlobalLevel(zerolog.WarnLevel)
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	if CLI.NoColor {
+		CLI.Color = "never"
+	}
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, NoColor: !colorEnabled(CLI.Color, os.Getenv, isTerminal(os.Stderr))})
 	if CLI.Verbosity == types.Debug {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 	}
 
-	utils.SkipColor = CLI.NoColor
+	utils.SkipColor = !colorEnabled(CLI.Color, os.Getenv, isTerminal(os.Stdout))
 	translate.AssumeIPStable = !CLI.PxcOperator
+	if CLI.Theme != "" {
+		err := loadTheme(CLI.Theme)
+		kongcli.FatalIfErrorf(err)
+	}
+	if CLI.DateFormat != "" {
+		regex.AddDateLayout(CLI.DateFormat)
+	}
 
 	err := kongcli.Run()
+	removeExtractedLogs()
 	kongcli.FatalIfErrorf(err)
+
+	if CLI.Anonymize && CLI.AnonymizeMapping != "" {
+		err = writeAnonymizeMapping(CLI.AnonymizeMapping)
+		kongcli.FatalIfErrorf(err)
+	}
+
+	if len(checkFailures) > 0 {
+		for _, failure := range checkFailures {
+			fmt.Fprintln(os.Stderr, "check failed: "+failure)
+		}
+		os.Exit(checkFailedExitCode)
+	}
 }
@@ -21,7 +21,7 @@ func TestMain(t *testing.T) {
 	}{
 		{
 			name: "upgrade_list_all",
-			cmd:  []string{"list", "--all"},
+			cmd:  []string{"list", "--all", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 		{
@@ -31,58 +31,58 @@ func TestMain(t *testing.T) {
 		},
 		{
 			name: "upgrade_list_sst",
-			cmd:  []string{"list", "--sst"},
+			cmd:  []string{"list", "--sst", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 		{
 			name: "upgrade_list_events",
-			cmd:  []string{"list", "--events"},
+			cmd:  []string{"list", "--events", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 		{
 			name: "upgrade_list_views",
-			cmd:  []string{"list", "--views"},
+			cmd:  []string{"list", "--views", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 		{
 			name: "upgrade_list_states",
-			cmd:  []string{"list", "--states"},
+			cmd:  []string{"list", "--states", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 		{
 			name: "upgrade_list_all_since_until",
-			cmd:  []string{"list", "--all", "--since=2023-03-12T13:13:14.886853Z", "--until=2023-03-12T19:35:07.644570Z"},
+			cmd:  []string{"list", "--all", "--since=2023-03-12T13:13:14.886853Z", "--until=2023-03-12T19:35:07.644570Z", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 		{
 			name: "upgrade_list_all_since",
-			cmd:  []string{"list", "--all", "--since=2023-03-12T13:13:14.886853Z"},
+			cmd:  []string{"list", "--all", "--since=2023-03-12T13:13:14.886853Z", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 		{
 			name: "upgrade_list_all_until",
-			cmd:  []string{"list", "--all", "--until=2023-03-12T19:35:07.644570Z"},
+			cmd:  []string{"list", "--all", "--until=2023-03-12T19:35:07.644570Z", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 		{
 			name: "upgrade_list_all_until_hiding_1_node",
-			cmd:  []string{"list", "--all", "--until=2023-03-12T13:13:19.031367Z"},
+			cmd:  []string{"list", "--all", "--until=2023-03-12T13:13:19.031367Z", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 		{
 			name: "upgrade_list_all_until_hiding_2_nodes",
-			cmd:  []string{"list", "--all", "--until=2023-03-12T12:29:51.445280Z"},
+			cmd:  []string{"list", "--all", "--until=2023-03-12T12:29:51.445280Z", "--color=always"},
 			path: "tests/logs/upgrade/*.log",
 		},
 
 		{
 			name: "merge_rotated_daily_list_all",
-			cmd:  []string{"list", "--all"},
+			cmd:  []string{"list", "--all", "--color=always"},
 			path: "tests/logs/merge_rotated_daily/*",
 		},
 		{
 			name: "merge_rotated_daily_list_all_since_keeping_latest_logs",
-			cmd:  []string{"list", "--all", "--since=2023-03-18T21:18:23.102709+02:00"},
+			cmd:  []string{"list", "--all", "--since=2023-03-18T21:18:23.102709+02:00", "--color=always"},
 			path: "tests/logs/merge_rotated_daily/*",
 		},
 
@@ -100,7 +100,7 @@ func TestMain(t *testing.T) {
 
 		{
 			name: "conflict_conflicts",
-			cmd:  []string{"conflicts"},
+			cmd:  []string{"conflicts", "--color=always"},
 			path: "tests/logs/conflict/*",
 		},
 
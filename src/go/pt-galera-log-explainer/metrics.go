@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type metrics struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+}
+
+func (m *metrics) Help() string {
+	return fmt.Sprintf(`Convert the timeline into time series, using the OpenMetrics text format
+	It exports restarts and flow control events as cumulative counters per node, and SST durations.
+	Every sample is timestamped, so that it can be backfilled into Prometheus to build incident dashboards
+
+Usage:
+	%[1]s metrics *.log > metrics.txt
+	promtool tsdb create-blocks-from openmetrics metrics.txt
+	`, toolname)
+}
+
+func (m *metrics) Run() error {
+
+	timeline, err := timelineFromPaths(m.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not compute metrics")
+	}
+
+	return display.TimelineMetrics(os.Stdout, timeline)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/pkg/errors"
+)
+
+// writeOutput exports the timeline using the "--output <type>:<path>" format
+// "-" as a path means stdout, when the type allows it
+func writeOutput(timeline types.Timeline, output string) error {
+	format, target, _ := strings.Cut(output, ":")
+	if target == "" {
+		return errors.Errorf("missing path in --output %s, expected format: <type>:<path>", output)
+	}
+
+	switch format {
+	case "sqlite":
+		return writeSQLite(timeline, target)
+	case "csv":
+		return writeFile(target, func(w io.Writer) error { return display.TimelineCSV(w, timeline, ',') })
+	case "tsv":
+		return writeFile(target, func(w io.Writer) error { return display.TimelineCSV(w, timeline, '\t') })
+	default:
+		return errors.Errorf("unsupported output type: %s", format)
+	}
+}
+
+// writeSQLite pipes the timeline to the sqlite3 command line
+// like grep, sqlite3 is widely available and it avoids depending on cgo
+func writeSQLite(timeline types.Timeline, path string) error {
+	cmd := exec.Command(CLI.SqliteCmd, "-bail", path)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return errors.Wrap(err, "could not open stdin pipe")
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err = cmd.Start()
+	if err != nil {
+		return errors.Wrapf(err, "failed to execute %s", CLI.SqliteCmd)
+	}
+
+	err = display.TimelineSQL(stdin, timeline)
+	stdin.Close()
+	if waitErr := cmd.Wait(); waitErr != nil {
+		return errors.Wrapf(waitErr, "sqlite subprocess error: %s", stderr.String())
+	}
+	return errors.Wrap(err, "could not write events to sqlite")
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "could not create output file")
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return errors.Wrapf(err, "could not write %s", path)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// smaller logs are parsed fast enough to not need any feedback
+const progressMinSize = 64 << 20
+
+const progressInterval = 500 * time.Millisecond
+
+// progressReader counts bytes read from a log, and regularly reports it
+// Counting happens before decompression, so that it can be compared with the file size
+type progressReader struct {
+	r     io.Reader
+	read  atomic.Int64
+	total int64 // 0 when unknown, eg: remote logs
+	path  string
+	start time.Time
+	out   io.Writer
+	done  chan struct{}
+	ended chan struct{}
+}
+
+// showProgress tells if progress should be reported on stderr
+// It is never reported when stderr is not a terminal, as it would pollute redirected outputs
+func showProgress() bool {
+	if CLI.Quiet {
+		return false
+	}
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressSize tells if progress should be reported for this log, and its size when known
+func progressSize(path string) (int64, bool) {
+	if !showProgress() {
+		return 0, false
+	}
+	// downloads can be slow whatever their size
+	if isRemote(path) {
+		return 0, true
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < progressMinSize {
+		return 0, false
+	}
+	return info.Size(), true
+}
+
+func newProgressReader(r io.Reader, path string, total int64, out io.Writer) *progressReader {
+	p := &progressReader{r: r, total: total, path: path, start: time.Now(), out: out, done: make(chan struct{}), ended: make(chan struct{})}
+	go func() {
+		defer close(p.ended)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprint(p.out, "\r"+p.String(time.Now())+"\033[K")
+			case <-p.done:
+				fmt.Fprint(p.out, "\r\033[K")
+				return
+			}
+		}
+	}()
+	return p
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read.Add(int64(n))
+	return n, err
+}
+
+// Close stops reporting and clears the progress line
+func (p *progressReader) Close() {
+	close(p.done)
+	<-p.ended
+}
+
+func (p *progressReader) String(now time.Time) string {
+	read := p.read.Load()
+	if p.total <= 0 {
+		return fmt.Sprintf("%s: %s", p.path, humanBytes(read))
+	}
+	s := fmt.Sprintf("%s: %s/%s (%.0f%%)", p.path, humanBytes(read), humanBytes(p.total), float64(read)*100/float64(p.total))
+	elapsed := now.Sub(p.start)
+	if read > 0 && read < p.total && elapsed > 0 {
+		eta := time.Duration(float64(elapsed) * float64(p.total-read) / float64(read))
+		s += " ETA " + eta.Round(time.Second).String()
+	}
+	return s
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressReader(t *testing.T) {
+	var out strings.Builder
+	p := newProgressReader(strings.NewReader(strings.Repeat("a", 3<<20)), "error.log", 4<<20, &out)
+	if _, err := io.Copy(io.Discard, p); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	expected := "error.log: 3.0MiB/4.0MiB (75%) ETA 10s"
+	if s := p.String(p.start.Add(30 * time.Second)); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+
+	unknown := &progressReader{path: "s3://bucket/error.log"}
+	unknown.read.Store(512)
+	if s := unknown.String(time.Now()); s != "s3://bucket/error.log: 512B" {
+		t.Errorf("unexpected progress for unknown size: %q", s)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type providerOptions struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+	All   bool     `help:"List every option loaded at startup, instead of only the ones usually involved in incidents"`
+}
+
+func (p *providerOptions) Help() string {
+	return fmt.Sprintf(`Track wsrep_provider_options of each node over time, and compare them between nodes
+	Options are taken from startup logs ("Passing config to GCS"). Runtime changes are not written in error logs:
+	they are found when general or audit logs recording "SET GLOBAL wsrep_provider_options" are given along.
+	Inconsistencies of gcache.size, evs timeouts, pc.weight and flow control limits are highlighted
+
+Usage:
+	%[1]s provider-options *.log
+	%[1]s provider-options --all error.log general.log
+	`, toolname)
+}
+
+func (p *providerOptions) Run() error {
+
+	timeline, err := timelineFromPaths(p.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not track provider options")
+	}
+
+	return display.ProviderOptionsCLI(os.Stdout, timeline, p.All)
+}
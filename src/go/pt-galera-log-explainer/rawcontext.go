@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	// browse and context read events again from their source files
+	display.OpenLog = openDisplayedLog
+}
+
+type rawContext struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+	At    string   `required:"" help:"Date of the event, as displayed in the timeline. A shorter date selects every event starting with it, eg: 2023-03-12T19:36"`
+	Node  string   `help:"Only drill down into events of this node"`
+	Match string   `help:"Only drill down into events whose message or raw log line match this regex"`
+	Lines int      `default:"10" help:"Number of raw lines to print before and after each event"`
+}
+
+func (c *rawContext) Help() string {
+	return fmt.Sprintf(`Print the raw log lines surrounding events of the timeline, including lines that matched no regex
+	Source files are read again, events are selected using the date displayed by other subcommands
+
+Usage:
+	%[1]s context --at 2023-03-12T19:36:48.567087Z *.log
+	%[1]s context --at 2023-03-12T19:36 --node node1 --match "SST" --lines 30 *.log
+	`, toolname)
+}
+
+func (c *rawContext) Run() error {
+	if c.Lines < 0 {
+		return errors.New("--lines cannot be negative")
+	}
+	filter := display.RawContextFilter{At: c.At, Node: c.Node}
+	if c.Match != "" {
+		re, err := regexp.Compile(c.Match)
+		if err != nil {
+			return errors.Wrap(err, "invalid --match regex")
+		}
+		filter.Match = re
+	}
+
+	timeline, err := timelineFromPaths(c.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not get events context")
+	}
+
+	return display.RawContextCLI(os.Stdout, timeline, CLI.Verbosity, filter, c.Lines)
+}
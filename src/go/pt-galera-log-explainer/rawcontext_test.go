@@ -0,0 +1,55 @@
+package main
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestReadRawLinesCompressed(t *testing.T) {
+	// latin1, and a line sanitized before being stored
+	log, err := charmap.Windows1252.NewEncoder().String("2023-03-12T07:24:13.000000Z 0 [Note] datadir /var/lib/données\n" +
+		"\t2023-03-12T07:24:14.000000Z 0 [Note] WSREP: Synchronized with group, ready for connections\n" +
+		"2023-03-12T07:24:15.000000Z 0 [Note] next\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "error.log.1.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(log)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// as stored in LogInfo.Log
+	line := "2023-03-12T07:24:14.000000Z 0 [Note] WSREP: Synchronized with group, ready for connections"
+	raw, err := display.ReadRawLines(path, line, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := display.RawLines{
+		Lines: []string{
+			"2023-03-12T07:24:13.000000Z 0 [Note] datadir /var/lib/données",
+			"\t" + line,
+			"2023-03-12T07:24:15.000000Z 0 [Note] next",
+		},
+		First: 1,
+		Event: 1,
+	}
+	if diff := cmp.Diff(expected, raw); diff != "" {
+		t.Errorf("unexpected lines (-want +got):\n%s", diff)
+	}
+}
@@ -2,6 +2,7 @@ package regex
 
 import (
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
@@ -45,6 +46,7 @@ var ApplicativeMap = types.RegexMap{
 		},
 	},
 
+	// inconsistency voting only exists since galera 4
 	"RegexInconsistencyVoteInit": &types.LogRegex{
 		Regex:         regexp.MustCompile("initiates vote on"),
 		InternalRegex: regexp.MustCompile("Member " + regexIdx + "\\(" + regexNodeName + "\\) initiates vote on " + regexUUID + ":" + regexSeqno + "," + regexErrorMD5 + ":  (?P<error>.*), Error_code:"),
@@ -72,6 +74,8 @@ var ApplicativeMap = types.RegexMap{
 				return utils.Paint(utils.YellowText, "inconsistency vote started by "+node) + "(seqno:" + seqno + ")"
 			}
 		},
+		Severity: types.SeverityWarning,
+		Profile:  types.ProfileGalera4,
 	},
 
 	"RegexInconsistencyVoteRespond": &types.LogRegex{
@@ -107,6 +111,7 @@ var ApplicativeMap = types.RegexMap{
 				return ""
 			}
 		},
+		Profile: types.ProfileGalera4,
 	},
 
 	// This one does not need to be variabilized
@@ -129,6 +134,8 @@ var ApplicativeMap = types.RegexMap{
 			}
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "vote (success) inconsistent, leaving cluster"))
 		},
+		Severity: types.SeverityFatal,
+		Profile:  types.ProfileGalera4,
 	},
 
 	"RegexInconsistencyVoted": &types.LogRegex{
@@ -136,6 +143,8 @@ var ApplicativeMap = types.RegexMap{
 		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "found inconsistent by vote"))
 		},
+		Severity: types.SeverityWarning,
+		Profile:  types.ProfileGalera4,
 	},
 
 	"RegexInconsistencyWinner": &types.LogRegex{
@@ -178,6 +187,8 @@ var ApplicativeMap = types.RegexMap{
 				return ""
 			}
 		},
+		Severity: types.SeverityWarning,
+		Profile:  types.ProfileGalera4,
 	},
 
 	"RegexInconsistencyRecovery": &types.LogRegex{
@@ -200,7 +211,114 @@ var ApplicativeMap = types.RegexMap{
 			return logCtx, types.SimpleDisplayer(voteResponse(vote, *c))
 		},
 		Verbosity: types.DebugMySQL,
+		Profile:   types.ProfileGalera4,
 	},
+
+	// 2023-03-12T13:13:19.158831Z 0 [Note] [MY-000000] [Galera] SST leaving flow control
+	"RegexFlowControl": &types.LogRegex{
+		Regex:         regexp.MustCompile("(entering|leaving) flow control"),
+		InternalRegex: regexp.MustCompile("(?P<action>entering|leaving) flow control"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.YellowText, submatches["action"]+" flow control"))
+		},
+		Verbosity: types.DebugMySQL,
+		Severity:  types.SeverityWarning,
+	},
+
+	// only logged with wsrep_log_conflicts
+	// 2023-05-09T17:39:19.955085Z 51 [Note] [MY-000000] [WSREP] cluster conflict due to certification failure for threads:
+	"RegexCertificationFailure": &types.LogRegex{
+		Regex: regexp.MustCompile("conflict due to certification failure"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.YellowText, "certification failure"))
+		},
+		Verbosity: types.DebugMySQL,
+		Severity:  types.SeverityWarning,
+	},
+
+	// the writeset is dumped in a GRA_<thread>_<seqno>*.log file of the datadir, see --gra-dir
+	// galera 4
+	// 2023-05-09T17:39:19.955085Z 12 [ERROR] [MY-000000] [WSREP] Failed to apply write set: gtid: 6ee26d1c-ee2b-11ed-8d6a-1e2e0a7ee0cf:1234 server_id: 5a6f0c2e-ee2b-11ed-a5e1-0b3c2f7ac4d6 client_key: 0 flags: 1
+	// galera 3
+	// 2023-05-09 17:39:19 12 [ERROR] WSREP: Failed to apply trx: source: 6ee26d1c-ee2b-11ed-8d6a-1e2e0a7ee0cf version: 4 local: 0 state: APPLYING flags: 1 conn_id: 12 trx_id: 3456 seqnos (l: 18, g: 1234, s: 1233, d: 1233, ts: 1683653959955085)
+	// 2023-05-09 17:39:19 12 [ERROR] WSREP: Failed to apply trx 1234 10 times
+	"RegexApplyFailure": &types.LogRegex{
+		Regex:         regexp.MustCompile("Failed to apply (write set|trx)"),
+		InternalRegex: regexp.MustCompile("Failed to apply (?:write set: gtid: " + regexUUID + ":" + regexSeqno + "|trx.*seqnos \\(l: [0-9]+, g: (?P<gseqno>[0-9]+)|trx (?P<trxseqno>[0-9]+) [0-9]+ times)?"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			msg := utils.Paint(utils.RedText, "failed to apply writeset")
+			// only one of them is found, depending on the wording
+			if seqno := submatches[groupSeqno] + submatches["gseqno"] + submatches["trxseqno"]; seqno != "" {
+				msg += "(seqno:" + seqno + ")"
+			}
+			return logCtx, types.SimpleDisplayer(msg)
+		},
+		Severity: types.SeverityError,
+	},
+
+	// galera 3
+	// 2023-05-09T17:39:19.955085Z 12 [ERROR] WSREP: Maximum writeset size exceeded by 1048612: 90 (Message too long)
+	"RegexMaxWriteSetSizeExceeded": &types.LogRegex{
+		Regex:         regexp.MustCompile("Maximum writeset size exceeded by"),
+		InternalRegex: regexp.MustCompile("exceeded by (?P<exceeded>[0-9]+)"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "writeset rejected") + ": wsrep_max_ws_size exceeded by " + submatches["exceeded"] + " bytes")
+		},
+		Severity: types.SeverityError,
+		Profile:  types.ProfileGalera3,
+	},
+
+	// galera 4
+	// 2023-05-09T17:39:19.955085Z 12 [Warning] [MY-000000] [Galera] transaction size limit (1073741824) exceeded: 1073758208
+	"RegexTransactionSizeLimitExceeded": &types.LogRegex{
+		Regex:         regexp.MustCompile("transaction size limit \\([0-9]+\\) exceeded"),
+		InternalRegex: regexp.MustCompile("limit \\((?P<limit>[0-9]+)\\) exceeded: (?P<size>[0-9]+)"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "writeset rejected") + ": " + submatches["size"] + " bytes, wsrep_max_ws_size is " + submatches["limit"])
+		},
+		Severity: types.SeverityError,
+		Profile:  types.ProfileGalera4,
+	},
+
+	// a replicated transaction has been waiting for locks held by a local one, usually long-running
+	// 2023-05-09T17:39:19.955085Z 12 [Note] WSREP: BF lock wait long for trx:0x3c7a3f query: UPDATE t SET c=1 WHERE id=1
+	"RegexBFLockWaitLong": &types.LogRegex{
+		Regex:         regexp.MustCompile("BF lock wait long"),
+		InternalRegex: regexp.MustCompile("BF lock wait long(?: for trx:? ?(?P<trx>[0-9a-fA-Fx]+))?"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			msg := utils.Paint(utils.YellowText, "replicated transaction waiting long for locks")
+			if submatches["trx"] != "" {
+				msg += "(trx: " + submatches["trx"] + ")"
+			}
+			return logCtx, types.SimpleDisplayer(msg)
+		},
+		Severity: types.SeverityWarning,
+	},
+
+	// wording depends on the galera and wsrep API versions, eg: "pre-commit timeout", "precommit timed out"
+	"RegexPreCommitTimeout": &types.LogRegex{
+		Regex: regexp.MustCompile("[Pp]re-?commit.*(timeout|timed out)"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.YellowText, "pre-commit timeout"))
+		},
+		Severity: types.SeverityWarning,
+	},
+}
+
+// WritesetSeqno returns the seqno of the writeset an applicative event is about, "" when there is none
+// It is used to link events with writesets dumped by nodes
+func WritesetSeqno(regexKey, log string) string {
+	r, ok := ApplicativeMap[regexKey]
+	if !ok || r.InternalRegex == nil {
+		return ""
+	}
+	submatches := r.InternalRegex.FindStringSubmatch(log)
+	for i, name := range r.InternalRegex.SubexpNames() {
+		if i < len(submatches) && submatches[i] != "" && strings.HasSuffix(name, groupSeqno) {
+			return submatches[i]
+		}
+	}
+	return ""
 }
 
 func voteResponse(vote types.ConflictVote, conflict types.Conflict) string {
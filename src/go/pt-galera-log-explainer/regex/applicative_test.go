@@ -159,7 +159,102 @@ func TestApplicativeRegex(t *testing.T) {
 			expectedOut: "vote (success) inconsistent, leaving cluster",
 			key:         "RegexInconsistencyVoteInconsistentWithGroup",
 		},
+
+		{
+			log:         "2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] SST leaving flow control",
+			expectedOut: "leaving flow control",
+			key:         "RegexFlowControl",
+		},
+		{
+			log:         "2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] SST entering flow control",
+			expectedOut: "entering flow control",
+			key:         "RegexFlowControl",
+		},
+
+		{
+			log:         "2001-01-01T01:01:01.000000Z 51 [Note] [MY-000000] [WSREP] cluster conflict due to certification failure for threads:",
+			expectedOut: "certification failure",
+			key:         "RegexCertificationFailure",
+		},
+
+		{
+			log:         "2001-01-01T01:01:01.000000Z 12 [ERROR] [MY-000000] [WSREP] Failed to apply write set: gtid: 6ee26d1c-ee2b-11ed-8d6a-1e2e0a7ee0cf:1234 server_id: 5a6f0c2e-ee2b-11ed-a5e1-0b3c2f7ac4d6 client_key: 0 flags: 1",
+			expectedOut: "failed to apply writeset(seqno:1234)",
+			key:         "RegexApplyFailure",
+		},
+		{
+			name:        "galera 3",
+			log:         "2001-01-01 01:01:01 12 [ERROR] WSREP: Failed to apply trx: source: 6ee26d1c-ee2b-11ed-8d6a-1e2e0a7ee0cf version: 4 local: 0 state: APPLYING flags: 1 conn_id: 12 trx_id: 3456 seqnos (l: 18, g: 1234, s: 1233, d: 1233, ts: 1683653959955085)",
+			expectedOut: "failed to apply writeset(seqno:1234)",
+			key:         "RegexApplyFailure",
+		},
+		{
+			name:        "galera 3 retries",
+			log:         "2001-01-01 01:01:01 12 [ERROR] WSREP: Failed to apply trx 1234 10 times",
+			expectedOut: "failed to apply writeset(seqno:1234)",
+			key:         "RegexApplyFailure",
+		},
+
+		{
+			log:         "2001-01-01T01:01:01.000000Z 12 [ERROR] WSREP: Maximum writeset size exceeded by 1048612: 90 (Message too long)",
+			expectedOut: "writeset rejected: wsrep_max_ws_size exceeded by 1048612 bytes",
+			key:         "RegexMaxWriteSetSizeExceeded",
+		},
+
+		{
+			log:         "2001-01-01T01:01:01.000000Z 12 [Warning] [MY-000000] [Galera] transaction size limit (1073741824) exceeded: 1073758208",
+			expectedOut: "writeset rejected: 1073758208 bytes, wsrep_max_ws_size is 1073741824",
+			key:         "RegexTransactionSizeLimitExceeded",
+		},
+
+		{
+			log:         "2001-01-01T01:01:01.000000Z 12 [Note] WSREP: BF lock wait long for trx:0x3c7a3f query: UPDATE t SET c=1 WHERE id=1",
+			expectedOut: "replicated transaction waiting long for locks(trx: 0x3c7a3f)",
+			key:         "RegexBFLockWaitLong",
+		},
+		{
+			name:        "without trx",
+			log:         "2001-01-01 01:01:01 12 [Note] InnoDB: WSREP: BF lock wait long",
+			expectedOut: "replicated transaction waiting long for locks",
+			key:         "RegexBFLockWaitLong",
+		},
+
+		{
+			log:         "2001-01-01T01:01:01.000000Z 12 [Warning] [MY-000000] [WSREP] pre-commit timeout for thread 12",
+			expectedOut: "pre-commit timeout",
+			key:         "RegexPreCommitTimeout",
+		},
 	}
 
 	iterateRegexTest(t, ApplicativeMap, tests)
 }
+
+func TestWritesetSeqno(t *testing.T) {
+	tests := []struct {
+		key, log, expected string
+	}{
+		{
+			key:      "RegexApplyFailure",
+			log:      "2001-01-01 01:01:01 12 [ERROR] WSREP: Failed to apply trx 1234 10 times",
+			expected: "1234",
+		},
+		{
+			key:      "RegexInconsistencyVoteInit",
+			log:      "2001-01-01T01:01:01.000000Z 1 [Note] [MY-000000] [Galera] Member 0(node1) initiates vote on 8d0c5e11-ee2b-11ed-8d6a-1e2e0a7ee0cf:20,bdb2b9234ae75cb3:  some error, Error_code: 1032;",
+			expected: "20",
+		},
+		{
+			key: "RegexCertificationFailure",
+			log: "2001-01-01T01:01:01.000000Z 51 [Note] [MY-000000] [WSREP] cluster conflict due to certification failure for threads:",
+		},
+		{
+			key: "RegexStarting",
+			log: "2001-01-01T01:01:01.000000Z 0 [System] [MY-010116] [Server] /usr/sbin/mysqld (mysqld 8.0.30-22) starting as process 1",
+		},
+	}
+	for _, test := range tests {
+		if out := WritesetSeqno(test.key, test.log); out != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.key, test.expected, out)
+		}
+	}
+}
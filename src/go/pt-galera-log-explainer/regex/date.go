@@ -2,7 +2,9 @@ package regex
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
@@ -14,16 +16,34 @@ import (
 //5.7 date : 2019-07-17T15:16:37.123456Z
 //5.7 date : 2019-07-17T15:16:37.123456+01:00
 // 10.3 date: 2019-07-15  7:32:25
+// garbd date: 2019-07-15 07:32:25.123
+// syslog date: Jul 15 07:32:25
 var DateLayouts = []string{
 	"2006-01-02T15:04:05.000000Z",      // 5.7
 	"2006-01-02T15:04:05.000000-07:00", // 5.7
 	"2006-01-02T15:04:05Z",             // found in some crashes
 	"060102 15:04:05",                  // 5.5
+	"2006-01-02 15:04:05.000000",       // ISO without T, found in forwarded logs
+	"2006-01-02 15:04:05.000",          // galera library logging on its own, eg: garbd
 	"2006-01-02 15:04:05",              // 5.6
 	"2006-01-02  15:04:05",             // 10.3, yes the extra space is needed
 	"2006/01/02 15:04:05",              // sometimes found in socat errors
 }
 
+// syslogPrefix is what syslog adds to forwarded lines: "Jul 15 07:32:25 hostname mysqld[1234]: "
+// the original line may still have its own date, which is more precise
+var syslogPrefix = regexp.MustCompile(`^(?P<date>[A-Z][a-z]{2} [ 0-9][0-9] [0-9]{2}:[0-9]{2}:[0-9]{2}) [^ ]+ [^ :]+: `)
+
+const syslogLayout = "Jan _2 15:04:05"
+
+// AddDateLayout registers a user-given layout, tried before every other ones
+func AddDateLayout(layout string) {
+	DateLayouts = append([]string{layout}, DateLayouts...)
+}
+
+// now is overridden in tests, to infer years from dates without any
+var now = time.Now
+
 // BetweenDateRegex generate a regex to filter mysql error log dates to just get
 // events between 2 dates
 // Currently limited to filter by day to produce "short" regexes. Finer events will be filtered later in code
@@ -96,18 +116,47 @@ func NoDatesRegex(skipLeadingCircumflex bool) string {
 const k8sprefix = `{"log":"`
 
 func SearchDateFromLog(logline string) (time.Time, string, bool) {
-	if logline[:len(k8sprefix)] == k8sprefix {
-		logline = logline[len(k8sprefix):]
+	logline = strings.TrimPrefix(logline, k8sprefix)
+
+	if submatches := syslogPrefix.FindStringSubmatch(logline); submatches != nil {
+		if t, layout, ok := searchDateFromLayouts(logline[len(submatches[0]):]); ok {
+			return t, layout, true
+		}
+		t, err := time.Parse(syslogLayout, submatches[syslogPrefix.SubexpIndex("date")])
+		if err == nil {
+			return withInferredYear(t), syslogLayout, true
+		}
+	}
+
+	if t, layout, ok := searchDateFromLayouts(logline); ok {
+		return t, layout, true
 	}
+	log.Debug().Str("log", logline).Msg("could not find date from log")
+	return time.Time{}, "", false
+}
+
+func searchDateFromLayouts(logline string) (time.Time, string, bool) {
 	for _, layout := range DateLayouts {
 		if len(logline) < len(layout) {
 			continue
 		}
 		t, err := time.Parse(layout, logline[:len(layout)])
 		if err == nil {
-			return t, layout, true
+			return withInferredYear(t), layout, true
 		}
 	}
-	log.Debug().Str("log", logline).Msg("could not find date from log")
 	return time.Time{}, "", false
 }
+
+// withInferredYear sets the current year to dates which do not have any, or the previous one when it would be in the future
+func withInferredYear(t time.Time) time.Time {
+	if t.Year() != 0 {
+		return t
+	}
+	current := now()
+	t = t.AddDate(current.Year(), 0, 0)
+	if t.After(current.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
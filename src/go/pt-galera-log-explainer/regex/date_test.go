@@ -42,3 +42,97 @@ func TestDateAfter(t *testing.T) {
 		}
 	}
 }
+
+func TestSearchDateFromLog(t *testing.T) {
+	tests := []struct {
+		log            string
+		expected       time.Time
+		expectedLayout string
+	}{
+		{
+			log:            "2023-03-12T07:24:13.776492Z 0 [Note] [MY-000000] [Galera] Passing config to GCS",
+			expected:       time.Date(2023, 3, 12, 7, 24, 13, 776492000, time.UTC),
+			expectedLayout: "2006-01-02T15:04:05.000000Z",
+		},
+		{
+			log:            "2023-03-12 07:24:13.776  INFO: Shifting PRIMARY -> JOINER (TO: 42)",
+			expected:       time.Date(2023, 3, 12, 7, 24, 13, 776000000, time.UTC),
+			expectedLayout: "2006-01-02 15:04:05.000",
+		},
+		{
+			log:            "2023-03-12 07:24:13 140121640494848 [Note] WSREP: Shifting PRIMARY -> JOINER (TO: 42)",
+			expected:       time.Date(2023, 3, 12, 7, 24, 13, 0, time.UTC),
+			expectedLayout: "2006-01-02 15:04:05",
+		},
+		{
+			log:            "2023-03-12 07:24:13.776492 0 [Note] [MY-000000] [Galera] Shifting PRIMARY -> JOINER (TO: 42)",
+			expected:       time.Date(2023, 3, 12, 7, 24, 13, 776492000, time.UTC),
+			expectedLayout: "2006-01-02 15:04:05.000000",
+		},
+		{
+			log:            "151027  6:02:49 [Note] WSREP: Shifting PRIMARY -> JOINER (TO: 42)",
+			expected:       time.Date(2015, 10, 27, 6, 2, 49, 0, time.UTC),
+			expectedLayout: "060102 15:04:05",
+		},
+		{
+			log:            "151027 16:02:49 [Note] WSREP: Shifting PRIMARY -> JOINER (TO: 42)",
+			expected:       time.Date(2015, 10, 27, 16, 2, 49, 0, time.UTC),
+			expectedLayout: "060102 15:04:05",
+		},
+		{
+			log:            "2019-07-15  7:32:25 0 [Note] WSREP: Shifting PRIMARY -> JOINER (TO: 42)",
+			expected:       time.Date(2019, 7, 15, 7, 32, 25, 0, time.UTC),
+			expectedLayout: "2006-01-02 15:04:05",
+		},
+		{
+			log:            "Mar 12 07:24:13 db1 mysqld[1234]: 2023-03-12T07:24:13.776492Z 0 [Note] [MY-000000] [Galera] Shifting PRIMARY -> JOINER (TO: 42)",
+			expected:       time.Date(2023, 3, 12, 7, 24, 13, 776492000, time.UTC),
+			expectedLayout: "2006-01-02T15:04:05.000000Z",
+		},
+		{
+			log:            "Mar 12 07:24:13 db1 mysqld[1234]: WSREP: Shifting PRIMARY -> JOINER (TO: 42)",
+			expected:       time.Date(2023, 3, 12, 7, 24, 13, 0, time.UTC),
+			expectedLayout: "Jan _2 15:04:05",
+		},
+		{
+			// would be in the future with the current year
+			log:            "Dec  2 07:24:13 db1 mysqld: WSREP: Shifting PRIMARY -> JOINER (TO: 42)",
+			expected:       time.Date(2022, 12, 2, 7, 24, 13, 0, time.UTC),
+			expectedLayout: "Jan _2 15:04:05",
+		},
+		{
+			log:            `{"log":"2023-03-12T07:24:13.776492Z 0 [Note] [MY-000000] [Galera] Shifting PRIMARY -> JOINER (TO: 42)`,
+			expected:       time.Date(2023, 3, 12, 7, 24, 13, 776492000, time.UTC),
+			expectedLayout: "2006-01-02T15:04:05.000000Z",
+		},
+	}
+
+	now = func() time.Time { return time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	for _, test := range tests {
+		out, layout, ok := SearchDateFromLog(test.log)
+		if !ok {
+			t.Errorf("could not find date from %s", test.log)
+			continue
+		}
+		if !out.Equal(test.expected) || layout != test.expectedLayout {
+			t.Errorf("log: %s, expected %s (%s), got %s (%s)", test.log, test.expected, test.expectedLayout, out, layout)
+		}
+	}
+}
+
+func TestAddDateLayout(t *testing.T) {
+	defer func(layouts []string) { DateLayouts = layouts }(DateLayouts)
+
+	log := "12/03/2023 07:24:13 WSREP: Shifting PRIMARY -> JOINER (TO: 42)"
+	if _, _, ok := SearchDateFromLog(log); ok {
+		t.Fatalf("date should not be found without the custom layout")
+	}
+
+	AddDateLayout("02/01/2006 15:04:05")
+	out, layout, ok := SearchDateFromLog(log)
+	if !ok || !out.Equal(time.Date(2023, 3, 12, 7, 24, 13, 0, time.UTC)) || layout != "02/01/2006 15:04:05" {
+		t.Errorf("custom layout not used: got %s (%s)", out, layout)
+	}
+}
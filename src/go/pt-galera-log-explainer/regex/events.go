@@ -45,6 +45,7 @@ var EventsMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "terminated"))
 		},
+		Severity: types.SeverityWarning,
 	},
 	"RegexGotSignal6": &types.LogRegex{
 		Regex: regexp.MustCompile("mysqld got signal 6"),
@@ -52,6 +53,7 @@ var EventsMap = types.RegexMap{
 			logCtx.SetState("CLOSED")
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "crash: got signal 6"))
 		},
+		Severity: types.SeverityFatal,
 	},
 	"RegexGotSignal11": &types.LogRegex{
 		Regex: regexp.MustCompile("mysqld got signal 11"),
@@ -59,6 +61,7 @@ var EventsMap = types.RegexMap{
 			logCtx.SetState("CLOSED")
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "crash: got signal 11"))
 		},
+		Severity: types.SeverityFatal,
 	},
 	"RegexShutdownSignal": &types.LogRegex{
 		Regex: regexp.MustCompile("Normal|Received shutdown"),
@@ -78,6 +81,7 @@ var EventsMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "ABORTING"))
 		},
+		Severity: types.SeverityFatal,
 	},
 
 	"RegexWsrepLoad": &types.LogRegex{
@@ -90,6 +94,17 @@ var EventsMap = types.RegexMap{
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.GreenText, "started(cluster)"))
 		},
 	},
+	// 2023-03-12T19:35:05.851530Z 0 [Note] [MY-000000] [Galera] wsrep_load(): Galera 4.11(a9008fc) by Codership Oy <info@codership.com> (modified by Percona <https://percona.com/>) loaded successfully.
+	// 2023-03-12 19:35:05 140557650536640 [Note] WSREP: wsrep_load(): Galera 25.3.37(r3e1be8d) by Codership Oy <info@codership.com> loaded successfully.
+	"RegexProviderVersion": &types.LogRegex{
+		Regex:         regexp.MustCompile("wsrep_load\\(\\): Galera [0-9]"),
+		InternalRegex: regexp.MustCompile("Galera (?P<providerversion>[0-9]+\\.[0-9]+(\\.[0-9]+)?)"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			logCtx.ProviderVersion = submatches["providerversion"]
+			return logCtx, types.SimpleDisplayer("galera provider " + logCtx.ProviderVersion + " loaded")
+		},
+		Verbosity: types.DebugMySQL,
+	},
 	"RegexWsrepRecovery": &types.LogRegex{
 		//  INFO: WSREP: Recovered position 00000000-0000-0000-0000-000000000000:-1
 		Regex: regexp.MustCompile("Recovered position"),
@@ -120,6 +135,7 @@ var EventsMap = types.RegexMap{
 			}
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.YellowText, "unknown variable") + ": " + v)
 		},
+		Severity: types.SeverityError,
 	},
 
 	"RegexAssertionFailure": &types.LogRegex{
@@ -129,6 +145,52 @@ var EventsMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "ASSERTION FAILURE"))
 		},
+		Severity: types.SeverityFatal,
+	},
+	// 2023-06-12T07:51:38.135646Z 0 [ERROR] [MY-000000] [Server] Out of memory (Needed 16777216 bytes)
+	"RegexOutOfMemory": &types.LogRegex{
+		Regex: regexp.MustCompile("Out of memory|Cannot allocate memory"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "out of memory"))
+		},
+		Severity: types.SeverityFatal,
+	},
+	// 2023-06-12T07:51:38.135646Z 0 [Note] [MY-012552] [InnoDB] Starting crash recovery.
+	// also found in SST prepare logs, and "apply batch completed" is printed on every start: both are mostly useful to analyze crashes
+	"RegexInnoDBCrashRecovery": &types.LogRegex{
+		Regex: regexp.MustCompile("Starting crash recovery"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.YellowText, "innodb crash recovery"))
+		},
+		Verbosity: types.DebugMySQL,
+		Severity:  types.SeverityWarning,
+	},
+	// 2023-06-12T07:51:38.135646Z 0 [Note] [MY-012533] [InnoDB] Apply batch completed!
+	"RegexInnoDBRecoveryComplete": &types.LogRegex{
+		Regex: regexp.MustCompile("Apply batch completed"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.GreenText, "innodb recovery completed"))
+		},
+		Verbosity: types.DebugMySQL,
+	},
+	// 2022-12-18T01:03:17.950545Z 0 [Note] [MY-000000] [Galera] Passing config to GCS: base_dir = /var/lib/mysql/; base_host = 127.0.0.1; ...; pc.weight = 1; ...
+	"RegexProviderOptions": &types.LogRegex{
+		Regex:         regexp.MustCompile("Passing config to GCS"),
+		InternalRegex: regexp.MustCompile("Passing config to GCS: (?P<options>.*)"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer("provider options loaded")
+		},
+		Verbosity: types.DebugMySQL,
+	},
+	// the error log does not record runtime changes, they are found when general or audit logs are given
+	// 2023-03-12T08:10:00.000000Z	   12 Query	SET GLOBAL wsrep_provider_options='pc.weight=10'
+	"RegexSetProviderOptions": &types.LogRegex{
+		Regex:         regexp.MustCompile(`(?i:wsrep_provider_options)\s*=\s*['"]`),
+		InternalRegex: regexp.MustCompile(`(?i:wsrep_provider_options)\s*=\s*['"](?P<options>[^'"]*)`),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.YellowText, "provider options set") + ": " + submatches["options"])
+		},
+		Severity: types.SeverityWarning,
 	},
 	"RegexBindAddressAlreadyUsed": &types.LogRegex{
 		Regex: regexp.MustCompile("asio error .bind: Address already in use"),
@@ -137,12 +199,14 @@ var EventsMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "bind address already used"))
 		},
+		Severity: types.SeverityError,
 	},
 	"RegexTooManyConnections": &types.LogRegex{
 		Regex: regexp.MustCompile("Too many connections"),
 		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "too many connections"))
 		},
+		Severity: types.SeverityError,
 	},
 
 	"RegexReversingHistory": &types.LogRegex{
@@ -152,6 +216,7 @@ var EventsMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.BrightRedText, "having "+submatches["diff"]+" more events than the other nodes, data loss possible"))
 		},
+		Severity: types.SeverityFatal,
 	},
 }
 var regexWsrepLoadNone = regexp.MustCompile("none")
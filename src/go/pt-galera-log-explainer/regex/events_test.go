@@ -148,6 +148,47 @@ func TestEventsRegex(t *testing.T) {
 			key:         "RegexGotSignal11",
 		},
 
+		{
+			log:         "2001-01-01T01:01:01.000000Z 0 [ERROR] [MY-000000] [Server] Out of memory (Needed 16777216 bytes)",
+			expectedOut: "out of memory",
+			key:         "RegexOutOfMemory",
+		},
+		{
+			log:         "2001-01-01T01:01:01.000000Z 0 [ERROR] [MY-012681] [InnoDB] mmap(137363456 bytes) failed; errno 12: Cannot allocate memory",
+			expectedOut: "out of memory",
+			key:         "RegexOutOfMemory",
+		},
+		{
+			log:         "2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] Passing config to GCS: base_dir = /var/lib/mysql/; base_host = 127.0.0.1; base_port = 4567; gcache.size = 128M; pc.weight = 1;",
+			expectedOut: "provider options loaded",
+			key:         "RegexProviderOptions",
+		},
+		{
+			log:         "2001-01-01T01:01:01.000000Z	   12 Query	SET GLOBAL wsrep_provider_options='pc.weight=10;gcs.fc_limit=200'",
+			expectedOut: "provider options set: pc.weight=10;gcs.fc_limit=200",
+			key:         "RegexSetProviderOptions",
+		},
+		{
+			log:         "2001-01-01T01:01:01.000000Z	   12 Query	set global WSREP_PROVIDER_OPTIONS = \"gcache.size=2G\"",
+			expectedOut: "provider options set: gcache.size=2G",
+			key:         "RegexSetProviderOptions",
+		},
+		{
+			log:         "2001-01-01T01:01:01.000000Z 0 [Note] [MY-012552] [InnoDB] Starting crash recovery.",
+			expectedOut: "innodb crash recovery",
+			key:         "RegexInnoDBCrashRecovery",
+		},
+		{
+			log:         "2001-01-01 01:01:01 0 [Note] InnoDB: Starting crash recovery from checkpoint LSN=1625133",
+			expectedOut: "innodb crash recovery",
+			key:         "RegexInnoDBCrashRecovery",
+		},
+		{
+			log:         "2001-01-01T01:01:01.000000Z 0 [Note] [MY-012533] [InnoDB] Apply batch completed!",
+			expectedOut: "innodb recovery completed",
+			key:         "RegexInnoDBRecoveryComplete",
+		},
+
 		{
 			log: "2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [WSREP] Received shutdown signal. Will sleep for 10 secs before initiating shutdown. pxc_maint_mode switched to SHUTDOWN",
 			expected: regexTestState{
@@ -208,6 +249,25 @@ func TestEventsRegex(t *testing.T) {
 			key:         "RegexWsrepLoad",
 		},
 
+		{
+			name: "galera 4",
+			log:  "2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] wsrep_load(): Galera 4.11(a9008fc) by Codership Oy <info@codership.com> (modified by Percona <https://percona.com/>) loaded successfully.",
+			expected: regexTestState{
+				LogCtx: types.LogCtx{ProviderVersion: "4.11"},
+			},
+			expectedOut: "galera provider 4.11 loaded",
+			key:         "RegexProviderVersion",
+		},
+		{
+			name: "mariadb galera 3",
+			log:  "2001-01-01 01:01:01 140557650536640 [Note] WSREP: wsrep_load(): Galera 25.3.37(r3e1be8d) by Codership Oy <info@codership.com> loaded successfully.",
+			expected: regexTestState{
+				LogCtx: types.LogCtx{ProviderVersion: "25.3.37"},
+			},
+			expectedOut: "galera provider 25.3.37 loaded",
+			key:         "RegexProviderVersion",
+		},
+
 		{
 			log: "2001-01-01T01:01:01.000000Z 3 [Note] [MY-000000] [Galera] Recovered position from storage: 7780bb61-87cf-11eb-b53b-6a7c64b0fee3:23506640",
 			expected: regexTestState{
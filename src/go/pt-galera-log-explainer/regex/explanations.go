@@ -0,0 +1,78 @@
+package regex
+
+// Explanations describes what events mean and their typical causes, keyed by regex name
+// They are printed with --explain, so that the tool can be used to learn how galera behaves
+// Routine events, or events whose message is self-explanatory, do not need one
+var Explanations = map[string]string{
+
+	// applicative
+	"RegexDesync": "The node asked to leave the flow control loop: it keeps receiving writesets but other nodes will not wait for it to apply them. " +
+		"This is usually done on purpose, by setting wsrep_desync=ON before a backup or a heavy maintenance operation, or automatically when a node acts as a donor.",
+	"RegexResync": "The node joined the flow control loop again after a desync. It may still be catching up with writesets received meanwhile.",
+	"RegexInconsistencyVoteInit": "A node failed to apply a writeset that others applied, and asked the cluster to vote on the outcome. " +
+		"It usually comes from data drifting between nodes, eg: writes done with wsrep_on=OFF, non-transactional tables, or unsafe statements.",
+	"RegexInconsistencyVoteInconsistentWithGroup": "The vote showed that this node is the one diverging from the majority: it leaves the cluster to protect data consistency. " +
+		"It will need a SST to rejoin.",
+	"RegexInconsistencyWinner": "The outcome of an inconsistency vote: nodes voting like the winner stay in the cluster, the others leave it.",
+	"RegexFlowControl": "Flow control pauses replication on every node when one of them has too many writesets waiting to be applied (gcs.fc_limit). " +
+		"Frequent flow control is caused by slow nodes: undersized hardware, long running queries, large transactions, or too few applier threads (wsrep_slave_threads/wsrep_applier_threads).",
+	"RegexCertificationFailure": "A local transaction conflicted with a writeset replicated from another node, and was rolled back. " +
+		"Applications writing the same rows on several nodes at the same time hit this, they should retry on deadlock errors or write on a single node.",
+	"RegexApplyFailure": "A node could not apply a writeset that was already committed elsewhere, usually because its data is inconsistent with the other nodes. " +
+		"The writeset is dumped in a GRA_*.log file of the datadir, which can be read with mysqlbinlog to find the failing rows, see --gra-dir.",
+	"RegexMaxWriteSetSizeExceeded": "A transaction was rolled back because its writeset is larger than wsrep_max_ws_size. " +
+		"Large writesets are replicated as a whole and block appliers on every node: such transactions should be split into smaller batches.",
+	"RegexTransactionSizeLimitExceeded": "A transaction was rolled back because its writeset is larger than wsrep_max_ws_size. " +
+		"Large writesets are replicated as a whole and block appliers on every node: such transactions should be split into smaller batches.",
+	"RegexBFLockWaitLong": "A replicated writeset, which must be applied (brute force), waited a long time for locks held by a local transaction. " +
+		"Long running transactions and DDLs holding metadata locks usually cause this, along with flow control.",
+	"RegexPreCommitTimeout": "A transaction could not get through replication and certification in time. " +
+		"It is frequently a side effect of flow control, network issues or a cluster losing its primary component.",
+
+	// events
+	"RegexTerminated": "mysqld received a termination request, usually from systemd, an orchestrator or an operator. It is a normal shutdown unless it was not expected.",
+	"RegexGotSignal6": "mysqld aborted itself (SIGABRT) after detecting a state it could not continue from, usually after an assertion failure or an InnoDB corruption. " +
+		"The lines before it explain the cause, and the stack trace after it may match a known bug.",
+	"RegexGotSignal11":            "mysqld crashed on an invalid memory access (SIGSEGV). It is almost always a bug: the stack trace printed after it should be compared with known bugs of this version.",
+	"RegexAborting":               "mysqld stopped because it could not start or continue, eg: invalid configuration, failed SST, or a node not allowed to bootstrap. The lines before it tell why.",
+	"RegexUnknownConf":            "mysqld refused to start because of an unknown option in its configuration, frequently after an upgrade removing or renaming variables.",
+	"RegexAssertionFailure":       "An internal consistency check failed and mysqld is going to abort. It is usually a bug, or a sign of data corruption.",
+	"RegexOutOfMemory":            "mysqld could not allocate memory. Buffer sizes (innodb_buffer_pool_size, gcache.size, per-thread buffers) may be too large for the host or the container limits.",
+	"RegexInnoDBCrashRecovery":    "InnoDB did not stop cleanly and replays its redo logs, after a crash or a kill. A long recovery delays the node joining the cluster.",
+	"RegexBindAddressAlreadyUsed": "A port needed by mysqld or galera is already used, usually by another mysqld instance still running, or a stuck SST process.",
+	"RegexTooManyConnections":     "max_connections was reached. Connection pools growing during flow control or lock waits often cause this.",
+	"RegexReversingHistory":       "Galera detected that a node tried to replay history it did not have, its state was inconsistent with the cluster. The node will abort, and will need a SST.",
+
+	// sst
+	"RegexSSTRequestSuccess":         "A donor was selected for a joining node. Donors stop participating in flow control during the transfer, and can be slower meanwhile.",
+	"RegexSSTResourceUnavailable":    "No node could be selected as a donor: none was SYNCED, or every candidate from wsrep_sst_donor was unavailable. The joiner will retry.",
+	"RegexSSTCompleteUnknown":        "The donor finished the state transfer, but the joiner had already left the cluster: the joiner likely failed or was restarted meanwhile.",
+	"RegexSSTFailedUnknown":          "The state transfer failed, and the joiner had already left the cluster.",
+	"RegexSSTStateTransferFailed":    "The state transfer between the donor and the joiner failed. The SST logs of both sides (innobackup.backup.log, innobackup.prepare.log) tell why: network, disk space, permissions, or mismatched SST settings.",
+	"RegexSSTError":                  "The SST script exited with an error. The lines before it, or the SST logs, tell why.",
+	"RegexSSTCancellation":           "An ongoing SST was cancelled, usually because mysqld is shutting down.",
+	"RegexFailedToPrepareIST":        "The joiner asked for an IST, but the donor gcache does not hold every writeset it missed: a full SST is done instead. A larger gcache.size avoids this after short outages.",
+	"RegexSocatConnRefused":          "socat could not connect to the other side of the SST: the port may be blocked by a firewall, or the joiner may not be listening anymore.",
+	"RegexTimeoutReceivingFirstData": "The joiner did not receive anything from the donor in time. The donor may still be preparing the backup, be too slow, or the network may be blocking the SST port.",
+	"RegexWillNeverReceive":          "The joiner gave up on receiving a state transfer: it will not be able to join the cluster until restarted.",
+	"RegexISTFailed":                 "The incremental state transfer failed, the joiner will need a full SST.",
+	"RegexBypassSST":                 "The joiner state is recent enough, the SST is bypassed and only an IST is done.",
+
+	// views
+	"RegexNodeSuspect": "The node did not hear from another one for evs.suspect_timeout. If every other node suspects it too, it will be removed from the cluster. " +
+		"Network issues, long pauses of mysqld (swapping, stalls, VM freezes) or overloaded hosts are the usual causes.",
+	"RegexNodeEvicted": "The node was evicted by the cluster: it was found delayed too many times (evs.auto_evict) or evicted manually. " +
+		"It will not be able to rejoin until it is restarted, and its UUID is removed from the evict list (evs.evict).",
+	"RegexNodeDeclaredInactive": "A suspected node did not answer to membership messages either, it is now considered gone and will be removed from the view.",
+	"RegexEvsDelayedList":       "Nodes that answered slower than evs.delay_margin, and how many times. With evs.auto_evict enabled, nodes delayed too many times are evicted.",
+	"RegexAutoEvict":            "A node was evicted because it was delayed too many times (evs.auto_evict). It needs to be restarted to join again.",
+	"RegexNodeChangedIdentity":  "A node came back with a different UUID, which means it restarted.",
+	"RegexLastInactiveCheck":    "The internal galera checks could not run on time: mysqld was likely frozen for a while (swapping, CPU starvation, VM pauses, long disk stalls).",
+	"RegexWsrepUnsafeBootstrap": "The node refused to bootstrap a new cluster because it may not have the latest data (safe_to_bootstrap: 0 in grastate.dat). " +
+		"The node with the highest seqno should be bootstrapped, after checking positions with --wsrep-recover on every node.",
+	"RegexWsrepConsistenctyCompromised": "Galera found that this node data diverges from the cluster. The node stops replicating and will need a SST.",
+	"RegexWsrepNonPrimary": "The node is not part of a primary component: it cannot serve queries, it waits for a quorum to be formed again. " +
+		"It happens after network partitions, or when too many nodes left the cluster at once.",
+	"RegexBootstrap":  "A new cluster is being created from this node. Bootstrapping a node while another primary component exists creates a split-brain.",
+	"RegexNoGrastate": "grastate.dat could not be found: the node will need a full SST to join the cluster.",
+}
@@ -179,6 +179,45 @@ var IdentsMap = types.RegexMap{
 }
 
 func init_add_regexes() {
+	// 2023-03-15T20:10:57.799130+02:00 0 [Note] [MY-000000] [Galera] Quorum results:
+	//	version    = 6,
+	//	component  = PRIMARY,
+	//	conf_id    = 4,
+	//	members    = 2/2 (primary/total),
+	//	act_id     = 1504,
+	//	last_appl. = 1495,
+	//	protocols  = 2/10/4 (gcs/repl/appl),
+	//	vote policy= 0,
+	//	group UUID = 937dcf28-d38e-11ed-82ac-63ef4aef5b2a
+	IdentsMap["RegexClusterUUIDFromQuorum"] = &types.LogRegex{
+		Regex:         regexp.MustCompile("group UUID = "),
+		InternalRegex: regexp.MustCompile("group UUID = " + regexUUID),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+
+			uuid := submatches[groupUUID]
+			// a node not yet part of any primary component
+			if uuid == "00000000-0000-0000-0000-000000000000" {
+				return logCtx, nil
+			}
+			if !logCtx.AddClusterUUID(uuid) {
+				return logCtx, nil
+			}
+			return logCtx, types.SimpleDisplayer("cluster uuid: " + uuid)
+		},
+		Verbosity: types.DebugMySQL,
+	}
+
+	// act_id is the last seqno of the group, from the same quorum results
+	// nothing is displayed, it is kept in the timeline to compare partitions histories
+	IdentsMap["RegexQuorumActID"] = &types.LogRegex{
+		Regex:         regexp.MustCompile("act_id +="),
+		InternalRegex: regexp.MustCompile("act_id += " + regexSeqno),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, nil
+		},
+		Verbosity: types.DebugMySQL,
+	}
+
 	// 2023-01-06T07:05:34.035959Z 0 [Note] WSREP: (9509c194, 'tcp://0.0.0.0:4567') connection established to 838ebd6d tcp://ip:4567
 	IdentsMap["RegexOwnUUIDFromEstablished"] = &types.LogRegex{
 		Regex:         regexp.MustCompile("connection established to"),
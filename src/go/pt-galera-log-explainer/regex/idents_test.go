@@ -264,6 +264,44 @@ func TestIdentsRegex(t *testing.T) {
 			expectedOut: "my_idx=1",
 			key:         "RegexOwnIndexFromView",
 		},
+
+		{
+			log: "	group UUID = 937dcf28-d38e-11ed-82ac-63ef4aef5b2a",
+			expected: regexTestState{
+				LogCtx: types.LogCtx{
+					ClusterUUIDs: []string{"937dcf28-d38e-11ed-82ac-63ef4aef5b2a"},
+				},
+			},
+			expectedOut: "cluster uuid: 937dcf28-d38e-11ed-82ac-63ef4aef5b2a",
+			key:         "RegexClusterUUIDFromQuorum",
+		},
+		{
+			name: "already known",
+			log:  "	group UUID = 937dcf28-d38e-11ed-82ac-63ef4aef5b2a",
+			input: regexTestState{
+				LogCtx: types.LogCtx{
+					ClusterUUIDs: []string{"937dcf28-d38e-11ed-82ac-63ef4aef5b2a"},
+				},
+			},
+			expected: regexTestState{
+				LogCtx: types.LogCtx{
+					ClusterUUIDs: []string{"937dcf28-d38e-11ed-82ac-63ef4aef5b2a"},
+				},
+			},
+			displayerExpectedNil: true,
+			key:                  "RegexClusterUUIDFromQuorum",
+		},
+		{
+			name:                 "non-primary",
+			log:                  "	group UUID = 00000000-0000-0000-0000-000000000000",
+			displayerExpectedNil: true,
+			key:                  "RegexClusterUUIDFromQuorum",
+		},
+		{
+			log:                  "	act_id     = 1504,",
+			displayerExpectedNil: true,
+			key:                  "RegexQuorumActID",
+		},
 	}
 
 	iterateRegexTest(t, IdentsMap, tests)
@@ -168,3 +168,14 @@ func testActualGrepOnLog(t *testing.T, log, regex string) error {
 	}
 	return nil
 }
+
+func TestExplanationsMatchRegexes(t *testing.T) {
+	for key, text := range Explanations {
+		if AllRegexes()[key] == nil && PXCOperatorMap[key] == nil {
+			t.Errorf("%s is explained but is not a known regex", key)
+		}
+		if text == "" {
+			t.Errorf("%s has an empty explanation", key)
+		}
+	}
+}
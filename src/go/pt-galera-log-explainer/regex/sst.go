@@ -62,6 +62,7 @@ var SSTMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(joiner + utils.Paint(utils.YellowText, " cannot find donor"))
 		},
+		Severity: types.SeverityWarning,
 	},
 
 	// 2022-12-24T03:28:22.444125Z 0 [Note] WSREP: 0.0 (name): State transfer to 2.0 (name2) complete.
@@ -102,6 +103,7 @@ var SSTMap = types.RegexMap{
 			delete(logCtx.SSTs, donor)
 			return logCtx, types.SimpleDisplayer(donor + utils.Paint(utils.RedText, " synced ??(node left)"))
 		},
+		Severity: types.SeverityWarning,
 	},
 
 	"RegexSSTFailedUnknown": &types.LogRegex{
@@ -113,6 +115,7 @@ var SSTMap = types.RegexMap{
 			delete(logCtx.SSTs, donor)
 			return logCtx, types.SimpleDisplayer(donor + utils.Paint(utils.RedText, " failed to sync ??(node left)"))
 		},
+		Severity: types.SeverityError,
 	},
 
 	"RegexSSTStateTransferFailed": &types.LogRegex{
@@ -125,6 +128,7 @@ var SSTMap = types.RegexMap{
 			delete(logCtx.SSTs, donor)
 			return logCtx, types.SimpleDisplayer(donor + utils.Paint(utils.RedText, " failed to sync ") + joiner)
 		},
+		Severity: types.SeverityError,
 	},
 
 	"RegexSSTError": &types.LogRegex{
@@ -133,6 +137,7 @@ var SSTMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "SST error"))
 		},
+		Severity: types.SeverityError,
 	},
 
 	"RegexSSTInitiating": &types.LogRegex{
@@ -150,6 +155,7 @@ var SSTMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "former SST cancelled"))
 		},
+		Severity: types.SeverityWarning,
 	},
 
 	"RegexSSTProceeding": &types.LogRegex{
@@ -237,6 +243,7 @@ var SSTMap = types.RegexMap{
 			logCtx.SetSSTTypeMaybe("SST")
 			return logCtx, types.SimpleDisplayer("IST is not applicable")
 		},
+		Severity: types.SeverityWarning,
 	},
 
 	"RegexXtrabackupISTReceived": &types.LogRegex{
@@ -262,6 +269,7 @@ var SSTMap = types.RegexMap{
 		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "socat: connection refused"))
 		},
+		Severity: types.SeverityError,
 	},
 
 	// 2023-05-12T02:52:33.767132Z 0 [Note] [MY-000000] [WSREP-SST] Preparing the backup at /var/lib/mysql/sst-xb-tmpdir
@@ -277,6 +285,7 @@ var SSTMap = types.RegexMap{
 		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "timeout from donor in gtid/keyring stage"))
 		},
+		Severity: types.SeverityError,
 	},
 
 	"RegexWillNeverReceive": &types.LogRegex{
@@ -284,6 +293,7 @@ var SSTMap = types.RegexMap{
 		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "will never receive SST, aborting"))
 		},
+		Severity: types.SeverityError,
 	},
 
 	"RegexISTFailed": &types.LogRegex{
@@ -296,6 +306,7 @@ var SSTMap = types.RegexMap{
 
 			return logCtx, types.FormatByIPDisplayer("IST to %s"+utils.Paint(utils.RedText, " failed: ")+istError, joiner, date)
 		},
+		Severity: types.SeverityError,
 	},
 }
 
@@ -2,6 +2,7 @@ package regex
 
 import (
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -57,6 +58,7 @@ var ViewsMap = types.RegexMap{
 			translate.AddIPToMethod(ip, submatches[groupMethod], date)
 			return logCtx, types.FormatByHashDisplayer("%s"+utils.Paint(utils.RedText, " left"), hash, date)
 		},
+		Severity: types.SeverityWarning,
 	},
 
 	// New COMPONENT: primary = yes, bootstrap = no, my_idx = 1, memb_num = 5
@@ -92,6 +94,17 @@ var ViewsMap = types.RegexMap{
 		},
 	},
 
+	// view (view_id(PRIM,0509936f-8cc2,9)
+	// nothing is displayed, the view id identifies the primary component to detect split-brains
+	"RegexPrimaryView": &types.LogRegex{
+		Regex:         regexp.MustCompile("view ?\\(view_id\\(PRIM,"),
+		InternalRegex: regexp.MustCompile("view_id\\(PRIM,(?P<viewid>[a-z0-9-]+,[0-9]+)\\)"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, nil
+		},
+		Verbosity: types.DebugMySQL,
+	},
+
 	"RegexNodeSuspect": &types.LogRegex{
 		Regex:         regexp.MustCompile("suspecting node"),
 		InternalRegex: regexp.MustCompile("suspecting node: " + regexNodeHash),
@@ -101,6 +114,62 @@ var ViewsMap = types.RegexMap{
 
 			return logCtx, types.FormatByHashDisplayer("%s"+utils.Paint(utils.YellowText, " suspected to be down"), hash, date)
 		},
+		Severity: types.SeverityWarning,
+	},
+
+	// 2001-01-01T01:01:01.000000Z 0 [ERROR] [MY-000000] [Galera] exception from gcomm, backend must be restarted: this node has been evicted out of the cluster, gcomm backend restart is required (FATAL)
+	"RegexNodeEvicted": &types.LogRegex{
+		Regex: regexp.MustCompile("this node has been evicted out of the cluster"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			logCtx.SetState("CLOSED")
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "evicted from cluster"))
+		},
+		Severity: types.SeverityError,
+	},
+
+	// 2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) suspected node without join message, declaring inactive
+	// the node concerned is the one from the previous "suspecting node" line
+	"RegexNodeDeclaredInactive": &types.LogRegex{
+		Regex: regexp.MustCompile("suspected node without join message, declaring inactive"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(utils.Paint(utils.YellowText, "suspected node declared inactive"))
+		},
+		Verbosity: types.DebugMySQL,
+		Severity:  types.SeverityWarning,
+	},
+
+	// entries are "uuid:address:count", the same format as wsrep_evs_delayed: count is how many times the node was found delayed
+	// 2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) delayed list: 8e6f32b6-dc8e-11ed-95fc-de68239ebf89:tcp://172.17.0.2:4567:3
+	"RegexEvsDelayedList": &types.LogRegex{
+		Regex:         regexp.MustCompile("delayed list"),
+		InternalRegex: regexp.MustCompile("delayed list:? (?P<list>[^ ]+)"),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			delayed := ParseEvsDelayedList(submatches["list"])
+			if len(delayed) == 0 {
+				return logCtx, nil
+			}
+			return logCtx, func(types.LogCtx) string {
+				nodes := []string{}
+				for hash, count := range delayed {
+					nodes = append(nodes, translate.SimplestInfoFromHash(hash, date)+"("+strconv.Itoa(count)+")")
+				}
+				sort.Strings(nodes)
+				return utils.Paint(utils.YellowText, "delayed: ") + strings.Join(nodes, ", ")
+			}
+		},
+		Severity: types.SeverityWarning,
+	},
+
+	// evs.auto_evict: nodes found delayed too many times are evicted
+	// 2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) auto evicting node 8e6f32b6-bf89
+	"RegexAutoEvict": &types.LogRegex{
+		Regex:         regexp.MustCompile("[Ee]victing (node|member)|[Aa]uto[- ]evict(ing|ed) "),
+		InternalRegex: regexp.MustCompile("[Ee]vict(ing|ed)(?: node| member)?:? " + regexNodeHash),
+		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
+			hash := utils.UUIDToShortUUID(submatches[groupNodeHash])
+			return logCtx, types.FormatByHashDisplayer("%s"+utils.Paint(utils.RedText, " evicted (evs.auto_evict)"), hash, date)
+		},
+		Severity: types.SeverityError,
 	},
 
 	"RegexNodeChangedIdentity": &types.LogRegex{
@@ -115,6 +184,7 @@ var ViewsMap = types.RegexMap{
 			}
 			return logCtx, types.FormatByHashDisplayer("%s"+utils.Paint(utils.YellowText, " changed identity"), hash, date)
 		},
+		Severity: types.SeverityWarning,
 	},
 
 	"RegexLastInactiveCheck": &types.LogRegex{
@@ -124,6 +194,7 @@ var ViewsMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.YellowText, "inactive check more than "+submatches["configuredValue"]+"s ("+submatches["inactiveTime"]+"s)"))
 		},
+		Severity: types.SeverityWarning,
 	},
 
 	"RegexWsrepUnsafeBootstrap": &types.LogRegex{
@@ -133,6 +204,7 @@ var ViewsMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "not safe to bootstrap"))
 		},
+		Severity: types.SeverityError,
 	},
 	"RegexWsrepConsistenctyCompromised": &types.LogRegex{
 		Regex: regexp.MustCompile(".ode consistency compromi.ed"),
@@ -141,12 +213,14 @@ var ViewsMap = types.RegexMap{
 
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.RedText, "consistency compromised"))
 		},
+		Severity: types.SeverityFatal,
 	},
 	"RegexWsrepNonPrimary": &types.LogRegex{
 		Regex: regexp.MustCompile("failed to reach primary view"),
 		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
 			return logCtx, types.SimpleDisplayer("received " + utils.Paint(utils.RedText, "non primary"))
 		},
+		Severity: types.SeverityError,
 	},
 
 	"RegexBootstrap": &types.LogRegex{
@@ -154,6 +228,7 @@ var ViewsMap = types.RegexMap{
 		Handler: func(submatches map[string]string, logCtx types.LogCtx, log string, date time.Time) (types.LogCtx, types.LogDisplayer) {
 			return logCtx, types.SimpleDisplayer(utils.Paint(utils.YellowText, "bootstrapping"))
 		},
+		Severity: types.SeverityWarning,
 	},
 
 	"RegexSafeToBootstrapSet": &types.LogRegex{
@@ -252,3 +327,21 @@ d4397932 at tcp://ip:4567
 
 
 */
+
+// ParseEvsDelayedList reads "uuid:address:count" entries, and returns the count of each node short uuid
+func ParseEvsDelayedList(list string) map[string]int {
+	delayed := map[string]int{}
+	for _, entry := range strings.Split(list, ",") {
+		uuid, _, found := strings.Cut(entry, ":")
+		idx := strings.LastIndex(entry, ":")
+		if !found || idx < 0 {
+			continue
+		}
+		count, err := strconv.Atoi(entry[idx+1:])
+		if err != nil {
+			continue
+		}
+		delayed[utils.UUIDToShortUUID(uuid)] = count
+	}
+	return delayed
+}
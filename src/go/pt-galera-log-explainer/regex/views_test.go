@@ -141,6 +141,51 @@ func TestViewsRegex(t *testing.T) {
 			key:         "RegexNodeSuspect",
 		},
 
+		{
+			log: "2001-01-01T01:01:01.000000Z 0 [ERROR] [MY-000000] [Galera] exception from gcomm, backend must be restarted: this node has been evicted out of the cluster, gcomm backend restart is required (FATAL)",
+			expected: regexTestState{
+				State: "CLOSED",
+			},
+			expectedOut: "evicted from cluster",
+			key:         "RegexNodeEvicted",
+		},
+
+		{
+			log:         "2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) suspected node without join message, declaring inactive",
+			expectedOut: "suspected node declared inactive",
+			key:         "RegexNodeDeclaredInactive",
+		},
+
+		{
+			log: "2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) delayed list: 8e6f32b6-dc8e-11ed-95fc-de68239ebf89:tcp://172.17.0.2:4567:3,23e5ab1e-dc8e-11ed-95fc-de68239eb4aa:tcp://172.17.0.3:4567:1",
+			input: regexTestState{
+				HashToNodeNames: map[string]string{"8e6f32b6-95fc": "node2"},
+			},
+			expected: regexTestState{
+				HashToNodeNames: map[string]string{"8e6f32b6-95fc": "node2"},
+			},
+			expectedOut: "delayed: 23e5ab1e-95fc(1), node2(3)",
+			key:         "RegexEvsDelayedList",
+		},
+		{
+			name:                 "empty list",
+			log:                  "2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) delayed list: none",
+			displayerExpectedNil: true,
+			key:                  "RegexEvsDelayedList",
+		},
+
+		{
+			log: "2001-01-01T01:01:01.000000Z 0 [Note] [MY-000000] [Galera] evs::proto(9e45da20-bc1b, OPERATIONAL, view_id(REG,1f740c29-927e,90)) auto evicting node 8e6f32b6-bf89",
+			input: regexTestState{
+				HashToNodeNames: map[string]string{"8e6f32b6-bf89": "node2"},
+			},
+			expected: regexTestState{
+				HashToNodeNames: map[string]string{"8e6f32b6-bf89": "node2"},
+			},
+			expectedOut: "node2 evicted (evs.auto_evict)",
+			key:         "RegexAutoEvict",
+		},
+
 		{
 			log: "2001-01-01T01:01:01.000000Z 0 [Note] WSREP: remote endpoint tcp://172.17.0.2:4567 changed identity 84953af9 -> 5a478da2",
 			input: regexTestState{
@@ -232,6 +277,16 @@ func TestViewsRegex(t *testing.T) {
 			expectedOut: "bootstrapping(empty grastate)",
 			key:         "RegexBootstrappingDefaultState",
 		},
+		{
+			log:                  "view (view_id(PRIM,0509936f-8cc2,9)",
+			displayerExpectedNil: true,
+			key:                  "RegexPrimaryView",
+		},
+		{
+			log:                  "2001-01-01  1:01:01 0 [Note] WSREP: view(view_id(PRIM,e7e3f4f8,3) memb {",
+			displayerExpectedNil: true,
+			key:                  "RegexPrimaryView",
+		},
 	}
 
 	iterateRegexTest(t, ViewsMap, tests)
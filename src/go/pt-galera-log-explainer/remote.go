@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// remoteFile is a log read without being downloaded first, from S3 or HTTP(S)
+// Size and ModTime are only used to validate --cache-dir entries
+type remoteFile struct {
+	URL     string
+	Size    int64
+	ModTime time.Time
+}
+
+// remoteFiles are the remote logs found while expanding paths, by URL
+var remoteFiles = map[string]remoteFile{}
+
+func isRemote(path string) bool {
+	return strings.HasPrefix(path, "s3://") || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// expandRemotes resolves remote paths
+// S3 paths can use glob patterns on file names, eg: s3://bucket/prefix/*.log, they are listed using the aws command line
+// like grep, it is widely available and it avoids depending on a whole SDK
+func expandRemotes(paths []string) ([]string, error) {
+	expanded := []string{}
+	for _, p := range paths {
+		var (
+			files []remoteFile
+			err   error
+		)
+		switch {
+		case strings.HasPrefix(p, "s3://"):
+			files, err = listS3(p)
+		case isRemote(p):
+			var file remoteFile
+			file, err = headHTTP(p)
+			files = []remoteFile{file}
+		default:
+			expanded = append(expanded, p)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not access %s", p)
+		}
+		if len(files) == 0 {
+			return nil, errors.Errorf("no files matching %s", p)
+		}
+		for _, file := range files {
+			remoteFiles[file.URL] = file
+			expanded = append(expanded, file.URL)
+		}
+	}
+	return expanded, nil
+}
+
+func headHTTP(url string) (remoteFile, error) {
+	resp, err := http.Head(url)
+	if err != nil {
+		return remoteFile{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return remoteFile{}, errors.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	file := remoteFile{URL: url, Size: resp.ContentLength}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		file.ModTime = lastModified
+	}
+	return file, nil
+}
+
+// listS3 lists the objects matching the pattern, only the file name can be a glob pattern
+func listS3(url string) ([]remoteFile, error) {
+	dir, pattern := path.Split(url)
+
+	cmd := exec.Command(CLI.AwsCmd, "s3", "ls", dir)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list objects: %s", stderr.String())
+	}
+
+	// 2024-05-01 10:00:00      12345 error.log
+	//                            PRE subdir/
+	files := []remoteFile{}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 4 || fields[0] == "PRE" {
+			continue
+		}
+		name := strings.Join(fields[3:], " ")
+		if ok, _ := path.Match(pattern, name); !ok {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		modTime, _ := time.ParseInLocation("2006-01-02 15:04:05", fields[0]+" "+fields[1], time.Local)
+		files = append(files, remoteFile{URL: dir + name, Size: size, ModTime: modTime})
+	}
+	return files, nil
+}
+
+// openRemote streams the content of a remote log
+func openRemote(url string) (io.ReadCloser, error) {
+	if strings.HasPrefix(url, "s3://") {
+		cmd := exec.Command(CLI.AwsCmd, "s3", "cp", url, "-")
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not open stdout pipe")
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute %s", CLI.AwsCmd)
+		}
+		return &cmdReadCloser{ReadCloser: out, cmd: cmd}, nil
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// cmdReadCloser waits for the command to end once its output is closed
+type cmdReadCloser struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (c *cmdReadCloser) Close() error {
+	c.ReadCloser.Close()
+	return c.cmd.Wait()
+}
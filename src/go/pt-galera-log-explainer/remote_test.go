@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandRemotesHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/node1.log" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Last-Modified", "Wed, 01 May 2024 10:00:00 GMT")
+		io.WriteString(w, "line1\nline2\n")
+	}))
+	defer server.Close()
+
+	paths, err := expandRemotes([]string{"local.log", server.URL + "/node1.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"local.log", server.URL + "/node1.log"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+	if file := remoteFiles[server.URL+"/node1.log"]; file.Size != 12 || file.ModTime.IsZero() {
+		t.Errorf("unexpected remote file metadata: %+v", file)
+	}
+
+	r, err := openRemote(server.URL + "/node1.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(content) != "line1\nline2\n" {
+		t.Errorf("unexpected content %q, err: %v", content, err)
+	}
+
+	if _, err := expandRemotes([]string{server.URL + "/missing.log"}); err == nil {
+		t.Errorf("expected an error for a missing remote file")
+	}
+}
+
+func TestExpandRemotesS3(t *testing.T) {
+	// fake aws command line, listing a bucket and printing a file
+	aws := filepath.Join(t.TempDir(), "aws")
+	err := os.WriteFile(aws, []byte(`#!/bin/sh
+if [ "$2" = "ls" ]; then
+	echo "                           PRE archives/"
+	echo "2024-05-01 10:00:00       1234 node1.log"
+	echo "2024-05-01 10:00:00       5678 node2.log"
+	echo "2024-05-01 10:00:00         42 my.cnf"
+	exit 0
+fi
+echo "content of $3"
+`), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	CLI.AwsCmd = aws
+
+	paths, err := expandRemotes([]string{"s3://bucket/logs/*.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"s3://bucket/logs/node1.log", "s3://bucket/logs/node2.log"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+	if file := remoteFiles["s3://bucket/logs/node2.log"]; file.Size != 5678 {
+		t.Errorf("unexpected remote file metadata: %+v", file)
+	}
+
+	r, err := openRemote("s3://bucket/logs/node1.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "content of s3://bucket/logs/node1.log\n" {
+		t.Errorf("unexpected content %q", content)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type replay struct {
+	Paths   []string      `arg:"" name:"paths" help:"paths of the log to use"`
+	Speed   float64       `help:"Replay at this speed compared to real time, eg: 60 replays one minute of logs per second. By default, enter has to be pressed to go to the next event"`
+	MaxWait time.Duration `default:"2s" help:"Longest wait between two events when using --speed, so that quiet periods do not stall the replay"`
+}
+
+func (r *replay) Help() string {
+	return fmt.Sprintf(`Replay the merged timeline event by event, like a movie of the incident
+	The state and member count seen by each node are printed each time they change
+
+Usage:
+	%[1]s replay *.log
+	%[1]s replay --speed 60 *.log
+	%[1]s --since 2023-03-12T19:30:00Z replay --speed 10 --max-wait 5s *.log
+	`, toolname)
+}
+
+func (r *replay) Run() error {
+	if r.Speed < 0 || r.MaxWait < 0 {
+		return errors.New("--speed and --max-wait cannot be negative")
+	}
+
+	timeline, err := timelineFromPaths(r.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not replay timeline")
+	}
+
+	return display.Replay(os.Stdout, os.Stdin, timeline, CLI.Verbosity, display.ReplayOptions{Speed: r.Speed, MaxWait: r.MaxWait})
+}
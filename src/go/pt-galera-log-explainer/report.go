@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type report struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+}
+
+func (r *report) Help() string {
+	return fmt.Sprintf(`Generate a Markdown incident report, ready to paste into a ticket or postmortem
+	It contains the cluster composition, view changes, SST/IST, detected failures, problematic transactions and the timeline
+
+Usage:
+	%[1]s report *.log > report.md
+	%[1]s --since 2023-03-12T19:41:28Z report *.log
+	`, toolname)
+}
+
+func (r *report) Run() error {
+
+	timeline, err := timelineFromPaths(r.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not generate report")
+	}
+
+	return display.TimelineMarkdown(os.Stdout, timeline, CLI.Verbosity, CLI.Explain)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// error.log.1, error.log.2, ...
+var rotatedFileRegex = regexp.MustCompile(`^(?P<base>.+)\.(?P<number>[0-9]{1,3})$`)
+
+// missingRotatedFiles lists the files that seem to be missing when looking at rotated file numbers
+// eg: error.log.2 when error.log.1 and error.log.3 were given
+// The current file, without number, counts as 0 when given
+func missingRotatedFiles(paths []string) []string {
+	numbersPerBase := map[string][]int{}
+	for _, path := range paths {
+		path = strings.TrimSuffix(filepath.Clean(path), ".gz")
+		submatches := rotatedFileRegex.FindStringSubmatch(path)
+		if submatches == nil {
+			numbersPerBase[path] = append(numbersPerBase[path], 0)
+			continue
+		}
+		base := submatches[rotatedFileRegex.SubexpIndex("base")]
+		number, err := strconv.Atoi(submatches[rotatedFileRegex.SubexpIndex("number")])
+		if err != nil {
+			continue
+		}
+		numbersPerBase[base] = append(numbersPerBase[base], number)
+	}
+
+	missing := []string{}
+	for base, numbers := range numbersPerBase {
+		sort.Ints(numbers)
+		for i := 1; i < len(numbers); i++ {
+			for n := numbers[i-1] + 1; n < numbers[i]; n++ {
+				missing = append(missing, base+"."+strconv.Itoa(n))
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// rotatedSuffixRegex matches what logrotate appends to a file name:
+// error.log.1, error.log.2.gz, error.log-20240501, error.log-20240501-1714557600.gz
+var rotatedSuffixRegex = regexp.MustCompile(`^(?:\.(?P<number>[0-9]{1,3})|-(?P<date>[0-9]{8}(?:-?[0-9]+)?))(?:\.gz)?$`)
+
+// discoverRotatedFiles adds the rotations of the given logs, found in the same directories
+// Paths are sorted oldest first for each log: dated rotations, numbered rotations, then the log itself.
+// Paths already given are not added twice.
+// It also returns the log each rotation belongs to, the log itself included, when rotations were found
+func discoverRotatedFiles(paths []string) ([]string, map[string]string) {
+	rotationOf := map[string]string{}
+	given := map[string]bool{}
+	for _, path := range paths {
+		given[filepath.Clean(path)] = true
+	}
+
+	out := []string{}
+	added := map[string]bool{}
+	add := func(path string) {
+		if !added[filepath.Clean(path)] {
+			added[filepath.Clean(path)] = true
+			out = append(out, path)
+		}
+	}
+
+	for _, path := range paths {
+		if isRemote(path) || isArchive(path) || rotatedFileRegex.MatchString(strings.TrimSuffix(path, ".gz")) {
+			add(path)
+			continue
+		}
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			add(path)
+			continue
+		}
+
+		type rotation struct {
+			path   string
+			number int
+			date   string
+		}
+		rotations := []rotation{}
+		base := filepath.Base(path)
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), base) {
+				continue
+			}
+			submatches := rotatedSuffixRegex.FindStringSubmatch(strings.TrimPrefix(entry.Name(), base))
+			if submatches == nil {
+				continue
+			}
+			r := rotation{path: filepath.Join(filepath.Dir(path), entry.Name()), date: submatches[rotatedSuffixRegex.SubexpIndex("date")]}
+			r.number, _ = strconv.Atoi(submatches[rotatedSuffixRegex.SubexpIndex("number")])
+			if !given[filepath.Clean(r.path)] {
+				logger.Debug().Str("path", r.path).Str("from", path).Msg("discovered rotated log")
+			}
+			rotations = append(rotations, r)
+		}
+
+		sort.Slice(rotations, func(i, j int) bool {
+			ri, rj := rotations[i], rotations[j]
+			if (ri.date != "") != (rj.date != "") {
+				return ri.date != ""
+			}
+			if ri.date != rj.date {
+				return ri.date < rj.date
+			}
+			return ri.number > rj.number
+		})
+		for _, r := range rotations {
+			add(r.path)
+			rotationOf[r.path] = path
+		}
+		add(path)
+		if len(rotations) > 0 {
+			rotationOf[path] = path
+		}
+	}
+	return out, rotationOf
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMissingRotatedFiles(t *testing.T) {
+	tests := []struct {
+		paths    []string
+		expected []string
+	}{
+		{
+			paths:    []string{"error.log", "error.log.1", "error.log.2"},
+			expected: []string{},
+		},
+		{
+			paths:    []string{"error.log.1", "error.log.3"},
+			expected: []string{"error.log.2"},
+		},
+		{
+			paths:    []string{"error.log", "error.log.2", "error.log.5"},
+			expected: []string{"error.log.1", "error.log.3", "error.log.4"},
+		},
+		{
+			paths:    []string{"node1/error.log", "node2/error.log.2"},
+			expected: []string{},
+		},
+		{
+			paths:    []string{"node1.20230315.log", "node1.20230317.log"},
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		out := missingRotatedFiles(test.paths)
+		if !cmp.Equal(out, test.expected) {
+			t.Errorf("with paths %v: %s", test.paths, cmp.Diff(test.expected, out))
+		}
+	}
+}
+
+func TestDiscoverRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"error.log", "error.log.1", "error.log.2.gz", "error.log-20240501", "error.log-20240430.gz", "error.log.bak", "error.log2", "other.log", "other.log.1"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in := func(names ...string) []string {
+		paths := []string{}
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name     string
+		paths    []string
+		expected []string
+	}{
+		{
+			name:     "oldest first",
+			paths:    in("error.log"),
+			expected: in("error.log-20240430.gz", "error.log-20240501", "error.log.2.gz", "error.log.1", "error.log"),
+		},
+		{
+			name:     "no duplicates when rotations are given too",
+			paths:    in("error.log.1", "error.log", "other.log"),
+			expected: in("error.log.1", "error.log-20240430.gz", "error.log-20240501", "error.log.2.gz", "error.log", "other.log.1", "other.log"),
+		},
+		{
+			name:     "rotated logs are not used to discover others",
+			paths:    in("other.log.1"),
+			expected: in("other.log.1"),
+		},
+		{
+			name:     "nothing to discover",
+			paths:    in("error.log2"),
+			expected: in("error.log2"),
+		},
+	}
+
+	for _, test := range tests {
+		out, rotationOf := discoverRotatedFiles(test.paths)
+		if !cmp.Equal(out, test.expected) {
+			t.Errorf("%s: %s", test.name, cmp.Diff(test.expected, out))
+		}
+		for _, path := range out {
+			if base, ok := rotationOf[path]; ok && !strings.HasPrefix(filepath.Base(path), filepath.Base(base)) {
+				t.Errorf("%s: %s is not a rotation of %s", test.name, path, base)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/pkg/errors"
+)
+
+type search struct {
+	Pattern string   `arg:"" name:"pattern" help:"regex to search, using the syntax of both grep -P and Go, eg: '(?i)innodb: .*corrupt'"`
+	Paths   []string `arg:"" name:"paths" help:"paths of the log to use"`
+}
+
+func (s *search) Help() string {
+	return fmt.Sprintf(`Search a regex in every log, printing matching lines in chronological order along with the node they come from
+	Nodes are identified the same way as other subcommands do, so that logs from several nodes can be searched at once
+
+Usage:
+	%[1]s search 'Too many connections' *.log
+	%[1]s search --since 2023-03-12T19:00:00Z '(?i)deadlock' *.log
+	`, toolname)
+}
+
+// searchRegexKey is the key of the pattern among regexes, it is never displayed
+const searchRegexKey = "RegexUserSearch"
+
+func (s *search) Run() error {
+	pattern, err := regexp.Compile(s.Pattern)
+	if err != nil {
+		return errors.Wrap(err, "invalid search regex")
+	}
+
+	// cached lines only hold what known regexes found
+	CLI.CacheDir = ""
+
+	regexes := types.RegexMap{}
+	regexes.Merge(regex.AllRegexes())
+	regexes[searchRegexKey] = &types.LogRegex{
+		Regex:     pattern,
+		Type:      types.SearchRegexType,
+		Verbosity: types.Info,
+		Handler: func(_ map[string]string, logCtx types.LogCtx, line string, _ time.Time) (types.LogCtx, types.LogDisplayer) {
+			return logCtx, types.SimpleDisplayer(line)
+		},
+	}
+
+	timeline, err := timelineFromPaths(s.Paths, regexes)
+	if err != nil {
+		return errors.Wrap(err, "could not search logs")
+	}
+
+	return display.SearchCLI(os.Stdout, timeline, pattern)
+}
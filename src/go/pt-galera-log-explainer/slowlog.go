@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+// slowQueryMaxLength is the maximum length of queries displayed, the timeline is not meant to read them in full
+const slowQueryMaxLength = 80
+
+// slowLogTimeLayouts are the "# Time:" formats of mysql 5.7+ and of older versions
+var slowLogTimeLayouts = []string{time.RFC3339Nano, "060102 15:04:05"}
+
+// slowQuery is a slow query log entry
+type slowQuery struct {
+	date      time.Time
+	queryTime time.Duration
+	query     []string
+}
+
+// interleaveSlowLogs adds the queries longer than threshold found in --slow-log files to the timeline of their node
+func interleaveSlowLogs(timeline types.Timeline, slowLogs []string, threshold time.Duration) error {
+	for _, slowLog := range slowLogs {
+		node, path, found := strings.Cut(slowLog, ":")
+		if !found || node == "" || path == "" {
+			return errors.Errorf("invalid --slow-log %s, expected format: <node>:<path>", slowLog)
+		}
+		if _, ok := timeline[node]; !ok {
+			return errors.Errorf("unknown node %s for slow query log %s, expected one of: %s", node, path, strings.Join(sortedNodes(timeline), ", "))
+		}
+
+		lt, err := slowQueriesFromFile(path, threshold)
+		if err != nil {
+			return errors.Wrapf(err, "could not read slow query log %s", path)
+		}
+		timeline[node] = timeline[node].Interleave(lt)
+	}
+	return nil
+}
+
+func sortedNodes(timeline types.Timeline) []string {
+	nodes := make([]string, 0, len(timeline))
+	for node := range timeline {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func slowQueriesFromFile(path string, threshold time.Duration) (types.LocalTimeline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	queries, err := parseSlowLog(f)
+	if err != nil {
+		return nil, err
+	}
+
+	regex := &types.LogRegex{
+		Type:      types.SlowQueryRegexType,
+		Verbosity: types.Info,
+		Severity:  types.SeverityWarning,
+	}
+	lt := types.LocalTimeline{}
+	for _, q := range queries {
+		if q.queryTime < threshold || q.date.IsZero() {
+			continue
+		}
+		if (CLI.Since != nil && CLI.Since.After(q.date)) || (CLI.Until != nil && CLI.Until.Before(q.date)) {
+			continue
+		}
+		msg := "slow query " + q.queryTime.Round(time.Millisecond).String() + ": " + utils.Truncate(strings.Join(q.query, " "), slowQueryMaxLength)
+		lt = append(lt, types.NewLogInfo(types.NewDate(q.date, "2006-01-02T15:04:05.000000Z"), types.SimpleDisplayer(msg), msg, regex, "SlowQuery", types.NewLogCtx(), ""))
+	}
+
+	sort.SliceStable(lt, func(i, j int) bool { return lt[i].Date.Time.Before(lt[j].Date.Time) })
+	return lt, nil
+}
+
+// parseSlowLog reads slow query log entries, eg:
+//
+//	# Time: 2023-03-12T07:35:00.123456Z
+//	# User@Host: root[root] @ localhost []  Id:    12
+//	# Query_time: 12.345678  Lock_time: 0.000100 Rows_sent: 1  Rows_examined: 1000000
+//	SET timestamp=1678606500;
+//	select count(*) from t1;
+//
+// Entries without "# Time:", as logged by older versions when several queries end in the same second,
+// use the SET timestamp statement instead
+func parseSlowLog(r io.Reader) ([]slowQuery, error) {
+	queries := []slowQuery{}
+	var current *slowQuery
+	closeQuery := func() {
+		if current != nil && len(current.query) > 0 {
+			queries = append(queries, *current)
+		}
+		current = nil
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case strings.HasPrefix(line, "# Time:"):
+			closeQuery()
+			current = &slowQuery{}
+			value := strings.Join(strings.Fields(strings.TrimPrefix(line, "# Time:")), " ")
+			for _, layout := range slowLogTimeLayouts {
+				if t, err := time.Parse(layout, value); err == nil {
+					current.date = t.UTC()
+					break
+				}
+			}
+
+		case strings.HasPrefix(line, "# User@Host:"):
+			if current == nil || len(current.query) > 0 {
+				closeQuery()
+				current = &slowQuery{}
+			}
+
+		case strings.HasPrefix(line, "# Query_time:"):
+			if current == nil {
+				current = &slowQuery{}
+			}
+			fields := strings.Fields(strings.TrimPrefix(line, "# Query_time:"))
+			if len(fields) > 0 {
+				if seconds, err := strconv.ParseFloat(fields[0], 64); err == nil {
+					current.queryTime = time.Duration(seconds * float64(time.Second))
+				}
+			}
+
+		case strings.HasPrefix(line, "SET timestamp="):
+			if current != nil && current.date.IsZero() {
+				if epoch, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(line, "SET timestamp="), ";"), 10, 64); err == nil {
+					current.date = time.Unix(epoch, 0).UTC()
+				}
+			}
+
+		// comments, "use db;" statements, and headers printed when mysqld starts
+		case strings.HasPrefix(line, "#"), strings.HasPrefix(line, "use "), line == "",
+			strings.Contains(line, ", Version: "), strings.HasPrefix(line, "Tcp port:"), strings.HasPrefix(line, "Time "):
+
+		default:
+			if current != nil {
+				current.query = append(current.query, line)
+			}
+		}
+	}
+	closeQuery()
+	return queries, s.Err()
+}
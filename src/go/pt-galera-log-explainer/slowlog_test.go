@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSlowLog(t *testing.T) {
+	log := `/usr/sbin/mysqld, Version: 8.0.28-19.1 (Percona XtraDB Cluster (GPL), Release rel19, Revision f544540, WSREP version 26.4.3). started with:
+Tcp port: 3306  Unix socket: /var/lib/mysql/mysql.sock
+Time                 Id Command    Argument
+# Time: 2023-03-12T07:36:00.123456Z
+# User@Host: root[root] @ localhost []  Id:    12
+# Query_time: 12.345678  Lock_time: 0.000100 Rows_sent: 1  Rows_examined: 1000000
+use test;
+SET timestamp=1678606548;
+select count(*)
+from t1 join t2 on t1.id = t2.id;
+# User@Host: app[app] @  [10.0.0.1]  Id:    13
+# Query_time: 2.000000  Lock_time: 0.000000 Rows_sent: 0  Rows_examined: 0
+SET timestamp=1678606550;
+update t1 set a = 1;
+# Time: 230312  7:37:00
+# User@Host: root[root] @ localhost []  Id:    12
+# Query_time: 0.500000  Lock_time: 0.000100 Rows_sent: 1  Rows_examined: 10
+SET timestamp=1678606620;
+select 1;
+`
+	queries, err := parseSlowLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []slowQuery{
+		{date: time.Date(2023, 3, 12, 7, 36, 0, 123456000, time.UTC), queryTime: 12345678 * time.Microsecond, query: []string{"select count(*)", "from t1 join t2 on t1.id = t2.id;"}},
+		{date: time.Date(2023, 3, 12, 7, 35, 50, 0, time.UTC), queryTime: 2 * time.Second, query: []string{"update t1 set a = 1;"}},
+		{date: time.Date(2023, 3, 12, 7, 37, 0, 0, time.UTC), queryTime: 500 * time.Millisecond, query: []string{"select 1;"}},
+	}
+	if diff := cmp.Diff(expected, queries, cmp.AllowUnexported(slowQuery{})); diff != "" {
+		t.Fatalf("unexpected queries (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type splitBrain struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+}
+
+func (s *splitBrain) Help() string {
+	return fmt.Sprintf(`Detect split-brains: periods where several primary components coexisted, and print recovery hints
+	Each partition is listed with its nodes, cluster state UUID and highest seqno found, to tell which one should be kept
+	and which nodes must rejoin with a full SST. Split-brains are also flagged in the timeline of other subcommands
+
+Usage:
+	%[1]s split-brain *.log
+	`, toolname)
+}
+
+func (s *splitBrain) Run() error {
+
+	timeline, err := timelineFromPaths(s.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not detect split-brains")
+	}
+
+	display.SplitBrainsCLI(os.Stdout, display.SplitBrains(timeline))
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type stats struct {
+	Paths []string `arg:"" name:"paths" help:"paths of the log to use"`
+}
+
+func (s *stats) Help() string {
+	return fmt.Sprintf(`Print a quick overview for each node before diving into the timeline
+	It counts restarts, view changes, SST requests, flow control events, certification failures and crashes,
+	along with the first and last event found and the duration covered by logs
+
+Usage:
+	%[1]s stats *.log
+	`, toolname)
+}
+
+func (s *stats) Run() error {
+
+	timeline, err := timelineFromPaths(s.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not compute stats")
+	}
+
+	return display.TimelineStats(os.Stdout, timeline)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+// systemLogEvent is an operating system event worth showing next to galera events
+type systemLogEvent struct {
+	regex    *regexp.Regexp
+	severity types.Severity
+	msg      func(submatches []string) string
+}
+
+// systemLogEvents are infrastructure failures often mistaken for galera issues
+var systemLogEvents = []systemLogEvent{
+	{
+		regex:    regexp.MustCompile(`Out of memory: Kill(?:ed)? process (\d+) \(([^)]+)\)`),
+		severity: types.SeverityError,
+		msg:      func(s []string) string { return fmt.Sprintf("OOM killer killed %s(%s)", s[2], s[1]) },
+	},
+	{
+		regex:    regexp.MustCompile(`(\S+) invoked oom-killer`),
+		severity: types.SeverityWarning,
+		msg:      func(s []string) string { return s[1] + " invoked the OOM killer" },
+	},
+	{
+		regex:    regexp.MustCompile(`I/O error,? (?:on )?dev (\w+)`),
+		severity: types.SeverityError,
+		msg:      func(s []string) string { return "I/O error on " + s[1] },
+	},
+	{
+		regex:    regexp.MustCompile(`EXT4-fs error \(device (\w+)\)|XFS \((\w+)\): .*(?:[Ee]rror|[Ss]hutdown)`),
+		severity: types.SeverityError,
+		msg:      func(s []string) string { return "filesystem error on " + s[1] + s[2] },
+	},
+	{
+		regex:    regexp.MustCompile(`(\w[\w.@-]*):? (?:NIC )?[Ll]ink is [Dd]own`),
+		severity: types.SeverityError,
+		msg:      func(s []string) string { return s[1] + " link down" },
+	},
+	{
+		regex:    regexp.MustCompile(`(\w[\w.@-]*):? (?:NIC )?[Ll]ink is [Uu]p`),
+		severity: types.SeverityInfo,
+		msg:      func(s []string) string { return s[1] + " link up" },
+	},
+}
+
+// dmesg -T date: [Sun Mar 12 07:35:00 2023]
+var dmesgDate = regexp.MustCompile(`^\[([A-Z][a-z]{2} [A-Z][a-z]{2} [ 0-9]?[0-9] [0-9]{2}:[0-9]{2}:[0-9]{2} [0-9]{4})\]`)
+
+const dmesgLayout = "Mon Jan _2 15:04:05 2006"
+
+// syslog hostname: Mar 12 07:35:00 hostname kernel: ...
+var syslogHostname = regexp.MustCompile(`^[A-Z][a-z]{2} [ 0-9][0-9] [0-9]{2}:[0-9]{2}:[0-9]{2} (\S+) `)
+
+// systemLogLine is an event found in a system log, along with the host that logged it when known
+type systemLogLine struct {
+	date     time.Time
+	noYear   bool
+	hostname string
+	event    systemLogEvent
+	log      string
+}
+
+// interleaveSystemLogs adds events found in --system-log files to the timeline of their node
+// Files can be given as <node>:<path>, else each line is attributed using the hostname logged by syslog
+// Dates without time zone are read in the timezone location, as system logs use the local time of their host
+func interleaveSystemLogs(timeline types.Timeline, systemLogs []string, timezone string) error {
+	if len(systemLogs) == 0 {
+		return nil
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return errors.Wrapf(err, "invalid --system-log-tz %s", timezone)
+	}
+	latestContexts := timeline.GetLatestContextsByNodes()
+
+	for _, systemLog := range systemLogs {
+		node, path := systemLogNode(timeline, systemLog)
+
+		f, err := os.Open(path)
+		if err != nil {
+			return errors.Wrapf(err, "could not read system log %s", path)
+		}
+		lines, undated, err := parseSystemLog(f, location)
+		f.Close()
+		if err != nil {
+			return errors.Wrapf(err, "could not read system log %s", path)
+		}
+		if undated > 0 {
+			logger.Warn().Str("path", path).Int("lines", undated).Msg("ignored events without dates, use 'dmesg -T' to get them")
+		}
+
+		events := map[string]types.LocalTimeline{}
+		unattributed := 0
+		for _, line := range lines {
+			target := node
+			if target == "" {
+				target = nodeOfHostname(line.hostname, sortedNodes(timeline), latestContexts)
+			}
+			if target == "" {
+				unattributed++
+				continue
+			}
+			li, ok := systemLogLineInfo(line, timeline[target])
+			if ok {
+				events[target] = append(events[target], li)
+			}
+		}
+		if unattributed > 0 {
+			logger.Warn().Str("path", path).Int("events", unattributed).Msg("could not attribute events to a node from hostnames, use --system-log <node>:<path>")
+		}
+
+		for target, lt := range events {
+			sort.SliceStable(lt, func(i, j int) bool { return lt[i].Date.Time.Before(lt[j].Date.Time) })
+			timeline[target] = timeline[target].Interleave(lt)
+		}
+	}
+	return nil
+}
+
+// systemLogNode splits a --system-log value into its node and path
+// Paths can have colons too, so the value is only split when it starts with a known node
+func systemLogNode(timeline types.Timeline, systemLog string) (string, string) {
+	node, path, found := strings.Cut(systemLog, ":")
+	if _, ok := timeline[node]; found && ok && path != "" {
+		return node, path
+	}
+	return "", systemLog
+}
+
+// nodeOfHostname finds the column of a host, using column names and node names found in logs
+func nodeOfHostname(hostname string, nodes []string, latestContexts map[string]types.LogCtx) string {
+	if hostname == "" {
+		return ""
+	}
+	short, _, _ := strings.Cut(hostname, ".")
+	for _, node := range nodes {
+		if node == hostname || node == short || utils.SliceContains(latestContexts[node].OwnNames, hostname) || utils.SliceContains(latestContexts[node].OwnNames, short) {
+			return node
+		}
+	}
+	return ""
+}
+
+// systemLogLineInfo builds the timeline event, dates without year get the one of the node events
+func systemLogLineInfo(line systemLogLine, lt types.LocalTimeline) (types.LogInfo, bool) {
+	date := line.date
+	if line.noYear {
+		var first *types.Date
+		for _, li := range lt {
+			if li.Date != nil {
+				first = li.Date
+				break
+			}
+		}
+		if first == nil {
+			return types.LogInfo{}, false
+		}
+		date = date.AddDate(first.Time.Year()-date.Year(), 0, 0)
+		// logs spanning new year
+		if date.Before(first.Time.AddDate(0, -6, 0)) {
+			date = date.AddDate(1, 0, 0)
+		}
+	}
+	if (CLI.Since != nil && CLI.Since.After(date)) || (CLI.Until != nil && CLI.Until.Before(date)) {
+		return types.LogInfo{}, false
+	}
+
+	regex := &types.LogRegex{
+		Type:      types.SystemRegexType,
+		Verbosity: types.Info,
+		Severity:  line.event.severity,
+	}
+	msg := line.event.msg(line.event.regex.FindStringSubmatch(line.log))
+	return types.NewLogInfo(types.NewDate(date, "2006-01-02T15:04:05.000000Z"), types.SimpleDisplayer(msg), line.log, regex, "SystemLog", types.NewLogCtx(), ""), true
+}
+
+// parseSystemLog reads dmesg -T outputs and syslog files, such as /var/log/messages, keeping only known events
+// It returns how many known events could not be dated, as plain dmesg only gives the uptime
+// Dates without time zone are read in location, and every date is converted to UTC like the ones of mysql logs
+func parseSystemLog(r io.Reader, location *time.Location) ([]systemLogLine, int, error) {
+	lines := []systemLogLine{}
+	undated := 0
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		log := s.Text()
+		var event *systemLogEvent
+		for i := range systemLogEvents {
+			if systemLogEvents[i].regex.MatchString(log) {
+				event = &systemLogEvents[i]
+				break
+			}
+		}
+		if event == nil {
+			continue
+		}
+
+		line := systemLogLine{event: *event, log: log}
+		if submatches := dmesgDate.FindStringSubmatch(log); submatches != nil {
+			t, err := time.ParseInLocation(dmesgLayout, strings.Join(strings.Fields(submatches[1]), " "), location)
+			if err != nil {
+				undated++
+				continue
+			}
+			line.date = t.UTC()
+		} else {
+			t, layout, ok := regex.SearchDateFromLog(log)
+			if !ok {
+				undated++
+				continue
+			}
+			// parsed as UTC when the layout has no time zone
+			if !strings.Contains(layout, "Z") && !strings.Contains(layout, "-07") {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), location)
+			}
+			line.date = t.UTC()
+			line.noYear = !strings.Contains(layout, "06")
+			if submatches := syslogHostname.FindStringSubmatch(log); submatches != nil {
+				line.hostname = submatches[1]
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, undated, s.Err()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+func TestSystemLogEvents(t *testing.T) {
+	utils.SkipColor = true
+	defer func() { utils.SkipColor = false }()
+
+	log := `Mar 12 07:35:30 node2 kernel: mysqld invoked oom-killer: gfp_mask=0x6200ca(GFP_HIGHUSER_MOVABLE), order=0, oom_score_adj=0
+Mar 12 07:35:31 node2 kernel: Out of memory: Killed process 1234 (mysqld) total-vm:12345678kB, anon-rss:1234567kB
+Mar 12 07:35:32 node2 systemd[1]: Started Session 12 of user root.
+Mar 12 07:35:40 node1.example.com kernel: e1000e: eth0 NIC Link is Down
+Mar 12 07:35:45 node1.example.com kernel: e1000e: eth0 NIC Link is Up 1000 Mbps Full Duplex, Flow Control: None
+Mar 12 07:36:00 web1 kernel: blk_update_request: I/O error, dev sda, sector 123456
+[Sun Mar 12 07:37:00 2023] XFS (sdb1): log I/O error -5
+[  123.456789] EXT4-fs error (device sda1): ext4_find_entry:1455: inode #2: comm mysqld: reading directory lblock 0
+`
+	lines, undated, err := parseSystemLog(strings.NewReader(log), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 6 || undated != 1 {
+		t.Fatalf("expected 6 events and 1 undated, got %d and %d", len(lines), undated)
+	}
+
+	date := time.Date(2022, time.March, 12, 7, 0, 0, 0, time.UTC)
+	layout := "2006-01-02T15:04:05.000000Z"
+	event := func(node string) types.LogInfo {
+		logCtx := types.NewLogCtx()
+		logCtx.OwnNames = []string{node}
+		return types.NewLogInfo(types.NewDate(date, layout), types.SimpleDisplayer("started"), "", &types.LogRegex{}, "RegexStarted", logCtx, "error.log")
+	}
+	timeline := types.Timeline{
+		"node1":      types.LocalTimeline{event("node1")},
+		"172.17.0.3": types.LocalTimeline{event("node2")},
+	}
+	latestContexts := timeline.GetLatestContextsByNodes()
+	nodes := sortedNodes(timeline)
+
+	expected := []struct {
+		node, date, msg string
+	}{
+		{"172.17.0.3", "2022-03-12T07:35:30.000000Z", "mysqld invoked the OOM killer"},
+		{"172.17.0.3", "2022-03-12T07:35:31.000000Z", "OOM killer killed mysqld(1234)"},
+		{"node1", "2022-03-12T07:35:40.000000Z", "eth0 link down"},
+		{"node1", "2022-03-12T07:35:45.000000Z", "eth0 link up"},
+		{"", "", ""},
+		{"", "2023-03-12T07:37:00.000000Z", "filesystem error on sdb1"},
+	}
+	for i, line := range lines {
+		node := nodeOfHostname(line.hostname, nodes, latestContexts)
+		if node != expected[i].node {
+			t.Errorf("line %d: expected node %q, got %q", i, expected[i].node, node)
+		}
+		if node == "" {
+			node = "node1"
+		}
+		if expected[i].date == "" {
+			continue
+		}
+		li, ok := systemLogLineInfo(line, timeline[node])
+		if !ok {
+			t.Fatalf("line %d: could not build event", i)
+		}
+		if diff := cmp.Diff([]string{expected[i].date, expected[i].msg}, []string{li.Date.DisplayTime, li.Msg(li.LogCtx)}); diff != "" {
+			t.Errorf("line %d: unexpected event (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestSystemLogLocation(t *testing.T) {
+	log := `Mar 12 08:35:40 node1 kernel: e1000e: eth0 NIC Link is Down
+[Sun Mar 12 08:37:00 2023] XFS (sdb1): log I/O error -5
+2023-03-12T08:38:00.000000+02:00 node1 kernel: e1000e: eth0 NIC Link is Up 1000 Mbps Full Duplex
+`
+	location := time.FixedZone("CET", 3600)
+	lines, _, err := parseSystemLog(strings.NewReader(log), location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Time{
+		time.Date(lines[0].date.Year(), time.March, 12, 7, 35, 40, 0, time.UTC),
+		time.Date(2023, time.March, 12, 7, 37, 0, 0, time.UTC),
+		// dates with a time zone keep it
+		time.Date(2023, time.March, 12, 6, 38, 0, 0, time.UTC),
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(lines))
+	}
+	for i, line := range lines {
+		if !line.date.Equal(expected[i]) {
+			t.Errorf("line %d: expected %s, got %s", i, expected[i], line.date)
+		}
+	}
+}
+
+func TestSystemLogNode(t *testing.T) {
+	timeline := types.Timeline{"node1": types.LocalTimeline{}}
+
+	tests := []struct {
+		value, node, path string
+	}{
+		{"node1:/var/log/messages", "node1", "/var/log/messages"},
+		{"/var/log/messages", "", "/var/log/messages"},
+		{"logs/node2:messages", "", "logs/node2:messages"},
+		{"C:/logs/messages", "", "C:/logs/messages"},
+		{"node1:logs/node1:messages", "node1", "logs/node1:messages"},
+	}
+	for _, test := range tests {
+		node, path := systemLogNode(timeline, test.value)
+		if node != test.node || path != test.path {
+			t.Errorf("%s: expected %q and %q, got %q and %q", test.value, test.node, test.path, node, path)
+		}
+	}
+}
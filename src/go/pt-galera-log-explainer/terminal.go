@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// colorEnabled resolves --color for an output
+// "auto" follows the NO_COLOR (https://no-color.org) and CLICOLOR/CLICOLOR_FORCE conventions,
+// then only colors terminals: escape codes make piped outputs unreadable
+func colorEnabled(mode string, getenv func(string) string, isTerminal bool) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if getenv("CLICOLOR") == "0" {
+		return false
+	}
+	return isTerminal
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// terminalWidth returns the width of the terminal stdout is printed to, 0 when it is not a terminal
+func terminalWidth() int {
+	if !isTerminal(os.Stdout) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
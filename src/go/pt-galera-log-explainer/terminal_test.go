@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		env        map[string]string
+		isTerminal bool
+		expected   bool
+	}{
+		{name: "terminal", mode: "auto", isTerminal: true, expected: true},
+		{name: "piped", mode: "auto", expected: false},
+		{name: "NO_COLOR", mode: "auto", env: map[string]string{"NO_COLOR": "1"}, isTerminal: true, expected: false},
+		{name: "CLICOLOR disabled", mode: "auto", env: map[string]string{"CLICOLOR": "0"}, isTerminal: true, expected: false},
+		{name: "CLICOLOR_FORCE when piped", mode: "auto", env: map[string]string{"CLICOLOR_FORCE": "1"}, expected: true},
+		{name: "NO_COLOR over CLICOLOR_FORCE", mode: "auto", env: map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, expected: false},
+		{name: "always over NO_COLOR", mode: "always", env: map[string]string{"NO_COLOR": "1"}, expected: true},
+		{name: "never on terminals", mode: "never", isTerminal: true, expected: false},
+	}
+
+	for _, test := range tests {
+		getenv := func(key string) string { return test.env[key] }
+		if enabled := colorEnabled(test.mode, getenv, test.isTerminal); enabled != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.name, test.expected, enabled)
+		}
+	}
+}
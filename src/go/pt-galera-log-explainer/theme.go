@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+// loadTheme reads a "severity => color name" JSON object and replaces the colors used for these severities
+// "none" removes the color of a severity, so that events keep the colors chosen by their regex
+func loadTheme(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "could not read theme")
+	}
+	theme := map[string]string{}
+	if err := json.Unmarshal(content, &theme); err != nil {
+		return errors.Wrap(err, "could not parse theme")
+	}
+
+	for name, colorName := range theme {
+		severity, err := types.ParseSeverity(name)
+		if err != nil {
+			return errors.Wrap(err, "invalid theme")
+		}
+		if colorName == "none" {
+			delete(types.SeverityColors, severity)
+			continue
+		}
+		color, ok := utils.ColorsByName[colorName]
+		if !ok {
+			return errors.Errorf("invalid theme: unknown color %q for %s", colorName, name)
+		}
+		types.SeverityColors[severity] = color
+	}
+	return nil
+}
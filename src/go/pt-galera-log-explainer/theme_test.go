@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+)
+
+func TestLoadTheme(t *testing.T) {
+	defaults := types.SeverityColors
+	defer func() { types.SeverityColors = defaults }()
+
+	tests := []struct {
+		name        string
+		theme       string
+		expected    map[types.Severity]utils.Color
+		expectedErr bool
+	}{
+		{
+			name:  "override and remove colors",
+			theme: `{"warning": "magenta", "fatal": "none"}`,
+			expected: map[types.Severity]utils.Color{
+				types.SeverityWarning: utils.MagentaText,
+				types.SeverityError:   utils.RedText,
+			},
+		},
+		{
+			name:  "color info events",
+			theme: `{"info": "cyan"}`,
+			expected: map[types.Severity]utils.Color{
+				types.SeverityInfo:    utils.CyanText,
+				types.SeverityWarning: utils.YellowText,
+				types.SeverityError:   utils.RedText,
+				types.SeverityFatal:   utils.BrightRedText,
+			},
+		},
+		{
+			name:        "unknown severity",
+			theme:       `{"critical": "red"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "unknown color",
+			theme:       `{"error": "orange"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "not json",
+			theme:       `error: red`,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		types.SeverityColors = map[types.Severity]utils.Color{}
+		for severity, color := range defaults {
+			types.SeverityColors[severity] = color
+		}
+
+		path := filepath.Join(t.TempDir(), "theme.json")
+		if err := os.WriteFile(path, []byte(test.theme), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		err := loadTheme(path)
+		if (err != nil) != test.expectedErr {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if test.expectedErr {
+			continue
+		}
+		if diff := cmp.Diff(test.expected, types.SeverityColors); diff != "" {
+			t.Errorf("%s: unexpected colors (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/display"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/pkg/errors"
+)
+
+type transfers struct {
+	Paths   []string `arg:"" name:"paths" help:"paths of the log to use"`
+	Mermaid bool     `help:"Print a mermaid sequence diagram instead, to be pasted in tickets or markdown documents"`
+}
+
+func (t *transfers) Help() string {
+	return fmt.Sprintf(`Draw which node acted as donor for which joiner, for each SST/IST
+	Transfers are taken from the donor selection up to their completion or failure, and merged when seen from several nodes
+
+Usage:
+	%[1]s transfers *.log
+	%[1]s transfers --mermaid *.log
+	`, toolname)
+}
+
+func (t *transfers) Run() error {
+
+	timeline, err := timelineFromPaths(t.Paths, regex.AllRegexes())
+	if err != nil {
+		return errors.Wrap(err, "could not list state transfers")
+	}
+
+	if t.Mermaid {
+		return display.TransfersMermaid(os.Stdout, display.StateTransfers(timeline))
+	}
+	return display.TransfersCLI(os.Stdout, display.StateTransfers(timeline))
+}
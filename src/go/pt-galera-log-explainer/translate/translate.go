@@ -104,6 +104,22 @@ func GetIPFromHash(hash string) string {
 	return db.HashToIP[hash].Value
 }
 
+// NodeNames returns every node names known, sorted
+func NodeNames() []string {
+	db.rwlock.RLock()
+	defer db.rwlock.RUnlock()
+	names := []string{}
+	for _, m := range []map[string][]translationUnit{db.HashToNodeNames, db.IPToNodeNames} {
+		for _, units := range m {
+			for _, unit := range units {
+				names = utils.SliceMergeDeduplicate(names, []string{unit.Value})
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func mostAppropriateValueFromTS(units []translationUnit, ts time.Time) string {
 
 	if len(units) == 0 {
@@ -203,3 +219,77 @@ func SimplestInfoFromHash(hash string, date time.Time) string {
 	}
 	return hash
 }
+
+// Hashes returns every node hashes known, sorted
+func Hashes() []string {
+	db.rwlock.RLock()
+	defer db.rwlock.RUnlock()
+	hashes := []string{}
+	for hash := range db.HashToIP {
+		hashes = append(hashes, hash)
+	}
+	for hash := range db.HashToNodeNames {
+		hashes = utils.SliceMergeDeduplicate(hashes, []string{hash})
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// HashesFromIP returns every hashes an IP had, from the oldest
+func HashesFromIP(ip string) []string {
+	hashes := []string{}
+	for _, unit := range db.getHashSliceFromIP(ip) {
+		hashes = append(hashes, unit.Value)
+	}
+	return hashes
+}
+
+// NodeNamesFromHash returns every names a hash had, whatever the time
+func NodeNamesFromHash(hash string) []string {
+	db.rwlock.RLock()
+	defer db.rwlock.RUnlock()
+	return unitValues(db.HashToNodeNames[hash])
+}
+
+// NodeNamesFromIP returns every names an IP had, whatever the time
+func NodeNamesFromIP(ip string) []string {
+	db.rwlock.RLock()
+	defer db.rwlock.RUnlock()
+	return unitValues(db.IPToNodeNames[ip])
+}
+
+// HashesFromNodeName is the reverse of NodeNamesFromHash, sorted
+func HashesFromNodeName(name string) []string {
+	db.rwlock.RLock()
+	defer db.rwlock.RUnlock()
+	return keysWithValue(db.HashToNodeNames, utils.ShortNodeName(name))
+}
+
+// IPsFromNodeName is the reverse of NodeNamesFromIP, sorted
+func IPsFromNodeName(name string) []string {
+	db.rwlock.RLock()
+	defer db.rwlock.RUnlock()
+	return keysWithValue(db.IPToNodeNames, utils.ShortNodeName(name))
+}
+
+func unitValues(units []translationUnit) []string {
+	values := []string{}
+	for _, unit := range units {
+		values = utils.SliceMergeDeduplicate(values, []string{unit.Value})
+	}
+	return values
+}
+
+func keysWithValue(m map[string][]translationUnit, value string) []string {
+	keys := []string{}
+	for key, units := range m {
+		for _, unit := range units {
+			if unit.Value == value {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
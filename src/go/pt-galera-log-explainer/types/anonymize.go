@@ -0,0 +1,74 @@
+package types
+
+import "github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+
+// Anonymize returns a copy of the timeline where IPs, node names, schemas and tables are replaced
+// by pseudonyms, including in every message it will display
+func (t Timeline) Anonymize(a *utils.Anonymizer) Timeline {
+	out := Timeline{}
+	for node, lt := range t {
+		if len(lt) == 0 {
+			continue
+		}
+
+		// displayers must still receive the real context: they compare it with the names and IPs they captured
+		latest := lt[len(lt)-1].LogCtx
+
+		anonymized := make(LocalTimeline, 0, len(lt))
+		for _, li := range lt {
+			if li.displayer != nil {
+				displayer := li.displayer
+				li.displayer = func(_ LogCtx) string { return a.Anonymize(displayer(latest)) }
+			}
+			li.Log = a.Anonymize(li.Log)
+			li.LogCtx = li.LogCtx.anonymize(a)
+			anonymized = append(anonymized, li)
+		}
+		out[a.Anonymize(node)] = anonymized
+	}
+	return out
+}
+
+func (logCtx LogCtx) anonymize(a *utils.Anonymizer) LogCtx {
+	logCtx.FilePath = a.Anonymize(logCtx.FilePath)
+	logCtx.OwnIPs = anonymizeSlice(a, logCtx.OwnIPs)
+	logCtx.OwnNames = anonymizeSlice(a, logCtx.OwnNames)
+
+	ssts := make(map[string]SST, len(logCtx.SSTs))
+	for donor, sst := range logCtx.SSTs {
+		sst.Donor = a.Anonymize(sst.Donor)
+		sst.Joiner = a.Anonymize(sst.Joiner)
+		ssts[a.Anonymize(donor)] = sst
+	}
+	logCtx.SSTs = ssts
+
+	conflicts := make(Conflicts, 0, len(logCtx.Conflicts))
+	for _, c := range logCtx.Conflicts {
+		anonymized := &Conflict{
+			Seqno:       c.Seqno,
+			InitiatedBy: anonymizeSlice(a, c.InitiatedBy),
+			Winner:      c.Winner,
+			VotePerNode: make(map[string]ConflictVote, len(c.VotePerNode)),
+		}
+		for node, vote := range c.VotePerNode {
+			vote.Error = a.Anonymize(vote.Error)
+			anonymized.VotePerNode[a.Anonymize(node)] = vote
+		}
+		conflicts = append(conflicts, anonymized)
+	}
+	if logCtx.Conflicts != nil {
+		logCtx.Conflicts = conflicts
+	}
+	return logCtx
+}
+
+func anonymizeSlice(a *utils.Anonymizer, s []string) []string {
+	if s == nil {
+		return nil
+	}
+	out := make([]string, 0, len(s))
+	for _, value := range s {
+		out = append(out, a.Anonymize(value))
+	}
+	return out
+}
@@ -19,11 +19,14 @@ type LogCtx struct {
 	OwnIPs                 []string
 	OwnHashes              []string
 	OwnNames               []string
+	ClusterUUIDs           []string
 	stateErrorLog          string
 	stateRecoveryLog       string
 	statePostProcessingLog string
 	stateBackupLog         string
 	Version                string
+	// galera provider version, eg: 4.11
+	ProviderVersion string
 
 	// SSTs where key is donor name, as it will always be known.
 	// is meant to be shared with a deep copy, there's no sense to share the pointer
@@ -90,7 +93,7 @@ func (logCtx *LogCtx) SetState(s string) {
 }
 
 func (logCtx *LogCtx) HasVisibleEvents(level Verbosity) bool {
-	return level >= logCtx.minVerbosity
+	return level.Displays(logCtx.minVerbosity)
 }
 
 func (logCtx *LogCtx) IsPrimary() bool {
@@ -141,6 +144,16 @@ func (logCtx *LogCtx) AddOwnIP(ip string, date time.Time) {
 	}
 }
 
+// AddClusterUUID stores the cluster state UUIDs the node was part of
+// A cluster gets a new one each time it is bootstrapped
+func (logCtx *LogCtx) AddClusterUUID(uuid string) bool {
+	if utils.SliceContains(logCtx.ClusterUUIDs, uuid) {
+		return false
+	}
+	logCtx.ClusterUUIDs = append(logCtx.ClusterUUIDs, uuid)
+	return true
+}
+
 // Inherit will fill the local information from given context
 // into the base
 // It is used when merging, so that we do not start from nothing
@@ -149,9 +162,15 @@ func (base *LogCtx) Inherit(logCtx LogCtx) {
 	base.OwnHashes = append(logCtx.OwnHashes, base.OwnHashes...)
 	base.OwnNames = append(logCtx.OwnNames, base.OwnNames...)
 	base.OwnIPs = append(logCtx.OwnIPs, base.OwnIPs...)
+	for _, uuid := range logCtx.ClusterUUIDs {
+		base.AddClusterUUID(uuid)
+	}
 	if base.Version == "" {
 		base.Version = logCtx.Version
 	}
+	if base.ProviderVersion == "" {
+		base.ProviderVersion = logCtx.ProviderVersion
+	}
 	base.Conflicts = append(logCtx.Conflicts, base.Conflicts...)
 }
 
@@ -190,11 +209,14 @@ func (logCtx *LogCtx) MarshalJSON() ([]byte, error) {
 		OwnIPs                 []string
 		OwnHashes              []string
 		OwnNames               []string
+		ClusterUUIDs           []string
 		StateErrorLog          string
 		StateRecoveryLog       string
 		StatePostProcessingLog string
 		StateBackupLog         string
 		Version                string
+		ProviderVersion        string
+		Profile                Profile
 		SSTs                   map[string]SST
 		MyIdx                  string
 		MemberCount            int
@@ -206,11 +228,15 @@ func (logCtx *LogCtx) MarshalJSON() ([]byte, error) {
 		FileType:               logCtx.FileType,
 		OwnIPs:                 logCtx.OwnIPs,
 		OwnHashes:              logCtx.OwnHashes,
+		OwnNames:               logCtx.OwnNames,
+		ClusterUUIDs:           logCtx.ClusterUUIDs,
 		StateErrorLog:          logCtx.stateErrorLog,
 		StateRecoveryLog:       logCtx.stateRecoveryLog,
 		StatePostProcessingLog: logCtx.statePostProcessingLog,
 		StateBackupLog:         logCtx.stateBackupLog,
 		Version:                logCtx.Version,
+		ProviderVersion:        logCtx.ProviderVersion,
+		Profile:                logCtx.Profile(),
 		SSTs:                   logCtx.SSTs,
 		MyIdx:                  logCtx.MyIdx,
 		MemberCount:            logCtx.MemberCount,
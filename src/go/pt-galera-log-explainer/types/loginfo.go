@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
@@ -15,8 +16,52 @@ const (
 	// DebugMySQL only includes finding that are usually not relevant to show but useful to create the log context (eg: how we found the local address)
 	DebugMySQL
 	Debug
+	// Hidden is never displayed, it is used for event categories hidden by users
+	Hidden
 )
 
+// Displays tells if events of the given verbosity are displayed at this verbosity
+// Hidden events never are, even when the verbosity counter goes past Debug
+func (v Verbosity) Displays(level Verbosity) bool {
+	return level != Hidden && level <= v
+}
+
+// Severity tells how bad an event is, independently of how useful it is to display it
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+	SeverityFatal
+)
+
+var severityNames = []string{"info", "warning", "error", "fatal"}
+
+func (s Severity) String() string {
+	if int(s) < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+func ParseSeverity(s string) (Severity, error) {
+	for i, name := range severityNames {
+		if name == s {
+			return Severity(i), nil
+		}
+	}
+	return SeverityInfo, fmt.Errorf("unknown severity %q, expected one of: %s", s, strings.Join(severityNames, ", "))
+}
+
+// SeverityColors are used to paint messages, overriding the colors chosen by each regex
+// Severities without color keep them
+var SeverityColors = map[Severity]utils.Color{
+	SeverityWarning: utils.YellowText,
+	SeverityError:   utils.RedText,
+	SeverityFatal:   utils.BrightRedText,
+}
+
 // LogInfo is to store a single event in log. This is something that should be displayed ultimately, this is what we want when we launch this tool
 type LogInfo struct {
 	Date            *Date
@@ -26,7 +71,9 @@ type LogInfo struct {
 	RegexUsed       string
 	LogCtx          LogCtx // the context is copied for each logInfo, so that it is easier to handle some info (current state), and this is also interesting to check how it evolved
 	Verbosity       Verbosity
+	Severity        Severity
 	RepetitionCount int
+	RepetitionSpan  time.Duration // between the first and the last repeated events
 	extraNotes      map[string]string
 }
 
@@ -39,6 +86,7 @@ func NewLogInfo(date *Date, displayer LogDisplayer, log string, regex *LogRegex,
 		RegexType:  regex.Type,
 		RegexUsed:  regexkey,
 		Verbosity:  regex.Verbosity,
+		Severity:   regex.Severity,
 		extraNotes: map[string]string{},
 	}
 	if filetype != "error.log" && filetype != "" {
@@ -53,15 +101,68 @@ func (li *LogInfo) Msg(logCtx LogCtx) string {
 	}
 	msg := ""
 	if li.RepetitionCount > 0 {
-		msg += utils.Paint(utils.BlueText, fmt.Sprintf("(repeated x%d)", li.RepetitionCount))
+		msg += utils.Paint(utils.BlueText, li.repetitionSummary())
 	}
-	msg += li.displayer(logCtx)
+	msg += li.paintSeverity(li.displayer(logCtx))
 	for _, note := range li.extraNotes {
 		msg += utils.Paint(utils.BlueText, fmt.Sprintf("(%s)", note))
 	}
 	return msg
 }
 
+// repetitionSummary tells how long events were repeated and how fast, when dates are known
+// The count alone hides whether a flood lasted seconds or hours
+func (li *LogInfo) repetitionSummary() string {
+	if li.RepetitionSpan <= 0 {
+		return fmt.Sprintf("(repeated x%d)", li.RepetitionCount)
+	}
+	span := li.RepetitionSpan.Round(time.Second)
+	if li.RepetitionSpan < time.Second {
+		span = li.RepetitionSpan.Round(time.Millisecond)
+	}
+	return fmt.Sprintf("(repeated x%d over %s (%s))", li.RepetitionCount, span, repetitionRate(li.RepetitionCount, li.RepetitionSpan))
+}
+
+// repetitionRate uses the shortest unit with at least 1 event, "0/s" would not tell anything
+func repetitionRate(count int, span time.Duration) string {
+	perSecond := float64(count) / span.Seconds()
+	switch {
+	case perSecond >= 1:
+		return fmt.Sprintf("%.0f/s", perSecond)
+	case perSecond*60 >= 1:
+		return fmt.Sprintf("%.0f/min", perSecond*60)
+	case perSecond*3600 >= 1:
+		return fmt.Sprintf("%.0f/h", perSecond*3600)
+	}
+	return fmt.Sprintf("%.1f/h", perSecond*3600)
+}
+
+// AddNote appends a note to the message, eg: information given by hooks
+// Adding a note with an existing key replaces it
+func (li *LogInfo) AddNote(key, note string) {
+	if li.extraNotes == nil {
+		li.extraNotes = map[string]string{}
+	}
+	li.extraNotes[key] = note
+}
+
+func (li *LogInfo) paintSeverity(msg string) string {
+	color, ok := SeverityColors[li.Severity]
+	if !ok || utils.SkipColor || msg == "" {
+		return msg
+	}
+	return utils.Paint(color, utils.RemoveColor(msg))
+}
+
+// RawMsg is the message without repetition count nor extra notes
+// It is useful to compare events between themselves
+func (li *LogInfo) RawMsg(logCtx LogCtx) string {
+	if li.displayer == nil {
+		return ""
+	}
+	return li.displayer(logCtx)
+}
+
 // IsDuplicatedEvent will aim to keep 2 occurrences of the same event
 // To be considered duplicated, they must be from the same regexes and have the same message
 func (current *LogInfo) IsDuplicatedEvent(base, previous LogInfo) bool {
@@ -51,3 +51,24 @@ func TestIsDuplicatedEvent(t *testing.T) {
 	}
 
 }
+
+func TestVerbosityDisplays(t *testing.T) {
+	tests := []struct {
+		verbosity, level Verbosity
+		expected         bool
+	}{
+		{verbosity: Info, level: Info, expected: true},
+		{verbosity: Info, level: DebugMySQL, expected: false},
+		{verbosity: Debug, level: DebugMySQL, expected: true},
+		{verbosity: Debug, level: Hidden, expected: false},
+		// -vvv
+		{verbosity: Hidden, level: Hidden, expected: false},
+		{verbosity: Hidden, level: Debug, expected: true},
+	}
+
+	for _, test := range tests {
+		if out := test.verbosity.Displays(test.level); out != test.expected {
+			t.Errorf("verbosity %d, level %d: expected %t, got %t", test.verbosity, test.level, test.expected, out)
+		}
+	}
+}
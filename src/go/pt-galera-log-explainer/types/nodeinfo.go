@@ -4,9 +4,11 @@ package types
 // This is to display its result
 // As it's the base work for "sed" subcommand, it's in types package
 type NodeInfo struct {
-	Input     string   `json:"input"`
-	IPs       []string `json:"IPs"`
+	Input string `json:"input"`
+	// timeline columns
+	Nodes     []string `json:"nodes"`
 	NodeNames []string `json:"nodeNames"`
-	Hostname  string   `json:"hostname"`
-	NodeUUIDs []string `json:"nodeUUIDs:"`
+	IPs       []string `json:"IPs"`
+	Hostnames []string `json:"hostnames"`
+	NodeUUIDs []string `json:"nodeUUIDs"`
 }
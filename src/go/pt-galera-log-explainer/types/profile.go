@@ -0,0 +1,48 @@
+package types
+
+import "strings"
+
+// Profile is a set of regexes specific to a galera provider major version
+// Galera 3 (PXC 5.x, MariaDB < 10.4) and Galera 4 (PXC 8.0, MariaDB 10.4+) word many messages differently
+type Profile string
+
+const (
+	ProfileGalera3 Profile = "galera3"
+	ProfileGalera4 Profile = "galera4"
+)
+
+// Profile returns the regex profile matching the versions found so far, empty when unknown
+// The provider version is preferred, the mysql version is only a fallback for logs missing the provider banner
+func (logCtx LogCtx) Profile() Profile {
+	if logCtx.ProviderVersion != "" {
+		// MariaDB packages prefix provider versions with the wsrep API version: 25.3.x, 26.4.x
+		version := strings.TrimPrefix(strings.TrimPrefix(logCtx.ProviderVersion, "25."), "26.")
+		switch {
+		case strings.HasPrefix(version, "3."):
+			return ProfileGalera3
+		case strings.HasPrefix(version, "4."):
+			return ProfileGalera4
+		}
+	}
+
+	switch {
+	case logCtx.Version == "":
+		return ""
+	case strings.HasPrefix(logCtx.Version, "5."):
+		return ProfileGalera3
+	case strings.HasPrefix(logCtx.Version, "8."), strings.HasPrefix(logCtx.Version, "11."):
+		return ProfileGalera4
+	case strings.HasPrefix(logCtx.Version, "10."):
+		if minor := strings.Split(logCtx.Version, ".")[1]; len(minor) == 1 && minor < "4" {
+			return ProfileGalera3
+		}
+		return ProfileGalera4
+	}
+	return ""
+}
+
+// Handles tells if a regex can be used with this profile
+// Regexes without profile, or logs without known versions, are always handled
+func (p Profile) Handles(regex *LogRegex) bool {
+	return p == "" || regex.Profile == "" || regex.Profile == p
+}
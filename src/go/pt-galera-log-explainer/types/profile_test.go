@@ -0,0 +1,29 @@
+package types
+
+import "testing"
+
+func TestLogCtxProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		logCtx   LogCtx
+		expected Profile
+	}{
+		{name: "unknown", expected: ""},
+		{name: "galera 3 provider", logCtx: LogCtx{ProviderVersion: "3.63"}, expected: ProfileGalera3},
+		{name: "galera 4 provider", logCtx: LogCtx{ProviderVersion: "4.11"}, expected: ProfileGalera4},
+		{name: "mariadb galera 3 provider", logCtx: LogCtx{ProviderVersion: "25.3.37"}, expected: ProfileGalera3},
+		{name: "mariadb galera 4 provider", logCtx: LogCtx{ProviderVersion: "26.4.14"}, expected: ProfileGalera4},
+		{name: "provider preferred over mysql version", logCtx: LogCtx{ProviderVersion: "4.11", Version: "5.7.31"}, expected: ProfileGalera4},
+		{name: "pxc 5.7", logCtx: LogCtx{Version: "5.7.31"}, expected: ProfileGalera3},
+		{name: "pxc 8.0", logCtx: LogCtx{Version: "8.0.30"}, expected: ProfileGalera4},
+		{name: "mariadb 10.2", logCtx: LogCtx{Version: "10.2.31"}, expected: ProfileGalera3},
+		{name: "mariadb 10.4", logCtx: LogCtx{Version: "10.4.25"}, expected: ProfileGalera4},
+		{name: "mariadb 10.11", logCtx: LogCtx{Version: "10.11.2"}, expected: ProfileGalera4},
+	}
+
+	for _, test := range tests {
+		if profile := test.logCtx.Profile(); profile != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, profile)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/json"
 	"regexp"
+	"sort"
 	"time"
 )
 
@@ -18,6 +19,9 @@ type LogRegex struct {
 	// This ensure every hash/ip/nodenames are already known when crafting the message
 	Handler   func(map[string]string, LogCtx, string, time.Time) (LogCtx, LogDisplayer)
 	Verbosity Verbosity // To be able to hide details from summaries
+	Severity  Severity  // info by default
+	// galera provider versions wording the message this way, empty when it is the same for every versions
+	Profile Profile
 }
 
 func (l *LogRegex) Handle(logCtx LogCtx, line string, date time.Time) (LogCtx, LogDisplayer) {
@@ -47,9 +51,13 @@ func (l *LogRegex) MarshalJSON() ([]byte, error) {
 		InternalRegex string    `json:"internalRegex"`
 		Type          RegexType `json:"type"`
 		Verbosity     Verbosity `json:"verbosity"`
+		Severity      string    `json:"severity"`
+		Profile       Profile   `json:"profile,omitempty"`
 	}{
 		Type:      l.Type,
 		Verbosity: l.Verbosity,
+		Severity:  l.Severity.String(),
+		Profile:   l.Profile,
 	}
 	if l.Regex != nil {
 		out.Regex = l.Regex.String()
@@ -71,6 +79,21 @@ var (
 	StatesRegexType      RegexType = "states"
 	PXCOperatorRegexType RegexType = "pxc-operator"
 	ApplicativeRegexType RegexType = "applicative"
+
+	// not an actual regex type, used for events generated by the tool itself
+	GapRegexType RegexType = "gap"
+	// results of analysis spanning several nodes, eg: split-brains
+	NoticeRegexType RegexType = "notice"
+	// events given by users, eg: deployments, configuration changes
+	AnnotationRegexType RegexType = "annotation"
+	// lines matching a pattern given by users, with the search subcommand
+	SearchRegexType RegexType = "search"
+	// queries found in slow query logs
+	SlowQueryRegexType RegexType = "slow-query"
+	// queries found in general query logs
+	GeneralQueryRegexType RegexType = "general-query"
+	// operating system events, eg: OOM killer, disk errors
+	SystemRegexType RegexType = "system"
 )
 
 type RegexMap map[string]*LogRegex
@@ -82,11 +105,24 @@ func (r RegexMap) Merge(r2 RegexMap) RegexMap {
 	return r
 }
 
+// SortedKeys is used to apply regexes in the same order on every run: a line can match several of them,
+// and handlers may depend on the context updated by the previous ones
+func (r RegexMap) SortedKeys() []string {
+	keys := make([]string, 0, len(r))
+	for key := range r {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (r RegexMap) Compile() []string {
 
 	arr := []string{}
 	for _, regex := range r {
 		arr = append(arr, regex.Regex.String())
 	}
+	// map iteration order is random, sorting keeps the grep argument stable between runs
+	sort.Strings(arr)
 	return arr
 }
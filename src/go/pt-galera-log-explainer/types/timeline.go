@@ -1,9 +1,13 @@
 package types
 
 import (
+	"fmt"
 	"math"
 	"path/filepath"
+	"sort"
 	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
 )
 
 // It should be kept already sorted by timestamp
@@ -15,8 +19,12 @@ func (lt LocalTimeline) Add(li LogInfo) LocalTimeline {
 	// 1st one for the 1st timestamp found, it will also show the number of repetition
 	// 2nd loginfo the keep the last timestamp found, so that we don't loose track
 	// so there will be a corner case if the first ever event is repeated, but that is acceptable
+	// the time span of the repetitions is kept along the count, to give the rate of the flood
 	if len(lt) > 1 && li.IsDuplicatedEvent(lt[len(lt)-2], lt[len(lt)-1]) {
 		lt[len(lt)-2].RepetitionCount++
+		if lt[len(lt)-2].Date != nil && li.Date != nil {
+			lt[len(lt)-2].RepetitionSpan = li.Date.Time.Sub(lt[len(lt)-2].Date.Time)
+		}
 		lt[len(lt)-1] = li
 	} else {
 		lt = append(lt, li)
@@ -24,6 +32,103 @@ func (lt LocalTimeline) Add(li LogInfo) LocalTimeline {
 	return lt
 }
 
+// WithGaps inserts an event wherever no events were found for longer than threshold
+// Silent gaps would otherwise look like "nothing happened", when the data could simply be absent
+// The event is dated with the last known date before the gap, so that it is displayed right before the next event
+func (lt LocalTimeline) WithGaps(threshold time.Duration) LocalTimeline {
+	if threshold <= 0 {
+		return lt
+	}
+
+	out := make(LocalTimeline, 0, len(lt))
+	var last *LogInfo
+	for i := range lt {
+		li := lt[i]
+		if li.Date != nil {
+			if last != nil && li.Date.Time.Sub(last.Date.Time) > threshold {
+				out = append(out, newGapLogInfo(*last, li.Date.Time.Sub(last.Date.Time)))
+			}
+			last = &lt[i]
+		}
+		out = append(out, li)
+	}
+	return out
+}
+
+func newGapLogInfo(previous LogInfo, gap time.Duration) LogInfo {
+	return LogInfo{
+		Date:       previous.Date,
+		displayer:  SimpleDisplayer(utils.Paint(utils.YellowText, fmt.Sprintf("no events for %s", gap.Round(time.Second)))),
+		RegexType:  GapRegexType,
+		RegexUsed:  "Gap",
+		LogCtx:     previous.LogCtx,
+		Verbosity:  Info,
+		extraNotes: map[string]string{},
+	}
+}
+
+// WithNotice inserts an event generated by the tool after every event dated before or at the given date
+// It is meant to flag findings which could not be seen from a single log line
+func (lt LocalTimeline) WithNotice(date *Date, msg string) LocalTimeline {
+	if len(lt) == 0 || date == nil {
+		return lt
+	}
+	i := 0
+	for i < len(lt) && (lt[i].Date == nil || !lt[i].Date.Time.After(date.Time)) {
+		i++
+	}
+	logCtx := lt[0].LogCtx
+	if i > 0 {
+		logCtx = lt[i-1].LogCtx
+	}
+	notice := LogInfo{
+		Date:       date,
+		displayer:  SimpleDisplayer(msg),
+		RegexType:  NoticeRegexType,
+		RegexUsed:  "Notice",
+		LogCtx:     logCtx,
+		Verbosity:  Info,
+		extraNotes: map[string]string{},
+	}
+
+	out := make(LocalTimeline, 0, len(lt)+1)
+	out = append(out, lt[:i]...)
+	out = append(out, notice)
+	return append(out, lt[i:]...)
+}
+
+// Interleave inserts dated events coming from another source than the error log, eg: slow query logs
+// Each event gets the context of the event preceding it, so that they do not look like context changes
+// Events are expected to be sorted, undated events are ignored
+func (lt LocalTimeline) Interleave(events LocalTimeline) LocalTimeline {
+	if len(lt) == 0 {
+		out := make(LocalTimeline, 0, len(events))
+		for _, event := range events {
+			if event.Date != nil {
+				out = append(out, event)
+			}
+		}
+		return out
+	}
+	out := make(LocalTimeline, 0, len(lt)+len(events))
+	i := 0
+	for _, event := range events {
+		if event.Date == nil {
+			continue
+		}
+		for i < len(lt) && (lt[i].Date == nil || !lt[i].Date.Time.After(event.Date.Time)) {
+			out = append(out, lt[i])
+			i++
+		}
+		event.LogCtx = lt[0].LogCtx
+		if i > 0 {
+			event.LogCtx = lt[i-1].LogCtx
+		}
+		out = append(out, event)
+	}
+	return append(out, lt[i:]...)
+}
+
 // "string" key is a node IP
 type Timeline map[string]LocalTimeline
 
@@ -157,9 +262,33 @@ func (t *Timeline) GetLatestContextsByNodes() map[string]LogCtx {
 	return latestlogCtxs
 }
 
+// GetContextsByNodesAt returns the context each node had at a given time, that is the one of its last event not after it
+// Nodes without any event before this time are not included
+func (t Timeline) GetContextsByNodesAt(at time.Time) map[string]LogCtx {
+	logCtxs := make(map[string]LogCtx, len(t))
+
+	for key, localtimeline := range t {
+		found := false
+		for _, li := range localtimeline {
+			if li.Date != nil {
+				if li.Date.Time.After(at) {
+					break
+				}
+				found = true
+			}
+			if found {
+				logCtxs[key] = li.LogCtx
+			}
+		}
+	}
+
+	return logCtxs
+}
+
 // iterateNode is used to search the source node(s) that contains the next chronological events
 // it returns a slice in case 2 nodes have their next event precisely at the same time, which
-// happens a lot on some versions
+// happens a lot on some versions, or when logs do not have sub-second precision
+// Ties are broken by node name, and each node keeps the order of its log lines, so that outputs are the same between runs
 func (t Timeline) IterateNode() []string {
 	var (
 		nextDate  time.Time
@@ -178,6 +307,8 @@ func (t Timeline) IterateNode() []string {
 			nextNodes = append(nextNodes, node)
 		}
 	}
+	// map iteration order is random, exports rely on a stable order
+	sort.Strings(nextNodes)
 	return nextNodes
 }
 
@@ -188,3 +319,72 @@ func (t Timeline) Dequeue(node string) {
 		t[node] = t[node][1:]
 	}
 }
+
+// ClusterTimeline is the subset of a timeline holding nodes seen in the same cluster
+type ClusterTimeline struct {
+	UUIDs    []string
+	Timeline Timeline
+}
+
+// SplitByCluster partitions the timeline using cluster state UUIDs, so that logs from unrelated
+// clusters are not merged in one misleading view
+// Nodes are grouped as soon as they share a UUID, because a cluster gets a new one each time it is bootstrapped
+// Nodes without any known UUID are grouped together, unless there is a single cluster to attach them to
+func (t Timeline) SplitByCluster() []ClusterTimeline {
+	latestContexts := t.GetLatestContextsByNodes()
+
+	nodes := make([]string, 0, len(t))
+	for node := range t {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	clusters := []*ClusterTimeline{}
+	unknown := Timeline{}
+	for _, node := range nodes {
+		uuids := latestContexts[node].ClusterUUIDs
+		if len(uuids) == 0 {
+			unknown[node] = t[node]
+			continue
+		}
+
+		// the node can link several clusters previously thought as different
+		merged := &ClusterTimeline{Timeline: Timeline{node: t[node]}, UUIDs: append([]string{}, uuids...)}
+		remaining := []*ClusterTimeline{}
+		for _, cluster := range clusters {
+			if !utils.SliceIntersects(cluster.UUIDs, uuids) {
+				remaining = append(remaining, cluster)
+				continue
+			}
+			merged.UUIDs = utils.SliceMergeDeduplicate(merged.UUIDs, cluster.UUIDs)
+			for n, lt := range cluster.Timeline {
+				merged.Timeline[n] = lt
+			}
+		}
+		clusters = append(remaining, merged)
+	}
+
+	if len(unknown) > 0 {
+		if len(clusters) == 1 {
+			for node, lt := range unknown {
+				clusters[0].Timeline[node] = lt
+			}
+		} else {
+			clusters = append(clusters, &ClusterTimeline{Timeline: unknown})
+		}
+	}
+
+	out := make([]ClusterTimeline, 0, len(clusters))
+	for _, cluster := range clusters {
+		sort.Strings(cluster.UUIDs)
+		out = append(out, *cluster)
+	}
+	// unknown cluster last, then by uuids, so that the output is stable
+	sort.SliceStable(out, func(i, j int) bool {
+		if len(out[i].UUIDs) == 0 || len(out[j].UUIDs) == 0 {
+			return len(out[j].UUIDs) == 0 && len(out[i].UUIDs) != 0
+		}
+		return out[i].UUIDs[0] < out[j].UUIDs[0]
+	})
+	return out
+}
@@ -2,8 +2,11 @@ package types
 
 import (
 	"reflect"
+	"sort"
 	"testing"
 	"time"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
 )
 
 func TestMergeTimeline(t *testing.T) {
@@ -221,6 +224,34 @@ func TestMergeTimeline(t *testing.T) {
 
 }
 
+func TestMergeTimelineSameTimestamp(t *testing.T) {
+	date := time.Date(2023, time.January, 1, 1, 1, 1, 0, time.UTC)
+	event := func(offset time.Duration, log string) LogInfo {
+		return LogInfo{Date: &Date{Time: date.Add(offset)}, Log: log}
+	}
+	// error.log was rotated while several lines were written in the same second
+	rotated := func() LocalTimeline {
+		return LocalTimeline{event(-time.Second, "rotated 1"), event(0, "rotated 2"), event(0, "rotated 3")}
+	}
+	current := func() LocalTimeline {
+		return LocalTimeline{event(0, "current 1"), event(0, "current 2"), event(time.Second, "current 3")}
+	}
+	expected := []string{"rotated 1", "rotated 2", "rotated 3", "current 1", "current 2", "current 3"}
+
+	for name, out := range map[string]LocalTimeline{
+		"rotated first": MergeTimeline(rotated(), current()),
+		"current first": MergeTimeline(current(), rotated()),
+	} {
+		logs := []string{}
+		for _, li := range out {
+			logs = append(logs, li.Log)
+		}
+		if !reflect.DeepEqual(logs, expected) {
+			t.Fatalf("%s failed: expected %v, got %v", name, expected, logs)
+		}
+	}
+}
+
 func TestCutTimelineAt(t *testing.T) {
 
 	tests := []struct {
@@ -259,3 +290,204 @@ func TestCutTimelineAt(t *testing.T) {
 	}
 
 }
+
+func TestGetContextsByNodesAt(t *testing.T) {
+	event := func(day int, version string) LogInfo {
+		li := LogInfo{LogCtx: LogCtx{Version: version}}
+		if day > 0 {
+			li.Date = &Date{Time: time.Date(2023, time.January, day, 1, 1, 1, 1, time.UTC)}
+		}
+		return li
+	}
+	timeline := Timeline{
+		"node1": LocalTimeline{event(1, "8.0.28"), event(0, "8.0.29"), event(3, "8.0.30")},
+		"node2": LocalTimeline{event(3, "8.0.30")},
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected map[string]string
+	}{
+		{
+			name:     "before every event",
+			at:       time.Date(2022, time.January, 1, 1, 1, 1, 1, time.UTC),
+			expected: map[string]string{},
+		},
+		{
+			name:     "events without dates follow the previous one",
+			at:       time.Date(2023, time.January, 2, 1, 1, 1, 1, time.UTC),
+			expected: map[string]string{"node1": "8.0.29"},
+		},
+		{
+			name:     "at the exact time of events",
+			at:       time.Date(2023, time.January, 3, 1, 1, 1, 1, time.UTC),
+			expected: map[string]string{"node1": "8.0.30", "node2": "8.0.30"},
+		},
+	}
+
+	for _, test := range tests {
+		out := map[string]string{}
+		for node, logCtx := range timeline.GetContextsByNodesAt(test.at) {
+			out[node] = logCtx.Version
+		}
+		if !reflect.DeepEqual(out, test.expected) {
+			t.Errorf("%s failed: expected %v, got %v", test.name, test.expected, out)
+		}
+	}
+}
+
+func TestWithGaps(t *testing.T) {
+	date := time.Date(2023, time.January, 1, 1, 1, 1, 1, time.UTC)
+	lt := LocalTimeline{
+		LogInfo{Date: &Date{Time: date}, RegexUsed: "RegexStarting"},
+		LogInfo{Date: &Date{Time: date.Add(time.Minute)}, RegexUsed: "RegexNodeJoined"},
+		LogInfo{RegexUsed: "RegexWsrepRecovery"},
+		LogInfo{Date: &Date{Time: date.Add(3 * time.Hour)}, RegexUsed: "RegexShutdownComplete"},
+	}
+
+	out := lt.WithGaps(time.Hour)
+	if len(out) != 5 {
+		t.Fatalf("expected a single gap to be added, got %d events", len(out))
+	}
+	gap := out[3]
+	if gap.RegexType != GapRegexType || !gap.Date.Time.Equal(date.Add(time.Minute)) {
+		t.Errorf("gap event is not as expected: %+v", gap)
+	}
+	if msg := gap.Msg(LogCtx{}); msg != utils.Paint(utils.YellowText, "no events for 2h59m0s") {
+		t.Errorf("unexpected gap message: %s", msg)
+	}
+
+	if out := lt.WithGaps(0); len(out) != len(lt) {
+		t.Errorf("gaps should not be detected without threshold")
+	}
+}
+
+func TestAddRepetitions(t *testing.T) {
+	date := time.Date(2023, time.January, 1, 1, 1, 1, 1, time.UTC)
+	repeated := func(d time.Duration) LogInfo {
+		return LogInfo{Date: &Date{Time: date.Add(d)}, RegexUsed: "RegexSSTResourceUnavailable", displayer: SimpleDisplayer("cannot find donor")}
+	}
+
+	lt := LocalTimeline{}
+	for i := 0; i <= 240; i++ {
+		lt = lt.Add(repeated(time.Duration(i) * 500 * time.Millisecond))
+	}
+	if len(lt) != 2 {
+		t.Fatalf("expected repetitions to be deduplicated, got %d events", len(lt))
+	}
+	if lt[0].RepetitionCount != 239 || lt[0].RepetitionSpan != 2*time.Minute {
+		t.Errorf("unexpected repetitions: x%d over %s", lt[0].RepetitionCount, lt[0].RepetitionSpan)
+	}
+	if !lt[1].Date.Time.Equal(date.Add(2 * time.Minute)) {
+		t.Errorf("the last repetition should be kept, got %s", lt[1].Date.Time)
+	}
+
+	tests := []struct {
+		count    int
+		span     time.Duration
+		expected string
+	}{
+		{count: 4231, span: 2 * time.Minute, expected: "(repeated x4231 over 2m0s (35/s))"},
+		{count: 97, span: 2*time.Hour + 15*time.Minute, expected: "(repeated x97 over 2h15m0s (43/h))"},
+		{count: 3, span: 10 * time.Hour, expected: "(repeated x3 over 10h0m0s (0.3/h))"},
+		{count: 12, span: 300 * time.Millisecond, expected: "(repeated x12 over 300ms (40/s))"},
+		{count: 5, expected: "(repeated x5)"},
+	}
+	for _, test := range tests {
+		li := LogInfo{RepetitionCount: test.count, RepetitionSpan: test.span}
+		if out := li.repetitionSummary(); out != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, out)
+		}
+	}
+}
+
+func TestSplitByCluster(t *testing.T) {
+	withUUIDs := func(uuids ...string) LocalTimeline {
+		return LocalTimeline{LogInfo{LogCtx: LogCtx{ClusterUUIDs: uuids}}}
+	}
+
+	tests := []struct {
+		name     string
+		input    Timeline
+		expected map[string][]string // first uuid => nodes
+	}{
+		{
+			name:     "single cluster, node without uuid attached to it",
+			input:    Timeline{"node1": withUUIDs("a"), "node2": withUUIDs("a"), "node3": withUUIDs()},
+			expected: map[string][]string{"a": {"node1", "node2", "node3"}},
+		},
+		{
+			name:     "two clusters and unknown nodes",
+			input:    Timeline{"node1": withUUIDs("a"), "node2": withUUIDs("b"), "node3": withUUIDs()},
+			expected: map[string][]string{"a": {"node1"}, "b": {"node2"}, "": {"node3"}},
+		},
+		{
+			name:     "cluster bootstrapped again links both uuids",
+			input:    Timeline{"node1": withUUIDs("a"), "node2": withUUIDs("c"), "node3": withUUIDs("a", "c"), "node4": withUUIDs("b")},
+			expected: map[string][]string{"a": {"node1", "node2", "node3"}, "b": {"node4"}},
+		},
+	}
+
+	for _, test := range tests {
+		out := map[string][]string{}
+		for _, cluster := range test.input.SplitByCluster() {
+			key := ""
+			if len(cluster.UUIDs) > 0 {
+				key = cluster.UUIDs[0]
+			}
+			for node := range cluster.Timeline {
+				out[key] = append(out[key], node)
+			}
+			sort.Strings(out[key])
+		}
+		if !reflect.DeepEqual(out, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, out)
+		}
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	date := time.Date(2023, time.January, 1, 1, 1, 1, 1, time.UTC)
+	synced := LogCtx{FilePath: "error.log"}
+	synced.SetState("SYNCED")
+	donor := LogCtx{FilePath: "error.log"}
+	donor.SetState("DONOR")
+
+	lt := LocalTimeline{
+		LogInfo{Date: &Date{Time: date}, RegexUsed: "RegexShift", LogCtx: synced},
+		LogInfo{Date: &Date{Time: date.Add(time.Minute)}, RegexUsed: "RegexShift", LogCtx: donor},
+	}
+	events := LocalTimeline{
+		LogInfo{Date: &Date{Time: date.Add(-time.Second)}, RegexUsed: "SlowQuery"},
+		LogInfo{Date: &Date{Time: date.Add(time.Minute)}, RegexUsed: "SlowQuery"},
+		LogInfo{Date: &Date{Time: date.Add(time.Hour)}, RegexUsed: "SlowQuery"},
+		LogInfo{RegexUsed: "SlowQuery"},
+	}
+
+	out := lt.Interleave(events)
+	expected := []struct {
+		offset time.Duration
+		regex  string
+		state  string
+	}{
+		{-time.Second, "SlowQuery", "SYNCED"},
+		{0, "RegexShift", "SYNCED"},
+		{time.Minute, "RegexShift", "DONOR"},
+		{time.Minute, "SlowQuery", "DONOR"},
+		{time.Hour, "SlowQuery", "DONOR"},
+	}
+	if len(out) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(out))
+	}
+	for i, e := range expected {
+		if !out[i].Date.Time.Equal(date.Add(e.offset)) || out[i].RegexUsed != e.regex || out[i].LogCtx.State() != e.state {
+			t.Errorf("event %d: expected %s at %s with state %s, got %s at %s with state %s", i, e.regex, date.Add(e.offset), e.state, out[i].RegexUsed, out[i].Date.Time, out[i].LogCtx.State())
+		}
+	}
+
+	// nodes without events left, eg: outside of --since and --until, still get the interleaved ones
+	if out := (LocalTimeline{}).Interleave(events); len(out) != 3 {
+		t.Errorf("expected the 3 dated events on an empty timeline, got %d", len(out))
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
+type unparseable struct {
+	Paths         []string `arg:"" name:"paths" help:"paths of the log to use"`
+	MaxLineLength int      `default:"65536" help:"Lines longer than this many bytes are reported"`
+}
+
+func (u *unparseable) Help() string {
+	return fmt.Sprintf(`Locate regions of logs that cannot be parsed: NUL bytes, invalid or truncated characters, crash stack traces and overly long lines
+	They are usually left by crashes, full disks or log collection issues. Events in these regions may be missing from other subcommands
+
+Usage:
+	%[1]s unparseable *.log
+	%[1]s unparseable --max-line-length 4096 *.log
+	`, toolname)
+}
+
+func (u *unparseable) Run() error {
+	if u.MaxLineLength <= 0 {
+		return errors.New("--max-line-length must be positive")
+	}
+
+	paths, err := expandRemotes(u.Paths)
+	if err != nil {
+		return err
+	}
+	paths, archives, cleanup, err := expandArchives(paths)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for i, path := range paths {
+		r, err := openLog(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", path)
+		}
+		report, err := scanUnparseable(r, u.MaxLineLength)
+		r.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", path)
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(report.String(archives.displayPath(path)))
+	}
+
+	_, err = fmt.Fprint(os.Stdout, b.String())
+	return err
+}
+
+const (
+	damageNUL         = "NUL bytes"
+	damageInvalidUTF8 = "invalid or truncated characters"
+	damageStackTrace  = "stack trace"
+	damageLongLine    = "line too long"
+)
+
+// stack frames printed by mysqld when crashing, eg:
+// /usr/sbin/mysqld(my_print_stacktrace+0x2c)[0x55d1e8a4c5ec]
+// /lib64/libpthread.so.0(+0x12cf0)[0x7f2b5a0b0cf0]
+var stackFrameRegex = regexp.MustCompile(`^\S*\(\S*\+0x[0-9a-f]+\)\s*\[0x[0-9a-f]+\]\s*$|^stack_bottom = |^\[0x[0-9a-f]+\]\s*$`)
+
+// unparseableRegion is a run of consecutive lines damaged the same way
+type unparseableRegion struct {
+	Kind string
+	// in bytes, from the beginning of the uncompressed log
+	Offset, Size int64
+	// 1-indexed
+	FirstLine, LastLine int
+}
+
+type unparseableReport struct {
+	Regions   []unparseableRegion
+	TotalSize int64
+}
+
+func damageKind(line string, maxLineLength int) string {
+	switch {
+	case len(line) > maxLineLength:
+		return damageLongLine
+	case strings.IndexByte(line, 0) >= 0:
+		return damageNUL
+	case !utf8.ValidString(line):
+		return damageInvalidUTF8
+	case stackFrameRegex.MatchString(line):
+		return damageStackTrace
+	}
+	return ""
+}
+
+// scanUnparseable reads a whole log, merging consecutive damaged lines of the same kind into regions
+func scanUnparseable(r io.Reader, maxLineLength int) (unparseableReport, error) {
+	report := unparseableReport{}
+	reader := bufio.NewReader(r)
+
+	var (
+		offset int64
+		lineNb int
+	)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNb++
+			size := int64(len(line))
+			kind := damageKind(strings.TrimRight(string(line), "\r\n"), maxLineLength)
+
+			last := len(report.Regions) - 1
+			switch {
+			case kind == "":
+			case last >= 0 && report.Regions[last].Kind == kind && report.Regions[last].LastLine == lineNb-1:
+				report.Regions[last].Size += size
+				report.Regions[last].LastLine = lineNb
+			default:
+				report.Regions = append(report.Regions, unparseableRegion{Kind: kind, Offset: offset, Size: size, FirstLine: lineNb, LastLine: lineNb})
+			}
+			offset += size
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+	report.TotalSize = offset
+	return report, nil
+}
+
+func (report unparseableReport) String(path string) string {
+	var b strings.Builder
+	b.WriteString(utils.Paint(utils.BlueText, path) + "\n")
+	if len(report.Regions) == 0 {
+		b.WriteString("\tno unparseable region found\n")
+		return b.String()
+	}
+
+	var damaged int64
+	for _, region := range report.Regions {
+		lines := fmt.Sprintf("line %d", region.FirstLine)
+		if region.LastLine > region.FirstLine {
+			lines = fmt.Sprintf("lines %d-%d", region.FirstLine, region.LastLine)
+		}
+		b.WriteString(fmt.Sprintf("\toffset %d, %d bytes (%s): %s\n", region.Offset, region.Size, lines, region.Kind))
+		damaged += region.Size
+	}
+	b.WriteString(fmt.Sprintf("\t%d bytes out of %d are unparseable (%.2f%%)\n", damaged, report.TotalSize, float64(damaged)*100/float64(report.TotalSize)))
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScanUnparseable(t *testing.T) {
+	log := "2023-03-12T07:35:00.000000Z 0 [Note] WSREP: Shifting SYNCED -> DONOR/DESYNCED (TO: 10)\n" +
+		"\x00\x00\x00\x00\n" +
+		"\x00\x00\n" +
+		"2023-03-12T07:35:01.000000Z 0 [Note] truncated \xe2\x82\n" +
+		"stack_bottom = 7f2b3c0a4d80 thread_stack 0x100000\n" +
+		"/usr/sbin/mysqld(my_print_stacktrace+0x2c)[0x55d1e8a4c5ec]\n" +
+		"/lib64/libpthread.so.0(+0x12cf0)[0x7f2b5a0b0cf0]\n" +
+		"2023-03-12T07:35:02.000000Z 0 [Note] " + strings.Repeat("a", 100) + "\n" +
+		"2023-03-12T07:35:03.000000Z 0 [Note] WSREP: Shifting DONOR/DESYNCED -> JOINED (TO: 10)"
+
+	report, err := scanUnparseable(strings.NewReader(log), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := unparseableReport{
+		Regions: []unparseableRegion{
+			{Kind: damageNUL, Offset: 87, Size: 8, FirstLine: 2, LastLine: 3},
+			{Kind: damageInvalidUTF8, Offset: 95, Size: 50, FirstLine: 4, LastLine: 4},
+			{Kind: damageStackTrace, Offset: 145, Size: 158, FirstLine: 5, LastLine: 7},
+			{Kind: damageLongLine, Offset: 303, Size: 138, FirstLine: 8, LastLine: 8},
+		},
+		TotalSize: int64(len(log)),
+	}
+	if diff := cmp.Diff(expected, report); diff != "" {
+		t.Fatalf("unexpected report (-want +got):\n%s", diff)
+	}
+}
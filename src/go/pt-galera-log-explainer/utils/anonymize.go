@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	anonymizeIPRegex = regexp.MustCompile(`\b[0-9]{1,3}\.[0-9]{1,3}\.[0-9]{1,3}\.[0-9]{1,3}\b`)
+
+	// IP pseudonyms are taken from the documentation ranges (RFC 5737), then from the benchmarking one (RFC 2544),
+	// so that they cannot be mistaken for the private addresses found in logs
+	anonymizeIPRanges = []string{"192.0.2", "198.51.100", "203.0.113"}
+
+	// only qualified names are considered, "table" alone is too common in logs
+	// eg: "Could not execute Write_rows event on table test.t1", "`test`.`t1`"
+	anonymizeTableRegexes = []*regexp.Regexp{
+		regexp.MustCompile("(?i)\\btable +[`'\"]?([a-zA-Z0-9_$]+)[`'\"]?\\.[`'\"]?([a-zA-Z0-9_$]+)"),
+		regexp.MustCompile("`([a-zA-Z0-9_$]+)`\\.`([a-zA-Z0-9_$]+)`"),
+	}
+)
+
+// Anonymizer consistently replaces IPs, node names, schemas and tables with pseudonyms
+// The same value always gets the same pseudonym, so that the output stays readable
+type Anonymizer struct {
+	pseudonyms map[string]map[string]string // kind => original => pseudonym
+	given      map[string]bool              // every pseudonym, so that already anonymized text is kept as is
+	names      []string
+	namesRegex *regexp.Regexp
+}
+
+func NewAnonymizer() *Anonymizer {
+	return &Anonymizer{
+		pseudonyms: map[string]map[string]string{"ip": {}, "node": {}, "schema": {}, "table": {}},
+		given:      map[string]bool{},
+	}
+}
+
+// AddNames registers node names, or hostnames, to hide
+// IPs, schemas and tables are found on their own
+func (a *Anonymizer) AddNames(names ...string) {
+	added := false
+	for _, name := range names {
+		if name == "" || SliceContains(a.names, name) {
+			continue
+		}
+		a.names = append(a.names, name)
+		added = true
+		// given right away, so that pseudonyms follow the order names were given in
+		a.pseudonym("node", name)
+	}
+	if !added {
+		return
+	}
+
+	// longest first, so that "node1" does not hide a part of "node1-backup"
+	sort.Slice(a.names, func(i, j int) bool { return len(a.names[i]) > len(a.names[j]) })
+	quoted := make([]string, 0, len(a.names))
+	for _, name := range a.names {
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	a.namesRegex = regexp.MustCompile(`\b(` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// Anonymize replaces every sensitive value from s
+// Pseudonyms are kept, so that s can be partially anonymized already
+func (a *Anonymizer) Anonymize(s string) string {
+	// colors are kept as is: their "m" suffix would prevent matching names at word boundaries
+	var b strings.Builder
+	last := 0
+	for _, loc := range colorRegex.FindAllStringIndex(s, -1) {
+		b.WriteString(a.anonymizeText(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(a.anonymizeText(s[last:]))
+	return b.String()
+}
+
+func (a *Anonymizer) anonymizeText(s string) string {
+	for _, r := range anonymizeTableRegexes {
+		s = replaceSubmatches(r, s, func(i int, value string) string {
+			if i == 1 {
+				return a.pseudonym("schema", value)
+			}
+			return a.pseudonym("table", value)
+		})
+	}
+	s = anonymizeIPRegex.ReplaceAllStringFunc(s, func(ip string) string {
+		// not tied to any infrastructure, and useful to understand logs
+		if ip == "0.0.0.0" || strings.HasPrefix(ip, "127.") {
+			return ip
+		}
+		return a.pseudonym("ip", ip)
+	})
+	if a.namesRegex != nil {
+		s = a.namesRegex.ReplaceAllStringFunc(s, func(name string) string { return a.pseudonym("node", name) })
+	}
+	return s
+}
+
+func (a *Anonymizer) pseudonym(kind, value string) string {
+	if a.given[value] {
+		return value
+	}
+	if p, ok := a.pseudonyms[kind][value]; ok {
+		return p
+	}
+	n := len(a.pseudonyms[kind]) + 1
+	var p string
+	switch kind {
+	case "ip":
+		p = pseudonymIP(n)
+	case "node":
+		p = fmt.Sprintf("anon-node-%d", n)
+	default:
+		p = fmt.Sprintf("anon_%s_%d", kind, n)
+	}
+	a.pseudonyms[kind][value] = p
+	a.given[p] = true
+	return p
+}
+
+// pseudonymIP returns the nth IP pseudonym, starting from 1
+func pseudonymIP(n int) string {
+	n--
+	if i := n / 254; i < len(anonymizeIPRanges) {
+		return fmt.Sprintf("%s.%d", anonymizeIPRanges[i], n%254+1)
+	}
+	n -= 254*len(anonymizeIPRanges) - 1
+	return fmt.Sprintf("198.%d.%d.%d", 18+n>>16&1, n>>8&255, n&255)
+}
+
+// Mapping returns every pseudonym given, along with the original value
+func (a *Anonymizer) Mapping() map[string]string {
+	mapping := map[string]string{}
+	for _, pseudonyms := range a.pseudonyms {
+		for original, p := range pseudonyms {
+			mapping[p] = original
+		}
+	}
+	return mapping
+}
+
+// replaceSubmatches calls replace for each submatch, keeping the rest of the matched text
+func replaceSubmatches(r *regexp.Regexp, s string, replace func(int, string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range r.FindAllStringSubmatchIndex(s, -1) {
+		for i := 1; i < len(loc)/2; i++ {
+			start, end := loc[2*i], loc[2*i+1]
+			if start < 0 {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(replace(i, s[start:end]))
+			last = end
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnonymize(t *testing.T) {
+	a := NewAnonymizer()
+	a.AddNames("node1", "node1-backup")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "node1 joined from 172.17.0.2",
+			expected: "anon-node-1 joined from 192.0.2.1",
+		},
+		{
+			input:    "node1-backup, node10 and 172.17.0.2 listening on 0.0.0.0:4567",
+			expected: "anon-node-2, node10 and 192.0.2.1 listening on 0.0.0.0:4567",
+		},
+		{
+			input:    Paint(GreenText, "node1") + " synced",
+			expected: Paint(GreenText, "anon-node-1") + " synced",
+		},
+		{
+			input:    "Could not execute Write_rows event on table shop.orders; Duplicate entry in `shop`.`customers`",
+			expected: "Could not execute Write_rows event on table anon_schema_1.anon_table_1; Duplicate entry in `anon_schema_1`.`anon_table_2`",
+		},
+		{
+			// already anonymized values are kept, eg: when anonymizing a dump that includes anonymized contexts
+			input:    "anon-node-1 joined from 192.0.2.1, then 172.17.0.3 in table anon_schema_1.anon_table_1",
+			expected: "anon-node-1 joined from 192.0.2.1, then 192.0.2.2 in table anon_schema_1.anon_table_1",
+		},
+	}
+
+	for _, test := range tests {
+		if out := a.Anonymize(test.input); out != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, out)
+		}
+	}
+
+	expectedMapping := map[string]string{
+		"anon-node-1":   "node1",
+		"anon-node-2":   "node1-backup",
+		"192.0.2.1":     "172.17.0.2",
+		"192.0.2.2":     "172.17.0.3",
+		"anon_schema_1": "shop",
+		"anon_table_1":  "orders",
+		"anon_table_2":  "customers",
+	}
+	if mapping := a.Mapping(); !reflect.DeepEqual(mapping, expectedMapping) {
+		t.Errorf("expected mapping %v, got %v", expectedMapping, mapping)
+	}
+}
+
+func TestPseudonymIP(t *testing.T) {
+	tests := map[int]string{
+		1:   "192.0.2.1",
+		254: "192.0.2.254",
+		255: "198.51.100.1",
+		762: "203.0.113.254",
+		763: "198.18.0.1",
+	}
+	for n, expected := range tests {
+		if ip := pseudonymIP(n); ip != expected {
+			t.Errorf("expected %s for %d, got %s", expected, n, ip)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"k8s.io/utils/net"
 )
@@ -32,10 +34,23 @@ const (
 	BrightWhiteText         = "\x1b[1;37m"
 )
 
-var colorsToTextColor = map[string]Color{
-	"yellow": YellowText,
-	"green":  GreenText,
-	"red":    RedText,
+// ColorsByName are the colors users can choose, eg: in themes
+var ColorsByName = map[string]Color{
+	"default":        DefaultText,
+	"red":            RedText,
+	"green":          GreenText,
+	"yellow":         YellowText,
+	"blue":           BlueText,
+	"magenta":        MagentaText,
+	"cyan":           CyanText,
+	"white":          WhiteText,
+	"bright-red":     BrightRedText,
+	"bright-green":   BrightGreenText,
+	"bright-yellow":  BrightYellowText,
+	"bright-blue":    BrightBlueText,
+	"bright-magenta": BrightMagentaText,
+	"bright-cyan":    BrightCyanText,
+	"bright-white":   BrightWhiteText,
 }
 
 var SkipColor bool
@@ -52,11 +67,24 @@ func Paint(color Color, value string) string {
 	return fmt.Sprintf("%v%v%v", color, value, ResetText)
 }
 
+var colorRegex = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// RemoveColor strips color special characters
+// Some messages are painted as soon as logs are handled, so SkipColor is not always enough
+func RemoveColor(s string) string {
+	return colorRegex.ReplaceAllString(s, "")
+}
+
+// ColorLocations returns the start and end indexes of the color special characters RemoveColor strips
+func ColorLocations(s string) [][]int {
+	return colorRegex.FindAllStringIndex(s, -1)
+}
+
 func PaintForState(text, state string) string {
 
 	c := ColorForState(state)
 	if c != "" {
-		return Paint(colorsToTextColor[c], text)
+		return Paint(ColorsByName[c], text)
 	}
 
 	return text
@@ -84,6 +112,16 @@ func SliceContains(s []string, str string) bool {
 	return false
 }
 
+// SliceIntersects returns true if both slices have at least one element in common
+func SliceIntersects(s, s2 []string) bool {
+	for _, str := range s2 {
+		if SliceContains(s, str) {
+			return true
+		}
+	}
+	return false
+}
+
 func SliceMergeDeduplicate(s, s2 []string) []string {
 	for _, str := range s2 {
 		if !SliceContains(s, str) {
@@ -130,3 +168,31 @@ func ShortNodeName(s string) string {
 	before, _, _ := strings.Cut(s, ".")
 	return before
 }
+
+// Truncate shortens s to width runes, ending with "..." to show something is missing
+func Truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width > 3 {
+		return string(runes[:width-3]) + "..."
+	}
+	return string(runes[:width])
+}
+
+// IsDamagedLine tells if a log line has NUL bytes or invalid utf-8, as left by crashes or full disks
+func IsDamagedLine(line string) bool {
+	return strings.IndexByte(line, 0) >= 0 || !utf8.ValidString(line)
+}
+
+// SanitizeLine removes NUL bytes and truncated multi-byte sequences, as they are left by crashes and would break regexes
+func SanitizeLine(s string) string {
+	if IsDamagedLine(s) {
+		s = strings.ToValidUTF8(strings.ReplaceAll(s, "\x00", ""), "")
+	}
+	if len(s) > 0 && s[0] == '\t' {
+		return s[1:]
+	}
+	return s
+}
@@ -40,3 +40,61 @@ func TestStringsReplaceReverse(t *testing.T) {
 		}
 	}
 }
+
+func TestRemoveColor(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    Paint(RedText, "shutdown complete"),
+			expected: "shutdown complete",
+		},
+		{
+			input:    "node1 " + Paint(BrightGreenText, "SYNCED") + " -> " + Paint(YellowText, "DONOR"),
+			expected: "node1 SYNCED -> DONOR",
+		},
+		{
+			input:    "no color",
+			expected: "no color",
+		},
+	}
+	for _, test := range tests {
+		if s := RemoveColor(test.input); s != test.expected {
+			t.Log("Expected", test.expected, "got", s)
+			t.Fail()
+		}
+	}
+}
+
+func TestSanitizeLine(t *testing.T) {
+	tests := map[string]string{
+		"\t2023-03-12T07:35:00.000000Z 0 [Note] WSREP: Synchronized with group, ready for connections": "2023-03-12T07:35:00.000000Z 0 [Note] WSREP: Synchronized with group, ready for connections",
+		"\x00\x002023-03-12T07:35:00.000000Z 0 [Note] WSREP: Synchronized":                             "2023-03-12T07:35:00.000000Z 0 [Note] WSREP: Synchronized",
+		"2023-03-12T07:35:00.000000Z 0 [Note] WSREP: truncated \xe2\x82":                               "2023-03-12T07:35:00.000000Z 0 [Note] WSREP: truncated ",
+	}
+	for input, expected := range tests {
+		if out := SanitizeLine(input); out != expected {
+			t.Errorf("SanitizeLine(%q): expected %q, got %q", input, expected, out)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		expected string
+	}{
+		{input: "select 1", width: 10, expected: "select 1"},
+		{input: "select * from t1", width: 10, expected: "select ..."},
+		{input: "select 'héhéhé'", width: 12, expected: "select 'h..."},
+		{input: "select 'ééééé'", width: 13, expected: "select 'éé..."},
+		{input: "select", width: 2, expected: "se"},
+	}
+	for _, test := range tests {
+		if out := Truncate(test.input, test.width); out != test.expected {
+			t.Errorf("Truncate(%q, %d): expected %q, got %q", test.input, test.width, test.expected, out)
+		}
+	}
+}
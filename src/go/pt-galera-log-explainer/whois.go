@@ -1,94 +1,231 @@
 package main
 
-/*
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/regex"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/utils"
+	"github.com/pkg/errors"
+)
+
 type whois struct {
-	Search string   `arg:"" name:"search" help:"the identifier (node name, ip, uuid, hash) to search"`
+	Search string   `arg:"" name:"search" help:"the identifier to search: node name, hostname, IP, node UUID or hash"`
 	Paths  []string `arg:"" name:"paths" help:"paths of the log to use"`
+	Json   bool     `help:"Print the result as JSON"`
+	Hosts  string   `help:"File in /etc/hosts format, used to link IPs and hostnames that logs alone cannot link"`
+	DNS    bool     `name:"dns" help:"Resolve IPs and hostnames using DNS"`
 }
 
 func (w *whois) Help() string {
-	return `Take any type of info pasted from error logs and find out about it.
-It will list known node name(s), IP(s), hostname(s), and other known node's UUIDs.
-`
+	return fmt.Sprintf(`Take any type of info pasted from error logs and find out about it
+	It will list the timeline columns, node names, IPs, hostnames and node UUIDs known for the node
+
+Usage:
+	%[1]s whois 218469b2 *.log
+	%[1]s whois --json 172.17.0.3 *.log
+	%[1]s whois --hosts /etc/hosts --dns db1.example.com *.log
+	`, toolname)
 }
 
 func (w *whois) Run() error {
 
-	toCheck := regex.AllRegexes()
-	timeline, err := timelineFromPaths(CLI.Whois.Paths, toCheck)
+	resolver := hostResolver{dns: w.DNS}
+	if w.Hosts != "" {
+		f, err := os.Open(w.Hosts)
+		if err != nil {
+			return errors.Wrap(err, "could not read hosts file")
+		}
+		resolver.hosts, err = parseHostsFile(f)
+		f.Close()
+		if err != nil {
+			return errors.Wrap(err, "could not read hosts file")
+		}
+	}
+
+	timeline, err := timelineFromPaths(w.Paths, regex.AllRegexes())
 	if err != nil {
 		return errors.Wrap(err, "found nothing to translate")
 	}
-	ctxs := timeline.GetLatestContextsByNodes()
 
-	ni := whoIs(ctxs, CLI.Whois.Search)
+	ni := whoIs(timeline.GetLatestContextsByNodes(), w.Search, resolver)
+	if len(ni.Nodes)+len(ni.NodeNames)+len(ni.IPs)+len(ni.Hostnames)+len(ni.NodeUUIDs) == 0 {
+		return errors.Errorf("no information found about %s", w.Search)
+	}
 
-	json, err := json.MarshalIndent(ni, "", "\t")
-	if err != nil {
-		return err
+	if w.Json {
+		out, err := json.MarshalIndent(ni, "", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
 	}
-	fmt.Println(string(json))
+	fmt.Print(whoisText(ni))
 	return nil
 }
 
-func whoIs(ctxs map[string]types.LogCtx, search string) types.NodeInfo {
-		ni := types.NodeInfo{Input: search}
-		if regex.IsNodeUUID(search) {
-			search = utils.UUIDToShortUUID(search)
+// whoIs gathers every identifiers linked to the search, until nothing new is found:
+// a hash gives an IP, which gives a hostname, which gives another node name, ...
+func whoIs(ctxs map[string]types.LogCtx, search string, resolver hostResolver) types.NodeInfo {
+	ni := types.NodeInfo{Input: search, Nodes: []string{}, NodeNames: []string{}, IPs: []string{}, Hostnames: []string{}, NodeUUIDs: []string{}}
+	if regex.IsNodeUUID(search) {
+		search = utils.UUIDToShortUUID(search)
+	}
+
+	nodes := make([]string, 0, len(ctxs))
+	knownHashes, knownNames := translate.Hashes(), translate.NodeNames()
+	for node, ctx := range ctxs {
+		nodes = append(nodes, node)
+		knownHashes = utils.SliceMergeDeduplicate(knownHashes, ctx.OwnHashes)
+		knownNames = utils.SliceMergeDeduplicate(knownNames, ctx.OwnNames)
+	}
+	sort.Strings(nodes)
+
+	pending := []string{search}
+	seen := map[string]bool{"": true}
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+		if seen[id] {
+			continue
 		}
-		var (
-			ips       []string
-			hashes    []string
-			nodenames []string
-		)
-			for _, ctx := range ctxs {
-				if utils.SliceContains(ctx.OwnNames, search) || utils.SliceContains(ctx.OwnHashes, search) || utils.SliceContains(ctx.OwnIPs, search) {
-					ni.NodeNames = ctx.OwnNames
-					ni.NodeUUIDs = ctx.OwnHashes
-					ni.IPs = ctx.OwnIPs
-					ni.Hostname = ctx.OwnHostname()
+		seen[id] = true
+		related := []string{}
+
+		if net.ParseIP(id) != nil {
+			ni.IPs = utils.SliceMergeDeduplicate(ni.IPs, []string{id})
+			related = append(related, translate.NodeNamesFromIP(id)...)
+			// IPs are reused between pods on k8s, they cannot link hashes
+			if translate.AssumeIPStable {
+				related = append(related, translate.HashesFromIP(id)...)
+			}
+			hostnames := resolver.namesOf(id)
+			ni.Hostnames = utils.SliceMergeDeduplicate(ni.Hostnames, hostnames)
+			related = append(related, hostnames...)
+		} else {
+			// hashes are often printed truncated, eg: 218469b2 for 218469b2-8ab7
+			hashes := []string{}
+			for _, hash := range knownHashes {
+				if hash == id || strings.HasPrefix(hash, id+"-") {
+					hashes = append(hashes, hash)
 				}
+			}
+			for _, hash := range hashes {
+				ni.NodeUUIDs = utils.SliceMergeDeduplicate(ni.NodeUUIDs, []string{hash})
+				related = append(related, hash, translate.GetIPFromHash(hash))
+				related = append(related, translate.NodeNamesFromHash(hash)...)
+			}
 
-				if nodename, ok := ctx.HashToNodeName[search]; ok {
-					nodenames = utils.SliceMergeDeduplicate(nodenames, []string{nodename})
-					hashes = utils.SliceMergeDeduplicate(hashes, []string{search})
+			if name := utils.ShortNodeName(id); utils.SliceContains(knownNames, name) {
+				ni.NodeNames = utils.SliceMergeDeduplicate(ni.NodeNames, []string{name})
+				related = append(related, translate.HashesFromNodeName(name)...)
+				related = append(related, translate.IPsFromNodeName(name)...)
+			}
+
+			if len(hashes) == 0 {
+				if ips := resolver.addressesOf(id); len(ips) > 0 {
+					ni.Hostnames = utils.SliceMergeDeduplicate(ni.Hostnames, []string{id})
+					related = append(related, ips...)
+					related = append(related, utils.ShortNodeName(id))
 				}
+			}
+		}
+
+		for _, node := range nodes {
+			ctx := ctxs[node]
+			if node != id && !utils.SliceContains(ctx.OwnNames, id) && !utils.SliceContains(ctx.OwnHashes, id) &&
+				!(translate.AssumeIPStable && utils.SliceContains(ctx.OwnIPs, id)) {
+				continue
+			}
+			ni.Nodes = utils.SliceMergeDeduplicate(ni.Nodes, []string{node})
+			related = append(related, ctx.OwnNames...)
+			related = append(related, ctx.OwnHashes...)
+			related = append(related, ctx.OwnIPs...)
+		}
 
-				if ip, ok := ctx.HashToIP[search]; ok {
-					ips = utils.SliceMergeDeduplicate(ips, []string{ip})
-					hashes = utils.SliceMergeDeduplicate(hashes, []string{search})
+		pending = append(pending, related...)
+	}
 
-				} else if nodename, ok := ctx.IPToNodeName[search]; ok {
-					nodenames = utils.SliceMergeDeduplicate(nodenames, []string{nodename})
-					ips = utils.SliceMergeDeduplicate(ips, []string{search})
+	for _, s := range [][]string{ni.Nodes, ni.NodeNames, ni.IPs, ni.Hostnames, ni.NodeUUIDs} {
+		sort.Strings(s)
+	}
+	return ni
+}
 
-				} else if utils.SliceContains(ctx.AllNodeNames(), search) {
-					nodenames = utils.SliceMergeDeduplicate(nodenames, []string{search})
-				}
+func whoisText(ni types.NodeInfo) string {
+	var b strings.Builder
+	for _, field := range []struct {
+		label  string
+		values []string
+	}{
+		{label: "input", values: []string{ni.Input}},
+		{label: "nodes", values: ni.Nodes},
+		{label: "node names", values: ni.NodeNames},
+		{label: "IPs", values: ni.IPs},
+		{label: "hostnames", values: ni.Hostnames},
+		{label: "node UUIDs", values: ni.NodeUUIDs},
+	} {
+		b.WriteString(utils.Paint(utils.BlueText, fmt.Sprintf("%-12s", field.label+":")))
+		b.WriteString(strings.Join(field.values, ", "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
 
-				for _, nodename := range nodenames {
-					hashes = utils.SliceMergeDeduplicate(hashes, ctx.HashesFromNodeName(nodename))
-					ips = utils.SliceMergeDeduplicate(ips, ctx.IPsFromNodeName(nodename))
-				}
+// hostResolver links IPs and hostnames using a hosts file, and DNS when enabled
+type hostResolver struct {
+	// both ways: IP to hostnames, and hostname to IPs
+	hosts map[string][]string
+	dns   bool
+}
 
-				for _, ip := range ips {
-					hashes = utils.SliceMergeDeduplicate(hashes, ctx.HashesFromIP(ip))
-					nodename, ok := ctx.IPToNodeName[ip]
-					if ok {
-						nodenames = utils.SliceMergeDeduplicate(nodenames, []string{nodename})
-					}
-				}
-				for _, hash := range hashes {
-					nodename, ok := ctx.HashToNodeName[hash]
-					if ok {
-						nodenames = utils.SliceMergeDeduplicate(nodenames, []string{nodename})
-					}
-				}
-			}
-			ni.NodeNames = nodenames
-			ni.NodeUUIDs = hashes
-			ni.IPs = ips
-			return ni
-	return types.NodeInfo{}
+// parseHostsFile reads files using the /etc/hosts format: an IP followed by its hostnames, eg:
+//
+//	172.17.0.2	db1.example.com db1 # primary datacenter
+func parseHostsFile(r io.Reader) (map[string][]string, error) {
+	hosts := map[string][]string{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line, _, _ := strings.Cut(s.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+			continue
+		}
+		ip := fields[0]
+		for _, name := range fields[1:] {
+			hosts[ip] = utils.SliceMergeDeduplicate(hosts[ip], []string{name})
+			hosts[name] = utils.SliceMergeDeduplicate(hosts[name], []string{ip})
+		}
+	}
+	return hosts, s.Err()
+}
+
+func (r hostResolver) namesOf(ip string) []string {
+	names := append([]string{}, r.hosts[ip]...)
+	if r.dns {
+		// errors only mean nothing was found
+		found, _ := net.LookupAddr(ip)
+		for _, name := range found {
+			names = utils.SliceMergeDeduplicate(names, []string{strings.TrimSuffix(name, ".")})
+		}
+	}
+	return names
+}
+
+func (r hostResolver) addressesOf(hostname string) []string {
+	ips := append([]string{}, r.hosts[hostname]...)
+	if r.dns {
+		found, _ := net.LookupHost(hostname)
+		ips = utils.SliceMergeDeduplicate(ips, found)
+	}
+	return ips
 }
-*/
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/translate"
+	"github.com/percona/percona-toolkit/src/go/pt-galera-log-explainer/types"
+)
+
+func TestWhoIs(t *testing.T) {
+	translate.ResetDB()
+	defer translate.ResetDB()
+	ts := time.Date(2023, 3, 12, 7, 0, 0, 0, time.UTC)
+	translate.AddHashToIP("218469b2-8ab7", "172.17.0.3", ts)
+	translate.AddHashToNodeName("218469b2-8ab7", "galera-node2", ts)
+	translate.AddHashToIP("259b78a0-9c1f", "172.17.0.3", ts.Add(time.Hour))
+	translate.AddIPToNodeName("172.17.0.2", "galera-node1", ts)
+
+	ctxs := map[string]types.LogCtx{
+		"node1": {OwnNames: []string{"galera-node1"}, OwnIPs: []string{"172.17.0.2"}, OwnHashes: []string{"1d3ea8f5-aa9c"}},
+		"node2": {OwnNames: []string{"galera-node2"}, OwnIPs: []string{"172.17.0.3"}},
+	}
+
+	hosts, err := parseHostsFile(strings.NewReader("# cluster\n172.17.0.3\tdb2.example.com db2 # second node\n::1 localhost\ninvalid line\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := hostResolver{hosts: hosts}
+
+	node2 := types.NodeInfo{
+		Nodes:     []string{"node2"},
+		NodeNames: []string{"galera-node2"},
+		IPs:       []string{"172.17.0.3"},
+		Hostnames: []string{"db2", "db2.example.com"},
+		NodeUUIDs: []string{"218469b2-8ab7", "259b78a0-9c1f"},
+	}
+
+	tests := []struct {
+		search   string
+		resolver hostResolver
+		expected types.NodeInfo
+	}{
+		{search: "218469b2", resolver: resolver, expected: node2},
+		{search: "218469b2-1111-2222-8ab7-123456789012", resolver: resolver, expected: node2},
+		{search: "172.17.0.3", resolver: resolver, expected: node2},
+		{search: "galera-node2", resolver: resolver, expected: node2},
+		{search: "db2.example.com", resolver: resolver, expected: node2},
+		{search: "node1", expected: types.NodeInfo{
+			Nodes:     []string{"node1"},
+			NodeNames: []string{"galera-node1"},
+			IPs:       []string{"172.17.0.2"},
+			Hostnames: []string{},
+			NodeUUIDs: []string{"1d3ea8f5-aa9c"},
+		}},
+		{search: "db2.example.com", expected: types.NodeInfo{Nodes: []string{}, NodeNames: []string{}, IPs: []string{}, Hostnames: []string{}, NodeUUIDs: []string{}}},
+	}
+
+	for _, test := range tests {
+		test.expected.Input = test.search
+		if diff := cmp.Diff(test.expected, whoIs(ctxs, test.search, test.resolver)); diff != "" {
+			t.Errorf("%s: unexpected result (-want +got):\n%s", test.search, diff)
+		}
+	}
+}
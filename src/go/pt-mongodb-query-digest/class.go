@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// classOther is the label of the operations that are neither in a transaction nor retryable writes.
+const classOther = "other"
+
+// classTotals sums up the operations of a class, so that the latency of the transactions and retryable writes
+// can be compared to the one of the other operations.
+type classTotals struct {
+	Class     string  `json:"class"`
+	Count     int     `json:"count"`
+	QueryTime float64 `json:"query_time_ms"` // total
+	Aborted   int     `json:"aborted"`
+	Conflicts int     `json:"write_conflict_errors"`
+}
+
+// AvgQueryTime returns the average execution time of the operations of the class, in milliseconds.
+func (c classTotals) AvgQueryTime() float64 {
+	if c.Count == 0 {
+		return 0
+	}
+
+	return c.QueryTime / float64(c.Count)
+}
+
+// getClassTotals returns the totals of the other operations, the transactions and the retryable writes, or
+// nil when all the operations are of the other class.
+func getClassTotals(queries []stats.QueryStats) []classTotals {
+	classes := []classTotals{{Class: classOther}, {Class: proto.ClassTransaction}, {Class: proto.ClassRetryableWrite}}
+	classified := false
+	for _, qs := range queries {
+		i := 0
+		switch qs.Class {
+		case proto.ClassTransaction:
+			i = 1
+		case proto.ClassRetryableWrite:
+			i = 2
+		}
+		if i > 0 {
+			classified = true
+		}
+
+		classes[i].Count += qs.Count
+		classes[i].QueryTime += qs.QueryTime.Total
+		classes[i].Aborted += qs.Aborted
+		classes[i].Conflicts += qs.Conflicts
+	}
+	if !classified {
+		return nil
+	}
+
+	return classes
+}
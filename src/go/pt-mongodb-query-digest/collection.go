@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionSpec is a collection the digests are stored in, like the ones of --review and --history: a
+// collection of the server being digested, or of the server of the URI.
+type collectionSpec struct {
+	URI        string
+	Database   string
+	Collection string
+}
+
+// parseCollectionSpec parses db.collection or mongodb://host:port/db.collection?options.
+func parseCollectionSpec(spec string) (collectionSpec, error) {
+	cs := collectionSpec{}
+	ns := spec
+
+	if i := strings.Index(spec, "://"); i >= 0 {
+		slash := strings.Index(spec[i+3:], "/")
+		if slash < 0 {
+			return cs, errors.Errorf("no db.collection in %q", spec)
+		}
+		slash += i + 3
+
+		ns = spec[slash+1:]
+		query := ""
+		if q := strings.Index(ns, "?"); q >= 0 {
+			ns, query = ns[:q], ns[q:]
+		}
+		cs.URI = spec[:slash] + "/" + query
+	}
+
+	dot := strings.Index(ns, ".")
+	if dot <= 0 || dot == len(ns)-1 {
+		return cs, errors.Errorf("invalid collection %q, must be db.collection", ns)
+	}
+	cs.Database, cs.Collection = ns[:dot], ns[dot+1:]
+
+	return cs, nil
+}
+
+// connectCollection connects to the server of the collection spec, which must be closed by disconnecting
+// the returned client.
+func connectCollection(ctx context.Context, opts *cliOptions, spec string) (*mongo.Client, *mongo.Collection, error) {
+	cs, err := parseCollectionSpec(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var clientOptions *options.ClientOptions
+	if cs.URI != "" {
+		clientOptions = options.Client().ApplyURI(cs.URI)
+	} else if clientOptions, err = getClientOptions(opts); err != nil {
+		return nil, nil, err
+	}
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot connect to the server of %s", spec)
+	}
+
+	return client, client.Database(cs.Database).Collection(cs.Collection), nil
+}
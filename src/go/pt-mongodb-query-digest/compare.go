@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/howeyc/gopass"
+	"github.com/pborman/getopt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	compareCommand = "compare"
+
+	DEFAULT_COMPARE_THRESHOLD = 20 // % of the average execution time
+)
+
+type compareOptions struct {
+	*cliOptions
+	Before    string
+	After     string
+	Source    string
+	Threshold int
+}
+
+// period is a time range of the history, the end excluded.
+type period struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+func (p period) String() string {
+	return p.From.Format(time.RFC3339) + " to " + p.To.Format(time.RFC3339)
+}
+
+// fingerprintPeriod is the aggregate of the history entries of a fingerprint in a period.
+type fingerprintPeriod struct {
+	ID          string
+	Namespace   string
+	Operation   string
+	Fingerprint string
+	Count       int
+	QueryTime   float64 // total, in milliseconds
+	QPS         float64 // of a digest, see diffDigests
+}
+
+func (fp fingerprintPeriod) avg() float64 {
+	if fp.Count == 0 {
+		return 0
+	}
+
+	return fp.QueryTime / float64(fp.Count)
+}
+
+type fingerprintChange struct {
+	ID          string  `json:"id"`
+	Namespace   string  `json:"namespace"`
+	Operation   string  `json:"operation"`
+	Fingerprint string  `json:"fingerprint"`
+	CountBefore int     `json:"count_before"`
+	CountAfter  int     `json:"count_after"`
+	AvgBefore   float64 `json:"avg_query_time_ms_before"`
+	AvgAfter    float64 `json:"avg_query_time_ms_after"`
+	ChangePct   float64 `json:"change_pct"`
+	// averaged 0 ms before, as operations under 1 ms are profiled as 0 ms: ChangePct cannot be computed
+	NewLatency bool `json:"new_latency,omitempty"`
+
+	CountChangePct float64 `json:"count_change_pct,omitempty"` // with the diff subcommand
+}
+
+type comparison struct {
+	Before       period              `json:"before"`
+	After        period              `json:"after"`
+	ThresholdPct float64             `json:"threshold_pct"`
+	Slower       []fingerprintChange `json:"slower"`
+	Faster       []fingerprintChange `json:"faster"`
+	Appeared     []fingerprintChange `json:"appeared"`
+	Disappeared  []fingerprintChange `json:"disappeared"`
+}
+
+// runCompare runs the compare subcommand, reporting the fingerprints of the --history collection that got
+// slower or faster, appeared or disappeared between two periods. It returns the exit code.
+func runCompare(args []string) int {
+	opts, err := getCompareOptions(args)
+	if err != nil {
+		log.Errorf("error processing command line arguments: %s", err)
+		return 1
+	}
+	if opts == nil {
+		return 0
+	}
+
+	before, err := parsePeriod(opts.Before)
+	if err != nil {
+		log.Errorf("invalid --before: %s", err)
+		return 1
+	}
+	after, err := parsePeriod(opts.After)
+	if err != nil {
+		log.Errorf("invalid --after: %s", err)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	client, coll, err := connectCollection(ctx, opts.cliOptions, opts.History)
+	if err != nil {
+		log.Errorf("Cannot connect to the history collection: %s", err)
+		return 2
+	}
+	defer client.Disconnect(ctx) //nolint
+
+	fpBefore, err := readHistoryPeriod(ctx, coll, before, opts.Source)
+	if err != nil {
+		log.Error(err)
+		return 3
+	}
+	fpAfter, err := readHistoryPeriod(ctx, coll, after, opts.Source)
+	if err != nil {
+		log.Error(err)
+		return 3
+	}
+
+	c := compareFingerprints(fpBefore, fpAfter, float64(opts.Threshold))
+	c.Before, c.After = before, after
+
+	var out []byte
+	if opts.Output == "json" {
+		out, err = json.MarshalIndent(c, "", "    ")
+	} else {
+		out, err = formatComparison(c)
+	}
+	if err != nil {
+		log.Errorf("Cannot format the comparison: %s", err)
+		return 4
+	}
+
+	fmt.Println(string(out))
+
+	return 0
+}
+
+func getCompareOptions(args []string) (*compareOptions, error) {
+	opts := &compareOptions{
+		cliOptions: &cliOptions{
+			Host:     DEFAULT_HOST,
+			LogLevel: DEFAULT_LOGLEVEL,
+			AuthDB:   DEFAULT_AUTHDB,
+			Output:   "text",
+		},
+		Threshold: DEFAULT_COMPARE_THRESHOLD,
+	}
+
+	gop := getopt.New()
+	gop.BoolVarLong(&opts.Help, "help", '?', "Show help")
+	gop.StringVarLong(&opts.History, "history", 0, "History collection: db.collection of the server or "+
+		"mongodb://host:port/db.collection")
+	gop.StringVarLong(&opts.Before, "before", 0, "First period, as from,to dates or RFC3339 times. The to date is included")
+	gop.StringVarLong(&opts.After, "after", 0, "Second period, as from,to dates or RFC3339 times. The to date is included")
+	gop.StringVarLong(&opts.Source, "source", 0, "Only compare the runs of this source, host/database or log files")
+	gop.IntVarLong(&opts.Threshold, "threshold", 0, "Change of the average execution time, in %, reporting a "+
+		"fingerprint as slower or faster. Default: 20")
+	gop.StringVarLong(&opts.Output, "output", 0, "Output: text, json. Default: text")
+	gop.StringVarLong(&opts.AuthDB, "authenticationDatabase", 'a', "Database to use for optional MongoDB authentication. Default: admin")
+	gop.StringVarLong(&opts.LogLevel, "log-level", 'l', "Log level: panic, fatal, error, warn, info, debug. Default: warn")
+	gop.StringVarLong(&opts.Password, "password", 'p', "Password to use for optional MongoDB authentication").SetOptional()
+	gop.StringVarLong(&opts.User, "username", 'u', "Username to use for optional MongoDB authentication")
+
+	gop.SetProgram(toolname + " " + compareCommand)
+	gop.SetParameters("host[:port]")
+
+	gop.Parse(args)
+	if gop.NArgs() > 0 {
+		opts.Host = gop.Arg(0)
+		gop.Parse(gop.Args())
+	}
+	if opts.Help {
+		gop.PrintUsage(os.Stdout)
+		return nil, nil
+	}
+
+	if gop.IsSet("password") && opts.Password == "" {
+		print("Password: ")
+		pass, err := gopass.GetPasswd()
+		if err != nil {
+			return nil, err
+		}
+		opts.Password = string(pass)
+	}
+
+	if logLevel, err := log.ParseLevel(opts.LogLevel); err == nil {
+		log.SetLevel(logLevel)
+	}
+
+	if opts.History == "" || opts.Before == "" || opts.After == "" {
+		return nil, errors.New("--history, --before and --after are required")
+	}
+	if _, err := parseCollectionSpec(opts.History); err != nil {
+		return nil, err
+	}
+	if opts.Output != "json" && opts.Output != "text" {
+		return nil, fmt.Errorf("invalid output %q", opts.Output)
+	}
+
+	if !strings.HasPrefix(opts.Host, "mongodb://") {
+		opts.Host = "mongodb://" + opts.Host
+	}
+
+	return opts, nil
+}
+
+// parsePeriod parses from,to. The dates without a time include the whole day.
+func parsePeriod(s string) (period, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return period{}, errors.Errorf("%q is not from,to", s)
+	}
+
+	p := period{}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if t, err := time.Parse(time.RFC3339, part); err == nil {
+			if i == 0 {
+				p.From = t
+			} else {
+				p.To = t
+			}
+			continue
+		}
+
+		t, err := time.ParseInLocation("2006-01-02", part, time.Local)
+		if err != nil {
+			return period{}, errors.Errorf("%q is neither a date nor an RFC3339 time", part)
+		}
+		if i == 0 {
+			p.From = t
+		} else {
+			p.To = t.AddDate(0, 0, 1)
+		}
+	}
+
+	if !p.From.Before(p.To) {
+		return period{}, errors.Errorf("%q ends before it starts", s)
+	}
+
+	return p, nil
+}
+
+func readHistoryPeriod(ctx context.Context, coll *mongo.Collection, p period, source string) (map[string]*fingerprintPeriod, error) {
+	filter := primitive.M{"run_at": primitive.M{"$gte": p.From, "$lt": p.To}}
+	if source != "" {
+		filter["source"] = source
+	}
+
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read the history of %s", p)
+	}
+
+	entries := []historyEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, errors.Wrapf(err, "cannot read the history of %s", p)
+	}
+
+	fps := make(map[string]*fingerprintPeriod)
+	for _, entry := range entries {
+		fp, ok := fps[entry.ID]
+		if !ok {
+			fp = &fingerprintPeriod{
+				ID:          entry.ID,
+				Namespace:   entry.Namespace,
+				Operation:   entry.Operation,
+				Fingerprint: entry.Fingerprint,
+			}
+			fps[entry.ID] = fp
+		}
+		fp.Count += entry.Count
+		fp.QueryTime += entry.QueryTimeTotal
+	}
+
+	return fps, nil
+}
+
+func compareFingerprints(before, after map[string]*fingerprintPeriod, threshold float64) comparison {
+	c := comparison{
+		ThresholdPct: threshold,
+		Slower:       []fingerprintChange{},
+		Faster:       []fingerprintChange{},
+		Appeared:     []fingerprintChange{},
+		Disappeared:  []fingerprintChange{},
+	}
+
+	for id, fpa := range after {
+		change := fingerprintChange{
+			ID:          id,
+			Namespace:   fpa.Namespace,
+			Operation:   fpa.Operation,
+			Fingerprint: fpa.Fingerprint,
+			CountAfter:  fpa.Count,
+			AvgAfter:    fpa.avg(),
+		}
+
+		fpb, ok := before[id]
+		if !ok {
+			c.Appeared = append(c.Appeared, change)
+			continue
+		}
+
+		change.CountBefore, change.AvgBefore = fpb.Count, fpb.avg()
+		if change.AvgBefore == 0 {
+			if change.AvgAfter > 0 {
+				change.NewLatency = true
+				c.Slower = append(c.Slower, change)
+			}
+			continue
+		}
+		change.ChangePct = (change.AvgAfter - change.AvgBefore) * 100 / change.AvgBefore
+
+		switch {
+		case change.ChangePct >= threshold:
+			c.Slower = append(c.Slower, change)
+		case change.ChangePct <= -threshold:
+			c.Faster = append(c.Faster, change)
+		}
+	}
+
+	for id, fpb := range before {
+		if _, ok := after[id]; !ok {
+			c.Disappeared = append(c.Disappeared, fingerprintChange{
+				ID:          id,
+				Namespace:   fpb.Namespace,
+				Operation:   fpb.Operation,
+				Fingerprint: fpb.Fingerprint,
+				CountBefore: fpb.Count,
+				AvgBefore:   fpb.avg(),
+			})
+		}
+	}
+
+	sort.Slice(c.Slower, func(i, j int) bool {
+		if c.Slower[i].NewLatency != c.Slower[j].NewLatency {
+			return c.Slower[i].NewLatency
+		}
+		if c.Slower[i].NewLatency {
+			return c.Slower[i].AvgAfter > c.Slower[j].AvgAfter
+		}
+		return c.Slower[i].ChangePct > c.Slower[j].ChangePct
+	})
+	sort.Slice(c.Faster, func(i, j int) bool { return c.Faster[i].ChangePct < c.Faster[j].ChangePct })
+	sort.Slice(c.Appeared, func(i, j int) bool { return c.Appeared[i].CountAfter > c.Appeared[j].CountAfter })
+	sort.Slice(c.Disappeared, func(i, j int) bool { return c.Disappeared[i].CountBefore > c.Disappeared[j].CountBefore })
+
+	return c
+}
+
+// changesTemplate is the list of fingerprint changes of the compare and diff subcommands.
+const changesTemplate = `
+{{- define "changes" }}
+{{- range . }}
+# {{.ID}}  {{.Namespace}}  {{.Operation}}
+#   Fingerprint: {{.Fingerprint}}
+#   Count {{.CountBefore}} -> {{.CountAfter}}{{ if .CountChangePct }} ({{printf "%+.0f" .CountChangePct}}%){{ end }}, avg exec time {{printf "%.2f" .AvgBefore}} ms -> {{printf "%.2f" .AvgAfter}} ms{{ if .NewLatency }} (new latency){{ else if .ChangePct }} ({{printf "%+.0f" .ChangePct}}%){{ end }}
+{{- else }}
+# none
+{{- end }}
+{{- end }}`
+
+const comparisonTemplate = `# Before: {{.Before}}
+# After:  {{.After}}
+
+# Slower (average exec time up {{printf "%.0f" .ThresholdPct}}% or more)
+{{- template "changes" .Slower }}
+
+# Faster (average exec time down {{printf "%.0f" .ThresholdPct}}% or more)
+{{- template "changes" .Faster }}
+
+# Appeared
+{{- template "changes" .Appeared }}
+
+# Disappeared
+{{- template "changes" .Disappeared }}
+`
+
+func formatComparison(c comparison) ([]byte, error) {
+	t, err := template.New("comparison").Parse(changesTemplate + comparisonTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, c); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
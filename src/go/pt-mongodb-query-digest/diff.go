@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/template"
+
+	"github.com/pborman/getopt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	diffCommand = "diff"
+
+	DEFAULT_DIFF_COUNT_THRESHOLD = 50 // % of the count per second
+)
+
+type diffOptions struct {
+	Help           bool
+	LogLevel       string
+	Output         string
+	Threshold      int
+	CountThreshold int
+}
+
+// digestDiff is the comparison of two digests saved with --output json, before and after a deployment.
+type digestDiff struct {
+	Old               string              `json:"old"`
+	New               string              `json:"new"`
+	ThresholdPct      float64             `json:"threshold_pct"`
+	CountThresholdPct float64             `json:"count_threshold_pct"`
+	Slower            []fingerprintChange `json:"slower"`
+	Faster            []fingerprintChange `json:"faster"`
+	MoreFrequent      []fingerprintChange `json:"more_frequent"`
+	LessFrequent      []fingerprintChange `json:"less_frequent"`
+	Appeared          []fingerprintChange `json:"appeared"`
+	Disappeared       []fingerprintChange `json:"disappeared"`
+}
+
+// runDiff runs the diff subcommand, reporting the fingerprints of two digests saved with --output json that
+// got slower or faster, more or less frequent, appeared or disappeared. It returns the exit code.
+func runDiff(args []string) int {
+	opts, files, err := getDiffOptions(args)
+	if err != nil {
+		log.Errorf("error processing command line arguments: %s", err)
+		return 1
+	}
+	if opts == nil {
+		return 0
+	}
+
+	digests := make([]jsonReport, len(files))
+	for i, filename := range files {
+		if digests[i], err = readDigest(filename); err != nil {
+			log.Error(err)
+			return 3
+		}
+	}
+
+	d := diffDigests(digests[0], digests[1], float64(opts.Threshold), float64(opts.CountThreshold))
+	d.Old, d.New = files[0], files[1]
+
+	var out []byte
+	if opts.Output == "json" {
+		out, err = json.MarshalIndent(d, "", "    ")
+	} else {
+		out, err = formatDiff(d)
+	}
+	if err != nil {
+		log.Errorf("Cannot format the diff: %s", err)
+		return 4
+	}
+
+	fmt.Println(string(out))
+
+	return 0
+}
+
+func getDiffOptions(args []string) (*diffOptions, []string, error) {
+	opts := &diffOptions{
+		LogLevel:       DEFAULT_LOGLEVEL,
+		Output:         "text",
+		Threshold:      DEFAULT_COMPARE_THRESHOLD,
+		CountThreshold: DEFAULT_DIFF_COUNT_THRESHOLD,
+	}
+
+	gop := getopt.New()
+	gop.BoolVarLong(&opts.Help, "help", '?', "Show help")
+	gop.IntVarLong(&opts.Threshold, "threshold", 0, "Change of the average execution time, in %, reporting a "+
+		"fingerprint as slower or faster. Default: 20")
+	gop.IntVarLong(&opts.CountThreshold, "count-threshold", 0, "Change of the count per second, in %, reporting "+
+		"a fingerprint as more or less frequent. Default: 50")
+	gop.StringVarLong(&opts.Output, "output", 0, "Output: text, json. Default: text")
+	gop.StringVarLong(&opts.LogLevel, "log-level", 'l', "Log level: panic, fatal, error, warn, info, debug. Default: warn")
+
+	gop.SetProgram(toolname + " " + diffCommand)
+	gop.SetParameters("old.json new.json")
+
+	gop.Parse(args)
+	if opts.Help {
+		gop.PrintUsage(os.Stdout)
+		return nil, nil, nil
+	}
+
+	if logLevel, err := log.ParseLevel(opts.LogLevel); err == nil {
+		log.SetLevel(logLevel)
+	}
+
+	if gop.NArgs() != 2 {
+		return nil, nil, errors.New("the old and new digests, saved with --output json, are required")
+	}
+	if opts.Threshold <= 0 || opts.CountThreshold <= 0 {
+		return nil, nil, fmt.Errorf("invalid --threshold %d or --count-threshold %d", opts.Threshold,
+			opts.CountThreshold)
+	}
+	if opts.Output != "json" && opts.Output != "text" {
+		return nil, nil, fmt.Errorf("invalid output %q", opts.Output)
+	}
+
+	return opts, gop.Args(), nil
+}
+
+// readDigest reads a digest saved with --output json.
+func readDigest(filename string) (jsonReport, error) {
+	var jr jsonReport
+
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return jr, errors.Wrapf(err, "cannot read %s", filename)
+	}
+	if err := json.Unmarshal(buf, &jr); err != nil {
+		return jr, errors.Wrapf(err, "cannot parse %s", filename)
+	}
+	if jr.Queries == nil {
+		return jr, errors.Errorf("%s is not a digest saved with --output json", filename)
+	}
+
+	return jr, nil
+}
+
+// digestFingerprints returns the fingerprints of a digest by ID, the operations past --max-fingerprints
+// excluded.
+func digestFingerprints(jr jsonReport) map[string]*fingerprintPeriod {
+	fps := make(map[string]*fingerprintPeriod)
+	for _, q := range jr.Queries {
+		if q.ID == "" {
+			continue
+		}
+		fps[q.ID] = &fingerprintPeriod{
+			ID:          q.ID,
+			Namespace:   q.Namespace,
+			Operation:   q.Operation,
+			Fingerprint: q.Fingerprint,
+			Count:       q.Count,
+			QueryTime:   q.QueryTimeMs.Total,
+			QPS:         q.QPS,
+		}
+	}
+
+	return fps
+}
+
+// diffDigests compares the fingerprints of two digests. The counts are compared by second when both digests
+// know the time range of their operations, so that digests of different lengths can be compared.
+func diffDigests(oldDigest, newDigest jsonReport, threshold, countThreshold float64) digestDiff {
+	before, after := digestFingerprints(oldDigest), digestFingerprints(newDigest)
+	byRate := oldDigest.UptimeSeconds > 0 && newDigest.UptimeSeconds > 0
+
+	c := compareFingerprints(before, after, threshold)
+	d := digestDiff{
+		ThresholdPct:      threshold,
+		CountThresholdPct: countThreshold,
+		Slower:            c.Slower,
+		Faster:            c.Faster,
+		MoreFrequent:      []fingerprintChange{},
+		LessFrequent:      []fingerprintChange{},
+		Appeared:          c.Appeared,
+		Disappeared:       c.Disappeared,
+	}
+
+	for _, changes := range [][]fingerprintChange{d.Slower, d.Faster} {
+		for i := range changes {
+			changes[i].CountChangePct = countChangePct(before[changes[i].ID], after[changes[i].ID], byRate)
+		}
+	}
+
+	for id, fpa := range after {
+		fpb, ok := before[id]
+		if !ok {
+			continue
+		}
+
+		change := fingerprintChange{
+			ID:             id,
+			Namespace:      fpa.Namespace,
+			Operation:      fpa.Operation,
+			Fingerprint:    fpa.Fingerprint,
+			CountBefore:    fpb.Count,
+			CountAfter:     fpa.Count,
+			AvgBefore:      fpb.avg(),
+			AvgAfter:       fpa.avg(),
+			CountChangePct: countChangePct(fpb, fpa, byRate),
+		}
+		if change.AvgBefore > 0 {
+			change.ChangePct = (change.AvgAfter - change.AvgBefore) * 100 / change.AvgBefore
+		}
+
+		switch {
+		case change.CountChangePct >= countThreshold:
+			d.MoreFrequent = append(d.MoreFrequent, change)
+		case change.CountChangePct <= -countThreshold:
+			d.LessFrequent = append(d.LessFrequent, change)
+		}
+	}
+
+	sort.Slice(d.MoreFrequent, func(i, j int) bool {
+		return d.MoreFrequent[i].CountChangePct > d.MoreFrequent[j].CountChangePct
+	})
+	sort.Slice(d.LessFrequent, func(i, j int) bool {
+		return d.LessFrequent[i].CountChangePct < d.LessFrequent[j].CountChangePct
+	})
+
+	return d
+}
+
+// countChangePct returns the change of the count per second of a fingerprint, or of its count.
+func countChangePct(before, after *fingerprintPeriod, byRate bool) float64 {
+	b, a := float64(before.Count), float64(after.Count)
+	if byRate {
+		b, a = before.QPS, after.QPS
+	}
+	if b == 0 {
+		return 0
+	}
+
+	return (a - b) * 100 / b
+}
+
+const diffTemplate = `# Old: {{.Old}}
+# New: {{.New}}
+
+# Slower (average exec time up {{printf "%.0f" .ThresholdPct}}% or more)
+{{- template "changes" .Slower }}
+
+# Faster (average exec time down {{printf "%.0f" .ThresholdPct}}% or more)
+{{- template "changes" .Faster }}
+
+# More frequent (count per second up {{printf "%.0f" .CountThresholdPct}}% or more)
+{{- template "changes" .MoreFrequent }}
+
+# Less frequent (count per second down {{printf "%.0f" .CountThresholdPct}}% or more)
+{{- template "changes" .LessFrequent }}
+
+# Appeared
+{{- template "changes" .Appeared }}
+
+# Disappeared
+{{- template "changes" .Disappeared }}
+`
+
+func formatDiff(d digestDiff) ([]byte, error) {
+	t, err := template.New("diff").Parse(changesTemplate + diffTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, d); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
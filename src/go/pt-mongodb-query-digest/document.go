@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// document is the report rendered by --output markdown and html, to attach to tickets and review meetings.
+type document struct {
+	Title      string
+	Headers    []string
+	Totals     reportQuery
+	Queries    []documentQuery
+	Indexes    []indexSuggestion
+	GroupLabel string
+	Evicted    int
+	Classes    []classTotals
+	Errors     []errorTotal
+	Heatmap    *heatmap
+}
+
+// documentQuery is a query of the document, with its sample query indented.
+type documentQuery struct {
+	reportQuery
+	Rank   int
+	Sample string
+}
+
+// documentMetric is a row of the statistics table of a query.
+type documentMetric struct {
+	Name  string
+	Stats stats.Statistics
+}
+
+func newDocument(rep report) document {
+	doc := document{
+		Title:      toolname + " report",
+		Totals:     reportQuery{QueryStats: rep.QueryTotals},
+		Indexes:    rep.Indexes,
+		GroupLabel: groupByLabel(rep.GroupBy),
+		Evicted:    rep.Evicted,
+		Classes:    rep.Classes,
+		Errors:     rep.Errors,
+		Heatmap:    rep.Heatmap,
+	}
+	for _, h := range rep.Headers {
+		doc.Headers = append(doc.Headers, strings.TrimSpace(h))
+	}
+
+	for i, qs := range rep.QueryStats {
+		doc.Queries = append(doc.Queries, documentQuery{
+			reportQuery: reportQuery{QueryStats: qs, Plan: rep.Plans[qs.ID], GroupLabel: doc.GroupLabel},
+			Rank:        i + 1,
+			Sample:      indentQuery(qs.Query),
+		})
+	}
+
+	return doc
+}
+
+// indentQuery returns the extended json of a sample query indented, or as is when it is not valid json.
+func indentQuery(query string) string {
+	buf := new(bytes.Buffer)
+	if err := json.Indent(buf, []byte(strings.TrimSpace(query)), "", "  "); err != nil {
+		return strings.TrimSpace(query)
+	}
+
+	return buf.String()
+}
+
+// Metrics returns the rows of the statistics table of the query.
+func (q reportQuery) Metrics() []documentMetric {
+	return []documentMetric{
+		{Name: "Exec time ms", Stats: q.QueryTime},
+		{Name: "Docs scanned", Stats: q.Scanned},
+		{Name: "Keys examined", Stats: q.KeysExamined},
+		{Name: "Docs returned", Stats: q.Returned},
+		{Name: "Bytes sent", Stats: q.ResponseLength},
+		{Name: "Write conflicts", Stats: q.WriteConflicts},
+	}
+}
+
+// formatDocument renders the report as a Markdown or an HTML document: a summary table of the queries, then
+// a section per query with its statistics, plan and sample query.
+func formatDocument(rep report, output string) ([]byte, error) {
+	doc := newDocument(rep)
+	buf := new(bytes.Buffer)
+
+	funcs := template.FuncMap{
+		"Format":    format,
+		"join":      strings.Join,
+		"sparkline": sparkline,
+		"code":      markdownCode,
+		"cell":      markdownCell,
+		"shade":     heatShade,
+		"heatAlpha": heatAlpha,
+		"errors":    errorsList,
+	}
+
+	if output == "html" {
+		t, err := htmltemplate.New("html").Funcs(htmltemplate.FuncMap(funcs)).Parse(getHTMLTemplate())
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(buf, doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	t, err := template.New("markdown").Funcs(funcs).Parse(getMarkdownTemplate())
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Execute(buf, doc); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// markdownCode returns s as inline code, fenced by more backticks than it contains.
+func markdownCode(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+
+	return fence + s + fence
+}
+
+// markdownCell escapes the pipes and the line breaks of a table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+func getMarkdownTemplate() string {
+	t := `# {{.Title}}
+{{ range .Headers }}
+- {{.}}
+{{- end }}
+
+## Summary
+
+| Rank | ID | Namespace | Operation | Count | QPS | Time % | Time total ms | Time avg ms | Time p95 ms | Docs scanned/returned | Fingerprint |
+|-----:|----|-----------|-----------|------:|----:|-------:|--------------:|------------:|------------:|----------------------:|-------------|
+{{- range .Queries }}
+| {{.Rank}} | {{ code .ID }} | {{ cell .Namespace }} | {{.Operation}} | {{.Count}} | {{printf "%.2f" .QPS}} | {{printf "%.0f" .QueryTime.Pct}} | {{printf "%.0f" .QueryTime.Total}} | {{printf "%.0f" .QueryTime.Avg}} | {{printf "%.0f" .QueryTime.Pct95}} | {{printf "%.2f" .Ratio}} | {{ cell (code .Fingerprint) }} |
+{{- end }}
+| | **Totals** | | | {{.Totals.Count}} | {{printf "%.2f" .Totals.QPS}} | 100 | {{printf "%.0f" .Totals.QueryTime.Total}} | {{printf "%.0f" .Totals.QueryTime.Avg}} | {{printf "%.0f" .Totals.QueryTime.Pct95}} | {{printf "%.2f" .Totals.Ratio}} | |
+{{- if .Evicted }}
+
+{{.Evicted}} operations of the fingerprints past ` + "`--max-fingerprints`" + ` are only counted in the totals.
+{{- end }}
+{{- if .Classes }}
+
+| Class | Count | Time total ms | Time avg ms | Aborted | Write conflict errors |
+|-------|------:|--------------:|------------:|--------:|----------------------:|
+{{- range .Classes }}
+| {{.Class}} | {{.Count}} | {{printf "%.0f" .QueryTime}} | {{printf "%.2f" .AvgQueryTime}} | {{.Aborted}} | {{.Conflicts}} |
+{{- end }}
+{{- end }}
+{{- if .Errors }}
+
+## Errors
+
+Operations ended in an error, by error code and fingerprint.
+
+| Error | Count | Fingerprints |
+|-------|------:|--------------|
+{{- range .Errors }}
+| {{ cell .Label }} | {{.Count}} |{{ range $i, $fp := .Fingerprints }}{{ if $i }},{{ end }} {{ code $fp.ID }} {{ cell $fp.Namespace }} {{$fp.Operation}} ({{$fp.Count}}){{ end }} |
+{{- end }}
+{{- end }}
+{{- with .Heatmap }}
+
+## Heatmap
+
+Percentage of the execution time by namespace and operation.
+
+| Namespace | Time total ms | Count |{{ range .Operations }} {{.}} |{{ end }}
+|-----------|--------------:|------:|{{ range .Operations }}------:|{{ end }}
+{{- range .Namespaces }}
+| {{ cell .Namespace }} | {{printf "%.0f" .QueryTime}} | {{.Count}} |{{ range .Cells }} {{ if .Count }}{{printf "%.1f%%" .Pct}} {{ shade . }}{{ else }}-{{ end }} |{{ end }}
+{{- end }}
+{{- end }}
+{{ range .Queries }}
+## Query {{.Rank}}: {{.Operation}} {{.Namespace}}
+
+- ID: {{ code .ID }}
+- Fingerprint: {{ code .Fingerprint }}
+{{- if .GroupLabel }}
+- {{.GroupLabel}}: {{ or .Group "-" }}
+{{- end }}
+{{- if .Class }}
+- Class: {{.Class}}{{ if .Aborted }}, {{.Aborted}} aborted ({{.Conflicts}} write conflict errors){{ end }}
+{{- end }}
+{{- if .Errors }}
+- Errors: {{ errors .Errors }}
+{{- end }}
+- Time range: {{.FirstSeen}} to {{.LastSeen}}
+{{- if .Shards }}
+- Shards: {{ join .Shards ", " }}
+{{- end }}
+- Count: {{.Count}}, {{printf "%.2f" .QPS}} QPS, docs scanned/returned {{printf "%.2f" .Ratio}}
+- Exec time percentiles:{{ range .QueryTimePercentiles }} {{.Label}} {{printf "%.0f" .Value}} ms{{ end }}
+- Exec time distribution: ` + "`1ms |{{ sparkline .QueryTimeHistogram }}| 10s+`" + `
+
+| Attribute | pct | total | min | max | avg | 95% | stddev | median |
+|-----------|----:|------:|----:|----:|----:|----:|-------:|-------:|
+{{- range .Metrics }}
+| {{.Name}} | {{ with .Stats }}{{printf "%.0f" .Pct}} | {{printf "%.0f" .Total}} | {{printf "%.0f" .Min}} | {{printf "%.0f" .Max}} | {{printf "%.2f" .Avg}} | {{printf "%.0f" .Pct95}} | {{printf "%.2f" .StdDev}} | {{printf "%.0f" .Median}} |{{ end }}
+{{- end }}
+{{- with .Plan }}
+
+Plan:
+{{ if .Error }}
+- cannot explain: {{.Error}}
+{{- else }}
+- {{ code (join .Stages " <- ") }}{{ if .Indexes }}, index {{ join .Indexes ", " }}{{ end }}
+- examined/returned: keys {{printf "%.2f" .KeysExaminedRatio}}, docs {{printf "%.2f" .DocsExaminedRatio}}
+{{- range .Advice }}
+- advice: {{.}}
+{{- end }}
+{{- end }}
+{{- end }}
+
+Sample query:
+
+~~~json
+{{.Sample}}
+~~~
+{{ end }}
+{{- if .Indexes }}
+## Index suggestions
+
+Indexes for the collection scans, with their fields ordered by equality, sort and range.
+
+| Namespace | Index | Operations | Queries |
+|-----------|-------|-----------:|--------:|
+{{- range .Indexes }}
+| {{ cell .Namespace }} | {{ cell (code .Spec) }} | {{.Operations}} | {{len .Fingerprints}} |
+{{- end }}
+{{ end -}}
+`
+	return t
+}
+
+func getHTMLTemplate() string {
+	t := `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; }
+td.num { text-align: right; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ul>
+{{- range .Headers }}
+<li>{{.}}</li>
+{{- end }}
+</ul>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Rank</th><th>ID</th><th>Namespace</th><th>Operation</th><th>Count</th><th>QPS</th><th>Time %</th><th>Time total ms</th><th>Time avg ms</th><th>Time p95 ms</th><th>Docs scanned/returned</th><th>Fingerprint</th></tr>
+{{- range .Queries }}
+<tr><td class="num"><a href="#query-{{.Rank}}">{{.Rank}}</a></td><td><code>{{.ID}}</code></td><td>{{.Namespace}}</td><td>{{.Operation}}</td><td class="num">{{.Count}}</td><td class="num">{{printf "%.2f" .QPS}}</td><td class="num">{{printf "%.0f" .QueryTime.Pct}}</td><td class="num">{{printf "%.0f" .QueryTime.Total}}</td><td class="num">{{printf "%.0f" .QueryTime.Avg}}</td><td class="num">{{printf "%.0f" .QueryTime.Pct95}}</td><td class="num">{{printf "%.2f" .Ratio}}</td><td><code>{{.Fingerprint}}</code></td></tr>
+{{- end }}
+<tr><th></th><th>Totals</th><th></th><th></th><td class="num">{{.Totals.Count}}</td><td class="num">{{printf "%.2f" .Totals.QPS}}</td><td class="num">100</td><td class="num">{{printf "%.0f" .Totals.QueryTime.Total}}</td><td class="num">{{printf "%.0f" .Totals.QueryTime.Avg}}</td><td class="num">{{printf "%.0f" .Totals.QueryTime.Pct95}}</td><td class="num">{{printf "%.2f" .Totals.Ratio}}</td><td></td></tr>
+</table>
+{{- if .Evicted }}
+<p>{{.Evicted}} operations of the fingerprints past <code>--max-fingerprints</code> are only counted in the totals.</p>
+{{- end }}
+{{- if .Classes }}
+<table>
+<tr><th>Class</th><th>Count</th><th>Time total ms</th><th>Time avg ms</th><th>Aborted</th><th>Write conflict errors</th></tr>
+{{- range .Classes }}
+<tr><td>{{.Class}}</td><td class="num">{{.Count}}</td><td class="num">{{printf "%.0f" .QueryTime}}</td><td class="num">{{printf "%.2f" .AvgQueryTime}}</td><td class="num">{{.Aborted}}</td><td class="num">{{.Conflicts}}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Errors }}
+
+<h2>Errors</h2>
+<p>Operations ended in an error, by error code and fingerprint.</p>
+<table>
+<tr><th>Error</th><th>Count</th><th>Fingerprints</th></tr>
+{{- range .Errors }}
+<tr><td>{{.Label}}</td><td class="num">{{.Count}}</td><td>{{ range $i, $fp := .Fingerprints }}{{ if $i }}, {{ end }}<code>{{$fp.ID}}</code> {{$fp.Namespace}} {{$fp.Operation}} ({{$fp.Count}}){{ end }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- with .Heatmap }}
+
+<h2>Heatmap</h2>
+<p>Percentage of the execution time by namespace and operation.</p>
+<table>
+<tr><th>Namespace</th><th>Time total ms</th><th>Count</th>{{ range .Operations }}<th>{{.}}</th>{{ end }}</tr>
+{{- range .Namespaces }}
+<tr><td>{{.Namespace}}</td><td class="num">{{printf "%.0f" .QueryTime}}</td><td class="num">{{.Count}}</td>{{ range .Cells }}{{ if .Count }}<td class="num" style="background: rgba(220, 53, 69, {{ heatAlpha . }})">{{printf "%.1f%%" .Pct}}</td>{{ else }}<td class="num">-</td>{{ end }}{{ end }}</tr>
+{{- end }}
+</table>
+{{- end }}
+{{ range .Queries }}
+<h2 id="query-{{.Rank}}">Query {{.Rank}}: {{.Operation}} {{.Namespace}}</h2>
+<ul>
+<li>ID: <code>{{.ID}}</code></li>
+<li>Fingerprint: <code>{{.Fingerprint}}</code></li>
+{{- if .GroupLabel }}
+<li>{{.GroupLabel}}: {{ or .Group "-" }}</li>
+{{- end }}
+{{- if .Class }}
+<li>Class: {{.Class}}{{ if .Aborted }}, {{.Aborted}} aborted ({{.Conflicts}} write conflict errors){{ end }}</li>
+{{- end }}
+{{- if .Errors }}
+<li>Errors: {{ errors .Errors }}</li>
+{{- end }}
+<li>Time range: {{.FirstSeen}} to {{.LastSeen}}</li>
+{{- if .Shards }}
+<li>Shards: {{ join .Shards ", " }}</li>
+{{- end }}
+<li>Count: {{.Count}}, {{printf "%.2f" .QPS}} QPS, docs scanned/returned {{printf "%.2f" .Ratio}}</li>
+<li>Exec time percentiles:{{ range .QueryTimePercentiles }} {{.Label}} {{printf "%.0f" .Value}} ms{{ end }}</li>
+<li>Exec time distribution: <code>1ms |{{ sparkline .QueryTimeHistogram }}| 10s+</code></li>
+</ul>
+<table>
+<tr><th>Attribute</th><th>pct</th><th>total</th><th>min</th><th>max</th><th>avg</th><th>95%</th><th>stddev</th><th>median</th></tr>
+{{- range .Metrics }}
+<tr><td>{{.Name}}</td>{{ with .Stats }}<td class="num">{{printf "%.0f" .Pct}}</td><td class="num">{{printf "%.0f" .Total}}</td><td class="num">{{printf "%.0f" .Min}}</td><td class="num">{{printf "%.0f" .Max}}</td><td class="num">{{printf "%.2f" .Avg}}</td><td class="num">{{printf "%.0f" .Pct95}}</td><td class="num">{{printf "%.2f" .StdDev}}</td><td class="num">{{printf "%.0f" .Median}}</td>{{ end }}</tr>
+{{- end }}
+</table>
+{{- with .Plan }}
+<p>Plan:</p>
+<ul>
+{{- if .Error }}
+<li>cannot explain: {{.Error}}</li>
+{{- else }}
+<li><code>{{ join .Stages " <- " }}</code>{{ if .Indexes }}, index {{ join .Indexes ", " }}{{ end }}</li>
+<li>examined/returned: keys {{printf "%.2f" .KeysExaminedRatio}}, docs {{printf "%.2f" .DocsExaminedRatio}}</li>
+{{- range .Advice }}
+<li>advice: {{.}}</li>
+{{- end }}
+{{- end }}
+</ul>
+{{- end }}
+<p>Sample query:</p>
+<pre><code>{{.Sample}}</code></pre>
+{{ end }}
+{{- if .Indexes }}
+<h2>Index suggestions</h2>
+<p>Indexes for the collection scans, with their fields ordered by equality, sort and range.</p>
+<table>
+<tr><th>Namespace</th><th>Index</th><th>Operations</th><th>Queries</th></tr>
+{{- range .Indexes }}
+<tr><td>{{.Namespace}}</td><td><code>{{.Spec}}</code></td><td class="num">{{.Operations}}</td><td class="num">{{len .Fingerprints}}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`
+	return t
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// errorNames are the names of the common error codes, for the logs of MongoDB before 4.4 that only record
+// the code.
+var errorNames = map[int]string{
+	2:     "BadValue",
+	11:    "UserNotFound",
+	13:    "Unauthorized",
+	24:    "LockTimeout",
+	43:    "CursorNotFound",
+	50:    "MaxTimeMSExpired",
+	112:   "WriteConflict",
+	121:   "DocumentValidationFailure",
+	189:   "PrimarySteppedDown",
+	251:   "NoSuchTransaction",
+	262:   "ExceededTimeLimit",
+	11000: "DuplicateKey",
+	11600: "InterruptedAtShutdown",
+	11601: "Interrupted",
+	11602: "InterruptedDueToReplStateChange",
+}
+
+// errorTotal is the count of the operations ended in an error, and of the fingerprints failing with it, since
+// the failed operations, often fast, rarely make the top of the reports ranked by duration.
+type errorTotal struct {
+	Code         int                `json:"code"`
+	Name         string             `json:"name,omitempty"`
+	Count        int                `json:"count"`
+	Fingerprints []errorFingerprint `json:"fingerprints"`
+}
+
+type errorFingerprint struct {
+	ID          string `json:"id"`
+	Namespace   string `json:"namespace"`
+	Operation   string `json:"operation"`
+	Fingerprint string `json:"fingerprint"`
+	Count       int    `json:"count"`
+}
+
+// errorName returns the name of the error, recorded or known from its code.
+func errorName(e stats.ErrorCount) string {
+	if e.Name != "" {
+		return e.Name
+	}
+
+	return errorNames[e.Code]
+}
+
+// errorLabel returns the code and the name of the error, like 11000 DuplicateKey.
+func errorLabel(e stats.ErrorCount) string {
+	name := errorName(e)
+	switch {
+	case e.Code == 0:
+		return name
+	case name == "":
+		return strconv.Itoa(e.Code)
+	}
+
+	return fmt.Sprintf("%d %s", e.Code, name)
+}
+
+// Label returns the code and the name of the error.
+func (t errorTotal) Label() string {
+	return errorLabel(stats.ErrorCount{Code: t.Code, Name: t.Name})
+}
+
+// errorsList returns the errors of a query, like 11000 DuplicateKey: 3, 50 MaxTimeMSExpired: 1.
+func errorsList(errs []stats.ErrorCount) string {
+	labels := make([]string, 0, len(errs))
+	for _, e := range errs {
+		labels = append(labels, fmt.Sprintf("%s: %d", errorLabel(e), e.Count))
+	}
+
+	return strings.Join(labels, ", ")
+}
+
+// getErrorTotals returns the errors of the queries by code, from the most frequent, or nil without failed
+// operation.
+func getErrorTotals(queries []stats.QueryStats) []errorTotal {
+	totals := map[string]*errorTotal{}
+	for _, qs := range queries {
+		for _, e := range qs.Errors {
+			key := fmt.Sprintf("%d/%s", e.Code, errorName(e))
+			t, ok := totals[key]
+			if !ok {
+				t = &errorTotal{Code: e.Code, Name: errorName(e), Fingerprints: []errorFingerprint{}}
+				totals[key] = t
+			}
+			t.Count += e.Count
+			t.Fingerprints = append(t.Fingerprints, errorFingerprint{
+				ID:          qs.ID,
+				Namespace:   qs.Namespace,
+				Operation:   qs.Operation,
+				Fingerprint: qs.Fingerprint,
+				Count:       e.Count,
+			})
+		}
+	}
+	if len(totals) == 0 {
+		return nil
+	}
+
+	errs := make([]errorTotal, 0, len(totals))
+	for _, t := range totals {
+		sort.Slice(t.Fingerprints, func(i, j int) bool {
+			if t.Fingerprints[i].Count != t.Fingerprints[j].Count {
+				return t.Fingerprints[i].Count > t.Fingerprints[j].Count
+			}
+			return t.Fingerprints[i].ID < t.Fingerprints[j].ID
+		})
+		errs = append(errs, *t)
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Count != errs[j].Count {
+			return errs[i].Count > errs[j].Count
+		}
+		if errs[i].Code != errs[j].Code {
+			return errs[i].Code < errs[j].Code
+		}
+		return errs[i].Name < errs[j].Name
+	})
+
+	return errs
+}
@@ -1,7 +1,10 @@
 package filter
 
 import (
+	"math/rand"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
 )
@@ -21,3 +24,76 @@ func NewFilterByCollection(collectionsToSkip []string) func(proto.SystemProfile)
 		return true
 	}
 }
+
+// NewFilterByDatabase keeps the documents of the database, for the sources that are not read from the
+// system.profile collection of a single database, like the logs.
+func NewFilterByDatabase(database string) func(proto.SystemProfile) bool {
+	return func(doc proto.SystemProfile) bool {
+		return strings.HasPrefix(doc.Ns, database+".")
+	}
+}
+
+// NewFilterByCollectionName keeps the documents of the collections, in any database.
+func NewFilterByCollectionName(collections []string) func(proto.SystemProfile) bool {
+	return func(doc proto.SystemProfile) bool {
+		parts := strings.SplitN(doc.Ns, ".", 2)
+		if len(parts) < 2 {
+			return false
+		}
+		for _, collection := range collections {
+			if parts[1] == collection {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NewFilterByOperation keeps the documents of the operation types, as named by the profiler: query,
+// getmore, insert, update, remove, command.
+func NewFilterByOperation(ops []string) func(proto.SystemProfile) bool {
+	return func(doc proto.SystemProfile) bool {
+		for _, op := range ops {
+			if doc.Op == op {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NewFilterByMinDuration keeps the operations that lasted at least the given milliseconds.
+func NewFilterByMinDuration(millis int) func(proto.SystemProfile) bool {
+	return func(doc proto.SystemProfile) bool {
+		return doc.Millis >= millis
+	}
+}
+
+// NewFilterByTimeRange keeps the operations that ran from since, included, to until, excluded. A zero time
+// leaves its end of the range open.
+func NewFilterByTimeRange(since, until time.Time) func(proto.SystemProfile) bool {
+	return func(doc proto.SystemProfile) bool {
+		if !since.IsZero() && doc.Ts.Before(since) {
+			return false
+		}
+		if !until.IsZero() && !doc.Ts.Before(until) {
+			return false
+		}
+		return true
+	}
+}
+
+// NewFilterBySampleRate keeps a random sample of the documents, each one with the probability rate. The
+// sample is the same from one run to the next for the same documents. It must be the last filter, for the
+// rate to apply to the documents kept by the other ones.
+func NewFilterBySampleRate(rate float64) func(proto.SystemProfile) bool {
+	var mu sync.Mutex
+	r := rand.New(rand.NewSource(1)) //nolint
+
+	return func(doc proto.SystemProfile) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return r.Float64() < rate
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+const (
+	groupByAppName = "appname"
+	groupByUser    = "user"
+	groupByClient  = "client"
+)
+
+// getGroupBy returns the function grouping the queries by the --group-by dimension, in addition to their
+// fingerprint. It is nil without a dimension.
+func getGroupBy(dimension string) (stats.GroupBy, error) {
+	switch dimension {
+	case "":
+		return nil, nil
+	case groupByAppName:
+		return func(doc proto.SystemProfile) string { return doc.AppName }, nil
+	case groupByUser:
+		return func(doc proto.SystemProfile) string { return doc.User }, nil
+	case groupByClient:
+		return clientHost, nil
+	}
+
+	return nil, fmt.Errorf("invalid --group-by %q", dimension)
+}
+
+// groupByLabel is the name of the --group-by dimension in the text report.
+func groupByLabel(dimension string) string {
+	switch dimension {
+	case groupByAppName:
+		return "App name"
+	case groupByUser:
+		return "User"
+	case groupByClient:
+		return "Client"
+	}
+
+	return ""
+}
+
+// clientHost returns the host of the client, the logs recording the port of its connection as well.
+func clientHost(doc proto.SystemProfile) string {
+	if host, _, err := net.SplitHostPort(doc.Client); err == nil {
+		return host
+	}
+
+	return doc.Client
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// heatmap rolls up the execution time of the fingerprints by namespace and operation, with --heatmap, to find
+// the collections burning the time before drilling into their queries.
+type heatmap struct {
+	Operations []string     `json:"operations"` // the columns, by descending execution time
+	Namespaces []heatmapRow `json:"namespaces"` // the rows, by descending execution time
+}
+
+// heatmapRow is the total of a namespace, and its cells by operation of the heatmap.
+type heatmapRow struct {
+	Namespace string `json:"namespace"`
+	heatmapCell
+	Cells []heatmapCell `json:"cells"`
+}
+
+type heatmapCell struct {
+	Count     int     `json:"count"`
+	QueryTime float64 `json:"query_time_ms"`
+	Pct       float64 `json:"pct"` // of the execution time of all the namespaces
+}
+
+func (c *heatmapCell) add(qs stats.QueryStats) {
+	c.Count += qs.Count
+	c.QueryTime += qs.QueryTime.Total
+}
+
+// newHeatmap returns the heatmap of the fingerprints.
+func newHeatmap(queries []stats.QueryStats) *heatmap {
+	var total float64
+	opTimes := map[string]float64{}
+	rows := map[string]*heatmapRow{}
+	cells := map[string]map[string]*heatmapCell{}
+
+	for _, qs := range queries {
+		total += qs.QueryTime.Total
+		opTimes[qs.Operation] += qs.QueryTime.Total
+
+		row, ok := rows[qs.Namespace]
+		if !ok {
+			row = &heatmapRow{Namespace: qs.Namespace}
+			rows[qs.Namespace] = row
+			cells[qs.Namespace] = map[string]*heatmapCell{}
+		}
+		row.add(qs)
+
+		cell, ok := cells[qs.Namespace][qs.Operation]
+		if !ok {
+			cell = &heatmapCell{}
+			cells[qs.Namespace][qs.Operation] = cell
+		}
+		cell.add(qs)
+	}
+
+	h := &heatmap{Operations: []string{}, Namespaces: []heatmapRow{}}
+	for op := range opTimes {
+		h.Operations = append(h.Operations, op)
+	}
+	sort.Slice(h.Operations, func(i, j int) bool {
+		if opTimes[h.Operations[i]] != opTimes[h.Operations[j]] {
+			return opTimes[h.Operations[i]] > opTimes[h.Operations[j]]
+		}
+		return h.Operations[i] < h.Operations[j]
+	})
+
+	pct := func(queryTime float64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return queryTime * 100 / total
+	}
+
+	for ns, row := range rows {
+		row.Pct = pct(row.QueryTime)
+		for _, op := range h.Operations {
+			cell := heatmapCell{}
+			if c, ok := cells[ns][op]; ok {
+				cell = *c
+				cell.Pct = pct(cell.QueryTime)
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		h.Namespaces = append(h.Namespaces, *row)
+	}
+	sort.Slice(h.Namespaces, func(i, j int) bool {
+		if h.Namespaces[i].QueryTime != h.Namespaces[j].QueryTime {
+			return h.Namespaces[i].QueryTime > h.Namespaces[j].QueryTime
+		}
+		return h.Namespaces[i].Namespace < h.Namespaces[j].Namespace
+	})
+
+	return h
+}
+
+// heatShade returns the shade of a cell of the heatmap, darker as its share of the execution time grows.
+func heatShade(c heatmapCell) string {
+	switch {
+	case c.Count == 0:
+		return " "
+	case c.Pct >= 50:
+		return "█"
+	case c.Pct >= 20:
+		return "▓"
+	case c.Pct >= 5:
+		return "▒"
+	}
+
+	return "░"
+}
+
+// heatAlpha returns the opacity of the background of a cell of the HTML heatmap.
+func heatAlpha(c heatmapCell) string {
+	return fmt.Sprintf("%.2f", 0.05+0.95*c.Pct/100)
+}
+
+// heatCell returns a cell of the text heatmap: its percentage of the execution time and its shade, or "-" for
+// the operations not run on the namespace.
+func heatCell(c heatmapCell) string {
+	if c.Count == 0 {
+		return fmt.Sprintf("%12s  ", "-")
+	}
+
+	return fmt.Sprintf("%11.1f%% %s", c.Pct, heatShade(c))
+}
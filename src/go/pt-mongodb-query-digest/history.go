@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// historyEntry is the aggregate of a fingerprint in a run, appended to the --history collection.
+type historyEntry struct {
+	RunAt         time.Time `bson:"run_at"`
+	Source        string    `bson:"source"`
+	UptimeSeconds int64     `bson:"uptime_seconds"`
+
+	ID          string `bson:"id"`
+	Namespace   string `bson:"namespace"`
+	Operation   string `bson:"operation"`
+	Fingerprint string `bson:"fingerprint"`
+
+	Count          int     `bson:"count"`
+	QPS            float64 `bson:"qps"`
+	QueryTimeTotal float64 `bson:"query_time_total_ms"`
+	QueryTimeAvg   float64 `bson:"query_time_avg_ms"`
+	QueryTimeMax   float64 `bson:"query_time_max_ms"`
+	QueryTimeP95   float64 `bson:"query_time_p95_ms"`
+	DocsExamined   float64 `bson:"docs_examined"`
+	KeysExamined   float64 `bson:"keys_examined"`
+	DocsReturned   float64 `bson:"docs_returned"`
+	ResponseBytes  float64 `bson:"response_bytes"`
+}
+
+func historySource(opts *cliOptions) string {
+	if len(opts.SlowLogs) > 0 {
+		return strings.Join(opts.SlowLogs, ",")
+	}
+
+	return strings.TrimPrefix(opts.Host, "mongodb://") + "/" + opts.Database
+}
+
+// saveHistory appends the aggregates of every fingerprint of the run to the --history collection.
+func saveHistory(ctx context.Context, opts *cliOptions, queries []stats.QueryStats, uptime int64) error {
+	if len(queries) == 0 {
+		return nil
+	}
+
+	client, coll, err := connectCollection(ctx, opts, opts.History)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(ctx) //nolint
+
+	return insertHistory(ctx, coll, newHistoryEntries(time.Now(), historySource(opts), queries, uptime))
+}
+
+func newHistoryEntries(runAt time.Time, source string, queries []stats.QueryStats, uptime int64) []historyEntry {
+	entries := []historyEntry{}
+	for _, qs := range queries {
+		entries = append(entries, historyEntry{
+			RunAt:          runAt,
+			Source:         source,
+			UptimeSeconds:  uptime,
+			ID:             qs.ID,
+			Namespace:      qs.Namespace,
+			Operation:      qs.Operation,
+			Fingerprint:    qs.Fingerprint,
+			Count:          qs.Count,
+			QPS:            qs.QPS,
+			QueryTimeTotal: qs.QueryTime.Total,
+			QueryTimeAvg:   qs.QueryTime.Avg,
+			QueryTimeMax:   qs.QueryTime.Max,
+			QueryTimeP95:   qs.QueryTime.Pct95,
+			DocsExamined:   qs.Scanned.Total,
+			KeysExamined:   qs.KeysExamined.Total,
+			DocsReturned:   qs.Returned.Total,
+			ResponseBytes:  qs.ResponseLength.Total,
+		})
+	}
+
+	return entries
+}
+
+func insertHistory(ctx context.Context, coll *mongo.Collection, entries []historyEntry) error {
+	docs := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		docs = append(docs, entry)
+	}
+
+	if _, err := coll.InsertMany(ctx, docs); err != nil {
+		return errors.Wrapf(err, "cannot append to the history collection %s.%s", coll.Database().Name(), coll.Name())
+	}
+
+	return nil
+}
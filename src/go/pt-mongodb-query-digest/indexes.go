@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// indexKey is a field of a suggested index.
+type indexKey struct {
+	Field     string `json:"field"`
+	Direction int    `json:"direction"`
+}
+
+// indexSuggestion is a candidate index of --suggest-indexes, with the operations of the collection scan
+// fingerprints it would support.
+type indexSuggestion struct {
+	Namespace    string     `json:"namespace"`
+	Keys         []indexKey `json:"keys"`
+	Fingerprints []string   `json:"fingerprints"` // query IDs
+	Operations   int        `json:"operations"`
+}
+
+// Spec returns the index in the notation of createIndex.
+func (s indexSuggestion) Spec() string {
+	keys := make([]string, 0, len(s.Keys))
+	for _, k := range s.Keys {
+		keys = append(keys, fmt.Sprintf("%s: %d", k.Field, k.Direction))
+	}
+
+	return "{ " + strings.Join(keys, ", ") + " }"
+}
+
+// queryShape is the fields of a query, by how it uses them.
+type queryShape struct {
+	Equality []string
+	Sort     []indexKey
+	Range    []string
+}
+
+// suggestIndexes proposes an index for the fingerprints that scanned their collection, in the profiler or
+// in their --explain plan. The fields are ordered by the ESR rule: equality fields first, then the sort
+// fields and the range fields last. The suggestions that are a prefix of another one of the collection are
+// left out, the other one supporting their operations as well.
+func suggestIndexes(queries []stats.QueryStats, plans map[string]*queryPlan) []indexSuggestion {
+	candidates := []indexCandidate{}
+	for _, qs := range queries {
+		collScan := qs.TableScan
+		if plan, ok := plans[qs.ID]; ok && plan.CollScan {
+			collScan = true
+		}
+		if !collScan {
+			continue
+		}
+
+		shape, ok := parseQueryShape(qs.Query)
+		if !ok {
+			continue
+		}
+
+		keys := shape.keys()
+		if len(keys) == 0 {
+			continue
+		}
+
+		candidates = append(candidates, indexCandidate{namespace: qs.Namespace, keys: keys, id: qs.ID, count: qs.Count})
+	}
+
+	suggestions := []indexSuggestion{}
+	for _, c := range candidates {
+		if c.isRedundant(candidates) {
+			continue
+		}
+
+		s := indexSuggestion{Namespace: c.namespace, Keys: c.keys}
+		if containsSuggestion(suggestions, s) {
+			continue
+		}
+
+		for _, o := range candidates {
+			if o.namespace == c.namespace && isKeysPrefix(o.keys, c.keys) {
+				s.Fingerprints = append(s.Fingerprints, o.id)
+				s.Operations += o.count
+			}
+		}
+		suggestions = append(suggestions, s)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Operations > suggestions[j].Operations })
+
+	return suggestions
+}
+
+// indexCandidate is the index of a collection scan fingerprint.
+type indexCandidate struct {
+	namespace string
+	keys      []indexKey
+	id        string
+	count     int
+}
+
+// isRedundant returns true if a longer candidate of the collection starts with the keys of c.
+func (c indexCandidate) isRedundant(candidates []indexCandidate) bool {
+	for _, o := range candidates {
+		if o.namespace == c.namespace && len(o.keys) > len(c.keys) && isKeysPrefix(c.keys, o.keys) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsSuggestion(suggestions []indexSuggestion, s indexSuggestion) bool {
+	for _, o := range suggestions {
+		if o.Namespace == s.Namespace && len(o.Keys) == len(s.Keys) && isKeysPrefix(o.Keys, s.Keys) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isKeysPrefix returns true if an index on keys can be served by an index on the other keys.
+func isKeysPrefix(keys, other []indexKey) bool {
+	if len(keys) > len(other) {
+		return false
+	}
+
+	for i, k := range keys {
+		if k.Field != other[i].Field {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (qs queryShape) keys() []indexKey {
+	keys := []indexKey{}
+	seen := make(map[string]bool)
+
+	add := func(field string, direction int) {
+		if !seen[field] {
+			seen[field] = true
+			keys = append(keys, indexKey{Field: field, Direction: direction})
+		}
+	}
+
+	for _, field := range qs.Equality {
+		add(field, 1)
+	}
+	for _, k := range qs.Sort {
+		add(k.Field, k.Direction)
+	}
+	for _, field := range qs.Range {
+		add(field, 1)
+	}
+
+	return keys
+}
+
+// parseQueryShape returns the shape of the filter and sort of the sample query of a fingerprint.
+func parseQueryShape(query string) (queryShape, bool) {
+	var eq proto.ExampleQuery
+	if err := bson.UnmarshalExtJSON([]byte(query), true, &eq); err != nil {
+		return queryShape{}, false
+	}
+
+	cmd := eq.Command
+	if len(cmd) == 0 {
+		cmd = eq.Query
+	}
+	if len(cmd) == 0 {
+		return queryShape{}, false
+	}
+
+	var filter, sortDoc interface{}
+	switch cmd[0].Key {
+	case "find":
+		filter, sortDoc = lookup(cmd, "filter"), lookup(cmd, "sort")
+	case "q":
+		// update and delete statements
+		filter = lookup(cmd, "q")
+	case "count", "distinct":
+		filter = lookup(cmd, "query")
+	case "findAndModify", "findandmodify":
+		filter, sortDoc = lookup(cmd, "query"), lookup(cmd, "sort")
+	case "aggregate":
+		pipeline, _ := lookup(cmd, "pipeline").(bson.A)
+		for _, stage := range pipeline {
+			d, ok := stage.(bson.D)
+			if !ok || len(d) == 0 {
+				break
+			}
+			if d[0].Key == "$match" && filter == nil {
+				filter = d[0].Value
+				continue
+			}
+			if d[0].Key == "$sort" && sortDoc == nil {
+				sortDoc = d[0].Value
+			}
+			break
+		}
+	case "$query", "query":
+		// legacy queries
+		filter, sortDoc = cmd[0].Value, lookup(cmd, "orderby")
+		if sortDoc == nil {
+			sortDoc = lookup(cmd, "$orderby")
+		}
+	default:
+		if eq.Op != "query" || len(eq.Command) > 0 {
+			return queryShape{}, false
+		}
+		filter = cmd
+	}
+
+	shape := queryShape{}
+	if f, ok := filter.(bson.D); ok {
+		shape.addFilter(f)
+	}
+	if s, ok := sortDoc.(bson.D); ok {
+		for _, e := range s {
+			shape.Sort = append(shape.Sort, indexKey{Field: e.Key, Direction: sortDirection(e.Value)})
+		}
+	}
+
+	return shape, true
+}
+
+// addFilter classifies the fields of a filter. $and is flattened, and $or, $nor and the other top level
+// operators are skipped since a single index cannot serve them.
+func (qs *queryShape) addFilter(filter bson.D) {
+	for _, e := range filter {
+		if e.Key == "$and" {
+			clauses, _ := e.Value.(bson.A)
+			for _, clause := range clauses {
+				if d, ok := clause.(bson.D); ok {
+					qs.addFilter(d)
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(e.Key, "$") {
+			continue
+		}
+
+		if isEquality(e.Value) {
+			qs.Equality = append(qs.Equality, e.Key)
+		} else {
+			qs.Range = append(qs.Range, e.Key)
+		}
+	}
+}
+
+// isEquality returns true for the values matched by equality, including $eq and $in, and false for the
+// ranges and the other operators.
+func isEquality(value interface{}) bool {
+	switch v := value.(type) {
+	case primitive.Regex:
+		return false
+	case bson.D:
+		if len(v) == 0 || !strings.HasPrefix(v[0].Key, "$") {
+			return true // embedded document
+		}
+		for _, op := range v {
+			if op.Key != "$eq" && op.Key != "$in" {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func sortDirection(value interface{}) int {
+	switch n := value.(type) {
+	case int32:
+		if n < 0 {
+			return -1
+		}
+	case int64:
+		if n < 0 {
+			return -1
+		}
+	case float64:
+		if n < 0 {
+			return -1
+		}
+	}
+
+	return 1
+}
+
+func lookup(d bson.D, key string) interface{} {
+	for _, e := range d {
+		if e.Key == key {
+			return e.Value
+		}
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -32,11 +33,14 @@ import (
 const (
 	toolname = "pt-mongodb-query-digest"
 
-	DEFAULT_AUTHDB          = "admin"
-	DEFAULT_HOST            = "localhost:27017"
-	DEFAULT_LOGLEVEL        = "warn"
-	DEFAULT_ORDERBY         = "-count"         // comma separated list
-	DEFAULT_SKIPCOLLECTIONS = "system.profile" // comma separated list
+	DEFAULT_AUTHDB           = "admin"
+	DEFAULT_HOST             = "localhost:27017"
+	DEFAULT_LOGLEVEL         = "warn"
+	DEFAULT_MAX_FINGERPRINTS = 10000
+	DEFAULT_MAX_SAMPLES      = 10000
+	DEFAULT_ORDERBY          = "-count"         // comma separated list
+	DEFAULT_PERCENTILES      = "50,95,99,99.9"  // comma separated list
+	DEFAULT_SKIPCOLLECTIONS  = "system.profile" // comma separated list
 )
 
 // We do not set anything here, these variables are defined by the Makefile
@@ -48,31 +52,83 @@ var (
 )
 
 type cliOptions struct {
-	AuthDB          string
-	Database        string
-	Debug           bool
-	Help            bool
-	Host            string
-	Limit           int
-	LogLevel        string
-	NoVersionCheck  bool
-	OrderBy         []string
-	OutputFormat    string
-	Password        string
-	SkipCollections []string
-	SSLCAFile       string
-	SSLPEMKeyFile   string
-	User            string
-	Version         bool
+	AuthDB           string
+	Collections      []string
+	Database         string
+	Debug            bool
+	EnableProfiling  []string
+	Explain          int
+	GroupBy          string
+	Heatmap          bool
+	Help             bool
+	History          string
+	Host             string
+	Limit            int
+	LogLevel         string
+	MaxFingerprints  int
+	MaxSamples       int
+	Member           string
+	MergeState       bool
+	MetricsListen    string
+	MetricsMax       int
+	MetricsPush      string
+	MinDuration      int
+	NoVersionCheck   bool
+	OpTypes          []string
+	OrderBy          []string
+	Output           string
+	OutputFormat     string
+	Password         string
+	Percentiles      []string
+	ReadFrom         string
+	ReadPreference   string
+	Redact           bool
+	RestoreProfiling bool
+	SampleRate       string
+	Review           string
+	SuggestIndexes   bool
+	Since            string
+	SkipCollections  []string
+	SlowLogs         []string
+	SSLCAFile        string
+	SSLPEMKeyFile    string
+	State            string
+	Until            string
+	User             string
+	Version          bool
+	Watch            bool
+	Window           int // minutes
+	Interval         int // seconds
 }
 
 type report struct {
 	Headers     []string
 	QueryStats  []stats.QueryStats
 	QueryTotals stats.QueryStats
+	Plans       map[string]*queryPlan `json:",omitempty"` // by query ID, with --explain
+	Indexes     []indexSuggestion     `json:",omitempty"` // with --suggest-indexes
+	Evicted     int                   `json:",omitempty"` // operations of the fingerprints past --max-fingerprints
+	Classes     []classTotals         `json:",omitempty"` // with transactions or retryable writes
+	Errors      []errorTotal          `json:",omitempty"` // with failed operations
+	Heatmap     *heatmap              `json:",omitempty"` // with --heatmap
+	GroupBy     string                `json:",omitempty"`
+}
+
+// reportQuery is a query of the text report.
+type reportQuery struct {
+	stats.QueryStats
+	Plan       *queryPlan
+	GroupLabel string // of the --group-by dimension
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == compareCommand {
+		os.Exit(runCompare(os.Args[1:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == diffCommand {
+		os.Exit(runDiff(os.Args[1:]))
+	}
+
 	opts, err := getOptions()
 	if err != nil {
 		log.Errorf("error processing command line arguments: %s", err)
@@ -109,6 +165,211 @@ func main() {
 
 	log.Debugf("Command line options:\n%+v\n", opts)
 
+	opts.SkipCollections = sanitizeSkipCollections(opts.SkipCollections)
+	filters := []filter.Filter{}
+
+	if len(opts.SkipCollections) > 0 {
+		filters = append(filters, filter.NewFilterByCollection(opts.SkipCollections))
+	}
+	if len(opts.Collections) > 0 {
+		filters = append(filters, filter.NewFilterByCollectionName(opts.Collections))
+	}
+	if len(opts.OpTypes) > 0 {
+		filters = append(filters, filter.NewFilterByOperation(opts.OpTypes))
+	}
+	if opts.MinDuration > 0 {
+		filters = append(filters, filter.NewFilterByMinDuration(opts.MinDuration))
+	}
+
+	// validated by getOptions
+	since, _ := parseFilterTime(opts.Since, time.Now())
+	until, _ := parseFilterTime(opts.Until, time.Now())
+	if !since.IsZero() || !until.IsZero() {
+		filters = append(filters, filter.NewFilterByTimeRange(since, until))
+	}
+
+	groupBy, _ := getGroupBy(opts.GroupBy) // validated by getOptions
+
+	var state *digestState
+	var checkpoint time.Time
+	if opts.State != "" {
+		if state, err = loadState(opts.State, historySource(opts)); err != nil {
+			log.Errorf("Cannot load the state: %s", err)
+			os.Exit(11)
+		}
+		checkpoint = state.Checkpoint
+		if !checkpoint.IsZero() {
+			// only the operations newer than the ones read by the previous run
+			filters = append(filters, filter.NewFilterByTimeRange(checkpoint.Add(time.Nanosecond), time.Time{}))
+		}
+	}
+
+	profilingDone := func() {}
+	if len(opts.EnableProfiling) > 0 {
+		if profilingDone, err = enableProfiling(context.Background(), opts); err != nil {
+			log.Errorf("Cannot enable the profiler: %s", err)
+			os.Exit(10)
+		}
+	}
+
+	if opts.Watch {
+		code := watch(opts, withSampleRate(opts, filters), groupBy)
+		profilingDone()
+		os.Exit(code)
+	}
+
+	var queries stats.Queries
+	var uptime int64
+
+	if len(opts.SlowLogs) > 0 {
+		if opts.Database != "" {
+			filters = append(filters, filter.NewFilterByDatabase(opts.Database))
+		}
+		queries, uptime, err = readSlowLogs(opts.SlowLogs, withSampleRate(opts, filters), groupBy, getLimits(opts))
+		if err != nil {
+			log.Errorf("Cannot read the slow operations: %s", err)
+			os.Exit(6)
+		}
+	} else {
+		queries, uptime = readProfiler(context.Background(), opts, profileQuery(checkpoint),
+			withSampleRate(opts, filters), groupBy)
+		profilingDone()
+
+		// the QPS are computed over the time range rather than over the server uptime
+		if !since.IsZero() {
+			end := until
+			if end.IsZero() {
+				end = time.Now()
+			}
+			if seconds := int64(end.Sub(since).Seconds()); seconds > 0 && (uptime == 0 || seconds < uptime) {
+				uptime = seconds
+			}
+		}
+	}
+
+	if state != nil {
+		if opts.MergeState {
+			queries = mergeState(state, queries, groupBy, getLimits(opts))
+		}
+		// the QPS are computed over the time range of the operations, the previous runs included
+		if first, last := queriesTimeRange(queries); !first.IsZero() {
+			uptime = int64(last.Sub(first).Seconds())
+			if uptime < 1 {
+				uptime = 1
+			}
+		}
+		state.advance(queries, opts.MergeState)
+	}
+
+	percentiles, _ := parsePercentiles(opts.Percentiles) // validated by getOptions
+	queriesStats, evicted := calcQueriesStats(opts, queries, uptime, percentiles)
+	if evicted > 0 {
+		log.Warnf("%d operations of the fingerprints past --max-fingerprints %d are only counted in the totals",
+			evicted, opts.MaxFingerprints)
+	}
+	sortedQueryStats := sortQueries(queriesStats, opts.OrderBy)
+
+	if opts.History != "" {
+		if err := saveHistory(context.Background(), opts, sortedQueryStats, uptime); err != nil {
+			log.Errorf("Cannot save the history: %s", err)
+			os.Exit(8)
+		}
+	}
+
+	if opts.MetricsPush != "" {
+		err := pushMetrics(context.Background(), opts.MetricsPush, sortedQueryStats, opts.MetricsMax, opts.GroupBy)
+		if err != nil {
+			log.Errorf("Cannot push the metrics: %s", err)
+			os.Exit(9)
+		}
+	}
+
+	if state != nil {
+		if err := saveState(opts.State, state); err != nil {
+			log.Errorf("Cannot save the state: %s", err)
+			os.Exit(11)
+		}
+	}
+
+	if opts.Output == "openmetrics" {
+		// every fingerprint, up to --metrics-max-fingerprints, rather than the reviewed and limited ones
+		if err := writeMetrics(os.Stdout, sortedQueryStats, opts.MetricsMax, opts.GroupBy); err != nil {
+			log.Errorf("Cannot write the metrics: %s", err)
+			os.Exit(5)
+		}
+		return
+	}
+
+	if opts.Review != "" {
+		if sortedQueryStats, err = review(context.Background(), opts, sortedQueryStats); err != nil {
+			log.Errorf("Cannot review the queries: %s", err)
+			os.Exit(7)
+		}
+	}
+
+	if opts.Limit > 0 && len(sortedQueryStats) > opts.Limit {
+		sortedQueryStats = sortedQueryStats[:opts.Limit]
+	}
+
+	if len(queries) == 0 {
+		if len(opts.SlowLogs) > 0 {
+			log.Errorf("No slow operations found in %v\n", opts.SlowLogs)
+			return
+		}
+		log.Errorf("No queries found in profiler information for database %q\n", opts.Database)
+		return
+	}
+	rep := report{
+		Headers:     getHeaders(opts, evicted),
+		GroupBy:     opts.GroupBy,
+		QueryTotals: calcTotalQueriesStats(opts, queries, uptime, percentiles),
+		QueryStats:  sortedQueryStats,
+		Evicted:     evicted,
+		Classes:     getClassTotals(queriesStats),
+		Errors:      getErrorTotals(queriesStats),
+	}
+	if opts.Heatmap {
+		// every fingerprint, rather than the reviewed and limited ones
+		rep.Heatmap = newHeatmap(queriesStats)
+	}
+
+	if opts.Explain > 0 {
+		if rep.Plans, err = explainQueries(context.Background(), opts, sortedQueryStats, opts.Explain); err != nil {
+			log.Errorf("Cannot explain the queries: %s", err)
+		}
+	}
+
+	if opts.SuggestIndexes {
+		rep.Indexes = suggestIndexes(queriesStats, rep.Plans)
+	}
+
+	if opts.Redact {
+		// after --explain and --suggest-indexes, that need the literal values
+		redactQueries(rep.QueryStats)
+	}
+
+	var out []byte
+	switch opts.Output {
+	case "json":
+		out, err = json.MarshalIndent(newJSONReport(opts, rep, uptime), "", "    ")
+	case "markdown", "html":
+		out, err = formatDocument(rep, opts.Output)
+	default:
+		out, err = formatResults(rep, opts.OutputFormat)
+	}
+	if err != nil {
+		log.Errorf("Cannot parse the report: %s", err.Error())
+		os.Exit(5)
+	}
+
+	fmt.Println(string(out))
+}
+
+// readProfiler reads the documents of the system.profile collection of the database matching the query,
+// returning their queries and the server uptime.
+func readProfiler(ctx context.Context, opts *cliOptions, query primitive.M, filters []filter.Filter,
+	groupBy stats.GroupBy,
+) (stats.Queries, int64) {
 	clientOptions, err := getClientOptions(opts)
 	if err != nil {
 		log.Errorf("Cannot get a MongoDB client: %s", err)
@@ -121,8 +382,6 @@ func main() {
 		os.Exit(2)
 	}
 
-	ctx := context.Background()
-
 	log.Debugf("Dial Info: %+v\n", clientOptions)
 
 	client, err := mongo.NewClient(clientOptions)
@@ -134,7 +393,34 @@ func main() {
 		log.Fatalf("Cannot connect to MongoDB: %s", err)
 	}
 
-	isProfilerEnabled, err := isProfilerEnabled(ctx, clientOptions, opts.Database)
+	// the profiler runs on the shards, each one profiling the operations it ran
+	if isMongos(ctx, client) {
+		if targetsMember(opts) {
+			log.Warn("--member and --read-preference are ignored with a mongos, --read-from selects the shard members")
+		}
+		members, err := getShardMembers(ctx, client, clientOptions, opts.ReadFrom)
+		if err != nil {
+			log.Errorf("Cannot get the shard members: %s", err)
+			os.Exit(4)
+		}
+
+		queries, err := readShardProfiles(ctx, clientOptions, members, opts.Database, query, filters, groupBy,
+			getLimits(opts))
+		if err != nil {
+			log.Errorf("Cannot read the profiler of the shards: %s", err)
+			os.Exit(4)
+		}
+
+		return queries, uptime(ctx, client)
+	}
+
+	client, clientOptions, member, err := connectProfiledMember(ctx, client, clientOptions, opts)
+	if err != nil {
+		log.Errorf("Cannot connect to the profiled member: %s", err)
+		os.Exit(4)
+	}
+
+	isProfilerEnabled, err := profilerEnabled(ctx, client, clientOptions, opts.Database, member)
 	if err != nil {
 		log.Errorf("Cannot get profiler status: %s", err.Error())
 		os.Exit(4)
@@ -151,50 +437,17 @@ func main() {
 		fmt.Println("Using those documents for the stats")
 	}
 
-	opts.SkipCollections = sanitizeSkipCollections(opts.SkipCollections)
-	filters := []filter.Filter{}
-
-	if len(opts.SkipCollections) > 0 {
-		filters = append(filters, filter.NewFilterByCollection(opts.SkipCollections))
-	}
-
-	cursor, err := client.Database(opts.Database).Collection("system.profile").Find(ctx, primitive.M{})
+	cursor, err := client.Database(opts.Database).Collection("system.profile").Find(ctx, query)
 	if err != nil {
 		panic(err)
 	}
 
-	fp := fingerprinter.NewFingerprinter(fingerprinter.DefaultKeyFilters())
-	s := stats.New(fp)
+	s := newStats(groupBy, getLimits(opts))
 	prof := profiler.NewProfiler(cursor, filters, nil, s)
 	prof.Start(ctx)
 	queries := <-prof.QueriesChan()
 
-	uptime := uptime(ctx, client)
-
-	queriesStats := queries.CalcQueriesStats(uptime)
-	sortedQueryStats := sortQueries(queriesStats, opts.OrderBy)
-
-	if opts.Limit > 0 && len(sortedQueryStats) > opts.Limit {
-		sortedQueryStats = sortedQueryStats[:opts.Limit]
-	}
-
-	if len(queries) == 0 {
-		log.Errorf("No queries found in profiler information for database %q\n", opts.Database)
-		return
-	}
-	rep := report{
-		Headers:     getHeaders(opts),
-		QueryTotals: queries.CalcTotalQueriesStats(uptime),
-		QueryStats:  sortedQueryStats,
-	}
-
-	out, err := formatResults(rep, opts.OutputFormat)
-	if err != nil {
-		log.Errorf("Cannot parse the report: %s", err.Error())
-		os.Exit(5)
-	}
-
-	fmt.Println(string(out))
+	return queries, uptime(ctx, client)
 }
 
 func formatResults(rep report, outputFormat string) ([]byte, error) {
@@ -211,16 +464,40 @@ func formatResults(rep report, outputFormat string) ([]byte, error) {
 		buf = new(bytes.Buffer)
 
 		tt, _ := template.New("query").Funcs(template.FuncMap{
-			"Format": format,
+			"Format":    format,
+			"sparkline": sparkline,
 		}).Parse(getTotalsTemplate())
 		tt.Execute(buf, rep.QueryTotals)
 
+		if rep.Classes != nil {
+			ct, _ := template.New("classes").Parse(getClassesTemplate())
+			ct.Execute(buf, rep.Classes)
+		}
+
+		if rep.Errors != nil {
+			et, _ := template.New("errors").Parse(getErrorsTemplate())
+			et.Execute(buf, rep.Errors)
+		}
+
+		if rep.Heatmap != nil {
+			ht, _ := template.New("heatmap").Funcs(template.FuncMap{"heat": heatCell}).Parse(getHeatmapTemplate())
+			ht.Execute(buf, rep.Heatmap)
+		}
+
 		t, _ := template.New("query").Funcs(template.FuncMap{
-			"Format": format,
+			"Format":    format,
+			"errors":    errorsList,
+			"join":      strings.Join,
+			"sparkline": sparkline,
 		}).Parse(getQueryTemplate())
 
 		for _, qs := range rep.QueryStats {
-			t.Execute(buf, qs)
+			t.Execute(buf, reportQuery{QueryStats: qs, Plan: rep.Plans[qs.ID], GroupLabel: groupByLabel(rep.GroupBy)})
+		}
+
+		if rep.Indexes != nil {
+			it, _ := template.New("indexes").Parse(getIndexesTemplate())
+			it.Execute(buf, rep.Indexes)
 		}
 	}
 
@@ -251,6 +528,30 @@ func format(val float64, size float64) string {
 	return fmt.Sprintf("%s%s", fval, unit)
 }
 
+// sparkline draws the execution time histogram, a bar per bucket of stats.LatencyBuckets, from the fastest
+// bucket to the slowest one. The empty buckets are blank.
+func sparkline(histogram []int) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+
+	max := 0
+	for _, count := range histogram {
+		if count > max {
+			max = count
+		}
+	}
+
+	line := make([]rune, 0, len(histogram))
+	for _, count := range histogram {
+		if count == 0 {
+			line = append(line, ' ')
+			continue
+		}
+		line = append(line, bars[(count*len(bars)-1)/max])
+	}
+
+	return string(line)
+}
+
 func uptime(ctx context.Context, client *mongo.Client) int64 {
 	res := client.Database("admin").RunCommand(ctx, primitive.D{{"serverStatus", 1}, {"recordStats", 1}})
 	if res.Err() != nil {
@@ -268,9 +569,17 @@ func getOptions() (*cliOptions, error) {
 		Host:            DEFAULT_HOST,
 		LogLevel:        DEFAULT_LOGLEVEL,
 		OrderBy:         strings.Split(DEFAULT_ORDERBY, ","),
+		Percentiles:     strings.Split(DEFAULT_PERCENTILES, ","),
 		SkipCollections: strings.Split(DEFAULT_SKIPCOLLECTIONS, ","),
 		AuthDB:          DEFAULT_AUTHDB,
 		OutputFormat:    "text",
+		ReadFrom:        readFromPrimary,
+		ReadPreference:  readPreferencePrimary,
+		MetricsMax:      DEFAULT_METRICS_MAX_FINGERPRINTS,
+		MaxFingerprints: DEFAULT_MAX_FINGERPRINTS,
+		MaxSamples:      DEFAULT_MAX_SAMPLES,
+		Window:          DEFAULT_WATCH_WINDOW,
+		Interval:        DEFAULT_WATCH_INTERVAL,
 	}
 
 	gop := getopt.New()
@@ -279,18 +588,87 @@ func getOptions() (*cliOptions, error) {
 	gop.BoolVarLong(&opts.NoVersionCheck, "no-version-check", 'c', "Default: Don't check for updates")
 
 	gop.IntVarLong(&opts.Limit, "limit", 'n', "Show the first n queries")
+	gop.IntVarLong(&opts.MaxFingerprints, "max-fingerprints", 0, "Maximum number of fingerprints tracked, the "+
+		"ones of the least execution time being evicted past it. 0 is unbounded. Default: "+
+		strconv.Itoa(DEFAULT_MAX_FINGERPRINTS))
+	gop.IntVarLong(&opts.MaxSamples, "max-samples", 0, "Maximum number of values kept per metric of a "+
+		"fingerprint, the percentiles being estimated from a sample past it. 0 is unbounded. Default: "+
+		strconv.Itoa(DEFAULT_MAX_SAMPLES))
+	gop.IntVarLong(&opts.MinDuration, "min-duration", 0, "Only digest the operations that lasted at least "+
+		"this number of milliseconds")
+	gop.IntVarLong(&opts.Explain, "explain", 0, "Explain the sample query of the first n queries, reporting "+
+		"collection scans, the indexes used and the keys/docs examined ratios")
 
 	gop.ListVarLong(&opts.OrderBy, "order-by", 'o',
-		"Comma separated list of order by fields (max values): "+
-			"count,ratio,query-time,docs-scanned,docs-returned. "+
+		"Comma separated list of order by fields: count, ratio, errors or a metric (query-time, docs-scanned, "+
+			"docs-returned, keys-examined, bytes-sent, num-yields, write-conflicts, lock-wait) followed by "+
+			":max, :min, :sum, :avg, :median, :p95 or :p99, max by default. "+
 			"- in front of the field name denotes reverse order. Default: "+DEFAULT_ORDERBY)
+	gop.ListVarLong(&opts.Percentiles, "percentiles", 0, "Comma separated list of the execution time percentiles "+
+		"to report. Default: "+DEFAULT_PERCENTILES)
 	gop.ListVarLong(&opts.SkipCollections, "skip-collections", 's', "A comma separated list of collections (namespaces) to skip."+
 		"  Default: "+DEFAULT_SKIPCOLLECTIONS)
 
+	gop.ListVarLong(&opts.Collections, "collection", 0, "Comma separated list of collections to digest, "+
+		"the other ones are skipped")
+	gop.ListVarLong(&opts.OpTypes, "op-type", 0, "Comma separated list of operation types to digest: "+
+		"query (or find), getmore, insert, update, remove (or delete), command")
+	gop.ListVarLong(&opts.SlowLogs, "slow-log", 0, "A comma separated list of mongod log files to read the slow operations "+
+		"from, instead of the system.profile collection. Gzipped files are decompressed, - reads the standard input")
+
 	gop.StringVarLong(&opts.AuthDB, "authenticationDatabase", 'a', "admin", "Database to use for optional MongoDB authentication. Default: admin")
 	gop.StringVarLong(&opts.Database, "database", 'd', "", "MongoDB database to profile")
 	gop.StringVarLong(&opts.LogLevel, "log-level", 'l', "Log level: error", "panic, fatal, error, warn, info, debug. Default: error")
-	gop.StringVarLong(&opts.OutputFormat, "output-format", 'f', "text", "Output format: text, json. Default: text")
+	gop.StringVarLong(&opts.Output, "output", 0, "Output: text, json (a document per fingerprint with all the statistics), "+
+		"openmetrics (the Prometheus text format), markdown or html (a document to attach to tickets). Default: text")
+	gop.StringVarLong(&opts.MetricsListen, "metrics-listen", 0, "With --watch, serve the metrics of the last report "+
+		"on this address, like :9216, at /metrics for Prometheus")
+	gop.StringVarLong(&opts.MetricsPush, "metrics-push", 0, "Push the metrics to this Prometheus Pushgateway url, "+
+		"after every report with --watch")
+	gop.IntVarLong(&opts.MetricsMax, "metrics-max-fingerprints", 0, "Maximum number of fingerprints exported in "+
+		"the metrics, the others are summed up in the series of the 'other' id. 0 exports all of them. "+
+		"Default: "+strconv.Itoa(DEFAULT_METRICS_MAX_FINGERPRINTS))
+	gop.StringVarLong(&opts.OutputFormat, "output-format", 'f', "text", "Output format: text, json. Default: text. "+
+		"Kept for compatibility, its json output keeps the previous layout")
+	gop.BoolVarLong(&opts.Heatmap, "heatmap", 0, "Report the execution time rolled up by namespace and operation, "+
+		"to find the collections burning the time before drilling into their queries")
+	gop.ListVarLong(&opts.EnableProfiling, "enable-profiling", 0, "Set the profiler of --database on the server, "+
+		"or on the shard members with a mongos, before reading it: comma separated list of level (1 by default), "+
+		"slowms, samplerate and, on Percona Server for MongoDB, ratelimit, like level=1,slowms=100")
+	gop.BoolVarLong(&opts.RestoreProfiling, "restore-profiling", 0, "With --enable-profiling, restore the previous "+
+		"settings of the profiler once the operations are read")
+	gop.BoolVarLong(&opts.Redact, "redact", 0, "Replace the literal values of the reported sample queries with "+
+		"placeholders, keeping their shape, so that the reports can be shared")
+	gop.StringVarLong(&opts.SampleRate, "sample-rate", 0, "Digest a random sample of the operations, each one "+
+		"with this probability, greater than 0 and up to 1, the counts and totals being scaled to all the "+
+		"operations. Default: 1")
+	gop.StringVarLong(&opts.ReadFrom, "read-from", 0, "When connected to a mongos, profiled members of every shard: "+
+		"primary, secondaries (all the data bearing members). Default: "+readFromPrimary)
+	gop.StringVarLong(&opts.ReadPreference, "read-preference", 0, "Read the profiler of the replica set member "+
+		"selected by this read preference: primary, primaryPreferred, secondary, secondaryPreferred or nearest. "+
+		"Default: "+readPreferencePrimary)
+	gop.StringVarLong(&opts.Member, "member", 0, "Read the profiler of this replica set member, host:port, "+
+		"hidden members included, rather than the one of the primary")
+	gop.BoolVarLong(&opts.SuggestIndexes, "suggest-indexes", 0, "Suggest indexes for the queries scanning "+
+		"their collection, with the number of operations they would support")
+	gop.BoolVarLong(&opts.Watch, "watch", 0, "Tail the profiler, or the logs, and report the top queries of "+
+		"the last --window every --interval, until interrupted")
+	gop.IntVarLong(&opts.Window, "window", 0, "With --watch, minutes of operations reported. Default: 5")
+	gop.IntVarLong(&opts.Interval, "interval", 0, "With --watch, seconds between the reports. Default: 60")
+	gop.StringVarLong(&opts.Since, "since", 0, "Only digest the operations that ran since this time: "+
+		"RFC3339 time, YYYY-MM-DD [HH:MM[:SS]] local time, or a duration ago like 12h")
+	gop.StringVarLong(&opts.Until, "until", 0, "Only digest the operations that ran before this time, "+
+		"in the format of --since")
+	gop.StringVarLong(&opts.Review, "review", 0, "Store the fingerprints in a review collection, db.collection of the "+
+		"server or mongodb://host:port/db.collection, and only report the ones not reviewed yet")
+	gop.StringVarLong(&opts.GroupBy, "group-by", 0, "Group the queries by client application (appname), "+
+		"user or client host as well as by fingerprint")
+	gop.StringVarLong(&opts.History, "history", 0, "Append the statistics of every fingerprint to a history "+
+		"collection, db.collection of the server or mongodb://host:port/db.collection. See the compare subcommand")
+	gop.StringVarLong(&opts.State, "state", 0, "Save the time of the last operation read to this file, and only "+
+		"digest the operations newer than the one saved by the previous run, for scheduled runs")
+	gop.BoolVarLong(&opts.MergeState, "merge-state", 0, "With --state, save the statistics of the fingerprints "+
+		"as well, and report the operations of the previous runs merged with the new ones")
 	gop.StringVarLong(&opts.Password, "password", 'p', "", "Password to use for optional MongoDB authentication").SetOptional()
 	gop.StringVarLong(&opts.User, "username", 'u', "Username to use for optional MongoDB authentication")
 	gop.StringVarLong(&opts.SSLCAFile, "sslCAFile", 0, "SSL CA cert file used for authentication")
@@ -309,20 +687,133 @@ func getOptions() (*cliOptions, error) {
 	}
 
 	if gop.IsSet("order-by") {
-		validFields := []string{"count", "ratio", "query-time", "docs-scanned", "docs-returned"}
 		for _, field := range opts.OrderBy {
-			valid := false
-			for _, vf := range validFields {
-				if field == vf || field == "-"+vf {
-					valid = true
-				}
-			}
-			if !valid {
-				return nil, fmt.Errorf("invalid sort field '%q'", field)
+			if _, err := parseOrderBy(field); err != nil {
+				return nil, err
 			}
 		}
 	}
 
+	if _, err := parsePercentiles(opts.Percentiles); err != nil {
+		return nil, err
+	}
+
+	if _, err := getGroupBy(opts.GroupBy); err != nil {
+		return nil, err
+	}
+
+	if _, err := parseSampleRate(opts.SampleRate); err != nil {
+		return nil, err
+	}
+
+	if opts.Watch {
+		if opts.Window <= 0 || opts.Interval <= 0 {
+			return nil, fmt.Errorf("invalid --window %d or --interval %d", opts.Window, opts.Interval)
+		}
+		if opts.Explain > 0 || opts.Review != "" || opts.History != "" || opts.SuggestIndexes || opts.State != "" {
+			return nil, errors.New("--watch cannot be used with --explain, --review, --history, --suggest-indexes " +
+				"or --state")
+		}
+	}
+
+	if len(opts.EnableProfiling) > 0 {
+		if len(opts.SlowLogs) > 0 {
+			return nil, errors.New("--enable-profiling cannot be used with --slow-log")
+		}
+		if opts.Database == "" {
+			return nil, errors.New("--enable-profiling requires --database")
+		}
+		if _, err := parseProfilingSettings(opts.EnableProfiling); err != nil {
+			return nil, err
+		}
+	}
+	if opts.RestoreProfiling && len(opts.EnableProfiling) == 0 {
+		return nil, errors.New("--restore-profiling requires --enable-profiling")
+	}
+
+	if opts.MergeState && opts.State == "" {
+		return nil, errors.New("--merge-state requires --state")
+	}
+
+	if opts.MetricsListen != "" && !opts.Watch {
+		return nil, errors.New("--metrics-listen requires --watch")
+	}
+	if opts.MaxFingerprints < 0 || opts.MaxSamples < 0 {
+		return nil, fmt.Errorf("invalid --max-fingerprints %d or --max-samples %d", opts.MaxFingerprints,
+			opts.MaxSamples)
+	}
+	if opts.MetricsMax < 0 {
+		return nil, fmt.Errorf("invalid --metrics-max-fingerprints %d", opts.MetricsMax)
+	}
+
+	for i, op := range opts.OpTypes {
+		switch op {
+		case "find":
+			opts.OpTypes[i] = "query"
+		case "delete":
+			opts.OpTypes[i] = "remove"
+		case "query", "getmore", "insert", "update", "remove", "command":
+		default:
+			return nil, fmt.Errorf("invalid operation type %q", op)
+		}
+	}
+
+	if opts.MinDuration < 0 {
+		return nil, fmt.Errorf("invalid --min-duration %d", opts.MinDuration)
+	}
+
+	since, err := parseFilterTime(opts.Since, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid --since: %s", err)
+	}
+	until, err := parseFilterTime(opts.Until, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid --until: %s", err)
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return nil, fmt.Errorf("--since %s is not before --until %s", opts.Since, opts.Until)
+	}
+
+	for _, spec := range []string{opts.Review, opts.History} {
+		if spec == "" {
+			continue
+		}
+		if _, err := parseCollectionSpec(spec); err != nil {
+			return nil, err
+		}
+	}
+
+	rp, err := parseReadPreference(opts.ReadPreference)
+	if err != nil {
+		return nil, err
+	}
+	opts.ReadPreference = rp.Mode().String()
+	if targetsMember(opts) {
+		if len(opts.SlowLogs) > 0 {
+			return nil, errors.New("--member and --read-preference cannot be used with --slow-log")
+		}
+		if opts.Member != "" && opts.ReadPreference != readPreferencePrimary {
+			return nil, errors.New("--member cannot be used with --read-preference")
+		}
+	}
+
+	if opts.ReadFrom != readFromPrimary && opts.ReadFrom != readFromSecondaries {
+		return nil, fmt.Errorf("invalid --read-from value %q", opts.ReadFrom)
+	}
+
+	switch opts.Output {
+	case "", "text", "json", "openmetrics":
+	case "markdown", "html":
+		if opts.Watch {
+			return nil, fmt.Errorf("--watch cannot be used with --output %s", opts.Output)
+		}
+	default:
+		return nil, fmt.Errorf("invalid output %q", opts.Output)
+	}
+	if opts.Output == "text" {
+		opts.OutputFormat = "text"
+	}
+
 	if opts.OutputFormat != "json" && opts.OutputFormat != "text" {
 		log.Infof("Invalid output format '%s'. Using text format", opts.OutputFormat)
 		opts.OutputFormat = "text"
@@ -344,6 +835,43 @@ func getOptions() (*cliOptions, error) {
 	return opts, nil
 }
 
+// parsePercentiles parses the --percentiles list, each one greater than 0 and up to 100.
+func parsePercentiles(list []string) ([]float64, error) {
+	percentiles := []float64{}
+	for _, s := range list {
+		p, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("invalid percentile %q", s)
+		}
+		percentiles = append(percentiles, p)
+	}
+
+	return percentiles, nil
+}
+
+// parseFilterTime parses the time of --since and --until: an RFC3339 time, a local date with an optional
+// time, or a duration before now. An empty string is the zero time.
+func parseFilterTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is neither a time, a date nor a duration", s)
+}
+
 func getClientOptions(opts *cliOptions) (*options.ClientOptions, error) {
 	clientOptions := options.Client().ApplyURI(opts.Host)
 	credential := options.Credential{}
@@ -359,11 +887,48 @@ func getClientOptions(opts *cliOptions) (*options.ClientOptions, error) {
 	return clientOptions, nil
 }
 
-func getHeaders(opts *cliOptions) []string {
+// newStats returns the statistics of the operations, bounded by the limits.
+func newStats(groupBy stats.GroupBy, limits stats.Limits) *stats.Stats {
+	fp := fingerprinter.NewFingerprinter(fingerprinter.DefaultKeyFilters())
+	s := stats.NewWithGroupBy(fp, groupBy)
+	s.SetLimits(limits)
+
+	return s
+}
+
+func getLimits(opts *cliOptions) stats.Limits {
+	return stats.Limits{MaxFingerprints: opts.MaxFingerprints, MaxSamples: opts.MaxSamples}
+}
+
+// splitEvicted returns the statistics of the fingerprints, without the evicted operations of
+// --max-fingerprints, and the number of these operations.
+func splitEvicted(queriesStats []stats.QueryStats) ([]stats.QueryStats, int) {
+	for i, qs := range queriesStats {
+		if qs.ID == stats.EvictedID {
+			return append(queriesStats[:i:i], queriesStats[i+1:]...), qs.Count
+		}
+	}
+
+	return queriesStats, 0
+}
+
+func getHeaders(opts *cliOptions, evicted int) []string {
 	h := []string{
 		fmt.Sprintf("%s - %s\n", toolname, time.Now().Format(time.RFC1123Z)),
-		fmt.Sprintf("Host: %s\n", opts.Host),
-		fmt.Sprintf("Skipping profiled queries on these collections: %v\n", opts.SkipCollections),
+	}
+	if len(opts.SlowLogs) > 0 {
+		h = append(h, fmt.Sprintf("Slow logs: %s\n", strings.Join(opts.SlowLogs, ", ")))
+	} else {
+		h = append(h, fmt.Sprintf("Host: %s\n", opts.Host))
+	}
+	h = append(h, fmt.Sprintf("Skipping profiled queries on these collections: %v\n", opts.SkipCollections))
+	if rate, _ := parseSampleRate(opts.SampleRate); rate < 1 {
+		h = append(h, fmt.Sprintf("Sample rate: %g, the counts and totals are estimated from the sampled operations\n",
+			rate))
+	}
+	if evicted > 0 {
+		h = append(h, fmt.Sprintf("Evicted: %d operations of the fingerprints past --max-fingerprints, only counted "+
+			"in the totals\n", evicted))
 	}
 	return h
 }
@@ -373,6 +938,9 @@ func getQueryTemplate() string {
 # Query {{.Rank}}: {{printf "% 0.2f" .QPS}} QPS, ID {{.ID}}
 # Ratio {{Format .Ratio 7.2}} (docs scanned/returned)
 # Time range: {{.FirstSeen}} to {{.LastSeen}}
+{{- if .Shards }}
+# Shards: {{ join .Shards ", " }}
+{{- end }}
 # Attribute            pct     total        min         max        avg         95%        stddev      median
 # ==================   ===   ========    ========    ========    ========    ========     =======    ========
 # Count (docs)               {{printf "% 7d " .Count}}
@@ -380,11 +948,36 @@ func getQueryTemplate() string {
 # Docs Scanned        {{printf "% 4.0f" .Scanned.Pct}}   {{Format .Scanned.Total 7.2}}    {{Format .Scanned.Min 7.2}}    {{Format .Scanned.Max 7.2}}    {{Format .Scanned.Avg 7.2}}    {{Format .Scanned.Pct95 7.2}}    {{Format .Scanned.StdDev 7.2}}    {{Format .Scanned.Median 7.2}}
 # Docs Returned       {{printf "% 4.0f" .Returned.Pct}}   {{Format .Returned.Total 7.2}}    {{Format .Returned.Min 7.2}}    {{Format .Returned.Max 7.2}}    {{Format .Returned.Avg 7.2}}    {{Format .Returned.Pct95 7.2}}    {{Format .Returned.StdDev 7.2}}    {{Format .Returned.Median 7.2}}
 # Bytes sent          {{printf "% 4.0f" .ResponseLength.Pct}}   {{Format .ResponseLength.Total 7.2}}    {{Format .ResponseLength.Min 7.2}}    {{Format .ResponseLength.Max 7.2}}    {{Format .ResponseLength.Avg 7.2}}    {{Format .ResponseLength.Pct95 7.2}}    {{Format .ResponseLength.StdDev 7.2}}    {{Format .ResponseLength.Median 7.2}}
+# Exec Time pctl ms {{ range .QueryTimePercentiles }}   {{.Label}} {{printf "%.0f" .Value}}{{ end }}
+# Exec Time dist       1ms |{{ sparkline .QueryTimeHistogram }}| 10s+
 # String:
 # Namespace           {{.Namespace}}
 # Operation           {{.Operation}}
 # Fingerprint         {{.Fingerprint}}
+{{- if .GroupLabel }}
+# {{printf "%-19s" .GroupLabel}} {{ or .Group "-" }}
+{{- end }}
+{{- if .Class }}
+# Class               {{.Class}}
+{{- end }}
+{{- if .Aborted }}
+# Aborted             {{.Aborted}} ({{.Conflicts}} write conflict errors)
+{{- end }}
+{{- if .Errors }}
+# Errors              {{ errors .Errors }}
+{{- end }}
 # Query               {{.Query}}
+{{- with .Plan }}
+{{- if .Error }}
+# Plan                cannot explain: {{.Error}}
+{{- else }}
+# Plan                {{ join .Stages " <- " }}{{ if .Indexes }}, index {{ join .Indexes ", " }}{{ end }}
+# Examined/returned   keys {{printf "%.2f" .KeysExaminedRatio}}, docs {{printf "%.2f" .DocsExaminedRatio}}
+{{- range .Advice }}
+# Advice              {{.}}
+{{- end }}
+{{- end }}
+{{- end }}
 `
 	return t
 }
@@ -400,11 +993,60 @@ func getTotalsTemplate() string {
 # Docs Scanned        {{printf "% 4.0f" .Scanned.Pct}}   {{Format .Scanned.Total 7.2}}    {{Format .Scanned.Min 7.2}}    {{Format .Scanned.Max 7.2}}    {{Format .Scanned.Avg 7.2}}    {{Format .Scanned.Pct95 7.2}}    {{Format .Scanned.StdDev 7.2}}    {{Format .Scanned.Median 7.2}}
 # Docs Returned       {{printf "% 4.0f" .Returned.Pct}}   {{Format .Returned.Total 7.2}}    {{Format .Returned.Min 7.2}}    {{Format .Returned.Max 7.2}}    {{Format .Returned.Avg 7.2}}    {{Format .Returned.Pct95 7.2}}    {{Format .Returned.StdDev 7.2}}    {{Format .Returned.Median 7.2}}
 # Bytes sent          {{printf "% 4.0f" .ResponseLength.Pct}}   {{Format .ResponseLength.Total 7.2}}    {{Format .ResponseLength.Min 7.2}}    {{Format .ResponseLength.Max 7.2}}    {{Format .ResponseLength.Avg 7.2}}    {{Format .ResponseLength.Pct95 7.2}}    {{Format .ResponseLength.StdDev 7.2}}    {{Format .ResponseLength.Median 7.2}}
+# Exec Time pctl ms {{ range .QueryTimePercentiles }}   {{.Label}} {{printf "%.0f" .Value}}{{ end }}
+# Exec Time dist       1ms |{{ sparkline .QueryTimeHistogram }}| 10s+
+#
+`
+	return t
+}
+
+func getClassesTemplate() string {
+	t := `# Classes             count   time total ms   time avg ms   aborted   write conflict errors
+{{- range . }}
+# {{printf "%-16s" .Class}} {{printf "% 8d" .Count}}   {{printf "% 13.0f" .QueryTime}}   {{printf "% 11.2f" .AvgQueryTime}}   {{printf "% 7d" .Aborted}}   {{printf "% 21d" .Conflicts}}
+{{- end }}
 #
 `
 	return t
 }
 
+func getErrorsTemplate() string {
+	t := `# Errors                                       count
+{{- range . }}
+# {{printf "%-40s" .Label}} {{printf "% 8d" .Count}}
+{{- range .Fingerprints }}
+#   {{printf "% 6d" .Count}}  {{.ID}}  {{.Namespace}}  {{.Operation}}
+{{- end }}
+{{- end }}
+#
+`
+	return t
+}
+
+func getHeatmapTemplate() string {
+	t := `# Heatmap: % of the execution time by namespace and operation
+# Namespace                       time ms      count{{ range .Operations }} {{printf "%14s" .}}{{ end }}
+{{- range .Namespaces }}
+# {{printf "%-28s" .Namespace}} {{printf "% 10.0f" .QueryTime}} {{printf "% 10d" .Count}}{{ range .Cells }} {{ heat . }}{{ end }}
+{{- end }}
+#
+`
+	return t
+}
+
+func getIndexesTemplate() string {
+	t := `
+# Index suggestions
+# Indexes for the collection scans, with their fields ordered by equality, sort and range
+{{- range . }}
+# {{.Namespace}}  {{.Spec}}  supports {{.Operations}} operations of {{len .Fingerprints}} queries
+{{- else }}
+# none
+{{- end }}
+`
+	return t
+}
+
 type lessFunc func(p1, p2 *stats.QueryStats) bool
 
 type multiSorter struct {
@@ -465,63 +1107,75 @@ func (ms *multiSorter) Less(i, j int) bool {
 func sortQueries(queries []stats.QueryStats, orderby []string) []stats.QueryStats {
 	sortFuncs := []lessFunc{}
 	for _, field := range orderby {
-		var f lessFunc
-		switch field {
-		//
-		case "count":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.Count < c2.Count
-			}
-		case "-count":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.Count > c2.Count
-			}
+		f, err := parseOrderBy(field)
+		if err != nil {
+			continue // validated by getOptions
+		}
+		sortFuncs = append(sortFuncs, f)
+	}
 
-		case "ratio":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.Ratio < c2.Ratio
-			}
-		case "-ratio":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.Ratio > c2.Ratio
-			}
+	orderedBy(sortFuncs...).Sort(queries)
+	return queries
+}
 
-		//
-		case "query-time":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.QueryTime.Max < c2.QueryTime.Max
-			}
-		case "-query-time":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.QueryTime.Max > c2.QueryTime.Max
-			}
+// orderByMetrics are the metrics of --order-by, ranked by an aggregate of orderByAggregates.
+var orderByMetrics = map[string]func(qs *stats.QueryStats) stats.Statistics{
+	"query-time":      func(qs *stats.QueryStats) stats.Statistics { return qs.QueryTime },
+	"docs-scanned":    func(qs *stats.QueryStats) stats.Statistics { return qs.Scanned },
+	"docs-returned":   func(qs *stats.QueryStats) stats.Statistics { return qs.Returned },
+	"keys-examined":   func(qs *stats.QueryStats) stats.Statistics { return qs.KeysExamined },
+	"bytes-sent":      func(qs *stats.QueryStats) stats.Statistics { return qs.ResponseLength },
+	"num-yields":      func(qs *stats.QueryStats) stats.Statistics { return qs.NumYield },
+	"write-conflicts": func(qs *stats.QueryStats) stats.Statistics { return qs.WriteConflicts },
+	"lock-wait":       func(qs *stats.QueryStats) stats.Statistics { return qs.LockWait },
+}
 
-		//
-		case "docs-scanned":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.Scanned.Max < c2.Scanned.Max
-			}
-		case "-docs-scanned":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.Scanned.Max > c2.Scanned.Max
-			}
+var orderByAggregates = map[string]func(s stats.Statistics) float64{
+	"max":    func(s stats.Statistics) float64 { return s.Max },
+	"min":    func(s stats.Statistics) float64 { return s.Min },
+	"sum":    func(s stats.Statistics) float64 { return s.Total },
+	"avg":    func(s stats.Statistics) float64 { return s.Avg },
+	"median": func(s stats.Statistics) float64 { return s.Median },
+	"p95":    func(s stats.Statistics) float64 { return s.Pct95 },
+	"p99":    func(s stats.Statistics) float64 { return s.Pct99 },
+}
 
-		//
-		case "docs-returned":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.Returned.Max < c2.Scanned.Max
-			}
-		case "-docs-returned":
-			f = func(c1, c2 *stats.QueryStats) bool {
-				return c1.Returned.Max > c2.Scanned.Max
-			}
+// parseOrderBy returns the less function of an --order-by field: count, ratio, or a metric followed by the
+// aggregate ranked by, like query-time:p99, the maximum by default. A - in front of the field denotes the
+// reverse order, from the highest value.
+func parseOrderBy(field string) (lessFunc, error) {
+	name := strings.TrimPrefix(field, "-")
+	reverse := name != field
+
+	var value func(qs *stats.QueryStats) float64
+	switch name {
+	case "count":
+		value = func(qs *stats.QueryStats) float64 { return float64(qs.Count) }
+	case "ratio":
+		value = func(qs *stats.QueryStats) float64 { return qs.Ratio }
+	case "errors":
+		value = func(qs *stats.QueryStats) float64 { return float64(qs.Failed) }
+	default:
+		metricName, aggregateName := name, "max"
+		if i := strings.Index(name, ":"); i >= 0 {
+			metricName, aggregateName = name[:i], name[i+1:]
 		}
-		// count,query-time,docs-scanned, docs-returned. - in front of the field name denotes reverse order.")
-		sortFuncs = append(sortFuncs, f)
+		metric, ok := orderByMetrics[metricName]
+		if !ok {
+			return nil, fmt.Errorf("invalid sort field %q", field)
+		}
+		aggregate, ok := orderByAggregates[aggregateName]
+		if !ok {
+			return nil, fmt.Errorf("invalid aggregate %q of the sort field %q", aggregateName, field)
+		}
+		value = func(qs *stats.QueryStats) float64 { return aggregate(metric(qs)) }
 	}
 
-	orderedBy(sortFuncs...).Sort(queries)
-	return queries
+	if reverse {
+		return func(c1, c2 *stats.QueryStats) bool { return value(c1) > value(c2) }, nil
+	}
+
+	return func(c1, c2 *stats.QueryStats) bool { return value(c1) < value(c2) }, nil
 }
 
 func isProfilerEnabled(ctx context.Context, clientOptions *options.ClientOptions, dbname string) (bool, error) {
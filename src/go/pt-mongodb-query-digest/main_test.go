@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -18,12 +19,15 @@ import (
 	"text/template"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/pborman/getopt"
 	"github.com/percona/percona-toolkit/src/go/lib/profiling"
 	"github.com/percona/percona-toolkit/src/go/lib/tutil"
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
 	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
 )
 
@@ -111,9 +115,16 @@ func TestParseArgs(t *testing.T) {
 				Host:            "mongodb://" + DEFAULT_HOST,
 				LogLevel:        DEFAULT_LOGLEVEL,
 				OrderBy:         strings.Split(DEFAULT_ORDERBY, ","),
+				Percentiles:     strings.Split(DEFAULT_PERCENTILES, ","),
 				SkipCollections: strings.Split(DEFAULT_SKIPCOLLECTIONS, ","),
 				AuthDB:          DEFAULT_AUTHDB,
 				OutputFormat:    "text",
+				ReadFrom:        readFromPrimary,
+				MetricsMax:      DEFAULT_METRICS_MAX_FINGERPRINTS,
+				MaxFingerprints: DEFAULT_MAX_FINGERPRINTS,
+				MaxSamples:      DEFAULT_MAX_SAMPLES,
+				Window:          DEFAULT_WATCH_WINDOW,
+				Interval:        DEFAULT_WATCH_INTERVAL,
 			},
 		},
 		{
@@ -126,10 +137,17 @@ func TestParseArgs(t *testing.T) {
 				Host:            "mongodb://zapp.brannigan.net:27018/samples",
 				LogLevel:        DEFAULT_LOGLEVEL,
 				OrderBy:         strings.Split(DEFAULT_ORDERBY, ","),
+				Percentiles:     strings.Split(DEFAULT_PERCENTILES, ","),
 				SkipCollections: strings.Split(DEFAULT_SKIPCOLLECTIONS, ","),
 				AuthDB:          DEFAULT_AUTHDB,
 				Help:            false,
 				OutputFormat:    "text",
+				ReadFrom:        readFromPrimary,
+				MetricsMax:      DEFAULT_METRICS_MAX_FINGERPRINTS,
+				MaxFingerprints: DEFAULT_MAX_FINGERPRINTS,
+				MaxSamples:      DEFAULT_MAX_SAMPLES,
+				Window:          DEFAULT_WATCH_WINDOW,
+				Interval:        DEFAULT_WATCH_INTERVAL,
 			},
 		},
 	}
@@ -150,6 +168,743 @@ func TestParseArgs(t *testing.T) {
 	}
 }
 
+func TestParseCollectionSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    collectionSpec
+		wantErr bool
+	}{
+		{
+			spec: "percona.query_review",
+			want: collectionSpec{Database: "percona", Collection: "query_review"},
+		},
+		{
+			spec: "mongodb://review.example.com:27017/percona.query.review?authSource=admin",
+			want: collectionSpec{
+				URI:        "mongodb://review.example.com:27017/?authSource=admin",
+				Database:   "percona",
+				Collection: "query.review",
+			},
+		},
+		{spec: "query_review", wantErr: true},
+		{spec: "mongodb://review.example.com:27017", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCollectionSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCollectionSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseCollectionSpec(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestCompareFingerprints(t *testing.T) {
+	before := map[string]*fingerprintPeriod{
+		"slower":      {ID: "slower", Count: 10, QueryTime: 100},
+		"faster":      {ID: "faster", Count: 10, QueryTime: 100},
+		"steady":      {ID: "steady", Count: 10, QueryTime: 100},
+		"disappeared": {ID: "disappeared", Count: 5, QueryTime: 50},
+	}
+	after := map[string]*fingerprintPeriod{
+		"slower":   {ID: "slower", Count: 10, QueryTime: 150},
+		"faster":   {ID: "faster", Count: 20, QueryTime: 100},
+		"steady":   {ID: "steady", Count: 10, QueryTime: 110},
+		"appeared": {ID: "appeared", Count: 3, QueryTime: 30},
+	}
+
+	c := compareFingerprints(before, after, 20)
+
+	ids := func(changes []fingerprintChange) []string {
+		s := []string{}
+		for _, change := range changes {
+			s = append(s, change.ID)
+		}
+		return s
+	}
+	for name, got := range map[string][]string{
+		"slower":      ids(c.Slower),
+		"faster":      ids(c.Faster),
+		"appeared":    ids(c.Appeared),
+		"disappeared": ids(c.Disappeared),
+	} {
+		if !reflect.DeepEqual(got, []string{name}) {
+			t.Errorf("%s = %v, want [%s]", name, got, name)
+		}
+	}
+
+	if c.Slower[0].ChangePct != 50 {
+		t.Errorf("slower change = %v, want 50", c.Slower[0].ChangePct)
+	}
+}
+
+func TestCompareFingerprintsNewLatency(t *testing.T) {
+	// operations under 1 ms are profiled with 0 ms
+	before := map[string]*fingerprintPeriod{
+		"slower": {ID: "slower", Count: 10, QueryTime: 100},
+		"new":    {ID: "new", Count: 10, QueryTime: 0},
+		"steady": {ID: "steady", Count: 10, QueryTime: 0},
+	}
+	after := map[string]*fingerprintPeriod{
+		"slower": {ID: "slower", Count: 10, QueryTime: 1000},
+		"new":    {ID: "new", Count: 10, QueryTime: 5000},
+		"steady": {ID: "steady", Count: 10, QueryTime: 0},
+	}
+
+	c := compareFingerprints(before, after, 20)
+
+	if len(c.Slower) != 2 || c.Slower[0].ID != "new" || !c.Slower[0].NewLatency || c.Slower[1].NewLatency {
+		t.Fatalf("slower = %+v, want the new latency first, then the slower one", c.Slower)
+	}
+	if c.Slower[0].AvgAfter != 500 {
+		t.Errorf("new latency avg = %v, want 500", c.Slower[0].AvgAfter)
+	}
+
+	out, err := formatComparison(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "avg exec time 0.00 ms -> 500.00 ms (new latency)") {
+		t.Errorf("the new latency is not reported:\n%s", out)
+	}
+}
+
+func TestDiffDigests(t *testing.T) {
+	query := func(id string, count int, qps, queryTime float64) jsonQuery {
+		return jsonQuery{ID: id, Count: count, QPS: qps, QueryTimeMs: jsonStatistics{Total: queryTime}}
+	}
+	oldDigest := jsonReport{UptimeSeconds: 100, Queries: []jsonQuery{
+		query("slower", 10, 0.1, 100),
+		query("busier", 10, 0.1, 100),
+		query("quieter", 10, 0.1, 100),
+		query("disappeared", 5, 0.05, 50),
+		{Count: 3}, // the evicted operations
+	}}
+	// twice as long: the same counts are half as frequent
+	newDigest := jsonReport{UptimeSeconds: 200, Queries: []jsonQuery{
+		query("slower", 20, 0.1, 300),
+		query("busier", 40, 0.2, 400),
+		query("quieter", 10, 0.05, 100),
+		query("appeared", 3, 0.015, 30),
+	}}
+
+	d := diffDigests(oldDigest, newDigest, 20, 50)
+
+	ids := func(changes []fingerprintChange) []string {
+		s := []string{}
+		for _, change := range changes {
+			s = append(s, change.ID)
+		}
+		return s
+	}
+	for _, tc := range []struct {
+		name string
+		got  []fingerprintChange
+		want []string
+	}{
+		{"slower", d.Slower, []string{"slower"}},
+		{"faster", d.Faster, []string{}},
+		{"more frequent", d.MoreFrequent, []string{"busier"}},
+		{"less frequent", d.LessFrequent, []string{"quieter"}},
+		{"appeared", d.Appeared, []string{"appeared"}},
+		{"disappeared", d.Disappeared, []string{"disappeared"}},
+	} {
+		if got := ids(tc.got); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if d.Slower[0].ChangePct != 50 || d.Slower[0].CountChangePct != 0 {
+		t.Errorf("got the slower change %+v, want +50%% and the same count per second", d.Slower[0])
+	}
+	if d.MoreFrequent[0].CountChangePct != 100 {
+		t.Errorf("got the count change %v, want +100%%", d.MoreFrequent[0].CountChangePct)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	p, err := parsePeriod("2023-05-01T00:00:00Z,2023-05-02")
+	if err != nil {
+		t.Fatalf("cannot parse the period: %s", err)
+	}
+	if want := time.Date(2023, 5, 3, 0, 0, 0, 0, time.Local); !p.To.Equal(want) {
+		t.Errorf("period to = %v, want %v", p.To, want)
+	}
+
+	for _, s := range []string{"2023-05-01", "2023-05-02,2023-05-01", "yesterday,today"} {
+		if _, err := parsePeriod(s); err == nil {
+			t.Errorf("parsePeriod(%q) should fail", s)
+		}
+	}
+}
+
+func TestNewQueryPlan(t *testing.T) {
+	explainOutput := `{
+		"queryPlanner": {
+			"namespace": "test.coll",
+			"winningPlan": {
+				"stage": "FETCH",
+				"filter": {"b": {"$eq": 1}},
+				"inputStage": {"stage": "IXSCAN", "keyPattern": {"a": 1}, "indexName": "a_1"}
+			},
+			"rejectedPlans": [{"stage": "COLLSCAN"}]
+		},
+		"ok": 1
+	}`
+	result := bson.M{}
+	if err := bson.UnmarshalExtJSON([]byte(explainOutput), true, &result); err != nil {
+		t.Fatalf("cannot parse the explain output: %s", err)
+	}
+
+	qs := stats.QueryStats{
+		Scanned:      stats.Statistics{Total: 2000},
+		KeysExamined: stats.Statistics{Total: 2000},
+		Returned:     stats.Statistics{Total: 100},
+	}
+	plan := newQueryPlan(result, qs)
+
+	want := &queryPlan{
+		Indexes:           []string{"a_1"},
+		Stages:            []string{"FETCH", "IXSCAN"},
+		KeysExaminedRatio: 20,
+		DocsExaminedRatio: 20,
+		Advice:            []string{"20 documents examined per document returned, the index is not selective enough"},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("newQueryPlan() = %+v, want %+v", plan, want)
+	}
+}
+
+func TestSuggestIndexes(t *testing.T) {
+	queries := []stats.QueryStats{
+		{
+			ID:        "1",
+			Namespace: "test.coll",
+			Count:     10,
+			TableScan: true,
+			Query:     `{"ns":"test.coll","op":"query","command":{"find":"coll","filter":{"status":"A","qty":{"$gt":5}},"sort":{"date":-1}}}`,
+		},
+		{
+			ID:        "2",
+			Namespace: "test.coll",
+			Count:     5,
+			TableScan: true,
+			Query:     `{"ns":"test.coll","op":"query","command":{"find":"coll","filter":{"status":{"$in":["A","B"]}}}}`,
+		},
+		{
+			ID:        "3",
+			Namespace: "test.coll",
+			Count:     100,
+			Query:     `{"ns":"test.coll","op":"query","command":{"find":"coll","filter":{"name":"x"}}}`,
+		},
+		{
+			ID:        "4",
+			Namespace: "test.other",
+			Count:     3,
+			Query:     `{"ns":"test.other","op":"remove","command":{"q":{"user":1,"$or":[{"a":1},{"b":2}]},"limit":0}}`,
+		},
+	}
+	plans := map[string]*queryPlan{"4": {CollScan: true}}
+
+	want := []indexSuggestion{
+		{
+			Namespace:    "test.coll",
+			Keys:         []indexKey{{"status", 1}, {"date", -1}, {"qty", 1}},
+			Fingerprints: []string{"1", "2"},
+			Operations:   15,
+		},
+		{
+			Namespace:    "test.other",
+			Keys:         []indexKey{{"user", 1}},
+			Fingerprints: []string{"4"},
+			Operations:   3,
+		},
+	}
+
+	got := suggestIndexes(queries, plans)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("suggestIndexes() = %+v, want %+v", got, want)
+	}
+	if spec := got[0].Spec(); spec != "{ status: 1, date: -1, qty: 1 }" {
+		t.Errorf("Spec() = %q", spec)
+	}
+}
+
+func TestParsePercentiles(t *testing.T) {
+	got, err := parsePercentiles([]string{"50", " 99.9", "100"})
+	if err != nil {
+		t.Fatalf("parsePercentiles() error: %s", err)
+	}
+	if want := []float64{50, 99.9, 100}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePercentiles() = %v, want %v", got, want)
+	}
+
+	for _, s := range []string{"0", "101", "p99", ""} {
+		if _, err := parsePercentiles([]string{s}); err == nil {
+			t.Errorf("parsePercentiles(%q) should fail", s)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got, want := sparkline([]int{0, 1, 4, 8, 0}), " ▁▄█ "; got != want {
+		t.Errorf("sparkline() = %q, want %q", got, want)
+	}
+	if got := sparkline([]int{0, 0}); got != "  " {
+		t.Errorf("sparkline() of an empty histogram = %q", got)
+	}
+}
+
+func TestParseFilterTime(t *testing.T) {
+	now := time.Date(2023, 5, 2, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"2023-05-01T22:00:00Z", time.Date(2023, 5, 1, 22, 0, 0, 0, time.UTC)},
+		{"2023-05-01 22:30", time.Date(2023, 5, 1, 22, 30, 0, 0, time.Local)},
+		{"2023-05-01", time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local)},
+		{"12h", time.Date(2023, 5, 1, 20, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		got, err := parseFilterTime(test.s, now)
+		if err != nil {
+			t.Errorf("parseFilterTime(%q) error: %s", test.s, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("parseFilterTime(%q) = %s, want %s", test.s, got, test.want)
+		}
+	}
+
+	for _, s := range []string{"yesterday", "-1h", "2023-13-01"} {
+		if _, err := parseFilterTime(s, now); err == nil {
+			t.Errorf("parseFilterTime(%q) should fail", s)
+		}
+	}
+}
+
+func TestGetGroupBy(t *testing.T) {
+	doc := proto.SystemProfile{AppName: "orders-service", User: "app@admin", Client: "10.0.0.1:51234"}
+	tests := map[string]string{
+		groupByAppName: "orders-service",
+		groupByUser:    "app@admin",
+		groupByClient:  "10.0.0.1",
+	}
+	for dimension, want := range tests {
+		groupBy, err := getGroupBy(dimension)
+		if err != nil {
+			t.Fatalf("getGroupBy(%q) error: %s", dimension, err)
+		}
+		if got := groupBy(doc); got != want {
+			t.Errorf("getGroupBy(%q) = %q, want %q", dimension, got, want)
+		}
+	}
+
+	if groupBy, err := getGroupBy(""); groupBy != nil || err != nil {
+		t.Errorf("getGroupBy(\"\") should not group")
+	}
+	if _, err := getGroupBy("database"); err == nil {
+		t.Errorf("getGroupBy(\"database\") should fail")
+	}
+}
+
+func TestRollingWindow(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	w := &rollingWindow{size: 5 * time.Minute}
+	w.add(proto.SystemProfile{Ns: "test.coll", Op: "query", Ts: now.Add(-10 * time.Minute), Millis: 1})
+	w.add(proto.SystemProfile{Ns: "test.coll", Op: "query", Ts: now.Add(-2 * time.Minute), Millis: 2})
+	w.add(proto.SystemProfile{Ns: "test.coll", Op: "insert", Ts: now.Add(-time.Minute), Millis: 3})
+
+	w.expire(now)
+	if len(w.docs) != 2 {
+		t.Fatalf("expire() kept %d operations, want 2", len(w.docs))
+	}
+
+	queries := w.queries(nil)
+	if len(queries) != 2 {
+		t.Errorf("queries() = %d queries, want 2", len(queries))
+	}
+}
+
+func TestFollowReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("line 1\n")) //nolint
+		pw.Close()
+	}()
+
+	fr := &followReader{ctx: ctx, r: pr, poll: time.Millisecond}
+	buf := make([]byte, 64)
+	n, err := fr.Read(buf)
+	if err != nil || string(buf[:n]) != "line 1\n" {
+		t.Fatalf("Read() = %q, %v", buf[:n], err)
+	}
+
+	// at the end, it waits for more data until the context is done
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if n, err := fr.Read(buf); n != 0 || err != io.EOF {
+		t.Errorf("Read() after the end = %d, %v, want io.EOF", n, err)
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	queries := []stats.QueryStats{
+		{
+			ID: "a1", Namespace: "test.coll", Operation: "FIND", Fingerprint: `FIND coll "a"`, Count: 2,
+			QueryTime:            stats.Statistics{Total: 1500},
+			QueryTimePercentiles: []stats.Percentile{{Percent: 99, Value: 1000}},
+		},
+		{ID: "b2", Namespace: "test.coll", Operation: "INSERT", Fingerprint: "INSERT coll", Count: 3},
+		{ID: "c3", Namespace: "test.other", Operation: "REMOVE", Fingerprint: "REMOVE other", Count: 4},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeMetrics(buf, queries, 1, ""); err != nil {
+		t.Fatalf("writeMetrics() error: %s", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"mongodb_query_digest_fingerprints 3\n",
+		"# TYPE mongodb_query_digest_query_time_seconds summary\n",
+		`mongodb_query_digest_query_time_seconds{id="a1",namespace="test.coll",operation="FIND",` +
+			`fingerprint="FIND coll \"a\"",quantile="0.99"} 1` + "\n",
+		`mongodb_query_digest_query_time_seconds_sum{id="a1",namespace="test.coll",operation="FIND",` +
+			`fingerprint="FIND coll \"a\""} 1.5` + "\n",
+		// the fingerprints over the cap are summed up
+		`mongodb_query_digest_query_time_seconds_count{id="other",namespace="other",operation="other",` +
+			`fingerprint="other"} 7` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("writeMetrics() output misses %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `"b2"`) {
+		t.Errorf("writeMetrics() exported a fingerprint over the cap:\n%s", got)
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	query := `{"ns":"test.users","op":"command","command":{"find":"users",` +
+		`"filter":{"email":"fry@planetexpress.com","age":{"$gt":{"$numberInt":"30"}},` +
+		`"tags":{"$in":["a","b"]}},"sort":{"age":{"$numberInt":"-1"}},"limit":{"$numberInt":"10"},"$db":"test"}}`
+	want := `{"ns":"test.users","op":"command","command":{"find":"users",` +
+		`"filter":{"email":"?","age":{"$gt":"?"},"tags":{"$in":["?","?"]}},` +
+		`"sort":{"age":{"$numberInt":"-1"}},"limit":{"$numberInt":"10"},"$db":"test"}}`
+	if got := redactQuery(query); got != want {
+		t.Errorf("redactQuery() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := redactQuery("not a query"); got != redactedValue {
+		t.Errorf("redactQuery() of an invalid query = %q", got)
+	}
+}
+
+func TestSplitEvicted(t *testing.T) {
+	queries := []stats.QueryStats{{ID: "a1", Count: 2}, {ID: stats.EvictedID, Count: 5}, {ID: "b2", Count: 1}}
+
+	got, evicted := splitEvicted(queries)
+	if evicted != 5 || len(got) != 2 || got[0].ID != "a1" || got[1].ID != "b2" {
+		t.Errorf("splitEvicted() = %+v, %d", got, evicted)
+	}
+	if queries[1].ID != stats.EvictedID {
+		t.Errorf("splitEvicted() modified its argument: %+v", queries)
+	}
+}
+
+func TestSortQueries(t *testing.T) {
+	queries := []stats.QueryStats{
+		{ID: "a", Count: 10, QueryTime: stats.Statistics{Total: 100, Max: 50, Pct99: 40}},
+		{ID: "b", Count: 1, QueryTime: stats.Statistics{Total: 900, Max: 900, Pct99: 900}},
+		{ID: "c", Count: 50, QueryTime: stats.Statistics{Total: 500, Max: 20, Pct99: 15},
+			WriteConflicts: stats.Statistics{Total: 3}},
+	}
+	tests := []struct {
+		orderBy []string
+		want    string
+	}{
+		{[]string{"-count"}, "cab"},
+		{[]string{"-query-time"}, "bac"},
+		{[]string{"-query-time:sum"}, "bca"},
+		{[]string{"query-time:p99"}, "cab"},
+		{[]string{"-write-conflicts:sum", "count"}, "cba"},
+	}
+	for _, test := range tests {
+		got := ""
+		for _, qs := range sortQueries(append([]stats.QueryStats{}, queries...), test.orderBy) {
+			got += qs.ID
+		}
+		if got != test.want {
+			t.Errorf("sortQueries(%v) = %s, want %s", test.orderBy, got, test.want)
+		}
+	}
+
+	for _, field := range []string{"docs", "query-time:p42", "-keys-examined:"} {
+		if _, err := parseOrderBy(field); err == nil {
+			t.Errorf("parseOrderBy(%q) did not fail", field)
+		}
+	}
+}
+
+func TestGetClassTotals(t *testing.T) {
+	queries := []stats.QueryStats{
+		{ID: "a", Count: 4, QueryTime: stats.Statistics{Total: 40}},
+		{ID: "b", Count: 2, QueryTime: stats.Statistics{Total: 100}, Class: proto.ClassTransaction, Aborted: 1, Conflicts: 1},
+		{ID: "c", Count: 3, QueryTime: stats.Statistics{Total: 30}, Class: proto.ClassTransaction},
+	}
+
+	want := []classTotals{
+		{Class: classOther, Count: 4, QueryTime: 40},
+		{Class: proto.ClassTransaction, Count: 5, QueryTime: 130, Aborted: 1, Conflicts: 1},
+		{Class: proto.ClassRetryableWrite},
+	}
+	if got := getClassTotals(queries); !reflect.DeepEqual(got, want) {
+		t.Errorf("getClassTotals() = %+v, want %+v", got, want)
+	}
+	if avg := want[1].AvgQueryTime(); avg != 26 {
+		t.Errorf("AvgQueryTime() = %v, want 26", avg)
+	}
+
+	if got := getClassTotals(queries[:1]); got != nil {
+		t.Errorf("getClassTotals() without transactions = %+v, want nil", got)
+	}
+}
+
+func TestGetErrorTotals(t *testing.T) {
+	if errs := getErrorTotals([]stats.QueryStats{{ID: "a", Count: 3}}); errs != nil {
+		t.Errorf("got the errors %+v without failed operation", errs)
+	}
+
+	queries := []stats.QueryStats{
+		{ID: "a", Namespace: "test.users", Operation: "INSERT", Count: 10, Errors: []stats.ErrorCount{
+			{Code: 11000, Count: 2},
+		}},
+		{ID: "b", Namespace: "test.users", Operation: "UPDATE", Count: 5, Errors: []stats.ErrorCount{
+			{Code: 50, Name: "MaxTimeMSExpired", Count: 1},
+			{Code: 11000, Name: "DuplicateKey", Count: 3},
+		}},
+	}
+
+	errs := getErrorTotals(queries)
+	if len(errs) != 2 {
+		t.Fatalf("got the errors %+v, want DuplicateKey and MaxTimeMSExpired", errs)
+	}
+	if errs[0].Label() != "11000 DuplicateKey" || errs[0].Count != 5 || len(errs[0].Fingerprints) != 2 ||
+		errs[0].Fingerprints[0].ID != "b" {
+		t.Errorf("got the first error %+v, want DuplicateKey of b then a", errs[0])
+	}
+	if errs[1].Label() != "50 MaxTimeMSExpired" || errs[1].Count != 1 {
+		t.Errorf("got the second error %+v, want MaxTimeMSExpired", errs[1])
+	}
+
+	if got := errorsList(queries[1].Errors); got != "50 MaxTimeMSExpired: 1, 11000 DuplicateKey: 3" {
+		t.Errorf("errorsList() = %q", got)
+	}
+	if got := errorLabel(stats.ErrorCount{Code: 12345}); got != "12345" {
+		t.Errorf("errorLabel() of an unknown code = %q", got)
+	}
+}
+
+func TestNewHeatmap(t *testing.T) {
+	queries := []stats.QueryStats{
+		{Namespace: "shop.orders", Operation: "FIND", Count: 10, QueryTime: stats.Statistics{Total: 50}},
+		{Namespace: "shop.users", Operation: "UPDATE", Count: 2, QueryTime: stats.Statistics{Total: 20}},
+		{Namespace: "shop.orders", Operation: "FIND", Count: 5, QueryTime: stats.Statistics{Total: 10}},
+		{Namespace: "shop.orders", Operation: "UPDATE", Count: 1, QueryTime: stats.Statistics{Total: 20}},
+	}
+
+	h := newHeatmap(queries)
+	if !reflect.DeepEqual(h.Operations, []string{"FIND", "UPDATE"}) {
+		t.Errorf("got the operations %v, want FIND, UPDATE", h.Operations)
+	}
+	if len(h.Namespaces) != 2 || h.Namespaces[0].Namespace != "shop.orders" {
+		t.Fatalf("got the namespaces %+v, want shop.orders first", h.Namespaces)
+	}
+
+	want := heatmapRow{
+		Namespace:   "shop.orders",
+		heatmapCell: heatmapCell{Count: 16, QueryTime: 80, Pct: 80},
+		Cells:       []heatmapCell{{Count: 15, QueryTime: 60, Pct: 60}, {Count: 1, QueryTime: 20, Pct: 20}},
+	}
+	if !reflect.DeepEqual(h.Namespaces[0], want) {
+		t.Errorf("got %+v, want %+v", h.Namespaces[0], want)
+	}
+	if cell := h.Namespaces[1].Cells[0]; cell.Count != 0 || heatCell(cell) != fmt.Sprintf("%12s  ", "-") {
+		t.Errorf("got the cell %+v of the operation not run on shop.users", cell)
+	}
+}
+
+func TestParseProfilingSettings(t *testing.T) {
+	ps, err := parseProfilingSettings([]string{"level=2", "slowms=100", "samplerate=0.5"})
+	if err != nil {
+		t.Fatalf("cannot parse the settings: %s", err)
+	}
+	want := bson.D{{Key: "profile", Value: 2}, {Key: "slowms", Value: 100}, {Key: "sampleRate", Value: 0.5}}
+	if got := ps.command(); !reflect.DeepEqual(got, want) {
+		t.Errorf("command() = %v, want %v", got, want)
+	}
+
+	ps, err = parseProfilingSettings([]string{"ratelimit=100"})
+	if err != nil {
+		t.Fatalf("cannot parse the settings: %s", err)
+	}
+	want = bson.D{{Key: "profile", Value: 1}, {Key: "ratelimit", Value: 100}}
+	if got := ps.command(); !reflect.DeepEqual(got, want) {
+		t.Errorf("command() = %v, want %v", got, want)
+	}
+
+	for _, settings := range [][]string{{"level=3"}, {"slowms"}, {"samplerate=2"}, {"ratelimit=0"}, {"filter=x"}} {
+		if _, err := parseProfilingSettings(settings); err == nil {
+			t.Errorf("parseProfilingSettings(%v) did not fail", settings)
+		}
+	}
+
+	previous := proto.ProfilerStatus{Was: 0, SlowMs: 200, SampleRate: 1}
+	want = bson.D{{Key: "profile", Value: int64(0)}, {Key: "slowms", Value: int64(200)}, {Key: "sampleRate", Value: 1.0}}
+	if got := restoreCommand(previous); !reflect.DeepEqual(got, want) {
+		t.Errorf("restoreCommand() = %v, want %v", got, want)
+	}
+}
+
+func TestParseReadPreference(t *testing.T) {
+	for mode, want := range map[string]string{
+		"primary":            "primary",
+		"secondaryPreferred": "secondaryPreferred",
+		"SECONDARY":          "secondary",
+		"nearest":            "nearest",
+	} {
+		rp, err := parseReadPreference(mode)
+		if err != nil || rp.Mode().String() != want {
+			t.Errorf("parseReadPreference(%q) = %v, %v, want %s", mode, rp, err, want)
+		}
+	}
+
+	for _, mode := range []string{"", "secondaries", "hidden"} {
+		if _, err := parseReadPreference(mode); err == nil {
+			t.Errorf("parseReadPreference(%q) did not fail", mode)
+		}
+	}
+
+	if targetsMember(&cliOptions{ReadPreference: readPreferencePrimary}) {
+		t.Errorf("the primary read preference targets a member")
+	}
+	if !targetsMember(&cliOptions{ReadPreference: readPreferencePrimary, Member: "hidden:27017"}) {
+		t.Errorf("--member does not target a member")
+	}
+}
+
+func TestScaleQueryStats(t *testing.T) {
+	qs := stats.QueryStats{
+		Count:              25,
+		QPS:                2.5,
+		QueryTime:          stats.Statistics{Total: 100, Avg: 4, Max: 10, Pct95: 9},
+		Scanned:            stats.Statistics{Total: 50},
+		QueryTimeHistogram: []int{10, 15},
+	}
+
+	scaleQueryStats(&qs, 0.25)
+	if qs.Count != 100 || qs.QPS != 10 || qs.QueryTime.Total != 400 || qs.Scanned.Total != 200 {
+		t.Errorf("the counts and totals are not scaled: %+v", qs)
+	}
+	if qs.QueryTime.Avg != 4 || qs.QueryTime.Max != 10 || qs.QueryTime.Pct95 != 9 {
+		t.Errorf("the averages and percentiles are scaled: %+v", qs.QueryTime)
+	}
+	if !reflect.DeepEqual(qs.QueryTimeHistogram, []int{40, 60}) {
+		t.Errorf("the histogram is not scaled: %v", qs.QueryTimeHistogram)
+	}
+
+	for _, rate := range []string{"0", "-1", "1.5", "x"} {
+		if _, err := parseSampleRate(rate); err == nil {
+			t.Errorf("parseSampleRate(%q) did not fail", rate)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	filename := t.TempDir() + "/state.json"
+
+	state, err := loadState(filename, "slow.log")
+	if err != nil || !state.Checkpoint.IsZero() || state.Queries != nil {
+		t.Fatalf("got the state %+v and the error %v for a new state file", state, err)
+	}
+	if q := profileQuery(state.Checkpoint); len(q) != 0 {
+		t.Errorf("got the query %v without checkpoint, want all the documents", q)
+	}
+
+	last := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	queries := stats.Queries{
+		{ID: "a", Count: 2, QueryTime: []float64{1, 2}, FirstSeen: last.Add(-time.Hour), LastSeen: last},
+		{ID: "b", Count: 1, QueryTime: []float64{3}, FirstSeen: last.Add(-time.Minute), LastSeen: last.Add(-time.Minute)},
+	}
+	state.advance(queries, true)
+	if err := saveState(filename, state); err != nil {
+		t.Fatalf("cannot save the state: %s", err)
+	}
+
+	state, err = loadState(filename, "slow.log")
+	if err != nil {
+		t.Fatalf("cannot load the state: %s", err)
+	}
+	if !state.Checkpoint.Equal(last) || len(state.Queries) != 2 || state.Queries[0].Count != 2 {
+		t.Errorf("got the state %+v, want the checkpoint %s and the queries", state, last)
+	}
+	if q := profileQuery(state.Checkpoint); !reflect.DeepEqual(q["ts"], primitive.M{"$gt": last}) {
+		t.Errorf("got the query %v, want the documents newer than %s", q, last)
+	}
+
+	// the checkpoint never goes back, and the queries are only kept with --merge-state
+	state.advance(stats.Queries{}, false)
+	if !state.Checkpoint.Equal(last) || state.Queries != nil {
+		t.Errorf("got the state %+v after a run without operations", state)
+	}
+
+	if _, err := loadState(filename, "other.log"); err == nil {
+		t.Errorf("loaded the state of another source")
+	}
+}
+
+func TestFormatDocument(t *testing.T) {
+	qs := stats.QueryStats{
+		ID: "a1", Namespace: "test.col", Operation: "FIND", Fingerprint: "FIND col a|b", Count: 3,
+		Query: `{"ns":"test.col","op":"query","query":{"find":"col","filter":{"a":"<b>"}}}`,
+	}
+	rep := report{QueryStats: []stats.QueryStats{qs}, QueryTotals: stats.QueryStats{Count: 3}}
+
+	tests := []struct {
+		output string
+		want   []string
+	}{
+		{"markdown", []string{
+			"| 1 | `a1` | test.col | FIND | 3 |",
+			"`FIND col a\\|b` |",
+			"~~~json\n{\n  \"ns\": \"test.col\",",
+			`"a": "<b>"`,
+		}},
+		{"html", []string{
+			`<a href="#query-1">1</a>`,
+			"<code>FIND col a|b</code>",
+			`&#34;a&#34;: &#34;&lt;b&gt;&#34;`,
+		}},
+	}
+	for _, test := range tests {
+		buf, err := formatDocument(rep, test.output)
+		if err != nil {
+			t.Fatalf("formatDocument(%s): %s", test.output, err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(string(buf), want) {
+				t.Errorf("formatDocument(%s) does not contain %q:\n%s", test.output, want, buf)
+			}
+		}
+	}
+}
+
 func TestPTMongoDBQueryDigest(t *testing.T) {
 	var err error
 	//
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+)
+
+const readPreferencePrimary = "primary"
+
+// parseReadPreference parses the --read-preference mode: primary, primaryPreferred, secondary,
+// secondaryPreferred or nearest.
+func parseReadPreference(mode string) (*readpref.ReadPref, error) {
+	m, err := readpref.ModeFromString(mode)
+	if err != nil {
+		return nil, errors.Errorf("invalid --read-preference %q, want primary, primaryPreferred, secondary, "+
+			"secondaryPreferred or nearest", mode)
+	}
+
+	return readpref.New(m)
+}
+
+// targetsMember returns true if the profiler is read from another member than the one of the connection:
+// the --member, or the one selected by --read-preference.
+func targetsMember(opts *cliOptions) bool {
+	return opts.Member != "" || opts.ReadPreference != readPreferencePrimary
+}
+
+// selectMember returns the member of the replica set whose profiler is read: the --member, including the
+// hidden ones that no read preference selects, or the member selected by --read-preference among the
+// members of the connection. It returns an empty host for the primary read preference and the standalones.
+func selectMember(ctx context.Context, client *mongo.Client, opts *cliOptions) (string, error) {
+	if opts.Member != "" {
+		return opts.Member, nil
+	}
+	if opts.ReadPreference == readPreferencePrimary {
+		return "", nil
+	}
+
+	rp, _ := parseReadPreference(opts.ReadPreference) // validated by getOptions
+	var md struct {
+		Me string `bson:"me"`
+	}
+	err := client.Database("admin").RunCommand(ctx, primitive.M{"isMaster": 1},
+		options.RunCmd().SetReadPreference(rp)).Decode(&md)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot select a member with the %s read preference", opts.ReadPreference)
+	}
+
+	return md.Me, nil
+}
+
+// connectProfiledMember returns the client of the member whose profiler is read, since every member only
+// profiles the operations it ran: a direct connection to the member selected by selectMember, replacing the
+// client, or the client itself. It returns the host of the member, empty for the client itself.
+func connectProfiledMember(ctx context.Context, client *mongo.Client, clientOptions *options.ClientOptions,
+	opts *cliOptions,
+) (*mongo.Client, *options.ClientOptions, string, error) {
+	member, err := selectMember(ctx, client, opts)
+	if err != nil || member == "" {
+		return client, clientOptions, "", err
+	}
+
+	memberOptions := util.GetClientOptionsForHost(clientOptions, member)
+	memberClient, err := mongo.Connect(ctx, memberOptions)
+	if err != nil {
+		return client, clientOptions, "", errors.Wrapf(err, "cannot connect to %s", member)
+	}
+	client.Disconnect(ctx) //nolint
+	log.Infof("Reading the profiler of %s", member)
+
+	return memberClient, memberOptions, member, nil
+}
+
+// profilerEnabled returns true if the profiler of the database is enabled on the member read, its settings
+// being local to every member, or on the primary without member.
+func profilerEnabled(ctx context.Context, client *mongo.Client, clientOptions *options.ClientOptions,
+	database, member string,
+) (bool, error) {
+	if member == "" {
+		return isProfilerEnabled(ctx, clientOptions, database)
+	}
+
+	var ps proto.ProfilerStatus
+	if err := client.Database(database).RunCommand(ctx, primitive.M{"profile": -1}).Decode(&ps); err != nil {
+		return false, err
+	}
+
+	return ps.Was > 0, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+const (
+	DEFAULT_METRICS_MAX_FINGERPRINTS = 100
+
+	metricsPrefix      = "mongodb_query_digest_"
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+	metricsOther       = "other"
+)
+
+// writeMetrics writes the statistics of the fingerprints in the Prometheus text format. Only the first max
+// fingerprints have their own series, to cap the cardinality: the others are summed up in series with the
+// "other" id, without latency quantiles.
+func writeMetrics(w io.Writer, queries []stats.QueryStats, max int, groupBy string) error {
+	top := queries
+	var other *stats.QueryStats
+	if max > 0 && len(queries) > max {
+		top = queries[:max]
+		other = &stats.QueryStats{ID: metricsOther, Namespace: metricsOther, Operation: metricsOther, Fingerprint: metricsOther}
+		for _, qs := range queries[max:] {
+			other.Count += qs.Count
+			other.QPS += qs.QPS
+			other.QueryTime.Total += qs.QueryTime.Total
+			other.Scanned.Total += qs.Scanned.Total
+			other.KeysExamined.Total += qs.KeysExamined.Total
+			other.Returned.Total += qs.Returned.Total
+			other.ResponseLength.Total += qs.ResponseLength.Total
+		}
+	}
+
+	series := top
+	if other != nil {
+		series = append(append([]stats.QueryStats{}, top...), *other)
+	}
+
+	buf := new(bytes.Buffer)
+
+	writeHeader(buf, "fingerprints", "gauge", "Number of fingerprints of the digest, before the cardinality cap.")
+	fmt.Fprintf(buf, "%sfingerprints %d\n", metricsPrefix, len(queries))
+
+	writeHeader(buf, "query_time_seconds", "summary", "Execution time of the operations of a fingerprint.")
+	for _, qs := range series {
+		labels := metricsLabels(qs, groupBy)
+		if qs.ID != metricsOther {
+			for _, p := range qs.QueryTimePercentiles {
+				quantile := strconv.FormatFloat(p.Percent/100, 'f', -1, 64)
+				fmt.Fprintf(buf, "%squery_time_seconds{%s,quantile=\"%s\"} %s\n", metricsPrefix, labels, quantile,
+					formatMetric(p.Value/1000))
+			}
+		}
+		fmt.Fprintf(buf, "%squery_time_seconds_sum{%s} %s\n", metricsPrefix, labels, formatMetric(qs.QueryTime.Total/1000))
+		fmt.Fprintf(buf, "%squery_time_seconds_count{%s} %d\n", metricsPrefix, labels, qs.Count)
+	}
+
+	counters := []struct {
+		name, help string
+		value      func(stats.QueryStats) float64
+	}{
+		{"docs_examined_total", "Documents examined by the operations of a fingerprint.",
+			func(qs stats.QueryStats) float64 { return qs.Scanned.Total }},
+		{"keys_examined_total", "Index keys examined by the operations of a fingerprint.",
+			func(qs stats.QueryStats) float64 { return qs.KeysExamined.Total }},
+		{"docs_returned_total", "Documents returned by the operations of a fingerprint.",
+			func(qs stats.QueryStats) float64 { return qs.Returned.Total }},
+		{"response_bytes_total", "Bytes sent by the operations of a fingerprint.",
+			func(qs stats.QueryStats) float64 { return qs.ResponseLength.Total }},
+	}
+	for _, c := range counters {
+		writeHeader(buf, c.name, "counter", c.help)
+		for _, qs := range series {
+			fmt.Fprintf(buf, "%s%s{%s} %s\n", metricsPrefix, c.name, metricsLabels(qs, groupBy), formatMetric(c.value(qs)))
+		}
+	}
+
+	writeHeader(buf, "qps", "gauge", "Operations per second of a fingerprint.")
+	for _, qs := range series {
+		fmt.Fprintf(buf, "%sqps{%s} %s\n", metricsPrefix, metricsLabels(qs, groupBy), formatMetric(qs.QPS))
+	}
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func writeHeader(w io.Writer, name, metricType, help string) {
+	fmt.Fprintf(w, "# HELP %s%s %s\n# TYPE %s%s %s\n", metricsPrefix, name, help, metricsPrefix, name, metricType)
+}
+
+func metricsLabels(qs stats.QueryStats, groupBy string) string {
+	labels := []string{
+		`id="` + escapeLabel(qs.ID) + `"`,
+		`namespace="` + escapeLabel(qs.Namespace) + `"`,
+		`operation="` + escapeLabel(qs.Operation) + `"`,
+		`fingerprint="` + escapeLabel(qs.Fingerprint) + `"`,
+	}
+	if groupBy != "" {
+		labels = append(labels, `group="`+escapeLabel(qs.Group)+`"`)
+	}
+
+	return strings.Join(labels, ",")
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// metricsExporter serves the metrics of the last digest of --watch on --metrics-listen.
+type metricsExporter struct {
+	sync.Mutex
+	queries []stats.QueryStats
+	max     int
+	groupBy string
+}
+
+func (e *metricsExporter) set(queries []stats.QueryStats) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.queries = queries
+}
+
+func (e *metricsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.Lock()
+	defer e.Unlock()
+
+	w.Header().Set("Content-Type", metricsContentType)
+	writeMetrics(w, e.queries, e.max, e.groupBy) //nolint
+}
+
+// pushMetrics replaces the metrics of the tool job on the Prometheus Pushgateway. The url is the address of
+// the gateway, or the full url of a grouping key like http://gateway:9091/metrics/job/digest/instance/db1.
+func pushMetrics(ctx context.Context, url string, queries []stats.QueryStats, max int, groupBy string) error {
+	if !strings.Contains(url, "/metrics/job/") {
+		url = strings.TrimSuffix(url, "/") + "/metrics/job/" + toolname
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeMetrics(buf, queries, max, groupBy); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, buf)
+	if err != nil {
+		return errors.Wrapf(err, "invalid --metrics-push url %s", url)
+	}
+	req.Header.Set("Content-Type", metricsContentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "cannot push the metrics to %s", url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("cannot push the metrics to %s: %s %s", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
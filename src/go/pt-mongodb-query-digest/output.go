@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// jsonReport is the output of --output json: a document per fingerprint with all the computed statistics,
+// with stable snake_case keys so that digests can be stored and compared.
+type jsonReport struct {
+	Tool          string        `json:"tool"`
+	Version       string        `json:"version"`
+	GeneratedAt   time.Time     `json:"generated_at"`
+	Host          string        `json:"host,omitempty"`
+	SlowLogs      []string      `json:"slow_logs,omitempty"`
+	Database      string        `json:"database,omitempty"`
+	GroupBy       string        `json:"group_by,omitempty"`
+	UptimeSeconds int64         `json:"uptime_seconds"`
+	SampleRate    float64       `json:"sample_rate,omitempty"`        // with --sample-rate, the counts are estimated
+	Evicted       int           `json:"evicted_operations,omitempty"` // past --max-fingerprints, in the totals only
+	Classes       []classTotals `json:"classes,omitempty"`            // with transactions or retryable writes
+	Errors        []errorTotal  `json:"errors,omitempty"`             // with failed operations
+	Totals        jsonQuery     `json:"totals"`
+	Queries       []jsonQuery   `json:"queries"`
+
+	Heatmap          *heatmap          `json:"heatmap,omitempty"`
+	IndexSuggestions []indexSuggestion `json:"index_suggestions,omitempty"`
+}
+
+type jsonQuery struct {
+	ID             string          `json:"id,omitempty"`
+	Rank           int             `json:"rank,omitempty"`
+	Namespace      string          `json:"namespace,omitempty"`
+	Operation      string          `json:"operation,omitempty"`
+	Fingerprint    string          `json:"fingerprint,omitempty"`
+	Group          string          `json:"group,omitempty"`
+	Class          string          `json:"class,omitempty"`
+	Query          json.RawMessage `json:"query,omitempty"`
+	FirstSeen      *time.Time      `json:"first_seen,omitempty"`
+	LastSeen       *time.Time      `json:"last_seen,omitempty"`
+	Shards         []string        `json:"shards,omitempty"`
+	Count          int             `json:"count"`
+	Aborted        int             `json:"aborted,omitempty"`
+	Conflicts      int             `json:"write_conflict_errors,omitempty"`
+	Failed         int             `json:"failed,omitempty"`
+	Errors         []jsonError     `json:"errors,omitempty"`
+	QPS            float64         `json:"qps"`
+	Ratio          float64         `json:"ratio"`
+	QueryTimeMs    jsonStatistics  `json:"query_time_ms"`
+	DocsExamined   jsonStatistics  `json:"docs_examined"`
+	KeysExamined   jsonStatistics  `json:"keys_examined"`
+	DocsReturned   jsonStatistics  `json:"docs_returned"`
+	ResponseBytes  jsonStatistics  `json:"response_bytes"`
+	NumYields      jsonStatistics  `json:"num_yields"`
+	WriteConflicts jsonStatistics  `json:"write_conflicts"`
+	LockWaitMicros jsonStatistics  `json:"lock_wait_micros"`
+	Plan           *queryPlan      `json:"plan,omitempty"`
+
+	QueryTimePercentiles map[string]float64    `json:"query_time_percentiles_ms"` // by label: p50, p99.9
+	QueryTimeHistogram   []jsonHistogramBucket `json:"query_time_histogram"`
+}
+
+// jsonError is the number of operations of a query ended in an error.
+type jsonError struct {
+	Code  int    `json:"code"`
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
+}
+
+// jsonHistogramBucket is a bucket of the execution time histogram, from the previous bucket upper bound
+// excluded. The last bucket has no upper bound.
+type jsonHistogramBucket struct {
+	FromMs float64  `json:"from_ms"`
+	ToMs   *float64 `json:"to_ms,omitempty"`
+	Count  int      `json:"count"`
+}
+
+type jsonStatistics struct {
+	Pct    float64 `json:"pct"`
+	Total  float64 `json:"total"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Avg    float64 `json:"avg"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+	StdDev float64 `json:"stddev"`
+	Median float64 `json:"median"`
+}
+
+func newJSONReport(opts *cliOptions, rep report, uptime int64) jsonReport {
+	jr := jsonReport{
+		Tool:          toolname,
+		Version:       Version,
+		GeneratedAt:   time.Now(),
+		SlowLogs:      opts.SlowLogs,
+		Database:      opts.Database,
+		GroupBy:       opts.GroupBy,
+		UptimeSeconds: uptime,
+		Evicted:       rep.Evicted,
+		Classes:       rep.Classes,
+		Errors:        rep.Errors,
+		Totals:        newJSONQuery(rep.QueryTotals, 0),
+		Queries:       []jsonQuery{},
+
+		Heatmap:          rep.Heatmap,
+		IndexSuggestions: rep.Indexes,
+	}
+	if rate, _ := parseSampleRate(opts.SampleRate); rate < 1 {
+		jr.SampleRate = rate
+	}
+	if len(opts.SlowLogs) == 0 {
+		jr.Host = strings.TrimPrefix(opts.Host, "mongodb://")
+	}
+
+	// the totals are not a query
+	jr.Totals.Query = nil
+	jr.Totals.FirstSeen, jr.Totals.LastSeen = nil, nil
+
+	for i, qs := range rep.QueryStats {
+		jq := newJSONQuery(qs, i+1)
+		jq.Plan = rep.Plans[qs.ID]
+		jr.Queries = append(jr.Queries, jq)
+	}
+
+	return jr
+}
+
+func newJSONQuery(qs stats.QueryStats, rank int) jsonQuery {
+	jq := jsonQuery{
+		ID:             qs.ID,
+		Rank:           rank,
+		Namespace:      qs.Namespace,
+		Operation:      qs.Operation,
+		Fingerprint:    qs.Fingerprint,
+		Group:          qs.Group,
+		Class:          qs.Class,
+		Aborted:        qs.Aborted,
+		Conflicts:      qs.Conflicts,
+		Failed:         qs.Failed,
+		Shards:         qs.Shards,
+		Count:          qs.Count,
+		QPS:            qs.QPS,
+		Ratio:          qs.Ratio,
+		QueryTimeMs:    newJSONStatistics(qs.QueryTime),
+		DocsExamined:   newJSONStatistics(qs.Scanned),
+		KeysExamined:   newJSONStatistics(qs.KeysExamined),
+		DocsReturned:   newJSONStatistics(qs.Returned),
+		ResponseBytes:  newJSONStatistics(qs.ResponseLength),
+		NumYields:      newJSONStatistics(qs.NumYield),
+		WriteConflicts: newJSONStatistics(qs.WriteConflicts),
+		LockWaitMicros: newJSONStatistics(qs.LockWait),
+	}
+
+	for _, e := range qs.Errors {
+		jq.Errors = append(jq.Errors, jsonError{Code: e.Code, Name: errorName(e), Count: e.Count})
+	}
+
+	jq.QueryTimePercentiles = make(map[string]float64)
+	for _, p := range qs.QueryTimePercentiles {
+		jq.QueryTimePercentiles[p.Label()] = p.Value
+	}
+	jq.QueryTimeHistogram = newJSONHistogram(qs.QueryTimeHistogram)
+
+	// the example query is extended json, embedded as a document rather than as a string
+	if query := strings.TrimSpace(qs.Query); json.Valid([]byte(query)) {
+		jq.Query = json.RawMessage(query)
+	}
+
+	if !qs.FirstSeen.IsZero() {
+		jq.FirstSeen, jq.LastSeen = &qs.FirstSeen, &qs.LastSeen
+	}
+
+	return jq
+}
+
+func newJSONHistogram(histogram []int) []jsonHistogramBucket {
+	buckets := []jsonHistogramBucket{}
+	from := 0.0
+	for i, count := range histogram {
+		bucket := jsonHistogramBucket{FromMs: from, Count: count}
+		if i < len(stats.LatencyBuckets) {
+			to := stats.LatencyBuckets[i]
+			bucket.ToMs = &to
+			from = to
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets
+}
+
+func newJSONStatistics(s stats.Statistics) jsonStatistics {
+	return jsonStatistics{
+		Pct:    s.Pct,
+		Total:  s.Total,
+		Min:    s.Min,
+		Max:    s.Max,
+		Avg:    s.Avg,
+		P95:    s.Pct95,
+		P99:    s.Pct99,
+		StdDev: s.StdDev,
+		Median: s.Median,
+	}
+}
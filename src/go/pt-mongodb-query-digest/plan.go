@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/explain"
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// docsExaminedRatioWarning is the number of documents examined per document returned above which an
+// index is reported as not selective enough.
+const docsExaminedRatioWarning = 10
+
+// queryPlan is the winning plan of the sample query of a fingerprint, from explain("queryPlanner"), with the
+// keys and docs examined ratios of the profiled operations.
+type queryPlan struct {
+	CollScan          bool     `json:"collscan"`
+	Indexes           []string `json:"indexes,omitempty"`
+	Stages            []string `json:"stages,omitempty"` // from the outermost stage
+	KeysExaminedRatio float64  `json:"keys_examined_ratio"`
+	DocsExaminedRatio float64  `json:"docs_examined_ratio"`
+	Advice            []string `json:"advice,omitempty"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// explainQueries explains the sample query of the first n fingerprints. The inserts are not explainable.
+func explainQueries(ctx context.Context, opts *cliOptions, queries []stats.QueryStats, n int) (map[string]*queryPlan, error) {
+	clientOptions, err := getClientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot connect to MongoDB to explain the queries")
+	}
+	defer client.Disconnect(ctx) //nolint
+
+	ex := explain.New(ctx, client)
+	plans := make(map[string]*queryPlan)
+
+	for i, qs := range queries {
+		if i >= n {
+			break
+		}
+		if qs.Operation == "INSERT" {
+			continue
+		}
+
+		res, err := ex.RunVerbosity("", []byte(qs.Query), "queryPlanner")
+		if err != nil {
+			log.Debugf("Cannot explain %s: %s", qs.ID, err)
+			plans[qs.ID] = &queryPlan{Error: err.Error()}
+			continue
+		}
+
+		result := bson.M{}
+		if err := bson.UnmarshalExtJSON(res, true, &result); err != nil {
+			plans[qs.ID] = &queryPlan{Error: err.Error()}
+			continue
+		}
+
+		plans[qs.ID] = newQueryPlan(result, qs)
+	}
+
+	return plans, nil
+}
+
+func newQueryPlan(result bson.M, qs stats.QueryStats) *queryPlan {
+	plan := &queryPlan{}
+
+	queryPlanner, _ := result["queryPlanner"].(bson.M)
+	winningPlan, ok := queryPlanner["winningPlan"]
+	if !ok {
+		// aggregations have the plan of their first stage
+		winningPlan = result["stages"]
+	}
+
+	indexes := make(map[string]bool)
+	walkPlan(winningPlan, func(stage, indexName string) {
+		plan.Stages = append(plan.Stages, stage)
+		if stage == "COLLSCAN" {
+			plan.CollScan = true
+		}
+		if indexName != "" {
+			indexes[indexName] = true
+		}
+	})
+
+	for index := range indexes {
+		plan.Indexes = append(plan.Indexes, index)
+	}
+	sort.Strings(plan.Indexes)
+
+	if qs.Returned.Total > 0 {
+		plan.KeysExaminedRatio = qs.KeysExamined.Total / qs.Returned.Total
+		plan.DocsExaminedRatio = qs.Scanned.Total / qs.Returned.Total
+	}
+
+	switch {
+	case plan.CollScan:
+		plan.Advice = append(plan.Advice, "collection scan, consider an index on the filtered and sorted fields")
+	case len(plan.Indexes) > 0 && plan.DocsExaminedRatio > docsExaminedRatioWarning:
+		plan.Advice = append(plan.Advice, fmt.Sprintf("%.0f documents examined per document returned, the index is "+
+			"not selective enough", plan.DocsExaminedRatio))
+	}
+
+	if len(plan.Stages) == 0 {
+		plan.Error = "no winning plan in the explain output"
+	}
+
+	return plan
+}
+
+// walkPlan calls fn for every stage of the plan, from the outermost one. It walks the plans of every shard
+// and the query plans of the slot based execution engine as well.
+func walkPlan(node interface{}, fn func(stage, indexName string)) {
+	switch n := node.(type) {
+	case bson.M:
+		if stage, ok := n["stage"].(string); ok {
+			indexName, _ := n["indexName"].(string)
+			fn(stage, indexName)
+		}
+
+		keys := make([]string, 0, len(n))
+		for key := range n {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			switch key {
+			case "inputStage", "inputStages", "queryPlan", "shards", "winningPlan", "$cursor", "queryPlanner":
+				walkPlan(n[key], fn)
+			}
+		}
+	case bson.A:
+		for _, v := range n {
+			walkPlan(v, fn)
+		}
+	}
+}
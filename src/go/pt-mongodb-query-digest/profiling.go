@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+)
+
+// profilingSettings are the settings of the profiler set by --enable-profiling, like level=1,slowms=100.
+type profilingSettings struct {
+	Level      int
+	SlowMs     *int
+	SampleRate *float64
+	RateLimit  *int // Percona Server for MongoDB
+}
+
+// parseProfilingSettings parses the key=value settings of --enable-profiling. The level is 1 by default.
+func parseProfilingSettings(settings []string) (profilingSettings, error) {
+	ps := profilingSettings{Level: 1}
+
+	for _, setting := range settings {
+		kv := strings.SplitN(setting, "=", 2)
+		if len(kv) != 2 {
+			return ps, errors.Errorf("invalid --enable-profiling setting %q, want key=value", setting)
+		}
+		key, value := strings.ToLower(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+
+		switch key {
+		case "level":
+			level, err := strconv.Atoi(value)
+			if err != nil || level < 0 || level > 2 {
+				return ps, errors.Errorf("invalid --enable-profiling level %q, want 0, 1 or 2", value)
+			}
+			ps.Level = level
+		case "slowms":
+			slowms, err := strconv.Atoi(value)
+			if err != nil {
+				return ps, errors.Errorf("invalid --enable-profiling slowms %q", value)
+			}
+			ps.SlowMs = &slowms
+		case "samplerate":
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return ps, errors.Errorf("invalid --enable-profiling samplerate %q, want a number in ]0, 1]", value)
+			}
+			ps.SampleRate = &rate
+		case "ratelimit":
+			rateLimit, err := strconv.Atoi(value)
+			if err != nil || rateLimit < 1 {
+				return ps, errors.Errorf("invalid --enable-profiling ratelimit %q, want a number from 1", value)
+			}
+			ps.RateLimit = &rateLimit
+		default:
+			return ps, errors.Errorf("invalid --enable-profiling setting %q, want level, slowms, samplerate "+
+				"or ratelimit", key)
+		}
+	}
+
+	return ps, nil
+}
+
+// command returns the profile command applying the settings.
+func (ps profilingSettings) command() bson.D {
+	cmd := bson.D{{Key: "profile", Value: ps.Level}}
+	if ps.SlowMs != nil {
+		cmd = append(cmd, bson.E{Key: "slowms", Value: *ps.SlowMs})
+	}
+	if ps.SampleRate != nil {
+		cmd = append(cmd, bson.E{Key: "sampleRate", Value: *ps.SampleRate})
+	}
+	if ps.RateLimit != nil {
+		cmd = append(cmd, bson.E{Key: "ratelimit", Value: *ps.RateLimit})
+	}
+
+	return cmd
+}
+
+// restoreCommand returns the profile command restoring the settings returned by a previous profile command.
+// The sample rate and the rate limit are only restored when the server reported them.
+func restoreCommand(previous proto.ProfilerStatus) bson.D {
+	cmd := bson.D{{Key: "profile", Value: previous.Was}, {Key: "slowms", Value: previous.SlowMs}}
+	if previous.SampleRate > 0 {
+		cmd = append(cmd, bson.E{Key: "sampleRate", Value: previous.SampleRate})
+	}
+	if previous.RateLimit > 0 {
+		cmd = append(cmd, bson.E{Key: "ratelimit", Value: previous.RateLimit})
+	}
+
+	return cmd
+}
+
+// profiledMember is a member whose profiler was set by --enable-profiling, with its previous settings.
+type profiledMember struct {
+	host     string
+	client   *mongo.Client
+	previous proto.ProfilerStatus
+}
+
+// enableProfiling sets the profiler of the database on the members the digest reads: the server, or every
+// shard member with a mongos. It returns the function to call once the operations are read, restoring their
+// previous settings with --restore-profiling.
+func enableProfiling(ctx context.Context, opts *cliOptions) (func(), error) {
+	settings, _ := parseProfilingSettings(opts.EnableProfiling) // validated by getOptions
+
+	clientOptions, err := getClientOptions(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get a MongoDB client")
+	}
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot connect to MongoDB")
+	}
+
+	members := []profiledMember{{host: strings.Join(clientOptions.Hosts, ","), client: client}}
+	if isMongos(ctx, client) {
+		if targetsMember(opts) {
+			log.Warn("--member and --read-preference are ignored with a mongos, --read-from selects the shard members")
+		}
+		shardMembers, err := getShardMembers(ctx, client, clientOptions, opts.ReadFrom)
+		client.Disconnect(ctx) //nolint
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get the shard members")
+		}
+
+		members = members[:0]
+		for _, member := range shardMembers {
+			memberClient, err := util.GetClientForHost(clientOptions, member.Host)
+			if err == nil {
+				err = memberClient.Connect(ctx)
+			}
+			if err != nil {
+				disconnectMembers(members)
+				return nil, errors.Wrapf(err, "cannot connect to %s", member.Host)
+			}
+			members = append(members, profiledMember{host: member.Host, client: memberClient})
+		}
+	} else {
+		// the profiler settings are local to the member read
+		client, _, member, err := connectProfiledMember(ctx, client, clientOptions, opts)
+		if err != nil {
+			client.Disconnect(ctx) //nolint
+			return nil, errors.Wrap(err, "cannot connect to the profiled member")
+		}
+		if member != "" {
+			members[0] = profiledMember{host: member, client: client}
+		}
+	}
+
+	cmd := settings.command()
+	for i := range members {
+		m := &members[i]
+		err := m.client.Database(opts.Database).RunCommand(ctx, cmd).Decode(&m.previous)
+		if err != nil {
+			restoreProfiling(ctx, opts.Database, members[:i])
+			disconnectMembers(members)
+			return nil, errors.Wrapf(err, "cannot set the profiler of %s", m.host)
+		}
+		log.Infof("The profiler of the %q database of %s is set to %v, it was %v", opts.Database, m.host, cmd,
+			restoreCommand(m.previous))
+	}
+
+	return func() {
+		if opts.RestoreProfiling {
+			restoreProfiling(context.Background(), opts.Database, members)
+		}
+		disconnectMembers(members)
+	}, nil
+}
+
+// restoreProfiling restores the previous settings of the profiler of the members, logging the errors.
+func restoreProfiling(ctx context.Context, database string, members []profiledMember) {
+	for _, m := range members {
+		cmd := restoreCommand(m.previous)
+		if err := m.client.Database(database).RunCommand(ctx, cmd).Err(); err != nil {
+			log.Errorf("Cannot restore the profiler of the %q database of %s to %v: %s", database, m.host, cmd, err)
+		}
+	}
+}
+
+func disconnectMembers(members []profiledMember) {
+	for _, m := range members {
+		m.client.Disconnect(context.Background()) //nolint
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// redactedValue replaces the literal values of the sample queries with --redact.
+const redactedValue = "?"
+
+// redactKeep is the command fields whose values are kept by --redact: the options and sort orders that
+// cannot hold user data.
+var redactKeep = map[string]bool{
+	"$db":         true,
+	"$limit":      true,
+	"$orderby":    true,
+	"$skip":       true,
+	"$sort":       true,
+	"batchSize":   true,
+	"hint":        true,
+	"limit":       true,
+	"multi":       true,
+	"new":         true,
+	"ordered":     true,
+	"orderby":     true,
+	"projection":  true,
+	"singleBatch": true,
+	"skip":        true,
+	"sort":        true,
+	"upsert":      true,
+}
+
+// redactQueries replaces the literal values of the sample queries of the fingerprints.
+func redactQueries(queries []stats.QueryStats) {
+	for i := range queries {
+		queries[i].Query = redactQuery(queries[i].Query)
+	}
+}
+
+// redactQuery replaces the literal values of a sample query with placeholders, keeping its field names,
+// operators and the length of its arrays, so that it can be shared without the data of the predicates. The
+// command name, whose value is the collection, and the fields of redactKeep are left untouched. A query
+// that cannot be parsed is redacted entirely.
+func redactQuery(query string) string {
+	var eq proto.ExampleQuery
+	if err := bson.UnmarshalExtJSON([]byte(query), true, &eq); err != nil {
+		return redactedValue
+	}
+
+	eq.Query = redactCommand(eq.Query)
+	eq.Command = redactCommand(eq.Command)
+	eq.OriginatingCommand = redactCommand(eq.OriginatingCommand)
+	eq.UpdateObj = redactDoc(eq.UpdateObj)
+
+	redacted, err := bson.MarshalExtJSON(eq, true, true)
+	if err != nil {
+		return redactedValue
+	}
+
+	return string(redacted)
+}
+
+func redactCommand(cmd bson.D) bson.D {
+	if len(cmd) == 0 {
+		return cmd
+	}
+
+	redacted := redactDoc(cmd)
+	if _, ok := cmd[0].Value.(string); ok {
+		// { find: "collection", ... }
+		redacted[0].Value = cmd[0].Value
+	}
+
+	return redacted
+}
+
+func redactDoc(doc bson.D) bson.D {
+	if doc == nil {
+		return nil
+	}
+
+	redacted := make(bson.D, 0, len(doc))
+	for _, e := range doc {
+		if redactKeep[e.Key] {
+			redacted = append(redacted, e)
+			continue
+		}
+		redacted = append(redacted, bson.E{Key: e.Key, Value: redactValue(e.Value)})
+	}
+
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case bson.D:
+		return redactDoc(v)
+	case bson.A:
+		redacted := make(bson.A, 0, len(v))
+		for _, item := range v {
+			redacted = append(redacted, redactValue(item))
+		}
+		return redacted
+	default:
+		return redactedValue
+	}
+}
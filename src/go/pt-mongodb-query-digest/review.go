@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+// reviewQuery is a fingerprint of the review collection. The reviewer fields are set by hand, like in the
+// review table of pt-query-digest, once the query has been looked at.
+type reviewQuery struct {
+	ID          string             `bson:"_id"`
+	Fingerprint string             `bson:"fingerprint"`
+	Namespace   string             `bson:"namespace"`
+	Operation   string             `bson:"operation"`
+	Sample      string             `bson:"sample"`
+	FirstSeen   primitive.DateTime `bson:"first_seen"`
+	LastSeen    primitive.DateTime `bson:"last_seen"`
+	ReviewedBy  string             `bson:"reviewed_by"`
+	ReviewedOn  primitive.DateTime `bson:"reviewed_on"`
+	Comments    string             `bson:"comments"`
+}
+
+// reviewQueries upserts every query into the review collection and returns the ones that have not been
+// reviewed yet.
+func reviewQueries(ctx context.Context, coll *mongo.Collection, queries []stats.QueryStats) ([]stats.QueryStats, error) {
+	models := []mongo.WriteModel{}
+	ids := []string{}
+	for _, qs := range queries {
+		update := primitive.M{
+			"$set": primitive.M{
+				"fingerprint": qs.Fingerprint,
+				"namespace":   qs.Namespace,
+				"operation":   qs.Operation,
+				"sample":      qs.Query,
+			},
+			"$min": primitive.M{"first_seen": qs.FirstSeen},
+			"$max": primitive.M{"last_seen": qs.LastSeen},
+			"$setOnInsert": primitive.M{
+				"reviewed_by": nil,
+				"reviewed_on": nil,
+				"comments":    nil,
+			},
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(primitive.M{"_id": qs.ID}).
+			SetUpdate(update).
+			SetUpsert(true))
+		ids = append(ids, qs.ID)
+	}
+
+	if len(models) == 0 {
+		return queries, nil
+	}
+
+	if _, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return nil, errors.Wrapf(err, "cannot update the review collection %s.%s", coll.Database().Name(), coll.Name())
+	}
+
+	filter := primitive.M{
+		"_id":         primitive.M{"$in": ids},
+		"reviewed_by": primitive.M{"$nin": primitive.A{nil, ""}},
+	}
+	cursor, err := coll.Find(ctx, filter, options.Find().SetProjection(primitive.M{"_id": 1}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read the review collection %s.%s", coll.Database().Name(), coll.Name())
+	}
+
+	reviewed := []reviewQuery{}
+	if err := cursor.All(ctx, &reviewed); err != nil {
+		return nil, errors.Wrapf(err, "cannot read the review collection %s.%s", coll.Database().Name(), coll.Name())
+	}
+
+	isReviewed := make(map[string]bool, len(reviewed))
+	for _, rq := range reviewed {
+		isReviewed[rq.ID] = true
+	}
+
+	pending := []stats.QueryStats{}
+	for _, qs := range queries {
+		if !isReviewed[qs.ID] {
+			pending = append(pending, qs)
+		}
+	}
+
+	return pending, nil
+}
+
+// review returns the queries that have not been reviewed yet.
+func review(ctx context.Context, opts *cliOptions, queries []stats.QueryStats) ([]stats.QueryStats, error) {
+	client, coll, err := connectCollection(ctx, opts, opts.Review)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(ctx) //nolint
+
+	return reviewQueries(ctx, coll, queries)
+}
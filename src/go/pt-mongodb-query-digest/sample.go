@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-query-digest/filter"
+)
+
+// parseSampleRate parses --sample-rate, greater than 0 and up to 1. An empty string is 1, every document.
+func parseSampleRate(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 1, nil
+	}
+
+	rate, err := strconv.ParseFloat(s, 64)
+	if err != nil || rate <= 0 || rate > 1 {
+		return 0, errors.Errorf("invalid --sample-rate %q, want a number greater than 0 and up to 1", s)
+	}
+
+	return rate, nil
+}
+
+// withSampleRate returns the filters followed by the one of --sample-rate, sampling the documents they keep.
+func withSampleRate(opts *cliOptions, filters []filter.Filter) []filter.Filter {
+	rate, _ := parseSampleRate(opts.SampleRate) // validated by getOptions
+	if rate >= 1 {
+		return filters
+	}
+
+	return append(filters[:len(filters):len(filters)], filter.NewFilterBySampleRate(rate))
+}
+
+// calcQueriesStats returns the statistics of the fingerprints and the number of operations evicted by
+// --max-fingerprints, scaled to all the operations with --sample-rate.
+func calcQueriesStats(opts *cliOptions, queries stats.Queries, uptime int64, percentiles []float64,
+) ([]stats.QueryStats, int) {
+	queriesStats, evicted := splitEvicted(queries.CalcQueriesStats(uptime, percentiles...))
+
+	rate, _ := parseSampleRate(opts.SampleRate) // validated by getOptions
+	for i := range queriesStats {
+		scaleQueryStats(&queriesStats[i], rate)
+	}
+
+	return queriesStats, scaleCount(evicted, rate)
+}
+
+// calcTotalQueriesStats returns the statistics of all the operations, scaled with --sample-rate.
+func calcTotalQueriesStats(opts *cliOptions, queries stats.Queries, uptime int64, percentiles []float64,
+) stats.QueryStats {
+	totals := queries.CalcTotalQueriesStats(uptime, percentiles...)
+
+	rate, _ := parseSampleRate(opts.SampleRate) // validated by getOptions
+	scaleQueryStats(&totals, rate)
+
+	return totals
+}
+
+// scaleQueryStats scales the statistics of a sample of the operations, taken with the rate, to all the
+// operations: their counts, QPS, totals and histogram. The averages, percentiles and ratios are the ones of
+// the sample, and the minimums and maximums the ones seen.
+func scaleQueryStats(qs *stats.QueryStats, rate float64) {
+	if rate >= 1 {
+		return
+	}
+
+	qs.Count = scaleCount(qs.Count, rate)
+	qs.QPS /= rate
+	for _, s := range []*stats.Statistics{
+		&qs.QueryTime, &qs.ResponseLength, &qs.Returned, &qs.Scanned, &qs.KeysExamined, &qs.NumYield,
+		&qs.WriteConflicts, &qs.LockWait,
+	} {
+		s.Total /= rate
+	}
+	for i, count := range qs.QueryTimeHistogram {
+		qs.QueryTimeHistogram[i] = scaleCount(count, rate)
+	}
+	qs.Aborted = scaleCount(qs.Aborted, rate)
+	qs.Conflicts = scaleCount(qs.Conflicts, rate)
+	qs.Failed = scaleCount(qs.Failed, rate)
+	for i := range qs.Errors {
+		qs.Errors[i].Count = scaleCount(qs.Errors[i].Count, rate)
+	}
+}
+
+func scaleCount(count int, rate float64) int {
+	if rate >= 1 {
+		return count
+	}
+
+	return int(math.Round(float64(count) / rate))
+}
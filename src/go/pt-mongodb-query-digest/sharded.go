@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/slowlog"
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-query-digest/filter"
+)
+
+const (
+	readFromPrimary     = "primary"
+	readFromSecondaries = "secondaries"
+)
+
+// shardMember is a member of a shard the profiler documents are read from.
+type shardMember struct {
+	Shard string
+	Host  string
+}
+
+func isMongos(ctx context.Context, client *mongo.Client) bool {
+	md := proto.MasterDoc{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"isMaster": 1}).Decode(&md); err != nil {
+		return false
+	}
+
+	return md.Msg == util.TypeIsDBGrid
+}
+
+// getShardMembers returns the primary of every shard or, with readFromSecondaries, all their data bearing
+// members, since each member only profiles the operations it ran.
+func getShardMembers(ctx context.Context, client *mongo.Client, clientOptions *options.ClientOptions,
+	readFrom string,
+) ([]shardMember, error) {
+	shardsInfo := proto.ShardsInfo{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"listShards": 1}).Decode(&shardsInfo); err != nil {
+		return nil, errors.Wrap(err, "cannot list shards")
+	}
+
+	members := []shardMember{}
+
+	for _, shard := range shardsInfo.Shards {
+		// rs/host1:27018,host2:27018 or host:27018 for the shards that are not replica sets
+		hosts := shard.Host
+		if i := strings.Index(hosts, "/"); i >= 0 {
+			hosts = hosts[i+1:]
+		}
+		seed := strings.Split(hosts, ",")[0]
+
+		shardClient, err := util.GetClientForHost(clientOptions, seed)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get a client for shard %s", shard.ID)
+		}
+		if err := shardClient.Connect(ctx); err != nil {
+			return nil, errors.Wrapf(err, "cannot connect to shard %s", shard.ID)
+		}
+
+		rss := proto.ReplicaSetStatus{}
+		err = shardClient.Database("admin").RunCommand(ctx, primitive.M{"replSetGetStatus": 1}).Decode(&rss)
+		shardClient.Disconnect(ctx) //nolint
+		if err != nil {
+			members = append(members, shardMember{Shard: shard.ID, Host: seed})
+			continue
+		}
+
+		for _, m := range rss.Members {
+			switch int(m.State) {
+			case proto.REPLICA_SET_MEMBER_PRIMARY:
+			case proto.REPLICA_SET_MEMBER_SECONDARY:
+				if readFrom != readFromSecondaries {
+					continue
+				}
+			default:
+				continue
+			}
+			members = append(members, shardMember{Shard: shard.ID, Host: m.Name})
+		}
+	}
+
+	return members, nil
+}
+
+// readShardProfiles reads the documents of the system.profile collection of the database matching the query
+// on the shard members, attributing every query to the shards it ran on. The members whose profile is empty are read from their recent log
+// lines instead, which only keep the last slow operations.
+func readShardProfiles(ctx context.Context, clientOptions *options.ClientOptions, members []shardMember,
+	database string, query primitive.M, filters []filter.Filter, groupBy stats.GroupBy, limits stats.Limits,
+) (stats.Queries, error) {
+	s := newStats(groupBy, limits)
+
+	// a member can be listed more than once, under different names, and its documents must be counted once
+	seen := make(map[string]bool, len(members))
+
+	for _, member := range members {
+		client, err := util.GetClientForHost(clientOptions, member.Host)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get a client for %s", member.Host)
+		}
+		if err := client.Connect(ctx); err != nil {
+			return nil, errors.Wrapf(err, "cannot connect to %s", member.Host)
+		}
+
+		name := memberName(ctx, client, member.Host)
+		if seen[name] {
+			log.Debugf("Skipping %s, its operations were already read from %s", member.Host, name)
+			client.Disconnect(ctx) //nolint
+			continue
+		}
+		seen[name] = true
+
+		add := func(doc proto.SystemProfile) {
+			if !keepDoc(doc, filters) {
+				return
+			}
+
+			if err := s.AddFromShard(doc, member.Shard); err != nil {
+				log.Debugf("Cannot add the operation of %s: %s", member.Host, err)
+			}
+		}
+
+		n, err := memberProfile(ctx, client, database, query, add)
+		if err == nil && n == 0 {
+			log.Infof("The system.profile collection of %s is empty, reading its recent log lines", member.Host)
+			var docs []proto.SystemProfile
+			docs, err = memberSlowLog(ctx, client, database)
+			for _, doc := range docs {
+				add(doc)
+			}
+		}
+		client.Disconnect(ctx) //nolint
+		if err != nil {
+			log.Warnf("Cannot read the operations of %s (shard %s): %s", member.Host, member.Shard, err)
+		}
+	}
+
+	return s.Queries(), nil
+}
+
+// memberName returns the name the member gives itself in its replica set, or host when it is not in one.
+func memberName(ctx context.Context, client *mongo.Client, host string) string {
+	repl := proto.Repl{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"isMaster": 1}).Decode(&repl); err != nil ||
+		repl.Me == "" {
+		return host
+	}
+
+	return repl.Me
+}
+
+// memberProfile streams the documents of the system.profile collection of the database matching the query
+// to add, returning their number.
+func memberProfile(ctx context.Context, client *mongo.Client, database string, query primitive.M,
+	add func(proto.SystemProfile),
+) (int, error) {
+	cursor, err := client.Database(database).Collection("system.profile").Find(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx) //nolint
+
+	n := 0
+	for cursor.Next(ctx) {
+		var doc proto.SystemProfile
+		if err := cursor.Decode(&doc); err != nil {
+			return n, err
+		}
+		add(doc)
+		n++
+	}
+
+	return n, cursor.Err()
+}
+
+// memberSlowLog returns the slow operations of the database among the log lines kept in memory by the
+// member.
+func memberSlowLog(ctx context.Context, client *mongo.Client, database string) ([]proto.SystemProfile, error) {
+	var res struct {
+		Log []string `bson:"log"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"getLog": "global"}).Decode(&res); err != nil {
+		return nil, err
+	}
+
+	docs := []proto.SystemProfile{}
+	for _, line := range res.Log {
+		doc, err := slowlog.ParseLine(line)
+		if err != nil || !strings.HasPrefix(doc.Ns, database+".") {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/slowlog"
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-query-digest/filter"
+)
+
+// readSlowLogs reads the slow operations of the log files. Without a server uptime, the QPS are computed
+// over the seconds between the first and the last operation read.
+func readSlowLogs(filenames []string, filters []filter.Filter, groupBy stats.GroupBy, limits stats.Limits,
+) (stats.Queries, int64, error) {
+	s := newStats(groupBy, limits)
+
+	var first, last time.Time
+	for _, filename := range filenames {
+		rc, err := slowlog.Open(filename)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "cannot open %s", filename)
+		}
+
+		r := slowlog.NewReader(rc)
+		for {
+			doc, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				rc.Close()
+				return nil, 0, errors.Wrapf(err, "cannot read %s", filename)
+			}
+
+			if !keepDoc(doc, filters) {
+				continue
+			}
+
+			if first.IsZero() || doc.Ts.Before(first) {
+				first = doc.Ts
+			}
+			if doc.Ts.After(last) {
+				last = doc.Ts
+			}
+
+			if err := s.Add(doc); err != nil {
+				log.Debugf("Cannot add the operation of %s: %s", filename, err)
+			}
+		}
+		rc.Close()
+
+		if r.Skipped() > 0 {
+			log.Warnf("Skipped %d slow operations of %s that could not be parsed", r.Skipped(), filename)
+		}
+	}
+
+	uptime := int64(last.Sub(first).Seconds())
+	if uptime < 1 {
+		uptime = 1
+	}
+
+	return s.Queries(), uptime, nil
+}
+
+func keepDoc(doc proto.SystemProfile, filters []filter.Filter) bool {
+	for _, f := range filters {
+		if !f(doc) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+)
+
+const stateVersion = 1
+
+// digestState is the --state file of the incremental runs: the checkpoint up to which the operations were
+// read and, with --merge-state, the aggregates of the operations of the previous runs.
+type digestState struct {
+	Version    int                `json:"version"`
+	Source     string             `json:"source"`
+	Checkpoint time.Time          `json:"checkpoint"` // time of the last operation read
+	Queries    []stats.SavedQuery `json:"queries,omitempty"`
+}
+
+// loadState reads the --state file of the source, returning an empty state when it does not exist yet.
+func loadState(filename, source string) (*digestState, error) {
+	state := &digestState{Version: stateVersion, Source: source}
+
+	buf, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", filename)
+	}
+
+	if err := json.Unmarshal(buf, state); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s", filename)
+	}
+	if state.Version != stateVersion {
+		return nil, errors.Errorf("%s has the unsupported version %d", filename, state.Version)
+	}
+	if state.Source != source {
+		return nil, errors.Errorf("%s is the state of %s, not of %s", filename, state.Source, source)
+	}
+
+	return state, nil
+}
+
+// saveState writes the state to the --state file, through a temporary file so that an interrupted run keeps
+// the previous state.
+func saveState(filename string, state *digestState) error {
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal the state")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return errors.Wrap(err, "cannot create the state file")
+	}
+	defer os.Remove(tmp.Name()) //nolint
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "cannot write %s", tmp.Name())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "cannot write %s", tmp.Name())
+	}
+
+	return errors.Wrapf(os.Rename(tmp.Name(), filename), "cannot rename %s", tmp.Name())
+}
+
+// advance moves the checkpoint of the state to the last operation of the queries, and keeps the queries for
+// the next runs with --merge-state.
+func (s *digestState) advance(queries stats.Queries, merge bool) {
+	_, last := queriesTimeRange(queries)
+	if last.After(s.Checkpoint) {
+		s.Checkpoint = last
+	}
+
+	s.Queries = nil
+	if merge {
+		s.Queries = queries.Save()
+	}
+}
+
+// mergeState returns the queries of the run merged with the queries of the previous runs.
+func mergeState(state *digestState, queries stats.Queries, groupBy stats.GroupBy, limits stats.Limits,
+) stats.Queries {
+	s := newStats(groupBy, limits)
+	s.Merge(state.Queries)
+	s.Merge(queries.Save())
+
+	return s.Queries()
+}
+
+// queriesTimeRange returns the time of the first and of the last operations of the queries.
+func queriesTimeRange(queries stats.Queries) (time.Time, time.Time) {
+	var first, last time.Time
+	for _, q := range queries {
+		if !q.FirstSeen.IsZero() && (first.IsZero() || q.FirstSeen.Before(first)) {
+			first = q.FirstSeen
+		}
+		if q.LastSeen.After(last) {
+			last = q.LastSeen
+		}
+	}
+
+	return first, last
+}
+
+// profileQuery returns the query of the system.profile documents newer than the checkpoint, all of them
+// without a checkpoint.
+func profileQuery(checkpoint time.Time) primitive.M {
+	if checkpoint.IsZero() {
+		return primitive.M{}
+	}
+
+	return primitive.M{"ts": primitive.M{"$gt": checkpoint}}
+}
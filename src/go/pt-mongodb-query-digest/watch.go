@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/slowlog"
+	"github.com/percona/percona-toolkit/src/go/mongolib/stats"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-query-digest/filter"
+)
+
+const (
+	DEFAULT_WATCH_WINDOW   = 5  // minutes
+	DEFAULT_WATCH_INTERVAL = 60 // seconds
+
+	// watchPoll is how often the profiler is queried, and the logs read, for new operations
+	watchPoll = time.Second
+)
+
+// rollingWindow keeps the operations of the last size, in the order they were read.
+type rollingWindow struct {
+	size   time.Duration
+	docs   []proto.SystemProfile
+	limits stats.Limits
+}
+
+func (w *rollingWindow) add(doc proto.SystemProfile) {
+	w.docs = append(w.docs, doc)
+}
+
+// expire drops the operations that ran before the window ending at now.
+func (w *rollingWindow) expire(now time.Time) {
+	start := now.Add(-w.size)
+
+	kept := w.docs[:0]
+	for _, doc := range w.docs {
+		if !doc.Ts.Before(start) {
+			kept = append(kept, doc)
+		}
+	}
+	w.docs = kept
+}
+
+func (w *rollingWindow) queries(groupBy stats.GroupBy) stats.Queries {
+	s := newStats(groupBy, w.limits)
+	for _, doc := range w.docs {
+		if err := s.Add(doc); err != nil {
+			log.Debugf("Cannot add the operation: %s", err)
+		}
+	}
+
+	return s.Queries()
+}
+
+// watch runs the --watch mode: it tails the profiler or the logs and, every --interval, reports the
+// top queries of the last --window until it is interrupted. It returns the exit code.
+func watch(opts *cliOptions, filters []filter.Filter, groupBy stats.GroupBy) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	docs := make(chan proto.SystemProfile, 1000)
+	errc := make(chan error, len(opts.SlowLogs)+2)
+
+	var exporter *metricsExporter
+	if opts.MetricsListen != "" {
+		exporter = &metricsExporter{max: opts.MetricsMax, groupBy: opts.GroupBy}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", exporter)
+		server := &http.Server{Addr: opts.MetricsListen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		defer server.Close()
+
+		go func() {
+			if err := server.ListenAndServe(); err != http.ErrServerClosed {
+				errc <- errors.Wrapf(err, "cannot serve the metrics on %s", opts.MetricsListen)
+			}
+		}()
+	}
+
+	if len(opts.SlowLogs) > 0 {
+		for _, filename := range opts.SlowLogs {
+			go func(filename string) {
+				errc <- tailSlowLog(ctx, filename, docs)
+			}(filename)
+		}
+	} else {
+		coll, disconnect, err := watchedProfile(ctx, opts)
+		if err != nil {
+			log.Error(err)
+			return 2
+		}
+		defer disconnect()
+
+		go func() {
+			errc <- tailProfiler(ctx, coll, time.Now(), docs)
+		}()
+	}
+
+	percentiles, _ := parsePercentiles(opts.Percentiles) // validated by getOptions
+	window := &rollingWindow{size: time.Duration(opts.Window) * time.Minute, limits: getLimits(opts)}
+	started := time.Now()
+
+	ticker := time.NewTicker(time.Duration(opts.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case err := <-errc:
+			if err != nil {
+				log.Errorf("Cannot watch the operations: %s", err)
+				return 6
+			}
+		case doc := <-docs:
+			if doc.Ts.IsZero() {
+				doc.Ts = time.Now()
+			}
+			if keepDoc(doc, filters) {
+				window.add(doc)
+			}
+		case now := <-ticker.C:
+			window.expire(now)
+
+			// the QPS are computed over the window, or over the time watched while it is shorter
+			uptime := int64(now.Sub(started).Seconds())
+			if size := int64(window.size.Seconds()); uptime > size {
+				uptime = size
+			}
+			if uptime < 1 {
+				uptime = 1
+			}
+
+			queries := window.queries(groupBy)
+			queriesStats, evicted := calcQueriesStats(opts, queries, uptime, percentiles)
+			sortedQueryStats := sortQueries(queriesStats, opts.OrderBy)
+
+			if exporter != nil {
+				exporter.set(sortedQueryStats)
+			}
+			if opts.MetricsPush != "" {
+				// the next report pushes them again, the gateway being down does not stop watching
+				err := pushMetrics(ctx, opts.MetricsPush, sortedQueryStats, opts.MetricsMax, opts.GroupBy)
+				if err != nil {
+					log.Errorf("Cannot push the metrics: %s", err)
+				}
+			}
+
+			out, err := formatWindow(opts, window, queries, sortedQueryStats, evicted, percentiles, now, uptime)
+			if err != nil {
+				log.Errorf("Cannot parse the report: %s", err)
+				return 5
+			}
+			fmt.Println(string(out))
+		}
+	}
+}
+
+// formatWindow reports the top queries of the window, like a single run would for the same operations.
+func formatWindow(opts *cliOptions, window *rollingWindow, queries stats.Queries, sortedQueryStats []stats.QueryStats,
+	evicted int, percentiles []float64, now time.Time, uptime int64,
+) ([]byte, error) {
+	if opts.Output == "openmetrics" {
+		buf := new(bytes.Buffer)
+		err := writeMetrics(buf, sortedQueryStats, opts.MetricsMax, opts.GroupBy)
+		return buf.Bytes(), err
+	}
+
+	allQueryStats := sortedQueryStats
+	if opts.Limit > 0 && len(sortedQueryStats) > opts.Limit {
+		sortedQueryStats = sortedQueryStats[:opts.Limit]
+	}
+	if opts.Redact {
+		redactQueries(sortedQueryStats)
+	}
+
+	rep := report{
+		Headers:     getHeaders(opts, evicted),
+		QueryTotals: calcTotalQueriesStats(opts, queries, uptime, percentiles),
+		QueryStats:  sortedQueryStats,
+		GroupBy:     opts.GroupBy,
+		Evicted:     evicted,
+		Errors:      getErrorTotals(allQueryStats),
+	}
+	if opts.Heatmap {
+		rep.Heatmap = newHeatmap(allQueryStats)
+	}
+
+	if opts.Output == "json" {
+		// a line per report, so that the reports can be streamed
+		return json.Marshal(newJSONReport(opts, rep, uptime))
+	}
+
+	out, err := formatResults(rep, opts.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	header := fmt.Sprintf("# Window: %s to %s, %d operations\n", now.Add(-window.size).Format(time.RFC3339),
+		now.Format(time.RFC3339), len(window.docs))
+	if len(queries) == 0 {
+		return []byte(header + "# No operations\n"), nil
+	}
+
+	return append([]byte(header), out...), nil
+}
+
+// watchedProfile connects to the server and returns the system.profile collection of the database to tail.
+// The shards are not tailed through a mongos.
+func watchedProfile(ctx context.Context, opts *cliOptions) (*mongo.Collection, func(), error) {
+	if opts.Database == "" {
+		return nil, nil, errors.New("must indicate a database to profile with the --database parameter")
+	}
+
+	clientOptions, err := getClientOptions(opts)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot get a MongoDB client")
+	}
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot connect to MongoDB")
+	}
+	disconnect := func() { client.Disconnect(context.Background()) } //nolint
+
+	if isMongos(ctx, client) {
+		disconnect()
+		return nil, nil, errors.New("--watch cannot tail the profiler of the shards through a mongos, " +
+			"run it on every shard instead")
+	}
+
+	client, clientOptions, member, err := connectProfiledMember(ctx, client, clientOptions, opts)
+	if err != nil {
+		disconnect()
+		return nil, nil, errors.Wrap(err, "cannot connect to the profiled member")
+	}
+	disconnect = func() { client.Disconnect(context.Background()) } //nolint
+
+	if enabled, err := profilerEnabled(ctx, client, clientOptions, opts.Database, member); err == nil && !enabled {
+		log.Warnf("The profiler is disabled for the %q database, no operation will be reported", opts.Database)
+	}
+
+	return client.Database(opts.Database).Collection("system.profile"), disconnect, nil
+}
+
+// tailProfiler sends the documents of the system.profile collection written after since, polling for the
+// new ones until the context is done.
+func tailProfiler(ctx context.Context, coll *mongo.Collection, since time.Time, docs chan<- proto.SystemProfile) error {
+	last := since
+	findOptions := options.Find().SetSort(primitive.D{{Key: "ts", Value: 1}})
+
+	for {
+		cursor, err := coll.Find(ctx, primitive.M{"ts": primitive.M{"$gt": last}}, findOptions)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "cannot read the system.profile collection")
+		}
+
+		for cursor.Next(ctx) {
+			var doc proto.SystemProfile
+			if err := cursor.Decode(&doc); err != nil {
+				log.Debugf("Cannot decode the profiled operation: %s", err)
+				continue
+			}
+			last = doc.Ts
+
+			select {
+			case docs <- doc:
+			case <-ctx.Done():
+				cursor.Close(ctx) //nolint
+				return nil
+			}
+		}
+		cursor.Close(ctx) //nolint
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchPoll):
+		}
+	}
+}
+
+// tailSlowLog sends the slow operations appended to the log file, from its current end, until the context
+// is done. The standard input is read until its end, and the gzipped files cannot be followed.
+func tailSlowLog(ctx context.Context, filename string, docs chan<- proto.SystemProfile) error {
+	var r *slowlog.Reader
+	switch {
+	case filename == slowlog.Stdin:
+		// like tail -F mongod.log | pt-mongodb-query-digest --watch --slow-log=-, following the rotations
+		r = slowlog.NewReader(os.Stdin)
+	case strings.HasSuffix(filename, ".gz"):
+		return errors.Errorf("cannot follow the gzipped file %s", filename)
+	default:
+		f, err := os.Open(filename)
+		if err != nil {
+			return errors.Wrapf(err, "cannot open %s", filename)
+		}
+		defer f.Close()
+
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			return errors.Wrapf(err, "cannot seek to the end of %s", filename)
+		}
+		r = slowlog.NewReader(&followReader{ctx: ctx, r: f, poll: watchPoll})
+	}
+
+	for {
+		doc, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot read %s", filename)
+		}
+
+		select {
+		case docs <- doc:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// followReader reads a growing file like tail -f: at its end, it waits for more data until the context is
+// done.
+type followReader struct {
+	ctx  context.Context
+	r    io.Reader
+	poll time.Duration
+}
+
+func (fr *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := fr.r.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+
+		select {
+		case <-fr.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(fr.poll):
+		}
+	}
+}
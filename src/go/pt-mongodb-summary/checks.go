@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+const (
+	// how much of the end of the log file is searched for fatal assertions
+	logTailBytes = 8 * 1024 * 1024
+	// fatal assertions reported, the most recent ones
+	fatalAssertionsLimit = 5
+)
+
+// Conditions of --check.
+const (
+	checkNoPrimary  = "no-primary"
+	checkLag        = "lag"
+	checkMemberDown = "member-down"
+	checkFassert    = "fassert"
+)
+
+// healthCheck is a condition of --check. Threshold is only used by the lag check.
+type healthCheck struct {
+	Name      string
+	Threshold time.Duration
+}
+
+func (c healthCheck) String() string {
+	if c.Name == checkLag {
+		return fmt.Sprintf("%s=%d", c.Name, int64(c.Threshold.Seconds()))
+	}
+
+	return c.Name
+}
+
+// checkResult is the outcome of a check, with a message for every problem found.
+type checkResult struct {
+	Check    string
+	Failed   bool
+	Problems []string
+}
+
+// parseChecks parses the conditions of --check, like no-primary or lag=30.
+func parseChecks(conditions []string) ([]healthCheck, error) {
+	checks := []healthCheck{}
+
+	for _, c := range conditions {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(c), "=")
+
+		switch name {
+		case checkNoPrimary, checkMemberDown, checkFassert:
+			if hasValue {
+				return nil, fmt.Errorf("the %s check takes no value", name)
+			}
+			checks = append(checks, healthCheck{Name: name})
+		case checkLag:
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				return nil, fmt.Errorf("invalid lag check %q, expected lag=<seconds>", c)
+			}
+			checks = append(checks, healthCheck{Name: name, Threshold: time.Duration(seconds) * time.Second})
+		default:
+			return nil, fmt.Errorf("unknown check %q, expected one of: %s, %s=<seconds>, %s, %s",
+				c, checkNoPrimary, checkLag, checkMemberDown, checkFassert)
+		}
+	}
+
+	return checks, nil
+}
+
+func hasCheck(checks []healthCheck, name string) bool {
+	for _, c := range checks {
+		if c.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runChecks evaluates the checks on the members of every replica set of the summary, including the ones
+// of the shards and config servers when connected to a mongos.
+func runChecks(ci *collectedInfo, checks []healthCheck) []checkResult {
+	sets := map[string][]proto.Members{}
+	for _, m := range ci.ReplicaMembers {
+		if m.Set != "" {
+			sets[m.Set] = append(sets[m.Set], m)
+		}
+	}
+
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := []checkResult{}
+
+	for _, c := range checks {
+		r := checkResult{Check: c.String()}
+
+		switch c.Name {
+		case checkNoPrimary:
+			for _, name := range names {
+				if findPrimary(sets[name]) == nil {
+					r.Problems = append(r.Problems, fmt.Sprintf("replica set %s has no primary", name))
+				}
+			}
+		case checkLag:
+			for _, name := range names {
+				r.Problems = append(r.Problems, laggingMembers(sets[name], c.Threshold)...)
+			}
+		case checkMemberDown:
+			for _, m := range ci.ReplicaMembers {
+				if m.Error != "" {
+					r.Problems = append(r.Problems, fmt.Sprintf("member %s cannot be reached: %s", m.Name, m.Error))
+				} else if m.Set != "" && m.Health != 1 {
+					r.Problems = append(r.Problems, fmt.Sprintf("member %s of %s is down (%s)", m.Name, m.Set, m.StateStr))
+				}
+			}
+		case checkFassert:
+			if ci.FatalAssertions == nil {
+				r.Problems = append(r.Problems, "the log of the server could not be read")
+			}
+			r.Problems = append(r.Problems, ci.FatalAssertions...)
+		}
+
+		r.Failed = len(r.Problems) > 0
+		results = append(results, r)
+	}
+
+	return results
+}
+
+func findPrimary(members []proto.Members) *proto.Members {
+	for i := range members {
+		if memberState(members[i]) == "PRIMARY" {
+			return &members[i]
+		}
+	}
+
+	return nil
+}
+
+// laggingMembers lists the secondaries further behind the primary than threshold. Without a primary,
+// the lag is unknown: the no-primary check reports it.
+func laggingMembers(members []proto.Members, threshold time.Duration) []string {
+	primary := findPrimary(members)
+	if primary == nil {
+		return nil
+	}
+
+	problems := []string{}
+	for _, m := range members {
+		if memberState(m) != "SECONDARY" {
+			continue
+		}
+
+		lag := primary.OptimeDate.Time().Sub(m.OptimeDate.Time()).Round(time.Second)
+		if lag > threshold {
+			problems = append(problems, fmt.Sprintf("member %s of %s is %s behind the primary", m.Name, m.Set, lag))
+		}
+	}
+
+	return problems
+}
+
+// getFatalAssertions searches the recent log of the server for fatal assertions. A fatal assertion stops the
+// server, so the lines kept in memory by the restarted process do not have it: when the server runs on this
+// host, the end of its log file is read instead.
+func getFatalAssertions(ctx context.Context, client *mongo.Client, local bool) ([]string, error) {
+	lines, err := recentLogLines(ctx, client, local)
+	if err != nil {
+		return nil, err
+	}
+
+	fassert := []string{}
+	for _, line := range lines {
+		if strings.Contains(strings.ToLower(line), "fatal assertion") {
+			fassert = append(fassert, line)
+		}
+	}
+
+	if len(fassert) > fatalAssertionsLimit {
+		fassert = fassert[len(fassert)-fatalAssertionsLimit:]
+	}
+
+	return fassert, nil
+}
+
+func recentLogLines(ctx context.Context, client *mongo.Client, local bool) ([]string, error) {
+	if local {
+		cmdOpts := proto.CommandLineOptions{}
+		err := client.Database("admin").RunCommand(ctx, primitive.M{"getCmdLineOpts": 1}).Decode(&cmdOpts)
+		if err == nil && cmdOpts.Parsed.SystemLog.Destination == "file" && cmdOpts.Parsed.SystemLog.Path != "" {
+			lines, err := tailLines(cmdOpts.Parsed.SystemLog.Path, logTailBytes)
+			if err == nil {
+				return lines, nil
+			}
+			log.Debugf("cannot read the log file, using the log in memory: %s", err)
+		}
+	}
+
+	var res struct {
+		Log []string `bson:"log"`
+	}
+
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"getLog": "global"}).Decode(&res); err != nil {
+		return nil, errors.Wrap(err, "cannot read the log")
+	}
+
+	return res.Log, nil
+}
+
+// tailLines returns the lines of the last size bytes of a file, the first one being dropped as it is
+// likely to be incomplete.
+func tailLines(path string, size int64) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := fi.Size() - size
+	if offset < 0 {
+		offset = 0
+	}
+
+	buf := make([]byte, fi.Size()-offset)
+	if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(buf), "\n"), "\n")
+	if offset > 0 && len(lines) > 0 {
+		lines = lines[1:]
+	}
+
+	return lines, nil
+}
+
+func anyCheckFailed(results []checkResult) bool {
+	for _, r := range results {
+		if r.Failed {
+			return true
+		}
+	}
+
+	return false
+}
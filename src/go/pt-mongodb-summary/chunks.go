@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/compat"
+)
+
+const (
+	// collections with the most chunks listed
+	chunkDistributionLimit = 10
+	// latest failed balancer rounds listed
+	balancerErrorsLimit = 5
+	balancerHistory     = 10 * 24 * time.Hour
+)
+
+// chunkDistribution is the number of chunks of the largest sharded collections on each shard,
+// and what the balancer did lately.
+type chunkDistribution struct {
+	Shards      []string
+	Collections []collectionChunks
+	// BalancesDataSize is true from MongoDB 6.0.3: the balancer evens the data size, not the chunks
+	BalancesDataSize bool
+	BalancerRounds   int64
+	ChunksMoved      int64
+	FailedRounds     int64
+	BalancerErrors   []balancerError
+}
+
+// collectionChunks has the chunks per shard, in the order of chunkDistribution.Shards.
+type collectionChunks struct {
+	Namespace  string
+	Total      int64
+	ByShard    []int64
+	Imbalance  int64
+	Threshold  int64
+	Imbalanced bool
+}
+
+type balancerError struct {
+	Time    time.Time
+	Message string
+}
+
+type chunksByCollectionAndShard struct {
+	ID struct {
+		Namespace string `bson:"ns"`
+		Shard     string `bson:"shard"`
+	} `bson:"_id"`
+	Count int64 `bson:"count"`
+}
+
+type balancerRound struct {
+	Time    time.Time `bson:"time"`
+	Details struct {
+		ChunksMoved int64 `bson:"chunksMoved"`
+		// spelled this way by the server
+		ErrorOccured bool   `bson:"errorOccured"`
+		ErrMsg       string `bson:"errmsg"`
+	} `bson:"details"`
+}
+
+// getChunkDistribution reads config.chunks and config.actionlog through a mongos. A threshold of 0 uses
+// the migration thresholds of the balancer.
+func getChunkDistribution(ctx context.Context, client *mongo.Client, srv *compat.Server, threshold int64) (*chunkDistribution, error) {
+	shardsInfo := proto.ShardsInfo{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"listShards": 1}).Decode(&shardsInfo); err != nil {
+		return nil, errors.Wrap(err, "cannot list shards")
+	}
+
+	shards := []string{}
+	for _, s := range shardsInfo.Shards {
+		shards = append(shards, s.ID)
+	}
+
+	cursor, err := client.Database("config").Collection("chunks").Aggregate(ctx, srv.ChunksByCollectionAndShardPipeline())
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot count the chunks per collection and shard")
+	}
+	defer cursor.Close(ctx)
+
+	counts := []chunksByCollectionAndShard{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, errors.Wrap(err, "cannot decode the chunks per collection and shard")
+	}
+
+	cd := newChunkDistribution(shards, counts, threshold, srv.BalancesDataSize())
+
+	if err := getBalancerRounds(ctx, client, cd); err != nil {
+		// the actionlog is only written by the balancer of MongoDB 3.0+
+		log.Debugf("cannot read the balancer rounds: %s", err)
+	}
+
+	return cd, nil
+}
+
+func newChunkDistribution(shards []string, counts []chunksByCollectionAndShard, threshold int64, bySize bool) *chunkDistribution {
+	sort.Strings(shards)
+	shardIndex := map[string]int{}
+	for i, s := range shards {
+		shardIndex[s] = i
+	}
+
+	cd := &chunkDistribution{Shards: shards, BalancesDataSize: bySize}
+
+	byNamespace := map[string]*collectionChunks{}
+	collections := []*collectionChunks{}
+	for _, c := range counts {
+		cc, ok := byNamespace[c.ID.Namespace]
+		if !ok {
+			cc = &collectionChunks{Namespace: c.ID.Namespace, ByShard: make([]int64, len(shards))}
+			byNamespace[c.ID.Namespace] = cc
+			collections = append(collections, cc)
+		}
+
+		cc.Total += c.Count
+		if i, ok := shardIndex[c.ID.Shard]; ok {
+			cc.ByShard[i] += c.Count
+		}
+	}
+
+	sort.SliceStable(collections, func(i, j int) bool {
+		if collections[i].Total != collections[j].Total {
+			return collections[i].Total > collections[j].Total
+		}
+		return collections[i].Namespace < collections[j].Namespace
+	})
+
+	if len(collections) > chunkDistributionLimit {
+		collections = collections[:chunkDistributionLimit]
+	}
+
+	for _, cc := range collections {
+		cc.Imbalance = chunkImbalance(cc.ByShard)
+		cc.Threshold = threshold
+		if threshold == 0 {
+			cc.Threshold = migrationThreshold(cc.Total)
+		}
+		// with the data size balancer, only a threshold given by the user makes sense
+		cc.Imbalanced = cc.Imbalance > cc.Threshold && (threshold > 0 || !bySize)
+
+		cd.Collections = append(cd.Collections, *cc)
+	}
+
+	return cd
+}
+
+// chunkImbalance is the difference between the shards with the most and the fewest chunks.
+func chunkImbalance(byShard []int64) int64 {
+	if len(byShard) == 0 {
+		return 0
+	}
+
+	least, most := byShard[0], byShard[0]
+	for _, n := range byShard {
+		if n < least {
+			least = n
+		}
+		if n > most {
+			most = n
+		}
+	}
+
+	return most - least
+}
+
+// migrationThreshold is the difference of chunks between shards from which the balancer of
+// MongoDB before 6.0.3 starts migrating chunks of a collection.
+func migrationThreshold(chunks int64) int64 {
+	switch {
+	case chunks < 20:
+		return 2
+	case chunks < 80:
+		return 4
+	}
+
+	return 8
+}
+
+func getBalancerRounds(ctx context.Context, client *mongo.Client, cd *chunkDistribution) error {
+	filter := primitive.M{"what": "balancer.round", "time": primitive.M{"$gt": time.Now().Add(-balancerHistory)}}
+	opts := options.Find().SetSort(primitive.M{"time": -1})
+
+	cursor, err := client.Database("config").Collection("actionlog").Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		round := balancerRound{}
+		if err := cursor.Decode(&round); err != nil {
+			return errors.Wrap(err, "cannot decode balancer round")
+		}
+
+		cd.BalancerRounds++
+		cd.ChunksMoved += round.Details.ChunksMoved
+
+		if round.Details.ErrorOccured {
+			cd.FailedRounds++
+			if len(cd.BalancerErrors) < balancerErrorsLimit {
+				cd.BalancerErrors = append(cd.BalancerErrors, balancerError{Time: round.Time, Message: round.Details.ErrMsg})
+			}
+		}
+	}
+
+	return cursor.Err()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// rawCommand is a command whose output is written as is by --collect-dir, for the cases the summary
+// does not cover.
+type rawCommand struct {
+	Name    string
+	Command primitive.D
+}
+
+//nolint:gochecknoglobals
+var rawCommands = []rawCommand{
+	{Name: "build-info", Command: primitive.D{{Key: "buildInfo", Value: 1}}},
+	{Name: "host-info", Command: primitive.D{{Key: "hostInfo", Value: 1}}},
+	{Name: "cmdline-opts", Command: primitive.D{{Key: "getCmdLineOpts", Value: 1}}},
+	{Name: "server-status", Command: primitive.D{{Key: "serverStatus", Value: 1}}},
+	{Name: "parameters", Command: primitive.D{{Key: "getParameter", Value: "*"}}},
+	{Name: "repl-set-status", Command: primitive.D{{Key: "replSetGetStatus", Value: 1}}},
+	{Name: "repl-set-config", Command: primitive.D{{Key: "replSetGetConfig", Value: 1}}},
+	{Name: "shard-map", Command: primitive.D{{Key: "getShardMap", Value: 1}}},
+	{Name: "shards", Command: primitive.D{{Key: "listShards", Value: 1}}},
+}
+
+// writeCollectDir writes every section of the text output to its own file, the summary as json and, with
+// raw, the output of rawCommands. The files are named after the tool, in a single directory, so that
+// pt-secure-collect can sanitize, pack and encrypt them with the ones of the other tools.
+func writeCollectDir(ctx context.Context, client *mongo.Client, ci *collectedInfo, dir string, raw bool) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return errors.Wrapf(err, "cannot create %q", dir)
+	}
+
+	for _, section := range textSections(ci) {
+		buf := new(bytes.Buffer)
+		if err := section.render(buf); err != nil {
+			return err
+		}
+
+		// sections that were not collected render nothing
+		if strings.TrimSpace(buf.String()) == "" {
+			continue
+		}
+
+		if err := writeCollectFile(dir, section.Name+".txt", buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(newReport(ci), "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "cannot convert results to json")
+	}
+
+	if err := writeCollectFile(dir, "summary.json", b); err != nil {
+		return err
+	}
+
+	if !raw {
+		return nil
+	}
+
+	for _, rc := range rawCommands {
+		res, err := client.Database("admin").RunCommand(ctx, rc.Command).DecodeBytes()
+		if err != nil {
+			// replica set and sharding commands fail on the other kinds of servers
+			log.Infof("Cannot run %s for --collect-dir: %s", rc.Command[0].Key, err)
+			continue
+		}
+
+		b, err := bson.MarshalExtJSONIndent(res, false, false, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "cannot convert the output of %s to json", rc.Command[0].Key)
+		}
+
+		if err := writeCollectFile(dir, "raw-"+rc.Name+".json", b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeCollectFile(dir, name string, data []byte) error {
+	filename := filepath.Join(dir, toolname+"_"+name)
+	if err := os.WriteFile(filename, append(data, '\n'), 0o600); err != nil {
+		return errors.Wrapf(err, "cannot write %q", filename)
+	}
+
+	return nil
+}
@@ -0,0 +1,316 @@
+// Package compat adapts the commands run by pt-mongodb-summary, and the way their results are read,
+// to the version of the server. Fields were renamed or moved over the releases and reading them the
+// old way on a newer server does not fail: it silently gives empty values.
+package compat
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+const (
+	// hello replaces isMaster, deprecated since 5.0.
+	helloVersion = "4.4.2"
+	// the net.ssl options were renamed net.tls.
+	tlsOptionsVersion = "4.2"
+	// config.chunks documents reference their collection by uuid instead of namespace.
+	chunksByUUIDVersion = "5.0"
+	// the collStats command is deprecated in favor of the $collStats aggregation stage.
+	collStatsStageVersion = "6.2"
+	// the $currentOp aggregation stage replaces the currentOp command.
+	currentOpStageVersion = "3.6"
+	// $currentOp on mongos lists its own client connections with localOps.
+	currentOpLocalOpsVersion = "4.0"
+	// $currentOp lists the idle cursors, like the ones of change streams waiting for events.
+	idleCursorsVersion = "4.2"
+	// the balancer evens the data size of the shards, instead of their number of chunks.
+	balanceByDataSizeVersion = "6.0.3"
+	// slaveDelay was renamed secondaryDelaySecs in the replica set configuration.
+	secondaryDelayVersion = "5.0"
+	// the cluster wide default write concern, set by setDefaultRWConcern, replaces getLastErrorDefaults.
+	defaultRWConcernVersion = "4.4"
+)
+
+// Server describes what a server supports, from its version.
+type Server struct {
+	Version string
+	version *version.Version
+}
+
+// New reads the version of the server the client is connected to.
+func New(ctx context.Context, client *mongo.Client) (*Server, error) {
+	bi := proto.BuildInfo{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"buildInfo": 1}).Decode(&bi); err != nil {
+		return nil, errors.Wrap(err, "cannot get the server version")
+	}
+
+	return NewFromVersion(bi.Version), nil
+}
+
+// NewFromVersion is used when the version is already known. Servers with an unknown version are
+// handled as the oldest ones, since the legacy commands are still available on newer servers.
+func NewFromVersion(ver string) *Server {
+	s := &Server{Version: ver}
+
+	v, err := version.NewVersion(ver)
+	if err != nil {
+		log.Debugf("cannot parse server version %q: %s", ver, err)
+		return s
+	}
+
+	// builds like 7.0.2-1 are not prereleases of 7.0.2
+	s.version = v.Core()
+
+	return s
+}
+
+// AtLeast returns true when the server version is ver or newer.
+func (s *Server) AtLeast(ver string) bool {
+	if s.version == nil {
+		return false
+	}
+
+	return s.version.GreaterThanOrEqual(version.Must(version.NewVersion(ver)))
+}
+
+// Before returns true when the server version is known to be older than ver.
+func (s *Server) Before(ver string) bool {
+	if s.version == nil {
+		return false
+	}
+
+	return s.version.LessThan(version.Must(version.NewVersion(ver)))
+}
+
+// HelloCommand is the command returning the role of the server.
+func (s *Server) HelloCommand() string {
+	if s.AtLeast(helloVersion) {
+		return "hello"
+	}
+
+	return "isMaster"
+}
+
+// TLSMode returns the TLS mode the server was started with, empty when it is not set.
+func (s *Server) TLSMode(cmdOpts proto.CommandLineOptions) string {
+	// servers still accept the net.ssl options, and report them as given
+	if s.AtLeast(tlsOptionsVersion) && cmdOpts.Parsed.Net.TLS.Mode != "" {
+		return cmdOpts.Parsed.Net.TLS.Mode
+	}
+
+	return cmdOpts.Parsed.Net.SSL.Mode
+}
+
+// BalancesDataSize returns true when the balancer moves data until the shards have the same size,
+// so that different numbers of chunks per shard are expected.
+func (s *Server) BalancesDataSize() bool {
+	return s.AtLeast(balanceByDataSizeVersion)
+}
+
+// SecondaryDelay returns how far behind the primary a delayed member is configured to be.
+func (s *Server) SecondaryDelay(m proto.Member) time.Duration {
+	if s.AtLeast(secondaryDelayVersion) {
+		return time.Duration(m.SecondaryDelaySecs) * time.Second
+	}
+
+	return time.Duration(m.SlaveDelay) * time.Second
+}
+
+// DefaultWriteConcern returns the w of the write concern used by the operations that do not set one.
+// From 5.0, the server reports the implicit default when none was set: majority, or 1 with arbiters.
+func (s *Server) DefaultWriteConcern(ctx context.Context, client *mongo.Client, settings proto.RSSettings) (string, error) {
+	if !s.AtLeast(defaultRWConcernVersion) {
+		if settings.GetLastErrorDefaults.W == nil {
+			return "1", nil
+		}
+		return fmt.Sprint(settings.GetLastErrorDefaults.W), nil
+	}
+
+	res := struct {
+		DefaultWriteConcern *struct {
+			W interface{} `bson:"w"`
+		} `bson:"defaultWriteConcern"`
+	}{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"getDefaultRWConcern": 1}).Decode(&res); err != nil {
+		return "", errors.Wrap(err, "cannot get the default write concern")
+	}
+
+	if res.DefaultWriteConcern == nil || res.DefaultWriteConcern.W == nil {
+		return "1", nil
+	}
+
+	return fmt.Sprint(res.DefaultWriteConcern.W), nil
+}
+
+// ChunksByCollectionPipeline counts the chunks of config.chunks per namespace.
+func (s *Server) ChunksByCollectionPipeline() []primitive.M {
+	if !s.AtLeast(chunksByUUIDVersion) {
+		// db.getSiblingDB('config').chunks.aggregate({$group:{_id:"$ns",count:{$sum:1}}})
+		return []primitive.M{{"$group": primitive.M{"_id": "$ns", "count": primitive.M{"$sum": 1}}}}
+	}
+
+	return []primitive.M{
+		{"$group": primitive.M{"_id": "$uuid", "count": primitive.M{"$sum": 1}}},
+		{"$lookup": primitive.M{"from": "collections", "localField": "_id", "foreignField": "uuid", "as": "collection"}},
+		{"$unwind": "$collection"},
+		{"$project": primitive.M{"_id": "$collection._id", "count": 1}},
+	}
+}
+
+// ChunksByCollectionAndShardPipeline counts the chunks of config.chunks per namespace and shard.
+func (s *Server) ChunksByCollectionAndShardPipeline() []primitive.M {
+	if !s.AtLeast(chunksByUUIDVersion) {
+		return []primitive.M{{"$group": primitive.M{
+			"_id":   primitive.M{"ns": "$ns", "shard": "$shard"},
+			"count": primitive.M{"$sum": 1},
+		}}}
+	}
+
+	return []primitive.M{
+		{"$group": primitive.M{"_id": primitive.M{"uuid": "$uuid", "shard": "$shard"}, "count": primitive.M{"$sum": 1}}},
+		{"$lookup": primitive.M{"from": "collections", "localField": "_id.uuid", "foreignField": "uuid", "as": "collection"}},
+		{"$unwind": "$collection"},
+		{"$project": primitive.M{"_id": primitive.M{"ns": "$collection._id", "shard": "$_id.shard"}, "count": 1}},
+	}
+}
+
+// OplogStats returns the storage statistics of an oplog collection.
+func (s *Server) OplogStats(ctx context.Context, client *mongo.Client, collection string) (proto.OplogColStats, error) {
+	var colStats proto.OplogColStats
+
+	if !s.AtLeast(collStatsStageVersion) {
+		err := client.Database("local").RunCommand(ctx, primitive.M{"collStats": collection}).Decode(&colStats)
+		return colStats, err
+	}
+
+	stage := primitive.M{"$collStats": primitive.M{"storageStats": primitive.M{}}}
+	cursor, err := client.Database("local").Collection(collection).Aggregate(ctx, []primitive.M{stage})
+	if err != nil {
+		return colStats, err
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		return colStats, errors.Errorf("no $collStats result for local.%s", collection)
+	}
+
+	res := struct {
+		StorageStats proto.OplogColStats `bson:"storageStats"`
+	}{}
+	if err := cursor.Decode(&res); err != nil {
+		return colStats, errors.Wrap(err, "cannot decode $collStats")
+	}
+
+	return res.StorageStats, nil
+}
+
+// CurrentOps returns the operations of all users matching filter, idle connections excluded.
+func (s *Server) CurrentOps(ctx context.Context, client *mongo.Client, filter primitive.D) ([]bson.Raw, error) {
+	if !s.AtLeast(currentOpStageVersion) {
+		cmd := append(primitive.D{{Key: "currentOp", Value: 1}, {Key: "$all", Value: true}}, filter...)
+		res := struct {
+			Inprog []bson.Raw `bson:"inprog"`
+		}{}
+		if err := client.Database("admin").RunCommand(ctx, cmd).Decode(&res); err != nil {
+			return nil, err
+		}
+
+		return res.Inprog, nil
+	}
+
+	pipeline := []primitive.M{
+		{"$currentOp": primitive.M{"allUsers": true}},
+		{"$match": filter},
+	}
+	cursor, err := client.Database("admin").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ops := []bson.Raw{}
+	for cursor.Next(ctx) {
+		ops = append(ops, append(bson.Raw{}, cursor.Current...))
+	}
+
+	return ops, cursor.Err()
+}
+
+// Connections returns the operations of all users, idle connections included. With local, mongos lists
+// its own client connections instead of the operations running on the shards.
+func (s *Server) Connections(ctx context.Context, client *mongo.Client, local bool) ([]bson.Raw, error) {
+	if !s.AtLeast(currentOpStageVersion) {
+		res := struct {
+			Inprog []bson.Raw `bson:"inprog"`
+		}{}
+		cmd := primitive.D{{Key: "currentOp", Value: 1}, {Key: "$all", Value: true}}
+		if err := client.Database("admin").RunCommand(ctx, cmd).Decode(&res); err != nil {
+			return nil, err
+		}
+
+		return res.Inprog, nil
+	}
+
+	stage := primitive.M{"allUsers": true, "idleConnections": true}
+	if local && s.AtLeast(currentOpLocalOpsVersion) {
+		stage["localOps"] = true
+	}
+
+	cursor, err := client.Database("admin").Aggregate(ctx, []primitive.M{{"$currentOp": stage}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ops := []bson.Raw{}
+	for cursor.Next(ctx) {
+		ops = append(ops, append(bson.Raw{}, cursor.Current...))
+	}
+
+	return ops, cursor.Err()
+}
+
+// ChangeStreamCursors counts the open change stream cursors, including the idle ones waiting for events.
+// It returns false before MongoDB 4.2, where $currentOp does not list idle cursors.
+func (s *Server) ChangeStreamCursors(ctx context.Context, client *mongo.Client, local bool) (int, bool, error) {
+	if !s.AtLeast(idleCursorsVersion) {
+		return 0, false, nil
+	}
+
+	stage := primitive.M{"allUsers": true, "idleCursors": true}
+	if local {
+		stage["localOps"] = true
+	}
+
+	pipeline := []primitive.M{
+		{"$currentOp": stage},
+		{"$match": primitive.M{"cursor.originatingCommand.pipeline.0.$changeStream": primitive.M{"$exists": true}}},
+		{"$count": "cursors"},
+	}
+	cursor, err := client.Database("admin").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, false, err
+	}
+	defer cursor.Close(ctx)
+
+	res := struct {
+		Cursors int `bson:"cursors"`
+	}{}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&res); err != nil {
+			return 0, false, err
+		}
+	}
+
+	return res.Cursors, true, cursor.Err()
+}
@@ -0,0 +1,80 @@
+package compat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+func TestVersions(t *testing.T) {
+	testCases := []struct {
+		version   string
+		hello     string
+		chunksLen int
+		before26  bool
+		bySize    bool
+	}{
+		{version: "3.6.23", hello: "isMaster", chunksLen: 1},
+		{version: "4.4.2", hello: "hello", chunksLen: 1},
+		{version: "6.0.2", hello: "hello", chunksLen: 4},
+		{version: "6.0.12", hello: "hello", chunksLen: 4, bySize: true},
+		{version: "7.0.2-1", hello: "hello", chunksLen: 4, bySize: true},
+		{version: "2.4.14", hello: "isMaster", chunksLen: 1, before26: true},
+		// unknown versions use the legacy commands, still available on recent servers
+		{version: "", hello: "isMaster", chunksLen: 1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.version, func(t *testing.T) {
+			s := NewFromVersion(test.version)
+			if got := s.HelloCommand(); got != test.hello {
+				t.Errorf("got hello command %s, want %s", got, test.hello)
+			}
+			if got := len(s.ChunksByCollectionPipeline()); got != test.chunksLen {
+				t.Errorf("got %d chunks pipeline stages, want %d", got, test.chunksLen)
+			}
+			if got := len(s.ChunksByCollectionAndShardPipeline()); got != test.chunksLen {
+				t.Errorf("got %d chunks per shard pipeline stages, want %d", got, test.chunksLen)
+			}
+			if got := s.BalancesDataSize(); got != test.bySize {
+				t.Errorf("got BalancesDataSize() %v, want %v", got, test.bySize)
+			}
+			if got := s.Before("2.6"); got != test.before26 {
+				t.Errorf("got Before(2.6) %v, want %v", got, test.before26)
+			}
+		})
+	}
+}
+
+func TestTLSMode(t *testing.T) {
+	cmdOpts := proto.CommandLineOptions{}
+	cmdOpts.Parsed.Net.SSL.Mode = "requireSSL"
+
+	if got := NewFromVersion("7.0.2").TLSMode(cmdOpts); got != "requireSSL" {
+		t.Errorf("net.ssl.mode should still be read on recent servers, got %q", got)
+	}
+
+	cmdOpts.Parsed.Net.SSL.Mode = ""
+	cmdOpts.Parsed.Net.TLS.Mode = "requireTLS"
+
+	if got := NewFromVersion("7.0.2").TLSMode(cmdOpts); got != "requireTLS" {
+		t.Errorf("got TLS mode %q, want requireTLS", got)
+	}
+
+	if got := NewFromVersion("4.0.28").TLSMode(cmdOpts); got != "" {
+		t.Errorf("net.tls does not exist before 4.2, got %q", got)
+	}
+}
+
+func TestSecondaryDelay(t *testing.T) {
+	m := proto.Member{SlaveDelay: 60, SecondaryDelaySecs: 3600}
+
+	if got := NewFromVersion("4.4.18").SecondaryDelay(m); got != time.Minute {
+		t.Errorf("slaveDelay should be read before 5.0, got %s", got)
+	}
+
+	if got := NewFromVersion("5.0.2").SecondaryDelay(m); got != time.Hour {
+		t.Errorf("secondaryDelaySecs should be read from 5.0, got %s", got)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/compat"
+)
+
+const (
+	DefaultConnectionClientsLimit = 10
+
+	// connections in use, compared to the ones the server accepts, above which new clients may be refused
+	connectionsUsedWarning = 80
+	// connections created per second since the server started, above which clients are likely not pooling them
+	connectionsCreatedWarning = 10
+)
+
+// connections is the state of the connection pool of the server and its network traffic. Clients has the
+// connections grouped by client host, the ones with the most connections first.
+type connections struct {
+	Current          int64
+	Available        int64
+	TotalCreated     int64
+	CreatedPerSecond float64
+	BytesIn          int64
+	BytesOut         int64
+	Requests         int64
+	Uptime           int64
+	// TotalClients is the number of client hosts, of which only the busiest ones are kept in Clients
+	TotalClients int
+	Clients      []clientConnections
+	Warnings     []string
+}
+
+type clientConnections struct {
+	Host        string
+	Connections int
+	Active      int
+	AppNames    []string
+}
+
+type rawConnection struct {
+	Client  string `bson:"client"`
+	ClientS string `bson:"client_s"`
+	AppName string `bson:"appName"`
+	Active  bool   `bson:"active"`
+}
+
+// getConnections reads the connection counters of serverStatus. With a clients limit, the connections
+// listed by $currentOp, idle ones included, are grouped by client host.
+func getConnections(ctx context.Context, client *mongo.Client, srv *compat.Server, mongos bool, clientsLimit int) (*connections, error) {
+	ss := proto.ServerStatus{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"serverStatus": 1}).Decode(&ss); err != nil {
+		return nil, errors.Wrap(err, "cannot get server status")
+	}
+
+	raws := []rawConnection{}
+	if clientsLimit > 0 {
+		docs, err := srv.Connections(ctx, client, mongos)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot list the connections")
+		}
+
+		for _, doc := range docs {
+			var raw rawConnection
+			if err := bson.Unmarshal(doc, &raw); err != nil {
+				return nil, errors.Wrap(err, "cannot decode connection")
+			}
+			raws = append(raws, raw)
+		}
+	}
+
+	return newConnections(ss, raws, clientsLimit), nil
+}
+
+func newConnections(ss proto.ServerStatus, raws []rawConnection, clientsLimit int) *connections {
+	c := &connections{Uptime: ss.Uptime, Clients: []clientConnections{}}
+
+	if ss.Connections != nil {
+		c.Current, c.Available, c.TotalCreated = ss.Connections.Current, ss.Connections.Available, ss.Connections.TotalCreated
+		if ss.Uptime > 0 {
+			c.CreatedPerSecond = float64(c.TotalCreated) / float64(ss.Uptime)
+		}
+	}
+
+	if ss.Network != nil {
+		c.BytesIn, c.BytesOut, c.Requests = ss.Network.BytesIn, ss.Network.BytesOut, ss.Network.NumRequests
+	}
+
+	c.Clients = groupConnections(raws)
+	c.TotalClients = len(c.Clients)
+	if len(c.Clients) > clientsLimit {
+		c.Clients = c.Clients[:clientsLimit]
+	}
+
+	c.Warnings = connectionsWarnings(c)
+
+	return c
+}
+
+// groupConnections counts the connections of every client host. Internal threads, without a client,
+// are left out.
+func groupConnections(raws []rawConnection) []clientConnections {
+	byHost := map[string]*clientConnections{}
+	appNames := map[string]map[string]bool{}
+
+	for _, raw := range raws {
+		addr := raw.Client
+		if addr == "" {
+			addr = raw.ClientS
+		}
+		if addr == "" {
+			continue
+		}
+
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+
+		cc, ok := byHost[host]
+		if !ok {
+			cc = &clientConnections{Host: host, AppNames: []string{}}
+			byHost[host] = cc
+			appNames[host] = map[string]bool{}
+		}
+
+		cc.Connections++
+		if raw.Active {
+			cc.Active++
+		}
+		if raw.AppName != "" && !appNames[host][raw.AppName] {
+			appNames[host][raw.AppName] = true
+			cc.AppNames = append(cc.AppNames, raw.AppName)
+		}
+	}
+
+	clients := []clientConnections{}
+	for _, cc := range byHost {
+		sort.Strings(cc.AppNames)
+		clients = append(clients, *cc)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Connections != clients[j].Connections {
+			return clients[i].Connections > clients[j].Connections
+		}
+		return clients[i].Host < clients[j].Host
+	})
+
+	return clients
+}
+
+func connectionsWarnings(c *connections) []string {
+	warnings := []string{}
+
+	if used := c.UsedPct(); used >= connectionsUsedWarning {
+		warnings = append(warnings, fmt.Sprintf("%.1f%% of the connections are in use: new clients will be refused once none are available", used))
+	}
+
+	if c.CreatedPerSecond >= connectionsCreatedWarning {
+		warnings = append(warnings, fmt.Sprintf("%.1f connections were created per second since the server started: clients may not be reusing them",
+			c.CreatedPerSecond))
+	}
+
+	return warnings
+}
+
+// UsedPct is how many connections are in use, compared to the ones the server accepts.
+func (c *connections) UsedPct() float64 {
+	return percent(c.Current, c.Current+c.Available)
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/howeyc/gopass"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/percona/percona-toolkit/src/go/lib/config"
+)
+
+// Environment variables read for the connection options not given on the command line.
+const (
+	envHost          = "PT_MONGODB_HOST"
+	envUser          = "PT_MONGODB_USER"
+	envPassword      = "PT_MONGODB_PASSWORD"
+	envAuthDB        = "PT_MONGODB_AUTHDB"
+	envEncryptionKey = "PT_MONGODB_ENCRYPTION_KEY"
+)
+
+// Key derivation of the encrypted passwords: scrypt, with the parameters recommended for interactive logins
+// and a random salt written before the nonce.
+const (
+	scryptN       = 32768
+	scryptR       = 8
+	scryptP       = 1
+	scryptSaltLen = 16
+	aesKeyLen     = 32
+)
+
+// connectionSetting is a connection option that can also be set in the environment or in the config files,
+// under the name of its long option.
+type connectionSetting struct {
+	option  string
+	aliases []string
+	env     string
+	value   *string
+}
+
+func connectionSettings(opts *cliOptions) []connectionSetting {
+	return []connectionSetting{
+		{option: "host", env: envHost, value: &opts.Host},
+		{option: "username", env: envUser, value: &opts.User},
+		{option: "password", env: envPassword, value: &opts.Password},
+		{option: "encrypted-password", value: &opts.EncryptedPassword},
+		{option: "encryption-key-file", value: &opts.EncryptionKeyFile},
+		{option: "authenticationDatabase", env: envAuthDB, value: &opts.AuthDB},
+		{option: "authenticationMechanism", value: &opts.AuthMechanism},
+		{option: "sslCAFile", aliases: []string{"tlsCAFile"}, value: &opts.SSLCAFile},
+		{option: "sslPEMKeyFile", aliases: []string{"tlsCertificateKeyFile"}, value: &opts.SSLPEMKeyFile},
+	}
+}
+
+// loadConfig reads the default config files, including the ones shared by every tool, then the one of
+// --config, that must exist.
+func loadConfig(file string) (*config.Config, error) {
+	files, err := config.DefaultConfigFiles(toolname)
+	if err != nil {
+		files = nil
+	}
+
+	return loadConfigFiles(files, file)
+}
+
+// loadConnectionConfig reads the connection settings from the default config files of the tool only, then
+// from the one of --config. The config files shared by every tool are skipped: their host, user and password
+// are the ones of the MySQL tools.
+func loadConnectionConfig(file string) (*config.Config, error) {
+	files, err := config.DefaultConfigFiles(toolname)
+	if err != nil {
+		files = nil
+	}
+
+	return loadConfigFiles(toolConfigFiles(files), file)
+}
+
+// toolConfigFiles returns the config files of the tool, pt-mongodb-summary.conf and .pt-mongodb-summary.conf.
+func toolConfigFiles(files []string) []string {
+	own := []string{}
+	for _, f := range files {
+		if base := filepath.Base(f); base == toolname+".conf" || base == "."+toolname+".conf" {
+			own = append(own, f)
+		}
+	}
+
+	return own
+}
+
+func loadConfigFiles(files []string, file string) (*config.Config, error) {
+	if file != "" {
+		if _, err := os.Stat(file); err != nil {
+			return nil, errors.Wrap(err, "cannot read the config file")
+		}
+		files = append(files, file)
+	}
+
+	return config.NewConfig(files...), nil
+}
+
+// applyConnectionSettings completes the connection options not given on the command line, from the
+// environment first and then from the config files, so that the password does not have to be in the
+// command line, where ps and the shell history show it.
+func applyConnectionSettings(opts *cliOptions, isSet func(string) bool, conf *config.Config, getenv func(string) string) error {
+	for _, s := range connectionSettings(opts) {
+		if isSet(s.option) || anySet(isSet, s.aliases) {
+			continue
+		}
+
+		if s.env != "" {
+			if v := getenv(s.env); v != "" {
+				*s.value = v
+				continue
+			}
+		}
+
+		for _, key := range append([]string{s.option}, s.aliases...) {
+			if v := conf.GetString(key); v != "" {
+				*s.value = v
+				break
+			}
+		}
+	}
+
+	if !isSet("tls") && conf.GetBool("tls") {
+		opts.TLS = true
+	}
+
+	if !isSet("tlsAllowInvalidCertificates") && conf.GetBool("tlsAllowInvalidCertificates") {
+		opts.TLSAllowInvalid = true
+	}
+
+	// a password in clear text, wherever it comes from, wins over an encrypted one from a config file
+	if opts.EncryptedPassword == "" || opts.Password != "" {
+		return nil
+	}
+
+	key, err := readEncryptionKey(opts.EncryptionKeyFile, getenv)
+	if err != nil {
+		return err
+	}
+
+	password, err := decryptPassword(opts.EncryptedPassword, key)
+	if err != nil {
+		return err
+	}
+
+	opts.Password = password
+
+	return nil
+}
+
+func anySet(isSet func(string) bool, options []string) bool {
+	for _, o := range options {
+		if isSet(o) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readEncryptionKey reads the key of the encrypted passwords from the key file or, without one,
+// from the environment.
+func readEncryptionKey(keyFile string, getenv func(string) string) ([]byte, error) {
+	if keyFile != "" {
+		key, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read the encryption key file")
+		}
+
+		return []byte(strings.TrimSpace(string(key))), nil
+	}
+
+	if key := getenv(envEncryptionKey); key != "" {
+		return []byte(key), nil
+	}
+
+	return nil, fmt.Errorf("an encrypted password needs a key: set encryption-key-file or %s", envEncryptionKey)
+}
+
+// encryptPassword encrypts with AES-256-GCM, using a key derived from key by scrypt. The salt and the nonce are
+// written before the cipher text, and the three are base64 encoded to fit in a config file.
+func encryptPassword(password string, key []byte) (string, error) {
+	salt := make([]byte, scryptSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", errors.Wrap(err, "cannot generate a salt")
+	}
+
+	gcm, err := newGCM(key, salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.Wrap(err, "cannot generate a nonce")
+	}
+
+	sealed := gcm.Seal(append(salt, nonce...), nonce, []byte(password), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptPassword(encrypted string, key []byte) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid encrypted password")
+	}
+
+	if len(sealed) < scryptSaltLen {
+		return "", errors.New("invalid encrypted password: too short")
+	}
+
+	gcm, err := newGCM(key, sealed[:scryptSaltLen])
+	if err != nil {
+		return "", err
+	}
+
+	sealed = sealed[scryptSaltLen:]
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted password: too short")
+	}
+
+	password, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt the password: wrong encryption key")
+	}
+
+	return string(password), nil
+}
+
+func newGCM(key, salt []byte) (cipher.AEAD, error) {
+	derivedKey, err := scrypt.Key(key, salt, scryptN, scryptR, scryptP, aesKeyLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot derive the encryption key")
+	}
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create the cipher")
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// runEncryptPassword prompts for a password and prints it encrypted, as the line to add to a config file.
+func runEncryptPassword(args []string, out io.Writer) int {
+	if len(args) > 1 {
+		fmt.Fprintf(out, "Usage: %s encrypt-password [<key file>]\n", toolname)
+		return cannotParseCommandLineParameters
+	}
+
+	keyFile := ""
+	if len(args) == 1 {
+		keyFile = args[0]
+	}
+
+	key, err := readEncryptionKey(keyFile, os.Getenv)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return cannotParseCommandLineParameters
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+
+	password, err := gopass.GetPasswd()
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return cannotParseCommandLineParameters
+	}
+
+	encrypted, err := encryptPassword(string(password), key)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return cannotFormatResults
+	}
+
+	fmt.Fprintf(out, "encrypted-password=%s\n", encrypted)
+
+	return 0
+}
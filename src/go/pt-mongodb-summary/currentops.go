@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/compat"
+)
+
+const (
+	DefaultCurrentOpsThreshold = 10 // seconds
+	DefaultCurrentOpsLimit     = 10
+
+	// command samples longer than this are truncated
+	maxCommandSampleLength = 512
+	redactedValue          = "?"
+)
+
+// fields of the command documents that are about the session or the driver, not the operation
+var commandMetadata = map[string]bool{
+	"lsid":                   true,
+	"txnNumber":              true,
+	"autocommit":             true,
+	"startTransaction":       true,
+	"$db":                    true,
+	"$clusterTime":           true,
+	"$readPreference":        true,
+	"$client":                true,
+	"$audit":                 true,
+	"$configServerState":     true,
+	"mayBypassWriteBlocking": true,
+}
+
+// currentOps lists the operations running for at least ThresholdSecs, longest first.
+// Total is the number of such operations, of which only the longest ones are kept in Ops.
+type currentOps struct {
+	ThresholdSecs int
+	Total         int
+	Ops           []currentOp
+}
+
+type currentOp struct {
+	OpID           string
+	Op             string
+	Namespace      string
+	Client         string
+	AppName        string
+	SecsRunning    int64
+	PlanSummary    string
+	WaitingForLock bool
+	// Command has its values redacted, only its shape is kept
+	Command string
+}
+
+type rawCurrentOp struct {
+	// opid is a number on mongod, and a "shard:opid" string on mongos
+	OpID           interface{} `bson:"opid"`
+	Op             string      `bson:"op"`
+	Namespace      string      `bson:"ns"`
+	Client         string      `bson:"client"`
+	ClientS        string      `bson:"client_s"`
+	AppName        string      `bson:"appName"`
+	SecsRunning    int64       `bson:"secs_running"`
+	PlanSummary    string      `bson:"planSummary"`
+	WaitingForLock bool        `bson:"waitingForLock"`
+	Command        bson.D      `bson:"command"`
+}
+
+func getCurrentOps(ctx context.Context, client *mongo.Client, srv *compat.Server, thresholdSecs, limit int) (*currentOps, error) {
+	filter := primitive.D{
+		{Key: "active", Value: true},
+		{Key: "secs_running", Value: primitive.M{"$gte": thresholdSecs}},
+	}
+
+	docs, err := srv.CurrentOps(ctx, client, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get the current operations")
+	}
+
+	ops := []currentOp{}
+	for _, doc := range docs {
+		var raw rawCurrentOp
+		if err := bson.Unmarshal(doc, &raw); err != nil {
+			return nil, errors.Wrap(err, "cannot decode current operation")
+		}
+		ops = append(ops, newCurrentOp(raw))
+	}
+
+	return newCurrentOps(ops, thresholdSecs, limit), nil
+}
+
+func newCurrentOp(raw rawCurrentOp) currentOp {
+	op := currentOp{
+		Op:             raw.Op,
+		Namespace:      raw.Namespace,
+		Client:         raw.Client,
+		AppName:        raw.AppName,
+		SecsRunning:    raw.SecsRunning,
+		PlanSummary:    raw.PlanSummary,
+		WaitingForLock: raw.WaitingForLock,
+		Command:        commandSample(raw.Command),
+	}
+	if raw.OpID != nil {
+		op.OpID = fmt.Sprint(raw.OpID)
+	}
+	if op.Client == "" {
+		op.Client = raw.ClientS
+	}
+
+	return op
+}
+
+// newCurrentOps keeps the limit longest running operations.
+func newCurrentOps(ops []currentOp, thresholdSecs, limit int) *currentOps {
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].SecsRunning > ops[j].SecsRunning })
+
+	co := &currentOps{ThresholdSecs: thresholdSecs, Total: len(ops)}
+	if len(ops) > limit {
+		ops = ops[:limit]
+	}
+	co.Ops = ops
+
+	return co
+}
+
+// commandSample returns the command as relaxed extended JSON, with every value replaced by "?" except
+// the collection the command runs on. Values can hold personal data, and must not end up in a summary
+// that is attached to tickets.
+func commandSample(cmd bson.D) string {
+	if len(cmd) == 0 {
+		return ""
+	}
+
+	sample := bson.D{}
+	for _, e := range cmd {
+		if commandMetadata[e.Key] {
+			continue
+		}
+
+		// the first field is the command name, and its value the collection
+		if _, ok := e.Value.(string); ok && len(sample) == 0 {
+			sample = append(sample, e)
+			continue
+		}
+
+		sample = append(sample, bson.E{Key: e.Key, Value: redact(e.Value)})
+	}
+
+	b, err := bson.MarshalExtJSON(sample, false, false)
+	if err != nil {
+		return ""
+	}
+
+	if len(b) > maxCommandSampleLength {
+		return string(b[:maxCommandSampleLength]) + "..."
+	}
+
+	return string(b)
+}
+
+// redact keeps the field names and operators of documents, and the length of arrays of documents.
+// Arrays of values, like the ones given to $in, are reduced to a single "?".
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		doc := bson.D{}
+		for _, e := range v {
+			doc = append(doc, bson.E{Key: e.Key, Value: redact(e.Value)})
+		}
+		return doc
+	case bson.A:
+		arr := bson.A{}
+		for _, item := range v {
+			r := redact(item)
+			if r == redactedValue && len(arr) > 0 && arr[len(arr)-1] == redactedValue {
+				continue
+			}
+			arr = append(arr, r)
+		}
+		return arr
+	}
+
+	return redactedValue
+}
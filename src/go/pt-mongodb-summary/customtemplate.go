@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// loadTemplate parses a --template file. It is done before connecting, so that a broken template
+// is reported before waiting for the data to be collected.
+func loadTemplate(path string) (*template.Template, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read template %s", path)
+	}
+
+	funcs := template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.MarshalIndent(v, "", "    ")
+			return string(b), err
+		},
+	}
+	for name, f := range templateFuncs {
+		funcs[name] = f
+	}
+
+	t, err := template.New(filepath.Base(path)).Funcs(funcs).Parse(string(b))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse template %s", path)
+	}
+
+	return t, nil
+}
+
+// renderTemplate executes a --template with the same data as --output json. Fields are accessed
+// by their Go names, like {{.Host.Version}}, that are as stable as the JSON ones.
+func renderTemplate(t *template.Template, ci *collectedInfo) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, newReport(ci)); err != nil {
+		return nil, errors.Wrap(err, "cannot execute the template")
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	categoryVersion       = "Version"
+	categoryTopology      = "Topology"
+	categoryConfiguration = "Configuration"
+)
+
+// difference is a field with different values in two summaries. Old or New is empty
+// when the value only exists in one of them.
+type difference struct {
+	Category string
+	Field    string
+	Old      string
+	New      string
+}
+
+type reportDiff struct {
+	diffs []difference
+}
+
+// runDiff implements "pt-mongodb-summary diff old.json new.json" and returns the exit code.
+func runDiff(args []string, out io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprintf(out, "Usage: %s diff <old summary.json> <new summary.json>\n", toolname)
+		return cannotParseCommandLineParameters
+	}
+
+	oldReport, err := readReport(args[0])
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return cannotReadReports
+	}
+
+	newReport, err := readReport(args[1])
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return cannotReadReports
+	}
+
+	if oldReport.FormatVersion != newReport.FormatVersion {
+		fmt.Fprintf(out, "cannot compare summaries of format versions %d and %d\n", oldReport.FormatVersion, newReport.FormatVersion)
+		return cannotReadReports
+	}
+
+	diffs := diffReports(oldReport, newReport)
+	fmt.Fprint(out, formatDifferences(args[0], args[1], diffs))
+
+	if len(diffs) > 0 {
+		return reportsDiffer
+	}
+
+	return 0
+}
+
+// readReport reads a summary printed by --output json.
+func readReport(path string) (*report, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", path)
+	}
+
+	r := &report{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s, it must be the output of --output json", path)
+	}
+
+	return r, nil
+}
+
+// diffReports compares the versions, topology and configuration of two summaries. Counters, sizes
+// and timestamps change all the time and are not compared.
+func diffReports(o, n *report) []difference {
+	d := &reportDiff{}
+
+	if o.Host != nil && n.Host != nil {
+		d.add(categoryVersion, "host.version", o.Host.Version, n.Host.Version)
+		d.add(categoryTopology, "host.hostname", o.Host.Hostname, n.Host.Hostname)
+		d.add(categoryTopology, "host.node_type", o.Host.NodeType, n.Host.NodeType)
+		d.add(categoryTopology, "host.replicaset", o.Host.Replicaset, n.Host.Replicaset)
+		d.add(categoryConfiguration, "host.os_type", o.Host.OSType, n.Host.OSType)
+		d.add(categoryConfiguration, "host.cpu_arch", o.Host.CPUArch, n.Host.CPUArch)
+		d.add(categoryConfiguration, "host.db_path", o.Host.DBPath, n.Host.DBPath)
+		d.add(categoryConfiguration, "host.cmdline_args", strings.Join(o.Host.CmdlineArgs, " "), strings.Join(n.Host.CmdlineArgs, " "))
+	}
+
+	d.diffMembers("members", o.Members, n.Members)
+
+	d.section("replica_set_config", o.ReplicaSet != nil, n.ReplicaSet != nil)
+	if o.ReplicaSet != nil && n.ReplicaSet != nil {
+		d.add(categoryConfiguration, "replica_set_config.default_write_concern", o.ReplicaSet.DefaultWriteConcern, n.ReplicaSet.DefaultWriteConcern)
+		d.diffReplicaSetMembers(o.ReplicaSet.Members, n.ReplicaSet.Members)
+	}
+
+	d.section("security", o.Security != nil, n.Security != nil)
+	if o.Security != nil && n.Security != nil {
+		d.add(categoryConfiguration, "security.auth", o.Security.Auth, n.Security.Auth)
+		d.add(categoryConfiguration, "security.ssl", o.Security.SSL, n.Security.SSL)
+		d.add(categoryConfiguration, "security.bind_ip", o.Security.BindIP, n.Security.BindIP)
+		d.add(categoryConfiguration, "security.port", o.Security.Port, n.Security.Port)
+		d.add(categoryConfiguration, "security.users", o.Security.Users, n.Security.Users)
+		d.add(categoryConfiguration, "security.roles", o.Security.Roles, n.Security.Roles)
+	}
+
+	d.section("security_audit", o.SecurityAudit != nil, n.SecurityAudit != nil)
+	if o.SecurityAudit != nil && n.SecurityAudit != nil {
+		oa, na := o.SecurityAudit, n.SecurityAudit
+		d.add(categoryConfiguration, "security_audit.authorization", oa.Authorization, na.Authorization)
+		d.add(categoryConfiguration, "security_audit.auditing", oa.Auditing, na.Auditing)
+		d.add(categoryConfiguration, "security_audit.tls_mode", oa.TLSMode, na.TLSMode)
+		d.add(categoryConfiguration, "security_audit.bind_ip_all", oa.BindIPAll, na.BindIPAll)
+		d.addSet(categoryConfiguration, "security_audit.auth_mechanisms", oa.AuthMechanisms, na.AuthMechanisms)
+		d.addSet(categoryConfiguration, "security_audit.users", auditUserNames(oa.Users), auditUserNames(na.Users))
+		d.addSet(categoryConfiguration, "security_audit.roles", auditRoleNames(oa.Roles), auditRoleNames(na.Roles))
+	}
+
+	d.section("wiredtiger", o.WiredTiger != nil, n.WiredTiger != nil)
+	if o.WiredTiger != nil && n.WiredTiger != nil {
+		d.add(categoryConfiguration, "wiredtiger.cache_max_bytes", o.WiredTiger.CacheMaxBytes, n.WiredTiger.CacheMaxBytes)
+	}
+
+	if o.Oplog != nil && n.Oplog != nil {
+		d.add(categoryConfiguration, "oplog.size_mb", o.Oplog.SizeMB, n.Oplog.SizeMB)
+	}
+
+	d.section("sharding", o.Sharding != nil, n.Sharding != nil)
+	if o.Sharding != nil && n.Sharding != nil {
+		d.add(categoryConfiguration, "sharding.balancer_mode", o.Sharding.BalancerMode, n.Sharding.BalancerMode)
+		if o.Sharding.ConfigServers != nil && n.Sharding.ConfigServers != nil {
+			d.diffShard("sharding.config_servers", *o.Sharding.ConfigServers, *n.Sharding.ConfigServers)
+		}
+		d.diffShards(o.Sharding.Shards, n.Sharding.Shards)
+	}
+
+	sort.SliceStable(d.diffs, func(i, j int) bool {
+		return categoryRank(d.diffs[i].Category) < categoryRank(d.diffs[j].Category)
+	})
+
+	return d.diffs
+}
+
+func (d *reportDiff) add(category, field string, o, n interface{}) {
+	if ov, nv := fmt.Sprint(o), fmt.Sprint(n); ov != nv {
+		d.diffs = append(d.diffs, difference{Category: category, Field: field, Old: ov, New: nv})
+	}
+}
+
+// addSet reports the items removed and added, regardless of their order.
+func (d *reportDiff) addSet(category, field string, o, n []string) {
+	inOld, inNew := map[string]bool{}, map[string]bool{}
+	for _, s := range o {
+		inOld[s] = true
+	}
+	for _, s := range n {
+		inNew[s] = true
+	}
+
+	for _, s := range sortedKeys(inOld) {
+		if !inNew[s] {
+			d.diffs = append(d.diffs, difference{Category: category, Field: field, Old: s})
+		}
+	}
+	for _, s := range sortedKeys(inNew) {
+		if !inOld[s] {
+			d.diffs = append(d.diffs, difference{Category: category, Field: field, New: s})
+		}
+	}
+}
+
+// section reports the optional sections collected in only one of the summaries, like --security-audit.
+func (d *reportDiff) section(name string, inOld, inNew bool) {
+	switch {
+	case inOld && !inNew:
+		d.diffs = append(d.diffs, difference{Category: categoryConfiguration, Field: name, Old: "collected"})
+	case !inOld && inNew:
+		d.diffs = append(d.diffs, difference{Category: categoryConfiguration, Field: name, New: "collected"})
+	}
+}
+
+func (d *reportDiff) diffMembers(field string, o, n []reportMember) {
+	d.addSet(categoryTopology, field, memberNames(o), memberNames(n))
+
+	byName := map[string]reportMember{}
+	for _, m := range o {
+		byName[m.Name] = m
+	}
+
+	for _, nm := range n {
+		om, ok := byName[nm.Name]
+		if !ok {
+			continue
+		}
+		d.add(categoryTopology, field+"."+nm.Name+".state", om.State, nm.State)
+		d.add(categoryTopology, field+"."+nm.Name+".replicaset", om.Replicaset, nm.Replicaset)
+		d.add(categoryConfiguration, field+"."+nm.Name+".storage_engine", om.StorageEngine, nm.StorageEngine)
+	}
+}
+
+// diffReplicaSetMembers compares how the members vote and replicate. Members added or removed are
+// already reported by diffMembers.
+func (d *reportDiff) diffReplicaSetMembers(o, n []reportReplicaSetMember) {
+	byHost := map[string]reportReplicaSetMember{}
+	for _, m := range o {
+		byHost[m.Host] = m
+	}
+
+	for _, nm := range n {
+		om, ok := byHost[nm.Host]
+		if !ok {
+			continue
+		}
+		field := "replica_set_config.members." + nm.Host
+		d.add(categoryConfiguration, field+".votes", om.Votes, nm.Votes)
+		d.add(categoryConfiguration, field+".priority", om.Priority, nm.Priority)
+		d.add(categoryConfiguration, field+".arbiter", om.Arbiter, nm.Arbiter)
+		d.add(categoryConfiguration, field+".hidden", om.Hidden, nm.Hidden)
+		d.add(categoryConfiguration, field+".delay_seconds", om.DelaySeconds, nm.DelaySeconds)
+		d.add(categoryConfiguration, field+".build_indexes", om.BuildIndexes, nm.BuildIndexes)
+	}
+}
+
+func (d *reportDiff) diffShards(o, n []reportShard) {
+	names := func(shards []reportShard) []string {
+		s := []string{}
+		for _, shard := range shards {
+			s = append(s, shard.Name)
+		}
+		return s
+	}
+	d.addSet(categoryTopology, "sharding.shards", names(o), names(n))
+
+	byName := map[string]reportShard{}
+	for _, s := range o {
+		byName[s.Name] = s
+	}
+
+	for _, ns := range n {
+		if oldShard, ok := byName[ns.Name]; ok {
+			d.diffShard("sharding.shards."+ns.Name, oldShard, ns)
+		}
+	}
+}
+
+func (d *reportDiff) diffShard(field string, o, n reportShard) {
+	d.add(categoryVersion, field+".version", o.Version, n.Version)
+	d.add(categoryTopology, field+".replicaset", o.Replicaset, n.Replicaset)
+	d.addSet(categoryTopology, field+".hosts", o.Hosts, n.Hosts)
+	d.diffMembers(field+".members", o.Members, n.Members)
+}
+
+// formatDifferences prints the differences grouped by category.
+func formatDifferences(oldName, newName string, diffs []difference) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	if len(diffs) == 0 {
+		sb.WriteString("No differences\n")
+		return sb.String()
+	}
+
+	category := ""
+	for _, diff := range diffs {
+		if diff.Category != category {
+			category = diff.Category
+			fmt.Fprintf(sb, "\n# %s\n", category)
+		}
+
+		switch {
+		case diff.Old == "":
+			fmt.Fprintf(sb, "  %s: added %s\n", diff.Field, diff.New)
+		case diff.New == "":
+			fmt.Fprintf(sb, "  %s: removed %s\n", diff.Field, diff.Old)
+		default:
+			fmt.Fprintf(sb, "  %s: %s -> %s\n", diff.Field, diff.Old, diff.New)
+		}
+	}
+
+	return sb.String()
+}
+
+func categoryRank(category string) int {
+	switch category {
+	case categoryVersion:
+		return 0
+	case categoryTopology:
+		return 1
+	}
+
+	return 2
+}
+
+func memberNames(members []reportMember) []string {
+	names := []string{}
+	for _, m := range members {
+		names = append(names, m.Name)
+	}
+
+	return names
+}
+
+func auditUserNames(users []reportAuditUser) []string {
+	names := []string{}
+	for _, u := range users {
+		names = append(names, fmt.Sprintf("%s@%s (%s)", u.User, u.DB, strings.Join(u.Roles, ", ")))
+	}
+
+	return names
+}
+
+func auditRoleNames(roles []reportAuditRole) []string {
+	names := []string{}
+	for _, r := range roles {
+		names = append(names, r.Role+"@"+r.DB)
+	}
+
+	return names
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
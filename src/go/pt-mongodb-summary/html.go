@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/templates"
+)
+
+// htmlReport is the data of --output html: the same report as --output json, with the warnings of
+// every section gathered at the top.
+type htmlReport struct {
+	*report
+	Title     string
+	Generated string
+	Warnings  []htmlWarning
+}
+
+type htmlWarning struct {
+	Section string
+	Message string
+}
+
+//nolint:gochecknoglobals
+var htmlFuncs = template.FuncMap{
+	"size": humanSize,
+	"join": func(s []string) string { return strings.Join(s, ", ") },
+	"seconds": func(s float64) string {
+		return time.Duration(s * float64(time.Second)).Round(time.Millisecond).String()
+	},
+	"lower": strings.ToLower,
+	"pct":   func(v float64) string { return fmt.Sprintf("%.1f%%", v) },
+	"deref": func(f *float64) float64 {
+		if f == nil {
+			return 0
+		}
+		return *f
+	},
+}
+
+// renderHTML renders a single file report, with its style embedded, that can be archived or shared
+// without the tool.
+func renderHTML(ci *collectedInfo, generated time.Time) ([]byte, error) {
+	r := newReport(ci)
+
+	hr := htmlReport{
+		report:    r,
+		Title:     toolname,
+		Generated: generated.UTC().Format(time.RFC1123),
+		Warnings:  htmlWarnings(r),
+	}
+	if r.Host != nil {
+		hr.Title = fmt.Sprintf("%s: %s", toolname, r.Host.Hostname)
+	}
+
+	t, err := template.New("html").Funcs(htmlFuncs).Parse(templates.HTML)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse the html template")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, hr); err != nil {
+		return nil, errors.Wrap(err, "cannot execute the html template")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// htmlWarnings gathers the problems flagged by the sections, so that they are seen first.
+func htmlWarnings(r *report) []htmlWarning {
+	warnings := []htmlWarning{}
+	add := func(section string, messages ...string) {
+		for _, m := range messages {
+			warnings = append(warnings, htmlWarning{Section: section, Message: m})
+		}
+	}
+
+	for _, c := range r.Checks {
+		if c.Failed {
+			add("Health Checks", c.Problems...)
+		}
+	}
+
+	for _, m := range r.Members {
+		if m.Error != "" {
+			add("Instances", fmt.Sprintf("%s: %s", m.Name, m.Error))
+		} else if m.Replicaset != "" && m.Health != 1 {
+			add("Instances", fmt.Sprintf("%s is down (%s)", m.Name, m.State))
+		}
+	}
+
+	if r.ReplicaSet != nil {
+		add("Replica Set Configuration", r.ReplicaSet.Warnings...)
+	}
+
+	if r.Connections != nil {
+		add("Connections", r.Connections.Warnings...)
+	}
+
+	if r.Transactions != nil {
+		add("Transactions and Change Streams", r.Transactions.Warnings...)
+	}
+
+	if r.WiredTiger != nil {
+		add("WiredTiger", r.WiredTiger.Warnings...)
+	}
+
+	if r.Security != nil {
+		add("Security", r.Security.Warnings...)
+	}
+
+	if r.SecurityAudit != nil {
+		for _, f := range r.SecurityAudit.Findings {
+			if f.Severity != severityLow {
+				add("Security Audit", fmt.Sprintf("[%s] %s", f.Severity, f.Message))
+			}
+		}
+	}
+
+	for _, w := range r.OplogWindows {
+		if w.Error != "" {
+			add("Oplog Window", fmt.Sprintf("%s: %s", w.Hostname, w.Error))
+		}
+	}
+
+	if r.Sharding != nil {
+		for _, s := range r.Sharding.Shards {
+			if s.Error != "" {
+				add("Sharded Cluster", fmt.Sprintf("%s: %s", s.Name, s.Error))
+			}
+		}
+	}
+
+	if r.Chunks != nil {
+		for _, c := range r.Chunks.Collections {
+			if c.Imbalanced {
+				add("Chunk Distribution", fmt.Sprintf("%s is imbalanced by %d chunks", c.Namespace, c.Imbalance))
+			}
+		}
+	}
+
+	for _, s := range r.Skipped {
+		add("Skipped Sections", fmt.Sprintf("%s: insufficient privileges", s.Section))
+	}
+
+	add("Errors", r.Errors...)
+
+	return warnings
+}
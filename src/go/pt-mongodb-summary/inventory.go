@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	DefaultCollStatsLimit          = 10
+	DefaultCollStatsMaxCollections = 1000
+)
+
+// inventory lists the largest databases and collections, the indexes that do not seem to be used and
+// the capped collections. Only the collections of the largest databases are read, up to MaxCollections,
+// so that servers with thousands of namespaces are summarized quickly.
+type inventory struct {
+	Databases         []databaseInventory
+	Collections       []collectionInventory
+	UnusedIndexes     []indexInventory
+	CappedCollections []collectionInventory
+	ReadCollections   int
+	MaxCollections    int
+	Truncated         bool
+}
+
+type databaseInventory struct {
+	Name        string
+	SizeOnDisk  int64
+	Collections int
+}
+
+type collectionInventory struct {
+	Namespace   string
+	Count       int64
+	Size        int64
+	StorageSize int64
+	Indexes     int64
+	IndexSize   int64
+	Capped      bool
+	MaxSize     int64
+}
+
+// indexInventory is an index with its accesses, summed on all the hosts it exists on.
+type indexInventory struct {
+	Namespace string
+	Name      string
+	Ops       int64
+	Since     time.Time
+}
+
+type databaseSizes struct {
+	Databases []struct {
+		Name       string `bson:"name"`
+		SizeOnDisk int64  `bson:"sizeOnDisk"`
+	} `bson:"databases"`
+}
+
+type collectionStats struct {
+	Count          int64 `bson:"count"`
+	Size           int64 `bson:"size"`
+	StorageSize    int64 `bson:"storageSize"`
+	Nindexes       int64 `bson:"nindexes"`
+	TotalIndexSize int64 `bson:"totalIndexSize"`
+	Capped         bool  `bson:"capped"`
+	MaxSize        int64 `bson:"maxSize"`
+}
+
+type indexStats struct {
+	Name     string `bson:"name"`
+	Accesses struct {
+		Ops   int64     `bson:"ops"`
+		Since time.Time `bson:"since"`
+	} `bson:"accesses"`
+}
+
+// internal databases, whose collections are not part of the inventory
+var internalDatabases = map[string]bool{"admin": true, "config": true, "local": true}
+
+func getInventory(ctx context.Context, client *mongo.Client, limit, maxCollections int) (*inventory, error) {
+	var dbs databaseSizes
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"listDatabases": 1}).Decode(&dbs); err != nil {
+		return nil, errors.Wrap(err, "cannot list databases")
+	}
+
+	sort.Slice(dbs.Databases, func(i, j int) bool { return dbs.Databases[i].SizeOnDisk > dbs.Databases[j].SizeOnDisk })
+
+	inv := &inventory{MaxCollections: maxCollections}
+	collections := []collectionInventory{}
+	unused := []indexInventory{}
+
+	for _, db := range dbs.Databases {
+		if internalDatabases[db.Name] {
+			continue
+		}
+
+		names, err := client.Database(db.Name).ListCollectionNames(ctx, primitive.M{"type": "collection"})
+		if err != nil {
+			log.Debugf("cannot list the collections of %s: %s", db.Name, err)
+			continue
+		}
+
+		inv.Databases = append(inv.Databases, databaseInventory{Name: db.Name, SizeOnDisk: db.SizeOnDisk, Collections: len(names)})
+
+		for _, name := range names {
+			if inv.ReadCollections >= maxCollections {
+				inv.Truncated = true
+				break
+			}
+			inv.ReadCollections++
+
+			ci, indexes, err := getCollectionInventory(ctx, client.Database(db.Name), name)
+			if err != nil {
+				log.Debugf("cannot get the stats of %s.%s: %s", db.Name, name, err)
+				continue
+			}
+
+			collections = append(collections, ci)
+			unused = append(unused, unusedIndexes(indexes)...)
+		}
+	}
+
+	inv.Databases = limitDatabases(inv.Databases, limit)
+	inv.Collections, inv.CappedCollections = rankCollections(collections, limit)
+	if len(unused) > limit {
+		unused = unused[:limit]
+	}
+	inv.UnusedIndexes = unused
+
+	return inv, nil
+}
+
+func getCollectionInventory(ctx context.Context, db *mongo.Database, name string) (collectionInventory, []indexInventory, error) {
+	ci := collectionInventory{Namespace: db.Name() + "." + name}
+
+	var stats collectionStats
+	if err := db.RunCommand(ctx, primitive.M{"collStats": name}).Decode(&stats); err != nil {
+		return ci, nil, errors.Wrap(err, "cannot run collStats")
+	}
+
+	ci.Count, ci.Size, ci.StorageSize = stats.Count, stats.Size, stats.StorageSize
+	ci.Indexes, ci.IndexSize = stats.Nindexes, stats.TotalIndexSize
+	ci.Capped, ci.MaxSize = stats.Capped, stats.MaxSize
+
+	cursor, err := db.Collection(name).Aggregate(ctx, []primitive.M{{"$indexStats": primitive.M{}}})
+	if err != nil {
+		// $indexStats needs MongoDB 3.2+ and the indexStats privilege: the collection is listed anyway
+		log.Debugf("cannot get the index stats of %s: %s", ci.Namespace, err)
+		return ci, nil, nil
+	}
+	defer cursor.Close(ctx)
+
+	accesses := []indexStats{}
+	if err := cursor.All(ctx, &accesses); err != nil {
+		return ci, nil, errors.Wrap(err, "cannot decode $indexStats")
+	}
+
+	return ci, sumIndexStats(ci.Namespace, accesses), nil
+}
+
+// sumIndexStats merges the accesses of the same index, reported once per host by mongos.
+// Since is the oldest, as accesses are only counted from then on all hosts.
+func sumIndexStats(namespace string, stats []indexStats) []indexInventory {
+	byName := map[string]int{}
+	indexes := []indexInventory{}
+
+	for _, s := range stats {
+		if i, ok := byName[s.Name]; ok {
+			indexes[i].Ops += s.Accesses.Ops
+			if s.Accesses.Since.Before(indexes[i].Since) {
+				indexes[i].Since = s.Accesses.Since
+			}
+			continue
+		}
+
+		byName[s.Name] = len(indexes)
+		indexes = append(indexes, indexInventory{Namespace: namespace, Name: s.Name, Ops: s.Accesses.Ops, Since: s.Accesses.Since})
+	}
+
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+
+	return indexes
+}
+
+// unusedIndexes returns the indexes without accesses. The _id index cannot be dropped, and is never listed.
+func unusedIndexes(indexes []indexInventory) []indexInventory {
+	unused := []indexInventory{}
+
+	for _, i := range indexes {
+		if i.Ops == 0 && i.Name != "_id_" {
+			unused = append(unused, i)
+		}
+	}
+
+	return unused
+}
+
+func limitDatabases(dbs []databaseInventory, limit int) []databaseInventory {
+	if len(dbs) > limit {
+		return dbs[:limit]
+	}
+
+	return dbs
+}
+
+// rankCollections returns the largest collections, and the largest capped collections.
+func rankCollections(collections []collectionInventory, limit int) ([]collectionInventory, []collectionInventory) {
+	sort.SliceStable(collections, func(i, j int) bool { return collections[i].Size > collections[j].Size })
+
+	capped := []collectionInventory{}
+	for _, c := range collections {
+		if c.Capped && len(capped) < limit {
+			capped = append(capped, c)
+		}
+	}
+
+	if len(collections) > limit {
+		collections = collections[:limit]
+	}
+
+	return collections, capped
+}
+
+// humanSize is used by the templates to print sizes in bytes.
+func humanSize(size int64) string {
+	value, unit := sizeAndUnit(size)
+	return fmt.Sprintf("%.2f %s", value, unit)
+}
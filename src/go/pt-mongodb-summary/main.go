@@ -7,16 +7,16 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
-	"html/template"
+	"io"
 	"io/ioutil"
 	"net"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
 
-	version "github.com/hashicorp/go-version"
 	"github.com/howeyc/gopass"
 	"github.com/pborman/getopt"
 	"github.com/pkg/errors"
@@ -25,11 +25,12 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/yaml.v2"
 
-	"github.com/percona/percona-toolkit/src/go/lib/config"
 	"github.com/percona/percona-toolkit/src/go/lib/versioncheck"
 	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
 	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/compat"
 	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/oplog"
 	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/templates"
 )
@@ -45,12 +46,20 @@ const (
 	DefaultOutputFormat       = "text"
 	typeMongos                = "mongos"
 
+	// the collected data as is, printed by --output-format json before the report document of --output json
+	outputCollectedJSON = "collected-json"
+
 	// Exit Codes.
 	cannotFormatResults              = 1
 	cannotParseCommandLineParameters = 2
 	cannotGetHostInfo                = 3
 	cannotGetClientOptions           = 4
 	cannotConnectToMongoDB           = 5
+	reportsDiffer                    = 6
+	cannotReadReports                = 7
+	cannotServeMetrics               = 8
+	healthChecksFailed               = 9
+	cannotWriteCollectDir            = 10
 )
 
 //nolint:gochecknoglobals
@@ -62,7 +71,14 @@ var (
 	Commit    string //nolint
 
 	defaultConnectionTimeout = 3 * time.Second
-	directConnection         = true
+	templateFuncs            = template.FuncMap{
+		"size": humanSize,
+		"join": func(s []string) string { return strings.Join(s, ", ") },
+	}
+	directConnection = true
+
+	// GSSAPI is not listed since it needs the driver to be built with the gssapi tag.
+	authMechanisms = []string{"SCRAM-SHA-1", "SCRAM-SHA-256", "MONGODB-X509", "PLAIN", "MONGODB-AWS"}
 )
 
 type TimedStats struct {
@@ -150,31 +166,78 @@ type cliOptions struct {
 	Host               string
 	User               string
 	Password           string
+	EncryptedPassword  string
+	EncryptionKeyFile  string
+	ConfigFile         string
 	AuthDB             string
 	LogLevel           string
 	OutputFormat       string
+	AuthMechanism      string
+	AWSSessionToken    string
 	SSLCAFile          string
 	SSLPEMKeyFile      string
+	TLS                bool
+	TLSAllowInvalid    bool
 	RunningOpsSamples  int
 	RunningOpsInterval int
 	Help               bool
 	Version            bool
 	NoVersionCheck     bool
 	NoRunningOps       bool
+	CollStats          bool
+	CollStatsLimit     int
+	CollStatsMax       int
+	SecurityAudit      bool
+	CurrentOpsSecs     int
+	CurrentOpsLimit    int
+	Interval           int
+	Samples            int
+	ChunkImbalance     int
+	HostTimeout        int
+	Mask               bool
+	Prometheus         string
+	Template           string
+	Checks             []healthCheck
+	ServerLogLimit     int
+	ClientsLimit       int
+	CollectDir         string
 }
 
 type collectedInfo struct {
 	BalancerStats    *proto.BalancerStats
 	ClusterWideInfo  *clusterwideInfo
+	ShardingInfo     *shardingInfo
+	Chunks           *chunkDistribution
 	OplogInfo        []proto.OplogInfo
+	OplogWindows     []oplog.MemberWindow
 	ReplicaMembers   []proto.Members
+	ReplicaSetConfig *replicaSetConfig
 	RunningOps       *opCounters
+	Activity         *activity
 	SecuritySettings *security
 	HostInfo         *hostInfo
+	Inventory        *inventory
+	SecurityAudit    *securityAudit
+	WiredTiger       *wiredTigerInfo
+	CurrentOps       *currentOps
+	Connections      *connections
+	Transactions     *transactions
 	Errors           []string
+	Skipped          skippedSections
+	ServerLog        *serverLog
+	FatalAssertions  []string
+	Checks           []checkResult
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:], os.Stdout))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "encrypt-password" {
+		os.Exit(runEncryptPassword(os.Args[2:], os.Stdout))
+	}
+
 	opts, err := parseFlags()
 	if err != nil {
 		log.Errorf("cannot get parameters: %s", err.Error())
@@ -202,7 +265,20 @@ func main() {
 		return
 	}
 
-	conf := config.DefaultConfig(toolname)
+	var customTemplate *template.Template
+	if opts.Template != "" {
+		if customTemplate, err = loadTemplate(opts.Template); err != nil {
+			log.Error(err)
+			os.Exit(cannotParseCommandLineParameters)
+		}
+	}
+
+	conf, err := loadConfig(opts.ConfigFile)
+	if err != nil {
+		log.Error(err)
+		os.Exit(cannotParseCommandLineParameters)
+	}
+
 	if !conf.GetBool("no-version-check") && !opts.NoVersionCheck {
 		advice, err := versioncheck.CheckUpdates(toolname, Version)
 		if err != nil {
@@ -234,6 +310,53 @@ func main() {
 
 	defer client.Disconnect(ctx) // nolint
 
+	if opts.Prometheus != "" {
+		if err := servePrometheus(opts, client, clientOptions); err != nil {
+			log.Errorf("Cannot serve the Prometheus metrics: %s", err)
+			os.Exit(cannotServeMetrics) //nolint:gocritic
+		}
+		return
+	}
+
+	ci, err := collect(ctx, opts, client, clientOptions)
+	if err != nil {
+		log.Errorf("Cannot get host info for %q: %s", opts.Host, err)
+		os.Exit(cannotGetHostInfo)
+	}
+
+	if opts.Mask {
+		newMasker().maskCollectedInfo(ci)
+	}
+
+	// the raw command outputs cannot be masked
+	if opts.CollectDir != "" {
+		if err := writeCollectDir(ctx, client, ci, opts.CollectDir, !opts.Mask); err != nil {
+			log.Errorf("Cannot write the collect dir: %s", err)
+			os.Exit(cannotWriteCollectDir)
+		}
+	}
+
+	var out []byte
+	if customTemplate != nil {
+		out, err = renderTemplate(customTemplate, ci)
+	} else {
+		out, err = formatResults(ci, opts.OutputFormat)
+	}
+	if err != nil {
+		log.Errorf("Cannot format the results: %s", err)
+		os.Exit(cannotFormatResults)
+	}
+
+	fmt.Println(string(out))
+
+	if anyCheckFailed(ci.Checks) {
+		os.Exit(healthChecksFailed)
+	}
+}
+
+// collect runs every section enabled by the options. It only fails when the host itself cannot be read,
+// the other sections are left empty when they cannot be collected.
+func collect(ctx context.Context, opts *cliOptions, client *mongo.Client, clientOptions *options.ClientOptions) (*collectedInfo, error) {
 	hostnames, err := util.GetHostnames(ctx, client)
 	if err != nil && errors.Is(err, util.ShardingNotEnabledError) {
 		log.Errorf("Cannot get hostnames: %s", err)
@@ -241,39 +364,94 @@ func main() {
 
 	log.Debugf("hostnames: %v", hostnames)
 
+	srv, err := compat.New(ctx, client)
+	if err != nil {
+		log.Warnf("Cannot get the server version, sections may be incomplete on recent versions: %s", err)
+		srv = compat.NewFromVersion("")
+	}
+
 	ci := &collectedInfo{}
 
-	ci.HostInfo, err = getHostInfo(ctx, client)
+	ci.HostInfo, err = getHostInfo(ctx, client, srv, &ci.Skipped)
 	if err != nil {
-		log.Errorf("Cannot get host info for %q: %s", opts.Host, err)
-		os.Exit(cannotGetHostInfo) //nolint:gocritic
+		return nil, err
 	}
 
-	if ci.ReplicaMembers, err = util.GetReplicasetMembers(ctx, clientOptions); err != nil {
+	hostTimeout := time.Duration(opts.HostTimeout) * time.Second
+
+	if ci.ReplicaMembers, err = getReplicasetMembers(ctx, clientOptions, hostTimeout); err != nil {
 		log.Warnf("[Error] cannot get replicaset members: %v\n", err)
 	}
 
 	log.Debugf("replicaMembers:\n%+v\n", ci.ReplicaMembers)
 
+	if ci.HostInfo.ReplicasetName != "" {
+		ci.ReplicaSetConfig, err = getReplicaSetConfig(ctx, client, srv)
+		if err != nil && !ci.Skipped.add("Replica Set Configuration", err) {
+			log.Printf("[Error] cannot get the replica set configuration: %v\n", err)
+		}
+	}
+
 	if opts.RunningOpsSamples > 0 && opts.RunningOpsInterval > 0 {
 		ci.RunningOps, err = getOpCountersStats(
 			ctx, client, opts.RunningOpsSamples,
 			time.Duration(opts.RunningOpsInterval)*time.Millisecond,
 		)
-		if err != nil {
+		if err != nil && !ci.Skipped.add("Running Ops", err) {
 			log.Printf("[Error] cannot get Opcounters stats: %v\n", err)
 		}
 	}
 
+	// mongos and servers using other storage engines have no WiredTiger section
+	ci.WiredTiger, err = getWiredTigerInfo(ctx, client)
+	if err != nil && !ci.Skipped.add("WiredTiger", err) {
+		log.Printf("[Error] cannot get WiredTiger stats: %v\n", err)
+	}
+
+	if opts.Interval > 0 && opts.Samples > 0 {
+		log.Infof("Sampling the activity %d times, every %d seconds", opts.Samples, opts.Interval)
+		ci.Activity, err = getActivity(ctx, client, time.Duration(opts.Interval)*time.Second, opts.Samples)
+		if err != nil && !ci.Skipped.add("Activity", err) {
+			log.Printf("[Error] cannot sample the activity: %v\n", err)
+		}
+	}
+
+	ci.Connections, err = getConnections(ctx, client, srv, ci.HostInfo.NodeType == typeMongos, opts.ClientsLimit)
+	if err != nil && !ci.Skipped.add("Connections", err) {
+		log.Printf("[Error] cannot get the connections: %v\n", err)
+	}
+
+	ci.Transactions, err = getTransactions(ctx, client, srv, ci.HostInfo.NodeType == typeMongos, hostnames,
+		arbiterNames(ci.ReplicaMembers),
+		clientOptions, hostTimeout)
+	if err != nil && !ci.Skipped.add("Transactions and Change Streams", err) {
+		log.Printf("[Error] cannot get the transactions: %v\n", err)
+	}
+
+	if opts.CurrentOpsLimit > 0 {
+		ci.CurrentOps, err = getCurrentOps(ctx, client, srv, opts.CurrentOpsSecs, opts.CurrentOpsLimit)
+		if err != nil && !ci.Skipped.add("Current Operations", err) {
+			log.Printf("[Error] cannot get the current operations: %v\n", err)
+		}
+	}
+
 	if ci.HostInfo != nil {
-		if ci.SecuritySettings, err = getSecuritySettings(ctx, client, ci.HostInfo.Version); err != nil {
+		ci.SecuritySettings, err = getSecuritySettings(ctx, client, srv)
+		if err != nil && !ci.Skipped.add("Security", err) {
 			log.Errorf("[Error] cannot get security settings: %v\n", err)
 		}
 	} else {
 		log.Warn("Cannot check security settings since host info is not available (permissions?)")
 	}
 
-	if ci.OplogInfo, err = oplog.GetOplogInfo(ctx, hostnames, clientOptions); err != nil {
+	if opts.SecurityAudit {
+		ci.SecurityAudit, err = getSecurityAudit(ctx, client, srv)
+		if err != nil && !ci.Skipped.add("Security Audit", err) {
+			log.Errorf("[Error] cannot get the security audit: %v\n", err)
+		}
+	}
+
+	if ci.OplogInfo, err = oplog.GetOplogInfo(ctx, hostnames, clientOptions, hostTimeout); err != nil {
 		log.Infof("Cannot get Oplog info: %s\n", err)
 	} else {
 		if len(ci.OplogInfo) == 0 {
@@ -283,100 +461,182 @@ func main() {
 		}
 	}
 
+	if len(hostnames) > 0 {
+		ci.OplogWindows = oplog.GetOplogWindows(ctx, hostnames, clientOptions, hostTimeout)
+	}
+
 	// individual servers won't know about this info
 	if ci.HostInfo.NodeType == typeMongos {
-		if ci.ClusterWideInfo, err = getClusterwideInfo(ctx, client); err != nil {
+		ci.ClusterWideInfo, err = getClusterwideInfo(ctx, client, srv)
+		if err != nil && !ci.Skipped.add("Cluster Wide", err) {
 			log.Printf("[Error] cannot get cluster wide info: %v\n", err)
 		}
 	}
 
 	if ci.HostInfo.NodeType == typeMongos {
-		if ci.BalancerStats, err = GetBalancerStats(ctx, client); err != nil {
+		ci.ShardingInfo, err = getShardingInfo(ctx, client, clientOptions, hostTimeout)
+		if err != nil && !ci.Skipped.add("Sharded Cluster", err) {
+			log.Printf("[Error] cannot get sharded cluster topology: %v\n", err)
+		}
+	}
+
+	if ci.HostInfo.NodeType == typeMongos {
+		ci.Chunks, err = getChunkDistribution(ctx, client, srv, int64(opts.ChunkImbalance))
+		if err != nil && !ci.Skipped.add("Chunk Distribution", err) {
+			log.Printf("[Error] cannot get the chunk distribution: %v\n", err)
+		}
+	}
+
+	if ci.HostInfo.NodeType == typeMongos {
+		ci.BalancerStats, err = GetBalancerStats(ctx, client)
+		if err != nil && !ci.Skipped.add("Balancer", err) {
 			log.Printf("[Error] cannot get balancer stats: %v\n", err)
 		}
 	}
 
-	out, err := formatResults(ci, opts.OutputFormat)
-	if err != nil {
-		log.Errorf("Cannot format the results: %s", err)
-		os.Exit(cannotFormatResults)
+	if opts.CollStats {
+		ci.Inventory, err = getInventory(ctx, client, opts.CollStatsLimit, opts.CollStatsMax)
+		if err != nil && !ci.Skipped.add("Collections and Indexes", err) {
+			log.Printf("[Error] cannot get collections inventory: %v\n", err)
+		}
 	}
 
-	fmt.Println(string(out))
+	if opts.ServerLogLimit > 0 {
+		ci.ServerLog, err = getServerLog(ctx, client, ci.HostInfo.Hostname, hostnames, clientOptions, hostTimeout,
+			opts.ServerLogLimit)
+		if err != nil && !ci.Skipped.add("Recent Log", err) {
+			log.Printf("[Error] cannot get the recent log: %v\n", err)
+		}
+	}
+
+	if hasCheck(opts.Checks, checkFassert) {
+		// the process of the server was found on this host
+		local := ci.HostInfo.ProcPath != "" && strings.HasPrefix(filepath.Base(ci.HostInfo.ProcPath), "mongo")
+		if ci.FatalAssertions, err = getFatalAssertions(ctx, client, local); err != nil {
+			log.Printf("[Error] cannot search the log for fatal assertions: %v\n", err)
+		}
+	}
+
+	if len(opts.Checks) > 0 {
+		ci.Checks = runChecks(ci, opts.Checks)
+	}
+
+	return ci, nil
 }
 
 func formatResults(ci *collectedInfo, format string) ([]byte, error) {
 	var buf *bytes.Buffer
 
 	switch format {
-	case "json":
+	case outputCollectedJSON:
 		b, err := json.MarshalIndent(ci, "", "    ")
 		if err != nil {
 			return nil, errors.Wrap(err, "Cannot convert results to json")
 		}
 
 		buf = bytes.NewBuffer(b)
-	default:
-		buf = new(bytes.Buffer)
-
-		t := template.Must(template.New("replicas").Parse(templates.Replicas))
-		if err := t.Execute(buf, ci.ReplicaMembers); err != nil {
-			return nil, errors.Wrap(err, "cannot parse replicas section of the output template")
-		}
-
-		t = template.Must(template.New("hosttemplateData").Parse(templates.HostInfo))
-		if err := t.Execute(buf, ci.HostInfo); err != nil {
-			return nil, errors.Wrap(err, "cannot parse hosttemplateData section of the output template")
+	case "json":
+		b, err := json.MarshalIndent(newReport(ci), "", "    ")
+		if err != nil {
+			return nil, errors.Wrap(err, "Cannot convert results to json")
 		}
 
-		t = template.Must(template.New("cmdlineargsa").Parse(templates.CmdlineArgs))
-		if err := t.Execute(buf, ci.HostInfo); err != nil {
-			return nil, errors.Wrap(err, "cannot parse the command line args section of the output template")
+		buf = bytes.NewBuffer(b)
+	case "yaml":
+		b, err := yaml.Marshal(newReport(ci))
+		if err != nil {
+			return nil, errors.Wrap(err, "Cannot convert results to yaml")
 		}
 
-		t = template.Must(template.New("runningOps").Parse(templates.RunningOps))
-		if err := t.Execute(buf, ci.RunningOps); err != nil {
-			return nil, errors.Wrap(err, "cannot parse runningOps section of the output template")
+		buf = bytes.NewBuffer(b)
+	case "prometheus":
+		buf = bytes.NewBuffer(newMetrics(ci))
+	case "html":
+		b, err := renderHTML(ci, time.Now())
+		if err != nil {
+			return nil, err
 		}
 
-		t = template.Must(template.New("ssl").Parse(templates.Security))
-		if err := t.Execute(buf, ci.SecuritySettings); err != nil {
-			return nil, errors.Wrap(err, "cannot parse ssl section of the output template")
-		}
+		buf = bytes.NewBuffer(b)
+	default:
+		buf = new(bytes.Buffer)
 
-		if ci.OplogInfo != nil && len(ci.OplogInfo) > 0 {
-			t = template.Must(template.New("oplogInfo").Parse(templates.Oplog))
-			if err := t.Execute(buf, ci.OplogInfo[0]); err != nil {
-				return nil, errors.Wrap(err, "cannot parse oplogInfo section of the output template")
+		for _, section := range textSections(ci) {
+			if err := section.render(buf); err != nil {
+				return nil, err
 			}
 		}
+	}
 
-		t = template.Must(template.New("clusterwide").Parse(templates.Clusterwide))
-		if err := t.Execute(buf, ci.ClusterWideInfo); err != nil {
-			return nil, errors.Wrap(err, "cannot parse clusterwide section of the output template")
-		}
+	return buf.Bytes(), nil
+}
 
-		t = template.Must(template.New("balancer").Parse(templates.BalancerStats))
-		if err := t.Execute(buf, ci.BalancerStats); err != nil {
-			return nil, errors.Wrap(err, "cannot parse balancer section of the output template")
-		}
+// textSection is a section of the text output, also written to its own file by --collect-dir.
+type textSection struct {
+	Name     string
+	Template string
+	Data     interface{}
+}
+
+func (s textSection) render(w io.Writer) error {
+	t := template.Must(template.New(s.Name).Funcs(templateFuncs).Parse(s.Template))
+	if err := t.Execute(w, s.Data); err != nil {
+		return errors.Wrapf(err, "cannot parse %s section of the output template", s.Name)
 	}
 
-	return buf.Bytes(), nil
+	return nil
+}
+
+// textSections lists the sections of the text output in the order they are printed.
+func textSections(ci *collectedInfo) []textSection {
+	sections := []textSection{
+		{Name: "replicas", Template: templates.Replicas, Data: ci.ReplicaMembers},
+		{Name: "replica-set-config", Template: templates.ReplicaSetConfig, Data: ci.ReplicaSetConfig},
+		{Name: "host-info", Template: templates.HostInfo, Data: ci.HostInfo},
+		{Name: "cmdline-args", Template: templates.CmdlineArgs, Data: ci.HostInfo},
+		{Name: "skipped-sections", Template: templates.SkippedSections, Data: ci.Skipped},
+		{Name: "running-ops", Template: templates.RunningOps, Data: ci.RunningOps},
+		{Name: "activity", Template: templates.Activity, Data: ci.Activity},
+		{Name: "connections", Template: templates.Connections, Data: ci.Connections},
+		{Name: "transactions", Template: templates.Transactions, Data: ci.Transactions},
+		{Name: "current-ops", Template: templates.CurrentOps, Data: ci.CurrentOps},
+		{Name: "wiredtiger", Template: templates.WiredTiger, Data: ci.WiredTiger},
+		{Name: "security", Template: templates.Security, Data: ci.SecuritySettings},
+		{Name: "security-audit", Template: templates.SecurityAudit, Data: ci.SecurityAudit},
+	}
+
+	if len(ci.OplogInfo) > 0 {
+		sections = append(sections, textSection{Name: "oplog", Template: templates.Oplog, Data: ci.OplogInfo[0]})
+	}
+
+	return append(sections,
+		textSection{Name: "oplog-windows", Template: templates.OplogWindows, Data: ci.OplogWindows},
+		textSection{Name: "cluster-wide", Template: templates.Clusterwide, Data: ci.ClusterWideInfo},
+		textSection{Name: "sharding", Template: templates.Sharding, Data: ci.ShardingInfo},
+		textSection{Name: "chunk-distribution", Template: templates.ChunkDistribution, Data: ci.Chunks},
+		textSection{Name: "balancer", Template: templates.BalancerStats, Data: ci.BalancerStats},
+		textSection{Name: "inventory", Template: templates.Inventory, Data: ci.Inventory},
+		textSection{Name: "server-log", Template: templates.ServerLog, Data: ci.ServerLog},
+		textSection{Name: "health-checks", Template: templates.HealthChecks, Data: ci.Checks},
+	)
 }
 
-func getHostInfo(ctx context.Context, client *mongo.Client) (*hostInfo, error) {
+// getHostInfo skips hostInfo and getCmdLineOpts when the user is not allowed to run them, like on Atlas:
+// the hostname is then the one of serverStatus, without the command line arguments.
+func getHostInfo(ctx context.Context, client *mongo.Client, srv *compat.Server, skipped *skippedSections) (*hostInfo, error) {
 	hi := proto.HostInfo{}
 	if err := client.Database("admin").RunCommand(ctx, primitive.M{"hostInfo": 1}).Decode(&hi); err != nil {
 		log.Debugf("run('hostInfo') error: %s", err)
 
-		return nil, errors.Wrap(err, "GetHostInfo.hostInfo")
+		if !skipped.add("Host Info", err) {
+			return nil, errors.Wrap(err, "GetHostInfo.hostInfo")
+		}
 	}
 
 	cmdOpts := proto.CommandLineOptions{}
 	query := primitive.D{{Key: "getCmdLineOpts", Value: 1}, {Key: "recordStats", Value: 1}}
 	err := client.Database("admin").RunCommand(ctx, query).Decode(&cmdOpts)
-	if err != nil {
+	if err != nil && !skipped.add("Command Line Options", err) {
 		return nil, errors.Wrap(err, "cannot get command line options")
 	}
 
@@ -386,12 +646,16 @@ func getHostInfo(ctx context.Context, client *mongo.Client) (*hostInfo, error) {
 		return nil, errors.Wrap(err, "GetHostInfo.serverStatus")
 	}
 
+	if hi.System.Hostname == "" {
+		hi.System.Hostname = ss.Host
+	}
+
 	pi := procInfo{}
 	if err := getProcInfo(int32(ss.Pid), &pi); err != nil {
 		pi.Error = err
 	}
 
-	nodeType, _ := getNodeType(ctx, client)
+	nodeType, _ := getNodeType(ctx, client, srv)
 	procCount, _ := countMongodProcesses()
 
 	i := &hostInfo{
@@ -442,7 +706,7 @@ func countMongodProcesses() (int, error) {
 	return count, nil
 }
 
-func getClusterwideInfo(ctx context.Context, client *mongo.Client) (*clusterwideInfo, error) {
+func getClusterwideInfo(ctx context.Context, client *mongo.Client, srv *compat.Server) (*clusterwideInfo, error) {
 	var databases databases
 
 	err := client.Database("admin").RunCommand(ctx, primitive.M{"listDatabases": 1}).Decode(&databases)
@@ -466,6 +730,11 @@ func getClusterwideInfo(ctx context.Context, client *mongo.Client) (*clusterwide
 				return nil, errors.Wrap(err, "cannot decode ListCollections doc")
 			}
 
+			// views have no stats, and time series are counted with their system.buckets collection
+			if c.Type != "" && c.Type != "collection" {
+				continue
+			}
+
 			var collStats proto.CollStats
 			err := client.Database(db.Name).RunCommand(ctx, primitive.M{"collStats": c.Name}).Decode(&collStats)
 			if err != nil {
@@ -489,7 +758,7 @@ func getClusterwideInfo(ctx context.Context, client *mongo.Client) (*clusterwide
 	cwi.ShardedDataSizeScaled, cwi.ShardedDataSizeScale = sizeAndUnit(cwi.ShardedDataSize)
 	cwi.UnshardedDataSizeScaled, cwi.UnshardedDataSizeScale = sizeAndUnit(cwi.UnshardedDataSize)
 
-	cwi.Chunks, err = getChunksCount(ctx, client)
+	cwi.Chunks, err = getChunksCount(ctx, client, srv)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot get chunks information")
 	}
@@ -512,21 +781,16 @@ func sizeAndUnit(size int64) (float64, string) {
 	return newSize, unit[idx]
 }
 
-func getSecuritySettings(ctx context.Context, client *mongo.Client, ver string) (*security, error) {
+func getSecuritySettings(ctx context.Context, client *mongo.Client, srv *compat.Server) (*security, error) {
 	s := security{
 		Auth: "disabled",
 		SSL:  "disabled",
 	}
 
-	v26, _ := version.NewVersion("2.6")
-	mongoVersion, err := version.NewVersion(ver)
-	prior26 := false
-	if err == nil && mongoVersion.LessThan(v26) {
-		prior26 = true
-	}
+	prior26 := srv.Before("2.6")
 
 	cmdOpts := proto.CommandLineOptions{}
-	err = client.Database("admin").RunCommand(ctx, primitive.D{
+	err := client.Database("admin").RunCommand(ctx, primitive.D{
 		{Key: "getCmdLineOpts", Value: 1},
 		{Key: "recordStats", Value: 1},
 	}).Decode(&cmdOpts)
@@ -539,8 +803,8 @@ func getSecuritySettings(ctx context.Context, client *mongo.Client, ver string)
 		s.Auth = "enabled"
 	}
 
-	if cmdOpts.Parsed.Net.SSL.Mode != "" && cmdOpts.Parsed.Net.SSL.Mode != "disabled" {
-		s.SSL = cmdOpts.Parsed.Net.SSL.Mode
+	if mode := srv.TLSMode(cmdOpts); mode != "" && mode != "disabled" {
+		s.SSL = mode
 	}
 
 	s.BindIP = cmdOpts.Parsed.Net.BindIP
@@ -600,9 +864,9 @@ func getUserRolesCount(ctx context.Context, client *mongo.Client) (int64, int64,
 	return users, roles, nil
 }
 
-func getNodeType(ctx context.Context, client *mongo.Client) (string, error) {
+func getNodeType(ctx context.Context, client *mongo.Client, srv *compat.Server) (string, error) {
 	md := proto.MasterDoc{}
-	if err := client.Database("admin").RunCommand(ctx, primitive.M{"isMaster": 1}).Decode(&md); err != nil {
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{srv.HelloCommand(): 1}).Decode(&md); err != nil {
 		return "", err
 	}
 
@@ -916,6 +1180,14 @@ func parseFlags() (*cliOptions, error) {
 		RunningOpsInterval: DefaultRunningOpsInterval, // milliseconds
 		AuthDB:             DefaultAuthDB,
 		OutputFormat:       DefaultOutputFormat,
+		CollStatsLimit:     DefaultCollStatsLimit,
+		CollStatsMax:       DefaultCollStatsMaxCollections,
+		CurrentOpsSecs:     DefaultCurrentOpsThreshold,
+		CurrentOpsLimit:    DefaultCurrentOpsLimit,
+		Samples:            DefaultSamples,
+		HostTimeout:        DefaultHostTimeout,
+		ServerLogLimit:     DefaultServerLogLimit,
+		ClientsLimit:       DefaultConnectionClientsLimit,
 	}
 
 	gop := getopt.New()
@@ -926,11 +1198,33 @@ func parseFlags() (*cliOptions, error) {
 	gop.StringVarLong(&opts.User, "username", 'u', "", "Username to use for optional MongoDB authentication")
 	gop.StringVarLong(&opts.Password, "password", 'p', "", "Password to use for optional MongoDB authentication").
 		SetOptional()
+	gop.StringVarLong(&opts.EncryptedPassword, "encrypted-password", 0,
+		"Password encrypted by the encrypt-password command, decrypted with --encryption-key-file")
+	gop.StringVarLong(&opts.EncryptionKeyFile, "encryption-key-file", 0,
+		"File holding the key of --encrypted-password. Default: the "+envEncryptionKey+" environment variable")
+	gop.StringVarLong(&opts.ConfigFile, "config", 0,
+		"Config file read after the default ones, that can hold the connection options and the password")
 	gop.StringVarLong(&opts.AuthDB, "authenticationDatabase", 'a', "admin",
 		"Database to use for optional MongoDB authentication. Default: admin")
+	gop.StringVarLong(&opts.AuthMechanism, "authenticationMechanism", 0,
+		"Authentication mechanism: "+strings.Join(authMechanisms, ", ")+". Default: negotiated with the server")
+	gop.StringVarLong(&opts.AWSSessionToken, "awsSessionToken", 0,
+		"AWS session token for MONGODB-AWS authentication with temporary credentials")
 	gop.StringVarLong(&opts.LogLevel, "log-level", 'l', "error",
 		"Log level: panic, fatal, error, warn, info, debug. Default: error")
-	gop.StringVarLong(&opts.OutputFormat, "output-format", 'f', "text", "Output format: text, json. Default: text")
+	gop.StringVarLong(&opts.OutputFormat, "output", 'o', "text",
+		"Output format: text, json, yaml, prometheus, html. Default: text")
+	var outputFormat string
+	gop.StringVarLong(&outputFormat, "output-format", 'f', "",
+		"Same as --output, except json which prints the collected data as is, like before --output")
+	gop.StringVarLong(&opts.Template, "template", 0,
+		"Go text/template file rendering the same data as --output json, instead of the text output")
+	gop.StringVarLong(&opts.Prometheus, "prometheus", 0,
+		"Serve the metrics of the summary to Prometheus on address[/path], eg :9216/metrics, collecting it on every scrape")
+	gop.BoolVarLong(&opts.Mask, "mask", 0, "",
+		"Replace hostnames, IP addresses and database names by pseudonyms, to share the summary")
+	gop.StringVarLong(&opts.CollectDir, "collect-dir", 0,
+		"Also write every section, the json summary and raw command outputs to files in this directory, for pt-secure-collect")
 
 	gop.IntVarLong(&opts.RunningOpsSamples, "running-ops-samples", 's',
 		fmt.Sprintf("Number of samples to collect for running ops. Default: %d", opts.RunningOpsSamples),
@@ -941,13 +1235,59 @@ func parseFlags() (*cliOptions, error) {
 			opts.RunningOpsInterval),
 	)
 
+	gop.IntVarLong(&opts.Interval, "interval", 0,
+		"Seconds between serverStatus samples, to report rates per second instead of counters. Default: 0, disabled")
+	gop.IntVarLong(&opts.Samples, "samples", 0,
+		fmt.Sprintf("Number of --interval samples. Default: %d", opts.Samples))
+
+	gop.IntVarLong(&opts.CurrentOpsSecs, "current-ops-threshold", 0,
+		fmt.Sprintf("List the operations running for at least this many seconds. Default: %d", opts.CurrentOpsSecs))
+	gop.IntVarLong(&opts.CurrentOpsLimit, "current-ops-limit", 0,
+		fmt.Sprintf("Number of long running operations listed, 0 to skip the section. Default: %d", opts.CurrentOpsLimit))
+
+	gop.IntVarLong(&opts.ClientsLimit, "connection-clients-limit", 0,
+		fmt.Sprintf("Number of client hosts listed with their connections, 0 to skip listing the connections. Default: %d",
+			opts.ClientsLimit))
+
+	gop.IntVarLong(&opts.ServerLogLimit, "server-log-limit", 0,
+		fmt.Sprintf("Number of recent log warnings and errors listed per member, 0 to skip the section. Default: %d",
+			opts.ServerLogLimit))
+
+	gop.IntVarLong(&opts.ChunkImbalance, "chunk-imbalance-threshold", 0,
+		"Flag the collections with a larger difference of chunks between shards. Default: 0, the balancer thresholds")
+
+	gop.IntVarLong(&opts.HostTimeout, "host-timeout", 0,
+		fmt.Sprintf("Seconds to wait for each member, shard and config server, that are read at the same time. "+
+			"Default: %d", opts.HostTimeout))
+
+	gop.BoolVarLong(&opts.CollStats, "collstats", 0, "",
+		"Add the largest databases and collections, unused indexes and capped collections")
+	gop.IntVarLong(&opts.CollStatsLimit, "collstats-limit", 0,
+		fmt.Sprintf("Number of items listed by --collstats. Default: %d", opts.CollStatsLimit))
+	gop.IntVarLong(&opts.CollStatsMax, "collstats-max-collections", 0,
+		fmt.Sprintf("Maximum number of collections read by --collstats, largest databases first. Default: %d",
+			opts.CollStatsMax))
+
+	gop.BoolVarLong(&opts.SecurityAudit, "security-audit", 0, "",
+		"Add the users, roles, authentication, TLS and auditing settings, and flag the risky ones")
+
 	gop.StringVarLong(&opts.SSLCAFile, "sslCAFile", 0, "SSL CA cert file used for authentication")
+	gop.StringVarLong(&opts.SSLCAFile, "tlsCAFile", 0, "Same as --sslCAFile")
 	gop.StringVarLong(&opts.SSLPEMKeyFile, "sslPEMKeyFile", 0, "SSL client PEM file used for authentication")
+	gop.StringVarLong(&opts.SSLPEMKeyFile, "tlsCertificateKeyFile", 0, "Same as --sslPEMKeyFile")
+	gop.BoolVarLong(&opts.TLS, "tls", 0, "", "Use TLS, implied by the other TLS options and by mongodb+srv:// URIs")
+	gop.BoolVarLong(&opts.TLSAllowInvalid, "tlsAllowInvalidCertificates", 0, "",
+		"Do not validate the server certificate and hostname")
+
+	var checks []string
+	gop.ListVarLong(&checks, "check", 0,
+		"Comma separated conditions setting the exit status to 9: no-primary, lag=<seconds>, member-down, fassert")
 
 	gop.SetParameters("host[:port]")
 	gop.Parse(os.Args)
 
-	if gop.NArgs() > 0 {
+	hostSet := gop.NArgs() > 0
+	if hostSet {
 		opts.Host = gop.Arg(0)
 		gop.Parse(gop.Args())
 	}
@@ -963,31 +1303,82 @@ func parseFlags() (*cliOptions, error) {
 		opts.Password = string(pass)
 	}
 
-	if !strings.HasPrefix(opts.Host, "mongodb://") {
-		opts.Host = "mongodb://" + opts.Host
-	}
-
 	if opts.Help {
 		gop.PrintUsage(os.Stdout)
 
 		return nil, nil
 	}
 
-	if opts.OutputFormat != "json" && opts.OutputFormat != "text" {
+	conf, err := loadConnectionConfig(opts.ConfigFile)
+	if err != nil {
+		return opts, err
+	}
+
+	isSet := func(option string) bool {
+		if option == "host" {
+			return hostSet
+		}
+		return gop.IsSet(option)
+	}
+	if err := applyConnectionSettings(opts, isSet, conf, os.Getenv); err != nil {
+		return opts, err
+	}
+
+	if !strings.HasPrefix(opts.Host, "mongodb://") && !strings.HasPrefix(opts.Host, "mongodb+srv://") {
+		opts.Host = "mongodb://" + opts.Host
+	}
+
+	if outputFormat != "" {
+		opts.OutputFormat = outputFormat
+		if outputFormat == "json" {
+			opts.OutputFormat = outputCollectedJSON
+		}
+	}
+
+	switch opts.OutputFormat {
+	case "json", "yaml", "text", "prometheus", "html", outputCollectedJSON:
+	default:
 		log.Infof("Invalid output format '%s'. Using text format", opts.OutputFormat)
 	}
 
+	if len(checks) > 0 {
+		var err error
+		if opts.Checks, err = parseChecks(checks); err != nil {
+			return opts, err
+		}
+	}
+
+	if opts.HostTimeout <= 0 {
+		return opts, fmt.Errorf("invalid --host-timeout %d, it must be greater than 0", opts.HostTimeout)
+	}
+
+	if opts.AuthMechanism != "" {
+		opts.AuthMechanism = strings.ToUpper(opts.AuthMechanism)
+		if !isValidAuthMechanism(opts.AuthMechanism) {
+			return opts, fmt.Errorf("invalid authentication mechanism %q, expected one of: %s",
+				opts.AuthMechanism, strings.Join(authMechanisms, ", "))
+		}
+	}
+
 	return opts, nil
 }
 
-func getChunksCount(ctx context.Context, client *mongo.Client) ([]proto.ChunksByCollection, error) {
+func isValidAuthMechanism(mechanism string) bool {
+	for _, m := range authMechanisms {
+		if m == mechanism {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getChunksCount(ctx context.Context, client *mongo.Client, srv *compat.Server) ([]proto.ChunksByCollection, error) {
 	var result []proto.ChunksByCollection
 
 	c := client.Database("config").Collection("chunks")
-	query := primitive.M{"$group": primitive.M{"_id": "$ns", "count": primitive.M{"$sum": 1}}}
 
-	// db.getSiblingDB('config').chunks.aggregate({$group:{_id:"$ns",count:{$sum:1}}})
-	cursor, err := c.Aggregate(ctx, []primitive.M{query})
+	cursor, err := c.Aggregate(ctx, srv.ChunksByCollectionPipeline())
 	if err != nil {
 		return nil, err
 	}
@@ -1008,35 +1399,78 @@ func getClientOptions(opts *cliOptions) (*options.ClientOptions, error) {
 	clientOptions := options.Client().ApplyURI(opts.Host)
 
 	clientOptions.ServerSelectionTimeout = &defaultConnectionTimeout
-	clientOptions.Direct = &directConnection
+	// The driver refuses direct connections to a seedlist: it discovers the topology from them instead.
+	if !strings.HasPrefix(opts.Host, "mongodb+srv://") && len(clientOptions.Hosts) <= 1 {
+		clientOptions.Direct = &directConnection
+	}
+
+	if credential := getCredential(opts, clientOptions.Auth); credential != nil {
+		clientOptions.SetAuth(*credential)
+	}
+
+	if opts.TLS || opts.TLSAllowInvalid || opts.SSLPEMKeyFile != "" || opts.SSLCAFile != "" {
+		tlsConfig, err := getTLSConfig(opts.SSLPEMKeyFile, opts.SSLCAFile, opts.TLSAllowInvalid)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read SSL certificate files")
+		}
+
+		clientOptions.TLSConfig = tlsConfig
+	}
+
+	if clientOptions.Auth != nil && clientOptions.Auth.AuthMechanism == "MONGODB-X509" &&
+		(clientOptions.TLSConfig == nil || len(clientOptions.TLSConfig.Certificates) == 0) {
+		return nil, errors.New("MONGODB-X509 authentication needs a client certificate, see --sslPEMKeyFile")
+	}
+
+	return clientOptions, nil
+}
+
+// getCredential adds the command line options to the credential of the URI, if any.
+// It returns nil when no authentication was requested.
+func getCredential(opts *cliOptions, uriCredential *options.Credential) *options.Credential {
+	if uriCredential == nil && opts.User == "" && opts.Password == "" && opts.AuthMechanism == "" {
+		return nil
+	}
+
 	credential := options.Credential{}
+	if uriCredential != nil {
+		credential = *uriCredential
+	}
+
 	if opts.User != "" {
 		credential.Username = opts.User
-		clientOptions.SetAuth(credential)
 	}
 
 	if opts.Password != "" {
 		credential.Password = opts.Password
 		credential.PasswordSet = true
-		clientOptions.SetAuth(credential)
 	}
 
-	if opts.SSLPEMKeyFile != "" || opts.SSLCAFile != "" {
-		tlsConfig, err := getTLSConfig(opts.SSLPEMKeyFile, opts.SSLCAFile)
-		if err != nil {
-			return nil, errors.Wrap(err, "cannot read SSL certificate files")
+	if opts.AuthMechanism != "" {
+		credential.AuthMechanism = opts.AuthMechanism
+	}
+
+	switch credential.AuthMechanism {
+	case "MONGODB-X509", "PLAIN", "MONGODB-AWS":
+		// users of these mechanisms are defined outside of MongoDB
+		credential.AuthSource = "$external"
+	default:
+		if credential.AuthSource == "" || opts.AuthDB != DefaultAuthDB {
+			credential.AuthSource = opts.AuthDB
 		}
+	}
 
-		clientOptions.TLSConfig = tlsConfig
+	if opts.AWSSessionToken != "" {
+		credential.AuthMechanismProperties = map[string]string{"AWS_SESSION_TOKEN": opts.AWSSessionToken}
 	}
 
-	return clientOptions, nil
+	return &credential
 }
 
-func getTLSConfig(sslPEMKeyFile, sslCAFile string) (*tls.Config, error) {
+func getTLSConfig(sslPEMKeyFile, sslCAFile string, allowInvalidCertificates bool) (*tls.Config, error) {
 	tlsConfig := &tls.Config{
 		MinVersion:         tls.VersionTLS10,
-		InsecureSkipVerify: true,
+		InsecureSkipVerify: allowInvalidCertificates, //nolint:gosec
 	}
 
 	roots := x509.NewCertPool()
@@ -1049,7 +1483,7 @@ func getTLSConfig(sslPEMKeyFile, sslCAFile string) (*tls.Config, error) {
 
 		cert, err := tls.X509KeyPair(crt, crt)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		tlsConfig.Certificates = []tls.Certificate{cert}
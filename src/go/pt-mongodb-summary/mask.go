@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+// hosts, addresses and databases that tell nothing about the deployment
+var (
+	unmaskedHosts = map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true, "0.0.0.0": true, "::": true, "*": true}
+	unmaskedDBs   = map[string]bool{"admin": true, "local": true, "config": true, "$external": true}
+
+	ipv4Regexp = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+)
+
+// masker replaces hostnames, IP addresses and database names by pseudonyms for --mask. A value always
+// gets the same pseudonym within a summary, so that the topology can still be followed.
+type masker struct {
+	hosts map[string]string
+	ips   map[string]string
+	dbs   map[string]string
+}
+
+func newMasker() *masker {
+	return &masker{
+		hosts: map[string]string{},
+		ips:   map[string]string{},
+		dbs:   map[string]string{},
+	}
+}
+
+// maskCollectedInfo masks the fields holding hostnames, addresses and databases first, then the messages
+// and command line arguments, that can only be masked by searching the values already seen.
+func (m *masker) maskCollectedInfo(ci *collectedInfo) {
+	if ci.HostInfo != nil {
+		ci.HostInfo.Hostname = m.host(ci.HostInfo.Hostname)
+	}
+
+	m.members(ci.ReplicaMembers)
+
+	if rc := ci.ReplicaSetConfig; rc != nil {
+		for i := range rc.Members {
+			rc.Members[i].Host = m.host(rc.Members[i].Host)
+		}
+	}
+
+	if si := ci.ShardingInfo; si != nil {
+		if si.ConfigServers != nil {
+			m.shard(si.ConfigServers)
+		}
+		for i := range si.Shards {
+			m.shard(&si.Shards[i])
+		}
+	}
+
+	for i := range ci.OplogInfo {
+		ci.OplogInfo[i].Hostname = m.host(ci.OplogInfo[i].Hostname)
+	}
+
+	for i := range ci.OplogWindows {
+		ci.OplogWindows[i].Hostname = m.host(ci.OplogWindows[i].Hostname)
+	}
+
+	if ci.ServerLog != nil {
+		for i := range ci.ServerLog.Members {
+			ci.ServerLog.Members[i].Hostname = m.host(ci.ServerLog.Members[i].Hostname)
+		}
+	}
+
+	if ci.Transactions != nil {
+		for i := range ci.Transactions.Members {
+			ci.Transactions.Members[i].Hostname = m.host(ci.Transactions.Members[i].Hostname)
+		}
+	}
+
+	if ci.SecuritySettings != nil {
+		ci.SecuritySettings.BindIP = m.hostList(ci.SecuritySettings.BindIP)
+	}
+
+	if sa := ci.SecurityAudit; sa != nil {
+		sa.BindIP = m.hostList(sa.BindIP)
+		for i := range sa.Users {
+			sa.Users[i].DB = m.db(sa.Users[i].DB)
+			m.roleRefs(sa.Users[i].Roles)
+		}
+		for i := range sa.Roles {
+			sa.Roles[i].DB = m.db(sa.Roles[i].DB)
+			m.roleRefs(sa.Roles[i].Roles)
+		}
+	}
+
+	if inv := ci.Inventory; inv != nil {
+		for i := range inv.Databases {
+			inv.Databases[i].Name = m.db(inv.Databases[i].Name)
+		}
+		for i := range inv.Collections {
+			inv.Collections[i].Namespace = m.namespace(inv.Collections[i].Namespace)
+		}
+		for i := range inv.UnusedIndexes {
+			inv.UnusedIndexes[i].Namespace = m.namespace(inv.UnusedIndexes[i].Namespace)
+		}
+		for i := range inv.CappedCollections {
+			inv.CappedCollections[i].Namespace = m.namespace(inv.CappedCollections[i].Namespace)
+		}
+	}
+
+	if ci.ClusterWideInfo != nil {
+		for i := range ci.ClusterWideInfo.Chunks {
+			ci.ClusterWideInfo.Chunks[i].ID = m.namespace(ci.ClusterWideInfo.Chunks[i].ID)
+		}
+	}
+
+	if ci.Chunks != nil {
+		for i := range ci.Chunks.Collections {
+			ci.Chunks.Collections[i].Namespace = m.namespace(ci.Chunks.Collections[i].Namespace)
+		}
+	}
+
+	if ci.Connections != nil {
+		for i := range ci.Connections.Clients {
+			ci.Connections.Clients[i].Host = m.host(ci.Connections.Clients[i].Host)
+		}
+	}
+
+	if ci.CurrentOps != nil {
+		for i := range ci.CurrentOps.Ops {
+			ci.CurrentOps.Ops[i].Namespace = m.namespace(ci.CurrentOps.Ops[i].Namespace)
+			ci.CurrentOps.Ops[i].Client = m.host(ci.CurrentOps.Ops[i].Client)
+		}
+	}
+
+	replacer := m.replacer()
+	text := func(s string) string { return replacer.Replace(m.text(s)) }
+
+	if ci.HostInfo != nil {
+		for i := range ci.HostInfo.CmdlineArgs {
+			ci.HostInfo.CmdlineArgs[i] = text(ci.HostInfo.CmdlineArgs[i])
+		}
+	}
+
+	for i := range ci.ReplicaMembers {
+		ci.ReplicaMembers[i].Error = text(ci.ReplicaMembers[i].Error)
+	}
+
+	if rc := ci.ReplicaSetConfig; rc != nil {
+		for i := range rc.Warnings {
+			rc.Warnings[i] = text(rc.Warnings[i])
+		}
+	}
+
+	if si := ci.ShardingInfo; si != nil {
+		for i := range si.Shards {
+			si.Shards[i].Error = text(si.Shards[i].Error)
+			for j := range si.Shards[i].Members {
+				si.Shards[i].Members[j].Error = text(si.Shards[i].Members[j].Error)
+			}
+		}
+		if cs := si.ConfigServers; cs != nil {
+			cs.Error = text(cs.Error)
+			for j := range cs.Members {
+				cs.Members[j].Error = text(cs.Members[j].Error)
+			}
+		}
+	}
+
+	for i := range ci.OplogWindows {
+		ci.OplogWindows[i].Error = text(ci.OplogWindows[i].Error)
+	}
+
+	if ci.Transactions != nil {
+		for i := range ci.Transactions.Members {
+			ci.Transactions.Members[i].Error = text(ci.Transactions.Members[i].Error)
+		}
+		for i := range ci.Transactions.Warnings {
+			ci.Transactions.Warnings[i] = text(ci.Transactions.Warnings[i])
+		}
+	}
+
+	if ci.SecuritySettings != nil {
+		for i := range ci.SecuritySettings.WarningMsgs {
+			ci.SecuritySettings.WarningMsgs[i] = text(ci.SecuritySettings.WarningMsgs[i])
+		}
+	}
+
+	if ci.SecurityAudit != nil {
+		for i := range ci.SecurityAudit.Findings {
+			ci.SecurityAudit.Findings[i].Message = text(ci.SecurityAudit.Findings[i].Message)
+		}
+	}
+
+	if ci.Chunks != nil {
+		for i := range ci.Chunks.BalancerErrors {
+			ci.Chunks.BalancerErrors[i].Message = text(ci.Chunks.BalancerErrors[i].Message)
+		}
+	}
+
+	for i := range ci.Errors {
+		ci.Errors[i] = text(ci.Errors[i])
+	}
+
+	for i := range ci.Skipped {
+		ci.Skipped[i].Reason = text(ci.Skipped[i].Reason)
+	}
+
+	if ci.ServerLog != nil {
+		for i := range ci.ServerLog.Members {
+			ml := &ci.ServerLog.Members[i]
+			ml.Error = text(ml.Error)
+			for j := range ml.Recent {
+				ml.Recent[j].Message = text(ml.Recent[j].Message)
+			}
+		}
+	}
+
+	for i := range ci.FatalAssertions {
+		ci.FatalAssertions[i] = text(ci.FatalAssertions[i])
+	}
+
+	for i := range ci.Checks {
+		for j := range ci.Checks[i].Problems {
+			ci.Checks[i].Problems[j] = text(ci.Checks[i].Problems[j])
+		}
+	}
+}
+
+func (m *masker) shard(s *shardSummary) {
+	for i := range s.Hosts {
+		s.Hosts[i] = m.host(s.Hosts[i])
+	}
+	m.members(s.Members)
+}
+
+func (m *masker) members(members []proto.Members) {
+	for i := range members {
+		members[i].Name = m.host(members[i].Name)
+	}
+}
+
+// roleRefs masks the database of roles written as role@db.
+func (m *masker) roleRefs(roles []string) {
+	for i, r := range roles {
+		if at := strings.LastIndex(r, "@"); at >= 0 {
+			roles[i] = r[:at+1] + m.db(r[at+1:])
+		}
+	}
+}
+
+// host masks a hostname or an IP address, with or without a port. The port is kept.
+func (m *masker) host(hostport string) string {
+	if hostport == "" {
+		return ""
+	}
+
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host, port = hostport, ""
+	}
+
+	var masked string
+	switch {
+	case unmaskedHosts[host]:
+		masked = host
+	case net.ParseIP(host) != nil:
+		masked = m.ip(host)
+	default:
+		masked = pseudonym(m.hosts, host, "host")
+	}
+
+	if port == "" {
+		return masked
+	}
+
+	return net.JoinHostPort(masked, port)
+}
+
+// hostList masks comma separated hosts, like the ones of bindIp.
+func (m *masker) hostList(list string) string {
+	if list == "" {
+		return ""
+	}
+
+	hosts := strings.Split(list, ",")
+	for i, h := range hosts {
+		hosts[i] = m.host(strings.TrimSpace(h))
+	}
+
+	return strings.Join(hosts, ",")
+}
+
+func (m *masker) ip(ip string) string {
+	if unmaskedHosts[ip] {
+		return ip
+	}
+
+	return pseudonym(m.ips, ip, "ip")
+}
+
+func (m *masker) db(name string) string {
+	if name == "" || unmaskedDBs[name] {
+		return name
+	}
+
+	return pseudonym(m.dbs, name, "db")
+}
+
+// namespace masks the database of a db.collection namespace. Collection names are kept.
+func (m *masker) namespace(ns string) string {
+	if i := strings.Index(ns, "."); i >= 0 {
+		return m.db(ns[:i]) + ns[i:]
+	}
+
+	return m.db(ns)
+}
+
+// text masks the IPv4 addresses of a message.
+func (m *masker) text(s string) string {
+	return ipv4Regexp.ReplaceAllStringFunc(s, m.ip)
+}
+
+// replacer masks the hostnames and the databases, written as user@db, already seen in messages.
+// Longer values are replaced first, so that db1.example.com is not masked as db1.
+func (m *masker) replacer() *strings.Replacer {
+	pairs := [][2]string{}
+	for host, masked := range m.hosts {
+		pairs = append(pairs, [2]string{host, masked})
+	}
+	for db, masked := range m.dbs {
+		pairs = append(pairs, [2]string{"@" + db, "@" + masked})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if len(pairs[i][0]) != len(pairs[j][0]) {
+			return len(pairs[i][0]) > len(pairs[j][0])
+		}
+		return pairs[i][0] < pairs[j][0]
+	})
+
+	oldnew := make([]string, 0, 2*len(pairs))
+	for _, p := range pairs {
+		oldnew = append(oldnew, p[0], p[1])
+	}
+
+	return strings.NewReplacer(oldnew...)
+}
+
+// pseudonym returns the pseudonym of value, numbered in the order the values are seen.
+func pseudonym(seen map[string]string, value, prefix string) string {
+	if masked, ok := seen[value]; ok {
+		return masked
+	}
+
+	masked := fmt.Sprintf("%s%d", prefix, len(seen)+1)
+	seen[value] = masked
+
+	return masked
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+)
+
+const (
+	DefaultHostTimeout = 10 // seconds
+
+	stateUnreachable = "UNREACHABLE"
+)
+
+// memberStatus is what a single host reports: itself for a mongos, every member of its replica set otherwise.
+type memberStatus struct {
+	Hostname string
+	Members  []proto.Members
+	Err      error
+}
+
+// getReplicasetMembers connects to every host of the cluster at the same time, each one with its own timeout,
+// so that a hidden or down member does not stall the summary. Hosts that cannot be read are listed with
+// their error instead of failing the whole section.
+func getReplicasetMembers(ctx context.Context, clientOptions *options.ClientOptions, hostTimeout time.Duration) ([]proto.Members, error) {
+	client, err := mongo.NewClient(clientOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get a new client for getReplicasetMembers")
+	}
+
+	seedCtx, cancel := context.WithTimeout(ctx, hostTimeout)
+	defer cancel()
+
+	if err := client.Connect(seedCtx); err != nil {
+		return nil, errors.Wrap(err, "cannot connect to MongoDB")
+	}
+
+	hostnames, err := util.GetHostnames(seedCtx, client)
+	client.Disconnect(ctx) // nolint
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]memberStatus, len(hostnames))
+
+	var wg sync.WaitGroup
+	for i, hostname := range hostnames {
+		wg.Add(1)
+		go func(i int, hostname string) {
+			defer wg.Done()
+
+			hostCtx, cancel := context.WithTimeout(ctx, hostTimeout)
+			defer cancel()
+
+			members, err := getMemberStatus(hostCtx, clientOptions, hostname)
+			statuses[i] = memberStatus{Hostname: hostname, Members: members, Err: err}
+		}(i, hostname)
+	}
+	wg.Wait()
+
+	return mergeMemberStatuses(statuses), nil
+}
+
+// mergeMemberStatuses keeps the first status reported for each member, in the order of the hosts. The error of an
+// unreachable host is added to the status its peers report, or to an UNREACHABLE member if none does.
+func mergeMemberStatuses(statuses []memberStatus) []proto.Members {
+	membersMap := make(map[string]proto.Members)
+
+	for _, s := range statuses {
+		for _, m := range s.Members {
+			if _, ok := membersMap[m.Name]; !ok {
+				membersMap[m.Name] = m
+			}
+		}
+	}
+
+	for _, s := range statuses {
+		if s.Err == nil {
+			continue
+		}
+
+		m, ok := membersMap[s.Hostname]
+		if !ok {
+			m = proto.Members{Name: s.Hostname, StateStr: stateUnreachable}
+		}
+		m.Error = s.Err.Error()
+		membersMap[s.Hostname] = m
+	}
+
+	members := make([]proto.Members, 0, len(membersMap))
+	for _, m := range membersMap {
+		members = append(members, m)
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+
+	return members
+}
+
+func getMemberStatus(ctx context.Context, clientOptions *options.ClientOptions, hostname string) ([]proto.Members, error) {
+	client, err := util.GetClientForHost(clientOptions, hostname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get a new client to connect to %s", hostname)
+	}
+
+	if err := client.Connect(ctx); err != nil {
+		return nil, errors.Wrapf(err, "cannot connect to %s", hostname)
+	}
+	defer client.Disconnect(context.Background()) // nolint
+
+	// Connect does not wait for the server, ping it so that an unreachable host is not taken for a mongos
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, errors.Wrapf(err, "cannot reach %s", hostname)
+	}
+
+	cmdOpts := proto.CommandLineOptions{}
+	// Not always we can get this info. For examples, we cannot get this for hidden hosts so
+	// if there is an error, just ignore it
+	res := client.Database("admin").RunCommand(ctx, primitive.D{
+		{Key: "getCmdLineOpts", Value: 1},
+		{Key: "recordStats", Value: 1},
+	})
+	if res.Err() == nil {
+		if err := res.Decode(&cmdOpts); err != nil {
+			return nil, errors.Wrapf(err, "cannot decode getCmdLineOpts response for host %s", hostname)
+		}
+	}
+
+	serverStatus, ssErr := util.GetServerStatus(ctx, client)
+
+	rss := proto.ReplicaSetStatus{}
+	res = client.Database("admin").RunCommand(ctx, primitive.M{"replSetGetStatus": 1})
+	if res.Err() != nil {
+		// If a host is a mongos we cannot get info but is not a real error
+		m := proto.Members{
+			Name:     hostname,
+			StateStr: strings.ToUpper(cmdOpts.Parsed.Sharding.ClusterRole),
+		}
+		if ssErr == nil {
+			m.ID = serverStatus.Pid
+			m.StorageEngine = serverStatus.StorageEngine
+		}
+
+		return []proto.Members{m}, nil
+	}
+
+	if err := res.Decode(&rss); err != nil {
+		return nil, errors.Wrapf(err, "cannot decode replSetGetStatus response for host %s", hostname)
+	}
+
+	members := make([]proto.Members, 0, len(rss.Members))
+	for _, m := range rss.Members {
+		m.Set = rss.Set
+
+		if ssErr == nil {
+			m.ID = serverStatus.Pid
+			m.StorageEngine = serverStatus.StorageEngine
+
+			if cmdOpts.Parsed.Sharding.ClusterRole != "" {
+				m.StateStr = cmdOpts.Parsed.Sharding.ClusterRole + "/" + m.StateStr
+			}
+
+			m.StateStr = strings.ToUpper(m.StateStr)
+		}
+
+		members = append(members, m)
+	}
+
+	return members, nil
+}
+
+// memberState returns the state of a member without the cluster role that getMemberStatus prefixes to the
+// members of the shards and config servers, like PRIMARY for SHARDSVR/PRIMARY.
+func memberState(m proto.Members) string {
+	role, state, found := strings.Cut(m.StateStr, "/")
+	if found && (role == "SHARDSVR" || role == "CONFIGSVR") {
+		return state
+	}
+
+	return m.StateStr
+}
+
+// arbiterNames returns the names of the arbiters among the members, whatever their cluster role.
+func arbiterNames(members []proto.Members) map[string]bool {
+	arbiters := map[string]bool{}
+	for _, m := range members {
+		if memberState(m) == "ARBITER" {
+			arbiters[m.Name] = true
+		}
+	}
+
+	return arbiters
+}
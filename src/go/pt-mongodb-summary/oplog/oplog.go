@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -13,79 +14,124 @@ import (
 
 	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
 	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/compat"
 )
 
-func GetOplogInfo(ctx context.Context, hostnames []string, co *options.ClientOptions) ([]proto.OplogInfo, error) {
+// GetOplogInfo reads the oplog of every host at the same time, each one with its own timeout. Hosts that cannot
+// be read are skipped, an error is only returned when none of them could be read.
+func GetOplogInfo(ctx context.Context, hostnames []string, co *options.ClientOptions, hostTimeout time.Duration) ([]proto.OplogInfo, error) {
+	infos := make([]*proto.OplogInfo, len(hostnames))
+	errs := make([]error, len(hostnames))
+
+	var wg sync.WaitGroup
+	for i, hostname := range hostnames {
+		wg.Add(1)
+		go func(i int, hostname string) {
+			defer wg.Done()
+
+			hostCtx, cancel := context.WithTimeout(ctx, hostTimeout)
+			defer cancel()
+
+			infos[i], errs[i] = getOplogInfo(hostCtx, hostname, co)
+		}(i, hostname)
+	}
+	wg.Wait()
+
 	results := proto.OpLogs{}
+	var firstErr error
 
-	for _, hostname := range hostnames {
-		result := proto.OplogInfo{
-			Hostname: hostname,
-		}
-		client, err := util.GetClientForHost(co, hostname)
-		if err != nil {
-			return nil, errors.Wrap(err, "cannot get a client (GetOplogInfo)")
-		}
-		if err := client.Connect(ctx); err != nil {
-			return nil, errors.Wrapf(err, "cannot connect to %s", hostname)
+	for i, info := range infos {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
 		}
 
-		oplogCol, err := getOplogCollection(ctx, client)
-		if err != nil {
-			return nil, errors.Wrap(err, "cannot determine the oplog collection")
+		if info != nil {
+			results = append(results, *info)
 		}
+	}
 
-		var colStats proto.OplogColStats
-		err = client.Database("local").RunCommand(ctx, bson.M{"collStats": oplogCol}).Decode(&colStats)
-		if err != nil {
-			return nil, errors.Wrapf(err, "cannot get collStats for collection %s", oplogCol)
-		}
+	if len(results) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
 
-		result.Size = colStats.Size
-		result.UsedMB = colStats.Size / (1024 * 1024)
+	sort.Sort(results)
+	return results, nil
+}
 
-		var firstRow, lastRow proto.OplogRow
-		options := options.FindOne()
-		options.SetSort(bson.M{"$natural": 1})
-		err = client.Database("local").Collection(oplogCol).FindOne(ctx, bson.M{}, options).Decode(&firstRow)
-		if err != nil {
-			return nil, errors.Wrap(err, "cannot read first oplog row")
-		}
+// getOplogInfo returns nil for hosts without a replica set status.
+func getOplogInfo(ctx context.Context, hostname string, co *options.ClientOptions) (*proto.OplogInfo, error) {
+	result := proto.OplogInfo{
+		Hostname: hostname,
+	}
+	client, err := util.GetClientForHost(co, hostname)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get a client (GetOplogInfo)")
+	}
+	if err := client.Connect(ctx); err != nil {
+		return nil, errors.Wrapf(err, "cannot connect to %s", hostname)
+	}
+	defer client.Disconnect(context.Background()) // nolint
 
-		options.SetSort(bson.M{"$natural": -1})
-		err = client.Database("local").Collection(oplogCol).FindOne(ctx, bson.M{}, options).Decode(&lastRow)
-		if err != nil {
-			return nil, errors.Wrap(err, "cannot read last oplog row")
-		}
+	oplogCol, err := getOplogCollection(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot determine the oplog collection")
+	}
 
-		result.TFirst = time.Unix(int64(firstRow.Timestamp.T), int64(firstRow.Timestamp.I))
-		result.TLast = time.Unix(int64(lastRow.Timestamp.T), int64(lastRow.Timestamp.I))
-		result.TimeDiff = result.TLast.Sub(result.TFirst)
-		result.TimeDiffHours = result.TimeDiff.Hours()
-		result.Now = time.Now().UTC()
-		if result.TimeDiffHours > 24 {
-			result.Running = fmt.Sprintf("%0.2f days", result.TimeDiffHours/24)
-		} else {
-			result.Running = fmt.Sprintf("%0.2f hours", result.TimeDiffHours)
-		}
+	srv, err := compat.New(ctx, client)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get the version of %s", hostname)
+	}
 
-		replSetStatus := proto.ReplicaSetStatus{}
-		err = client.Database("admin").RunCommand(ctx, bson.M{"replSetGetStatus": 1}).Decode(&replSetStatus)
-		if err != nil {
-			continue
-		}
+	colStats, err := srv.OplogStats(ctx, client, oplogCol)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get collStats for collection %s", oplogCol)
+	}
 
-		for _, member := range replSetStatus.Members {
-			if member.State == 1 {
-				result.ElectionTime = time.Unix(int64(member.ElectionTime.T), 0)
-				break
-			}
+	result.Size = colStats.Size
+	result.UsedMB = colStats.Size / (1024 * 1024)
+
+	var firstRow, lastRow proto.OplogRow
+	options := options.FindOne()
+	options.SetSort(bson.M{"$natural": 1})
+	err = client.Database("local").Collection(oplogCol).FindOne(ctx, bson.M{}, options).Decode(&firstRow)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read first oplog row")
+	}
+
+	options.SetSort(bson.M{"$natural": -1})
+	err = client.Database("local").Collection(oplogCol).FindOne(ctx, bson.M{}, options).Decode(&lastRow)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read last oplog row")
+	}
+
+	result.TFirst = time.Unix(int64(firstRow.Timestamp.T), int64(firstRow.Timestamp.I))
+	result.TLast = time.Unix(int64(lastRow.Timestamp.T), int64(lastRow.Timestamp.I))
+	result.TimeDiff = result.TLast.Sub(result.TFirst)
+	result.TimeDiffHours = result.TimeDiff.Hours()
+	result.Now = time.Now().UTC()
+	if result.TimeDiffHours > 24 {
+		result.Running = fmt.Sprintf("%0.2f days", result.TimeDiffHours/24)
+	} else {
+		result.Running = fmt.Sprintf("%0.2f hours", result.TimeDiffHours)
+	}
+
+	replSetStatus := proto.ReplicaSetStatus{}
+	err = client.Database("admin").RunCommand(ctx, bson.M{"replSetGetStatus": 1}).Decode(&replSetStatus)
+	if err != nil {
+		return nil, nil
+	}
+
+	for _, member := range replSetStatus.Members {
+		if member.State == 1 {
+			result.ElectionTime = time.Unix(int64(member.ElectionTime.T), 0)
+			break
 		}
-		results = append(results, result)
 	}
 
-	sort.Sort(results)
-	return results, nil
+	return &result, nil
 }
 
 func getOplogCollection(ctx context.Context, client *mongo.Client) (string, error) {
@@ -76,7 +76,7 @@ func TestGetOplogInfo(t *testing.T) {
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
-			oplogInfo, err := GetOplogInfo(ctx, []string{"127.0.0.1:" + test.port}, tu.TestClientOptions(test.port))
+			oplogInfo, err := GetOplogInfo(ctx, []string{"127.0.0.1:" + test.port}, tu.TestClientOptions(test.port), 5*time.Second)
 			if (err != nil) != test.err {
 				t.Errorf("Expected error=%v, got %v", test.err, err)
 			}
@@ -0,0 +1,199 @@
+package oplog
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/compat"
+)
+
+const stateSecondary = "SECONDARY"
+
+// MemberWindow is the oplog window of a replica set member, and how far behind the primary it is.
+type MemberWindow struct {
+	Hostname       string
+	Replicaset     string
+	State          string
+	MaxSizeMB      int64
+	UsedMB         int64
+	First          time.Time
+	Last           time.Time
+	Window         time.Duration
+	ChurnMBPerHour float64
+	// Lag is only known for secondaries, while the primary is reachable
+	Lag time.Duration
+	// Headroom is how long a secondary can be down before the oplogs of the other members
+	// no longer have the entries it needs, and it requires an initial sync
+	Headroom time.Duration
+	Error    string
+}
+
+// GetOplogWindows reads the oplog window of every host at the same time, each one with its own timeout.
+// Hosts that cannot be read are returned with their error, so that one unreachable member does not hide
+// the others.
+func GetOplogWindows(ctx context.Context, hostnames []string, co *options.ClientOptions, hostTimeout time.Duration) []MemberWindow {
+	windows := make([]MemberWindow, len(hostnames))
+
+	var wg sync.WaitGroup
+	for i, hostname := range hostnames {
+		wg.Add(1)
+		go func(i int, hostname string) {
+			defer wg.Done()
+
+			hostCtx, cancel := context.WithTimeout(ctx, hostTimeout)
+			defer cancel()
+
+			w, err := getOplogWindow(hostCtx, hostname, co)
+			if err != nil {
+				w.Error = err.Error()
+			}
+
+			windows[i] = w
+		}(i, hostname)
+	}
+	wg.Wait()
+
+	projectHeadroom(windows)
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		if windows[i].Replicaset != windows[j].Replicaset {
+			return windows[i].Replicaset < windows[j].Replicaset
+		}
+		return windows[i].Hostname < windows[j].Hostname
+	})
+
+	return windows
+}
+
+func getOplogWindow(ctx context.Context, hostname string, co *options.ClientOptions) (MemberWindow, error) {
+	w := MemberWindow{Hostname: hostname}
+
+	client, err := util.GetClientForHost(co, hostname)
+	if err != nil {
+		return w, errors.Wrap(err, "cannot get a client")
+	}
+
+	if err := client.Connect(ctx); err != nil {
+		return w, errors.Wrapf(err, "cannot connect to %s", hostname)
+	}
+	defer client.Disconnect(context.Background()) // nolint
+
+	rss := proto.ReplicaSetStatus{}
+	if err := client.Database("admin").RunCommand(ctx, bson.M{"replSetGetStatus": 1}).Decode(&rss); err != nil {
+		// mongos and config servers of old versions have no oplog to check
+		return w, errors.Wrap(err, "cannot get the replica set status")
+	}
+
+	w.Replicaset = rss.Set
+	w.State, w.Lag = replicationLag(rss)
+
+	oplogCol, err := getOplogCollection(ctx, client)
+	if err != nil {
+		return w, errors.Wrap(err, "cannot determine the oplog collection")
+	}
+
+	srv, err := compat.New(ctx, client)
+	if err != nil {
+		return w, errors.Wrap(err, "cannot get the server version")
+	}
+
+	colStats, err := srv.OplogStats(ctx, client, oplogCol)
+	if err != nil {
+		return w, errors.Wrapf(err, "cannot get collStats for collection %s", oplogCol)
+	}
+
+	w.MaxSizeMB = colStats.MaxSize / (1024 * 1024)
+	w.UsedMB = colStats.Size / (1024 * 1024)
+
+	var firstRow, lastRow proto.OplogRow
+	opts := options.FindOne().SetSort(bson.M{"$natural": 1})
+	if err := client.Database("local").Collection(oplogCol).FindOne(ctx, bson.M{}, opts).Decode(&firstRow); err != nil {
+		return w, errors.Wrap(err, "cannot read first oplog row")
+	}
+
+	opts.SetSort(bson.M{"$natural": -1})
+	if err := client.Database("local").Collection(oplogCol).FindOne(ctx, bson.M{}, opts).Decode(&lastRow); err != nil {
+		return w, errors.Wrap(err, "cannot read last oplog row")
+	}
+
+	w.First = time.Unix(int64(firstRow.Timestamp.T), 0).UTC()
+	w.Last = time.Unix(int64(lastRow.Timestamp.T), 0).UTC()
+	w.Window = w.Last.Sub(w.First)
+	w.ChurnMBPerHour = churnRate(colStats.Size, w.Window)
+
+	return w, nil
+}
+
+// replicationLag returns the state of the member the status was read from and, for secondaries,
+// how far behind the primary it is.
+func replicationLag(rss proto.ReplicaSetStatus) (string, time.Duration) {
+	var self, primary *proto.Members
+
+	for i := range rss.Members {
+		if rss.Members[i].Self {
+			self = &rss.Members[i]
+		}
+
+		if rss.Members[i].State == 1 {
+			primary = &rss.Members[i]
+		}
+	}
+
+	if self == nil {
+		return "", 0
+	}
+
+	if self.StateStr != stateSecondary || primary == nil {
+		return self.StateStr, 0
+	}
+
+	lag := primary.OptimeDate.Time().Sub(self.OptimeDate.Time()).Round(time.Second)
+	if lag < 0 {
+		lag = 0
+	}
+
+	return self.StateStr, lag
+}
+
+// churnRate is how many MB per hour are written to the oplog.
+func churnRate(size int64, window time.Duration) float64 {
+	if window <= 0 {
+		return 0
+	}
+
+	return float64(size) / (1024 * 1024) / window.Hours()
+}
+
+// projectHeadroom computes how long each secondary can be down: the smallest window of the other members
+// of its replica set, any of them being a possible sync source, minus its current lag.
+func projectHeadroom(windows []MemberWindow) {
+	for i := range windows {
+		if windows[i].State != stateSecondary || windows[i].Error != "" {
+			continue
+		}
+
+		var smallest time.Duration
+
+		for j := range windows {
+			if i == j || windows[j].Replicaset != windows[i].Replicaset || windows[j].Error != "" || windows[j].Window <= 0 {
+				continue
+			}
+
+			if smallest == 0 || windows[j].Window < smallest {
+				smallest = windows[j].Window
+			}
+		}
+
+		if smallest > 0 {
+			windows[i].Headroom = smallest - windows[i].Lag
+		}
+	}
+}
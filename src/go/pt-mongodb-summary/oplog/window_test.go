@@ -0,0 +1,92 @@
+package oplog
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+func TestReplicationLag(t *testing.T) {
+	now := time.Date(2023, 5, 9, 17, 39, 19, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		members   []proto.Members
+		wantState string
+		wantLag   time.Duration
+	}{
+		{
+			name: "secondary",
+			members: []proto.Members{
+				{State: 1, StateStr: "PRIMARY", OptimeDate: primitive.NewDateTimeFromTime(now)},
+				{State: 2, StateStr: "SECONDARY", Self: true, OptimeDate: primitive.NewDateTimeFromTime(now.Add(-90 * time.Second))},
+			},
+			wantState: "SECONDARY",
+			wantLag:   90 * time.Second,
+		},
+		{
+			name: "primary",
+			members: []proto.Members{
+				{State: 1, StateStr: "PRIMARY", Self: true, OptimeDate: primitive.NewDateTimeFromTime(now)},
+				{State: 2, StateStr: "SECONDARY", OptimeDate: primitive.NewDateTimeFromTime(now.Add(-90 * time.Second))},
+			},
+			wantState: "PRIMARY",
+		},
+		{
+			name: "no_primary",
+			members: []proto.Members{
+				{State: 2, StateStr: "SECONDARY", Self: true, OptimeDate: primitive.NewDateTimeFromTime(now)},
+			},
+			wantState: "SECONDARY",
+		},
+		{
+			name: "ahead_of_primary",
+			members: []proto.Members{
+				{State: 1, StateStr: "PRIMARY", OptimeDate: primitive.NewDateTimeFromTime(now.Add(-time.Second))},
+				{State: 2, StateStr: "SECONDARY", Self: true, OptimeDate: primitive.NewDateTimeFromTime(now)},
+			},
+			wantState: "SECONDARY",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			state, lag := replicationLag(proto.ReplicaSetStatus{Members: test.members})
+			if state != test.wantState || lag != test.wantLag {
+				t.Errorf("got %s/%s, want %s/%s", state, lag, test.wantState, test.wantLag)
+			}
+		})
+	}
+}
+
+func TestChurnRate(t *testing.T) {
+	if got := churnRate(300*1024*1024, 2*time.Hour); got != 150 {
+		t.Errorf("got %f MB/h, want 150", got)
+	}
+
+	if got := churnRate(300*1024*1024, 0); got != 0 {
+		t.Errorf("an empty window should have no churn, got %f", got)
+	}
+}
+
+func TestProjectHeadroom(t *testing.T) {
+	windows := []MemberWindow{
+		{Hostname: "a:27017", Replicaset: "rs1", State: "PRIMARY", Window: 10 * time.Hour},
+		{Hostname: "b:27017", Replicaset: "rs1", State: "SECONDARY", Window: 8 * time.Hour, Lag: time.Minute},
+		{Hostname: "c:27017", Replicaset: "rs1", State: "SECONDARY", Window: 6 * time.Hour, Lag: 0},
+		{Hostname: "d:27017", Replicaset: "rs1", State: "SECONDARY", Error: "cannot connect"},
+		{Hostname: "e:27017", Replicaset: "rs2", State: "SECONDARY", Window: 4 * time.Hour},
+	}
+
+	projectHeadroom(windows)
+
+	want := []time.Duration{0, 6*time.Hour - time.Minute, 8 * time.Hour, 0, 0}
+	for i, w := range windows {
+		if w.Headroom != want[i] {
+			t.Errorf("%s: got headroom %s, want %s", w.Hostname, w.Headroom, want[i])
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Error codes of the commands the user is not allowed to run.
+const (
+	codeUnauthorized = 13
+	// Atlas answers with its own code for the commands it does not allow on shared and serverless tiers
+	codeAtlasError = 8000
+)
+
+// skippedSection is a section, or a part of it, that was not collected because the user is not allowed to
+// run the commands it needs, like on Atlas or with locked-down roles.
+type skippedSection struct {
+	Section string
+	Reason  string
+}
+
+type skippedSections []skippedSection
+
+// add records the section as skipped when err is a missing privilege, and reports whether it was.
+// Other errors are left to the caller.
+func (s *skippedSections) add(section string, err error) bool {
+	if !isUnauthorized(err) {
+		return false
+	}
+
+	log.Infof("Skipping %s: insufficient privileges: %s", section, err)
+	*s = append(*s, skippedSection{Section: section, Reason: errors.Cause(err).Error()})
+
+	return true
+}
+
+func isUnauthorized(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var se mongo.ServerError
+	if errors.As(err, &se) {
+		return se.HasErrorCode(codeUnauthorized) || se.HasErrorCode(codeAtlasError)
+	}
+
+	return false
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	metricsPrefix      = "mongodb_summary_"
+	defaultMetricsPath = "/metrics"
+	// the version of the Prometheus text exposition format
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// exposition writes gauges in the Prometheus text format. The samples of a metric must be added
+// one after the other, its HELP and TYPE lines are written before the first one.
+type exposition struct {
+	buf  bytes.Buffer
+	last string
+}
+
+// gauge adds a sample. labels are pairs of label names and values.
+func (e *exposition) gauge(name, help string, value float64, labels ...string) {
+	name = metricsPrefix + name
+	if name != e.last {
+		fmt.Fprintf(&e.buf, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		e.last = name
+	}
+
+	e.buf.WriteString(name)
+	if len(labels) > 0 {
+		e.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&e.buf, "%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1]))
+		}
+		e.buf.WriteByte('}')
+	}
+
+	fmt.Fprintf(&e.buf, " %s\n", formatMetricValue(value))
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatMetricValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// newMetrics exposes the numbers of the summary that are worth graphing and alerting on. Names, versions and
+// states are labels of gauges set to 1.
+func newMetrics(ci *collectedInfo) []byte {
+	e := &exposition{}
+
+	e.gauge("up", "1 when the host could be read.", boolValue(ci.HostInfo != nil))
+
+	if hi := ci.HostInfo; hi != nil {
+		e.gauge("host_info", "Host the summary is collected from.", 1,
+			"hostname", hi.Hostname, "version", hi.Version, "node_type", hi.NodeType, "replicaset", hi.ReplicasetName)
+	}
+
+	for _, s := range ci.Skipped {
+		e.gauge("section_skipped", "Sections not collected for lack of privileges.", 1, "section", s.Section)
+	}
+
+	for _, m := range ci.ReplicaMembers {
+		// mongos and standalone hosts have no health, unless they could not be reached
+		if m.Set == "" && m.Error == "" {
+			continue
+		}
+		e.gauge("member_health", "Health of the member as seen by its replica set, 1 when up.", m.Health,
+			"member", m.Name, "replicaset", m.Set, "state", m.StateStr)
+	}
+
+	if rc := ci.ReplicaSetConfig; rc != nil {
+		e.gauge("replicaset_voting_members", "Voting members of the replica set.", float64(rc.Voters), "replicaset", rc.Name)
+		e.gauge("replicaset_warnings", "Risky settings found in the replica set configuration.", float64(len(rc.Warnings)),
+			"replicaset", rc.Name)
+	}
+
+	if ops := ci.RunningOps; ops != nil {
+		counters := []struct {
+			op    string
+			stats TimedStats
+		}{
+			{"insert", ops.Insert}, {"query", ops.Query}, {"update", ops.Update},
+			{"delete", ops.Delete}, {"getmore", ops.GetMore}, {"command", ops.Command},
+		}
+		for _, c := range counters {
+			e.gauge("running_ops_max", "Largest number of operations per sample of the Running Ops section.", float64(c.stats.Max),
+				"op", c.op)
+		}
+	}
+
+	if a := ci.Activity; a != nil {
+		o := a.Overall
+		rates := []struct {
+			op   string
+			rate float64
+		}{
+			{"insert", o.Inserts}, {"query", o.Queries}, {"update", o.Updates},
+			{"delete", o.Deletes}, {"getmore", o.GetMores}, {"command", o.Commands},
+		}
+		for _, r := range rates {
+			e.gauge("activity_ops_per_second", "Operations per second over the --interval samples.", r.rate, "op", r.op)
+		}
+		e.gauge("activity_replicated_ops_per_second", "Replicated operations applied per second.", o.ReplOps)
+		e.gauge("activity_page_faults_per_second", "Page faults per second.", o.PageFaults)
+		e.gauge("activity_network_bytes_per_second", "Network bytes per second.", float64(o.BytesIn), "direction", "in")
+		e.gauge("activity_network_bytes_per_second", "Network bytes per second.", float64(o.BytesOut), "direction", "out")
+		e.gauge("activity_connections", "Largest number of connections over the samples.", float64(o.Connections))
+	}
+
+	if c := ci.Connections; c != nil {
+		e.gauge("connections", "Connections in use and available.", float64(c.Current), "state", "current")
+		e.gauge("connections", "Connections in use and available.", float64(c.Available), "state", "available")
+		e.gauge("connections_created_per_second", "Connections created per second since the server started.", c.CreatedPerSecond)
+		e.gauge("network_bytes", "Network bytes since the server started.", float64(c.BytesIn), "direction", "in")
+		e.gauge("network_bytes", "Network bytes since the server started.", float64(c.BytesOut), "direction", "out")
+		for _, cc := range c.Clients {
+			e.gauge("client_connections", "Connections of the client hosts listed by --connection-clients-limit.",
+				float64(cc.Connections), "client", cc.Host)
+		}
+	}
+
+	if t := ci.Transactions; t != nil {
+		e.gauge("transactions_open", "Open transactions.", float64(t.CurrentOpen))
+		e.gauge("transactions", "Transactions since the server started.", float64(t.TotalCommitted), "outcome", "committed")
+		e.gauge("transactions", "Transactions since the server started.", float64(t.TotalAborted), "outcome", "aborted")
+		if t.ChangeStreamsCounted {
+			e.gauge("change_streams", "Open change stream cursors.", float64(t.ChangeStreams))
+		}
+	}
+
+	if co := ci.CurrentOps; co != nil {
+		e.gauge("long_running_operations", "Operations running for at least --current-ops-threshold seconds.", float64(co.Total))
+	}
+
+	if wt := ci.WiredTiger; wt != nil {
+		e.gauge("wiredtiger_cache_bytes", "WiredTiger cache size and usage.", float64(wt.CacheMaxBytes), "type", "max")
+		e.gauge("wiredtiger_cache_bytes", "WiredTiger cache size and usage.", float64(wt.CacheUsedBytes), "type", "used")
+		e.gauge("wiredtiger_cache_bytes", "WiredTiger cache size and usage.", float64(wt.CacheDirtyBytes), "type", "dirty")
+		e.gauge("wiredtiger_pages_evicted_by_app_threads", "Pages application threads had to evict themselves.",
+			float64(wt.PagesEvictedByAppThreads))
+		e.gauge("wiredtiger_last_checkpoint_seconds", "Duration of the most recent checkpoint.", wt.LastCheckpointDuration.Seconds())
+		e.gauge("wiredtiger_tickets_available", "Available read and write tickets.", float64(wt.ReadTicketsAvailable), "type", "read")
+		e.gauge("wiredtiger_tickets_available", "Available read and write tickets.", float64(wt.WriteTicketsAvailable), "type", "write")
+	}
+
+	if sa := ci.SecurityAudit; sa != nil {
+		counts := map[string]int{}
+		for _, f := range sa.Findings {
+			counts[f.Severity]++
+		}
+		for _, severity := range []string{severityHigh, severityMedium, severityLow} {
+			e.gauge("security_audit_findings", "Risky security settings found by --security-audit.", float64(counts[severity]),
+				"severity", severity)
+		}
+	}
+
+	for _, w := range ci.OplogWindows {
+		if w.Error == "" {
+			e.gauge("oplog_window_seconds", "Time between the first and the last entries of the oplog.", w.Window.Seconds(),
+				"member", w.Hostname, "replicaset", w.Replicaset)
+		}
+	}
+	for _, w := range ci.OplogWindows {
+		if w.Error == "" && w.State == "SECONDARY" {
+			e.gauge("replication_lag_seconds", "How far behind the primary a secondary is.", w.Lag.Seconds(),
+				"member", w.Hostname, "replicaset", w.Replicaset)
+		}
+	}
+
+	if sl := ci.ServerLog; sl != nil {
+		for _, m := range sl.Members {
+			for _, c := range m.Categories {
+				e.gauge("server_log_entries", "Warnings and errors in the log lines kept in memory by the member.",
+					float64(c.Count), "member", m.Hostname, "severity", c.Severity, "component", c.Component)
+			}
+		}
+	}
+
+	if si := ci.ShardingInfo; si != nil {
+		e.gauge("chunks", "Chunks of the sharded cluster.", float64(si.TotalChunks))
+		e.gauge("balancer_running", "1 when a balancing round is in progress.", boolValue(si.BalancerRunning))
+		for _, s := range si.Shards {
+			e.gauge("shard_chunks", "Chunks on each shard.", float64(s.Chunks), "shard", s.Name)
+		}
+		for _, s := range si.Shards {
+			e.gauge("shard_up", "1 when the shard could be read.", boolValue(s.Error == ""), "shard", s.Name)
+		}
+	}
+
+	if cd := ci.Chunks; cd != nil {
+		for _, c := range cd.Collections {
+			e.gauge("collection_chunk_imbalance", "Difference of chunks between the shards with the most and the fewest.",
+				float64(c.Imbalance), "namespace", c.Namespace)
+		}
+		e.gauge("balancer_failed_rounds", "Failed balancer rounds over the last 10 days.", float64(cd.FailedRounds))
+	}
+
+	if inv := ci.Inventory; inv != nil {
+		for _, db := range inv.Databases {
+			e.gauge("database_size_bytes", "Size on disk of the largest databases.", float64(db.SizeOnDisk), "database", db.Name)
+		}
+		e.gauge("unused_indexes", "Indexes not used since the server started.", float64(len(inv.UnusedIndexes)))
+	}
+
+	for _, c := range ci.Checks {
+		e.gauge("check_failed", "1 when the --check condition is met.", boolValue(c.Failed), "check", c.Check)
+	}
+
+	return e.buf.Bytes()
+}
+
+// servePrometheus serves the metrics on address[/path], the default path is /metrics. Every scrape
+// collects the summary again, one at a time.
+func servePrometheus(opts *cliOptions, client *mongo.Client, clientOptions *options.ClientOptions) error {
+	addr, path := opts.Prometheus, defaultMetricsPath
+	if i := strings.Index(addr, "/"); i >= 0 {
+		addr, path = addr[:i], addr[i:]
+	}
+
+	var mu sync.Mutex
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		start := time.Now()
+		ci, err := collect(r.Context(), opts, client, clientOptions)
+		if err != nil {
+			log.Errorf("cannot collect the summary: %s", err)
+			// still answer, so that the failure is visible as mongodb_summary_up 0
+			ci = &collectedInfo{Errors: []string{err.Error()}}
+		}
+
+		if opts.Mask {
+			newMasker().maskCollectedInfo(ci)
+		}
+
+		w.Header().Set("Content-Type", metricsContentType)
+		if _, err := w.Write(newMetrics(ci)); err != nil {
+			log.Debugf("cannot write the metrics: %s", err)
+		}
+
+		log.Infof("metrics collected in %s", time.Since(start).Round(time.Millisecond))
+	})
+
+	log.Infof("Serving the Prometheus metrics on %s%s", addr, path)
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	if err := srv.ListenAndServe(); err != nil {
+		return errors.Wrapf(err, "cannot listen on %s", addr)
+	}
+
+	return nil
+}
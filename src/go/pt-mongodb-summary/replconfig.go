@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/compat"
+)
+
+const writeConcernMajority = "majority"
+
+// replicaSetConfig is how the members of the replica set of the host take part in elections and
+// write acknowledgement, from replSetGetConfig.
+type replicaSetConfig struct {
+	Name                string
+	ConfigVersion       int32
+	Members             []replicaSetMember
+	Voters              int
+	Majority            int
+	DefaultWriteConcern string
+	Warnings            []string
+}
+
+type replicaSetMember struct {
+	Host         string
+	State        string
+	Healthy      bool
+	Votes        int32
+	Priority     float64
+	Arbiter      bool
+	Hidden       bool
+	Delay        time.Duration
+	BuildIndexes bool
+}
+
+// Role lists what makes the member special, "-" for a regular one.
+func (m replicaSetMember) Role() string {
+	roles := []string{}
+	if m.Arbiter {
+		roles = append(roles, "arbiter")
+	}
+	if m.Hidden {
+		roles = append(roles, "hidden")
+	}
+	if m.Delay > 0 {
+		roles = append(roles, "delayed "+m.Delay.String())
+	}
+	if !m.Arbiter && !m.BuildIndexes {
+		roles = append(roles, "no indexes")
+	}
+
+	if len(roles) == 0 {
+		return "-"
+	}
+
+	return strings.Join(roles, ", ")
+}
+
+func getReplicaSetConfig(ctx context.Context, client *mongo.Client, srv *compat.Server) (*replicaSetConfig, error) {
+	rsc, err := util.ReplicasetConfig(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get the replica set configuration")
+	}
+
+	// the state and health of the members are only in replSetGetStatus
+	rss := proto.ReplicaSetStatus{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"replSetGetStatus": 1}).Decode(&rss); err != nil {
+		log.Debugf("cannot get the replica set status: %s", err)
+	}
+
+	wc, err := srv.DefaultWriteConcern(ctx, client, rsc.Config.Settings)
+	if err != nil {
+		log.Debugf("cannot get the default write concern: %s", err)
+	}
+
+	return newReplicaSetConfig(rsc.Config, rss, srv, wc), nil
+}
+
+func newReplicaSetConfig(cfg proto.RSConfig, rss proto.ReplicaSetStatus, srv *compat.Server, defaultWriteConcern string) *replicaSetConfig {
+	rc := &replicaSetConfig{
+		Name:                cfg.ID,
+		ConfigVersion:       cfg.Version,
+		DefaultWriteConcern: defaultWriteConcern,
+	}
+
+	status := map[string]proto.Members{}
+	for _, m := range rss.Members {
+		status[m.Name] = m
+	}
+
+	for _, m := range cfg.Members {
+		member := replicaSetMember{
+			Host:         m.Host,
+			Votes:        m.Votes,
+			Priority:     m.Priority,
+			Arbiter:      m.ArbiterOnly,
+			Hidden:       m.Hidden,
+			Delay:        srv.SecondaryDelay(m),
+			BuildIndexes: m.BuildIndexes,
+		}
+		if s, ok := status[m.Host]; ok {
+			member.State = s.StateStr
+			member.Healthy = s.Health == 1
+		}
+
+		if member.Votes > 0 {
+			rc.Voters++
+		}
+
+		rc.Members = append(rc.Members, member)
+	}
+
+	rc.Majority = rc.Voters/2 + 1
+	rc.Warnings = replicaSetWarnings(rc)
+
+	return rc
+}
+
+// replicaSetWarnings flags the configurations that lose elections or majority writes when a single
+// member is down, the situation replica sets are deployed for.
+func replicaSetWarnings(rc *replicaSetConfig) []string {
+	warnings := []string{}
+
+	var arbiters, dataVoters int
+	for _, m := range rc.Members {
+		if m.Arbiter {
+			arbiters++
+		} else if m.Votes > 0 {
+			dataVoters++
+		}
+
+		if m.Delay > 0 && m.Votes > 0 {
+			warnings = append(warnings, fmt.Sprintf("delayed member %s votes: majority writes wait for it "+
+				"when another member is down, set its votes to 0", m.Host))
+		}
+
+		if m.State != "" && !m.Healthy {
+			warnings = append(warnings, fmt.Sprintf("member %s is down (%s)", m.Host, m.State))
+		}
+	}
+
+	if rc.Voters%2 == 0 && rc.Voters > 0 {
+		warnings = append(warnings, fmt.Sprintf("even number of voting members (%d): a network partition "+
+			"between two halves leaves no side able to elect a primary", rc.Voters))
+	}
+
+	if arbiters > 1 {
+		warnings = append(warnings, fmt.Sprintf("%d arbiters: a replica set should have at most one", arbiters))
+	}
+
+	// with one data-bearing voter down, the arbiters complete the majority of an election but not of a write
+	if arbiters > 0 && dataVoters-1 < rc.Majority {
+		msg := "with an arbiter, writes with w: majority cannot be acknowledged while a data-bearing member is down"
+		if rc.DefaultWriteConcern == writeConcernMajority {
+			msg += ", and it is the default write concern"
+		}
+		warnings = append(warnings, msg)
+	}
+
+	return warnings
+}
@@ -0,0 +1,906 @@
+package main
+
+import (
+	"time"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+// reportFormatVersion must be increased when fields of the report are renamed, removed or change meaning.
+// Adding fields does not change it, so that tools ingesting the report only have to check it.
+const reportFormatVersion = 1
+
+// report is the machine readable summary printed by --output json and --output yaml.
+// Unlike collectedInfo, its field names are part of the output format and must stay stable.
+type report struct {
+	FormatVersion int                      `json:"format_version" yaml:"format_version"`
+	Host          *reportHost              `json:"host,omitempty" yaml:"host,omitempty"`
+	Members       []reportMember           `json:"members" yaml:"members"`
+	ReplicaSet    *reportReplicaSetConfig  `json:"replica_set_config,omitempty" yaml:"replica_set_config,omitempty"`
+	RunningOps    *reportRunningOps        `json:"running_ops,omitempty" yaml:"running_ops,omitempty"`
+	Activity      *reportActivity          `json:"activity,omitempty" yaml:"activity,omitempty"`
+	Connections   *reportConnections       `json:"connections,omitempty" yaml:"connections,omitempty"`
+	Transactions  *reportTransactions      `json:"transactions,omitempty" yaml:"transactions,omitempty"`
+	CurrentOps    *reportCurrentOps        `json:"current_ops,omitempty" yaml:"current_ops,omitempty"`
+	WiredTiger    *reportWiredTiger        `json:"wiredtiger,omitempty" yaml:"wiredtiger,omitempty"`
+	Security      *reportSecurity          `json:"security,omitempty" yaml:"security,omitempty"`
+	SecurityAudit *reportSecurityAudit     `json:"security_audit,omitempty" yaml:"security_audit,omitempty"`
+	Oplog         *reportOplog             `json:"oplog,omitempty" yaml:"oplog,omitempty"`
+	OplogWindows  []reportOplogWindow      `json:"oplog_windows,omitempty" yaml:"oplog_windows,omitempty"`
+	ServerLog     []reportMemberLog        `json:"server_log,omitempty" yaml:"server_log,omitempty"`
+	ClusterWide   *reportClusterWide       `json:"cluster_wide,omitempty" yaml:"cluster_wide,omitempty"`
+	Sharding      *reportSharding          `json:"sharding,omitempty" yaml:"sharding,omitempty"`
+	Chunks        *reportChunkDistribution `json:"chunk_distribution,omitempty" yaml:"chunk_distribution,omitempty"`
+	Balancer      *reportBalancer          `json:"balancer,omitempty" yaml:"balancer,omitempty"`
+	Inventory     *reportInventory         `json:"inventory,omitempty" yaml:"inventory,omitempty"`
+	Errors        []string                 `json:"errors" yaml:"errors"`
+	Skipped       []reportSkippedSection   `json:"skipped_sections" yaml:"skipped_sections"`
+	Checks        []reportCheck            `json:"checks,omitempty" yaml:"checks,omitempty"`
+}
+
+// reportCheck is the outcome of a --check condition.
+type reportCheck struct {
+	Check    string   `json:"check" yaml:"check"`
+	Failed   bool     `json:"failed" yaml:"failed"`
+	Problems []string `json:"problems" yaml:"problems"`
+}
+
+// reportSkippedSection is a section left out for lack of privileges.
+type reportSkippedSection struct {
+	Section string `json:"section" yaml:"section"`
+	Reason  string `json:"reason" yaml:"reason"`
+}
+
+type reportHost struct {
+	Hostname       string   `json:"hostname" yaml:"hostname"`
+	Version        string   `json:"version" yaml:"version"`
+	NodeType       string   `json:"node_type" yaml:"node_type"`
+	Replicaset     string   `json:"replicaset,omitempty" yaml:"replicaset,omitempty"`
+	OSType         string   `json:"os_type" yaml:"os_type"`
+	CPUArch        string   `json:"cpu_arch" yaml:"cpu_arch"`
+	DBPath         string   `json:"db_path,omitempty" yaml:"db_path,omitempty"`
+	ProcessName    string   `json:"process_name" yaml:"process_name"`
+	ProcessPath    string   `json:"process_path,omitempty" yaml:"process_path,omitempty"`
+	ProcessUser    string   `json:"process_user,omitempty" yaml:"process_user,omitempty"`
+	ProcessStarted string   `json:"process_started,omitempty" yaml:"process_started,omitempty"`
+	ProcessCount   int      `json:"process_count" yaml:"process_count"`
+	CmdlineArgs    []string `json:"cmdline_args" yaml:"cmdline_args"`
+}
+
+type reportMember struct {
+	Name          string  `json:"name" yaml:"name"`
+	PID           int64   `json:"pid" yaml:"pid"`
+	State         string  `json:"state" yaml:"state"`
+	Replicaset    string  `json:"replicaset,omitempty" yaml:"replicaset,omitempty"`
+	StorageEngine string  `json:"storage_engine,omitempty" yaml:"storage_engine,omitempty"`
+	Health        float64 `json:"health" yaml:"health"`
+	UptimeSeconds float64 `json:"uptime_seconds" yaml:"uptime_seconds"`
+	Error         string  `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// reportReplicaSetConfig is only set when connected to a replica set member.
+type reportReplicaSetConfig struct {
+	Name                string                   `json:"name" yaml:"name"`
+	ConfigVersion       int32                    `json:"config_version" yaml:"config_version"`
+	Members             []reportReplicaSetMember `json:"members" yaml:"members"`
+	Voters              int                      `json:"voters" yaml:"voters"`
+	Majority            int                      `json:"majority" yaml:"majority"`
+	DefaultWriteConcern string                   `json:"default_write_concern,omitempty" yaml:"default_write_concern,omitempty"`
+	Warnings            []string                 `json:"warnings" yaml:"warnings"`
+}
+
+type reportReplicaSetMember struct {
+	Host         string  `json:"host" yaml:"host"`
+	State        string  `json:"state,omitempty" yaml:"state,omitempty"`
+	Healthy      bool    `json:"healthy" yaml:"healthy"`
+	Votes        int32   `json:"votes" yaml:"votes"`
+	Priority     float64 `json:"priority" yaml:"priority"`
+	Arbiter      bool    `json:"arbiter" yaml:"arbiter"`
+	Hidden       bool    `json:"hidden" yaml:"hidden"`
+	DelaySeconds float64 `json:"delay_seconds" yaml:"delay_seconds"`
+	BuildIndexes bool    `json:"build_indexes" yaml:"build_indexes"`
+}
+
+type reportCounter struct {
+	Min   int64 `json:"min" yaml:"min"`
+	Max   int64 `json:"max" yaml:"max"`
+	Total int64 `json:"total" yaml:"total"`
+}
+
+type reportRunningOps struct {
+	SampleSeconds float64       `json:"sample_seconds" yaml:"sample_seconds"`
+	Insert        reportCounter `json:"insert" yaml:"insert"`
+	Query         reportCounter `json:"query" yaml:"query"`
+	Update        reportCounter `json:"update" yaml:"update"`
+	Delete        reportCounter `json:"delete" yaml:"delete"`
+	GetMore       reportCounter `json:"getmore" yaml:"getmore"`
+	Command       reportCounter `json:"command" yaml:"command"`
+}
+
+// reportActivity is only set with --interval. Rates are per second, queues, active clients and
+// connections are read at the end of each sample. Overall has the largest of them.
+type reportActivity struct {
+	IntervalSeconds float64                `json:"interval_seconds" yaml:"interval_seconds"`
+	Samples         []reportActivitySample `json:"samples" yaml:"samples"`
+	Overall         reportActivitySample   `json:"overall" yaml:"overall"`
+}
+
+type reportActivitySample struct {
+	Time           string  `json:"time,omitempty" yaml:"time,omitempty"`
+	ElapsedSeconds float64 `json:"elapsed_seconds" yaml:"elapsed_seconds"`
+	Inserts        float64 `json:"inserts" yaml:"inserts"`
+	Queries        float64 `json:"queries" yaml:"queries"`
+	Updates        float64 `json:"updates" yaml:"updates"`
+	Deletes        float64 `json:"deletes" yaml:"deletes"`
+	GetMores       float64 `json:"getmores" yaml:"getmores"`
+	Commands       float64 `json:"commands" yaml:"commands"`
+	ReplOps        float64 `json:"repl_ops" yaml:"repl_ops"`
+	PageFaults     float64 `json:"page_faults" yaml:"page_faults"`
+	BytesIn        int64   `json:"bytes_in" yaml:"bytes_in"`
+	BytesOut       int64   `json:"bytes_out" yaml:"bytes_out"`
+	QueuedReaders  int64   `json:"queued_readers" yaml:"queued_readers"`
+	QueuedWriters  int64   `json:"queued_writers" yaml:"queued_writers"`
+	ActiveReaders  int64   `json:"active_readers" yaml:"active_readers"`
+	ActiveWriters  int64   `json:"active_writers" yaml:"active_writers"`
+	Connections    int64   `json:"connections" yaml:"connections"`
+}
+
+// reportConnections has the counters since the server started. Clients is empty
+// with --connection-clients-limit 0.
+type reportConnections struct {
+	Current          int64                     `json:"current" yaml:"current"`
+	Available        int64                     `json:"available" yaml:"available"`
+	UsedPct          float64                   `json:"used_pct" yaml:"used_pct"`
+	TotalCreated     int64                     `json:"total_created" yaml:"total_created"`
+	CreatedPerSecond float64                   `json:"created_per_second" yaml:"created_per_second"`
+	BytesIn          int64                     `json:"bytes_in" yaml:"bytes_in"`
+	BytesOut         int64                     `json:"bytes_out" yaml:"bytes_out"`
+	Requests         int64                     `json:"requests" yaml:"requests"`
+	UptimeSeconds    int64                     `json:"uptime_seconds" yaml:"uptime_seconds"`
+	TotalClients     int                       `json:"total_clients" yaml:"total_clients"`
+	Clients          []reportClientConnections `json:"clients" yaml:"clients"`
+	Warnings         []string                  `json:"warnings" yaml:"warnings"`
+}
+
+type reportClientConnections struct {
+	Host        string   `json:"host" yaml:"host"`
+	Connections int      `json:"connections" yaml:"connections"`
+	Active      int      `json:"active" yaml:"active"`
+	AppNames    []string `json:"app_names" yaml:"app_names"`
+}
+
+// reportTransactions is not set before MongoDB 3.6. ChangeStreams is omitted before MongoDB 4.2,
+// where the change stream cursors cannot be counted.
+type reportTransactions struct {
+	CurrentActive     int64                        `json:"current_active" yaml:"current_active"`
+	CurrentInactive   int64                        `json:"current_inactive" yaml:"current_inactive"`
+	CurrentOpen       int64                        `json:"current_open" yaml:"current_open"`
+	TotalStarted      int64                        `json:"total_started" yaml:"total_started"`
+	TotalCommitted    int64                        `json:"total_committed" yaml:"total_committed"`
+	TotalAborted      int64                        `json:"total_aborted" yaml:"total_aborted"`
+	TotalPrepared     int64                        `json:"total_prepared" yaml:"total_prepared"`
+	RetriedCommands   int64                        `json:"retried_commands" yaml:"retried_commands"`
+	RetriedStatements int64                        `json:"retried_statements" yaml:"retried_statements"`
+	ChangeStreams     *int                         `json:"change_streams,omitempty" yaml:"change_streams,omitempty"`
+	Members           []reportMemberSessionSupport `json:"members" yaml:"members"`
+	Warnings          []string                     `json:"warnings" yaml:"warnings"`
+}
+
+type reportMemberSessionSupport struct {
+	Hostname       string `json:"hostname" yaml:"hostname"`
+	StorageEngine  string `json:"storage_engine,omitempty" yaml:"storage_engine,omitempty"`
+	CommittedReads bool   `json:"committed_reads" yaml:"committed_reads"`
+	Error          string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// reportCurrentOps is not set with --current-ops-limit 0. Total counts all the operations above
+// the threshold, while only the longest ones are listed.
+type reportCurrentOps struct {
+	ThresholdSeconds int               `json:"threshold_seconds" yaml:"threshold_seconds"`
+	Total            int               `json:"total" yaml:"total"`
+	Ops              []reportCurrentOp `json:"ops" yaml:"ops"`
+}
+
+type reportCurrentOp struct {
+	OpID           string `json:"opid" yaml:"opid"`
+	Op             string `json:"op" yaml:"op"`
+	Namespace      string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Client         string `json:"client,omitempty" yaml:"client,omitempty"`
+	AppName        string `json:"app_name,omitempty" yaml:"app_name,omitempty"`
+	SecondsRunning int64  `json:"seconds_running" yaml:"seconds_running"`
+	PlanSummary    string `json:"plan_summary,omitempty" yaml:"plan_summary,omitempty"`
+	WaitingForLock bool   `json:"waiting_for_lock" yaml:"waiting_for_lock"`
+	Command        string `json:"command,omitempty" yaml:"command,omitempty"`
+}
+
+// reportWiredTiger is not set for mongos, nor for other storage engines.
+type reportWiredTiger struct {
+	CacheMaxBytes            int64    `json:"cache_max_bytes" yaml:"cache_max_bytes"`
+	CacheUsedBytes           int64    `json:"cache_used_bytes" yaml:"cache_used_bytes"`
+	CacheDirtyBytes          int64    `json:"cache_dirty_bytes" yaml:"cache_dirty_bytes"`
+	CacheUsedPct             float64  `json:"cache_used_pct" yaml:"cache_used_pct"`
+	CacheDirtyPct            float64  `json:"cache_dirty_pct" yaml:"cache_dirty_pct"`
+	PagesReadIntoCache       int64    `json:"pages_read_into_cache" yaml:"pages_read_into_cache"`
+	PagesWrittenFromCache    int64    `json:"pages_written_from_cache" yaml:"pages_written_from_cache"`
+	ModifiedPagesEvicted     int64    `json:"modified_pages_evicted" yaml:"modified_pages_evicted"`
+	UnmodifiedPagesEvicted   int64    `json:"unmodified_pages_evicted" yaml:"unmodified_pages_evicted"`
+	PagesEvictedByAppThreads int64    `json:"pages_evicted_by_app_threads" yaml:"pages_evicted_by_app_threads"`
+	Checkpoints              int64    `json:"checkpoints" yaml:"checkpoints"`
+	CheckpointRunning        bool     `json:"checkpoint_running" yaml:"checkpoint_running"`
+	LastCheckpointSeconds    float64  `json:"last_checkpoint_seconds" yaml:"last_checkpoint_seconds"`
+	MinCheckpointSeconds     float64  `json:"min_checkpoint_seconds" yaml:"min_checkpoint_seconds"`
+	MaxCheckpointSeconds     float64  `json:"max_checkpoint_seconds" yaml:"max_checkpoint_seconds"`
+	AvgCheckpointSeconds     float64  `json:"avg_checkpoint_seconds" yaml:"avg_checkpoint_seconds"`
+	ReadTicketsOut           int64    `json:"read_tickets_out" yaml:"read_tickets_out"`
+	ReadTicketsAvailable     int64    `json:"read_tickets_available" yaml:"read_tickets_available"`
+	WriteTicketsOut          int64    `json:"write_tickets_out" yaml:"write_tickets_out"`
+	WriteTicketsAvailable    int64    `json:"write_tickets_available" yaml:"write_tickets_available"`
+	Warnings                 []string `json:"warnings" yaml:"warnings"`
+}
+
+type reportSecurity struct {
+	Users    int64    `json:"users" yaml:"users"`
+	Roles    int64    `json:"roles" yaml:"roles"`
+	Auth     string   `json:"auth" yaml:"auth"`
+	SSL      string   `json:"ssl" yaml:"ssl"`
+	BindIP   string   `json:"bind_ip" yaml:"bind_ip"`
+	Port     int64    `json:"port" yaml:"port"`
+	Warnings []string `json:"warnings" yaml:"warnings"`
+}
+
+// reportSecurityAudit is only set with --security-audit.
+type reportSecurityAudit struct {
+	Authorization  bool                 `json:"authorization" yaml:"authorization"`
+	Auditing       string               `json:"auditing,omitempty" yaml:"auditing,omitempty"`
+	TLSMode        string               `json:"tls_mode,omitempty" yaml:"tls_mode,omitempty"`
+	BindIP         string               `json:"bind_ip,omitempty" yaml:"bind_ip,omitempty"`
+	BindIPAll      bool                 `json:"bind_ip_all" yaml:"bind_ip_all"`
+	AuthMechanisms []string             `json:"auth_mechanisms" yaml:"auth_mechanisms"`
+	Users          []reportAuditUser    `json:"users" yaml:"users"`
+	Roles          []reportAuditRole    `json:"roles" yaml:"roles"`
+	Findings       []reportAuditFinding `json:"findings" yaml:"findings"`
+}
+
+type reportAuditUser struct {
+	User       string   `json:"user" yaml:"user"`
+	DB         string   `json:"db" yaml:"db"`
+	Roles      []string `json:"roles" yaml:"roles"`
+	Mechanisms []string `json:"mechanisms" yaml:"mechanisms"`
+}
+
+type reportAuditRole struct {
+	Role       string   `json:"role" yaml:"role"`
+	DB         string   `json:"db" yaml:"db"`
+	Roles      []string `json:"roles" yaml:"roles"`
+	Privileges int      `json:"privileges" yaml:"privileges"`
+}
+
+type reportAuditFinding struct {
+	Severity string `json:"severity" yaml:"severity"`
+	Message  string `json:"message" yaml:"message"`
+}
+
+type reportOplog struct {
+	Hostname     string  `json:"hostname" yaml:"hostname"`
+	SizeMB       int64   `json:"size_mb" yaml:"size_mb"`
+	UsedMB       int64   `json:"used_mb" yaml:"used_mb"`
+	LengthHours  float64 `json:"length_hours" yaml:"length_hours"`
+	First        string  `json:"first,omitempty" yaml:"first,omitempty"`
+	Last         string  `json:"last,omitempty" yaml:"last,omitempty"`
+	ElectionTime string  `json:"election_time,omitempty" yaml:"election_time,omitempty"`
+}
+
+type reportMemberLog struct {
+	Hostname   string              `json:"hostname" yaml:"hostname"`
+	Categories []reportLogCategory `json:"categories" yaml:"categories"`
+	Recent     []reportLogEntry    `json:"recent" yaml:"recent"`
+	Error      string              `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type reportLogCategory struct {
+	Severity  string `json:"severity" yaml:"severity"`
+	Component string `json:"component" yaml:"component"`
+	Count     int    `json:"count" yaml:"count"`
+}
+
+type reportLogEntry struct {
+	Time      string `json:"time" yaml:"time"`
+	Severity  string `json:"severity" yaml:"severity"`
+	Component string `json:"component" yaml:"component"`
+	Message   string `json:"message" yaml:"message"`
+}
+
+// reportOplogWindow gives lag_seconds and headroom_seconds for secondaries only.
+type reportOplogWindow struct {
+	Hostname        string   `json:"hostname" yaml:"hostname"`
+	Replicaset      string   `json:"replicaset,omitempty" yaml:"replicaset,omitempty"`
+	State           string   `json:"state,omitempty" yaml:"state,omitempty"`
+	MaxSizeMB       int64    `json:"max_size_mb" yaml:"max_size_mb"`
+	UsedMB          int64    `json:"used_mb" yaml:"used_mb"`
+	First           string   `json:"first,omitempty" yaml:"first,omitempty"`
+	Last            string   `json:"last,omitempty" yaml:"last,omitempty"`
+	WindowSeconds   float64  `json:"window_seconds" yaml:"window_seconds"`
+	ChurnMBPerHour  float64  `json:"churn_mb_per_hour" yaml:"churn_mb_per_hour"`
+	LagSeconds      *float64 `json:"lag_seconds,omitempty" yaml:"lag_seconds,omitempty"`
+	HeadroomSeconds *float64 `json:"headroom_seconds,omitempty" yaml:"headroom_seconds,omitempty"`
+	Error           string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type reportChunks struct {
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Count     int    `json:"count" yaml:"count"`
+}
+
+type reportClusterWide struct {
+	Databases            int            `json:"databases" yaml:"databases"`
+	Collections          int            `json:"collections" yaml:"collections"`
+	ShardedCollections   int            `json:"sharded_collections" yaml:"sharded_collections"`
+	UnshardedCollections int            `json:"unsharded_collections" yaml:"unsharded_collections"`
+	ShardedDataBytes     int64          `json:"sharded_data_bytes" yaml:"sharded_data_bytes"`
+	UnshardedDataBytes   int64          `json:"unsharded_data_bytes" yaml:"unsharded_data_bytes"`
+	Chunks               []reportChunks `json:"chunks" yaml:"chunks"`
+}
+
+type reportShard struct {
+	Name       string         `json:"name" yaml:"name"`
+	Replicaset string         `json:"replicaset,omitempty" yaml:"replicaset,omitempty"`
+	Hosts      []string       `json:"hosts" yaml:"hosts"`
+	Version    string         `json:"version,omitempty" yaml:"version,omitempty"`
+	Chunks     int64          `json:"chunks" yaml:"chunks"`
+	Members    []reportMember `json:"members" yaml:"members"`
+	Error      string         `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type reportSharding struct {
+	TotalChunks     int64         `json:"total_chunks" yaml:"total_chunks"`
+	BalancerMode    string        `json:"balancer_mode" yaml:"balancer_mode"`
+	BalancerRunning bool          `json:"balancer_running" yaml:"balancer_running"`
+	ConfigServers   *reportShard  `json:"config_servers,omitempty" yaml:"config_servers,omitempty"`
+	Shards          []reportShard `json:"shards" yaml:"shards"`
+}
+
+// reportChunkDistribution is only set when connected to a mongos. chunks_by_shard is keyed by shard name.
+type reportChunkDistribution struct {
+	BalancesDataSize bool                     `json:"balances_data_size" yaml:"balances_data_size"`
+	BalancerRounds   int64                    `json:"balancer_rounds" yaml:"balancer_rounds"`
+	ChunksMoved      int64                    `json:"chunks_moved" yaml:"chunks_moved"`
+	FailedRounds     int64                    `json:"failed_rounds" yaml:"failed_rounds"`
+	BalancerErrors   []reportBalancerError    `json:"balancer_errors" yaml:"balancer_errors"`
+	Collections      []reportCollectionChunks `json:"collections" yaml:"collections"`
+}
+
+type reportBalancerError struct {
+	Time    string `json:"time" yaml:"time"`
+	Message string `json:"message" yaml:"message"`
+}
+
+type reportCollectionChunks struct {
+	Namespace     string           `json:"namespace" yaml:"namespace"`
+	Chunks        int64            `json:"chunks" yaml:"chunks"`
+	ChunksByShard map[string]int64 `json:"chunks_by_shard" yaml:"chunks_by_shard"`
+	Imbalance     int64            `json:"imbalance" yaml:"imbalance"`
+	Threshold     int64            `json:"threshold" yaml:"threshold"`
+	Imbalanced    bool             `json:"imbalanced" yaml:"imbalanced"`
+}
+
+// reportBalancer counts the sharding changelog events of the last 10 days.
+type reportBalancer struct {
+	Success int64 `json:"success" yaml:"success"`
+	Failed  int64 `json:"failed" yaml:"failed"`
+	Splits  int64 `json:"splits" yaml:"splits"`
+	Drops   int64 `json:"drops" yaml:"drops"`
+}
+
+// reportInventory is only set with --collstats.
+type reportInventory struct {
+	ReadCollections   int                         `json:"read_collections" yaml:"read_collections"`
+	Truncated         bool                        `json:"truncated" yaml:"truncated"`
+	Databases         []reportDatabaseInventory   `json:"databases" yaml:"databases"`
+	Collections       []reportCollectionInventory `json:"collections" yaml:"collections"`
+	UnusedIndexes     []reportIndexInventory      `json:"unused_indexes" yaml:"unused_indexes"`
+	CappedCollections []reportCollectionInventory `json:"capped_collections" yaml:"capped_collections"`
+}
+
+type reportDatabaseInventory struct {
+	Name            string `json:"name" yaml:"name"`
+	SizeOnDiskBytes int64  `json:"size_on_disk_bytes" yaml:"size_on_disk_bytes"`
+	Collections     int    `json:"collections" yaml:"collections"`
+}
+
+type reportCollectionInventory struct {
+	Namespace        string `json:"namespace" yaml:"namespace"`
+	Documents        int64  `json:"documents" yaml:"documents"`
+	SizeBytes        int64  `json:"size_bytes" yaml:"size_bytes"`
+	StorageSizeBytes int64  `json:"storage_size_bytes" yaml:"storage_size_bytes"`
+	Indexes          int64  `json:"indexes" yaml:"indexes"`
+	IndexSizeBytes   int64  `json:"index_size_bytes" yaml:"index_size_bytes"`
+	Capped           bool   `json:"capped" yaml:"capped"`
+	MaxSizeBytes     int64  `json:"max_size_bytes,omitempty" yaml:"max_size_bytes,omitempty"`
+}
+
+type reportIndexInventory struct {
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Name      string `json:"name" yaml:"name"`
+	Since     string `json:"since,omitempty" yaml:"since,omitempty"`
+}
+
+func newReport(ci *collectedInfo) *report {
+	r := &report{
+		FormatVersion: reportFormatVersion,
+		Members:       newReportMembers(ci.ReplicaMembers),
+		Errors:        ci.Errors,
+	}
+	if r.Errors == nil {
+		r.Errors = []string{}
+	}
+
+	r.Skipped = []reportSkippedSection{}
+	for _, s := range ci.Skipped {
+		r.Skipped = append(r.Skipped, reportSkippedSection{Section: s.Section, Reason: s.Reason})
+	}
+
+	for _, c := range ci.Checks {
+		r.Checks = append(r.Checks, reportCheck{Check: c.Check, Failed: c.Failed, Problems: nonNilStrings(c.Problems)})
+	}
+
+	if hi := ci.HostInfo; hi != nil {
+		r.Host = &reportHost{
+			Hostname:       hi.Hostname,
+			Version:        hi.Version,
+			NodeType:       hi.NodeType,
+			Replicaset:     hi.ReplicasetName,
+			OSType:         hi.HostOsType,
+			CPUArch:        hi.HostSystemCPUArch,
+			DBPath:         hi.DBPath,
+			ProcessName:    hi.ProcessName,
+			ProcessPath:    hi.ProcPath,
+			ProcessUser:    hi.ProcUserName,
+			ProcessStarted: reportTime(hi.ProcCreateTime),
+			ProcessCount:   hi.ProcProcessCount,
+			CmdlineArgs:    hi.CmdlineArgs,
+		}
+		if r.Host.CmdlineArgs == nil {
+			r.Host.CmdlineArgs = []string{}
+		}
+	}
+
+	if rc := ci.ReplicaSetConfig; rc != nil {
+		r.ReplicaSet = newReportReplicaSetConfig(rc)
+	}
+
+	if ops := ci.RunningOps; ops != nil {
+		r.RunningOps = &reportRunningOps{
+			SampleSeconds: ops.SampleRate.Seconds(),
+			Insert:        newReportCounter(ops.Insert),
+			Query:         newReportCounter(ops.Query),
+			Update:        newReportCounter(ops.Update),
+			Delete:        newReportCounter(ops.Delete),
+			GetMore:       newReportCounter(ops.GetMore),
+			Command:       newReportCounter(ops.Command),
+		}
+	}
+
+	if a := ci.Activity; a != nil {
+		r.Activity = &reportActivity{
+			IntervalSeconds: a.Interval.Seconds(),
+			Samples:         []reportActivitySample{},
+			Overall:         newReportActivitySample(a.Overall),
+		}
+		for _, s := range a.Samples {
+			r.Activity.Samples = append(r.Activity.Samples, newReportActivitySample(s))
+		}
+	}
+
+	if cd := ci.Chunks; cd != nil {
+		r.Chunks = newReportChunkDistribution(cd)
+	}
+
+	if c := ci.Connections; c != nil {
+		r.Connections = &reportConnections{
+			Current:          c.Current,
+			Available:        c.Available,
+			UsedPct:          c.UsedPct(),
+			TotalCreated:     c.TotalCreated,
+			CreatedPerSecond: c.CreatedPerSecond,
+			BytesIn:          c.BytesIn,
+			BytesOut:         c.BytesOut,
+			Requests:         c.Requests,
+			UptimeSeconds:    c.Uptime,
+			TotalClients:     c.TotalClients,
+			Clients:          []reportClientConnections{},
+			Warnings:         nonNilStrings(c.Warnings),
+		}
+		for _, cc := range c.Clients {
+			r.Connections.Clients = append(r.Connections.Clients, reportClientConnections{
+				Host:        cc.Host,
+				Connections: cc.Connections,
+				Active:      cc.Active,
+				AppNames:    nonNilStrings(cc.AppNames),
+			})
+		}
+	}
+
+	if t := ci.Transactions; t != nil {
+		r.Transactions = &reportTransactions{
+			CurrentActive:     t.CurrentActive,
+			CurrentInactive:   t.CurrentInactive,
+			CurrentOpen:       t.CurrentOpen,
+			TotalStarted:      t.TotalStarted,
+			TotalCommitted:    t.TotalCommitted,
+			TotalAborted:      t.TotalAborted,
+			TotalPrepared:     t.TotalPrepared,
+			RetriedCommands:   t.RetriedCommands,
+			RetriedStatements: t.RetriedStatements,
+			Members:           []reportMemberSessionSupport{},
+			Warnings:          nonNilStrings(t.Warnings),
+		}
+		if t.ChangeStreamsCounted {
+			changeStreams := t.ChangeStreams
+			r.Transactions.ChangeStreams = &changeStreams
+		}
+		for _, m := range t.Members {
+			r.Transactions.Members = append(r.Transactions.Members, reportMemberSessionSupport(m))
+		}
+	}
+
+	if co := ci.CurrentOps; co != nil {
+		r.CurrentOps = &reportCurrentOps{ThresholdSeconds: co.ThresholdSecs, Total: co.Total, Ops: []reportCurrentOp{}}
+		for _, op := range co.Ops {
+			r.CurrentOps.Ops = append(r.CurrentOps.Ops, reportCurrentOp{
+				OpID:           op.OpID,
+				Op:             op.Op,
+				Namespace:      op.Namespace,
+				Client:         op.Client,
+				AppName:        op.AppName,
+				SecondsRunning: op.SecsRunning,
+				PlanSummary:    op.PlanSummary,
+				WaitingForLock: op.WaitingForLock,
+				Command:        op.Command,
+			})
+		}
+	}
+
+	if wt := ci.WiredTiger; wt != nil {
+		r.WiredTiger = &reportWiredTiger{
+			CacheMaxBytes:            wt.CacheMaxBytes,
+			CacheUsedBytes:           wt.CacheUsedBytes,
+			CacheDirtyBytes:          wt.CacheDirtyBytes,
+			CacheUsedPct:             wt.CacheUsedPct(),
+			CacheDirtyPct:            wt.CacheDirtyPct(),
+			PagesReadIntoCache:       wt.PagesReadIntoCache,
+			PagesWrittenFromCache:    wt.PagesWrittenFromCache,
+			ModifiedPagesEvicted:     wt.ModifiedPagesEvicted,
+			UnmodifiedPagesEvicted:   wt.UnmodifiedPagesEvicted,
+			PagesEvictedByAppThreads: wt.PagesEvictedByAppThreads,
+			Checkpoints:              wt.Checkpoints,
+			CheckpointRunning:        wt.CheckpointRunning,
+			LastCheckpointSeconds:    wt.LastCheckpointDuration.Seconds(),
+			MinCheckpointSeconds:     wt.MinCheckpointDuration.Seconds(),
+			MaxCheckpointSeconds:     wt.MaxCheckpointDuration.Seconds(),
+			AvgCheckpointSeconds:     wt.AvgCheckpointDuration().Seconds(),
+			ReadTicketsOut:           wt.ReadTicketsOut,
+			ReadTicketsAvailable:     wt.ReadTicketsAvailable,
+			WriteTicketsOut:          wt.WriteTicketsOut,
+			WriteTicketsAvailable:    wt.WriteTicketsAvailable,
+			Warnings:                 nonNilStrings(wt.Warnings),
+		}
+	}
+
+	if s := ci.SecuritySettings; s != nil {
+		r.Security = &reportSecurity{
+			Users:    s.Users,
+			Roles:    s.Roles,
+			Auth:     s.Auth,
+			SSL:      s.SSL,
+			BindIP:   s.BindIP,
+			Port:     s.Port,
+			Warnings: s.WarningMsgs,
+		}
+		if r.Security.Warnings == nil {
+			r.Security.Warnings = []string{}
+		}
+	}
+
+	if sa := ci.SecurityAudit; sa != nil {
+		r.SecurityAudit = newReportSecurityAudit(sa)
+	}
+
+	if len(ci.OplogInfo) > 0 {
+		o := ci.OplogInfo[0]
+		r.Oplog = &reportOplog{
+			Hostname:     o.Hostname,
+			SizeMB:       o.Size,
+			UsedMB:       o.UsedMB,
+			LengthHours:  o.TimeDiffHours,
+			First:        reportTime(o.TFirst),
+			Last:         reportTime(o.TLast),
+			ElectionTime: reportTime(o.ElectionTime),
+		}
+	}
+
+	for _, w := range ci.OplogWindows {
+		rw := reportOplogWindow{
+			Hostname:       w.Hostname,
+			Replicaset:     w.Replicaset,
+			State:          w.State,
+			MaxSizeMB:      w.MaxSizeMB,
+			UsedMB:         w.UsedMB,
+			First:          reportTime(w.First),
+			Last:           reportTime(w.Last),
+			WindowSeconds:  w.Window.Seconds(),
+			ChurnMBPerHour: w.ChurnMBPerHour,
+			Error:          w.Error,
+		}
+		if w.State == "SECONDARY" && w.Error == "" {
+			lag, headroom := w.Lag.Seconds(), w.Headroom.Seconds()
+			rw.LagSeconds, rw.HeadroomSeconds = &lag, &headroom
+		}
+		r.OplogWindows = append(r.OplogWindows, rw)
+	}
+
+	if sl := ci.ServerLog; sl != nil {
+		for _, m := range sl.Members {
+			rm := reportMemberLog{
+				Hostname:   m.Hostname,
+				Categories: []reportLogCategory{},
+				Recent:     []reportLogEntry{},
+				Error:      m.Error,
+			}
+			for _, c := range m.Categories {
+				rm.Categories = append(rm.Categories, reportLogCategory{Severity: c.Severity, Component: c.Component, Count: c.Count})
+			}
+			for _, e := range m.Recent {
+				rm.Recent = append(rm.Recent, reportLogEntry{
+					Time: reportTime(e.Time), Severity: e.Severity, Component: e.Component, Message: e.Message,
+				})
+			}
+			r.ServerLog = append(r.ServerLog, rm)
+		}
+	}
+
+	if cwi := ci.ClusterWideInfo; cwi != nil {
+		r.ClusterWide = &reportClusterWide{
+			Databases:            cwi.TotalDBsCount,
+			Collections:          cwi.TotalCollectionsCount,
+			ShardedCollections:   cwi.ShardedColsCount,
+			UnshardedCollections: cwi.UnshardedColsCount,
+			ShardedDataBytes:     cwi.ShardedDataSize,
+			UnshardedDataBytes:   cwi.UnshardedDataSize,
+			Chunks:               []reportChunks{},
+		}
+		for _, c := range cwi.Chunks {
+			r.ClusterWide.Chunks = append(r.ClusterWide.Chunks, reportChunks{Namespace: c.ID, Count: c.Count})
+		}
+	}
+
+	if si := ci.ShardingInfo; si != nil {
+		r.Sharding = &reportSharding{
+			TotalChunks:     si.TotalChunks,
+			BalancerMode:    si.BalancerMode,
+			BalancerRunning: si.BalancerRunning,
+			Shards:          []reportShard{},
+		}
+		if si.ConfigServers != nil {
+			cs := newReportShard(*si.ConfigServers)
+			r.Sharding.ConfigServers = &cs
+		}
+		for _, s := range si.Shards {
+			r.Sharding.Shards = append(r.Sharding.Shards, newReportShard(s))
+		}
+	}
+
+	if b := ci.BalancerStats; b != nil {
+		r.Balancer = &reportBalancer{
+			Success: b.Success,
+			Failed:  b.Failed,
+			Splits:  b.Splits,
+			Drops:   b.Drops,
+		}
+	}
+
+	if inv := ci.Inventory; inv != nil {
+		r.Inventory = &reportInventory{
+			ReadCollections:   inv.ReadCollections,
+			Truncated:         inv.Truncated,
+			Databases:         []reportDatabaseInventory{},
+			Collections:       newReportCollections(inv.Collections),
+			UnusedIndexes:     []reportIndexInventory{},
+			CappedCollections: newReportCollections(inv.CappedCollections),
+		}
+		for _, db := range inv.Databases {
+			r.Inventory.Databases = append(r.Inventory.Databases, reportDatabaseInventory{
+				Name:            db.Name,
+				SizeOnDiskBytes: db.SizeOnDisk,
+				Collections:     db.Collections,
+			})
+		}
+		for _, i := range inv.UnusedIndexes {
+			r.Inventory.UnusedIndexes = append(r.Inventory.UnusedIndexes, reportIndexInventory{
+				Namespace: i.Namespace,
+				Name:      i.Name,
+				Since:     reportTime(i.Since),
+			})
+		}
+	}
+
+	return r
+}
+
+func newReportSecurityAudit(sa *securityAudit) *reportSecurityAudit {
+	rsa := &reportSecurityAudit{
+		Authorization:  sa.Authorization,
+		Auditing:       sa.Auditing,
+		TLSMode:        sa.TLSMode,
+		BindIP:         sa.BindIP,
+		BindIPAll:      sa.BindIPAll,
+		AuthMechanisms: nonNilStrings(sa.AuthMechanisms),
+		Users:          []reportAuditUser{},
+		Roles:          []reportAuditRole{},
+		Findings:       []reportAuditFinding{},
+	}
+	for _, u := range sa.Users {
+		rsa.Users = append(rsa.Users, reportAuditUser{
+			User:       u.User,
+			DB:         u.DB,
+			Roles:      nonNilStrings(u.Roles),
+			Mechanisms: nonNilStrings(u.Mechanisms),
+		})
+	}
+	for _, r := range sa.Roles {
+		rsa.Roles = append(rsa.Roles, reportAuditRole{Role: r.Role, DB: r.DB, Roles: nonNilStrings(r.Roles), Privileges: r.Privileges})
+	}
+	for _, f := range sa.Findings {
+		rsa.Findings = append(rsa.Findings, reportAuditFinding{Severity: f.Severity, Message: f.Message})
+	}
+
+	return rsa
+}
+
+func newReportChunkDistribution(cd *chunkDistribution) *reportChunkDistribution {
+	rcd := &reportChunkDistribution{
+		BalancesDataSize: cd.BalancesDataSize,
+		BalancerRounds:   cd.BalancerRounds,
+		ChunksMoved:      cd.ChunksMoved,
+		FailedRounds:     cd.FailedRounds,
+		BalancerErrors:   []reportBalancerError{},
+		Collections:      []reportCollectionChunks{},
+	}
+	for _, e := range cd.BalancerErrors {
+		rcd.BalancerErrors = append(rcd.BalancerErrors, reportBalancerError{Time: reportTime(e.Time), Message: e.Message})
+	}
+	for _, c := range cd.Collections {
+		byShard := map[string]int64{}
+		for i, shard := range cd.Shards {
+			byShard[shard] = c.ByShard[i]
+		}
+		rcd.Collections = append(rcd.Collections, reportCollectionChunks{
+			Namespace:     c.Namespace,
+			Chunks:        c.Total,
+			ChunksByShard: byShard,
+			Imbalance:     c.Imbalance,
+			Threshold:     c.Threshold,
+			Imbalanced:    c.Imbalanced,
+		})
+	}
+
+	return rcd
+}
+
+func newReportActivitySample(s activitySample) reportActivitySample {
+	return reportActivitySample{
+		Time:           reportTime(s.Time),
+		ElapsedSeconds: s.Elapsed.Seconds(),
+		Inserts:        s.Inserts,
+		Queries:        s.Queries,
+		Updates:        s.Updates,
+		Deletes:        s.Deletes,
+		GetMores:       s.GetMores,
+		Commands:       s.Commands,
+		ReplOps:        s.ReplOps,
+		PageFaults:     s.PageFaults,
+		BytesIn:        s.BytesIn,
+		BytesOut:       s.BytesOut,
+		QueuedReaders:  s.QueuedReaders,
+		QueuedWriters:  s.QueuedWriters,
+		ActiveReaders:  s.ActiveReaders,
+		ActiveWriters:  s.ActiveWriters,
+		Connections:    s.Connections,
+	}
+}
+
+// nonNilStrings prints empty lists as [] instead of null.
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
+
+func newReportCollections(collections []collectionInventory) []reportCollectionInventory {
+	rc := []reportCollectionInventory{}
+	for _, c := range collections {
+		rc = append(rc, reportCollectionInventory{
+			Namespace:        c.Namespace,
+			Documents:        c.Count,
+			SizeBytes:        c.Size,
+			StorageSizeBytes: c.StorageSize,
+			Indexes:          c.Indexes,
+			IndexSizeBytes:   c.IndexSize,
+			Capped:           c.Capped,
+			MaxSizeBytes:     c.MaxSize,
+		})
+	}
+
+	return rc
+}
+
+func newReportReplicaSetConfig(rc *replicaSetConfig) *reportReplicaSetConfig {
+	r := &reportReplicaSetConfig{
+		Name:                rc.Name,
+		ConfigVersion:       rc.ConfigVersion,
+		Members:             []reportReplicaSetMember{},
+		Voters:              rc.Voters,
+		Majority:            rc.Majority,
+		DefaultWriteConcern: rc.DefaultWriteConcern,
+		Warnings:            nonNilStrings(rc.Warnings),
+	}
+	for _, m := range rc.Members {
+		r.Members = append(r.Members, reportReplicaSetMember{
+			Host:         m.Host,
+			State:        m.State,
+			Healthy:      m.Healthy,
+			Votes:        m.Votes,
+			Priority:     m.Priority,
+			Arbiter:      m.Arbiter,
+			Hidden:       m.Hidden,
+			DelaySeconds: m.Delay.Seconds(),
+			BuildIndexes: m.BuildIndexes,
+		})
+	}
+
+	return r
+}
+
+func newReportMembers(members []proto.Members) []reportMember {
+	rm := []reportMember{}
+	for _, m := range members {
+		rm = append(rm, reportMember{
+			Name:          m.Name,
+			PID:           m.ID,
+			State:         m.StateStr,
+			Replicaset:    m.Set,
+			StorageEngine: m.StorageEngine.Name,
+			Health:        m.Health,
+			UptimeSeconds: m.Uptime,
+			Error:         m.Error,
+		})
+	}
+
+	return rm
+}
+
+func newReportCounter(s TimedStats) reportCounter {
+	return reportCounter{Min: s.Min, Max: s.Max, Total: s.Total}
+}
+
+func newReportShard(s shardSummary) reportShard {
+	return reportShard{
+		Name:       s.Name,
+		Replicaset: s.ReplicasetName,
+		Hosts:      s.Hosts,
+		Version:    s.Version,
+		Chunks:     s.Chunks,
+		Members:    newReportMembers(s.Members),
+		Error:      s.Error,
+	}
+}
+
+// reportTime formats dates the same way in JSON and YAML, zero dates are omitted.
+func reportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+)
+
+const DefaultSamples = 3
+
+// activity is the result of --interval: the rates computed between consecutive serverStatus
+// samples, instead of the counters since the server started.
+type activity struct {
+	Interval time.Duration
+	Samples  []activitySample
+	// Overall has the rates over the whole sampling, and the largest queues and active clients
+	Overall activitySample
+}
+
+// activitySample has the rates, per second, of the counters and the gauges read at the end of an interval.
+type activitySample struct {
+	Time          time.Time
+	Elapsed       time.Duration
+	Inserts       float64
+	Queries       float64
+	Updates       float64
+	Deletes       float64
+	GetMores      float64
+	Commands      float64
+	ReplOps       float64
+	PageFaults    float64
+	BytesIn       int64
+	BytesOut      int64
+	QueuedReaders int64
+	QueuedWriters int64
+	ActiveReaders int64
+	ActiveWriters int64
+	Connections   int64
+}
+
+func getActivity(ctx context.Context, client *mongo.Client, interval time.Duration, samples int) (*activity, error) {
+	statuses := []proto.ServerStatus{}
+
+	// samples + 1 because the first one is only the base of the first delta
+	for i := 0; i < samples+1; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+
+		ss := proto.ServerStatus{}
+		if err := client.Database("admin").RunCommand(ctx, primitive.M{"serverStatus": 1}).Decode(&ss); err != nil {
+			return nil, errors.Wrap(err, "cannot get server status")
+		}
+		statuses = append(statuses, ss)
+	}
+
+	return newActivity(statuses, interval), nil
+}
+
+func newActivity(statuses []proto.ServerStatus, interval time.Duration) *activity {
+	a := &activity{Interval: interval, Samples: []activitySample{}}
+	if len(statuses) < 2 {
+		return a
+	}
+
+	for i := 1; i < len(statuses); i++ {
+		a.Samples = append(a.Samples, newActivitySample(statuses[i-1], statuses[i], interval))
+	}
+
+	a.Overall = newActivitySample(statuses[0], statuses[len(statuses)-1], interval*time.Duration(len(a.Samples)))
+	for _, s := range a.Samples {
+		a.Overall.QueuedReaders = maxInt64(a.Overall.QueuedReaders, s.QueuedReaders)
+		a.Overall.QueuedWriters = maxInt64(a.Overall.QueuedWriters, s.QueuedWriters)
+		a.Overall.ActiveReaders = maxInt64(a.Overall.ActiveReaders, s.ActiveReaders)
+		a.Overall.ActiveWriters = maxInt64(a.Overall.ActiveWriters, s.ActiveWriters)
+		a.Overall.Connections = maxInt64(a.Overall.Connections, s.Connections)
+	}
+
+	return a
+}
+
+// newActivitySample computes the rates between two samples. The elapsed time is read from the server
+// clock, the expected one is only used when localTime is missing.
+func newActivitySample(prev, cur proto.ServerStatus, expected time.Duration) activitySample {
+	s := activitySample{Time: cur.LocalTime, Elapsed: expected}
+	if !prev.LocalTime.IsZero() && cur.LocalTime.After(prev.LocalTime) {
+		s.Elapsed = cur.LocalTime.Sub(prev.LocalTime)
+	}
+
+	secs := s.Elapsed.Seconds()
+	if secs <= 0 {
+		return s
+	}
+
+	rate := func(prev, cur int64) float64 {
+		// counters start over when the server restarts
+		if cur < prev {
+			return 0
+		}
+		return float64(cur-prev) / secs
+	}
+
+	if prev.Opcounters != nil && cur.Opcounters != nil {
+		s.Inserts = rate(prev.Opcounters.Insert, cur.Opcounters.Insert)
+		s.Queries = rate(prev.Opcounters.Query, cur.Opcounters.Query)
+		s.Updates = rate(prev.Opcounters.Update, cur.Opcounters.Update)
+		s.Deletes = rate(prev.Opcounters.Delete, cur.Opcounters.Delete)
+		s.GetMores = rate(prev.Opcounters.GetMore, cur.Opcounters.GetMore)
+		s.Commands = rate(prev.Opcounters.Command, cur.Opcounters.Command)
+	}
+
+	if prev.OpcountersRepl != nil && cur.OpcountersRepl != nil {
+		s.ReplOps = rate(totalOps(prev.OpcountersRepl), totalOps(cur.OpcountersRepl))
+	}
+
+	if prev.ExtraInfo != nil && cur.ExtraInfo != nil {
+		s.PageFaults = rate(int64(prev.ExtraInfo.PageFaults), int64(cur.ExtraInfo.PageFaults))
+	}
+
+	if prev.Network != nil && cur.Network != nil {
+		s.BytesIn = int64(math.Round(rate(prev.Network.BytesIn, cur.Network.BytesIn)))
+		s.BytesOut = int64(math.Round(rate(prev.Network.BytesOut, cur.Network.BytesOut)))
+	}
+
+	if gl := cur.GlobalLock; gl != nil {
+		if gl.CurrentQueue != nil {
+			s.QueuedReaders, s.QueuedWriters = gl.CurrentQueue.Readers, gl.CurrentQueue.Writers
+		}
+		if gl.ActiveClients != nil {
+			s.ActiveReaders, s.ActiveWriters = gl.ActiveClients.Readers, gl.ActiveClients.Writers
+		}
+	}
+
+	if cur.Connections != nil {
+		s.Connections = cur.Connections.Current
+	}
+
+	return s
+}
+
+func totalOps(oc *proto.OpcountStats) int64 {
+	return oc.Insert + oc.Query + oc.Update + oc.Delete + oc.GetMore + oc.Command
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
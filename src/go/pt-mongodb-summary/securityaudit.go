@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/compat"
+)
+
+const (
+	severityHigh   = "HIGH"
+	severityMedium = "MEDIUM"
+	severityLow    = "LOW"
+)
+
+// roles giving control over all the databases, or the whole cluster
+var powerfulRoles = map[string]bool{
+	"root":                 true,
+	"__system":             true,
+	"userAdminAnyDatabase": true,
+	"dbOwner":              true,
+	"clusterAdmin":         true,
+	"restore":              true,
+}
+
+// securityAudit is a first-pass review of the security settings. Users and roles are read without their
+// credentials, which are never requested from the server.
+type securityAudit struct {
+	Authorization  bool
+	Auditing       string
+	TLSMode        string
+	BindIP         string
+	BindIPAll      bool
+	AuthMechanisms []string
+	Users          []auditUser
+	Roles          []auditRole
+	Findings       []auditFinding
+}
+
+type auditUser struct {
+	User       string
+	DB         string
+	Roles      []string
+	Mechanisms []string
+}
+
+type auditRole struct {
+	Role       string
+	DB         string
+	Roles      []string
+	Privileges int
+}
+
+type auditFinding struct {
+	Severity string
+	Message  string
+}
+
+type roleRef struct {
+	Role string `bson:"role"`
+	DB   string `bson:"db"`
+}
+
+func (r roleRef) String() string {
+	return r.Role + "@" + r.DB
+}
+
+func getSecurityAudit(ctx context.Context, client *mongo.Client, srv *compat.Server) (*securityAudit, error) {
+	cmdOpts := proto.CommandLineOptions{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"getCmdLineOpts": 1}).Decode(&cmdOpts); err != nil {
+		return nil, errors.Wrap(err, "cannot get command line options")
+	}
+
+	sa := &securityAudit{
+		// a keyFile enables authorization, even when it is not set
+		Authorization: cmdOpts.Parsed.Security.Authorization == "enabled" || cmdOpts.Parsed.Security.KeyFile != "" ||
+			cmdOpts.Security.Authorization == "enabled" || cmdOpts.Security.KeyFile != "",
+		Auditing:  cmdOpts.Parsed.AuditLog.Destination,
+		TLSMode:   srv.TLSMode(cmdOpts),
+		BindIP:    cmdOpts.Parsed.Net.BindIP,
+		BindIPAll: cmdOpts.Parsed.Net.BindIPAll,
+	}
+
+	params := struct {
+		AuthenticationMechanisms []string `bson:"authenticationMechanisms"`
+	}{}
+	query := primitive.D{{Key: "getParameter", Value: 1}, {Key: "authenticationMechanisms", Value: 1}}
+	if err := client.Database("admin").RunCommand(ctx, query).Decode(&params); err != nil {
+		log.Debugf("cannot get the authentication mechanisms: %s", err)
+	}
+	sa.AuthMechanisms = params.AuthenticationMechanisms
+
+	var err error
+	if sa.Users, err = getAuditUsers(ctx, client); err != nil {
+		return nil, err
+	}
+
+	if sa.Roles, err = getAuditRoles(ctx, client); err != nil {
+		return nil, err
+	}
+
+	sa.Findings = auditFindings(sa)
+
+	return sa, nil
+}
+
+func getAuditUsers(ctx context.Context, client *mongo.Client) ([]auditUser, error) {
+	res := struct {
+		Users []struct {
+			User       string    `bson:"user"`
+			DB         string    `bson:"db"`
+			Roles      []roleRef `bson:"roles"`
+			Mechanisms []string  `bson:"mechanisms"`
+		} `bson:"users"`
+	}{}
+
+	// showCredentials is false by default: the password hashes are not even sent
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"usersInfo": primitive.M{"forAllDBs": true}}).Decode(&res); err != nil {
+		return nil, errors.Wrap(err, "cannot list users")
+	}
+
+	users := []auditUser{}
+	for _, u := range res.Users {
+		user := auditUser{User: u.User, DB: u.DB, Mechanisms: u.Mechanisms}
+		for _, r := range u.Roles {
+			user.Roles = append(user.Roles, r.String())
+		}
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].DB != users[j].DB {
+			return users[i].DB < users[j].DB
+		}
+		return users[i].User < users[j].User
+	})
+
+	return users, nil
+}
+
+// getAuditRoles lists the user defined roles, built-in roles are documented.
+func getAuditRoles(ctx context.Context, client *mongo.Client) ([]auditRole, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"role": 1, "db": 1, "roles": 1, "privileges": 1}).
+		SetSort(bson.D{{Key: "db", Value: 1}, {Key: "role", Value: 1}})
+
+	cursor, err := client.Database("admin").Collection("system.roles").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list roles")
+	}
+	defer cursor.Close(ctx)
+
+	roles := []auditRole{}
+	for cursor.Next(ctx) {
+		res := struct {
+			Role       string     `bson:"role"`
+			DB         string     `bson:"db"`
+			Roles      []roleRef  `bson:"roles"`
+			Privileges []bson.Raw `bson:"privileges"`
+		}{}
+		if err := cursor.Decode(&res); err != nil {
+			return nil, errors.Wrap(err, "cannot decode role")
+		}
+
+		role := auditRole{Role: res.Role, DB: res.DB, Privileges: len(res.Privileges)}
+		for _, r := range res.Roles {
+			role.Roles = append(role.Roles, r.String())
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+// auditFindings flags the risky settings, most severe first.
+func auditFindings(sa *securityAudit) []auditFinding {
+	findings := []auditFinding{}
+	add := func(severity, format string, args ...interface{}) {
+		findings = append(findings, auditFinding{Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !sa.Authorization {
+		add(severityHigh, "authorization is disabled: any client that can connect has full access")
+	} else if len(sa.Users) == 0 {
+		add(severityMedium, "authorization is enabled without users: the localhost exception lets any local client create one")
+	}
+
+	if sa.listensOnAllInterfaces() {
+		severity := severityMedium
+		if !sa.Authorization {
+			severity = severityHigh
+		}
+		add(severity, "listening on all network interfaces")
+	}
+
+	requireTLS := sa.TLSMode == "requireTLS" || sa.TLSMode == "requireSSL"
+	switch sa.TLSMode {
+	case "", "disabled", "allowTLS", "allowSSL":
+		add(severityMedium, "client connections are not encrypted, TLS mode: %s", sa.tlsModeOrDisabled())
+	case "preferTLS", "preferSSL":
+		add(severityLow, "clients can connect without TLS, TLS mode: %s", sa.TLSMode)
+	}
+
+	for _, mechanism := range sa.AuthMechanisms {
+		switch {
+		case mechanism == "PLAIN" && !requireTLS:
+			add(severityHigh, "PLAIN authentication sends passwords in clear text while TLS is not required")
+		case mechanism == "MONGODB-CR":
+			add(severityMedium, "MONGODB-CR authentication is enabled, it was replaced by SCRAM in 3.0")
+		}
+	}
+
+	if sa.Auditing == "" {
+		add(severityLow, "auditing is disabled")
+	}
+
+	for _, u := range sa.Users {
+		for _, r := range u.Roles {
+			if name := strings.TrimSuffix(r, "@admin"); name != r && powerfulRoles[name] {
+				add(severityLow, "user %s@%s has the %s role", u.User, u.DB, r)
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank(findings[i].Severity) < severityRank(findings[j].Severity)
+	})
+
+	return findings
+}
+
+func (sa *securityAudit) listensOnAllInterfaces() bool {
+	if sa.BindIPAll {
+		return true
+	}
+
+	for _, ip := range strings.Split(sa.BindIP, ",") {
+		switch strings.TrimSpace(ip) {
+		case "0.0.0.0", "::", "*":
+			return true
+		}
+	}
+
+	return false
+}
+
+func (sa *securityAudit) tlsModeOrDisabled() string {
+	if sa.TLSMode == "" {
+		return "disabled"
+	}
+
+	return sa.TLSMode
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case severityHigh:
+		return 0
+	case severityMedium:
+		return 1
+	}
+
+	return 2
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+)
+
+const (
+	DefaultServerLogLimit = 10
+
+	// log messages longer than this are truncated
+	maxLogMessageLength = 256
+)
+
+// severities kept from the log: warnings, errors and fatal errors
+var notableSeverities = map[string]bool{"W": true, "E": true, "F": true}
+
+// 2024-03-01T10:00:00.000+0000 W  NETWORK  [conn12] message, the log format before MongoDB 4.4
+var legacyLogLineRegexp = regexp.MustCompile(`^(\S+)\s+([DIWEF])\s+(\S+)\s+\[[^\]]*\]\s+(.*)$`)
+
+// serverLog is the recent warnings and errors of the members, from the log lines they keep in memory.
+type serverLog struct {
+	Members []memberLog
+}
+
+type memberLog struct {
+	Hostname   string
+	Categories []logCategory
+	Recent     []logEntry
+	Error      string
+}
+
+// logCategory counts the entries of a severity and component, like the W NETWORK ones.
+type logCategory struct {
+	Severity  string
+	Component string
+	Count     int
+}
+
+type logEntry struct {
+	Time      time.Time
+	Severity  string
+	Component string
+	Message   string
+}
+
+// structuredLogLine is a log line of MongoDB 4.4 and later.
+type structuredLogLine struct {
+	T struct {
+		Date time.Time `json:"$date"`
+	} `json:"t"`
+	S    string                 `json:"s"`
+	C    string                 `json:"c"`
+	Msg  string                 `json:"msg"`
+	Attr map[string]interface{} `json:"attr"`
+}
+
+// getServerLog reads the log of every host at the same time, each one with its own timeout. Without hosts,
+// for a standalone server, the log of the connected server is read.
+func getServerLog(ctx context.Context, client *mongo.Client, hostname string, hostnames []string,
+	co *options.ClientOptions, hostTimeout time.Duration, limit int,
+) (*serverLog, error) {
+	if len(hostnames) == 0 {
+		lines, err := getLogLines(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+
+		return &serverLog{Members: []memberLog{newMemberLog(hostname, lines, limit)}}, nil
+	}
+
+	sl := &serverLog{Members: make([]memberLog, len(hostnames))}
+
+	var wg sync.WaitGroup
+	for i, hostname := range hostnames {
+		wg.Add(1)
+		go func(i int, hostname string) {
+			defer wg.Done()
+
+			hostCtx, cancel := context.WithTimeout(ctx, hostTimeout)
+			defer cancel()
+
+			lines, err := getHostLogLines(hostCtx, co, hostname)
+			if err != nil {
+				sl.Members[i] = memberLog{Hostname: hostname, Error: err.Error()}
+				return
+			}
+
+			sl.Members[i] = newMemberLog(hostname, lines, limit)
+		}(i, hostname)
+	}
+	wg.Wait()
+
+	sort.SliceStable(sl.Members, func(i, j int) bool { return sl.Members[i].Hostname < sl.Members[j].Hostname })
+
+	return sl, nil
+}
+
+func getHostLogLines(ctx context.Context, co *options.ClientOptions, hostname string) ([]string, error) {
+	client, err := util.GetClientForHost(co, hostname)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get a client")
+	}
+
+	if err := client.Connect(ctx); err != nil {
+		return nil, errors.Wrapf(err, "cannot connect to %s", hostname)
+	}
+	defer client.Disconnect(context.Background()) // nolint
+
+	return getLogLines(ctx, client)
+}
+
+func getLogLines(ctx context.Context, client *mongo.Client) ([]string, error) {
+	var res struct {
+		Log []string `bson:"log"`
+	}
+
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"getLog": "global"}).Decode(&res); err != nil {
+		return nil, errors.Wrap(err, "cannot read the log")
+	}
+
+	return res.Log, nil
+}
+
+// newMemberLog counts the warnings and errors of the log lines by severity and component, and keeps the
+// limit most recent ones.
+func newMemberLog(hostname string, lines []string, limit int) memberLog {
+	ml := memberLog{Hostname: hostname, Categories: []logCategory{}, Recent: []logEntry{}}
+
+	counts := map[logCategory]int{}
+	for _, line := range lines {
+		e, ok := parseLogLine(line)
+		if !ok || !notableSeverities[e.Severity] {
+			continue
+		}
+
+		counts[logCategory{Severity: e.Severity, Component: e.Component}]++
+
+		ml.Recent = append(ml.Recent, e)
+		if len(ml.Recent) > limit {
+			ml.Recent = ml.Recent[1:]
+		}
+	}
+
+	for c, count := range counts {
+		c.Count = count
+		ml.Categories = append(ml.Categories, c)
+	}
+
+	sort.Slice(ml.Categories, func(i, j int) bool {
+		if ml.Categories[i].Count != ml.Categories[j].Count {
+			return ml.Categories[i].Count > ml.Categories[j].Count
+		}
+		if ml.Categories[i].Severity != ml.Categories[j].Severity {
+			return ml.Categories[i].Severity < ml.Categories[j].Severity
+		}
+		return ml.Categories[i].Component < ml.Categories[j].Component
+	})
+
+	return ml
+}
+
+// parseLogLine parses the structured log lines of MongoDB 4.4 and later, and the text ones of the
+// previous versions.
+func parseLogLine(line string) (logEntry, bool) {
+	var e logEntry
+
+	if sl := (structuredLogLine{}); json.Unmarshal([]byte(line), &sl) == nil && sl.S != "" {
+		e = logEntry{Time: sl.T.Date, Severity: sl.S[:1], Component: sl.C, Message: sl.Msg}
+		if err, ok := sl.Attr["error"]; ok {
+			if b, jerr := json.Marshal(err); jerr == nil {
+				e.Message += ": " + string(b)
+			}
+		}
+	} else if m := legacyLogLineRegexp.FindStringSubmatch(line); m != nil {
+		t, _ := time.Parse("2006-01-02T15:04:05.000-0700", m[1])
+		e = logEntry{Time: t, Severity: m[2], Component: m[3], Message: m[4]}
+	} else {
+		return e, false
+	}
+
+	if len(e.Message) > maxLogMessageLength {
+		e.Message = e.Message[:maxLogMessageLength] + "..."
+	}
+
+	return e, true
+}
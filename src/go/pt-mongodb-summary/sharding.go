@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+)
+
+const (
+	roleConfig = "config"
+	roleShard  = "shard"
+)
+
+// shardSummary is a shard, or the config servers replica set, of a sharded cluster.
+type shardSummary struct {
+	Name           string
+	Role           string
+	ReplicasetName string
+	Hosts          []string
+	Version        string
+	Chunks         int64
+	Members        []proto.Members
+	Error          string
+}
+
+// shardingInfo is the topology of the cluster behind a mongos.
+type shardingInfo struct {
+	ConfigServers   *shardSummary
+	Shards          []shardSummary
+	TotalChunks     int64
+	BalancerMode    string
+	BalancerRunning bool
+}
+
+type balancerStatus struct {
+	Mode            string `bson:"mode"`
+	InBalancerRound bool   `bson:"inBalancerRound"`
+}
+
+type chunksByShard struct {
+	ID    string `bson:"_id"` // shard name
+	Count int64  `bson:"count"`
+}
+
+// getShardingInfo discovers the config servers and the shards a mongos routes to, and connects
+// to each of them since the mongos alone only knows about itself.
+// The shards are read at the same time, and unreachable ones are reported in their section instead of
+// failing the whole summary.
+func getShardingInfo(ctx context.Context, client *mongo.Client, clientOptions *options.ClientOptions, hostTimeout time.Duration) (*shardingInfo, error) {
+	shardsInfo := proto.ShardsInfo{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"listShards": 1}).Decode(&shardsInfo); err != nil {
+		return nil, errors.Wrap(err, "cannot list shards")
+	}
+
+	si := &shardingInfo{}
+
+	chunks, err := getChunksByShard(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get chunks per shard")
+	}
+
+	for _, count := range chunks {
+		si.TotalChunks += count
+	}
+
+	si.BalancerMode, si.BalancerRunning = getBalancerState(ctx, client)
+
+	var wg sync.WaitGroup
+
+	var shardsMap proto.ShardsMap
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"getShardMap": 1}).Decode(&shardsMap); err != nil {
+		log.Debugf("cannot get the config servers from getShardMap: %s", err)
+	} else if configDB, ok := shardsMap.Map["config"]; ok {
+		si.ConfigServers = &shardSummary{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			*si.ConfigServers = summarizeShard(ctx, clientOptions, hostTimeout, roleConfig, configDB)
+			si.ConfigServers.Role = roleConfig
+		}()
+	}
+
+	si.Shards = make([]shardSummary, len(shardsInfo.Shards))
+	for i, shard := range shardsInfo.Shards {
+		wg.Add(1)
+		go func(i int, id, host string) {
+			defer wg.Done()
+			s := summarizeShard(ctx, clientOptions, hostTimeout, id, host)
+			s.Role = roleShard
+			s.Chunks = chunks[id]
+			si.Shards[i] = s
+		}(i, shard.ID, shard.Host)
+	}
+
+	wg.Wait()
+
+	sort.Slice(si.Shards, func(i, j int) bool { return si.Shards[i].Name < si.Shards[j].Name })
+
+	return si, nil
+}
+
+// summarizeShard connects to the first reachable host of a shard. Each host has hostTimeout to answer.
+func summarizeShard(ctx context.Context, clientOptions *options.ClientOptions, hostTimeout time.Duration, name, host string) shardSummary {
+	s := shardSummary{Name: name}
+	s.ReplicasetName, s.Hosts = parseShardHost(host)
+
+	var err error
+	for _, hostname := range s.Hosts {
+		hostOptions := util.GetClientOptionsForHost(clientOptions, hostname)
+
+		if s.Version, s.Members, err = getShardStatus(ctx, hostOptions, hostTimeout); err == nil {
+			return s
+		}
+
+		log.Debugf("cannot get the status of %s from %s: %s", name, hostname, err)
+	}
+
+	if err != nil {
+		s.Error = err.Error()
+	}
+
+	return s
+}
+
+func getShardStatus(ctx context.Context, hostOptions *options.ClientOptions, hostTimeout time.Duration) (string, []proto.Members, error) {
+	hostCtx, cancel := context.WithTimeout(ctx, hostTimeout)
+	defer cancel()
+
+	client, err := mongo.NewClient(hostOptions)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "cannot get a new client")
+	}
+
+	if err := client.Connect(hostCtx); err != nil {
+		return "", nil, errors.Wrap(err, "cannot connect to MongoDB")
+	}
+	defer client.Disconnect(ctx) // nolint
+
+	ss, err := util.GetServerStatus(hostCtx, client)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "cannot get server status")
+	}
+
+	members, err := getReplicasetMembers(ctx, hostOptions, hostTimeout)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "cannot get replicaset members")
+	}
+
+	return ss.Version, members, nil
+}
+
+// parseShardHost splits shard hosts as returned by listShards and getShardMap, eg:
+// rs1/localhost:17001,localhost:17002 or localhost:19001,localhost:19002 for old mirrored config servers.
+func parseShardHost(host string) (string, []string) {
+	replicasetName, hosts := "", host
+	if i := strings.Index(host, "/"); i >= 0 {
+		replicasetName, hosts = host[:i], host[i+1:]
+	}
+
+	return replicasetName, strings.Split(hosts, ",")
+}
+
+func getChunksByShard(ctx context.Context, client *mongo.Client) (map[string]int64, error) {
+	chunks := make(map[string]int64)
+	query := primitive.M{"$group": primitive.M{"_id": "$shard", "count": primitive.M{"$sum": 1}}}
+
+	// db.getSiblingDB('config').chunks.aggregate({$group:{_id:"$shard",count:{$sum:1}}})
+	cursor, err := client.Database("config").Collection("chunks").Aggregate(ctx, []primitive.M{query})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		res := chunksByShard{}
+		if err := cursor.Decode(&res); err != nil {
+			return nil, errors.Wrap(err, "cannot decode chunks aggregation")
+		}
+
+		chunks[res.ID] = res.Count
+	}
+
+	return chunks, nil
+}
+
+// getBalancerState uses balancerStatus (MongoDB 3.4+), and the balancer settings on older versions.
+func getBalancerState(ctx context.Context, client *mongo.Client) (string, bool) {
+	bs := balancerStatus{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"balancerStatus": 1}).Decode(&bs); err == nil {
+		return bs.Mode, bs.InBalancerRound
+	}
+
+	settings := struct {
+		Stopped bool `bson:"stopped"`
+	}{}
+
+	err := client.Database("config").Collection("settings").FindOne(ctx, primitive.M{"_id": "balancer"}).Decode(&settings)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return "full", false
+	case err != nil:
+		log.Debugf("cannot get the balancer state: %s", err)
+		return "unknown", false
+	case settings.Stopped:
+		return "off", false
+	}
+
+	return "full", false
+}
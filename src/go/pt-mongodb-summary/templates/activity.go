@@ -0,0 +1,16 @@
+package templates
+
+const Activity = `
+{{ if . -}}
+# Activity ###############################################################################################
+Per second, sampled every {{.Interval}}. Queues, active clients and connections are read at the end of each sample.
+
+Time         Insert     Query    Update    Delete   GetMore   Command      Repl    Faults       Net in      Net out   Queue R/W  Active R/W   Conns
+{{- range .Samples }}
+{{.Time.Format "15:04:05"}}   {{printf "%8.1f" .Inserts}}  {{printf "%8.1f" .Queries}}  {{printf "%8.1f" .Updates}}  {{printf "%8.1f" .Deletes}}  {{printf "%8.1f" .GetMores}}  {{printf "%8.1f" .Commands}}  {{printf "%8.1f" .ReplOps}}  {{printf "%8.1f" .PageFaults}}  {{printf "%11s" (size .BytesIn)}}  {{printf "%11s" (size .BytesOut)}}  {{printf "%10s" (printf "%d/%d" .QueuedReaders .QueuedWriters)}}  {{printf "%10s" (printf "%d/%d" .ActiveReaders .ActiveWriters)}}  {{printf "%6d" .Connections}}
+{{- end }}
+{{- with .Overall }}
+Overall    {{printf "%8.1f" .Inserts}}  {{printf "%8.1f" .Queries}}  {{printf "%8.1f" .Updates}}  {{printf "%8.1f" .Deletes}}  {{printf "%8.1f" .GetMores}}  {{printf "%8.1f" .Commands}}  {{printf "%8.1f" .ReplOps}}  {{printf "%8.1f" .PageFaults}}  {{printf "%11s" (size .BytesIn)}}  {{printf "%11s" (size .BytesOut)}}  {{printf "%10s" (printf "%d/%d" .QueuedReaders .QueuedWriters)}}  {{printf "%10s" (printf "%d/%d" .ActiveReaders .ActiveWriters)}}  {{printf "%6d" .Connections}}
+{{- end }}
+{{- end }}
+`
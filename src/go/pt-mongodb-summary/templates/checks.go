@@ -0,0 +1,13 @@
+package templates
+
+const HealthChecks = `
+{{ if . -}}
+# Health Checks ##########################################################################################
+{{- range . }}
+  {{printf "%-20s" .Check}} {{ if .Failed }}FAILED{{ else }}OK{{ end }}
+{{- range .Problems }}
+    {{.}}
+{{- end }}
+{{- end }}
+{{- end }}
+`
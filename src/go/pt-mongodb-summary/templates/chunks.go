@@ -0,0 +1,19 @@
+package templates
+
+const ChunkDistribution = `
+{{ if . -}}
+# Chunk Distribution #####################################################################################
+Balancer rounds (last 10 days): {{.BalancerRounds}}, chunks moved: {{.ChunksMoved}}, failed rounds: {{.FailedRounds}}
+{{- range .BalancerErrors }}
+  {{.Time.UTC.Format "2006-01-02 15:04:05"}}  {{or .Message "unknown error"}}
+{{- end }}
+{{- if .BalancesDataSize }}
+The balancer evens the data size of the shards, not their number of chunks
+{{- end }}
+
+  {{printf "%-40s" "Collection"}} {{printf "%10s" "Chunks"}}{{ range .Shards }} {{printf "%10s" .}}{{ end }}  Imbalance
+{{- range .Collections }}
+  {{printf "%-40s" .Namespace}} {{printf "%10d" .Total}}{{ range .ByShard }} {{printf "%10d" .}}{{ end }}  {{.Imbalance}}{{ if .Imbalanced }} (more than {{.Threshold}}){{ end }}
+{{- end }}
+{{- end }}
+`
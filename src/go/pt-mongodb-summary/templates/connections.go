@@ -0,0 +1,30 @@
+package templates
+
+const Connections = `
+{{ if . -}}
+# Connections ############################################################################################
+               Current: {{.Current}} ({{printf "%.1f" .UsedPct}}% used)
+             Available: {{.Available}}
+         Total created: {{.TotalCreated}} ({{printf "%.1f" .CreatedPerSecond}}/s since the server started)
+              Bytes in: {{size .BytesIn}}
+             Bytes out: {{size .BytesOut}}
+              Requests: {{.Requests}}
+{{- if .Clients }}
+
+Client                                     Connections    Active   Applications
+{{- range .Clients }}
+{{printf "%-40s" .Host}}  {{printf "%11d" .Connections}}  {{printf "%8d" .Active}}   {{ range $i, $name := .AppNames }}{{ if $i }}, {{ end }}{{ $name }}{{ else }}-{{ end }}
+{{- end }}
+{{- if gt .TotalClients (len .Clients) }}
+({{.TotalClients}} clients, the {{len .Clients}} with the most connections are listed)
+{{- end }}
+{{- end }}
+{{- if .Warnings }}
+
+  Warnings
+{{- range .Warnings }}
+  {{.}}
+{{- end }}
+{{- end }}
+{{- end }}
+`
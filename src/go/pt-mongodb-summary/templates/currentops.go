@@ -0,0 +1,14 @@
+package templates
+
+const CurrentOps = `
+{{ if . -}}
+# Current Operations #####################################################################################
+Operations running for {{.ThresholdSecs}}s or more: {{.Total}}{{ if gt .Total (len .Ops) }}, the {{len .Ops}} longest are listed{{ end }}
+{{- range .Ops }}
+
+  OpID: {{.OpID}}   Running: {{.SecsRunning}}s{{ if .WaitingForLock }}, waiting for a lock{{ end }}   Op: {{.Op}}   Namespace: {{or .Namespace "-"}}
+  Client: {{or .Client "-"}}{{ if .AppName }} ({{.AppName}}){{ end }}{{ if .PlanSummary }}   Plan: {{.PlanSummary}}{{ end }}
+  Command: {{or .Command "-"}}
+{{- end }}
+{{- end }}
+`
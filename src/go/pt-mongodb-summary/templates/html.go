@@ -0,0 +1,374 @@
+package templates
+
+const HTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 14px; color: #222; margin: 2em auto; max-width: 1200px; padding: 0 1em; }
+  h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+  .generated { color: #777; margin-top: 0; }
+  details { border: 1px solid #ddd; border-radius: 4px; margin: 0.8em 0; padding: 0.4em 0.8em; }
+  summary { font-size: 1.15em; font-weight: 600; cursor: pointer; padding: 0.3em 0; }
+  table { border-collapse: collapse; margin: 0.6em 0; }
+  th, td { border-bottom: 1px solid #eee; padding: 0.3em 0.8em; text-align: left; vertical-align: top; }
+  th { background: #f6f6f6; }
+  td.num { text-align: right; font-variant-numeric: tabular-nums; }
+  code, pre { font-family: Menlo, Consolas, monospace; font-size: 0.9em; }
+  pre { white-space: pre-wrap; word-break: break-all; }
+  .ok { color: #1a7f37; font-weight: 600; }
+  .bad { color: #c62828; font-weight: 600; }
+  .warnings { border-color: #e0a800; background: #fff8e1; }
+  .warnings li, li.warning { color: #8a5300; }
+  .high { color: #c62828; font-weight: 600; }
+  .medium { color: #8a5300; font-weight: 600; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="generated">Generated on {{.Generated}}</p>
+
+{{- if .Warnings }}
+<details class="warnings" open>
+<summary>Warnings ({{len .Warnings}})</summary>
+<ul>
+{{- range .Warnings }}
+  <li><b>{{.Section}}</b>: {{.Message}}</li>
+{{- end }}
+</ul>
+</details>
+{{- end }}
+
+{{- if .Checks }}
+<details open>
+<summary>Health Checks</summary>
+<table>
+<tr><th>Check</th><th>Result</th><th>Problems</th></tr>
+{{- range .Checks }}
+<tr><td><code>{{.Check}}</code></td><td>{{ if .Failed }}<span class="bad">FAILED</span>{{ else }}<span class="ok">OK</span>{{ end }}</td><td>{{ range .Problems }}{{.}}<br>{{ end }}</td></tr>
+{{- end }}
+</table>
+</details>
+{{- end }}
+
+{{- with .Host }}
+<details open>
+<summary>This Host</summary>
+<table>
+<tr><th>Hostname</th><td>{{.Hostname}}</td></tr>
+<tr><th>Version</th><td>{{.Version}}</td></tr>
+<tr><th>Process Type</th><td>{{.NodeType}}</td></tr>
+{{- if .Replicaset }}
+<tr><th>Replica Set</th><td>{{.Replicaset}}</td></tr>
+{{- end }}
+<tr><th>Built On</th><td>{{.OSType}} {{.CPUArch}}</td></tr>
+{{- if .DBPath }}
+<tr><th>Datadir</th><td><code>{{.DBPath}}</code></td></tr>
+{{- end }}
+{{- if .ProcessStarted }}
+<tr><th>Started</th><td>{{.ProcessStarted}}</td></tr>
+{{- end }}
+</table>
+{{- if .CmdlineArgs }}
+<details>
+<summary>Command Line Arguments</summary>
+<pre>{{ join .CmdlineArgs }}</pre>
+</details>
+{{- end }}
+</details>
+{{- end }}
+
+{{- if .Members }}
+<details open>
+<summary>Instances</summary>
+<table>
+<tr><th>Name</th><th>Replica Set</th><th>State</th><th>Health</th><th>Uptime</th><th>Storage Engine</th></tr>
+{{- range .Members }}
+<tr><td>{{.Name}}</td><td>{{.Replicaset}}</td><td>{{.State}}</td>
+{{- if .Error }}<td colspan="3" class="bad">{{.Error}}</td>
+{{- else }}<td>{{ if eq .Health 1.0 }}<span class="ok">up</span>{{ else if .Replicaset }}<span class="bad">down</span>{{ end }}</td><td>{{ seconds .UptimeSeconds }}</td><td>{{.StorageEngine}}</td>
+{{- end }}</tr>
+{{- end }}
+</table>
+</details>
+{{- end }}
+
+{{- with .ReplicaSet }}
+<details open>
+<summary>Replica Set Configuration ({{.Name}})</summary>
+<table>
+<tr><th>Host</th><th>State</th><th>Health</th><th>Votes</th><th>Priority</th><th>Arbiter</th><th>Hidden</th><th>Delay</th></tr>
+{{- range .Members }}
+<tr><td>{{.Host}}</td><td>{{.State}}</td><td>{{ if .Healthy }}<span class="ok">up</span>{{ else }}<span class="bad">down</span>{{ end }}</td><td class="num">{{.Votes}}</td><td class="num">{{.Priority}}</td><td>{{ if .Arbiter }}yes{{ end }}</td><td>{{ if .Hidden }}yes{{ end }}</td><td>{{ if .DelaySeconds }}{{ seconds .DelaySeconds }}{{ end }}</td></tr>
+{{- end }}
+</table>
+<p>Config version {{.ConfigVersion}}, {{.Voters}} voting members, majority {{.Majority}}, default write concern {{ if .DefaultWriteConcern }}<code>{{.DefaultWriteConcern}}</code>{{ else }}unknown{{ end }}.</p>
+{{- if .Warnings }}
+<ul>{{ range .Warnings }}<li class="warning">{{.}}</li>{{ end }}</ul>
+{{- end }}
+</details>
+{{- end }}
+
+{{- with .RunningOps }}
+<details open>
+<summary>Running Ops</summary>
+<table>
+<tr><th>Type</th><th>Min</th><th>Max</th><th>Total</th></tr>
+<tr><td>Insert</td><td class="num">{{.Insert.Min}}</td><td class="num">{{.Insert.Max}}</td><td class="num">{{.Insert.Total}}</td></tr>
+<tr><td>Query</td><td class="num">{{.Query.Min}}</td><td class="num">{{.Query.Max}}</td><td class="num">{{.Query.Total}}</td></tr>
+<tr><td>Update</td><td class="num">{{.Update.Min}}</td><td class="num">{{.Update.Max}}</td><td class="num">{{.Update.Total}}</td></tr>
+<tr><td>Delete</td><td class="num">{{.Delete.Min}}</td><td class="num">{{.Delete.Max}}</td><td class="num">{{.Delete.Total}}</td></tr>
+<tr><td>GetMore</td><td class="num">{{.GetMore.Min}}</td><td class="num">{{.GetMore.Max}}</td><td class="num">{{.GetMore.Total}}</td></tr>
+<tr><td>Command</td><td class="num">{{.Command.Min}}</td><td class="num">{{.Command.Max}}</td><td class="num">{{.Command.Total}}</td></tr>
+</table>
+</details>
+{{- end }}
+
+{{- with .Activity }}
+<details open>
+<summary>Activity</summary>
+<table>
+<tr><th>Per second</th><th>Inserts</th><th>Queries</th><th>Updates</th><th>Deletes</th><th>GetMores</th><th>Commands</th><th>Repl Ops</th><th>Page Faults</th><th>Connections</th></tr>
+{{- with .Overall }}
+<tr><td>Largest</td><td class="num">{{printf "%.1f" .Inserts}}</td><td class="num">{{printf "%.1f" .Queries}}</td><td class="num">{{printf "%.1f" .Updates}}</td><td class="num">{{printf "%.1f" .Deletes}}</td><td class="num">{{printf "%.1f" .GetMores}}</td><td class="num">{{printf "%.1f" .Commands}}</td><td class="num">{{printf "%.1f" .ReplOps}}</td><td class="num">{{printf "%.1f" .PageFaults}}</td><td class="num">{{.Connections}}</td></tr>
+{{- end }}
+</table>
+</details>
+{{- end }}
+
+{{- with .Connections }}
+<details open>
+<summary>Connections</summary>
+<table>
+<tr><th>Current</th><td>{{.Current}} ({{ pct .UsedPct }} used)</td></tr>
+<tr><th>Available</th><td>{{.Available}}</td></tr>
+<tr><th>Total Created</th><td>{{.TotalCreated}} ({{printf "%.1f" .CreatedPerSecond}}/s since the server started)</td></tr>
+<tr><th>Bytes In</th><td>{{ size .BytesIn }}</td></tr>
+<tr><th>Bytes Out</th><td>{{ size .BytesOut }}</td></tr>
+<tr><th>Requests</th><td>{{.Requests}}</td></tr>
+</table>
+{{- if .Clients }}
+<table>
+<tr><th>Client</th><th>Connections</th><th>Active</th><th>Applications</th></tr>
+{{- range .Clients }}
+<tr><td>{{.Host}}</td><td class="num">{{.Connections}}</td><td class="num">{{.Active}}</td><td>{{ join .AppNames }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Warnings }}
+<ul>{{ range .Warnings }}<li class="warning">{{.}}</li>{{ end }}</ul>
+{{- end }}
+</details>
+{{- end }}
+
+{{- with .Transactions }}
+<details open>
+<summary>Transactions and Change Streams</summary>
+<table>
+<tr><th>Open</th><td>{{.CurrentOpen}} ({{.CurrentActive}} active, {{.CurrentInactive}} inactive)</td></tr>
+<tr><th>Committed</th><td>{{.TotalCommitted}}</td></tr>
+<tr><th>Aborted</th><td>{{.TotalAborted}}</td></tr>
+<tr><th>Retried Writes</th><td>{{.RetriedCommands}} commands, {{.RetriedStatements}} statements</td></tr>
+{{- with .ChangeStreams }}
+<tr><th>Change Streams</th><td>{{.}}</td></tr>
+{{- end }}
+</table>
+{{- if .Members }}
+<table>
+<tr><th>Member</th><th>Storage Engine</th><th>Majority Reads</th></tr>
+{{- range .Members }}
+<tr><td>{{.Hostname}}</td>{{ if .Error }}<td colspan="2" class="warning">{{.Error}}</td>{{ else }}<td>{{.StorageEngine}}</td><td>{{ if .CommittedReads }}yes{{ else }}no{{ end }}</td>{{ end }}</tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Warnings }}
+<ul>{{ range .Warnings }}<li class="warning">{{.}}</li>{{ end }}</ul>
+{{- end }}
+</details>
+{{- end }}
+
+{{- with .CurrentOps }}
+<details open>
+<summary>Current Operations ({{.Total}} running for at least {{.ThresholdSeconds}}s)</summary>
+{{- if .Ops }}
+<table>
+<tr><th>OpID</th><th>Op</th><th>Namespace</th><th>Seconds</th><th>Plan</th><th>Client</th><th>Command</th></tr>
+{{- range .Ops }}
+<tr><td>{{.OpID}}</td><td>{{.Op}}</td><td>{{.Namespace}}</td><td class="num">{{.SecondsRunning}}</td><td>{{.PlanSummary}}</td><td>{{.Client}}</td><td><code>{{.Command}}</code></td></tr>
+{{- end }}
+</table>
+{{- end }}
+</details>
+{{- end }}
+
+{{- with .WiredTiger }}
+<details open>
+<summary>WiredTiger</summary>
+<table>
+<tr><th>Cache Size</th><td>{{ size .CacheMaxBytes }}</td></tr>
+<tr><th>Cache Used</th><td>{{ size .CacheUsedBytes }} ({{ pct .CacheUsedPct }})</td></tr>
+<tr><th>Cache Dirty</th><td>{{ size .CacheDirtyBytes }} ({{ pct .CacheDirtyPct }})</td></tr>
+<tr><th>Pages Evicted by Application Threads</th><td>{{.PagesEvictedByAppThreads}}</td></tr>
+<tr><th>Last Checkpoint</th><td>{{ seconds .LastCheckpointSeconds }}</td></tr>
+<tr><th>Read Tickets</th><td>{{.ReadTicketsOut}} out, {{.ReadTicketsAvailable}} available</td></tr>
+<tr><th>Write Tickets</th><td>{{.WriteTicketsOut}} out, {{.WriteTicketsAvailable}} available</td></tr>
+</table>
+{{- if .Warnings }}
+<ul>{{ range .Warnings }}<li class="warning">{{.}}</li>{{ end }}</ul>
+{{- end }}
+</details>
+{{- end }}
+
+{{- with .Security }}
+<details open>
+<summary>Security</summary>
+<table>
+<tr><th>Users</th><td>{{.Users}}</td></tr>
+<tr><th>Roles</th><td>{{.Roles}}</td></tr>
+<tr><th>Auth</th><td>{{.Auth}}</td></tr>
+<tr><th>SSL</th><td>{{.SSL}}</td></tr>
+<tr><th>Port</th><td>{{.Port}}</td></tr>
+<tr><th>Bind IP</th><td>{{.BindIP}}</td></tr>
+</table>
+{{- if .Warnings }}
+<ul>{{ range .Warnings }}<li class="warning">{{.}}</li>{{ end }}</ul>
+{{- end }}
+</details>
+{{- end }}
+
+{{- with .SecurityAudit }}
+<details open>
+<summary>Security Audit</summary>
+{{- if .Findings }}
+<table>
+<tr><th>Severity</th><th>Finding</th></tr>
+{{- range .Findings }}
+<tr><td class="{{ lower .Severity }}">{{.Severity}}</td><td>{{.Message}}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+<details>
+<summary>Users ({{len .Users}})</summary>
+<table>
+<tr><th>User</th><th>Database</th><th>Roles</th><th>Mechanisms</th></tr>
+{{- range .Users }}
+<tr><td>{{.User}}</td><td>{{.DB}}</td><td>{{ join .Roles }}</td><td>{{ join .Mechanisms }}</td></tr>
+{{- end }}
+</table>
+</details>
+</details>
+{{- end }}
+
+{{- if .OplogWindows }}
+<details open>
+<summary>Oplog Window</summary>
+<table>
+<tr><th>Host</th><th>Replica Set</th><th>State</th><th>Window</th><th>Used/Size MB</th><th>Churn MB/h</th><th>Lag</th><th>Can Be Down For</th></tr>
+{{- range .OplogWindows }}
+<tr><td>{{.Hostname}}</td>
+{{- if .Error }}<td colspan="7" class="bad">{{.Error}}</td>
+{{- else }}<td>{{.Replicaset}}</td><td>{{.State}}</td><td>{{ seconds .WindowSeconds }}</td><td class="num">{{.UsedMB}}/{{.MaxSizeMB}}</td><td class="num">{{printf "%.1f" .ChurnMBPerHour}}</td><td>{{ if .LagSeconds }}{{ seconds (deref .LagSeconds) }}{{ end }}</td><td>{{ if .HeadroomSeconds }}{{ seconds (deref .HeadroomSeconds) }}{{ end }}</td>
+{{- end }}</tr>
+{{- end }}
+</table>
+</details>
+{{- end }}
+
+{{- if .ServerLog }}
+<details>
+<summary>Recent Log Warnings and Errors</summary>
+{{- range .ServerLog }}
+<h4>{{.Hostname}}</h4>
+{{- if .Error }}
+<p class="bad">{{.Error}}</p>
+{{- else if .Recent }}
+<table>
+<tr><th>Severity</th><th>Component</th><th>Count</th></tr>
+{{- range .Categories }}
+<tr><td>{{.Severity}}</td><td>{{.Component}}</td><td class="num">{{.Count}}</td></tr>
+{{- end }}
+</table>
+<table>
+<tr><th>Time</th><th>Severity</th><th>Component</th><th>Message</th></tr>
+{{- range .Recent }}
+<tr><td>{{.Time}}</td><td>{{.Severity}}</td><td>{{.Component}}</td><td><code>{{.Message}}</code></td></tr>
+{{- end }}
+</table>
+{{- else }}
+<p>No warnings or errors in the log kept in memory.</p>
+{{- end }}
+{{- end }}
+</details>
+{{- end }}
+
+{{- with .Sharding }}
+<details open>
+<summary>Sharded Cluster</summary>
+<p>{{.TotalChunks}} chunks, balancer {{.BalancerMode}}{{ if .BalancerRunning }}, running{{ end }}.</p>
+<table>
+<tr><th>Shard</th><th>Replica Set</th><th>Version</th><th>Chunks</th><th>Hosts</th></tr>
+{{- with .ConfigServers }}
+<tr><td>config servers</td><td>{{.Replicaset}}</td><td>{{.Version}}</td><td></td><td>{{ if .Error }}<span class="bad">{{.Error}}</span>{{ else }}{{ join .Hosts }}{{ end }}</td></tr>
+{{- end }}
+{{- range .Shards }}
+<tr><td>{{.Name}}</td><td>{{.Replicaset}}</td><td>{{.Version}}</td><td class="num">{{.Chunks}}</td><td>{{ if .Error }}<span class="bad">{{.Error}}</span>{{ else }}{{ join .Hosts }}{{ end }}</td></tr>
+{{- end }}
+</table>
+</details>
+{{- end }}
+
+{{- with .Chunks }}
+<details open>
+<summary>Chunk Distribution</summary>
+<p>{{.BalancerRounds}} balancer rounds, {{.ChunksMoved}} chunks moved, {{.FailedRounds}} failed rounds over the last 10 days.</p>
+{{- if .Collections }}
+<table>
+<tr><th>Namespace</th><th>Chunks</th><th>Imbalance</th><th>Threshold</th></tr>
+{{- range .Collections }}
+<tr><td>{{.Namespace}}</td><td class="num">{{.Chunks}}</td><td class="num{{ if .Imbalanced }} bad{{ end }}">{{.Imbalance}}</td><td class="num">{{.Threshold}}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .BalancerErrors }}
+<ul>{{ range .BalancerErrors }}<li class="warning">{{.Time}}: {{.Message}}</li>{{ end }}</ul>
+{{- end }}
+</details>
+{{- end }}
+
+{{- with .Inventory }}
+<details>
+<summary>Collections and Indexes</summary>
+<table>
+<tr><th>Database</th><th>Size on Disk</th><th>Collections</th></tr>
+{{- range .Databases }}
+<tr><td>{{.Name}}</td><td class="num">{{ size .SizeOnDiskBytes }}</td><td class="num">{{.Collections}}</td></tr>
+{{- end }}
+</table>
+<table>
+<tr><th>Collection</th><th>Documents</th><th>Size</th><th>Indexes</th><th>Index Size</th></tr>
+{{- range .Collections }}
+<tr><td>{{.Namespace}}</td><td class="num">{{.Documents}}</td><td class="num">{{ size .SizeBytes }}</td><td class="num">{{.Indexes}}</td><td class="num">{{ size .IndexSizeBytes }}</td></tr>
+{{- end }}
+</table>
+{{- if .UnusedIndexes }}
+<p>Unused indexes:</p>
+<ul>{{ range .UnusedIndexes }}<li>{{.Namespace}} <code>{{.Name}}</code></li>{{ end }}</ul>
+{{- end }}
+</details>
+{{- end }}
+
+{{- if .Skipped }}
+<details open>
+<summary>Skipped Sections</summary>
+<p>Insufficient privileges, grant the <code>clusterMonitor</code> role to get them:</p>
+<table>
+{{- range .Skipped }}
+<tr><th>{{.Section}}</th><td>{{.Reason}}</td></tr>
+{{- end }}
+</table>
+</details>
+{{- end }}
+</body>
+</html>
+`
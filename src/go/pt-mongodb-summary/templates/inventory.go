@@ -0,0 +1,33 @@
+package templates
+
+const Inventory = `
+{{ if . -}}
+# Collections and Indexes ################################################################################
+{{- if .Truncated }}
+  Only the first {{.MaxCollections}} collections of the largest databases were read, see --collstats-max-collections
+{{- end }}
+  Database                       Size on disk     Collections
+{{- range .Databases }}
+  {{printf "%-30s" .Name}} {{printf "%-16s" (size .SizeOnDisk)}} {{.Collections}}
+{{- end }}
+
+  Largest collections            Documents    Size             Storage size     Indexes  Index size
+{{- range .Collections }}
+  {{printf "%-30s" .Namespace}} {{printf "%-12d" .Count}} {{printf "%-16s" (size .Size)}} {{printf "%-16s" (size .StorageSize)}} {{printf "%-8d" .Indexes}} {{size .IndexSize}}
+{{- end }}
+{{- if .UnusedIndexes }}
+
+  Unused indexes                 Index                          No access since
+{{- range .UnusedIndexes }}
+  {{printf "%-30s" .Namespace}} {{printf "%-30s" .Name}} {{.Since.Format "2006-01-02 15:04:05 MST"}}
+{{- end }}
+{{- end }}
+{{- if .CappedCollections }}
+
+  Capped collections             Size             Max size
+{{- range .CappedCollections }}
+  {{printf "%-30s" .Namespace}} {{printf "%-16s" (size .Size)}} {{size .MaxSize}}
+{{- end }}
+{{- end }}
+{{- end }}
+`
@@ -0,0 +1,15 @@
+package templates
+
+const OplogWindows = `
+{{ if . -}}
+# Oplog Window ###########################################################################################
+  Host                           ReplSet     State         Window          Used/Size MB     Churn MB/h   Lag          Can be down for
+{{- range . }}
+{{- if .Error }}
+  {{printf "%-30s" .Hostname}} {{.Error}}
+{{- else }}
+  {{printf "%-30s" .Hostname}} {{printf "%-11s" .Replicaset}} {{printf "%-13s" .State}} {{printf "%-15s" .Window.String}} {{printf "%-16s" (printf "%d/%d" .UsedMB .MaxSizeMB)}} {{printf "%-12.1f" .ChurnMBPerHour}} {{ if eq .State "SECONDARY" }}{{printf "%-12s" .Lag.String}} {{.Headroom}}{{ else }}-            -{{ end }}
+{{- end }}
+{{- end }}
+{{- end }}
+`
@@ -0,0 +1,22 @@
+package templates
+
+const ReplicaSetConfig = `
+{{ if . -}}
+# Replica Set Configuration ({{.Name}}) ##################################################################
+  Host                           State         Health  Votes  Priority  Role
+{{- range .Members }}
+  {{printf "%-30s" .Host}} {{printf "%-13s" .State}} {{ if .Healthy }}up      {{ else }}down    {{ end }}{{printf "%-6d" .Votes}} {{printf "%-9g" .Priority}} {{.Role}}
+{{- end }}
+
+       Config version: {{.ConfigVersion}}
+       Voting members: {{.Voters}}, majority: {{.Majority}}
+Default write concern: {{ if .DefaultWriteConcern }}{{.DefaultWriteConcern}}{{ else }}unknown{{ end }}
+{{- if .Warnings }}
+
+  Warnings
+{{- range .Warnings }}
+  {{.}}
+{{- end }}
+{{- end }}
+{{- end }}
+`
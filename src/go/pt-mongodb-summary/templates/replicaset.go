@@ -5,7 +5,7 @@ const Replicas = `
   PID    Host                         Type                      ReplSet                   Engine
 {{- if . -}}
 {{- range . }}
-{{printf "% 6d" .ID}} {{printf "%-30s" .Name}} {{printf "%-25s" .StateStr}} {{ if .Set }}{{printf "%-10s" .Set }}{{else}}-         {{end}}  {{printf "%20s" .StorageEngine.Name -}}
+{{printf "% 6d" .ID}} {{printf "%-30s" .Name}} {{printf "%-25s" .StateStr}} {{ if .Set }}{{printf "%-10s" .Set }}{{else}}-         {{end}}  {{printf "%20s" .StorageEngine.Name -}}{{ if .Error }}  {{.Error}}{{ end -}}
 {{end}}
 {{else}}
                                           no replica sets found
@@ -0,0 +1,31 @@
+package templates
+
+const SecurityAudit = `
+{{ if . -}}
+# Security Audit #########################################################################################
+       Authorization: {{ if .Authorization }}enabled{{ else }}disabled{{ end }}
+            Auditing: {{ or .Auditing "disabled" }}
+            TLS mode: {{ or .TLSMode "disabled" }}
+             Bind IP: {{ if .BindIPAll }}all interfaces{{ else }}{{ or .BindIP "-" }}{{ end }}
+     Auth mechanisms: {{ range $i, $m := .AuthMechanisms }}{{ if $i }}, {{ end }}{{ $m }}{{ end }}
+
+  User                           Roles                                              Mechanisms
+{{- range .Users }}
+  {{printf "%-30s" (printf "%s@%s" .User .DB)}} {{printf "%-50s" (join .Roles)}} {{join .Mechanisms}}
+{{- end }}
+{{- if .Roles }}
+
+  Custom role                    Inherited roles                                    Privileges
+{{- range .Roles }}
+  {{printf "%-30s" (printf "%s@%s" .Role .DB)}} {{printf "%-50s" (join .Roles)}} {{.Privileges}}
+{{- end }}
+{{- end }}
+{{- if .Findings }}
+
+  Findings
+{{- range .Findings }}
+  {{printf "%-6s" .Severity}} {{.Message}}
+{{- end }}
+{{- end }}
+{{- end }}
+`
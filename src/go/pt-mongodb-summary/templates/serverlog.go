@@ -0,0 +1,25 @@
+package templates
+
+const ServerLog = `
+{{ if . -}}
+# Recent Log Warnings and Errors #########################################################################
+{{- range .Members }}
+  {{.Hostname}}
+{{- if .Error }}
+    {{.Error}}
+{{- else if not .Categories }}
+    No warnings or errors in the log kept in memory
+{{- else }}
+    Severity  Component         Count
+{{- range .Categories }}
+    {{printf "%-9s" .Severity}} {{printf "%-17s" .Component}} {{.Count}}
+{{- end }}
+
+    Most recent
+{{- range .Recent }}
+    {{.Time.Format "2006-01-02 15:04:05"}} {{.Severity}} {{printf "%-17s" .Component}} {{.Message}}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+`
@@ -0,0 +1,31 @@
+package templates
+
+const Sharding = `
+{{- define "shard" }}
+# {{ if eq .Role "config" }}Config Servers{{ else }}Shard {{.Name}}{{ end }}{{ if .ReplicasetName }} ({{.ReplicasetName}}){{ end }} ########################################
+{{- if .Error }}
+                Error: {{.Error}}
+{{- else }}
+              Version: {{.Version}}
+{{- if ne .Role "config" }}
+               Chunks: {{.Chunks}}
+{{- end }}
+  Host                           Type                      Engine
+{{- range .Members }}
+  {{printf "%-30s" .Name}} {{printf "%-25s" .StateStr}} {{.StorageEngine.Name}}{{ if .Error }} {{.Error}}{{ end }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{ if . -}}
+# Sharded Cluster ########################################################################################
+               Shards: {{len .Shards}}
+               Chunks: {{.TotalChunks}}
+             Balancer: {{.BalancerMode}}{{ if .BalancerRunning }} (balancing round in progress){{ end }}
+{{- with .ConfigServers }}
+{{ template "shard" . }}
+{{- end }}
+{{- range .Shards }}
+{{ template "shard" . }}
+{{- end }}
+{{- end }}
+`
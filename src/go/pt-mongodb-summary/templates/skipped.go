@@ -0,0 +1,11 @@
+package templates
+
+const SkippedSections = `
+{{ if . -}}
+# Skipped Sections #######################################################################################
+  Insufficient privileges, grant the clusterMonitor role to get them:
+{{- range . }}
+  {{printf "%-26s" .Section}} {{.Reason}}
+{{- end }}
+{{- end }}
+`
@@ -0,0 +1,29 @@
+package templates
+
+const Transactions = `
+{{ if . -}}
+# Transactions and Change Streams ########################################################################
+                      Open: {{.CurrentOpen}} ({{.CurrentActive}} active, {{.CurrentInactive}} inactive)
+                   Started: {{.TotalStarted}}
+                 Committed: {{.TotalCommitted}}
+                   Aborted: {{.TotalAborted}}
+                  Prepared: {{.TotalPrepared}}
+  Retried writes, commands: {{.RetriedCommands}}
+Retried writes, statements: {{.RetriedStatements}}
+            Change streams: {{ if .ChangeStreamsCounted }}{{.ChangeStreams}}{{ else }}not counted before MongoDB 4.2{{ end }}
+{{- if .Members }}
+
+Member                                     Storage Engine    Majority Reads
+{{- range .Members }}
+{{printf "%-40s" .Hostname}}  {{ if .Error }}{{.Error}}{{ else }}{{printf "%-16s" .StorageEngine}}  {{ if .CommittedReads }}yes{{ else }}no{{ end }}{{ end }}
+{{- end }}
+{{- end }}
+{{- if .Warnings }}
+
+  Warnings
+{{- range .Warnings }}
+  {{.}}
+{{- end }}
+{{- end }}
+{{- end }}
+`
@@ -0,0 +1,31 @@
+package templates
+
+const WiredTiger = `
+{{ if . -}}
+# WiredTiger #############################################################################################
+                Cache size: {{size .CacheMaxBytes}}
+            Bytes in cache: {{size .CacheUsedBytes}} ({{printf "%.1f" .CacheUsedPct}}%)
+               Dirty bytes: {{size .CacheDirtyBytes}} ({{printf "%.1f" .CacheDirtyPct}}%)
+     Pages read into cache: {{.PagesReadIntoCache}}
+  Pages written from cache: {{.PagesWrittenFromCache}}
+    Modified pages evicted: {{.ModifiedPagesEvicted}}
+  Unmodified pages evicted: {{.UnmodifiedPagesEvicted}}
+    Evicted by app threads: {{.PagesEvictedByAppThreads}}
+
+               Checkpoints: {{.Checkpoints}}{{ if .CheckpointRunning }} (one running){{ end }}
+               Most recent: {{.LastCheckpointDuration}}
+                       Min: {{.MinCheckpointDuration}}
+                       Max: {{.MaxCheckpointDuration}}
+                   Average: {{.AvgCheckpointDuration}}
+
+              Read tickets: {{.ReadTicketsOut}} in use, {{.ReadTicketsAvailable}} available
+             Write tickets: {{.WriteTicketsOut}} in use, {{.WriteTicketsAvailable}} available
+{{- if .Warnings }}
+
+  Warnings
+{{- range .Warnings }}
+  {{.}}
+{{- end }}
+{{- end }}
+{{- end }}
+`
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-toolkit/src/go/mongolib/proto"
+	"github.com/percona/percona-toolkit/src/go/mongolib/util"
+	"github.com/percona/percona-toolkit/src/go/pt-mongodb-summary/compat"
+)
+
+// mmapv1 has no document level locking, needed by retryable writes and transactions
+const engineMMAPv1 = "mmapv1"
+
+// transactions is the usage of transactions, retryable writes and change streams on the server, and whether
+// the members of the deployment all support them. ChangeStreams is only counted from MongoDB 4.2.
+type transactions struct {
+	CurrentActive        int64
+	CurrentInactive      int64
+	CurrentOpen          int64
+	TotalStarted         int64
+	TotalCommitted       int64
+	TotalAborted         int64
+	TotalPrepared        int64
+	RetriedCommands      int64
+	RetriedStatements    int64
+	ChangeStreams        int
+	ChangeStreamsCounted bool
+	Members              []memberSessionSupport
+	Warnings             []string
+}
+
+// memberSessionSupport is what a member supports of the features sessions rely on: majority committed
+// reads for causal consistency and change streams, and a storage engine able to run retryable writes.
+type memberSessionSupport struct {
+	Hostname       string
+	StorageEngine  string
+	CommittedReads bool
+	Error          string
+}
+
+type txnServerStatus struct {
+	Transactions *struct {
+		RetriedCommandsCount   int64 `bson:"retriedCommandsCount"`
+		RetriedStatementsCount int64 `bson:"retriedStatementsCount"`
+		CurrentActive          int64 `bson:"currentActive"`
+		CurrentInactive        int64 `bson:"currentInactive"`
+		CurrentOpen            int64 `bson:"currentOpen"`
+		TotalStarted           int64 `bson:"totalStarted"`
+		TotalCommitted         int64 `bson:"totalCommitted"`
+		TotalAborted           int64 `bson:"totalAborted"`
+		TotalPrepared          int64 `bson:"totalPrepared"`
+	} `bson:"transactions"`
+	StorageEngine proto.StorageEngine `bson:"storageEngine"`
+}
+
+// getTransactions returns nil before MongoDB 3.6, without the transactions section of serverStatus.
+// The members are read at the same time, each one with its own timeout, arbiters excepted since they
+// have no users to authenticate with.
+func getTransactions(ctx context.Context, client *mongo.Client, srv *compat.Server, mongos bool, hostnames []string,
+	arbiters map[string]bool, co *options.ClientOptions, hostTimeout time.Duration,
+) (*transactions, error) {
+	ss := txnServerStatus{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"serverStatus": 1}).Decode(&ss); err != nil {
+		return nil, errors.Wrap(err, "cannot get server status")
+	}
+
+	if ss.Transactions == nil {
+		return nil, nil
+	}
+
+	t := &transactions{
+		CurrentActive:     ss.Transactions.CurrentActive,
+		CurrentInactive:   ss.Transactions.CurrentInactive,
+		CurrentOpen:       ss.Transactions.CurrentOpen,
+		TotalStarted:      ss.Transactions.TotalStarted,
+		TotalCommitted:    ss.Transactions.TotalCommitted,
+		TotalAborted:      ss.Transactions.TotalAborted,
+		TotalPrepared:     ss.Transactions.TotalPrepared,
+		RetriedCommands:   ss.Transactions.RetriedCommandsCount,
+		RetriedStatements: ss.Transactions.RetriedStatementsCount,
+		Members:           []memberSessionSupport{},
+	}
+
+	var err error
+	t.ChangeStreams, t.ChangeStreamsCounted, err = srv.ChangeStreamCursors(ctx, client, mongos)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot count the change streams")
+	}
+
+	t.Members = getMembersSessionSupport(ctx, hostnames, arbiters, co, hostTimeout)
+	t.Warnings = transactionsWarnings(t)
+
+	return t, nil
+}
+
+func getMembersSessionSupport(ctx context.Context, hostnames []string, arbiters map[string]bool,
+	co *options.ClientOptions, hostTimeout time.Duration,
+) []memberSessionSupport {
+	members := []memberSessionSupport{}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, hostname := range hostnames {
+		if arbiters[hostname] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(hostname string) {
+			defer wg.Done()
+
+			hostCtx, cancel := context.WithTimeout(ctx, hostTimeout)
+			defer cancel()
+
+			m := memberSessionSupport{Hostname: hostname}
+			engine, err := getHostStorageEngine(hostCtx, co, hostname)
+			if err != nil {
+				m.Error = err.Error()
+			} else {
+				m.StorageEngine, m.CommittedReads = engine.Name, engine.SupportsCommittedReads
+			}
+
+			mu.Lock()
+			members = append(members, m)
+			mu.Unlock()
+		}(hostname)
+	}
+	wg.Wait()
+
+	sort.Slice(members, func(i, j int) bool { return members[i].Hostname < members[j].Hostname })
+
+	return members
+}
+
+func getHostStorageEngine(ctx context.Context, co *options.ClientOptions, hostname string) (proto.StorageEngine, error) {
+	client, err := util.GetClientForHost(co, hostname)
+	if err != nil {
+		return proto.StorageEngine{}, errors.Wrap(err, "cannot get a client")
+	}
+
+	if err := client.Connect(ctx); err != nil {
+		return proto.StorageEngine{}, errors.Wrapf(err, "cannot connect to %s", hostname)
+	}
+	defer client.Disconnect(context.Background()) // nolint
+
+	ss := txnServerStatus{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"serverStatus": 1}).Decode(&ss); err != nil {
+		return proto.StorageEngine{}, errors.Wrap(err, "cannot get server status")
+	}
+
+	return ss.StorageEngine, nil
+}
+
+// transactionsWarnings flags the members that do not support what the others do: a session can then
+// behave differently depending on the member it reads from or the primary it writes to.
+func transactionsWarnings(t *transactions) []string {
+	warnings := []string{}
+
+	mmapv1, noCommittedReads, committedReads := []string{}, []string{}, 0
+	for _, m := range t.Members {
+		if m.Error != "" {
+			continue
+		}
+		if m.StorageEngine == engineMMAPv1 {
+			mmapv1 = append(mmapv1, m.Hostname)
+		}
+		if m.CommittedReads {
+			committedReads++
+		} else {
+			noCommittedReads = append(noCommittedReads, m.Hostname)
+		}
+	}
+
+	if len(mmapv1) > 0 {
+		warnings = append(warnings, fmt.Sprintf("Retryable writes and transactions fail on the %s storage engine of %s",
+			engineMMAPv1, strings.Join(mmapv1, ", ")))
+	}
+
+	if committedReads > 0 && len(noCommittedReads) > 0 {
+		warnings = append(warnings, fmt.Sprintf("Majority read concern is not supported by %s, unlike the other members: "+
+			"causally consistent reads and change streams depend on the member they run on", strings.Join(noCommittedReads, ", ")))
+	}
+
+	if t.TotalAborted > t.TotalCommitted {
+		warnings = append(warnings, fmt.Sprintf("More transactions were aborted (%d) than committed (%d) since the server started",
+			t.TotalAborted, t.TotalCommitted))
+	}
+
+	return warnings
+}
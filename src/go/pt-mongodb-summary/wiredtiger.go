@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// WiredTiger eviction_target and eviction_dirty_target defaults: background eviction starts above them
+	cacheUsedTarget  = 80
+	cacheDirtyTarget = 5
+	// above eviction_trigger, application threads have to evict pages themselves
+	cacheUsedTrigger = 95
+	// checkpoints run every 60 seconds
+	checkpointInterval = 60 * time.Second
+)
+
+// wiredTigerInfo is the state of the WiredTiger cache and checkpoints, the usual suspects when
+// a MongoDB server is slow.
+type wiredTigerInfo struct {
+	CacheMaxBytes            int64
+	CacheUsedBytes           int64
+	CacheDirtyBytes          int64
+	PagesReadIntoCache       int64
+	PagesWrittenFromCache    int64
+	ModifiedPagesEvicted     int64
+	UnmodifiedPagesEvicted   int64
+	PagesEvictedByAppThreads int64
+	Checkpoints              int64
+	CheckpointRunning        bool
+	LastCheckpointDuration   time.Duration
+	MaxCheckpointDuration    time.Duration
+	MinCheckpointDuration    time.Duration
+	TotalCheckpointDuration  time.Duration
+	ReadTicketsOut           int64
+	ReadTicketsAvailable     int64
+	WriteTicketsOut          int64
+	WriteTicketsAvailable    int64
+	Warnings                 []string
+}
+
+type wtTickets struct {
+	Out       int64 `bson:"out"`
+	Available int64 `bson:"available"`
+}
+
+type wtServerStatus struct {
+	WiredTiger *struct {
+		Cache struct {
+			MaxBytes               int64 `bson:"maximum bytes configured"`
+			UsedBytes              int64 `bson:"bytes currently in the cache"`
+			DirtyBytes             int64 `bson:"tracked dirty bytes in the cache"`
+			PagesRead              int64 `bson:"pages read into cache"`
+			PagesWritten           int64 `bson:"pages written from cache"`
+			ModifiedPagesEvicted   int64 `bson:"modified pages evicted"`
+			UnmodifiedPagesEvicted int64 `bson:"unmodified pages evicted"`
+			AppThreadsEvicted      int64 `bson:"pages evicted by application threads"`
+		} `bson:"cache"`
+		Transaction wtCheckpoints `bson:"transaction"`
+		// WiredTiger 10 (MongoDB 5.0+) moved the checkpoint statistics to their own section
+		Checkpoint *wtCheckpoints `bson:"checkpoint"`
+		// MongoDB 7.0 moved the tickets to queues.execution
+		ConcurrentTransactions *struct {
+			Read  wtTickets `bson:"read"`
+			Write wtTickets `bson:"write"`
+		} `bson:"concurrentTransactions"`
+	} `bson:"wiredTiger"`
+	Queues *struct {
+		Execution *struct {
+			Read  wtTickets `bson:"read"`
+			Write wtTickets `bson:"write"`
+		} `bson:"execution"`
+	} `bson:"queues"`
+}
+
+// wtCheckpoints has both the names of the transaction section and the ones of the checkpoint section.
+type wtCheckpoints struct {
+	Count              int64 `bson:"transaction checkpoints"`
+	Running            int64 `bson:"transaction checkpoint currently running"`
+	MostRecentMsecs    int64 `bson:"transaction checkpoint most recent time (msecs)"`
+	MaxMsecs           int64 `bson:"transaction checkpoint max time (msecs)"`
+	MinMsecs           int64 `bson:"transaction checkpoint min time (msecs)"`
+	TotalMsecs         int64 `bson:"transaction checkpoint total time (msecs)"`
+	CountV10           int64 `bson:"number of checkpoints"`
+	RunningV10         int64 `bson:"currently running"`
+	MostRecentMsecsV10 int64 `bson:"most recent time (msecs)"`
+	MaxMsecsV10        int64 `bson:"max time (msecs)"`
+	MinMsecsV10        int64 `bson:"min time (msecs)"`
+	TotalMsecsV10      int64 `bson:"total time (msecs)"`
+}
+
+// getWiredTigerInfo returns nil when the server does not use WiredTiger, like mongos.
+func getWiredTigerInfo(ctx context.Context, client *mongo.Client) (*wiredTigerInfo, error) {
+	ss := wtServerStatus{}
+	if err := client.Database("admin").RunCommand(ctx, primitive.M{"serverStatus": 1}).Decode(&ss); err != nil {
+		return nil, errors.Wrap(err, "cannot get server status")
+	}
+
+	return newWiredTigerInfo(ss), nil
+}
+
+func newWiredTigerInfo(ss wtServerStatus) *wiredTigerInfo {
+	if ss.WiredTiger == nil {
+		return nil
+	}
+
+	wt := ss.WiredTiger
+	wti := &wiredTigerInfo{
+		CacheMaxBytes:            wt.Cache.MaxBytes,
+		CacheUsedBytes:           wt.Cache.UsedBytes,
+		CacheDirtyBytes:          wt.Cache.DirtyBytes,
+		PagesReadIntoCache:       wt.Cache.PagesRead,
+		PagesWrittenFromCache:    wt.Cache.PagesWritten,
+		ModifiedPagesEvicted:     wt.Cache.ModifiedPagesEvicted,
+		UnmodifiedPagesEvicted:   wt.Cache.UnmodifiedPagesEvicted,
+		PagesEvictedByAppThreads: wt.Cache.AppThreadsEvicted,
+	}
+
+	cp := wt.Transaction
+	if wt.Checkpoint != nil {
+		cp = *wt.Checkpoint
+		cp.Count, cp.Running, cp.MostRecentMsecs = cp.CountV10, cp.RunningV10, cp.MostRecentMsecsV10
+		cp.MaxMsecs, cp.MinMsecs, cp.TotalMsecs = cp.MaxMsecsV10, cp.MinMsecsV10, cp.TotalMsecsV10
+	}
+
+	wti.Checkpoints = cp.Count
+	wti.CheckpointRunning = cp.Running != 0
+	wti.LastCheckpointDuration = time.Duration(cp.MostRecentMsecs) * time.Millisecond
+	wti.MaxCheckpointDuration = time.Duration(cp.MaxMsecs) * time.Millisecond
+	wti.MinCheckpointDuration = time.Duration(cp.MinMsecs) * time.Millisecond
+	wti.TotalCheckpointDuration = time.Duration(cp.TotalMsecs) * time.Millisecond
+
+	switch {
+	case ss.Queues != nil && ss.Queues.Execution != nil:
+		wti.ReadTicketsOut, wti.ReadTicketsAvailable = ss.Queues.Execution.Read.Out, ss.Queues.Execution.Read.Available
+		wti.WriteTicketsOut, wti.WriteTicketsAvailable = ss.Queues.Execution.Write.Out, ss.Queues.Execution.Write.Available
+	case wt.ConcurrentTransactions != nil:
+		wti.ReadTicketsOut, wti.ReadTicketsAvailable = wt.ConcurrentTransactions.Read.Out, wt.ConcurrentTransactions.Read.Available
+		wti.WriteTicketsOut, wti.WriteTicketsAvailable = wt.ConcurrentTransactions.Write.Out, wt.ConcurrentTransactions.Write.Available
+	}
+
+	wti.Warnings = wiredTigerWarnings(wti)
+
+	return wti
+}
+
+func wiredTigerWarnings(wti *wiredTigerInfo) []string {
+	warnings := []string{}
+
+	if used := wti.CacheUsedPct(); used >= cacheUsedTrigger {
+		warnings = append(warnings, fmt.Sprintf("The cache is %.1f%% full: application threads are evicting pages, slowing down queries", used))
+	} else if used >= cacheUsedTarget {
+		warnings = append(warnings, fmt.Sprintf("The cache is %.1f%% full, above the %d%% eviction target", used, cacheUsedTarget))
+	}
+
+	if dirty := wti.CacheDirtyPct(); dirty >= cacheDirtyTarget {
+		warnings = append(warnings, fmt.Sprintf("%.1f%% of the cache is dirty, above the %d%% eviction target: writes are faster than checkpoints", dirty, cacheDirtyTarget))
+	}
+
+	if wti.PagesEvictedByAppThreads > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d pages were evicted by application threads since the server started", wti.PagesEvictedByAppThreads))
+	}
+
+	if wti.LastCheckpointDuration > checkpointInterval {
+		warnings = append(warnings, fmt.Sprintf("The last checkpoint took %s, longer than the %s interval between checkpoints",
+			wti.LastCheckpointDuration, checkpointInterval))
+	}
+
+	if wti.ReadTicketsOut > 0 && wti.ReadTicketsAvailable == 0 {
+		warnings = append(warnings, "No read tickets available: read operations are queued")
+	}
+
+	if wti.WriteTicketsOut > 0 && wti.WriteTicketsAvailable == 0 {
+		warnings = append(warnings, "No write tickets available: write operations are queued")
+	}
+
+	return warnings
+}
+
+// CacheUsedPct is how full the cache is, compared to its configured size.
+func (wti *wiredTigerInfo) CacheUsedPct() float64 {
+	return percent(wti.CacheUsedBytes, wti.CacheMaxBytes)
+}
+
+// CacheDirtyPct is how much of the cache is dirty, compared to its configured size.
+func (wti *wiredTigerInfo) CacheDirtyPct() float64 {
+	return percent(wti.CacheDirtyBytes, wti.CacheMaxBytes)
+}
+
+// AvgCheckpointDuration is 0 until the first checkpoint.
+func (wti *wiredTigerInfo) AvgCheckpointDuration() time.Duration {
+	if wti.Checkpoints == 0 {
+		return 0
+	}
+
+	return (wti.TotalCheckpointDuration / time.Duration(wti.Checkpoints)).Round(time.Millisecond)
+}
+
+func percent(value, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(value) * 100 / float64(total)
+}
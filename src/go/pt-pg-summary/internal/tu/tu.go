@@ -17,6 +17,9 @@ const (
 	ipv4PG10Port = "6433"
 	ipv4PG11Port = "6434"
 	ipv4PG12Port = "6435"
+	ipv4PG15Port = "6436"
+	ipv4PG16Port = "6437"
+	ipv4PG17Port = "6438"
 
 	ipv6PG9Port  = "6432"
 	ipv6PG10Port = "6432"
@@ -43,6 +46,9 @@ var (
 	IPv4PG10Port = getVar("PG_IPV4_10_PORT", ipv4PG10Port)
 	IPv4PG11Port = getVar("PG_IPV4_11_PORT", ipv4PG11Port)
 	IPv4PG12Port = getVar("PG_IPV4_12_PORT", ipv4PG12Port)
+	IPv4PG15Port = getVar("PG_IPV4_15_PORT", ipv4PG15Port)
+	IPv4PG16Port = getVar("PG_IPV4_16_PORT", ipv4PG16Port)
+	IPv4PG17Port = getVar("PG_IPV4_17_PORT", ipv4PG17Port)
 
 	IPv6PG9Port  = getVar("PG_IPV6_9_PORT", ipv6PG9Port)
 	IPv6PG10Port = getVar("PG_IPV6_10_PORT", ipv6PG10Port)
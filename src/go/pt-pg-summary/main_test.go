@@ -26,6 +26,9 @@ var tests []Test = []Test{
 	{"IPv4PG10", tu.IPv4Host, tu.IPv4PG10Port, tu.Username, tu.Password},
 	{"IPv4PG11", tu.IPv4Host, tu.IPv4PG11Port, tu.Username, tu.Password},
 	{"IPv4PG12", tu.IPv4Host, tu.IPv4PG12Port, tu.Username, tu.Password},
+	{"IPv4PG15", tu.IPv4Host, tu.IPv4PG15Port, tu.Username, tu.Password},
+	{"IPv4PG16", tu.IPv4Host, tu.IPv4PG16Port, tu.Username, tu.Password},
+	{"IPv4PG17", tu.IPv4Host, tu.IPv4PG17Port, tu.Username, tu.Password},
 }
 
 var logger = logrus.New()
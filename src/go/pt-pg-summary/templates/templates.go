@@ -19,6 +19,17 @@ var TPL = `{{define "report"}}
 {{ template "counters_header" .Sleep }}
 {{ template "counters" .Counters }}
 {{ template "table_access" .TableAccess }}
+{{ if .Checkpointer16 -}}
+  {{ template "checkpointer" .Checkpointer16 }}
+{{- else if .Checkpointer17 -}}
+  {{ template "checkpointer" .Checkpointer17 }}
+{{- end }}
+{{ if .WalStats -}}
+  {{ template "wal" .WalStats }}
+{{- end }}
+{{ if .IOStats -}}
+  {{ template "io" .IOStats }}
+{{- end }}
 {{ template "settings" .Settings }}
 {{ template "processes" .Processes }}
 {{ end }} {{/* end "report" */}}` +
@@ -280,6 +291,86 @@ Database: {{ $dbname }}
 	"{{ end -}}" +
 	"{{ end }}" +
 
+	/*
+	   Checkpointer, from pg_stat_bgwriter before PostgreSQL 17 and pg_stat_checkpointer since
+	*/
+	`{{ define "checkpointer" -}}` +
+	"##### --- Checkpointer statistics --- ####\n" +
+	` Timed checkpoints    : {{ .NumTimed }}` + "\n" +
+	` Requested checkpoints: {{ .NumRequested }}` + "\n" +
+	` Write time (ms)      : {{ printf "%.2f" .WriteTime }}` + "\n" +
+	` Sync time (ms)       : {{ printf "%.2f" .SyncTime }}` + "\n" +
+	` Buffers written      : {{ .BuffersWritten }}` + "\n" +
+	` Stats reset          : {{ convertnullstring .StatsReset }}` + "\n" +
+	"{{ end }}" +
+
+	/*
+	   WAL, PostgreSQL 14+
+	*/
+	`{{ define "wal" -}}` +
+	"##### --- WAL statistics --- ####\n" +
+	` Records         : {{ .WalRecords }}` + "\n" +
+	` Full page images: {{ .WalFpi }}` + "\n" +
+	` Bytes           : {{ .WalBytes }}` + "\n" +
+	` Buffers full    : {{ .WalBuffersFull }}` + "\n" +
+	` Stats reset     : {{ convertnullstring .StatsReset }}` + "\n" +
+	"{{ end }}" +
+
+	/*
+	   I/O, PostgreSQL 16+
+	*/
+	`{{ define "io" -}}` +
+	"##### --- I/O statistics --- ####\n" +
+	"+----------------------" +
+	"+---------------" +
+	"+-----------" +
+	"+------------" +
+	"+------------" +
+	"+------------" +
+	"+--------------" +
+	"+------------" +
+	"+------------+\n" +
+	"| Backend Type         " +
+	"| Object        " +
+	"| Context   " +
+	"| Reads      " +
+	"| Writes     " +
+	"| Extends    " +
+	"| Hits         " +
+	"| Evictions  " +
+	"| Fsyncs     |\n" +
+	"+----------------------" +
+	"+---------------" +
+	"+-----------" +
+	"+------------" +
+	"+------------" +
+	"+------------" +
+	"+--------------" +
+	"+------------" +
+	"+------------+\n" +
+	`{{ range . -}}` +
+	`| {{ printf "%-20s" .BackendType }} ` +
+	`| {{ printf "%-13s" .Object }} ` +
+	`| {{ printf "%-9s" .Context }} ` +
+	`| {{ printf "% 10d" .Reads }} ` +
+	`| {{ printf "% 10d" .Writes }} ` +
+	`| {{ printf "% 10d" .Extends }} ` +
+	`| {{ printf "% 12d" .Hits }} ` +
+	`| {{ printf "% 10d" .Evictions }} ` +
+	`| {{ printf "% 10d" .Fsyncs }} ` +
+	"|\n" +
+	"{{ end }}" +
+	"+----------------------" +
+	"+---------------" +
+	"+-----------" +
+	"+------------" +
+	"+------------" +
+	"+------------" +
+	"+--------------" +
+	"+------------" +
+	"+------------+\n" +
+	"{{ end }}" +
+
 	`{{ define "settings" -}}` +
 	/*
 	   Settings